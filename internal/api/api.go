@@ -17,6 +17,7 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	httpdocs "github.com/benthosdev/benthos/v4/internal/http/docs"
 	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/secrets"
 )
 
 //------------------------------------------------------------------------------
@@ -127,10 +128,18 @@ func New(
 		w.Write(stackSlice[:s])
 	}
 
+	// Resolved secrets are redacted from the config before it is printed.
+	redactedConf := func() interface{} {
+		if node, ok := wholeConf.(yaml.Node); ok {
+			return *secrets.DefaultRegistry.RedactNode(&node)
+		}
+		return wholeConf
+	}
+
 	handlePrintJSONConfig := func(w http.ResponseWriter, r *http.Request) {
 		var g interface{}
 		var err error
-		if node, ok := wholeConf.(yaml.Node); ok {
+		if node, ok := redactedConf().(yaml.Node); ok {
 			err = node.Decode(&g)
 		} else {
 			g = node
@@ -147,7 +156,7 @@ func New(
 	}
 
 	handlePrintYAMLConfig := func(w http.ResponseWriter, r *http.Request) {
-		resBytes, err := yaml.Marshal(wholeConf)
+		resBytes, err := yaml.Marshal(redactedConf())
 		if err != nil {
 			w.WriteHeader(http.StatusBadGateway)
 			return
@@ -3,13 +3,16 @@ package api
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/secrets"
 )
 
 func TestAPIEnableCORS(t *testing.T) {
@@ -82,3 +85,51 @@ func TestAPIEnableCORSNoHeaders(t *testing.T) {
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "must specify at least one allowed origin")
 }
+
+func TestAPIDebugConfigRedactsSecrets(t *testing.T) {
+	var rawNode, node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(`
+input:
+  kafka:
+    sasl:
+      password: ${secret:file:/etc/kafka_password}
+      user: hunter
+`), &rawNode))
+	require.NoError(t, yaml.Unmarshal([]byte(`
+input:
+  kafka:
+    sasl:
+      password: hunter2
+      user: hunter
+`), &node))
+	secrets.DefaultRegistry.TrackFields("", &rawNode, &node, func(v string) bool {
+		return strings.HasPrefix(v, "${secret:")
+	})
+
+	conf := NewConfig()
+	conf.DebugEndpoints = true
+
+	s, err := New("", "", conf, *node.Content[0], log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	handler := s.server.Handler
+
+	for path, exp := range map[string]string{
+		"/debug/config/yaml": "password: '!!!SECRET_SCRUBBED!!!'",
+		"/debug/config/json": `"password":"!!!SECRET_SCRUBBED!!!"`,
+	} {
+		request, _ := http.NewRequest("GET", path, http.NoBody)
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+
+		assert.Equal(t, http.StatusOK, response.Code, path)
+		assert.Contains(t, response.Body.String(), exp, path)
+		assert.NotContains(t, response.Body.String(), "hunter2", path)
+		assert.Contains(t, response.Body.String(), "hunter", path)
+	}
+
+	// The original config is left untouched.
+	out, err := yaml.Marshal(&node)
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "hunter2")
+}
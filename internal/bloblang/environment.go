@@ -12,6 +12,22 @@ import (
 type Environment struct {
 	pCtx            parser.Context
 	maxMapRecursion int
+	onMatchCase     func(c MatchCase) func()
+}
+
+// MatchCase describes a case of a match expression within a parsed mapping or
+// field expression.
+type MatchCase struct {
+	// The mapping or field expression that contains the case.
+	Source string
+
+	// The pattern of the case, which is `_` for a catch-all case.
+	Pattern string
+
+	// The position of the case within the source, which is zero when the case
+	// originates from an imported file.
+	Line   int
+	Column int
 }
 
 // GlobalEnvironment returns the global default environment. Modifying this
@@ -53,7 +69,7 @@ func NewEmptyEnvironment() *Environment {
 // When a parsing error occurs the returned error will be a *parser.Error type,
 // which allows you to gain positional and structured error messages.
 func (e *Environment) NewField(expr string) (*field.Expression, error) {
-	f, err := parser.ParseField(e.pCtx, expr)
+	f, err := parser.ParseField(e.parserContext(expr), expr)
 	if err != nil {
 		return nil, err
 	}
@@ -67,7 +83,7 @@ func (e *Environment) NewField(expr string) (*field.Expression, error) {
 // gives access to the line and column where the error occurred, as well as a
 // method for creating a well formatted error message.
 func (e *Environment) NewMapping(blobl string) (*mapping.Executor, error) {
-	exec, err := parser.ParseMapping(e.pCtx, blobl)
+	exec, err := parser.ParseMapping(e.parserContext(blobl), blobl)
 	if err != nil {
 		return nil, err
 	}
@@ -77,6 +93,34 @@ func (e *Environment) NewMapping(blobl string) (*mapping.Executor, error) {
 	return exec, nil
 }
 
+// WithMatchCaseHook returns a copy of the environment where each case of a
+// match expression within parsed mappings and field expressions is provided to
+// a function, which returns a closure that is called each time the case
+// matches. This can be used in order to measure which cases are exercised.
+func (e *Environment) WithMatchCaseHook(fn func(c MatchCase) func()) *Environment {
+	env := *e
+	env.onMatchCase = fn
+	return &env
+}
+
+func (e *Environment) parserContext(source string) parser.Context {
+	if e.onMatchCase == nil {
+		return e.pCtx
+	}
+	return e.pCtx.WithMatchCaseWrapper(func(pattern string, line, column int, queryFn query.Function) query.Function {
+		onMatch := e.onMatchCase(MatchCase{
+			Source:  source,
+			Pattern: pattern,
+			Line:    line,
+			Column:  column,
+		})
+		return query.ClosureFunction("match case", func(ctx query.FunctionContext) (interface{}, error) {
+			onMatch()
+			return queryFn.Exec(ctx)
+		}, queryFn.QueryTargets)
+	})
+}
+
 // Deactivated returns a version of the environment where constructors are
 // disabled for all functions and methods, allowing mappings to be parsed and
 // validated but not executed.
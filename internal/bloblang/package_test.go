@@ -224,3 +224,40 @@ func TestMappingParallelExecution(t *testing.T) {
 		})
 	}
 }
+
+func TestMatchCaseHook(t *testing.T) {
+	var cases []MatchCase
+	hits := map[string]int{}
+
+	env := GlobalEnvironment().WithMatchCaseHook(func(c MatchCase) func() {
+		cases = append(cases, c)
+		return func() {
+			hits[c.Pattern]++
+		}
+	})
+
+	mapping := `root = match this.type {
+  "a" => "first"
+  this.has_prefix("b") => "second"
+  _ => "other"
+}`
+	m, err := env.NewMapping(mapping)
+	require.NoError(t, err)
+
+	assert.Equal(t, []MatchCase{
+		{Source: mapping, Pattern: `"a"`, Line: 2, Column: 3},
+		{Source: mapping, Pattern: `this.has_prefix("b")`, Line: 3, Column: 3},
+		{Source: mapping, Pattern: `_`, Line: 4, Column: 3},
+	}, cases)
+
+	for _, v := range []string{"a", "bar", "baz"} {
+		msg := message.QuickBatch([][]byte{[]byte(`{"type":"` + v + `"}`)})
+		_, err := m.MapPart(0, msg)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, map[string]int{
+		`"a"`:                  1,
+		`this.has_prefix("b")`: 2,
+	}, hits)
+}
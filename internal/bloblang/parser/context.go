@@ -12,10 +12,15 @@ import (
 // Context contains context used throughout a Bloblang parser for
 // accessing function and method constructors.
 type Context struct {
-	Functions    *query.FunctionSet
-	Methods      *query.MethodSet
-	namedContext *namedContext
-	importer     Importer
+	Functions        *query.FunctionSet
+	Methods          *query.MethodSet
+	namedContext     *namedContext
+	importer         Importer
+	matchCaseWrapper MatchCaseWrapper
+
+	// The mapping or field expression being parsed, which is used in order to
+	// determine the position of parsed match cases.
+	source []rune
 }
 
 // EmptyContext returns a parser context with no functions, methods or import
@@ -101,6 +106,28 @@ func (pCtx Context) Deactivated() Context {
 	return nextCtx
 }
 
+// MatchCaseWrapper is a function called for each case of a match expression as
+// it is parsed, with the pattern of the case, its line and column within the
+// mapping or field expression being parsed, and the query executed when the
+// case matches. The returned query is used in its place. The line and column
+// are zero when the case is within an imported file.
+type MatchCaseWrapper func(pattern string, line, column int, queryFn query.Function) query.Function
+
+// WithMatchCaseWrapper returns a version of the parser context where the query
+// of each case of a match expression is wrapped by a provided function, this
+// allows the cases of match expressions to be instrumented.
+func (pCtx Context) WithMatchCaseWrapper(fn MatchCaseWrapper) Context {
+	nextCtx := pCtx
+	nextCtx.matchCaseWrapper = fn
+	return nextCtx
+}
+
+func (pCtx Context) withSource(source []rune) Context {
+	nextCtx := pCtx
+	nextCtx.source = source
+	return nextCtx
+}
+
 // CustomImporter returns a version of the parser context where file imports are
 // done exclusively through a provided closure function, which takes an import
 // path (relative or absolute).
@@ -75,7 +75,7 @@ func parseFieldResolvers(pCtx Context, expr string) ([]field.Resolver, *Error) {
 
 // ParseField attempts to parse a field expression.
 func ParseField(pCtx Context, expr string) (*field.Expression, *Error) {
-	resolvers, err := parseFieldResolvers(pCtx, expr)
+	resolvers, err := parseFieldResolvers(pCtx.withSource([]rune(expr)), expr)
 	if err != nil {
 		return nil, err
 	}
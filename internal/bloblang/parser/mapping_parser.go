@@ -18,6 +18,7 @@ import (
 // messages.
 func ParseMapping(pCtx Context, expr string) (*mapping.Executor, *Error) {
 	in := []rune(expr)
+	pCtx = pCtx.withSource(in)
 
 	resDirectImport := singleRootImport(pCtx)(in)
 	if resDirectImport.Err != nil && resDirectImport.Err.IsFatal() {
@@ -120,7 +121,7 @@ func singleRootImport(pCtx Context) Func {
 			return Fail(NewFatalError(input, fmt.Errorf("failed to read import: %w", err)), input)
 		}
 
-		nextCtx := pCtx.WithImporterRelativeToFile(fpath)
+		nextCtx := pCtx.WithImporterRelativeToFile(fpath).withSource(nil)
 
 		importContent := []rune(string(contents))
 		execRes := parseExecutor(nextCtx)(importContent)
@@ -205,7 +206,7 @@ func importParser(maps map[string]query.Function, pCtx Context) Func {
 			return Fail(NewFatalError(input, fmt.Errorf("failed to read import: %w", err)), input)
 		}
 
-		nextCtx := pCtx.WithImporterRelativeToFile(fpath)
+		nextCtx := pCtx.WithImporterRelativeToFile(fpath).withSource(nil)
 
 		importContent := []rune(string(contents))
 		execRes := parseExecutor(nextCtx)(importContent)
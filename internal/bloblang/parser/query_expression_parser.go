@@ -2,6 +2,7 @@ package parser
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/google/go-cmp/cmp"
 
@@ -11,39 +12,44 @@ import (
 func matchCaseParser(pCtx Context) Func {
 	whitespace := SpacesAndTabs()
 
-	p := Sequence(
-		OneOf(
-			Sequence(
-				Expect(
-					Char('_'),
-					"match case",
-				),
-				Optional(whitespace),
-				Term("=>"),
+	patternParser := OneOf(
+		Sequence(
+			Expect(
+				Char('_'),
+				"match case",
 			),
-			Sequence(
-				Expect(
-					queryParser(pCtx),
-					"match case",
-				),
-				Optional(whitespace),
-				Term("=>"),
+			Optional(whitespace),
+			Term("=>"),
+		),
+		Sequence(
+			Expect(
+				queryParser(pCtx),
+				"match case",
 			),
+			Optional(whitespace),
+			Term("=>"),
 		),
+	)
+	p := Sequence(
 		Optional(whitespace),
 		queryParser(pCtx),
 	)
 
 	return func(input []rune) Result {
-		res := p(input)
+		// The pattern is parsed separately in order to capture its source.
+		patternRes := patternParser(input)
+		if patternRes.Err != nil {
+			return Fail(patternRes.Err, input)
+		}
+		res := p(patternRes.Remaining)
 		if res.Err != nil {
-			return res
+			return Fail(res.Err, input)
 		}
 
 		seqSlice := res.Payload.([]interface{})
 
 		var caseFn query.Function
-		switch t := seqSlice[0].([]interface{})[0].(type) {
+		switch t := patternRes.Payload.([]interface{})[0].(type) {
 		case query.Function:
 			if lit, isLiteral := t.(*query.Literal); isLiteral {
 				caseFn = query.ClosureFunction("case statement", func(ctx query.FunctionContext) (interface{}, error) {
@@ -60,8 +66,20 @@ func matchCaseParser(pCtx Context) Func {
 			caseFn = query.NewLiteralFunction("", true)
 		}
 
+		queryFn := seqSlice[1].(query.Function)
+		if pCtx.matchCaseWrapper != nil {
+			pattern := string(input[:len(input)-len(patternRes.Remaining)])
+			pattern = strings.TrimSpace(strings.TrimSuffix(pattern, "=>"))
+
+			var line, column int
+			if pCtx.source != nil {
+				line, column = LineAndColOf(pCtx.source, input)
+			}
+			queryFn = pCtx.matchCaseWrapper(pattern, line, column, queryFn)
+		}
+
 		return Success(
-			query.NewMatchCase(caseFn, seqSlice[2].(query.Function)),
+			query.NewMatchCase(caseFn, queryFn),
 			res.Remaining,
 		)
 	}
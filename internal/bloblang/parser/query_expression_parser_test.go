@@ -283,3 +283,40 @@ func TestExpressionsParser(t *testing.T) {
 		})
 	}
 }
+
+func TestMatchCaseWrapperPositions(t *testing.T) {
+	type matchCase struct {
+		pattern      string
+		line, column int
+	}
+
+	var cases []matchCase
+	pCtx := GlobalContext().CustomImporter(func(name string) ([]byte, error) {
+		return []byte(`map foo {
+  root = match this {
+    "b" => "imported"
+    _ => "other"
+  }
+}`), nil
+	}).WithMatchCaseWrapper(func(pattern string, line, column int, queryFn query.Function) query.Function {
+		cases = append(cases, matchCase{pattern: pattern, line: line, column: column})
+		return queryFn
+	})
+
+	_, err := ParseField(pCtx, `foo ${! match this { "a" => "first", _ => "second" } } bar`)
+	require.Nil(t, err)
+	assert.Equal(t, []matchCase{
+		{pattern: `"a"`, line: 1, column: 22},
+		{pattern: `_`, line: 1, column: 38},
+	}, cases)
+
+	cases = nil
+	_, err = ParseMapping(pCtx, `import "foo.blobl"
+root.a = match this.type {
+  this.has_prefix("b") => "second"
+}`)
+	require.Nil(t, err)
+	assert.Contains(t, cases, matchCase{pattern: `this.has_prefix("b")`, line: 3, column: 3})
+	assert.Contains(t, cases, matchCase{pattern: `"b"`})
+	assert.Contains(t, cases, matchCase{pattern: `_`})
+}
@@ -1062,6 +1062,53 @@ var _ = registerSimpleMethod(
 	},
 )
 
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"format_xml", "",
+	).InCategory(
+		MethodCategoryParsing,
+		`Serializes a target value into an XML byte array, following the same rules as `+"[`parse_xml`](#parse_xml)"+` in reverse:
+
+- Keys prefixed with a hyphen, `+"`-`"+`, are serialized as attributes of the element.
+- The key `+"`#text`"+` is serialized as the value of an element with attributes.
+- Arrays are serialized as repeated elements.
+
+The target value must be an object with a single key which becomes the root element, otherwise the keys of the object are wrapped within a root element `+"`doc`"+`. Elements are serialized in alphabetical order.`,
+		NewExampleSpec("",
+			`root = this.format_xml()`,
+			`{"root":{"title":"This is a title","number":{"#text":"123","-id":"99"}}}`,
+			`<root><number id="99">123</number><title>This is a title</title></root>`,
+		),
+		NewExampleSpec("Provide an argument string in order to pretty print the document with an indentation. Use the `.string()` method in order to coerce the result into a string.",
+			`root.doc = this.format_xml("  ").string()`,
+			`{"root":{"elements":["foo1","foo2"]}}`,
+			`{"doc":"<root>\n  <elements>foo1</elements>\n  <elements>foo2</elements>\n</root>"}`,
+		),
+	).
+		Beta().
+		Param(ParamString(
+			"indent",
+			"Indentation string. When non-empty each element will begin on a new, indented line followed by one or more copies of indent according to the indentation nesting.",
+		).Optional().Default("")),
+	func(args *ParsedParams) (simpleMethod, error) {
+		indentOpt, err := args.FieldOptionalString("indent")
+		if err != nil {
+			return nil, err
+		}
+		indent := ""
+		if indentOpt != nil {
+			indent = *indentOpt
+		}
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			xmlBytes, err := xml.FromMap(v, indent)
+			if err != nil {
+				return nil, fmt.Errorf("failed to format value as XML: %w", err)
+			}
+			return xmlBytes, nil
+		}, nil
+	},
+)
+
 var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"parse_yaml", "",
@@ -60,3 +60,58 @@ func TestParseXML(t *testing.T) {
 		})
 	}
 }
+
+func TestFormatXML(t *testing.T) {
+	testCases := []struct {
+		name   string
+		target interface{}
+		args   []interface{}
+		exp    interface{}
+		errStr string
+	}{
+		{
+			name:   "attributes and text",
+			target: map[string]interface{}{"root": map[string]interface{}{"number": map[string]interface{}{"#text": "123", "-id": "99"}, "title": "This is a title"}},
+			args:   []interface{}{},
+			exp:    []byte(`<root><number id="99">123</number><title>This is a title</title></root>`),
+		},
+		{
+			name:   "repeated elements with indent",
+			target: map[string]interface{}{"root": map[string]interface{}{"elements": []interface{}{"foo1", "foo2"}}},
+			args:   []interface{}{" "},
+			exp:    []byte("<root>\n <elements>foo1</elements>\n <elements>foo2</elements>\n</root>"),
+		},
+		{
+			name:   "not an object",
+			target: "foo",
+			args:   []interface{}{},
+			errStr: "failed to format value as XML",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			targetClone := IClone(test.target)
+			argsClone := IClone(test.args).([]interface{})
+
+			fn, err := InitMethodHelper("format_xml", NewLiteralFunction("", targetClone), argsClone...)
+			require.NoError(t, err)
+
+			res, err := fn.Exec(FunctionContext{
+				Maps:     map[string]Function{},
+				Index:    0,
+				MsgBatch: nil,
+			})
+			if test.errStr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.errStr)
+				return
+			}
+			require.NoError(t, err)
+
+			assert.Equal(t, test.exp, res)
+			assert.Equal(t, test.target, targetClone)
+		})
+	}
+}
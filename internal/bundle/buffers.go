@@ -3,6 +3,7 @@ package bundle
 import (
 	"fmt"
 	"sort"
+	"sync"
 
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/buffer"
@@ -45,6 +46,7 @@ type bufferSpec struct {
 // BufferSet contains an explicit set of buffers available to a Benthos service.
 type BufferSet struct {
 	specs map[string]bufferSpec
+	mut   sync.RWMutex
 }
 
 // Add a new buffer to this set by providing a spec (name, documentation, and
@@ -53,6 +55,7 @@ func (s *BufferSet) Add(constructor BufferConstructor, spec docs.ComponentSpec)
 	if !nameRegexp.MatchString(spec.Name) {
 		return fmt.Errorf("component name '%v' does not match the required regular expression /%v/", spec.Name, nameRegexpRaw)
 	}
+	s.mut.Lock()
 	if s.specs == nil {
 		s.specs = map[string]bufferSpec{}
 	}
@@ -61,13 +64,16 @@ func (s *BufferSet) Add(constructor BufferConstructor, spec docs.ComponentSpec)
 		constructor: constructor,
 		spec:        spec,
 	}
+	s.mut.Unlock()
 	docs.RegisterDocs(spec)
 	return nil
 }
 
 // Init attempts to initialise an buffer from a config.
 func (s *BufferSet) Init(conf buffer.Config, mgr NewManagement) (buffer.Streamed, error) {
+	s.mut.RLock()
 	spec, exists := s.specs[conf.Type]
+	s.mut.RUnlock()
 	if !exists {
 		return nil, component.ErrInvalidType("buffer", conf.Type)
 	}
@@ -79,9 +85,11 @@ func (s *BufferSet) Init(conf buffer.Config, mgr NewManagement) (buffer.Streamed
 // Docs returns a slice of buffer specs, which document each method.
 func (s *BufferSet) Docs() []docs.ComponentSpec {
 	var docs []docs.ComponentSpec
+	s.mut.RLock()
 	for _, v := range s.specs {
 		docs = append(docs, v.spec)
 	}
+	s.mut.RUnlock()
 	sort.Slice(docs, func(i, j int) bool {
 		return docs[i].Name < docs[j].Name
 	})
@@ -91,7 +99,9 @@ func (s *BufferSet) Docs() []docs.ComponentSpec {
 // DocsFor returns the documentation for a given component name, returns a
 // boolean indicating whether the component name exists.
 func (s *BufferSet) DocsFor(name string) (docs.ComponentSpec, bool) {
+	s.mut.RLock()
 	c, ok := s.specs[name]
+	s.mut.RUnlock()
 	if !ok {
 		return docs.ComponentSpec{}, false
 	}
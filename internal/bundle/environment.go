@@ -32,24 +32,36 @@ func NewEnvironment() *Environment {
 // independently.
 func (e *Environment) Clone() *Environment {
 	newEnv := NewEnvironment()
+	e.buffers.mut.RLock()
 	for _, v := range e.buffers.specs {
 		_ = newEnv.buffers.Add(v.constructor, v.spec)
 	}
+	e.buffers.mut.RUnlock()
+	e.caches.mut.RLock()
 	for _, v := range e.caches.specs {
 		_ = newEnv.caches.Add(v.constructor, v.spec)
 	}
+	e.caches.mut.RUnlock()
+	e.inputs.mut.RLock()
 	for _, v := range e.inputs.specs {
 		_ = newEnv.inputs.Add(v.constructor, v.spec)
 	}
+	e.inputs.mut.RUnlock()
+	e.outputs.mut.RLock()
 	for _, v := range e.outputs.specs {
 		_ = newEnv.outputs.Add(v.constructor, v.spec)
 	}
+	e.outputs.mut.RUnlock()
+	e.processors.mut.RLock()
 	for _, v := range e.processors.specs {
 		_ = newEnv.processors.Add(v.constructor, v.spec)
 	}
+	e.processors.mut.RUnlock()
+	e.rateLimits.mut.RLock()
 	for _, v := range e.rateLimits.specs {
 		_ = newEnv.rateLimits.Add(v.constructor, v.spec)
 	}
+	e.rateLimits.mut.RUnlock()
 	return newEnv
 }
 
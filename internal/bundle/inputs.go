@@ -3,6 +3,7 @@ package bundle
 import (
 	"fmt"
 	"sort"
+	"sync"
 
 	"github.com/benthosdev/benthos/v4/internal/component"
 	iinput "github.com/benthosdev/benthos/v4/internal/component/input"
@@ -66,6 +67,7 @@ type inputSpec struct {
 // InputSet contains an explicit set of inputs available to a Benthos service.
 type InputSet struct {
 	specs map[string]inputSpec
+	mut   sync.RWMutex
 }
 
 // Add a new input to this set by providing a constructor and documentation.
@@ -73,6 +75,7 @@ func (s *InputSet) Add(constructor InputConstructor, spec docs.ComponentSpec) er
 	if !nameRegexp.MatchString(spec.Name) {
 		return fmt.Errorf("component name '%v' does not match the required regular expression /%v/", spec.Name, nameRegexpRaw)
 	}
+	s.mut.Lock()
 	if s.specs == nil {
 		s.specs = map[string]inputSpec{}
 	}
@@ -81,13 +84,16 @@ func (s *InputSet) Add(constructor InputConstructor, spec docs.ComponentSpec) er
 		constructor: constructor,
 		spec:        spec,
 	}
+	s.mut.Unlock()
 	docs.RegisterDocs(spec)
 	return nil
 }
 
 // Init attempts to initialise an input from a config.
 func (s *InputSet) Init(conf input.Config, mgr NewManagement, pipelines ...iprocessor.PipelineConstructorFunc) (iinput.Streamed, error) {
+	s.mut.RLock()
 	spec, exists := s.specs[conf.Type]
+	s.mut.RUnlock()
 	if !exists {
 		return nil, component.ErrInvalidType("input", conf.Type)
 	}
@@ -99,9 +105,11 @@ func (s *InputSet) Init(conf input.Config, mgr NewManagement, pipelines ...iproc
 // Docs returns a slice of input specs, which document each method.
 func (s *InputSet) Docs() []docs.ComponentSpec {
 	var docs []docs.ComponentSpec
+	s.mut.RLock()
 	for _, v := range s.specs {
 		docs = append(docs, v.spec)
 	}
+	s.mut.RUnlock()
 	sort.Slice(docs, func(i, j int) bool {
 		return docs[i].Name < docs[j].Name
 	})
@@ -111,7 +119,9 @@ func (s *InputSet) Docs() []docs.ComponentSpec {
 // DocsFor returns the documentation for a given component name, returns a
 // boolean indicating whether the component name exists.
 func (s *InputSet) DocsFor(name string) (docs.ComponentSpec, bool) {
+	s.mut.RLock()
 	c, ok := s.specs[name]
+	s.mut.RUnlock()
 	if !ok {
 		return docs.ComponentSpec{}, false
 	}
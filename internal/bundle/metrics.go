@@ -3,6 +3,7 @@ package bundle
 import (
 	"fmt"
 	"sort"
+	"sync"
 
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
@@ -29,6 +30,7 @@ type metricsSpec struct {
 // service.
 type MetricsSet struct {
 	specs map[string]metricsSpec
+	mut   sync.RWMutex
 }
 
 // Add a new metrics to this set by providing a spec (name, documentation, and
@@ -37,6 +39,7 @@ func (s *MetricsSet) Add(constructor MetricConstructor, spec docs.ComponentSpec)
 	if !nameRegexp.MatchString(spec.Name) {
 		return fmt.Errorf("component name '%v' does not match the required regular expression /%v/", spec.Name, nameRegexpRaw)
 	}
+	s.mut.Lock()
 	if s.specs == nil {
 		s.specs = map[string]metricsSpec{}
 	}
@@ -45,13 +48,16 @@ func (s *MetricsSet) Add(constructor MetricConstructor, spec docs.ComponentSpec)
 		constructor: constructor,
 		spec:        spec,
 	}
+	s.mut.Unlock()
 	docs.RegisterDocs(spec)
 	return nil
 }
 
 // Init attempts to initialise an metrics from a config.
 func (s *MetricsSet) Init(conf metrics.Config, log log.Modular) (*metrics.Namespaced, error) {
+	s.mut.RLock()
 	spec, exists := s.specs[conf.Type]
+	s.mut.RUnlock()
 	if !exists {
 		return nil, component.ErrInvalidType("metric", conf.Type)
 	}
@@ -75,9 +81,11 @@ func (s *MetricsSet) Init(conf metrics.Config, log log.Modular) (*metrics.Namesp
 // Docs returns a slice of metrics specs, which document each method.
 func (s *MetricsSet) Docs() []docs.ComponentSpec {
 	var docs []docs.ComponentSpec
+	s.mut.RLock()
 	for _, v := range s.specs {
 		docs = append(docs, v.spec)
 	}
+	s.mut.RUnlock()
 	sort.Slice(docs, func(i, j int) bool {
 		return docs[i].Name < docs[j].Name
 	})
@@ -87,7 +95,9 @@ func (s *MetricsSet) Docs() []docs.ComponentSpec {
 // DocsFor returns the documentation for a given component name, returns a
 // boolean indicating whether the component name exists.
 func (s *MetricsSet) DocsFor(name string) (docs.ComponentSpec, bool) {
+	s.mut.RLock()
 	c, ok := s.specs[name]
+	s.mut.RUnlock()
 	if !ok {
 		return docs.ComponentSpec{}, false
 	}
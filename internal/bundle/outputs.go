@@ -3,6 +3,7 @@ package bundle
 import (
 	"fmt"
 	"sort"
+	"sync"
 
 	"github.com/benthosdev/benthos/v4/internal/component"
 	ioutput "github.com/benthosdev/benthos/v4/internal/component/output"
@@ -66,6 +67,7 @@ type outputSpec struct {
 // OutputSet contains an explicit set of outputs available to a Benthos service.
 type OutputSet struct {
 	specs map[string]outputSpec
+	mut   sync.RWMutex
 }
 
 // Add a new output to this set by providing a spec (name, documentation, and
@@ -74,6 +76,7 @@ func (s *OutputSet) Add(constructor OutputConstructor, spec docs.ComponentSpec)
 	if !nameRegexp.MatchString(spec.Name) {
 		return fmt.Errorf("component name '%v' does not match the required regular expression /%v/", spec.Name, nameRegexpRaw)
 	}
+	s.mut.Lock()
 	if s.specs == nil {
 		s.specs = map[string]outputSpec{}
 	}
@@ -82,6 +85,7 @@ func (s *OutputSet) Add(constructor OutputConstructor, spec docs.ComponentSpec)
 		constructor: constructor,
 		spec:        spec,
 	}
+	s.mut.Unlock()
 	docs.RegisterDocs(spec)
 	return nil
 }
@@ -92,7 +96,9 @@ func (s *OutputSet) Init(
 	mgr NewManagement,
 	pipelines ...iprocessor.PipelineConstructorFunc,
 ) (ioutput.Streamed, error) {
+	s.mut.RLock()
 	spec, exists := s.specs[conf.Type]
+	s.mut.RUnlock()
 	if !exists {
 		return nil, component.ErrInvalidType("output", conf.Type)
 	}
@@ -104,9 +110,11 @@ func (s *OutputSet) Init(
 // Docs returns a slice of output specs, which document each method.
 func (s *OutputSet) Docs() []docs.ComponentSpec {
 	var docs []docs.ComponentSpec
+	s.mut.RLock()
 	for _, v := range s.specs {
 		docs = append(docs, v.spec)
 	}
+	s.mut.RUnlock()
 	sort.Slice(docs, func(i, j int) bool {
 		return docs[i].Name < docs[j].Name
 	})
@@ -116,7 +124,9 @@ func (s *OutputSet) Docs() []docs.ComponentSpec {
 // DocsFor returns the documentation for a given component name, returns a
 // boolean indicating whether the component name exists.
 func (s *OutputSet) DocsFor(name string) (docs.ComponentSpec, bool) {
+	s.mut.RLock()
 	c, ok := s.specs[name]
+	s.mut.RUnlock()
 	if !ok {
 		return docs.ComponentSpec{}, false
 	}
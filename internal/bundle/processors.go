@@ -3,6 +3,7 @@ package bundle
 import (
 	"fmt"
 	"sort"
+	"sync"
 
 	"github.com/benthosdev/benthos/v4/internal/component"
 	iprocessor "github.com/benthosdev/benthos/v4/internal/component/processor"
@@ -48,6 +49,7 @@ type processorSpec struct {
 // service.
 type ProcessorSet struct {
 	specs map[string]processorSpec
+	mut   sync.RWMutex
 }
 
 // Add a new processor to this set by providing a spec (name, documentation, and
@@ -56,6 +58,7 @@ func (s *ProcessorSet) Add(constructor ProcessorConstructor, spec docs.Component
 	if !nameRegexp.MatchString(spec.Name) {
 		return fmt.Errorf("component name '%v' does not match the required regular expression /%v/", spec.Name, nameRegexpRaw)
 	}
+	s.mut.Lock()
 	if s.specs == nil {
 		s.specs = map[string]processorSpec{}
 	}
@@ -64,13 +67,16 @@ func (s *ProcessorSet) Add(constructor ProcessorConstructor, spec docs.Component
 		constructor: constructor,
 		spec:        spec,
 	}
+	s.mut.Unlock()
 	docs.RegisterDocs(spec)
 	return nil
 }
 
 // Init attempts to initialise an processor from a config.
 func (s *ProcessorSet) Init(conf processor.Config, mgr NewManagement) (iprocessor.V1, error) {
+	s.mut.RLock()
 	spec, exists := s.specs[conf.Type]
+	s.mut.RUnlock()
 	if !exists {
 		return nil, component.ErrInvalidType("processor", conf.Type)
 	}
@@ -82,9 +88,11 @@ func (s *ProcessorSet) Init(conf processor.Config, mgr NewManagement) (iprocesso
 // Docs returns a slice of processor specs, which document each method.
 func (s *ProcessorSet) Docs() []docs.ComponentSpec {
 	var docs []docs.ComponentSpec
+	s.mut.RLock()
 	for _, v := range s.specs {
 		docs = append(docs, v.spec)
 	}
+	s.mut.RUnlock()
 	sort.Slice(docs, func(i, j int) bool {
 		return docs[i].Name < docs[j].Name
 	})
@@ -94,7 +102,9 @@ func (s *ProcessorSet) Docs() []docs.ComponentSpec {
 // DocsFor returns the documentation for a given component name, returns a
 // boolean indicating whether the component name exists.
 func (s *ProcessorSet) DocsFor(name string) (docs.ComponentSpec, bool) {
+	s.mut.RLock()
 	c, ok := s.specs[name]
+	s.mut.RUnlock()
 	if !ok {
 		return docs.ComponentSpec{}, false
 	}
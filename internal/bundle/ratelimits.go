@@ -3,6 +3,7 @@ package bundle
 import (
 	"fmt"
 	"sort"
+	"sync"
 
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/ratelimit"
@@ -45,6 +46,7 @@ type rateLimitSpec struct {
 // RateLimitSet contains an explicit set of ratelimits available to a Benthos service.
 type RateLimitSet struct {
 	specs map[string]rateLimitSpec
+	mut   sync.RWMutex
 }
 
 // Add a new ratelimit to this set by providing a spec (name, documentation, and
@@ -53,6 +55,7 @@ func (s *RateLimitSet) Add(constructor RateLimitConstructor, spec docs.Component
 	if !nameRegexp.MatchString(spec.Name) {
 		return fmt.Errorf("component name '%v' does not match the required regular expression /%v/", spec.Name, nameRegexpRaw)
 	}
+	s.mut.Lock()
 	if s.specs == nil {
 		s.specs = map[string]rateLimitSpec{}
 	}
@@ -61,13 +64,16 @@ func (s *RateLimitSet) Add(constructor RateLimitConstructor, spec docs.Component
 		constructor: constructor,
 		spec:        spec,
 	}
+	s.mut.Unlock()
 	docs.RegisterDocs(spec)
 	return nil
 }
 
 // Init attempts to initialise an ratelimit from a config.
 func (s *RateLimitSet) Init(conf ratelimit.Config, mgr NewManagement) (ratelimit.V1, error) {
+	s.mut.RLock()
 	spec, exists := s.specs[conf.Type]
+	s.mut.RUnlock()
 	if !exists {
 		return nil, component.ErrInvalidType("rate_limit", conf.Type)
 	}
@@ -79,9 +85,11 @@ func (s *RateLimitSet) Init(conf ratelimit.Config, mgr NewManagement) (ratelimit
 // Docs returns a slice of ratelimit specs, which document each method.
 func (s *RateLimitSet) Docs() []docs.ComponentSpec {
 	var docs []docs.ComponentSpec
+	s.mut.RLock()
 	for _, v := range s.specs {
 		docs = append(docs, v.spec)
 	}
+	s.mut.RUnlock()
 	sort.Slice(docs, func(i, j int) bool {
 		return docs[i].Name < docs[j].Name
 	})
@@ -91,7 +99,9 @@ func (s *RateLimitSet) Docs() []docs.ComponentSpec {
 // DocsFor returns the documentation for a given component name, returns a
 // boolean indicating whether the component name exists.
 func (s *RateLimitSet) DocsFor(name string) (docs.ComponentSpec, bool) {
+	s.mut.RLock()
 	c, ok := s.specs[name]
+	s.mut.RUnlock()
 	if !ok {
 		return docs.ComponentSpec{}, false
 	}
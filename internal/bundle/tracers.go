@@ -3,6 +3,7 @@ package bundle
 import (
 	"fmt"
 	"sort"
+	"sync"
 
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/tracer"
@@ -27,6 +28,7 @@ type tracerSpec struct {
 // TracerSet contains an explicit set of tracers available to a Benthos service.
 type TracerSet struct {
 	specs map[string]tracerSpec
+	mut   sync.RWMutex
 }
 
 // Add a new tracer to this set by providing a spec (name, documentation, and
@@ -35,6 +37,7 @@ func (s *TracerSet) Add(constructor TracerConstructor, spec docs.ComponentSpec)
 	if !nameRegexp.MatchString(spec.Name) {
 		return fmt.Errorf("component name '%v' does not match the required regular expression /%v/", spec.Name, nameRegexpRaw)
 	}
+	s.mut.Lock()
 	if s.specs == nil {
 		s.specs = map[string]tracerSpec{}
 	}
@@ -43,13 +46,16 @@ func (s *TracerSet) Add(constructor TracerConstructor, spec docs.ComponentSpec)
 		constructor: constructor,
 		spec:        spec,
 	}
+	s.mut.Unlock()
 	docs.RegisterDocs(spec)
 	return nil
 }
 
 // Init attempts to initialise an tracer from a config.
 func (s *TracerSet) Init(conf tracer.Config) (tracer.Type, error) {
+	s.mut.RLock()
 	spec, exists := s.specs[conf.Type]
+	s.mut.RUnlock()
 	if !exists {
 		return nil, component.ErrInvalidType("tracer", conf.Type)
 	}
@@ -59,9 +65,11 @@ func (s *TracerSet) Init(conf tracer.Config) (tracer.Type, error) {
 // Docs returns a slice of tracer specs, which document each method.
 func (s *TracerSet) Docs() []docs.ComponentSpec {
 	var docs []docs.ComponentSpec
+	s.mut.RLock()
 	for _, v := range s.specs {
 		docs = append(docs, v.spec)
 	}
+	s.mut.RUnlock()
 	sort.Slice(docs, func(i, j int) bool {
 		return docs[i].Name < docs[j].Name
 	})
@@ -71,7 +79,9 @@ func (s *TracerSet) Docs() []docs.ComponentSpec {
 // DocsFor returns the documentation for a given component name, returns a
 // boolean indicating whether the component name exists.
 func (s *TracerSet) DocsFor(name string) (docs.ComponentSpec, bool) {
+	s.mut.RLock()
 	c, ok := s.specs[name]
+	s.mut.RUnlock()
 	if !ok {
 		return docs.ComponentSpec{}, false
 	}
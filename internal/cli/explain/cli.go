@@ -0,0 +1,131 @@
+// Package explain provides a CLI subcommand for resolving the component graph
+// of a config, including the components of templates and resources.
+package explain
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/config"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+// ReadGraph reads a config along with resource files and resolves its
+// component graph.
+func ReadGraph(path string, resourcePaths, overrides []string) (*Graph, error) {
+	conf := config.New()
+	lints, err := config.NewReader(path, resourcePaths,
+		config.OptAddOverrides(overrides...),
+		config.OptSetSecretsMode(config.SecretsPlaceholder),
+	).Read(&conf)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range lints {
+		fmt.Fprintf(os.Stderr, "%v: %v\n", path, l)
+	}
+
+	var node yaml.Node
+	if err := node.Encode(conf); err != nil {
+		return nil, err
+	}
+	if err := config.Spec().SanitiseYAML(&node, docs.SanitiseConfig{}); err != nil {
+		return nil, err
+	}
+	return FromYAML(nil, &node)
+}
+
+func writeGraph(w io.Writer, g *Graph, format string) error {
+	switch format {
+	case "text":
+		return g.WriteText(w)
+	case "dot":
+		return g.WriteDOT(w)
+	case "mermaid":
+		return g.WriteMermaid(w)
+	}
+	return fmt.Errorf("unrecognised format: %v", format)
+}
+
+// CliCommand is a cli.Command definition for explaining configs.
+func CliCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "explain",
+		Usage: "Print the resolved component graph of a config",
+		Description: `
+Reads a config along with any resource files and templates, and prints each
+component of the resulting pipeline along with its label, the path that it
+emits metrics and logs with, and any resources that it references. Template
+components are expanded into the components that they resolve to.
+
+  benthos -c ./config.yaml explain
+  benthos -r "./resources/*.yaml" explain ./config.yaml
+  benthos -c ./config.yaml explain --format dot | dot -Tsvg > graph.svg
+  benthos -c ./config.yaml explain --format mermaid
+
+With the --diff flag the config is compared against a second config, read with
+the same resources and templates, and the components that were added, removed
+or changed are printed instead. Components are matched by their path within
+the config, and resources by their label. Exits with a status code 1 if any
+differences are found:
+
+  benthos -c ./old.yaml explain --diff ./new.yaml`[1:],
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "format",
+				Value: "text",
+				Usage: "The format to print the graph in, options are: text, dot, mermaid.",
+			},
+			&cli.StringFlag{
+				Name:  "diff",
+				Value: "",
+				Usage: "A path to a second config to compare against, printing the components that differ.",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			path := c.String("config")
+			if c.Args().Len() > 0 {
+				path = c.Args().First()
+			}
+			if path == "" {
+				fmt.Fprintln(os.Stderr, "A config must be specified either with --config or as an argument")
+				os.Exit(1)
+			}
+
+			resources, overrides := c.StringSlice("resources"), c.StringSlice("set")
+
+			g, err := ReadGraph(path, resources, overrides)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to explain config %v: %v\n", path, err)
+				os.Exit(1)
+			}
+
+			if diffPath := c.String("diff"); diffPath != "" {
+				other, err := ReadGraph(diffPath, resources, overrides)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Failed to explain config %v: %v\n", diffPath, err)
+					os.Exit(1)
+				}
+				changes := Diff(g, other)
+				if err := WriteDiff(os.Stdout, changes); err != nil {
+					fmt.Fprintf(os.Stderr, "Failed to write diff: %v\n", err)
+					os.Exit(1)
+				}
+				if len(changes) > 0 {
+					os.Exit(1)
+				}
+				return nil
+			}
+
+			if err := writeGraph(os.Stdout, g, c.String("format")); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to write graph: %v\n", err)
+				os.Exit(1)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,93 @@
+package explain
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Change is a difference of a component between two graphs, where Before is
+// nil for added components and After is nil for removed components.
+type Change struct {
+	ID     string
+	Before *Component
+	After  *Component
+}
+
+// Details returns descriptions of the differences between a component that
+// exists within both graphs.
+func (c Change) Details() []string {
+	if c.Before == nil || c.After == nil {
+		return nil
+	}
+
+	var details []string
+	if c.Before.Name != c.After.Name {
+		details = append(details, fmt.Sprintf("type %v -> %v", c.Before.Name, c.After.Name))
+	}
+	if c.Before.Label != c.After.Label {
+		details = append(details, fmt.Sprintf("label '%v' -> '%v'", c.Before.Label, c.After.Label))
+	}
+	if c.Before.MetricPath != c.After.MetricPath {
+		details = append(details, fmt.Sprintf("path %v -> %v", c.Before.MetricPath, c.After.MetricPath))
+	}
+	if before, after := refsString(c.Before), refsString(c.After); before != after {
+		details = append(details, fmt.Sprintf("references [%v] -> [%v]", before, after))
+	}
+	if len(details) == 0 && c.Before.fingerprint != c.After.fingerprint {
+		details = append(details, "config changed")
+	}
+	return details
+}
+
+func refsString(c *Component) string {
+	refs := make([]string, 0, len(c.References))
+	for _, r := range c.References {
+		refs = append(refs, r.ID())
+	}
+	return strings.Join(refs, " ")
+}
+
+// Diff returns the components that were removed or changed between two graphs
+// in the order of the original graph, followed by the components that were
+// added in the order of the new graph. Components are matched by their ID.
+func Diff(before, after *Graph) []Change {
+	var changes []Change
+	before.Walk(func(_ int, b *Component) {
+		a, exists := after.Lookup(b.ID())
+		if !exists {
+			changes = append(changes, Change{ID: b.ID(), Before: b})
+			return
+		}
+		if c := (Change{ID: b.ID(), Before: b, After: a}); len(c.Details()) > 0 {
+			changes = append(changes, c)
+		}
+	})
+	after.Walk(func(_ int, a *Component) {
+		if _, exists := before.Lookup(a.ID()); !exists {
+			changes = append(changes, Change{ID: a.ID(), After: a})
+		}
+	})
+	return changes
+}
+
+// WriteDiff writes a list of changes with a line per component, prefixed with
+// - for removed components, + for added components and ~ for changed
+// components.
+func WriteDiff(w io.Writer, changes []Change) error {
+	for _, c := range changes {
+		var line string
+		switch {
+		case c.After == nil:
+			line = fmt.Sprintf("- %v: %v path=%v", c.ID, c.Before.Name, c.Before.MetricPath)
+		case c.Before == nil:
+			line = fmt.Sprintf("+ %v: %v path=%v", c.ID, c.After.Name, c.After.MetricPath)
+		default:
+			line = fmt.Sprintf("~ %v: %v", c.ID, strings.Join(c.Details(), ", "))
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
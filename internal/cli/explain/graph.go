@@ -0,0 +1,464 @@
+package explain
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/internal/config"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/template"
+)
+
+// Reference is a field of a component that references a resource.
+type Reference struct {
+	Field string
+	Type  docs.Type
+	Label string
+}
+
+// ID returns the ID of the resource component being referenced.
+func (r Reference) ID() string {
+	return query.SliceToDotPath(string(r.Type)+"_resources", r.Label)
+}
+
+// Component is a resolved component within a config.
+type Component struct {
+	Type  docs.Type
+	Name  string
+	Label string
+
+	// The path of the component within the config, where resources are
+	// identified by their label rather than their index.
+	Path []string
+
+	// The path that the component emits metrics and logs with.
+	MetricPath string
+
+	// Whether the component is a template, in which case its expanded
+	// component is the last of its children.
+	Template bool
+
+	References []Reference
+	Children   []*Component
+
+	// The config of the component with the configs of children removed, used
+	// in order to detect changes.
+	fingerprint string
+}
+
+// ID returns a string that uniquely identifies the component within a config.
+func (c *Component) ID() string {
+	return query.SliceToDotPath(c.Path...)
+}
+
+// Graph is the resolved component tree of a config.
+type Graph struct {
+	// The components of the stream in the order that data flows through them.
+	Stream []*Component
+
+	// Resource components in the order that they were defined.
+	Resources []*Component
+}
+
+// Walk calls a closure for each component of the graph, depth first, along
+// with the depth of the component.
+func (g *Graph) Walk(fn func(depth int, c *Component)) {
+	var walk func(depth int, c *Component)
+	walk = func(depth int, c *Component) {
+		fn(depth, c)
+		for _, child := range c.Children {
+			walk(depth+1, child)
+		}
+	}
+	for _, c := range g.Stream {
+		walk(0, c)
+	}
+	for _, c := range g.Resources {
+		walk(0, c)
+	}
+}
+
+// Lookup returns a component by its ID.
+func (g *Graph) Lookup(id string) (*Component, bool) {
+	var found *Component
+	g.Walk(func(_ int, c *Component) {
+		if found == nil && c.ID() == id {
+			found = c
+		}
+	})
+	return found, found != nil
+}
+
+//------------------------------------------------------------------------------
+
+// metricPathElided lists the segments of config paths, keyed by component type
+// and name, that are not included within the metric paths of children.
+var metricPathElided = map[docs.Type]map[string]map[string]struct{}{
+	docs.TypeOutput: {
+		"switch": {"cases": {}},
+	},
+}
+
+var resourceTypes = []struct {
+	field string
+	cType docs.Type
+}{
+	{"input_resources", docs.TypeInput},
+	{"processor_resources", docs.TypeProcessor},
+	{"output_resources", docs.TypeOutput},
+	{"cache_resources", docs.TypeCache},
+	{"rate_limit_resources", docs.TypeRateLimit},
+}
+
+type walkPath struct {
+	conf   []string
+	metric []string
+	field  []string
+}
+
+func (p walkPath) with(conf, field string, elide bool) walkPath {
+	n := walkPath{
+		conf:   append(append([]string{}, p.conf...), conf),
+		metric: p.metric,
+		field:  p.field,
+	}
+	if !elide {
+		n.metric = append(append([]string{}, p.metric...), conf)
+	}
+	if field != "" {
+		n.field = append(append([]string{}, p.field...), field)
+	}
+	return n
+}
+
+type builder struct {
+	prov docs.Provider
+}
+
+// FromYAML resolves the component graph of a config, where templates are
+// expanded and resource references are identified.
+func FromYAML(prov docs.Provider, node *yaml.Node) (*Graph, error) {
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	if node.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("expected object, got %v", node.Kind)
+	}
+
+	fields := map[string]*yaml.Node{}
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		fields[node.Content[i].Value] = node.Content[i+1]
+	}
+
+	b := builder{prov: prov}
+	g := &Graph{}
+
+	addStream := func(cType docs.Type, n *yaml.Node, path ...string) error {
+		p := walkPath{conf: path, metric: path}
+		c, err := b.component(cType, n, p)
+		if err != nil {
+			return fmt.Errorf("%v: %w", query.SliceToDotPath(path...), err)
+		}
+		if c != nil {
+			g.Stream = append(g.Stream, c)
+		}
+		return nil
+	}
+
+	if n, exists := fields["input"]; exists {
+		if err := addStream(docs.TypeInput, n, "input"); err != nil {
+			return nil, err
+		}
+	}
+	if n, exists := fields["buffer"]; exists {
+		if err := addStream(docs.TypeBuffer, n, "buffer"); err != nil {
+			return nil, err
+		}
+	}
+	if n, exists := fields["pipeline"]; exists && n.Kind == yaml.MappingNode {
+		for i := 0; i < len(n.Content)-1; i += 2 {
+			if n.Content[i].Value != "processors" || n.Content[i+1].Kind != yaml.SequenceNode {
+				continue
+			}
+			for j, pNode := range n.Content[i+1].Content {
+				if err := addStream(docs.TypeProcessor, pNode, "pipeline", "processors", strconv.Itoa(j)); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	if n, exists := fields["output"]; exists {
+		if err := addStream(docs.TypeOutput, n, "output"); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, rt := range resourceTypes {
+		n, exists := fields[rt.field]
+		if !exists || n.Kind != yaml.SequenceNode {
+			continue
+		}
+		for i, rNode := range n.Content {
+			c, err := b.component(rt.cType, rNode, walkPath{
+				conf:   []string{rt.field, strconv.Itoa(i)},
+				metric: []string{rt.field},
+			})
+			if err != nil {
+				return nil, fmt.Errorf("%v.%v: %w", rt.field, i, err)
+			}
+			if c == nil {
+				continue
+			}
+			// Resources are identified by their label, which is the only
+			// means by which they're referenced.
+			if c.Label != "" {
+				relabel(c, len(c.Path)-1, c.Label)
+			}
+			g.Resources = append(g.Resources, c)
+		}
+	}
+	return g, nil
+}
+
+// relabel replaces a segment of the path of a component and its children.
+func relabel(c *Component, index int, segment string) {
+	c.Path[index] = segment
+	for _, child := range c.Children {
+		relabel(child, index, segment)
+	}
+}
+
+func (b builder) component(cType docs.Type, node *yaml.Node, p walkPath) (*Component, error) {
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	if node.Kind != yaml.MappingNode || len(node.Content) == 0 {
+		return nil, nil
+	}
+
+	name, spec, err := docs.GetInferenceCandidateFromYAML(b.prov, cType, node)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Component{
+		Type:       cType,
+		Name:       name,
+		Path:       p.conf,
+		MetricPath: "root." + query.SliceToDotPath(p.metric...),
+	}
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		if node.Content[i].Value == "label" {
+			c.Label = node.Content[i+1].Value
+		}
+	}
+
+	confNode, err := docs.GetPluginConfigYAML(name, node)
+	if err != nil {
+		return nil, err
+	}
+
+	childNodes := map[*yaml.Node]struct{}{}
+	if confNode.Kind != 0 {
+		cp := walkPath{
+			conf:   append(append([]string{}, p.conf...), name),
+			metric: append(append([]string{}, p.metric...), name),
+		}
+		if err := b.walkField(c, childNodes, spec.Config, &confNode, cp); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		if node.Content[i].Value != "processors" || (cType != docs.TypeInput && cType != docs.TypeOutput) {
+			continue
+		}
+		if err := b.walkField(c, childNodes, docs.FieldProcessor("processors", "").Array(), node.Content[i+1], p.with("processors", "processors", false)); err != nil {
+			return nil, err
+		}
+	}
+
+	if spec.Plugin && confNode.Kind != 0 {
+		expanded, isTemplate, err := template.ExpandNode(cType, name, &confNode)
+		if err != nil {
+			return nil, err
+		}
+		if isTemplate {
+			c.Template = true
+			// Template components share the metric path of the template.
+			child, err := b.component(cType, expanded, walkPath{
+				conf:   append(append([]string{}, p.conf...), name),
+				metric: p.metric,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("template %v: %w", name, err)
+			}
+			if child != nil {
+				// The processors of a template component are executed after
+				// those of the expanded input, and before those of the
+				// expanded output, and are indexed accordingly.
+				switch cType {
+				case docs.TypeInput:
+					offsetProcessors(c, c.MetricPath, countProcessors(child, child.MetricPath))
+				case docs.TypeOutput:
+					offsetProcessors(child, child.MetricPath, countProcessors(c, c.MetricPath))
+				}
+				c.Children = append(c.Children, child)
+			}
+		}
+	}
+
+	fingerprint, err := yaml.Marshal(withoutNodes(node, childNodes))
+	if err != nil {
+		return nil, err
+	}
+	c.fingerprint = string(fingerprint)
+	return c, nil
+}
+
+func (b builder) walkField(c *Component, childNodes map[*yaml.Node]struct{}, f docs.FieldSpec, node *yaml.Node, p walkPath) error {
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+
+	elided := func(segment string) bool {
+		_, e := metricPathElided[c.Type][c.Name][segment]
+		return e
+	}
+
+	switch f.Kind {
+	case docs.Kind2DArray, docs.KindArray:
+		if node.Kind != yaml.SequenceNode {
+			return nil
+		}
+		elem := f.Scalar()
+		if f.Kind == docs.Kind2DArray {
+			elem = f.Array()
+		}
+		for i, child := range node.Content {
+			if err := b.walkField(c, childNodes, elem, child, p.with(strconv.Itoa(i), "", false)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case docs.KindMap:
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		for i := 0; i < len(node.Content)-1; i += 2 {
+			if err := b.walkField(c, childNodes, f.Scalar(), node.Content[i+1], p.with(node.Content[i].Value, "", false)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if coreType, isCore := f.Type.IsCoreComponent(); isCore {
+		child, err := b.component(coreType, node, p)
+		if err != nil {
+			return fmt.Errorf("%v: %w", query.SliceToDotPath(p.conf...), err)
+		}
+		if child != nil {
+			childNodes[node] = struct{}{}
+			c.Children = append(c.Children, child)
+		}
+		return nil
+	}
+
+	if len(f.Children) > 0 {
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		specs := map[string]docs.FieldSpec{}
+		for _, child := range f.Children {
+			specs[child.Name] = child
+		}
+		for i := 0; i < len(node.Content)-1; i += 2 {
+			key := node.Content[i].Value
+			spec, exists := specs[key]
+			if !exists {
+				continue
+			}
+			if err := b.walkField(c, childNodes, spec, node.Content[i+1], p.with(key, key, elided(key))); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if node.Kind != yaml.ScalarNode || node.Value == "" || strings.Contains(node.Value, "${") {
+		return nil
+	}
+	if rType, isRef := config.ResourceReference(c.Type, c.Name, p.field); isRef {
+		c.References = append(c.References, Reference{
+			Field: strings.Join(p.field, "."),
+			Type:  rType,
+			Label: node.Value,
+		})
+	}
+	return nil
+}
+
+func processorIndex(c *Component, metricPath string) (int, bool) {
+	if c.Type != docs.TypeProcessor {
+		return 0, false
+	}
+	suffix := strings.TrimPrefix(c.MetricPath, metricPath+".processors.")
+	if suffix == c.MetricPath || strings.Contains(suffix, ".") {
+		return 0, false
+	}
+	i, err := strconv.Atoi(suffix)
+	return i, err == nil
+}
+
+func countProcessors(c *Component, metricPath string) (n int) {
+	for _, child := range c.Children {
+		if _, isProc := processorIndex(child, metricPath); isProc {
+			n++
+		}
+	}
+	return
+}
+
+// offsetProcessors shifts the indexes of the processors of a component within
+// the metric paths of the processors and their descendants.
+func offsetProcessors(c *Component, metricPath string, offset int) {
+	if offset == 0 {
+		return
+	}
+	var rewrite func(c *Component, from, to string)
+	rewrite = func(c *Component, from, to string) {
+		c.MetricPath = to + strings.TrimPrefix(c.MetricPath, from)
+		for _, child := range c.Children {
+			rewrite(child, from, to)
+		}
+	}
+	for _, child := range c.Children {
+		if i, isProc := processorIndex(child, metricPath); isProc {
+			rewrite(child, child.MetricPath, metricPath+".processors."+strconv.Itoa(i+offset))
+		}
+	}
+}
+
+// withoutNodes returns a copy of a node where a set of descendant nodes are
+// removed.
+func withoutNodes(n *yaml.Node, remove map[*yaml.Node]struct{}) *yaml.Node {
+	c := *n
+	if len(n.Content) == 0 {
+		return &c
+	}
+	c.Content = make([]*yaml.Node, 0, len(n.Content))
+	for _, child := range n.Content {
+		if _, exists := remove[child]; exists {
+			child = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+		} else {
+			child = withoutNodes(child, remove)
+		}
+		c.Content = append(c.Content, child)
+	}
+	return &c
+}
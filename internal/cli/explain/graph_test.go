@@ -0,0 +1,212 @@
+package explain_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/cli/explain"
+	"github.com/benthosdev/benthos/v4/internal/template"
+
+	_ "github.com/benthosdev/benthos/v4/public/components/all"
+)
+
+func graphFromYAML(t testing.TB, conf string) *explain.Graph {
+	t.Helper()
+
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(conf), &node))
+
+	g, err := explain.FromYAML(nil, &node)
+	require.NoError(t, err)
+	return g
+}
+
+func graphText(t testing.TB, g *explain.Graph) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	require.NoError(t, g.WriteText(&buf))
+	return buf.String()
+}
+
+func TestGraphPaths(t *testing.T) {
+	g := graphFromYAML(t, `
+input:
+  label: in
+  broker:
+    inputs:
+      - generate:
+          mapping: 'root = "a"'
+      - resource: foo
+  processors:
+    - cache:
+        resource: mycache
+        operator: get
+        key: x
+pipeline:
+  processors:
+    - bloblang: root = this
+    - rate_limit:
+        resource: nope
+output:
+  switch:
+    cases:
+      - check: this.a == 1
+        output:
+          stdout: {}
+      - output:
+          drop: {}
+cache_resources:
+  - label: mycache
+    memory: {}
+input_resources:
+  - label: foo
+    broker:
+      inputs:
+        - generate:
+            mapping: 'root = "b"'
+`)
+
+	assert.Equal(t, `input: broker label=in path=root.input
+  broker.inputs.0: generate path=root.input.broker.inputs.0
+  broker.inputs.1: resource path=root.input.broker.inputs.1 -> input_resources.foo
+  processors.0: cache path=root.input.processors.0 -> cache_resources.mycache
+pipeline.processors.0: bloblang path=root.pipeline.processors.0
+pipeline.processors.1: rate_limit path=root.pipeline.processors.1 -> rate_limit_resources.nope (undefined)
+output: switch path=root.output
+  switch.cases.0.output: stdout path=root.output.switch.0.output
+  switch.cases.1.output: drop path=root.output.switch.1.output
+
+input_resources.foo: broker label=foo path=root.input_resources
+  broker.inputs.0: generate path=root.input_resources.broker.inputs.0
+cache_resources.mycache: memory label=mycache path=root.cache_resources
+`, graphText(t, g))
+
+	c, exists := g.Lookup("input_resources.foo.broker.inputs.0")
+	require.True(t, exists)
+	assert.Equal(t, "generate", c.Name)
+}
+
+func TestGraphTemplates(t *testing.T) {
+	tmplPath := filepath.Join(t.TempDir(), "tmpl.yaml")
+	require.NoError(t, os.WriteFile(tmplPath, []byte(`
+name: explain_test_input
+type: input
+fields:
+  - name: value
+    type: string
+mapping: |
+  root.generate.mapping = "root = \"%v\"".format(this.value)
+  root.processors = [ { "bloblang": "root = this" } ]
+`), 0o644))
+	_, err := template.InitTemplates(tmplPath)
+	require.NoError(t, err)
+
+	g := graphFromYAML(t, `
+input:
+  explain_test_input:
+    value: foo
+  processors:
+    - bloblang: root = content().uppercase()
+`)
+
+	assert.Equal(t, `input: explain_test_input (template) path=root.input
+  processors.0: bloblang path=root.input.processors.1
+  explain_test_input: generate path=root.input
+    processors.0: bloblang path=root.input.processors.0
+`, graphText(t, g))
+}
+
+func TestGraphDOTAndMermaid(t *testing.T) {
+	g := graphFromYAML(t, `
+input:
+  resource: foo
+pipeline:
+  processors:
+    - bloblang: root = this
+output:
+  drop: {}
+input_resources:
+  - label: foo
+    generate:
+      mapping: 'root = "b"'
+`)
+
+	var buf bytes.Buffer
+	require.NoError(t, g.WriteDOT(&buf))
+	assert.Equal(t, `digraph benthos {
+  rankdir=LR;
+  node [shape=box];
+  "input" [label="resource\nroot.input"];
+  "pipeline.processors.0" [label="bloblang\nroot.pipeline.processors.0"];
+  "output" [label="drop\nroot.output"];
+  "input_resources.foo" [label="generate\nlabel: foo\nroot.input_resources"];
+  "input" -> "pipeline.processors.0" [style=bold];
+  "pipeline.processors.0" -> "output" [style=bold];
+  "input" -> "input_resources.foo" [style=dashed];
+}
+`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, g.WriteMermaid(&buf))
+	assert.Equal(t, `graph LR
+  n0["resource<br/>root.input"]
+  n1["bloblang<br/>root.pipeline.processors.0"]
+  n2["drop<br/>root.output"]
+  n3["generate<br/>label: foo<br/>root.input_resources"]
+  n0 ==> n1
+  n1 ==> n2
+  n0 -.-> n3
+`, buf.String())
+}
+
+func TestGraphDiff(t *testing.T) {
+	before := graphFromYAML(t, `
+input:
+  generate:
+    mapping: 'root = "a"'
+pipeline:
+  processors:
+    - bloblang: root = this
+    - bloblang: root = this.foo
+output:
+  label: out
+  drop: {}
+cache_resources:
+  - label: foo
+    memory: {}
+`)
+
+	after := graphFromYAML(t, `
+input:
+  generate:
+    mapping: 'root = "b"'
+pipeline:
+  processors:
+    - bloblang: root = this
+output:
+  label: out2
+  stdout: {}
+cache_resources:
+  - label: foo
+    memory: {}
+  - label: bar
+    memory: {}
+`)
+
+	var buf bytes.Buffer
+	require.NoError(t, explain.WriteDiff(&buf, explain.Diff(before, after)))
+	assert.Equal(t, `~ input: config changed
+- pipeline.processors.1: bloblang path=root.pipeline.processors.1
+~ output: type drop -> stdout, label 'out' -> 'out2'
++ cache_resources.bar: memory path=root.cache_resources
+`, buf.String())
+
+	assert.Empty(t, explain.Diff(before, before))
+}
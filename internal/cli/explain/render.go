@@ -0,0 +1,175 @@
+package explain
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+)
+
+// relativeKey returns the path of a component relative to its parent.
+func relativeKey(parent, c *Component) string {
+	if parent == nil {
+		return c.ID()
+	}
+	return query.SliceToDotPath(c.Path[len(parent.Path):]...)
+}
+
+func (g *Graph) walkWithParents(fn func(depth int, parent, c *Component)) {
+	var walk func(depth int, parent, c *Component)
+	walk = func(depth int, parent, c *Component) {
+		fn(depth, parent, c)
+		for _, child := range c.Children {
+			walk(depth+1, c, child)
+		}
+	}
+	for _, c := range g.Stream {
+		walk(0, nil, c)
+	}
+	for _, c := range g.Resources {
+		walk(0, nil, c)
+	}
+}
+
+func (g *Graph) resourceExists(r Reference) bool {
+	for _, c := range g.Resources {
+		if c.Type == r.Type && c.Label == r.Label {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteText writes the graph as an indented tree of components, each with its
+// label, the path that it emits metrics with and any resources it references.
+func (g *Graph) WriteText(w io.Writer) error {
+	var err error
+	g.walkWithParents(func(depth int, parent, c *Component) {
+		if err != nil {
+			return
+		}
+		if depth == 0 && len(g.Stream) > 0 && len(g.Resources) > 0 && c == g.Resources[0] {
+			if _, err = fmt.Fprintln(w); err != nil {
+				return
+			}
+		}
+
+		var b strings.Builder
+		b.WriteString(strings.Repeat("  ", depth))
+		b.WriteString(relativeKey(parent, c))
+		b.WriteString(": ")
+		b.WriteString(c.Name)
+		if c.Template {
+			b.WriteString(" (template)")
+		}
+		if c.Label != "" {
+			fmt.Fprintf(&b, " label=%v", c.Label)
+		}
+		fmt.Fprintf(&b, " path=%v", c.MetricPath)
+		for _, r := range c.References {
+			fmt.Fprintf(&b, " -> %v", r.ID())
+			if !g.resourceExists(r) {
+				b.WriteString(" (undefined)")
+			}
+		}
+		_, err = fmt.Fprintln(w, b.String())
+	})
+	return err
+}
+
+func nodeDescription(c *Component, lineBreak string) string {
+	lines := []string{c.Name}
+	if c.Template {
+		lines[0] += " (template)"
+	}
+	if c.Label != "" {
+		lines = append(lines, "label: "+c.Label)
+	}
+	lines = append(lines, c.MetricPath)
+	return strings.Join(lines, lineBreak)
+}
+
+type edge struct {
+	from, to *Component
+	kind     string
+}
+
+const (
+	edgeFlow  = "flow"
+	edgeChild = "child"
+	edgeRef   = "ref"
+)
+
+// edges returns the edges of the graph, which are the flow of data between the
+// components of the stream, the children of components, and references from
+// components to resources.
+func (g *Graph) edges() []edge {
+	var edges []edge
+	for i := 1; i < len(g.Stream); i++ {
+		edges = append(edges, edge{from: g.Stream[i-1], to: g.Stream[i], kind: edgeFlow})
+	}
+	g.Walk(func(_ int, c *Component) {
+		for _, child := range c.Children {
+			edges = append(edges, edge{from: c, to: child, kind: edgeChild})
+		}
+		for _, r := range c.References {
+			for _, res := range g.Resources {
+				if res.Type == r.Type && res.Label == r.Label {
+					edges = append(edges, edge{from: c, to: res, kind: edgeRef})
+				}
+			}
+		}
+	})
+	return edges
+}
+
+// WriteDOT writes the graph in the Graphviz DOT language.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("digraph benthos {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box];\n")
+	g.Walk(func(_ int, c *Component) {
+		fmt.Fprintf(&b, "  %v [label=%v];\n", strconv.Quote(c.ID()), strconv.Quote(nodeDescription(c, "\n")))
+	})
+	for _, e := range g.edges() {
+		var attrs string
+		switch e.kind {
+		case edgeFlow:
+			attrs = " [style=bold]"
+		case edgeRef:
+			attrs = " [style=dashed]"
+		}
+		fmt.Fprintf(&b, "  %v -> %v%v;\n", strconv.Quote(e.from.ID()), strconv.Quote(e.to.ID()), attrs)
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteMermaid writes the graph as a Mermaid flowchart.
+func (g *Graph) WriteMermaid(w io.Writer) error {
+	ids := map[*Component]string{}
+
+	var b strings.Builder
+	b.WriteString("graph LR\n")
+	g.Walk(func(_ int, c *Component) {
+		ids[c] = "n" + strconv.Itoa(len(ids))
+		desc := strings.ReplaceAll(nodeDescription(c, "<br/>"), `"`, "#quot;")
+		fmt.Fprintf(&b, "  %v[\"%v\"]\n", ids[c], desc)
+	})
+	for _, e := range g.edges() {
+		arrow := "-->"
+		switch e.kind {
+		case edgeFlow:
+			arrow = "==>"
+		case edgeRef:
+			arrow = "-.->"
+		}
+		fmt.Fprintf(&b, "  %v %v %v\n", ids[e.from], arrow, ids[e.to])
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
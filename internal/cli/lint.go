@@ -2,10 +2,12 @@ package cli
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path"
 	"runtime"
+	"sort"
 	"sync"
 
 	"github.com/fatih/color"
@@ -21,28 +23,89 @@ var red = color.New(color.FgRed).SprintFunc()
 var yellow = color.New(color.FgYellow).SprintFunc()
 
 type pathLint struct {
-	source string
-	line   int
-	lint   string
-	err    string
+	source  string
+	line    int
+	column  int
+	snippet bool
+	warning bool
+	lint    string
+	err     string
 }
 
-func lintFile(path string, rejectDeprecated bool) (pathLints []pathLint) {
-	conf := config.New()
-	lints, err := config.ReadFileLinted(path, rejectDeprecated, &conf)
+func lintFile(path string, rejectDeprecated bool) (pathLints []pathLint, inc *config.IncludedYAML) {
+	inc, err := config.ReadFileWithIncludes(path, config.SecretsPlaceholder)
+	if err == nil {
+		conf := config.New()
+		err = inc.SourceError(inc.Node.Decode(&conf))
+	}
 	if err != nil {
 		pathLints = append(pathLints, pathLint{
 			source: path,
 			err:    err.Error(),
 		})
-		return
+		return nil, nil
 	}
-	for _, l := range lints {
+	for _, l := range inc.Lints {
 		pathLints = append(pathLints, pathLint{
 			source: path,
 			lint:   l,
 		})
 	}
+	if inc.LintDisabled {
+		return pathLints, nil
+	}
+
+	lintCtx := docs.NewLintContext()
+	lintCtx.RejectDeprecated = rejectDeprecated
+	for _, l := range config.LintYAML(lintCtx, inc.Node) {
+		source, line := inc.Source(l.Line)
+		pathLints = append(pathLints, pathLint{
+			source: source,
+			line:   line,
+			column: l.Column,
+			lint:   l.What,
+		})
+	}
+	return
+}
+
+// lintReferences performs a resolution pass across resource files and the
+// configs that were linted, where the lines of configs that include other files
+// are resolved to the files that they originate from.
+func lintReferences(resourcePaths []string, configs []config.LintFile, includes map[string]*config.IncludedYAML) (pathLints []pathLint) {
+	var resources []config.LintFile
+	for _, p := range resourcePaths {
+		resBytes, _, err := config.ReadFileEnvSwap(p, config.SecretsPlaceholder)
+		var node yaml.Node
+		if err == nil {
+			err = yaml.Unmarshal(resBytes, &node)
+		}
+		if err != nil {
+			pathLints = append(pathLints, pathLint{
+				source: p,
+				err:    err.Error(),
+			})
+			continue
+		}
+		resources = append(resources, config.LintFile{Path: p, Node: &node})
+	}
+
+	sort.Slice(configs, func(i, j int) bool {
+		return configs[i].Path < configs[j].Path
+	})
+	for _, l := range config.LintReferences(nil, resources, configs) {
+		source, line := l.Path, l.Line
+		if inc, exists := includes[l.Path]; exists {
+			source, line = inc.Source(l.Line)
+		}
+		pathLints = append(pathLints, pathLint{
+			source:  source,
+			line:    line,
+			column:  l.Column,
+			warning: l.Level == docs.LintWarning,
+			lint:    l.What,
+		})
+	}
 	return
 }
 
@@ -67,9 +130,10 @@ func lintMDSnippets(path string, rejectDeprecated bool) (pathLints []pathLint) {
 		endOfSnippet := bytes.Index(rawBytes[nextSnippet:], endTag)
 		if endOfSnippet == -1 {
 			pathLints = append(pathLints, pathLint{
-				source: path,
-				line:   snippetLine,
-				err:    "markdown snippet not terminated",
+				source:  path,
+				line:    snippetLine,
+				snippet: true,
+				err:     "markdown snippet not terminated",
 			})
 			return
 		}
@@ -80,9 +144,10 @@ func lintMDSnippets(path string, rejectDeprecated bool) (pathLints []pathLint) {
 
 		if err := yaml.Unmarshal(configBytes, &conf); err != nil {
 			pathLints = append(pathLints, pathLint{
-				source: path,
-				line:   snippetLine,
-				err:    err.Error(),
+				source:  path,
+				line:    snippetLine,
+				snippet: true,
+				err:     err.Error(),
 			})
 		} else {
 			lintCtx := docs.NewLintContext()
@@ -90,16 +155,18 @@ func lintMDSnippets(path string, rejectDeprecated bool) (pathLints []pathLint) {
 			lints, err := config.LintBytes(lintCtx, configBytes)
 			if err != nil {
 				pathLints = append(pathLints, pathLint{
-					source: path,
-					line:   snippetLine,
-					err:    err.Error(),
+					source:  path,
+					line:    snippetLine,
+					snippet: true,
+					err:     err.Error(),
 				})
 			}
 			for _, l := range lints {
 				pathLints = append(pathLints, pathLint{
-					source: path,
-					line:   snippetLine,
-					lint:   l,
+					source:  path,
+					line:    snippetLine,
+					snippet: true,
+					lint:    l,
 				})
 			}
 		}
@@ -111,6 +178,53 @@ func lintMDSnippets(path string, rejectDeprecated bool) (pathLints []pathLint) {
 	return
 }
 
+type jsonLint struct {
+	Path    string `json:"path"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Level   string `json:"level"`
+	Message string `json:"message"`
+}
+
+func printLintsJSON(pathLints []pathLint) {
+	jLints := make([]jsonLint, 0, len(pathLints))
+	for _, lint := range pathLints {
+		jl := jsonLint{
+			Path:    lint.source,
+			Line:    lint.line,
+			Column:  lint.column,
+			Level:   "error",
+			Message: lint.lint,
+		}
+		if lint.warning {
+			jl.Level = "warning"
+		}
+		if len(lint.err) > 0 {
+			jl.Message = lint.err
+		}
+		jLints = append(jLints, jl)
+	}
+	jBytes, _ := json.MarshalIndent(jLints, "", "  ")
+	fmt.Println(string(jBytes))
+}
+
+func printLintsText(pathLints []pathLint) {
+	for _, lint := range pathLints {
+		message := yellow(lint.lint)
+		if len(lint.err) > 0 {
+			message = red(lint.err)
+		}
+		switch {
+		case lint.snippet:
+			fmt.Fprintf(os.Stderr, "%v: from snippet at line %v: %v\n", lint.source, lint.line, message)
+		case lint.line > 0:
+			fmt.Fprintf(os.Stderr, "%v: line %v: %v\n", lint.source, lint.line, message)
+		default:
+			fmt.Fprintf(os.Stderr, "%v: %v\n", lint.source, message)
+		}
+	}
+}
+
 func lintCliCommand() *cli.Command {
 	return &cli.Command{
 		Name:  "lint",
@@ -122,17 +236,35 @@ Exits with a status code 1 if any linting errors are detected:
   benthos lint ./configs/*.yaml
   benthos lint ./foo.yaml ./bar.yaml
   benthos lint ./configs/...
+  benthos -r "./resources/*.yaml" lint --format json ./configs/...
 
 If a path ends with '...' then Benthos will walk the target and lint any
-files with the .yaml or .yml extension.`[1:],
+files with the .yaml or .yml extension.
+
+Resource files provided with the --resources flag are resolved along with
+each config in order to report references to resources that are not defined,
+resources that are never referenced and resource labels that collide across
+files. Warnings, such as unused resources, do not result in a failing status
+code.`[1:],
 		Flags: []cli.Flag{
 			&cli.BoolFlag{
 				Name:  "deprecated",
 				Value: false,
 				Usage: "Print linting errors for the presence of deprecated fields.",
 			},
+			&cli.StringFlag{
+				Name:  "format",
+				Value: "text",
+				Usage: "The format to print lints in, options are: text, json.",
+			},
 		},
 		Action: func(c *cli.Context) error {
+			format := c.String("format")
+			if format != "text" && format != "json" {
+				fmt.Fprintf(os.Stderr, "Unrecognised lint format: %v\n", format)
+				os.Exit(1)
+			}
+
 			targets, err := ifilepath.GlobsAndSuperPaths(c.Args().Slice(), "yaml", "yml")
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Lint paths error: %v\n", err)
@@ -142,10 +274,18 @@ files with the .yaml or .yml extension.`[1:],
 				targets = append(targets, conf)
 			}
 
+			resourcePaths, err := ifilepath.Globs(c.StringSlice("resources"))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Resource paths error: %v\n", err)
+				os.Exit(1)
+			}
+
 			rejectDeprecated := c.Bool("deprecated")
 
 			var pathLintMut sync.Mutex
 			var pathLints []pathLint
+			var configs []config.LintFile
+			includes := map[string]*config.IncludedYAML{}
 			threads := runtime.NumCPU()
 			var wg sync.WaitGroup
 			wg.Add(threads)
@@ -160,35 +300,53 @@ files with the .yaml or .yml extension.`[1:],
 							continue
 						}
 						var lints []pathLint
+						var inc *config.IncludedYAML
 						if path.Ext(target) == ".md" {
 							lints = lintMDSnippets(target, rejectDeprecated)
 						} else {
-							lints = lintFile(target, rejectDeprecated)
+							lints, inc = lintFile(target, rejectDeprecated)
 						}
-						if len(lints) > 0 {
-							pathLintMut.Lock()
-							pathLints = append(pathLints, lints...)
-							pathLintMut.Unlock()
+						pathLintMut.Lock()
+						pathLints = append(pathLints, lints...)
+						if inc != nil {
+							configs = append(configs, config.LintFile{Path: target, Node: inc.Node})
+							includes[target] = inc
 						}
+						pathLintMut.Unlock()
 					}
 				}(i)
 			}
 			wg.Wait()
-			if len(pathLints) == 0 {
-				os.Exit(0)
-			}
+
+			pathLints = append(pathLints, lintReferences(resourcePaths, configs, includes)...)
+
+			// Files included by multiple configs are linted with each of them.
+			seen := map[pathLint]struct{}{}
+			uniqueLints := pathLints[:0]
 			for _, lint := range pathLints {
-				message := yellow(lint.lint)
-				if len(lint.err) > 0 {
-					message = red(lint.err)
+				if _, exists := seen[lint]; !exists {
+					seen[lint] = struct{}{}
+					uniqueLints = append(uniqueLints, lint)
 				}
-				if lint.line > 0 {
-					fmt.Fprintf(os.Stderr, "%v: from snippet at line %v: %v\n", lint.source, lint.line, message)
-				} else {
-					fmt.Fprintf(os.Stderr, "%v: %v\n", lint.source, message)
+			}
+			pathLints = uniqueLints
+
+			failed := false
+			for _, lint := range pathLints {
+				if !lint.warning {
+					failed = true
 				}
 			}
-			os.Exit(1)
+
+			if format == "json" {
+				printLintsJSON(pathLints)
+			} else {
+				printLintsText(pathLints)
+			}
+			if failed {
+				os.Exit(1)
+			}
+			os.Exit(0)
 			return nil
 		},
 	}
@@ -0,0 +1,30 @@
+// Package lsp provides a CLI subcommand that runs a language server for
+// authoring Benthos configs within editors.
+package lsp
+
+import (
+	"os"
+
+	"github.com/urfave/cli/v2"
+)
+
+// CliCommand is a cli.Command definition for running a language server.
+func CliCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "lsp",
+		Usage: "Run a language server for editing configs",
+		Description: `
+Runs a language server that communicates with an editor over stdin and stdout
+using the Language Server Protocol. The server offers completions of component
+names and config fields, Bloblang functions and methods within mapping fields
+and interpolations, docs on hover, and diagnostics from the config linter.
+
+Editors with language server support can be configured to launch the server
+for YAML config files with the command:
+
+  benthos lsp`[1:],
+		Action: func(c *cli.Context) error {
+			return NewServer().Serve(os.Stdin, os.Stdout)
+		},
+	}
+}
@@ -0,0 +1,222 @@
+package lsp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+var bloblangKeywords = []string{"root", "this", "meta", "let", "if", "else", "match", "map", "import", "from"}
+
+// complete returns the completion items for a cursor.
+func (s *Server) complete(c cursor) []completionItem {
+	parent, ok := resolveSpec(c.path)
+	if !ok {
+		return nil
+	}
+	if !c.inValue {
+		return keyCompletions(parent)
+	}
+	f, ok := childSpec(parent, c.key)
+	if !ok {
+		return nil
+	}
+	return s.valueCompletions(f, c.text)
+}
+
+func keyCompletions(f docs.FieldSpec) (items []completionItem) {
+	if t, ok := componentSlot(f); ok {
+		for _, c := range componentDocs(t) {
+			items = append(items, completionItem{
+				Label:         c.Name,
+				Kind:          completionItemKindModule,
+				Detail:        fmt.Sprintf("%v %v", c.Type, c.Status),
+				Documentation: markdown(c.Summary),
+				InsertText:    c.Name + ":",
+				SortText:      "1" + c.Name,
+				Deprecated:    c.Status == docs.StatusDeprecated,
+			})
+		}
+		for _, r := range reservedFields(t) {
+			items = append(items, fieldCompletion(r, "0"))
+		}
+		sortItems(items)
+		return
+	}
+
+	if f.Kind != docs.KindScalar && f.Kind != "" {
+		return nil
+	}
+	for _, child := range f.Children {
+		sortPrefix := "0"
+		if child.IsAdvanced {
+			sortPrefix = "1"
+		}
+		if child.IsDeprecated {
+			sortPrefix = "2"
+		}
+		items = append(items, fieldCompletion(child, sortPrefix))
+	}
+	return
+}
+
+func fieldCompletion(f docs.FieldSpec, sortPrefix string) completionItem {
+	insert := f.Name + ": "
+	if _, isComponent := f.Type.IsCoreComponent(); isComponent || f.Type == docs.FieldTypeObject || f.Kind != docs.KindScalar {
+		insert = f.Name + ":"
+	}
+	return completionItem{
+		Label:         f.Name,
+		Kind:          completionItemKindField,
+		Detail:        fieldTypeString(f),
+		Documentation: markdown(f.Description),
+		InsertText:    insert,
+		SortText:      sortPrefix + f.Name,
+		Deprecated:    f.IsDeprecated,
+	}
+}
+
+func (s *Server) valueCompletions(f docs.FieldSpec, text string) (items []completionItem) {
+	if f.Bloblang {
+		return s.bloblangCompletions(strings.TrimPrefix(text, `"`))
+	}
+	if f.Interpolated {
+		if start := strings.LastIndex(text, "${!"); start >= 0 && !strings.Contains(text[start:], "}") {
+			return s.bloblangCompletions(text[start+3:])
+		}
+	}
+	if f.Kind != docs.KindScalar {
+		return nil
+	}
+
+	for _, o := range f.AnnotatedOptions {
+		items = append(items, completionItem{
+			Label:         o[0],
+			Kind:          completionItemKindValue,
+			Documentation: markdown(o[1]),
+		})
+	}
+	for _, o := range f.Options {
+		items = append(items, completionItem{Label: o, Kind: completionItemKindValue})
+	}
+	if f.Type == docs.FieldTypeBool {
+		items = append(items,
+			completionItem{Label: "true", Kind: completionItemKindValue},
+			completionItem{Label: "false", Kind: completionItemKindValue},
+		)
+	}
+	if len(items) > 0 {
+		return
+	}
+	for _, e := range f.Examples {
+		switch e.(type) {
+		case string, int, int64, float64, bool:
+			items = append(items, completionItem{
+				Label:  fmt.Sprintf("%v", e),
+				Kind:   completionItemKindValue,
+				Detail: "example",
+			})
+		}
+	}
+	return
+}
+
+// bloblangCompletions returns completions for the Bloblang text preceding a
+// cursor, which are methods when the cursor follows a dot and functions
+// otherwise.
+func (s *Server) bloblangCompletions(text string) (items []completionItem) {
+	if lineStart := strings.LastIndexByte(text, '\n'); lineStart >= 0 {
+		text = text[lineStart+1:]
+	}
+	if (strings.Count(text, `"`)-strings.Count(text, `\"`))%2 == 1 {
+		return nil
+	}
+
+	start, _ := wordAt(text, len(text))
+	if start > 0 && text[start-1] == '.' {
+		s.bloblEnv.WalkMethods(func(name string, spec query.MethodSpec) {
+			if spec.Status == query.StatusHidden {
+				return
+			}
+			items = append(items, completionItem{
+				Label:         name,
+				Kind:          completionItemKindMethod,
+				Detail:        bloblangSignature(name, spec.Params),
+				Documentation: markdown(spec.Description),
+				Deprecated:    spec.Status == query.StatusDeprecated,
+			})
+		})
+		sortItems(items)
+		return
+	}
+
+	s.bloblEnv.WalkFunctions(func(name string, spec query.FunctionSpec) {
+		if spec.Status == query.StatusHidden {
+			return
+		}
+		items = append(items, completionItem{
+			Label:         name,
+			Kind:          completionItemKindFunction,
+			Detail:        bloblangSignature(name, spec.Params),
+			Documentation: markdown(spec.Description),
+			Deprecated:    spec.Status == query.StatusDeprecated,
+		})
+	})
+	for _, k := range bloblangKeywords {
+		items = append(items, completionItem{Label: k, Kind: completionItemKindKeyword})
+	}
+	sortItems(items)
+	return
+}
+
+//------------------------------------------------------------------------------
+
+func bloblangSignature(name string, params query.Params) string {
+	var args []string
+	for _, p := range params.Definitions {
+		arg := fmt.Sprintf("%v: %v", p.Name, p.ValueType)
+		if p.IsOptional || p.DefaultValue != nil {
+			arg += "?"
+		}
+		args = append(args, arg)
+	}
+	if params.Variadic {
+		args = append(args, "...")
+	}
+	return fmt.Sprintf("%v(%v)", name, strings.Join(args, ", "))
+}
+
+func fieldTypeString(f docs.FieldSpec) string {
+	switch f.Kind {
+	case docs.KindArray:
+		return fmt.Sprintf("array of %v", f.Type)
+	case docs.Kind2DArray:
+		return fmt.Sprintf("two-dimensional array of %v", f.Type)
+	case docs.KindMap:
+		return fmt.Sprintf("map of %v", f.Type)
+	}
+	return string(f.Type)
+}
+
+func markdown(s string) *markupContent {
+	if s = strings.TrimSpace(s); s == "" {
+		return nil
+	}
+	return &markupContent{Kind: markupKindMarkdown, Value: s}
+}
+
+func sortItems(items []completionItem) {
+	sort.SliceStable(items, func(i, j int) bool {
+		ki, kj := items[i].SortText, items[j].SortText
+		if ki == "" {
+			ki = items[i].Label
+		}
+		if kj == "" {
+			kj = items[j].Label
+		}
+		return ki < kj
+	})
+}
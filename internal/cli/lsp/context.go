@@ -0,0 +1,252 @@
+package lsp
+
+import (
+	"regexp"
+	"strings"
+)
+
+// The documents being edited are frequently invalid YAML, and therefore the
+// position of a cursor within the config structure is resolved from the
+// indentation of the lines preceding it rather than from a parsed node tree.
+
+var (
+	keyRegexp         = regexp.MustCompile(`^(?:"([^"]*)"|'([^']*)'|([\w\-./]+))\s*:(?:\s+(.*))?$`)
+	blockScalarRegexp = regexp.MustCompile(`^[|>][-+0-9]*\s*(#.*)?$`)
+)
+
+// seqMarker is the path segment that represents an element of a sequence.
+const seqMarker = "-"
+
+type yamlLine struct {
+	blank  bool
+	indent int
+	dashes []int
+	keyCol int
+	hasKey bool
+	key    string
+	value  string
+}
+
+func parseLine(s string) (l yamlLine) {
+	trimmed := strings.TrimLeft(s, " ")
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+		l.blank = true
+		return
+	}
+
+	col := len(s) - len(trimmed)
+	l.indent = col
+	for strings.HasPrefix(trimmed, "- ") || trimmed == "-" {
+		l.dashes = append(l.dashes, col)
+		rest := strings.TrimLeft(trimmed[1:], " ")
+		col += len(trimmed) - len(rest)
+		trimmed = rest
+	}
+	l.keyCol = col
+
+	if m := keyRegexp.FindStringSubmatch(trimmed); m != nil {
+		l.hasKey = true
+		l.key = m[1] + m[2] + m[3]
+		if l.value = m[4]; strings.HasPrefix(l.value, "#") {
+			l.value = ""
+		}
+	}
+	return
+}
+
+// cursor describes the position of a cursor within a config.
+type cursor struct {
+	// The keys of the mappings that lead to the mapping the cursor is within,
+	// with elements of sequences represented by seqMarker.
+	path []string
+
+	// Whether the cursor is within the value of a key rather than the key
+	// itself, in which case key is the key of the value.
+	inValue bool
+	key     string
+
+	// The partial key preceding the cursor, or the value text preceding the
+	// cursor, which spans multiple lines within block scalars.
+	text string
+}
+
+// cursorAt resolves the position of a cursor at a line and byte column of a
+// document.
+func cursorAt(lines []string, lineIdx, col int) cursor {
+	if lineIdx >= len(lines) {
+		return cursor{}
+	}
+	current := lines[lineIdx]
+	if col > len(current) {
+		col = len(current)
+	}
+	before := current[:col]
+
+	if c, ok := blockScalarCursor(lines, lineIdx, before); ok {
+		return c
+	}
+
+	trimmed := strings.TrimLeft(before, " ")
+	keyCol := col - len(trimmed)
+	var dashes []int
+	for strings.HasPrefix(trimmed, "- ") || trimmed == "-" {
+		dashes = append(dashes, keyCol)
+		rest := strings.TrimLeft(trimmed[1:], " ")
+		keyCol += len(trimmed) - len(rest)
+		trimmed = rest
+	}
+
+	c := cursor{
+		path: parentPath(lines, lineIdx, keyCol, dashes),
+		text: trimmed,
+	}
+	if m := keyRegexp.FindStringSubmatchIndex(trimmed); m != nil && m[8] >= 0 {
+		c.inValue = true
+		for g := 1; g <= 3; g++ {
+			if m[2*g] >= 0 {
+				c.key = trimmed[m[2*g]:m[2*g+1]]
+			}
+		}
+		c.text = trimmed[m[8]:]
+	}
+	return c
+}
+
+// blockScalarCursor checks whether the cursor is within the contents of a
+// block scalar, such as a multiple line Bloblang mapping.
+func blockScalarCursor(lines []string, lineIdx int, before string) (cursor, bool) {
+	minIndent := len(before) - len(strings.TrimLeft(before, " "))
+	if full := lines[lineIdx]; strings.TrimSpace(full) != "" {
+		minIndent = len(full) - len(strings.TrimLeft(full, " "))
+	}
+
+	for i := lineIdx - 1; i >= 0; i-- {
+		if strings.TrimSpace(lines[i]) == "" {
+			continue
+		}
+		indent := len(lines[i]) - len(strings.TrimLeft(lines[i], " "))
+		if indent >= minIndent {
+			continue
+		}
+		l := parseLine(lines[i])
+		if !l.hasKey && len(l.dashes) == 0 {
+			minIndent = indent
+			continue
+		}
+		if !l.hasKey || !blockScalarRegexp.MatchString(l.value) {
+			return cursor{}, false
+		}
+
+		var text strings.Builder
+		for _, content := range lines[i+1 : lineIdx] {
+			text.WriteString(strings.TrimSpace(content))
+			text.WriteByte('\n')
+		}
+		text.WriteString(strings.TrimLeft(before, " "))
+		return cursor{
+			path:    parentPath(lines, i, l.keyCol, l.dashes),
+			inValue: true,
+			key:     l.key,
+			text:    text.String(),
+		}, true
+	}
+	return cursor{}, false
+}
+
+// parentPath walks the lines preceding a key at a given column in order to
+// resolve the keys of the mappings and sequences that it belongs to.
+func parentPath(lines []string, lineIdx, keyCol int, dashes []int) []string {
+	var reversed []string
+	target, inclusive := keyCol, false
+	pushDashes := func(d []int) {
+		for range d {
+			reversed = append(reversed, seqMarker)
+		}
+		if len(d) > 0 {
+			target, inclusive = d[0], true
+		}
+	}
+	pushDashes(dashes)
+
+	for i := lineIdx - 1; i >= 0 && (target > 0 || inclusive); i-- {
+		l := parseLine(lines[i])
+		if l.blank {
+			continue
+		}
+		switch {
+		case l.keyCol < target || (inclusive && l.keyCol == target && len(l.dashes) == 0):
+			if !l.hasKey {
+				continue
+			}
+			reversed = append(reversed, l.key)
+			target, inclusive = l.keyCol, false
+			pushDashes(l.dashes)
+		case l.keyCol == target && len(l.dashes) > 0 && !inclusive:
+			pushDashes(l.dashes)
+		}
+	}
+
+	path := make([]string, len(reversed))
+	for i, k := range reversed {
+		path[len(reversed)-1-i] = k
+	}
+	return path
+}
+
+//------------------------------------------------------------------------------
+
+func splitLines(text string) []string {
+	return strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+}
+
+// byteOffset converts a position character, which counts UTF-16 code units,
+// into a byte offset within a line.
+func byteOffset(line string, character int) int {
+	units := 0
+	for i, r := range line {
+		if units >= character {
+			return i
+		}
+		units += utf16Len(r)
+	}
+	return len(line)
+}
+
+// characterOffset converts a byte offset within a line into a position
+// character.
+func characterOffset(line string, offset int) int {
+	if offset > len(line) {
+		offset = len(line)
+	}
+	units := 0
+	for _, r := range line[:offset] {
+		units += utf16Len(r)
+	}
+	return units
+}
+
+func utf16Len(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || (b >= '0' && b <= '9')
+}
+
+// wordAt returns the bounds of the word that spans a byte column of a line.
+func wordAt(line string, col int) (start, end int) {
+	if col > len(line) {
+		col = len(line)
+	}
+	start, end = col, col
+	for start > 0 && isWordByte(line[start-1]) {
+		start--
+	}
+	for end < len(line) && isWordByte(line[end]) {
+		end++
+	}
+	return
+}
@@ -0,0 +1,100 @@
+package lsp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursorAt(t *testing.T) {
+	doc := `
+input:
+  generate:
+    mapping: 'root = this'
+
+pipeline:
+  processors:
+    - label: foo
+      bloblang: |
+        root = this
+        root.bar = this.
+    - switch:
+        - check: this.a == "b"
+          processors:
+            -
+output:
+  drop: {}
+
+`
+	lines := strings.Split(doc, "\n")
+
+	tests := map[string]struct {
+		line, col int
+		cursor    cursor
+	}{
+		"top level key": {
+			line: 1, col: 3,
+			cursor: cursor{path: []string{}, text: "inp"},
+		},
+		"component slot": {
+			line: 2, col: 5,
+			cursor: cursor{path: []string{"input"}, text: "gen"},
+		},
+		"component value": {
+			line: 3, col: 25,
+			cursor: cursor{path: []string{"input", "generate"}, inValue: true, key: "mapping", text: "'root = this"},
+		},
+		"start of key": {
+			line: 3, col: 4,
+			cursor: cursor{path: []string{"input", "generate"}},
+		},
+		"sequence sibling": {
+			line: 8, col: 8,
+			cursor: cursor{path: []string{"pipeline", "processors", "-"}, text: "bl"},
+		},
+		"block scalar": {
+			line: 10, col: 24,
+			cursor: cursor{path: []string{"pipeline", "processors", "-"}, inValue: true, key: "bloblang", text: "root = this\nroot.bar = this."},
+		},
+		"nested sequence": {
+			line: 12, col: 23,
+			cursor: cursor{path: []string{"pipeline", "processors", "-", "switch", "-"}, inValue: true, key: "check", text: "this.a"},
+		},
+		"nested sequence key": {
+			line: 13, col: 14,
+			cursor: cursor{path: []string{"pipeline", "processors", "-", "switch", "-"}, text: "proc"},
+		},
+		"empty sequence item": {
+			line: 14, col: 14,
+			cursor: cursor{path: []string{"pipeline", "processors", "-", "switch", "-", "processors", "-"}},
+		},
+		"after sequences": {
+			line: 16, col: 2,
+			cursor: cursor{path: []string{"output"}},
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			c := cursorAt(lines, test.line, test.col)
+			if c.path == nil {
+				c.path = []string{}
+			}
+			assert.Equal(t, test.cursor, c)
+		})
+	}
+}
+
+func TestPositionOffsets(t *testing.T) {
+	line := "a: 😀 b"
+	assert.Equal(t, 7, byteOffset(line, 5))
+	assert.Equal(t, 5, characterOffset(line, 7))
+	assert.Equal(t, len(line), byteOffset(line, 100))
+
+	assert.Equal(t, "foo: bar\nbaz: buz\n", applyChange("foo: bar\nbaz: qux\n", contentChange{
+		Range: &textRange{Start: position{Line: 1, Character: 5}, End: position{Line: 1, Character: 8}},
+		Text:  "buz",
+	}))
+}
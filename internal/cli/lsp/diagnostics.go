@@ -0,0 +1,70 @@
+package lsp
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/config"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+var yamlErrLineRegexp = regexp.MustCompile(`line (\d+)`)
+
+// lintDocument returns the diagnostics of a config document, which are the
+// same lints reported by the lint subcommand along with YAML parse errors.
+func lintDocument(text string) []diagnostic {
+	diags := []diagnostic{}
+	if strings.HasPrefix(text, "# BENTHOS LINT DISABLE") {
+		return diags
+	}
+
+	lines := splitLines(text)
+
+	var node yaml.Node
+	if err := yaml.Unmarshal([]byte(text), &node); err != nil {
+		line := 1
+		if m := yamlErrLineRegexp.FindStringSubmatch(err.Error()); m != nil {
+			line, _ = strconv.Atoi(m[1])
+		}
+		return append(diags, lineDiagnostic(lines, line, 0, diagnosticSeverityError, err.Error()))
+	}
+
+	for _, l := range config.Spec().LintYAML(docs.NewLintContext(), &node) {
+		severity := diagnosticSeverityWarning
+		if l.Level == docs.LintError {
+			severity = diagnosticSeverityError
+		}
+		diags = append(diags, lineDiagnostic(lines, l.Line, l.Column, severity, l.What))
+	}
+	return diags
+}
+
+// lineDiagnostic creates a diagnostic that spans a line from a column, or from
+// the first non-whitespace character when the column is not known. Lines and
+// columns are indexed from one.
+func lineDiagnostic(lines []string, line, column, severity int, msg string) diagnostic {
+	if line < 1 {
+		line = 1
+	}
+	if line > len(lines) {
+		line = len(lines)
+	}
+	text := lines[line-1]
+
+	start := len(text) - len(strings.TrimLeft(text, " "))
+	if column >= 1 && column-1 <= len(text) {
+		start = column - 1
+	}
+	return diagnostic{
+		Range: textRange{
+			Start: position{Line: line - 1, Character: characterOffset(text, start)},
+			End:   position{Line: line - 1, Character: characterOffset(text, len(text))},
+		},
+		Severity: severity,
+		Source:   "benthos",
+		Message:  msg,
+	}
+}
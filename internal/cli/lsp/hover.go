@@ -0,0 +1,125 @@
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+// hoverAt returns the docs of the word at a line and byte column of a
+// document, which is either a config key, a component name, an option of a
+// field or a Bloblang function or method.
+func (s *Server) hoverAt(lines []string, lineIdx, col int) *hover {
+	if lineIdx >= len(lines) {
+		return nil
+	}
+	line := lines[lineIdx]
+	start, end := wordAt(line, col)
+	if start == end {
+		return nil
+	}
+	word := line[start:end]
+
+	c := cursorAt(lines, lineIdx, end)
+	parent, ok := resolveSpec(c.path)
+	if !ok {
+		return nil
+	}
+
+	var content string
+	if !c.inValue {
+		content = keyHover(parent, word)
+	} else if f, ok := childSpec(parent, c.key); ok {
+		content = s.valueHover(f, c.text, word)
+	}
+	if content == "" {
+		return nil
+	}
+	return &hover{
+		Contents: markupContent{Kind: markupKindMarkdown, Value: content},
+		Range: &textRange{
+			Start: position{Line: lineIdx, Character: characterOffset(line, start)},
+			End:   position{Line: lineIdx, Character: characterOffset(line, end)},
+		},
+	}
+}
+
+func keyHover(parent docs.FieldSpec, key string) string {
+	if t, ok := componentSlot(parent); ok {
+		if c, exists := componentSpec(t, key); exists {
+			var b strings.Builder
+			fmt.Fprintf(&b, "**%v** (%v, %v)", c.Name, c.Type, c.Status)
+			if summary := strings.TrimSpace(c.Summary); summary != "" {
+				fmt.Fprintf(&b, "\n\n%v", summary)
+			}
+			if desc := strings.TrimSpace(c.Description); desc != "" {
+				fmt.Fprintf(&b, "\n\n%v", desc)
+			}
+			return b.String()
+		}
+	}
+	f, ok := childSpec(parent, key)
+	if !ok || f.Name != key {
+		return ""
+	}
+	return fieldHover(f)
+}
+
+func fieldHover(f docs.FieldSpec) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "**%v** `%v`", f.Name, fieldTypeString(f))
+	if desc := strings.TrimSpace(f.Description); desc != "" {
+		fmt.Fprintf(&b, "\n\n%v", desc)
+	}
+	if f.Default != nil {
+		if defBytes, err := json.Marshal(*f.Default); err == nil {
+			fmt.Fprintf(&b, "\n\nDefault: `%s`", defBytes)
+		}
+	}
+	return b.String()
+}
+
+func (s *Server) valueHover(f docs.FieldSpec, text, word string) string {
+	if f.Bloblang || f.Interpolated {
+		return s.bloblangHover(text, word)
+	}
+	for _, o := range f.AnnotatedOptions {
+		if o[0] == word {
+			return fmt.Sprintf("**%v**\n\n%v", o[0], strings.TrimSpace(o[1]))
+		}
+	}
+	return ""
+}
+
+// bloblangHover returns the docs of a Bloblang function or method named by a
+// word at the end of a Bloblang text.
+func (s *Server) bloblangHover(text, word string) string {
+	isMethod := len(text) > len(word) && text[len(text)-len(word)-1] == '.'
+
+	var content string
+	if isMethod {
+		s.bloblEnv.WalkMethods(func(name string, spec query.MethodSpec) {
+			if name == word {
+				content = bloblangDocs(bloblangSignature(name, spec.Params), spec.Description)
+			}
+		})
+		return content
+	}
+	s.bloblEnv.WalkFunctions(func(name string, spec query.FunctionSpec) {
+		if name == word {
+			content = bloblangDocs(bloblangSignature(name, spec.Params), spec.Description)
+		}
+	})
+	return content
+}
+
+func bloblangDocs(signature, description string) string {
+	content := "```coffee\n" + signature + "\n```"
+	if description = strings.TrimSpace(description); description != "" {
+		content += "\n\n" + description
+	}
+	return content
+}
@@ -0,0 +1,227 @@
+package lsp
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang"
+)
+
+// ErrExitWithoutShutdown is returned by Serve when the client sends an exit
+// notification without first requesting a shutdown.
+var ErrExitWithoutShutdown = errors.New("exit notification received without a shutdown request")
+
+// Server is a language server for Benthos configs, which offers completions,
+// hover docs and diagnostics to editors over the language server protocol.
+type Server struct {
+	bloblEnv *bloblang.Environment
+
+	documents   map[string]string
+	initialized bool
+	shutdown    bool
+}
+
+// NewServer creates a language server.
+func NewServer() *Server {
+	return &Server{
+		bloblEnv:  bloblang.GlobalEnvironment(),
+		documents: map[string]string{},
+	}
+}
+
+// Serve reads requests from a client until the connection is closed or the
+// client sends an exit notification.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	c := newConn(r, w)
+	for {
+		body, err := c.read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		var msg rpcMessage
+		if err := json.Unmarshal(body, &msg); err != nil {
+			if err := c.reply(nil, nil, &rpcError{Code: codeParseError, Message: err.Error()}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				return ErrExitWithoutShutdown
+			}
+			return nil
+		}
+
+		result, err := s.handle(c, msg)
+		if msg.ID == nil {
+			continue
+		}
+		if err := c.reply(msg.ID, result, err); err != nil {
+			return err
+		}
+	}
+}
+
+func decodeParams(msg rpcMessage, v interface{}) error {
+	if err := json.Unmarshal(msg.Params, v); err != nil {
+		return &rpcError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+func (s *Server) handle(c *conn, msg rpcMessage) (interface{}, error) {
+	if !s.initialized && msg.Method != "initialize" {
+		if msg.ID == nil {
+			return nil, nil
+		}
+		return nil, &rpcError{Code: codeServerNotInitialized, Message: "server not initialized"}
+	}
+
+	switch msg.Method {
+	case "initialize":
+		s.initialized = true
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync": map[string]interface{}{
+					"openClose": true,
+					"change":    2,
+				},
+				"completionProvider": map[string]interface{}{
+					"triggerCharacters": []string{".", ":", " ", "-"},
+				},
+				"hoverProvider": true,
+			},
+			"serverInfo": map[string]interface{}{
+				"name": "benthos",
+			},
+		}, nil
+
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if err := decodeParams(msg, &params); err != nil {
+			return nil, err
+		}
+		s.documents[params.TextDocument.URI] = params.TextDocument.Text
+		return nil, s.publishDiagnostics(c, params.TextDocument.URI)
+
+	case "textDocument/didChange":
+		var params didChangeParams
+		if err := decodeParams(msg, &params); err != nil {
+			return nil, err
+		}
+		text := s.documents[params.TextDocument.URI]
+		for _, change := range params.ContentChanges {
+			text = applyChange(text, change)
+		}
+		s.documents[params.TextDocument.URI] = text
+		return nil, s.publishDiagnostics(c, params.TextDocument.URI)
+
+	case "textDocument/didClose":
+		var params didCloseParams
+		if err := decodeParams(msg, &params); err != nil {
+			return nil, err
+		}
+		delete(s.documents, params.TextDocument.URI)
+		return nil, c.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+			URI:         params.TextDocument.URI,
+			Diagnostics: []diagnostic{},
+		})
+
+	case "textDocument/completion":
+		var params textDocumentPositionParams
+		if err := decodeParams(msg, &params); err != nil {
+			return nil, err
+		}
+		lines, col, ok := s.position(params)
+		if !ok {
+			return nil, nil
+		}
+		items := s.complete(cursorAt(lines, params.Position.Line, col))
+		if items == nil {
+			items = []completionItem{}
+		}
+		return completionList{Items: items}, nil
+
+	case "textDocument/hover":
+		var params textDocumentPositionParams
+		if err := decodeParams(msg, &params); err != nil {
+			return nil, err
+		}
+		lines, col, ok := s.position(params)
+		if !ok {
+			return nil, nil
+		}
+		if h := s.hoverAt(lines, params.Position.Line, col); h != nil {
+			return h, nil
+		}
+		return nil, nil
+	}
+
+	if msg.ID != nil && !strings.HasPrefix(msg.Method, "$/") {
+		return nil, &rpcError{Code: codeMethodNotFound, Message: "method not found: " + msg.Method}
+	}
+	return nil, nil
+}
+
+// position returns the lines of a document along with the byte column of a
+// position within it.
+func (s *Server) position(params textDocumentPositionParams) (lines []string, col int, ok bool) {
+	text, exists := s.documents[params.TextDocument.URI]
+	if !exists {
+		return nil, 0, false
+	}
+	lines = splitLines(text)
+	if params.Position.Line < 0 || params.Position.Line >= len(lines) {
+		return nil, 0, false
+	}
+	return lines, byteOffset(lines[params.Position.Line], params.Position.Character), true
+}
+
+func (s *Server) publishDiagnostics(c *conn, uri string) error {
+	return c.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+		URI:         uri,
+		Diagnostics: lintDocument(s.documents[uri]),
+	})
+}
+
+// applyChange applies an incremental change to a document, or replaces the
+// document entirely when the change has no range.
+func applyChange(text string, change contentChange) string {
+	if change.Range == nil {
+		return change.Text
+	}
+	start := documentOffset(text, change.Range.Start)
+	end := documentOffset(text, change.Range.End)
+	if end < start {
+		start, end = end, start
+	}
+	return text[:start] + change.Text + text[end:]
+}
+
+// documentOffset converts a position into a byte offset within a document.
+func documentOffset(text string, pos position) int {
+	offset := 0
+	for line := 0; line < pos.Line; line++ {
+		next := strings.IndexByte(text[offset:], '\n')
+		if next == -1 {
+			return len(text)
+		}
+		offset += next + 1
+	}
+	lineText := text[offset:]
+	if end := strings.IndexByte(lineText, '\n'); end >= 0 {
+		lineText = lineText[:end]
+	}
+	return offset + byteOffset(strings.TrimSuffix(lineText, "\r"), pos.Character)
+}
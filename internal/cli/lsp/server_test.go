@@ -0,0 +1,277 @@
+package lsp_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/cli/lsp"
+
+	_ "github.com/benthosdev/benthos/v4/public/components/all"
+)
+
+type testMessage struct {
+	ID     *int            `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+type testClient struct {
+	buf    bytes.Buffer
+	nextID int
+}
+
+func (c *testClient) send(method string, params interface{}) int {
+	msg := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  method,
+		"params":  params,
+	}
+	c.nextID++
+	msg["id"] = c.nextID
+
+	body, _ := json.Marshal(msg)
+	fmt.Fprintf(&c.buf, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	return c.nextID
+}
+
+func (c *testClient) notify(method string, params interface{}) {
+	body, _ := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  method,
+		"params":  params,
+	})
+	fmt.Fprintf(&c.buf, "Content-Length: %d\r\n\r\n%s", len(body), body)
+}
+
+func readMessages(t testing.TB, r io.Reader) (results map[int]testMessage, notifications []testMessage) {
+	t.Helper()
+
+	results = map[int]testMessage{}
+	br := bufio.NewReader(r)
+	for {
+		header, err := br.ReadString('\n')
+		if err == io.EOF {
+			return
+		}
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(header, "Content-Length: "), header)
+
+		length, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(header, "Content-Length: ")))
+		require.NoError(t, err)
+		_, err = br.ReadString('\n')
+		require.NoError(t, err)
+
+		body := make([]byte, length)
+		_, err = io.ReadFull(br, body)
+		require.NoError(t, err)
+
+		var msg testMessage
+		require.NoError(t, json.Unmarshal(body, &msg))
+		if msg.ID != nil {
+			results[*msg.ID] = msg
+		} else {
+			notifications = append(notifications, msg)
+		}
+	}
+}
+
+func positionParams(uri string, line, character int) map[string]interface{} {
+	return map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": uri},
+		"position":     map[string]interface{}{"line": line, "character": character},
+	}
+}
+
+func completionLabels(t testing.TB, msg testMessage) []string {
+	t.Helper()
+
+	require.Nil(t, msg.Error)
+	var list struct {
+		Items []struct {
+			Label  string `json:"label"`
+			Detail string `json:"detail"`
+		} `json:"items"`
+	}
+	require.NoError(t, json.Unmarshal(msg.Result, &list))
+
+	var labels []string
+	for _, item := range list.Items {
+		labels = append(labels, item.Label)
+	}
+	return labels
+}
+
+func hoverContent(t testing.TB, msg testMessage) string {
+	t.Helper()
+
+	require.Nil(t, msg.Error)
+	var h struct {
+		Contents struct {
+			Value string `json:"value"`
+		} `json:"contents"`
+	}
+	require.NoError(t, json.Unmarshal(msg.Result, &h))
+	return h.Contents.Value
+}
+
+func TestServer(t *testing.T) {
+	uri := "file:///config.yaml"
+	doc := `input:
+  generate:
+    mapping: 'root = now'
+    interval: 1s
+  processors:
+    - bloblang: |
+        root = this.
+        root.id = uuid_v4
+pipeline:
+  processors:
+    -
+output:
+  drop: {}
+  nope: true
+`
+
+	var client testClient
+	initID := client.send("initialize", map[string]interface{}{"capabilities": map[string]interface{}{}})
+	client.notify("initialized", map[string]interface{}{})
+	client.notify("textDocument/didOpen", map[string]interface{}{
+		"textDocument": map[string]interface{}{
+			"uri":        uri,
+			"languageId": "yaml",
+			"version":    1,
+			"text":       doc,
+		},
+	})
+
+	processorsID := client.send("textDocument/completion", positionParams(uri, 10, 6))
+	inputsID := client.send("textDocument/completion", positionParams(uri, 1, 2))
+	fieldsID := client.send("textDocument/completion", positionParams(uri, 3, 4))
+	methodsID := client.send("textDocument/completion", positionParams(uri, 6, 20))
+	functionsID := client.send("textDocument/completion", positionParams(uri, 7, 22))
+	mappingID := client.send("textDocument/completion", positionParams(uri, 2, 21))
+
+	componentHoverID := client.send("textDocument/hover", positionParams(uri, 1, 5))
+	fieldHoverID := client.send("textDocument/hover", positionParams(uri, 3, 6))
+	functionHoverID := client.send("textDocument/hover", positionParams(uri, 7, 20))
+	emptyHoverID := client.send("textDocument/hover", positionParams(uri, 10, 0))
+
+	unknownID := client.send("textDocument/definition", positionParams(uri, 0, 0))
+	shutdownID := client.send("shutdown", nil)
+	client.notify("exit", nil)
+
+	var out bytes.Buffer
+	require.NoError(t, lsp.NewServer().Serve(&client.buf, &out))
+
+	results, notifications := readMessages(t, &out)
+
+	require.Nil(t, results[initID].Error)
+	assert.Contains(t, string(results[initID].Result), `"hoverProvider":true`)
+
+	assert.Subset(t, completionLabels(t, results[processorsID]), []string{"bloblang", "switch", "label"})
+	assert.NotContains(t, completionLabels(t, results[processorsID]), "plugin")
+	assert.Subset(t, completionLabels(t, results[inputsID]), []string{"generate", "kafka", "label", "processors"})
+	assert.Subset(t, completionLabels(t, results[fieldsID]), []string{"mapping", "interval", "count"})
+	assert.Subset(t, completionLabels(t, results[methodsID]), []string{"uppercase", "map_each"})
+	assert.NotContains(t, completionLabels(t, results[methodsID]), "uuid_v4")
+	assert.Subset(t, completionLabels(t, results[functionsID]), []string{"uuid_v4", "now", "this"})
+	assert.Subset(t, completionLabels(t, results[mappingID]), []string{"now", "timestamp_unix"})
+
+	assert.Contains(t, hoverContent(t, results[componentHoverID]), "**generate** (input, stable)")
+	assert.Contains(t, hoverContent(t, results[fieldHoverID]), "**interval** `string`")
+	assert.Contains(t, hoverContent(t, results[functionHoverID]), "uuid_v4()")
+	assert.Equal(t, "null", string(results[emptyHoverID].Result))
+
+	require.NotNil(t, results[unknownID].Error)
+	assert.Equal(t, -32601, results[unknownID].Error.Code)
+	assert.Equal(t, "null", string(results[shutdownID].Result))
+
+	require.Len(t, notifications, 1)
+	assert.Equal(t, "textDocument/publishDiagnostics", notifications[0].Method)
+
+	var diags struct {
+		URI         string `json:"uri"`
+		Diagnostics []struct {
+			Range struct {
+				Start struct {
+					Line      int `json:"line"`
+					Character int `json:"character"`
+				} `json:"start"`
+			} `json:"range"`
+			Message string `json:"message"`
+		} `json:"diagnostics"`
+	}
+	require.NoError(t, json.Unmarshal(notifications[0].Params, &diags))
+	assert.Equal(t, uri, diags.URI)
+
+	var found bool
+	for _, d := range diags.Diagnostics {
+		if strings.Contains(d.Message, "nope") {
+			found = true
+			assert.Equal(t, 13, d.Range.Start.Line)
+			assert.Equal(t, 2, d.Range.Start.Character)
+		}
+	}
+	assert.True(t, found, "%+v", diags.Diagnostics)
+}
+
+func TestServerParseDiagnostics(t *testing.T) {
+	uri := "file:///config.yaml"
+
+	var client testClient
+	client.send("initialize", map[string]interface{}{"capabilities": map[string]interface{}{}})
+	client.notify("textDocument/didOpen", map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": uri, "text": "input:\n  generate:\n    mapping: 'root = now()'\n"},
+	})
+	client.notify("textDocument/didChange", map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": uri, "version": 2},
+		"contentChanges": []interface{}{
+			map[string]interface{}{
+				"range": map[string]interface{}{
+					"start": map[string]interface{}{"line": 2, "character": 13},
+					"end":   map[string]interface{}{"line": 2, "character": 14},
+				},
+				"text": "[",
+			},
+		},
+	})
+	completionID := client.send("textDocument/completion", positionParams(uri, 2, 4))
+
+	var out bytes.Buffer
+	require.NoError(t, lsp.NewServer().Serve(&client.buf, &out))
+
+	results, notifications := readMessages(t, &out)
+	require.Len(t, notifications, 2)
+	assert.JSONEq(t, `{"uri":"file:///config.yaml","diagnostics":[]}`, string(notifications[0].Params))
+	assert.Contains(t, string(notifications[1].Params), `did not find expected`)
+	assert.Contains(t, string(notifications[1].Params), `"severity":1`)
+
+	assert.Subset(t, completionLabels(t, results[completionID]), []string{"mapping", "interval"})
+}
+
+func TestServerNotInitialized(t *testing.T) {
+	var client testClient
+	id := client.send("textDocument/hover", positionParams("file:///foo.yaml", 0, 0))
+	client.notify("exit", nil)
+
+	var out bytes.Buffer
+	require.Equal(t, lsp.ErrExitWithoutShutdown, lsp.NewServer().Serve(&client.buf, &out))
+
+	results, _ := readMessages(t, &out)
+	require.NotNil(t, results[id].Error)
+	assert.Equal(t, -32002, results[id].Error.Code)
+}
@@ -0,0 +1,124 @@
+package lsp
+
+import (
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/config"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+func rootSpec() docs.FieldSpec {
+	return docs.FieldObject("", "").WithChildren(config.Spec()...)
+}
+
+// componentDocs returns the specs of all components registered for a type.
+func componentDocs(t docs.Type) []docs.ComponentSpec {
+	switch t {
+	case docs.TypeBuffer:
+		return bundle.AllBuffers.Docs()
+	case docs.TypeCache:
+		return bundle.AllCaches.Docs()
+	case docs.TypeInput:
+		return bundle.AllInputs.Docs()
+	case docs.TypeMetrics:
+		return bundle.AllMetrics.Docs()
+	case docs.TypeOutput:
+		return bundle.AllOutputs.Docs()
+	case docs.TypeProcessor:
+		return bundle.AllProcessors.Docs()
+	case docs.TypeRateLimit:
+		return bundle.AllRateLimits.Docs()
+	case docs.TypeTracer:
+		return bundle.AllTracers.Docs()
+	}
+	return nil
+}
+
+func componentSpec(t docs.Type, name string) (docs.ComponentSpec, bool) {
+	for _, c := range componentDocs(t) {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return docs.ComponentSpec{}, false
+}
+
+// componentSlot returns the component type of a field when it is a scalar
+// component, where the keys of its mapping are component names along with the
+// fields reserved for that type of component.
+func componentSlot(f docs.FieldSpec) (docs.Type, bool) {
+	if f.Kind != docs.KindScalar && f.Kind != "" {
+		return "", false
+	}
+	return f.Type.IsCoreComponent()
+}
+
+// reservedFields returns the fields reserved for a type of component that are
+// worth suggesting, which excludes the legacy type and plugin fields.
+func reservedFields(t docs.Type) docs.FieldSpecs {
+	var fields docs.FieldSpecs
+	for name, f := range docs.ReservedFieldsByType(t) {
+		if name == "type" || name == "plugin" {
+			continue
+		}
+		f.Name = name
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// childSpec resolves the spec of a key, or a sequence element, of a field.
+func childSpec(f docs.FieldSpec, key string) (docs.FieldSpec, bool) {
+	switch f.Kind {
+	case docs.KindArray:
+		if key != seqMarker {
+			return f, false
+		}
+		return f.Scalar(), true
+	case docs.Kind2DArray:
+		if key != seqMarker {
+			return f, false
+		}
+		return f.Array(), true
+	case docs.KindMap:
+		return f.Scalar(), true
+	}
+
+	if t, ok := componentSlot(f); ok {
+		if key == "type" || key == "plugin" {
+			return f, false
+		}
+		if reserved, exists := docs.ReservedFieldsByType(t)[key]; exists {
+			reserved.Name = key
+			return reserved, true
+		}
+		c, exists := componentSpec(t, key)
+		if !exists {
+			return f, false
+		}
+		conf := c.Config
+		conf.Name = key
+		if conf.Description == "" {
+			conf.Description = c.Summary
+		}
+		return conf, true
+	}
+
+	for _, child := range f.Children {
+		if child.Name == key {
+			return child, true
+		}
+	}
+	return f, false
+}
+
+// resolveSpec walks a cursor path from the root of the config spec.
+func resolveSpec(path []string) (docs.FieldSpec, bool) {
+	f := rootSpec()
+	for _, key := range path {
+		var ok bool
+		if f, ok = childSpec(f, key); !ok {
+			return f, false
+		}
+	}
+	return f, true
+}
@@ -0,0 +1,153 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/config"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+	ifilepath "github.com/benthosdev/benthos/v4/internal/filepath"
+)
+
+// migrateFile applies the registered migrations to a config file, returning
+// the migrated config along with the changes made.
+func migrateFile(path string) ([]byte, []docs.MigrationChange, error) {
+	configBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal(configBytes, &node); err != nil {
+		return nil, nil, err
+	}
+	if node.Kind == 0 {
+		return configBytes, nil, nil
+	}
+
+	changes, err := docs.MigrateYAML(nil, config.Spec(), &node)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(changes) == 0 {
+		return configBytes, nil, nil
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(detectIndent(configBytes))
+	if err := enc.Encode(&node); err != nil {
+		return nil, nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, nil, err
+	}
+	return buf.Bytes(), changes, nil
+}
+
+// detectIndent returns the smallest indentation of the lines of a YAML
+// document in order for migrated configs to keep their original style.
+func detectIndent(configBytes []byte) int {
+	indent := 0
+	for _, line := range strings.Split(string(configBytes), "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if n := len(line) - len(trimmed); n > 0 && (indent == 0 || n < indent) {
+			indent = n
+		}
+	}
+	if indent < 2 {
+		return 2
+	}
+	return indent
+}
+
+func migrateCliCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "migrate",
+		Usage: "Rewrite deprecated components and fields of configs",
+		Description: `
+Applies migrations to configs that replace deprecated components and fields
+with their equivalent replacements, such as the deprecated sql output with
+either the sql_insert or sql_raw output. Comments within the configs are
+preserved. Each change made is printed to stderr.
+
+By default the migrated config is printed to stdout, with the --in-place flag
+the migrated configs are instead written back to their files:
+
+  benthos -c ./config.yaml migrate > ./migrated.yaml
+  benthos migrate --in-place ./configs/*.yaml
+  benthos migrate --in-place ./configs/...
+
+If a path ends with '...' then Benthos will walk the target and migrate any
+files with the .yaml or .yml extension.`[1:],
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "in-place",
+				Value: false,
+				Usage: "Write migrated configs back to their files rather than printing them.",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			targets, err := ifilepath.GlobsAndSuperPaths(c.Args().Slice(), "yaml", "yml")
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Migrate paths error: %v\n", err)
+				os.Exit(1)
+			}
+			if conf := c.String("config"); len(conf) > 0 {
+				targets = append(targets, conf)
+			}
+			if len(targets) == 0 {
+				fmt.Fprintln(os.Stderr, "At least one config must be specified either with --config or as an argument")
+				os.Exit(1)
+			}
+
+			inPlace := c.Bool("in-place")
+			if !inPlace && len(targets) > 1 {
+				fmt.Fprintln(os.Stderr, "Multiple configs can only be migrated with the --in-place flag")
+				os.Exit(1)
+			}
+
+			failed := false
+			for _, target := range targets {
+				migrated, changes, err := migrateFile(target)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "%v: %v\n", target, red(err))
+					failed = true
+					continue
+				}
+				for _, change := range changes {
+					fmt.Fprintf(os.Stderr, "%v: line %v: %v\n", target, change.Line, change.What)
+				}
+				if !inPlace {
+					_, _ = os.Stdout.Write(migrated)
+					continue
+				}
+				if len(changes) == 0 {
+					continue
+				}
+				info, err := os.Stat(target)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "%v: %v\n", target, red(err))
+					failed = true
+					continue
+				}
+				if err := os.WriteFile(target, migrated, info.Mode().Perm()); err != nil {
+					fmt.Fprintf(os.Stderr, "%v: %v\n", target, red(err))
+					failed = true
+				}
+			}
+			if failed {
+				os.Exit(1)
+			}
+			return nil
+		},
+	}
+}
@@ -11,6 +11,8 @@ import (
 
 	"github.com/benthosdev/benthos/v4/internal/bloblang/parser"
 	"github.com/benthosdev/benthos/v4/internal/cli/blobl"
+	"github.com/benthosdev/benthos/v4/internal/cli/explain"
+	"github.com/benthosdev/benthos/v4/internal/cli/lsp"
 	"github.com/benthosdev/benthos/v4/internal/cli/studio"
 	clitemplate "github.com/benthosdev/benthos/v4/internal/cli/template"
 	"github.com/benthosdev/benthos/v4/internal/cli/test"
@@ -230,6 +232,7 @@ Either run Benthos as a stream processor or choose a command:
 			os.Exit(cmdService(
 				c.String("config"),
 				c.StringSlice("resources"),
+				c.StringSlice("templates"),
 				c.StringSlice("set"),
 				c.String("log.level"),
 				!c.Bool("chilled"),
@@ -251,7 +254,8 @@ variables have been resolved:
 
   benthos -c ./config.yaml echo | less`[1:],
 				Action: func(c *cli.Context) error {
-					confReader := readConfig(c.String("config"), false, c.StringSlice("resources"), nil, c.StringSlice("set"))
+					confReader := readConfig(c.String("config"), false, c.StringSlice("resources"), nil, c.StringSlice("set"),
+						config.OptSetSecretsMode(config.SecretsPlaceholder))
 					conf := config.New()
 					if _, err := confReader.Read(&conf); err != nil {
 						fmt.Fprintf(os.Stderr, "Configuration file read error: %v\n", err)
@@ -278,6 +282,9 @@ variables have been resolved:
 				},
 			},
 			lintCliCommand(),
+			migrateCliCommand(),
+			explain.CliCommand(),
+			lsp.CliCommand(),
 			{
 				Name:  "streams",
 				Usage: "Run Benthos in streams mode",
@@ -308,6 +315,7 @@ https://benthos.dev/docs/guides/streams_mode/about`[1:],
 					os.Exit(cmdService(
 						c.String("config"),
 						c.StringSlice("resources"),
+						c.StringSlice("templates"),
 						c.StringSlice("set"),
 						c.String("log.level"),
 						!c.Bool("chilled"),
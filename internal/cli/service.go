@@ -37,7 +37,7 @@ type stoppable interface {
 
 //------------------------------------------------------------------------------
 
-func readConfig(path string, streamsMode bool, resourcesPaths, streamsPaths, overrides []string) *config.Reader {
+func readConfig(path string, streamsMode bool, resourcesPaths, streamsPaths, overrides []string, extraOpts ...config.OptFunc) *config.Reader {
 	if path == "" {
 		// Iterate default config paths
 		for _, dpath := range []string{
@@ -59,6 +59,7 @@ func readConfig(path string, streamsMode bool, resourcesPaths, streamsPaths, ove
 	if streamsMode {
 		opts = append(opts, config.OptSetStreamPaths(streamsPaths...))
 	}
+	opts = append(opts, extraOpts...)
 	return config.NewReader(path, resourcesPaths, opts...)
 }
 
@@ -222,13 +223,14 @@ func initNormalMode(
 func cmdService(
 	confPath string,
 	resourcesPaths []string,
+	templatesPaths []string,
 	confOverrides []string,
 	overrideLogLevel string,
 	strict, watching, enableStreamsAPI bool,
 	streamsMode bool,
 	streamsPaths []string,
 ) int {
-	confReader := readConfig(confPath, streamsMode, resourcesPaths, streamsPaths, confOverrides)
+	confReader := readConfig(confPath, streamsMode, resourcesPaths, streamsPaths, confOverrides, config.OptSetTemplatePaths(templatesPaths...))
 	conf := config.New()
 
 	lints, err := confReader.Read(&conf)
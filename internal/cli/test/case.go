@@ -1,9 +1,12 @@
 package test
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	yaml "gopkg.in/yaml.v3"
 
@@ -62,15 +65,25 @@ func (i *InputPart) UnmarshalYAML(value *yaml.Node) error {
 	return nil
 }
 
+// OutputExpectations defines the batches expected to be written to a captured
+// output of a stream test case.
+type OutputExpectations struct {
+	OutputBatches [][]ConditionsMap `yaml:"output_batches"`
+}
+
 // Case contains a definition of a single Benthos config test case.
 type Case struct {
-	Name             string               `yaml:"name"`
-	Environment      map[string]string    `yaml:"environment"`
-	TargetProcessors string               `yaml:"target_processors"`
-	TargetMapping    string               `yaml:"target_mapping"`
-	Mocks            map[string]yaml.Node `yaml:"mocks"`
-	InputBatch       []InputPart          `yaml:"input_batch"`
-	OutputBatches    [][]ConditionsMap    `yaml:"output_batches"`
+	Name             string                        `yaml:"name"`
+	Environment      map[string]string             `yaml:"environment"`
+	TargetProcessors string                        `yaml:"target_processors"`
+	TargetMapping    string                        `yaml:"target_mapping"`
+	TargetStream     bool                          `yaml:"target_stream"`
+	Mocks            map[string]yaml.Node          `yaml:"mocks"`
+	InputBatch       []InputPart                   `yaml:"input_batch"`
+	OutputBatches    [][]ConditionsMap             `yaml:"output_batches"`
+	Outputs          map[string]OutputExpectations `yaml:"outputs"`
+	Snapshot         string                        `yaml:"snapshot"`
+	Property         *PropertyTest                 `yaml:"property"`
 
 	line int
 }
@@ -88,9 +101,13 @@ func NewCase() Case {
 		Environment:      map[string]string{},
 		TargetProcessors: "/pipeline/processors",
 		TargetMapping:    "",
+		TargetStream:     false,
 		Mocks:            map[string]yaml.Node{},
 		InputBatch:       []InputPart{},
 		OutputBatches:    [][]ConditionsMap{},
+		Outputs:          map[string]OutputExpectations{},
+		Snapshot:         "",
+		Property:         nil,
 	}
 }
 
@@ -127,34 +144,144 @@ func (c CaseFailure) String() string {
 type ProcProvider interface {
 	Provide(jsonPtr string, environment map[string]string, mocks map[string]yaml.Node) ([]iprocessor.V1, error)
 	ProvideBloblang(path string) ([]iprocessor.V1, error)
+	ProvideStream(environment map[string]string, mocks map[string]yaml.Node) (StreamExecutor, error)
 }
 
-func (c *Case) executeFrom(dir string, provider ProcProvider) (failures []CaseFailure, err error) {
-	var procSet []iprocessor.V1
+func (c *Case) executeFrom(dir string, provider ProcProvider, opts executeOpts) (failures []CaseFailure, err error) {
+	if c.Property != nil {
+		return c.executePropertyFrom(dir, provider, opts)
+	}
+	if c.TargetStream {
+		return c.executeStreamFrom(dir, provider, opts)
+	}
+	if len(c.Outputs) > 0 {
+		return nil, errors.New("outputs can only be specified when target_stream is enabled")
+	}
+
+	procSet, err := c.provideProcessors(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	inputMsg, err := c.inputBatch(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	outputBatches, result := processor.ExecuteAll(procSet, inputMsg)
+	if result != nil {
+		failures = append(failures, c.failure(fmt.Sprintf("processors resulted in error: %v", result)))
+	}
+
+	// When a snapshot is recorded the batches are only checked against explicit
+	// expectations if output_batches is also specified.
+	if c.Snapshot == "" || len(c.OutputBatches) > 0 {
+		failures = append(failures, c.checkBatches(dir, "", c.OutputBatches, outputBatches)...)
+	}
+
+	snapFailures, err := c.snapshotFrom(dir, opts, outputBatches, nil)
+	if err != nil {
+		return nil, err
+	}
+	failures = append(failures, snapFailures...)
+	return
+}
+
+func (c *Case) executeStreamFrom(dir string, provider ProcProvider, opts executeOpts) (failures []CaseFailure, err error) {
 	if c.TargetMapping != "" {
-		if procSet, err = provider.ProvideBloblang(c.TargetMapping); err != nil {
-			return nil, fmt.Errorf("failed to initialise Bloblang mapping '%v': %v", c.TargetMapping, err)
+		return nil, errors.New("target_mapping cannot be specified when target_stream is enabled")
+	}
+
+	executor, err := provider.ProvideStream(c.Environment, c.Mocks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialise stream: %v", err)
+	}
+
+	outputNames := map[string]struct{}{}
+	for _, name := range executor.Outputs() {
+		outputNames[name] = struct{}{}
+	}
+	for name := range c.Outputs {
+		if _, exists := outputNames[name]; !exists {
+			return nil, fmt.Errorf("output '%v' was not found, captured outputs are: %v", name, strings.Join(executor.Outputs(), ", "))
 		}
-	} else {
-		if procSet, err = provider.Provide(c.TargetProcessors, c.Environment, c.Mocks); err != nil {
-			return nil, fmt.Errorf("failed to initialise processors '%v': %v", c.TargetProcessors, err)
+	}
+
+	inputMsg, err := c.inputBatch(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	captured, result := executor.Execute(inputMsg)
+	if result != nil {
+		failures = append(failures, c.failure(fmt.Sprintf("stream resulted in error: %v", result)))
+	}
+
+	// When expectations are defined per output, or a snapshot is recorded, the
+	// batches of all outputs are only checked if output_batches is also
+	// specified.
+	if (len(c.Outputs) == 0 && c.Snapshot == "") || len(c.OutputBatches) > 0 {
+		all := make([]*message.Batch, len(captured))
+		for i, b := range captured {
+			all[i] = b.Batch
 		}
+		failures = append(failures, c.checkBatches(dir, "", c.OutputBatches, all)...)
 	}
 
-	reportFailure := func(reason string) {
-		failures = append(failures, CaseFailure{
-			Name:     c.Name,
-			TestLine: c.line,
-			Reason:   reason,
-		})
+	outputs := make([]string, 0, len(c.Outputs))
+	for name := range c.Outputs {
+		outputs = append(outputs, name)
+	}
+	sort.Strings(outputs)
+
+	for _, name := range outputs {
+		var batches []*message.Batch
+		for _, b := range captured {
+			if b.Output == name {
+				batches = append(batches, b.Batch)
+			}
+		}
+		failures = append(failures, c.checkBatches(dir, fmt.Sprintf("output %v: ", name), c.Outputs[name].OutputBatches, batches)...)
+	}
+
+	snapFailures, err := c.snapshotFrom(dir, opts, nil, captured)
+	if err != nil {
+		return nil, err
 	}
+	failures = append(failures, snapFailures...)
+	return
+}
+
+// snapshotFrom either records the resulting batches of a test case into its
+// snapshot file or compares them against it, depending on whether snapshots are
+// being updated.
+func (c *Case) snapshotFrom(dir string, opts executeOpts, outputBatches []*message.Batch, captured []CapturedBatch) ([]CaseFailure, error) {
+	if c.Snapshot == "" {
+		return nil, nil
+	}
+	if opts.updateSnapshots {
+		if err := c.writeSnapshot(dir, outputBatches, captured); err != nil {
+			return nil, fmt.Errorf("failed to write snapshot '%v': %v", c.Snapshot, err)
+		}
+		return nil, nil
+	}
+	return c.checkSnapshot(dir, outputBatches, captured), nil
+}
 
+func (c *Case) failure(reason string) CaseFailure {
+	return CaseFailure{
+		Name:     c.Name,
+		TestLine: c.line,
+		Reason:   reason,
+	}
+}
+
+func (c *Case) inputBatch(dir string) (*message.Batch, error) {
 	parts := make([]*message.Part, len(c.InputBatch))
 	for i, v := range c.InputBatch {
-		var content string
-		if content, err = v.getContent(dir); err != nil {
-			err = fmt.Errorf("failed to create mock input %v: %w", i, err)
-			return
+		content, err := v.getContent(dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create mock input %v: %w", i, err)
 		}
 		part := message.NewPart([]byte(content))
 		for k, v := range v.Metadata {
@@ -165,21 +292,26 @@ func (c *Case) executeFrom(dir string, provider ProcProvider) (failures []CaseFa
 
 	inputMsg := message.QuickBatch(nil)
 	inputMsg.SetAll(parts)
-	outputBatches, result := processor.ExecuteAll(procSet, inputMsg)
-	if result != nil {
-		reportFailure(fmt.Sprintf("processors resulted in error: %v", result))
+	return inputMsg, nil
+}
+
+// checkBatches compares resulting batches against expected conditions, with
+// each failure reason prefixed by a given string.
+func (c *Case) checkBatches(dir, prefix string, expected [][]ConditionsMap, outputBatches []*message.Batch) (failures []CaseFailure) {
+	reportFailure := func(reason string) {
+		failures = append(failures, c.failure(prefix+reason))
 	}
 
-	if lExp, lAct := len(c.OutputBatches), len(outputBatches); lAct < lExp {
+	if lExp, lAct := len(expected), len(outputBatches); lAct < lExp {
 		reportFailure(fmt.Sprintf("wrong batch count, expected %v, got %v", lExp, lAct))
 	}
 
 	for i, v := range outputBatches {
-		if len(c.OutputBatches) <= i {
+		if len(expected) <= i {
 			reportFailure(fmt.Sprintf("unexpected batch: %s", message.GetAllBytes(v)))
 			continue
 		}
-		expectedBatch := c.OutputBatches[i]
+		expectedBatch := expected[i]
 		if lExp, lAct := len(expectedBatch), v.Len(); lExp != lAct {
 			reportFailure(fmt.Sprintf("mismatch of output batch %v message counts, expected %v, got %v", i, lExp, lAct))
 		}
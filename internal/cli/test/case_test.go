@@ -35,6 +35,10 @@ func (m mockProvider) ProvideBloblang(name string) ([]iprocessor.V1, error) {
 	return nil, errors.New("mapping not found")
 }
 
+func (m mockProvider) ProvideStream(env map[string]string, mocks map[string]yaml.Node) (StreamExecutor, error) {
+	return nil, errors.New("streams not supported")
+}
+
 func TestCase(t *testing.T) {
 	color.NoColor = true
 
@@ -191,7 +195,7 @@ output_batches:
 			if err = yaml.Unmarshal([]byte(test.conf), &c); err != nil {
 				tt.Fatal(err)
 			}
-			fails, err := c.executeFrom("", provider)
+			fails, err := c.executeFrom("", provider, executeOpts{})
 			if err != nil {
 				tt.Fatal(err)
 			}
@@ -234,7 +238,7 @@ output_batches:
   - content_equals: hello world FOO BAR BAZ
 `), &c))
 
-	fails, err := c.executeFrom(tmpDir, provider)
+	fails, err := c.executeFrom(tmpDir, provider, executeOpts{})
 	require.NoError(t, err)
 
 	assert.Equal(t, []CaseFailure(nil), fails)
@@ -249,7 +253,7 @@ output_batches:
   - content_equals: hello world FOO BAR BAZ
 `), &c))
 
-	fails, err = c.executeFrom(tmpDir, provider)
+	fails, err = c.executeFrom(tmpDir, provider, executeOpts{})
 	require.NoError(t, err)
 
 	assert.Equal(t, []CaseFailure{
@@ -293,7 +297,7 @@ output_batches:
   - file_equals: "./inner/uppercased.txt"
 `), &c))
 
-	fails, err := c.executeFrom(tmpDir, provider)
+	fails, err := c.executeFrom(tmpDir, provider, executeOpts{})
 	require.NoError(t, err)
 
 	assert.Equal(t, []CaseFailure(nil), fails)
@@ -308,7 +312,7 @@ output_batches:
   - file_equals: "./not_uppercased.txt"
 `), &c))
 
-	fails, err = c.executeFrom(tmpDir, provider)
+	fails, err = c.executeFrom(tmpDir, provider, executeOpts{})
 	require.NoError(t, err)
 
 	assert.Equal(t, []CaseFailure{
@@ -1,6 +1,7 @@
 package test
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 
@@ -22,6 +23,8 @@ fail the process will report the errors and exit with a status code 1.
   benthos test ./path/to/configs/...
   benthos test ./foo_configs/*.yaml ./bar_configs/*.yaml
   benthos test ./foo.yaml
+  benthos test --update-snapshots ./foo.yaml
+  benthos test --coverage --junit ./report.xml ./path/to/configs/...
 
 For more information check out the docs at:
 https://benthos.dev/docs/configuration/unit_testing`[1:],
@@ -31,6 +34,30 @@ https://benthos.dev/docs/configuration/unit_testing`[1:],
 				Value: "",
 				Usage: "allow components to write logs at a provided level to stdout.",
 			},
+			&cli.BoolFlag{
+				Name:  "update-snapshots",
+				Value: false,
+				Usage: "write the results of test cases with a snapshot to their snapshot files rather than comparing them.",
+			},
+			&cli.Int64Flag{
+				Name:  "seed",
+				Usage: "the seed used to generate the inputs of property tests, which allows a failed property to be reproduced.",
+			},
+			&cli.BoolFlag{
+				Name:  "coverage",
+				Value: false,
+				Usage: "print a report of the processors, switch cases and Bloblang match cases executed by the tests.",
+			},
+			&cli.StringFlag{
+				Name:  "coverage-json",
+				Value: "",
+				Usage: "write a coverage report in JSON format to a file path.",
+			},
+			&cli.StringFlag{
+				Name:  "junit",
+				Value: "",
+				Usage: "write the test results in JUnit XML format to a file path.",
+			},
 		},
 		Action: func(c *cli.Context) error {
 			if len(c.StringSlice("set")) > 0 {
@@ -43,18 +70,65 @@ https://benthos.dev/docs/configuration/unit_testing`[1:],
 				fmt.Printf("Failed to resolve resource glob pattern: %v\n", err)
 				os.Exit(1)
 			}
+			logger := log.Noop()
 			if logLevel := c.String("log"); len(logLevel) > 0 {
 				logConf := log.NewConfig()
 				logConf.LogLevel = logLevel
-				logger, err := log.NewV2(os.Stdout, logConf)
-				if err != nil {
+				if logger, err = log.NewV2(os.Stdout, logConf); err != nil {
 					fmt.Printf("Failed to init logger: %v\n", err)
 					os.Exit(1)
 				}
-				if RunAll(c.Args().Slice(), testSuffix, true, logger, resourcesPaths) {
-					os.Exit(0)
+			}
+
+			var opts []ExecuteOpt
+			if c.Bool("update-snapshots") {
+				opts = append(opts, OptUpdateSnapshots())
+			}
+			if c.IsSet("seed") {
+				opts = append(opts, OptPropertySeed(c.Int64("seed")))
+			}
+			var coverage *Coverage
+			if c.Bool("coverage") || c.String("coverage-json") != "" {
+				coverage = NewCoverage()
+				opts = append(opts, OptRecordCoverage(coverage))
+			}
+			var results *Results
+			if c.String("junit") != "" {
+				results = &Results{}
+				opts = append(opts, OptRecordResults(results))
+			}
+
+			success := RunAll(c.Args().Slice(), testSuffix, true, logger, resourcesPaths, opts...)
+
+			if coverage != nil {
+				report := coverage.Report()
+				if c.Bool("coverage") {
+					fmt.Println("\nCoverage:")
+					fmt.Println("")
+					if err := report.WriteText(os.Stdout); err != nil {
+						fmt.Fprintf(os.Stderr, "Failed to write coverage report: %v\n", err)
+						os.Exit(1)
+					}
+				}
+				if path := c.String("coverage-json"); path != "" {
+					reportBytes, err := json.MarshalIndent(report, "", "  ")
+					if err == nil {
+						err = os.WriteFile(path, reportBytes, 0o644)
+					}
+					if err != nil {
+						fmt.Fprintf(os.Stderr, "Failed to write coverage report: %v\n", err)
+						os.Exit(1)
+					}
+				}
+			}
+			if path := c.String("junit"); path != "" {
+				if err := writeJUnitFile(path, results); err != nil {
+					fmt.Fprintf(os.Stderr, "Failed to write JUnit report: %v\n", err)
+					os.Exit(1)
 				}
-			} else if RunAll(c.Args().Slice(), testSuffix, true, log.Noop(), resourcesPaths) {
+			}
+
+			if success {
 				os.Exit(0)
 			}
 			os.Exit(1)
@@ -62,3 +136,15 @@ https://benthos.dev/docs/configuration/unit_testing`[1:],
 		},
 	}
 }
+
+func writeJUnitFile(path string, results *Results) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := results.WriteJUnit(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -90,7 +90,7 @@ func GetTestTargets(targetPaths []string, testSuffix string) (map[string]Definit
 func lintTarget(path, testSuffix string) ([]string, error) {
 	confPath, _ := GetPathPair(path, testSuffix)
 	dummyConf := config.New()
-	lints, err := config.ReadFileLinted(confPath, false, &dummyConf)
+	lints, err := config.ReadFileLinted(confPath, false, config.SecretsPlaceholder, &dummyConf)
 	if err != nil {
 		return nil, err
 	}
@@ -102,7 +102,9 @@ func lintTarget(path, testSuffix string) ([]string, error) {
 // RunAll executes the test command for a slice of paths. The path can either be
 // a config file, a config files test definition file, a directory, or the
 // wildcard pattern './...'.
-func RunAll(paths []string, testSuffix string, lint bool, logger log.Modular, resourcesPaths []string) bool {
+func RunAll(paths []string, testSuffix string, lint bool, logger log.Modular, resourcesPaths []string, opts ...ExecuteOpt) bool {
+	eOpts := newExecuteOpts(opts)
+
 	targets, err := GetTestTargets(paths, testSuffix)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to obtain test targets: %v\n", err)
@@ -135,10 +137,18 @@ func RunAll(paths []string, testSuffix string, lint bool, logger log.Modular, re
 				return false
 			}
 		}
-		if failCases, err = targets[target].Execute(target, resourcesPaths, logger); err != nil {
+		if failCases, err = targets[target].Execute(target, resourcesPaths, logger, opts...); err != nil {
 			fmt.Fprintf(os.Stderr, "Failed to execute test target '%v': %v\n", target, err)
 			return false
 		}
+		if eOpts.results != nil {
+			eOpts.results.add(TargetResult{
+				Path:     target,
+				Cases:    targets[target].Cases,
+				Lints:    lints,
+				Failures: failCases,
+			})
+		}
 		if len(lints) > 0 || len(failCases) > 0 {
 			fails = append(fails, failedTarget{
 				target: target,
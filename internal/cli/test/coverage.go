@@ -0,0 +1,480 @@
+package test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/cli/explain"
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	iprocessor "github.com/benthosdev/benthos/v4/internal/component/processor"
+	"github.com/benthosdev/benthos/v4/internal/config"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/old/processor"
+)
+
+// Coverage records which processors, switch cases and Bloblang match cases of
+// tested config files are executed across all test cases.
+type Coverage struct {
+	mut     sync.Mutex
+	targets map[string]*TargetCoverage
+}
+
+// NewCoverage creates an empty coverage record.
+func NewCoverage() *Coverage {
+	return &Coverage{
+		targets: map[string]*TargetCoverage{},
+	}
+}
+
+// Target returns the coverage record of a config file, which is created from
+// the config file and any resource files when it does not yet exist.
+func (c *Coverage) Target(path string, resourcesPaths []string) (*TargetCoverage, error) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	path = filepath.Clean(path)
+	if t, exists := c.targets[path]; exists {
+		return t, nil
+	}
+
+	t := &TargetCoverage{
+		path:       path,
+		stats:      metrics.NewLocal(),
+		matchCases: map[matchCaseKey]*matchCaseRecord{},
+		processed:  map[componentKey]int64{},
+		captured:   map[string]int{},
+	}
+	for _, p := range append([]string{path}, resourcesPaths...) {
+		if err := t.addComponents(p); err != nil {
+			return nil, err
+		}
+	}
+	c.targets[path] = t
+	return t, nil
+}
+
+// Report returns a summary of the coverage of all config files.
+func (c *Coverage) Report() CoverageReport {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	paths := make([]string, 0, len(c.targets))
+	for k := range c.targets {
+		paths = append(paths, k)
+	}
+	sort.Strings(paths)
+
+	var r CoverageReport
+	for _, p := range paths {
+		r.Targets = append(r.Targets, c.targets[p].report())
+	}
+	return r
+}
+
+//------------------------------------------------------------------------------
+
+type matchCaseKey struct {
+	source  string
+	pattern string
+	line    int
+	column  int
+}
+
+type matchCaseRecord struct {
+	component  string
+	executions int64
+}
+
+// TargetCoverage records the coverage of a single config file.
+type TargetCoverage struct {
+	path   string
+	graphs []*explain.Graph
+	stats  *metrics.Local
+
+	mut        sync.Mutex
+	matchCases map[matchCaseKey]*matchCaseRecord
+	matchOrder []matchCaseKey
+	processed  map[componentKey]int64
+	captured   map[string]int
+}
+
+func componentDescription(c *docs.WalkedYAMLComponent) string {
+	if c == nil {
+		return ""
+	}
+	desc := string(c.Type) + " " + c.Name
+	if c.Label != "" {
+		desc += " label=" + c.Label
+	}
+	return desc
+}
+
+// addComponents reads the components of a config file as well as the cases of
+// any match expressions within their Bloblang fields, so that components that
+// are never executed are also reported.
+func (t *TargetCoverage) addComponents(path string) error {
+	confBytes, _, err := config.ReadFileEnvSwap(path, config.SecretsPlaceholder)
+	if err != nil {
+		return fmt.Errorf("failed to read config file '%v': %v", path, err)
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal(confBytes, &node); err != nil {
+		return fmt.Errorf("failed to parse config file '%v': %v", path, err)
+	}
+	if node.Kind == 0 {
+		return nil
+	}
+
+	g, err := explain.FromYAML(nil, &node)
+	if err != nil {
+		return fmt.Errorf("failed to resolve components of config file '%v': %v", path, err)
+	}
+	t.graphs = append(t.graphs, g)
+
+	docs.YAMLWalker{
+		OnField: func(f docs.WalkedYAMLField) {
+			if !f.Spec.Bloblang || f.Value.Kind != yaml.ScalarNode {
+				return
+			}
+			component := componentDescription(f.Component)
+			env := bloblang.GlobalEnvironment().Deactivated().WithMatchCaseHook(func(c bloblang.MatchCase) func() {
+				t.matchCase(c, component)
+				return func() {}
+			})
+			_, _ = env.NewMapping(f.Value.Value)
+		},
+	}.WalkFields(config.Spec(), &node)
+	return nil
+}
+
+func (t *TargetCoverage) matchCase(c bloblang.MatchCase, component string) *matchCaseRecord {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	k := matchCaseKey{source: c.Source, pattern: c.Pattern, line: c.Line, column: c.Column}
+	r, exists := t.matchCases[k]
+	if !exists {
+		r = &matchCaseRecord{component: component}
+		t.matchCases[k] = r
+		t.matchOrder = append(t.matchOrder, k)
+	}
+	return r
+}
+
+func (t *TargetCoverage) metrics() *metrics.Namespaced {
+	return metrics.NewNamespaced(t.stats)
+}
+
+func (t *TargetCoverage) bloblEnvironment() *bloblang.Environment {
+	return bloblang.GlobalEnvironment().WithMatchCaseHook(func(c bloblang.MatchCase) func() {
+		r := t.matchCase(c, "")
+		return func() {
+			t.mut.Lock()
+			r.executions++
+			t.mut.Unlock()
+		}
+	})
+}
+
+// environment returns a component environment where each processor is wrapped
+// in order to count the messages it receives, keyed by the path and label that
+// it was initialised with.
+func (t *TargetCoverage) environment() *bundle.Environment {
+	env := bundle.GlobalEnvironment.Clone()
+	for _, spec := range bundle.GlobalEnvironment.ProcessorDocs() {
+		_ = env.ProcessorAdd(func(conf processor.Config, mgr bundle.NewManagement) (iprocessor.V1, error) {
+			proc, err := bundle.GlobalEnvironment.ProcessorInit(conf, mgr)
+			if err != nil {
+				// The error is annotated with the component again by the
+				// wrapping environment.
+				if uErr := errors.Unwrap(err); uErr != nil {
+					err = uErr
+				}
+				return nil, err
+			}
+			key := componentKey{label: mgr.Label()}
+			if p := mgr.Path(); len(p) > 0 {
+				key.path = "root." + query.SliceToDotPath(p...)
+			}
+			return &coveredProcessor{V1: proc, target: t, key: key}, nil
+		}, spec)
+	}
+	return env
+}
+
+func (t *TargetCoverage) processorExecuted(key componentKey, n int) {
+	t.mut.Lock()
+	t.processed[key] += int64(n)
+	t.mut.Unlock()
+}
+
+// coveredProcessor records the messages received by a processor.
+type coveredProcessor struct {
+	iprocessor.V1
+
+	target *TargetCoverage
+	key    componentKey
+}
+
+func (c *coveredProcessor) ProcessMessage(msg *message.Batch) ([]*message.Batch, error) {
+	c.target.processorExecuted(c.key, msg.Len())
+	return c.V1.ProcessMessage(msg)
+}
+
+func (t *TargetCoverage) outputCaptured(path string, n int) {
+	t.mut.Lock()
+	t.captured[path] += n
+	t.mut.Unlock()
+}
+
+type componentKey struct {
+	path  string
+	label string
+}
+
+func (t *TargetCoverage) report() TargetCoverageReport {
+	sent := map[componentKey]int64{}
+	for k, v := range t.stats.GetCounters() {
+		name, tagNames, tagValues := metrics.ReverseLabelledPath(k)
+		var key componentKey
+		for i, tag := range tagNames {
+			switch tag {
+			case "path":
+				key.path = tagValues[i]
+			case "label":
+				key.label = tagValues[i]
+			}
+		}
+		if name == "output_sent" {
+			sent[key] += v
+		}
+	}
+
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	processed := t.processed
+
+	var executed func(c *explain.Component) bool
+	executed = func(c *explain.Component) bool {
+		key := componentKey{path: c.MetricPath, label: c.Label}
+		if processed[key] > 0 || sent[key] > 0 || t.captured[c.ID()] > 0 {
+			return true
+		}
+		for _, child := range c.Children {
+			if executed(child) {
+				return true
+			}
+		}
+		return false
+	}
+
+	r := TargetCoverageReport{Path: t.path}
+	for _, g := range t.graphs {
+		g.Walk(func(_ int, c *explain.Component) {
+			if c.Template {
+				return
+			}
+			if c.Type == docs.TypeProcessor {
+				r.Processors = append(r.Processors, ComponentCoverage{
+					Path:       c.MetricPath,
+					Label:      c.Label,
+					Name:       c.Name,
+					Executions: processed[componentKey{path: c.MetricPath, label: c.Label}],
+				})
+			}
+			if c.Name == "switch" && (c.Type == docs.TypeProcessor || c.Type == docs.TypeOutput) {
+				r.SwitchCases = append(r.SwitchCases, switchCases(c, executed)...)
+			}
+		})
+	}
+
+	for _, k := range t.matchOrder {
+		m := t.matchCases[k]
+		r.MatchCases = append(r.MatchCases, MatchCaseCoverage{
+			Component:  m.component,
+			Source:     k.source,
+			Pattern:    k.pattern,
+			Line:       k.line,
+			Column:     k.column,
+			Executions: m.executions,
+		})
+	}
+	return r
+}
+
+// switchCases returns the coverage of each case of a switch component that
+// contains child components, a case is executed when any of its children are.
+func switchCases(c *explain.Component, executed func(c *explain.Component) bool) []SwitchCaseCoverage {
+	// Cases of a switch output are nested under a cases field.
+	indexOffset := len(c.Path) + 1
+	if c.Type == docs.TypeOutput {
+		indexOffset++
+	}
+
+	cases := map[int]bool{}
+	for _, child := range c.Children {
+		if len(child.Path) <= indexOffset {
+			continue
+		}
+		i, err := strconv.Atoi(child.Path[indexOffset])
+		if err != nil {
+			continue
+		}
+		cases[i] = cases[i] || executed(child)
+	}
+
+	indexes := make([]int, 0, len(cases))
+	for i := range cases {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
+	coverage := make([]SwitchCaseCoverage, 0, len(indexes))
+	for _, i := range indexes {
+		coverage = append(coverage, SwitchCaseCoverage{
+			Path:     c.MetricPath + ".switch." + strconv.Itoa(i),
+			Type:     string(c.Type),
+			Label:    c.Label,
+			Executed: cases[i],
+		})
+	}
+	return coverage
+}
+
+//------------------------------------------------------------------------------
+
+// CoverageReport summarises the coverage of tested config files.
+type CoverageReport struct {
+	Targets []TargetCoverageReport `json:"targets"`
+}
+
+// TargetCoverageReport summarises the coverage of a single config file.
+type TargetCoverageReport struct {
+	Path        string               `json:"path"`
+	Processors  []ComponentCoverage  `json:"processors"`
+	SwitchCases []SwitchCaseCoverage `json:"switch_cases"`
+	MatchCases  []MatchCaseCoverage  `json:"match_cases"`
+}
+
+// ComponentCoverage describes how many messages a component processed.
+type ComponentCoverage struct {
+	Path       string `json:"path"`
+	Label      string `json:"label,omitempty"`
+	Name       string `json:"name"`
+	Executions int64  `json:"executions"`
+}
+
+// SwitchCaseCoverage describes whether a case of a switch processor or output
+// was executed.
+type SwitchCaseCoverage struct {
+	Path     string `json:"path"`
+	Type     string `json:"type"`
+	Label    string `json:"label,omitempty"`
+	Executed bool   `json:"executed"`
+}
+
+// MatchCaseCoverage describes how many times a case of a Bloblang match
+// expression was matched.
+type MatchCaseCoverage struct {
+	Component  string `json:"component,omitempty"`
+	Source     string `json:"source"`
+	Pattern    string `json:"pattern"`
+	Line       int    `json:"line"`
+	Column     int    `json:"column"`
+	Executions int64  `json:"executions"`
+}
+
+func coverageRatio(covered, total int) string {
+	if total == 0 {
+		return "0/0"
+	}
+	return fmt.Sprintf("%v/%v (%.1f%%)", covered, total, float64(covered)/float64(total)*100)
+}
+
+func executedStr(executed bool, count string) string {
+	if executed {
+		return green(count)
+	}
+	return red("not executed")
+}
+
+// WriteText writes a human readable summary of the coverage.
+func (r CoverageReport) WriteText(w io.Writer) error {
+	var err error
+	printf := func(format string, args ...interface{}) {
+		if err == nil {
+			_, err = fmt.Fprintf(w, format, args...)
+		}
+	}
+
+	var covered, total int
+	for i, t := range r.Targets {
+		if i > 0 {
+			printf("\n")
+		}
+		printf("--- %v ---\n", t.Path)
+
+		var procsCovered int
+		for _, p := range t.Processors {
+			if p.Executions > 0 {
+				procsCovered++
+			}
+		}
+		printf("\nProcessors: %v\n", coverageRatio(procsCovered, len(t.Processors)))
+		for _, p := range t.Processors {
+			label := ""
+			if p.Label != "" {
+				label = " label=" + p.Label
+			}
+			printf("  %v %v%v: %v\n", p.Path, p.Name, label, executedStr(p.Executions > 0, fmt.Sprintf("%v messages", p.Executions)))
+		}
+
+		var casesCovered int
+		for _, c := range t.SwitchCases {
+			if c.Executed {
+				casesCovered++
+			}
+		}
+		if len(t.SwitchCases) > 0 {
+			printf("\nSwitch cases: %v\n", coverageRatio(casesCovered, len(t.SwitchCases)))
+			for _, c := range t.SwitchCases {
+				printf("  %v: %v\n", c.Path, executedStr(c.Executed, "executed"))
+			}
+		}
+
+		var matchCovered int
+		for _, m := range t.MatchCases {
+			if m.Executions > 0 {
+				matchCovered++
+			}
+		}
+		if len(t.MatchCases) > 0 {
+			printf("\nMatch cases: %v\n", coverageRatio(matchCovered, len(t.MatchCases)))
+			for _, m := range t.MatchCases {
+				component := m.Component
+				if component == "" {
+					component = "mapping"
+				}
+				printf("  %v line %v: %v => %v\n", component, m.Line, m.Pattern, executedStr(m.Executions > 0, fmt.Sprintf("%v matches", m.Executions)))
+			}
+		}
+
+		covered += procsCovered + casesCovered + matchCovered
+		total += len(t.Processors) + len(t.SwitchCases) + len(t.MatchCases)
+	}
+	printf("\nTotal coverage: %v\n", coverageRatio(covered, total))
+	return err
+}
@@ -0,0 +1,130 @@
+package test_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/cli/test"
+	"github.com/benthosdev/benthos/v4/internal/log"
+)
+
+func TestCoverageReport(t *testing.T) {
+	testDir, err := initTestFiles(t, map[string]string{
+		"config.yaml": `
+pipeline:
+  processors:
+    - label: categorise
+      bloblang: |
+        root = this
+        root.category = match this.type {
+          "a" => "first"
+          "b" => "second"
+          _ => "other"
+        }
+    - switch:
+        - check: this.type == "a"
+          processors:
+            - resource: upper
+        - check: this.type == "z"
+          processors:
+            - bloblang: 'root = deleted()'
+    - log:
+        message: 'categorised as ${! this.category }'
+output:
+  switch:
+    cases:
+      - check: this.TYPE == "a"
+        output:
+          label: type_a
+          aws_s3:
+            bucket: foo
+            path: a
+      - output:
+          drop: {}
+`,
+		"resources.yaml": `
+processor_resources:
+  - label: upper
+    bloblang: 'root = this.map_each_key(k -> k.uppercase())'
+`,
+	})
+	require.NoError(t, err)
+
+	var definition test.Definition
+	require.NoError(t, yaml.Unmarshal([]byte(`
+tests:
+  - name: processors
+    input_batch:
+      - content: '{"type":"a"}'
+      - content: '{"type":"c"}'
+    output_batches:
+      - - json_equals: { "TYPE": "a", "CATEGORY": "first" }
+        - json_equals: { "type": "c", "category": "other" }
+  - name: stream
+    target_stream: true
+    input_batch:
+      - content: '{"type":"a"}'
+    outputs:
+      type_a:
+        output_batches:
+          - - json_equals: { "TYPE": "a", "CATEGORY": "first" }
+`), &definition))
+
+	coverage := test.NewCoverage()
+	failures, err := definition.Execute(
+		filepath.Join(testDir, "config.yaml"),
+		[]string{filepath.Join(testDir, "resources.yaml")},
+		log.Noop(),
+		test.OptRecordCoverage(coverage),
+	)
+	require.NoError(t, err)
+	require.Empty(t, failures)
+
+	report := coverage.Report()
+	require.Len(t, report.Targets, 1)
+	target := report.Targets[0]
+	assert.Equal(t, filepath.Join(testDir, "config.yaml"), target.Path)
+
+	assert.Equal(t, []test.ComponentCoverage{
+		{Path: "root.pipeline.processors.0", Label: "categorise", Name: "bloblang", Executions: 3},
+		{Path: "root.pipeline.processors.1", Name: "switch", Executions: 3},
+		{Path: "root.pipeline.processors.1.switch.0.processors.0", Name: "resource", Executions: 2},
+		{Path: "root.pipeline.processors.1.switch.1.processors.0", Name: "bloblang", Executions: 0},
+		{Path: "root.pipeline.processors.2", Name: "log", Executions: 3},
+		{Path: "root.processor_resources", Label: "upper", Name: "bloblang", Executions: 2},
+	}, target.Processors)
+
+	assert.Equal(t, []test.SwitchCaseCoverage{
+		{Path: "root.pipeline.processors.1.switch.0", Type: "processor", Executed: true},
+		{Path: "root.pipeline.processors.1.switch.1", Type: "processor", Executed: false},
+		{Path: "root.output.switch.0", Type: "output", Executed: true},
+		{Path: "root.output.switch.1", Type: "output", Executed: false},
+	}, target.SwitchCases)
+
+	require.Len(t, target.MatchCases, 3)
+	var patterns []string
+	var executions []int64
+	for _, m := range target.MatchCases {
+		patterns = append(patterns, m.Pattern)
+		executions = append(executions, m.Executions)
+		assert.Equal(t, "processor bloblang label=categorise", m.Component)
+	}
+	assert.Equal(t, []string{`"a"`, `"b"`, `_`}, patterns)
+	assert.Equal(t, []int64{2, 0, 1}, executions)
+	assert.Equal(t, 3, target.MatchCases[0].Line)
+
+	color.NoColor = true
+	var buf bytes.Buffer
+	require.NoError(t, report.WriteText(&buf))
+	assert.Contains(t, buf.String(), "Processors: 5/6 (83.3%)")
+	assert.Contains(t, buf.String(), "Switch cases: 2/4 (50.0%)")
+	assert.Contains(t, buf.String(), "Match cases: 2/3 (66.7%)")
+	assert.Contains(t, buf.String(), "root.pipeline.processors.1.switch.1.processors.0 bloblang: not executed")
+	assert.Contains(t, buf.String(), "Total coverage: 9/13 (69.2%)")
+}
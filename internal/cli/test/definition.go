@@ -12,20 +12,79 @@ type Definition struct {
 	Cases []Case `yaml:"tests"`
 }
 
+type executeOpts struct {
+	updateSnapshots bool
+	propertySeed    *int64
+	coverage        *Coverage
+	results         *Results
+}
+
+// ExecuteOpt customises the execution of test definitions.
+type ExecuteOpt func(*executeOpts)
+
+// OptUpdateSnapshots causes the snapshot files of test cases to be written with
+// the actual results of the test case rather than compared against them.
+func OptUpdateSnapshots() ExecuteOpt {
+	return func(o *executeOpts) {
+		o.updateSnapshots = true
+	}
+}
+
+// OptPropertySeed sets the seed used to generate the inputs of property tests,
+// overriding any seed specified by the tests themselves.
+func OptPropertySeed(seed int64) ExecuteOpt {
+	return func(o *executeOpts) {
+		o.propertySeed = &seed
+	}
+}
+
+// OptRecordCoverage records the components of tested config files that are
+// executed into a coverage record.
+func OptRecordCoverage(coverage *Coverage) ExecuteOpt {
+	return func(o *executeOpts) {
+		o.coverage = coverage
+	}
+}
+
+// OptRecordResults records the results of each executed test target, which is
+// only applicable to RunAll.
+func OptRecordResults(results *Results) ExecuteOpt {
+	return func(o *executeOpts) {
+		o.results = results
+	}
+}
+
+func newExecuteOpts(opts []ExecuteOpt) executeOpts {
+	var o executeOpts
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
 // Execute the test definition.
-func (d Definition) Execute(testFilePath string, resourcesPaths []string, logger log.Modular) ([]CaseFailure, error) {
-	procsProvider := NewProcessorsProvider(
-		testFilePath,
+func (d Definition) Execute(testFilePath string, resourcesPaths []string, logger log.Modular, opts ...ExecuteOpt) ([]CaseFailure, error) {
+	eOpts := newExecuteOpts(opts)
+
+	providerOpts := []func(*ProcessorsProvider){
 		OptAddResourcesPaths(resourcesPaths),
 		OptProcessorsProviderSetLogger(logger),
-	)
+	}
+	if eOpts.coverage != nil {
+		coverage, err := eOpts.coverage.Target(testFilePath, resourcesPaths)
+		if err != nil {
+			return nil, err
+		}
+		providerOpts = append(providerOpts, OptProcessorsProviderSetCoverage(coverage))
+	}
+	procsProvider := NewProcessorsProvider(testFilePath, providerOpts...)
 
 	dir := filepath.Dir(testFilePath)
 
 	var totalFailures []CaseFailure
 	for i, c := range d.Cases {
 		cleanupEnv := setEnvironment(c.Environment)
-		failures, err := c.executeFrom(dir, procsProvider)
+		failures, err := c.executeFrom(dir, procsProvider, eOpts)
 		if err != nil {
 			cleanupEnv()
 			return nil, fmt.Errorf("test case %v failed: %v", i, err)
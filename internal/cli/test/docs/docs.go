@@ -51,9 +51,13 @@ It is also possible to target processors in a separate file by prefixing the tar
 			"target_mapping",
 			"A file path relative to the test definition path of a Bloblang file to execute as an alternative to testing processors with the `target_processors` field. This allows you to define unit tests for Bloblang mappings directly.",
 		).HasDefault(""),
+		docs.FieldBool(
+			"target_stream",
+			"Whether to execute the full stream of the config as an alternative to testing processors with the `target_processors` field. The input of the stream is replaced with one that sends the messages of `input_batch`, and each output that isn't mocked is replaced with one that captures the messages written to it.",
+		).HasDefault(false),
 		docs.FieldAnything(
 			"mocks",
-			"An optional map of processors to mock. Keys should contain either a label or a JSON pointer of a processor that should be mocked. Values should contain a processor definition, which will replace the mocked processor. Most of the time you'll want to use a `bloblang` processor here, and use it to create a result that emulates the target processor.",
+			"An optional map of components to mock. Keys should contain either a label or a JSON pointer of a component that should be mocked, which can be a processor, input, output or resource. Values should contain a component definition, which will replace the mocked component. When mocking processors most of the time you'll want to use a `bloblang` processor here, and use it to create a result that emulates the target processor.",
 			map[string]interface{}{
 				"get_foobar_api": map[string]interface{}{
 					"bloblang": "root = content().string() + \" this is some mock content\"",
@@ -81,40 +85,71 @@ It is also possible to target processors in a separate file by prefixing the tar
 			).Optional(),
 			docs.FieldString("metadata", "A map of metadata key/values to add to the input message.").Map().Optional(),
 		),
+		outputBatchesSpec(),
 		docs.FieldObject(
-			"output_batches", "",
-		).ArrayOfArrays().Optional().WithChildren(
-			docs.FieldString("content", "The raw content of the input message.").HasDefault(""),
-			docs.FieldString("metadata", "A map of metadata key/values to add to the input message.").Map().Optional(),
-			docs.FieldString(
-				`bloblang`,
-				"Executes a Bloblang mapping on the output message, if the result is anything other than a boolean equalling `true` the test fails.",
-				"this.age > 10 && meta(\"foo\").length() > 0",
-			).Optional(),
-			docs.FieldString(`content_equals`, "Checks the full raw contents of a message against a value.").Optional(),
-			docs.FieldString(`content_matches`, "Checks whether the full raw contents of a message matches a regular expression (re2).", "^foo [a-z]+ bar$").Optional(),
-			docs.FieldString(
-				`metadata_equals`,
-				"Checks a map of metadata keys to values against the metadata stored in the message. If there is a value mismatch between a key of the condition versus the message metadata this condition will fail.",
-				map[string]interface{}{
-					"example_key": "example metadata value",
-				},
-			).Map().Optional(),
-			docs.FieldString(
-				`file_equals`,
-				"Checks that the contents of a message matches the contents of a file. The path of the file should be relative to the path of the test file.",
-				"./foo/bar.txt",
-			).Optional(),
-			docs.FieldAnything(
-				`json_equals`,
-				"Checks that both the message and the condition are valid JSON documents, and that they are structurally equivalent. Will ignore formatting and ordering differences.",
-				map[string]interface{}{"key": "value"},
-			).Optional(),
-			docs.FieldString(
-				`json_contains`,
-				"Checks that both the message and the condition are valid JSON documents, and that the message is a superset of the condition.",
-				map[string]interface{}{"key": "value"},
+			"outputs", "When `target_stream` is enabled this is an optional map of captured outputs to the batches that are expected to be written to them. Keys should contain either the label of an output or, for outputs without a label, a JSON pointer to the output.",
+		).Map().Optional().WithChildren(
+			outputBatchesSpec(),
+		),
+		docs.FieldString(
+			"snapshot", "An optional path, relative to the config file, of a snapshot file that the resulting batches of the test are compared against. Running tests with the flag `--update-snapshots` writes the resulting batches to the snapshot file instead.",
+			"snapshots/foo.yaml",
+		).Optional(),
+		docs.FieldObject(
+			"property", "Turns the test into a property test, where the target is executed with a number of randomly generated inputs rather than `input_batch`, and the resulting messages of each must satisfy a set of invariants.",
+		).Optional().WithChildren(
+			docs.FieldInt("iterations", "The number of inputs to generate.").HasDefault(100),
+			docs.FieldInt("seed", "An optional seed for generating inputs, when omitted a random seed is used. The seed of a failed property is reported so that it can be reproduced.").Optional(),
+			docs.FieldObject("generator", "Defines how inputs are generated, exactly one of `mapping` or `json_schema` must be specified.").WithChildren(
+				docs.FieldString(
+					"mapping", "A [Bloblang mapping](/docs/guides/bloblang/about) that generates an input message. Within this mapping the function `random_int` draws from the random choices of the test.",
+					`root.name = [ "foo", null, "bar" ].index(random_int() % 3)`,
+				).Optional(),
+				docs.FieldAnything(
+					"json_schema", "A JSON schema that inputs are generated from, either as an object or a string. The keywords `type`, `enum`, `const`, `anyOf`, `oneOf`, `properties`, `required`, `items`, `minimum`, `maximum`, `minLength`, `maxLength`, `minItems` and `maxItems` are supported.",
+				).Optional(),
+			),
+			docs.FieldObject(
+				"invariants", "Conditions that every resulting message must satisfy, using the same [output conditions](#output-conditions) as `output_batches`.",
 			).Optional(),
 		),
 	)
 }
+
+func outputBatchesSpec() docs.FieldSpec {
+	return docs.FieldObject(
+		"output_batches", "",
+	).ArrayOfArrays().Optional().WithChildren(
+		docs.FieldString("content", "The raw content of the input message.").HasDefault(""),
+		docs.FieldString("metadata", "A map of metadata key/values to add to the input message.").Map().Optional(),
+		docs.FieldString(
+			`bloblang`,
+			"Executes a Bloblang mapping on the output message, if the result is anything other than a boolean equalling `true` the test fails.",
+			"this.age > 10 && meta(\"foo\").length() > 0",
+		).Optional(),
+		docs.FieldString(`content_equals`, "Checks the full raw contents of a message against a value.").Optional(),
+		docs.FieldString(`content_matches`, "Checks whether the full raw contents of a message matches a regular expression (re2).", "^foo [a-z]+ bar$").Optional(),
+		docs.FieldString(
+			`metadata_equals`,
+			"Checks a map of metadata keys to values against the metadata stored in the message. If there is a value mismatch between a key of the condition versus the message metadata this condition will fail.",
+			map[string]interface{}{
+				"example_key": "example metadata value",
+			},
+		).Map().Optional(),
+		docs.FieldString(
+			`file_equals`,
+			"Checks that the contents of a message matches the contents of a file. The path of the file should be relative to the path of the test file.",
+			"./foo/bar.txt",
+		).Optional(),
+		docs.FieldAnything(
+			`json_equals`,
+			"Checks that both the message and the condition are valid JSON documents, and that they are structurally equivalent. Will ignore formatting and ordering differences.",
+			map[string]interface{}{"key": "value"},
+		).Optional(),
+		docs.FieldString(
+			`json_contains`,
+			"Checks that both the message and the condition are valid JSON documents, and that the message is a superset of the condition.",
+			map[string]interface{}{"key": "value"},
+		).Optional(),
+	)
+}
@@ -5,12 +5,13 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/Jeffail/gabs/v2"
 	yaml "gopkg.in/yaml.v3"
 
-	"github.com/benthosdev/benthos/v4/internal/bloblang/parser"
+	"github.com/benthosdev/benthos/v4/internal/bloblang"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	iprocessor "github.com/benthosdev/benthos/v4/internal/component/processor"
 	"github.com/benthosdev/benthos/v4/internal/config"
@@ -24,6 +25,11 @@ import (
 type cachedConfig struct {
 	mgr   manager.ResourceConfig
 	procs []processor.Config
+
+	// The path of the processors within the config, and whether the path
+	// targets a single processor rather than an array.
+	path   []string
+	single bool
 }
 
 // ProcessorsProvider consumes a Benthos config and, given a JSON Pointer,
@@ -33,7 +39,8 @@ type ProcessorsProvider struct {
 	resourcesPaths []string
 	cachedConfigs  map[string]cachedConfig
 
-	logger log.Modular
+	logger   log.Modular
+	coverage *TargetCoverage
 }
 
 // NewProcessorsProvider returns a new processors provider aimed at a filepath.
@@ -63,6 +70,36 @@ func OptProcessorsProviderSetLogger(logger log.Modular) func(*ProcessorsProvider
 	}
 }
 
+// OptProcessorsProviderSetCoverage sets a coverage record that the execution of
+// tested components is recorded to.
+func OptProcessorsProviderSetCoverage(coverage *TargetCoverage) func(*ProcessorsProvider) {
+	return func(p *ProcessorsProvider) {
+		p.coverage = coverage
+	}
+}
+
+func (p *ProcessorsProvider) stats() *metrics.Namespaced {
+	if p.coverage != nil {
+		return p.coverage.metrics()
+	}
+	return metrics.Noop()
+}
+
+func (p *ProcessorsProvider) bloblEnvironment() *bloblang.Environment {
+	if p.coverage != nil {
+		return p.coverage.bloblEnvironment()
+	}
+	return bloblang.GlobalEnvironment()
+}
+
+func (p *ProcessorsProvider) newManager(conf manager.ResourceConfig) (*manager.Type, error) {
+	opts := []manager.OptFunc{manager.OptSetBloblangEnvironment(p.bloblEnvironment())}
+	if p.coverage != nil {
+		opts = append(opts, manager.OptSetEnvironment(p.coverage.environment()))
+	}
+	return manager.NewV2(conf, mock.NewManager(), p.logger, p.stats(), opts...)
+}
+
 //------------------------------------------------------------------------------
 
 // Provide attempts to extract an array of processors from a Benthos config.
@@ -89,10 +126,9 @@ func (p *ProcessorsProvider) ProvideBloblang(pathStr string) ([]iprocessor.V1, e
 		return nil, err
 	}
 
-	pCtx := parser.GlobalContext().WithImporterRelativeToFile(pathStr)
-	exec, mapErr := parser.ParseMapping(pCtx, string(mappingBytes))
-	if mapErr != nil {
-		return nil, mapErr
+	exec, err := p.bloblEnvironment().WithImporterRelativeToFile(pathStr).NewMapping(string(mappingBytes))
+	if err != nil {
+		return nil, err
 	}
 
 	return []iprocessor.V1{
@@ -103,14 +139,26 @@ func (p *ProcessorsProvider) ProvideBloblang(pathStr string) ([]iprocessor.V1, e
 //------------------------------------------------------------------------------
 
 func (p *ProcessorsProvider) initProcs(confs cachedConfig) ([]iprocessor.V1, error) {
-	mgr, err := manager.NewV2(confs.mgr, mock.NewManager(), p.logger, metrics.Noop())
+	mgr, err := p.newManager(confs.mgr)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialise resources: %v", err)
 	}
 
+	// Processors are initialised with their path within the config so that
+	// their execution is observable, resources are identified by their label
+	// instead.
+	path := confs.path
+	if len(path) == 2 && strings.HasSuffix(path[0], "_resources") {
+		path = path[:1]
+	}
+
 	procs := make([]iprocessor.V1, len(confs.procs))
 	for i, conf := range confs.procs {
-		if procs[i], err = processor.New(conf, mgr, p.logger, metrics.Noop()); err != nil {
+		pMgr := mgr.IntoPath(path...)
+		if !confs.single {
+			pMgr = mgr.IntoPath(append(append([]string{}, path...), strconv.Itoa(i))...)
+		}
+		if procs[i], err = processor.New(conf, pMgr, p.logger, metrics.Noop()); err != nil {
 			return nil, fmt.Errorf("failed to initialise processor index '%v': %v", i, err)
 		}
 	}
@@ -171,22 +219,73 @@ func resolveProcessorsPointer(targetFile, jsonPtr string) (filePath, procPath st
 	return
 }
 
-func (p *ProcessorsProvider) getConfs(jsonPtr string, environment map[string]string, mocks map[string]yaml.Node) (cachedConfig, error) {
-	cacheKey := confTargetID(jsonPtr, environment, mocks)
+// mockedTarget is a parsed config file along with any resource files, where
+// mocks have been applied.
+type mockedTarget struct {
+	root          *yaml.Node
+	resources     manager.ResourceConfig
+	resourceRoots []*yaml.Node
+	labelsToPaths map[string][]string
 
-	confs, exists := p.cachedConfigs[cacheKey]
-	if exists {
-		return confs, nil
+	// The nodes that have been replaced by mocks.
+	mocked map[*yaml.Node]struct{}
+}
+
+// withLabel returns a mock node that carries the label of the node it
+// replaces, unless the mock specifies a label of its own. Resources are
+// identified by their labels and therefore mocks must retain them.
+func withLabel(mock *yaml.Node, replaced *yaml.Node) *yaml.Node {
+	replaced = unwrapNode(replaced)
+	if mock.Kind != yaml.MappingNode || replaced == nil || replaced.Kind != yaml.MappingNode {
+		return mock
 	}
+	for i := 0; i < len(mock.Content)-1; i += 2 {
+		if mock.Content[i].Value == "label" {
+			return mock
+		}
+	}
+	for i := 0; i < len(replaced.Content)-1; i += 2 {
+		if replaced.Content[i].Value == "label" {
+			labelled := *mock
+			labelled.Content = append([]*yaml.Node{replaced.Content[i], replaced.Content[i+1]}, mock.Content...)
+			return &labelled
+		}
+	}
+	return mock
+}
 
-	targetPath, procPath, err := resolveProcessorsPointer(p.targetPath, jsonPtr)
+func unwrapNode(node *yaml.Node) *yaml.Node {
+	if node != nil && node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		return node.Content[0]
+	}
+	return node
+}
+
+func setMock(root *yaml.Node, mock yaml.Node, path []string, mocked map[*yaml.Node]struct{}) error {
+	confSpec := config.Spec()
+	replaced, _ := docs.GetYAMLPath(root, path...)
+	if err := confSpec.SetYAMLPath(nil, root, withLabel(&mock, replaced), path...); err != nil {
+		return err
+	}
+	if node, err := docs.GetYAMLPath(root, path...); err == nil {
+		mocked[node] = struct{}{}
+	}
+	return nil
+}
+
+func readYAMLEnvSwap(path string) (*yaml.Node, error) {
+	configBytes, _, err := config.ReadFileEnvSwap(path, config.SecretsPlaceholder)
 	if err != nil {
-		return confs, err
+		return nil, err
 	}
-	if targetPath == "" {
-		targetPath = p.targetPath
+	root := &yaml.Node{}
+	if err = yaml.Unmarshal(configBytes, root); err != nil {
+		return nil, err
 	}
+	return root, nil
+}
 
+func (p *ProcessorsProvider) readTarget(targetPath string, environment map[string]string, mocks map[string]yaml.Node) (*mockedTarget, error) {
 	// Set custom environment vars.
 	ogEnvVars := map[string]string{}
 	for k, v := range environment {
@@ -202,35 +301,21 @@ func (p *ProcessorsProvider) getConfs(jsonPtr string, environment map[string]str
 		remainingMocks[k] = v
 	}
 
-	configBytes, _, err := config.ReadFileEnvSwap(targetPath)
-	if err != nil {
-		return confs, fmt.Errorf("failed to parse config file '%v': %v", targetPath, err)
+	t := &mockedTarget{
+		labelsToPaths: map[string][]string{},
+		mocked:        map[*yaml.Node]struct{}{},
 	}
 
-	mgrWrapper := manager.NewResourceConfig()
-	if err = yaml.Unmarshal(configBytes, &mgrWrapper); err != nil {
-		return confs, fmt.Errorf("failed to parse config file '%v': %v", targetPath, err)
+	var err error
+	if t.root, err = readYAMLEnvSwap(targetPath); err != nil {
+		return nil, fmt.Errorf("failed to parse config file '%v': %v", targetPath, err)
 	}
-
 	for _, path := range p.resourcesPaths {
-		resourceBytes, _, err := config.ReadFileEnvSwap(path)
+		resRoot, err := readYAMLEnvSwap(path)
 		if err != nil {
-			return confs, fmt.Errorf("failed to parse resources config file '%v': %v", path, err)
-		}
-		extraMgrWrapper := manager.NewResourceConfig()
-		if err = yaml.Unmarshal(resourceBytes, &extraMgrWrapper); err != nil {
-			return confs, fmt.Errorf("failed to parse resources config file '%v': %v", path, err)
-		}
-		if err = mgrWrapper.AddFrom(&extraMgrWrapper); err != nil {
-			return confs, fmt.Errorf("failed to merge resources from '%v': %v", path, err)
+			return nil, fmt.Errorf("failed to parse resources config file '%v': %v", path, err)
 		}
-	}
-
-	confs.mgr = mgrWrapper
-
-	root := &yaml.Node{}
-	if err = yaml.Unmarshal(configBytes, root); err != nil {
-		return confs, fmt.Errorf("failed to parse config file '%v': %v", targetPath, err)
+		t.resourceRoots = append(t.resourceRoots, resRoot)
 	}
 
 	// Replace mock components, starting with all absolute paths in JSON pointer
@@ -242,44 +327,109 @@ func (p *ProcessorsProvider) getConfs(jsonPtr string, environment map[string]str
 		}
 		mockPathSlice, err := gabs.JSONPointerToSlice(k)
 		if err != nil {
-			return confs, fmt.Errorf("failed to parse mock path '%v': %w", k, err)
+			return nil, fmt.Errorf("failed to parse mock path '%v': %w", k, err)
+		}
+		if err = setMock(t.root, v, mockPathSlice, t.mocked); err != nil {
+			return nil, fmt.Errorf("failed to set mock '%v': %w", k, err)
+		}
+		delete(remainingMocks, k)
+	}
+
+	confSpec.YAMLLabelsToPaths(nil, t.root, t.labelsToPaths, nil)
+	for k, v := range remainingMocks {
+		mockPathSlice, exists := t.labelsToPaths[k]
+		if !exists {
+			continue
 		}
-		if err = confSpec.SetYAMLPath(nil, root, &v, mockPathSlice...); err != nil {
-			return confs, fmt.Errorf("failed to set mock '%v': %w", k, err)
+		if err = setMock(t.root, v, mockPathSlice, t.mocked); err != nil {
+			return nil, fmt.Errorf("failed to set mock '%v': %w", k, err)
 		}
 		delete(remainingMocks, k)
 	}
 
-	labelsToPaths := map[string][]string{}
-	if len(remainingMocks) > 0 {
-		confSpec.YAMLLabelsToPaths(nil, root, labelsToPaths, nil)
+	// Mocks of labels that remain may target resources imported separately.
+	for _, resRoot := range t.resourceRoots {
+		if len(remainingMocks) == 0 {
+			break
+		}
+		resLabelsToPaths := map[string][]string{}
+		confSpec.YAMLLabelsToPaths(nil, resRoot, resLabelsToPaths, nil)
 		for k, v := range remainingMocks {
-			mockPathSlice, exists := labelsToPaths[k]
+			mockPathSlice, exists := resLabelsToPaths[k]
 			if !exists {
-				return confs, fmt.Errorf("mock for label '%v' could not be applied as the label was not found in the test target file, it is not currently possible to mock resources imported separate to the test file", k)
+				continue
 			}
-			if err = confSpec.SetYAMLPath(nil, root, &v, mockPathSlice...); err != nil {
-				return confs, fmt.Errorf("failed to set mock '%v': %w", k, err)
+			if err = setMock(resRoot, v, mockPathSlice, t.mocked); err != nil {
+				return nil, fmt.Errorf("failed to set mock '%v': %w", k, err)
 			}
 			delete(remainingMocks, k)
 		}
 	}
 
+	for k := range remainingMocks {
+		return nil, fmt.Errorf("mock for label '%v' could not be applied as the label was not found in the test target file or resource files", k)
+	}
+
+	if err = t.decodeResources(targetPath); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *mockedTarget) decodeResources(targetPath string) error {
+	t.resources = manager.NewResourceConfig()
+	if err := t.root.Decode(&t.resources); err != nil {
+		return fmt.Errorf("failed to parse config file '%v': %v", targetPath, err)
+	}
+	for _, resRoot := range t.resourceRoots {
+		extraMgrWrapper := manager.NewResourceConfig()
+		if err := resRoot.Decode(&extraMgrWrapper); err != nil {
+			return fmt.Errorf("failed to parse resources config file: %v", err)
+		}
+		if err := t.resources.AddFrom(&extraMgrWrapper); err != nil {
+			return fmt.Errorf("failed to merge resources: %v", err)
+		}
+	}
+	return nil
+}
+
+func (p *ProcessorsProvider) getConfs(jsonPtr string, environment map[string]string, mocks map[string]yaml.Node) (cachedConfig, error) {
+	cacheKey := confTargetID(jsonPtr, environment, mocks)
+
+	confs, exists := p.cachedConfigs[cacheKey]
+	if exists {
+		return confs, nil
+	}
+
+	targetPath, procPath, err := resolveProcessorsPointer(p.targetPath, jsonPtr)
+	if err != nil {
+		return confs, err
+	}
+	if targetPath == "" {
+		targetPath = p.targetPath
+	}
+
+	target, err := p.readTarget(targetPath, environment, mocks)
+	if err != nil {
+		return confs, err
+	}
+	confs.mgr = target.resources
+
 	var pathSlice []string
 	if strings.HasPrefix(procPath, "/") {
 		if pathSlice, err = gabs.JSONPointerToSlice(procPath); err != nil {
 			return confs, fmt.Errorf("failed to parse case processors path '%v': %w", procPath, err)
 		}
 	} else {
-		if len(labelsToPaths) == 0 {
-			confSpec.YAMLLabelsToPaths(nil, root, labelsToPaths, nil)
-		}
-		if pathSlice, exists = labelsToPaths[procPath]; !exists {
+		if pathSlice, exists = target.labelsToPaths[procPath]; !exists {
 			return confs, fmt.Errorf("target for label '%v' failed as the label was not found in the test target file, it is not currently possible to target resources imported separate to the test file", procPath)
 		}
 	}
 
-	if root, err = docs.GetYAMLPath(root, pathSlice...); err != nil {
+	confs.path = pathSlice
+
+	root, err := docs.GetYAMLPath(target.root, pathSlice...)
+	if err != nil {
 		return confs, fmt.Errorf("failed to resolve case processors from '%v': %v", targetPath, err)
 	}
 
@@ -293,6 +443,7 @@ func (p *ProcessorsProvider) getConfs(jsonPtr string, environment map[string]str
 			return confs, fmt.Errorf("failed to resolve case processors from '%v': %v", targetPath, err)
 		}
 		confs.procs = append(confs.procs, procConf)
+		confs.single = true
 	}
 
 	p.cachedConfigs[cacheKey] = confs
@@ -0,0 +1,516 @@
+package test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	iprocessor "github.com/benthosdev/benthos/v4/internal/component/processor"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/old/processor"
+)
+
+const (
+	propertyDefaultIterations = 100
+	propertyShrinkBudget      = 1000
+	propertyMaxDepth          = 4
+)
+
+// PropertyGenerator defines how the random inputs of a property test are
+// generated, either from a Bloblang mapping or from a JSON schema.
+type PropertyGenerator struct {
+	Mapping    string    `yaml:"mapping"`
+	JSONSchema yaml.Node `yaml:"json_schema"`
+}
+
+// PropertyTest defines a test case that executes with a number of randomly
+// generated inputs, where the resulting messages of each are checked against
+// invariant conditions.
+type PropertyTest struct {
+	Iterations int               `yaml:"iterations"`
+	Seed       *int64            `yaml:"seed"`
+	Generator  PropertyGenerator `yaml:"generator"`
+	Invariants ConditionsMap     `yaml:"invariants"`
+}
+
+//------------------------------------------------------------------------------
+
+// choiceSource provides the random choices made while generating an input. The
+// choices are recorded so that an input can be reproduced, and shrunk by
+// replaying a simplified sequence of choices, where choices beyond the end of
+// a replayed sequence are zero.
+type choiceSource struct {
+	rand   *rand.Rand
+	replay []int64
+	drawn  []int64
+}
+
+func (c *choiceSource) draw() int64 {
+	var v int64
+	if c.rand != nil {
+		v = c.rand.Int63()
+	} else if i := len(c.drawn); i < len(c.replay) {
+		v = c.replay[i]
+	}
+	c.drawn = append(c.drawn, v)
+	return v
+}
+
+// drawN returns a choice within the range [0, n).
+func (c *choiceSource) drawN(n int64) int64 {
+	if n <= 1 {
+		_ = c.draw()
+		return 0
+	}
+	return c.draw() % n
+}
+
+type inputGenerator interface {
+	generate(choices *choiceSource) (*message.Part, error)
+}
+
+//------------------------------------------------------------------------------
+
+// mappingGenerator generates inputs by executing a Bloblang mapping, where the
+// function random_int draws from the choices of the input.
+type mappingGenerator struct {
+	exec    *mapping.Executor
+	choices *choiceSource
+}
+
+func newMappingGenerator(blobl string) (*mappingGenerator, error) {
+	g := &mappingGenerator{}
+
+	env := bloblang.NewEnvironment()
+
+	var randomIntSpec query.FunctionSpec
+	env.WalkFunctions(func(name string, spec query.FunctionSpec) {
+		if name == "random_int" {
+			randomIntSpec = spec
+		}
+	})
+	if err := env.RegisterFunction(randomIntSpec, func(args *query.ParsedParams) (query.Function, error) {
+		return query.ClosureFunction("function random_int", func(ctx query.FunctionContext) (interface{}, error) {
+			return g.choices.draw(), nil
+		}, nil), nil
+	}); err != nil {
+		return nil, err
+	}
+
+	exec, err := env.NewMapping(blobl)
+	if err != nil {
+		return nil, err
+	}
+	g.exec = exec
+	return g, nil
+}
+
+func (g *mappingGenerator) generate(choices *choiceSource) (*message.Part, error) {
+	g.choices = choices
+	part, err := g.exec.MapPart(0, message.QuickBatch([][]byte{nil}))
+	if err != nil {
+		return nil, err
+	}
+	if part == nil {
+		return nil, errors.New("mapping deleted the generated message")
+	}
+	return part, nil
+}
+
+//------------------------------------------------------------------------------
+
+const schemaStringChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789 "
+
+// schemaGenerator generates JSON documents that satisfy a subset of JSON
+// schema. Choices of zero result in the simplest values, which are the first
+// of each enum and type, empty strings and arrays, zero numbers, and the
+// omission of optional object properties.
+type schemaGenerator struct {
+	schema interface{}
+}
+
+func newSchemaGenerator(node *yaml.Node) (*schemaGenerator, error) {
+	var schema interface{}
+	if node.Kind == yaml.ScalarNode {
+		if err := json.Unmarshal([]byte(node.Value), &schema); err != nil {
+			return nil, fmt.Errorf("failed to parse json_schema: %v", err)
+		}
+	} else if err := node.Decode(&schema); err != nil {
+		return nil, fmt.Errorf("failed to parse json_schema: %v", err)
+	}
+	return &schemaGenerator{schema: schema}, nil
+}
+
+func (g *schemaGenerator) generate(choices *choiceSource) (*message.Part, error) {
+	v, err := generateFromSchema(choices, g.schema, 0)
+	if err != nil {
+		return nil, err
+	}
+	part := message.NewPart(nil)
+	part.SetJSON(v)
+	return part, nil
+}
+
+func schemaInt(schema map[string]interface{}, key string) (int64, bool) {
+	v, exists := schema[key]
+	if !exists {
+		return 0, false
+	}
+	i, err := query.IGetInt(v)
+	if err != nil {
+		return 0, false
+	}
+	return i, true
+}
+
+// generateInt returns an integer within optional bounds, where increasing
+// choices move away from zero (or the bound) alternating in sign.
+func generateInt(choices *choiceSource, schema map[string]interface{}) int64 {
+	minimum, hasMin := schemaInt(schema, "minimum")
+	maximum, hasMax := schemaInt(schema, "maximum")
+	switch {
+	case hasMin && hasMax:
+		if maximum < minimum {
+			return minimum
+		}
+		return minimum + choices.drawN(maximum-minimum+1)
+	case hasMin:
+		return minimum + choices.drawN(1000)
+	case hasMax:
+		return maximum - choices.drawN(1000)
+	}
+	v := choices.drawN(2001)
+	return (v >> 1) ^ -(v & 1)
+}
+
+func generateFromSchema(choices *choiceSource, schemaV interface{}, depth int) (interface{}, error) {
+	if b, ok := schemaV.(bool); ok {
+		if !b {
+			return nil, errors.New("schema false cannot be satisfied")
+		}
+		schemaV = map[string]interface{}{}
+	}
+	schema, ok := schemaV.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected schema to be an object, got %T", schemaV)
+	}
+
+	if c, exists := schema["const"]; exists {
+		return c, nil
+	}
+	if e, ok := schema["enum"].([]interface{}); ok && len(e) > 0 {
+		return e[choices.drawN(int64(len(e)))], nil
+	}
+	for _, k := range []string{"anyOf", "oneOf"} {
+		if options, ok := schema[k].([]interface{}); ok && len(options) > 0 {
+			return generateFromSchema(choices, options[choices.drawN(int64(len(options)))], depth+1)
+		}
+	}
+
+	var types []string
+	switch t := schema["type"].(type) {
+	case string:
+		types = []string{t}
+	case []interface{}:
+		for _, v := range t {
+			if s, ok := v.(string); ok {
+				types = append(types, s)
+			}
+		}
+	}
+	if len(types) == 0 {
+		switch {
+		case schema["properties"] != nil:
+			types = []string{"object"}
+		case schema["items"] != nil:
+			types = []string{"array"}
+		case depth >= propertyMaxDepth:
+			types = []string{"null", "boolean", "integer", "string"}
+		default:
+			types = []string{"null", "boolean", "integer", "number", "string", "array", "object"}
+		}
+	}
+
+	switch t := types[choices.drawN(int64(len(types)))]; t {
+	case "null":
+		return nil, nil
+	case "boolean":
+		return choices.drawN(2) == 1, nil
+	case "integer":
+		return generateInt(choices, schema), nil
+	case "number":
+		v := float64(generateInt(choices, schema)) + float64(choices.drawN(100))/100
+		if maximum, hasMax := schemaInt(schema, "maximum"); hasMax && v > float64(maximum) {
+			v = float64(maximum)
+		}
+		return v, nil
+	case "string":
+		minLen, _ := schemaInt(schema, "minLength")
+		maxLen, hasMax := schemaInt(schema, "maxLength")
+		if !hasMax || maxLen < minLen {
+			maxLen = minLen + 16
+		}
+		l := minLen + choices.drawN(maxLen-minLen+1)
+		var b strings.Builder
+		for i := int64(0); i < l; i++ {
+			b.WriteByte(schemaStringChars[choices.drawN(int64(len(schemaStringChars)))])
+		}
+		return b.String(), nil
+	case "array":
+		minItems, _ := schemaInt(schema, "minItems")
+		maxItems, hasMax := schemaInt(schema, "maxItems")
+		if !hasMax || maxItems < minItems {
+			maxItems = minItems + 4
+		}
+		items, exists := schema["items"]
+		if !exists {
+			items = true
+		}
+		arr := []interface{}{}
+		for i := minItems + choices.drawN(maxItems-minItems+1); i > 0; i-- {
+			v, err := generateFromSchema(choices, items, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		return arr, nil
+	case "object":
+		required := map[string]bool{}
+		if r, ok := schema["required"].([]interface{}); ok {
+			for _, k := range r {
+				if s, ok := k.(string); ok {
+					required[s] = true
+				}
+			}
+		}
+		props, _ := schema["properties"].(map[string]interface{})
+		keys := make([]string, 0, len(props))
+		for k := range props {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		obj := map[string]interface{}{}
+		for _, k := range keys {
+			if !required[k] && choices.drawN(2) == 0 {
+				continue
+			}
+			v, err := generateFromSchema(choices, props[k], depth+1)
+			if err != nil {
+				return nil, fmt.Errorf("property %v: %w", k, err)
+			}
+			obj[k] = v
+		}
+		return obj, nil
+	}
+	return nil, fmt.Errorf("schema type not supported: %v", types)
+}
+
+//------------------------------------------------------------------------------
+
+func (c *Case) propertyGenerator() (inputGenerator, error) {
+	gen := c.Property.Generator
+	hasSchema := gen.JSONSchema.Kind != 0
+	if (gen.Mapping == "") == !hasSchema {
+		return nil, errors.New("property generator requires exactly one of mapping or json_schema")
+	}
+	if hasSchema {
+		return newSchemaGenerator(&gen.JSONSchema)
+	}
+	g, err := newMappingGenerator(gen.Mapping)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse generator mapping: %v", err)
+	}
+	return g, nil
+}
+
+// checkProperty executes the target processors of a test case with an input
+// and returns the reasons that the invariants of the property do not hold.
+func (c *Case) checkProperty(dir string, provider ProcProvider, input *message.Part) ([]string, error) {
+	procSet, err := c.provideProcessors(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	inputMsg := message.QuickBatch(nil)
+	inputMsg.Append(input.Copy())
+
+	var reasons []string
+	outputBatches, result := processor.ExecuteAll(procSet, inputMsg)
+	if result != nil {
+		reasons = append(reasons, fmt.Sprintf("processors resulted in error: %v", result))
+	}
+	for i, b := range outputBatches {
+		_ = b.Iter(func(i2 int, part *message.Part) error {
+			if procErr := processor.GetFail(part); len(procErr) > 0 {
+				reasons = append(reasons, fmt.Sprintf("batch %v message %v: %v", i, i2, red(procErr)))
+			}
+			for _, condErr := range c.Property.Invariants.CheckAll(dir, part) {
+				reasons = append(reasons, fmt.Sprintf("batch %v message %v: %v", i, i2, condErr))
+			}
+			return nil
+		})
+	}
+	return reasons, nil
+}
+
+// simplerChoices returns true if a sequence of choices is shorter than another,
+// or of equal length and lexicographically smaller.
+func simplerChoices(a, b []int64) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return false
+}
+
+// shrinkChoices attempts to simplify a sequence of choices that results in a
+// failed property, by removing and reducing individual choices for as long as
+// the property still fails. Returns the simplest failing choices found along
+// with the input and failure reasons they result in.
+func shrinkChoices(choices []int64, input *message.Part, reasons []string, run func(*choiceSource) (*message.Part, []string)) ([]int64, *message.Part, []string) {
+	budget := propertyShrinkBudget
+	for improved := true; improved && budget > 0; {
+		improved = false
+		for i := len(choices) - 1; i >= 0 && budget > 0; i-- {
+			if i >= len(choices) {
+				continue
+			}
+
+			candidates := [][]int64{append(append([]int64{}, choices[:i]...), choices[i+1:]...)}
+			if choices[i] != 0 {
+				zeroed := append([]int64{}, choices...)
+				zeroed[i] = 0
+				candidates = append(candidates, zeroed)
+			}
+			if choices[i] > 1 {
+				halved := append([]int64{}, choices...)
+				halved[i] /= 2
+				candidates = append(candidates, halved)
+			}
+
+			for _, candidate := range candidates {
+				if budget--; budget < 0 {
+					break
+				}
+				source := &choiceSource{replay: candidate}
+				if cInput, cReasons := run(source); len(cReasons) > 0 && simplerChoices(source.drawn, choices) {
+					choices, input, reasons = source.drawn, cInput, cReasons
+					improved = true
+					break
+				}
+			}
+		}
+	}
+	return choices, input, reasons
+}
+
+func describeInput(part *message.Part) string {
+	desc := string(part.Get())
+	var meta []string
+	_ = part.MetaIter(func(k, v string) error {
+		meta = append(meta, fmt.Sprintf("%v: %v", k, v))
+		return nil
+	})
+	if len(meta) > 0 {
+		sort.Strings(meta)
+		desc += fmt.Sprintf(" (metadata %v)", strings.Join(meta, ", "))
+	}
+	return desc
+}
+
+func (c *Case) executePropertyFrom(dir string, provider ProcProvider, opts executeOpts) (failures []CaseFailure, err error) {
+	if c.TargetStream {
+		return nil, errors.New("property cannot be specified when target_stream is enabled")
+	}
+	if len(c.InputBatch) > 0 || len(c.OutputBatches) > 0 {
+		return nil, errors.New("input_batch and output_batches cannot be specified with property, use property.invariants instead")
+	}
+
+	gen, err := c.propertyGenerator()
+	if err != nil {
+		return nil, err
+	}
+
+	seed := time.Now().UnixNano()
+	if opts.propertySeed != nil {
+		seed = *opts.propertySeed
+	} else if c.Property.Seed != nil {
+		seed = *c.Property.Seed
+	}
+
+	iterations := c.Property.Iterations
+	if iterations <= 0 {
+		iterations = propertyDefaultIterations
+	}
+
+	var runErr error
+	run := func(choices *choiceSource) (*message.Part, []string) {
+		input, err := gen.generate(choices)
+		if err != nil {
+			return nil, nil
+		}
+		reasons, err := c.checkProperty(dir, provider, input)
+		if err != nil && runErr == nil {
+			runErr = err
+		}
+		return input, reasons
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+	for i := 0; i < iterations; i++ {
+		source := &choiceSource{rand: rng}
+		input, err := gen.generate(source)
+		if err != nil {
+			return []CaseFailure{c.failure(fmt.Sprintf("property generator failed on iteration %v with seed %v: %v", i, seed, err))}, nil
+		}
+
+		reasons, err := c.checkProperty(dir, provider, input)
+		if err != nil {
+			return nil, err
+		}
+		if len(reasons) == 0 {
+			continue
+		}
+
+		_, input, reasons = shrinkChoices(source.drawn, input, reasons, run)
+		if runErr != nil {
+			return nil, runErr
+		}
+
+		failures = append(failures, c.failure(fmt.Sprintf("property falsified on iteration %v with seed %v, shrunk input: %v", i, seed, describeInput(input))))
+		for _, r := range reasons {
+			failures = append(failures, c.failure(r))
+		}
+		return failures, nil
+	}
+	return nil, nil
+}
+
+// provideProcessors returns the processors targeted by a test case.
+func (c *Case) provideProcessors(provider ProcProvider) (procSet []iprocessor.V1, err error) {
+	if c.TargetMapping != "" {
+		if procSet, err = provider.ProvideBloblang(c.TargetMapping); err != nil {
+			return nil, fmt.Errorf("failed to initialise Bloblang mapping '%v': %v", c.TargetMapping, err)
+		}
+		return
+	}
+	if procSet, err = provider.Provide(c.TargetProcessors, c.Environment, c.Mocks); err != nil {
+		return nil, fmt.Errorf("failed to initialise processors '%v': %v", c.TargetProcessors, err)
+	}
+	return
+}
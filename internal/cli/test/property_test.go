@@ -0,0 +1,136 @@
+package test_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/cli/test"
+	"github.com/benthosdev/benthos/v4/internal/log"
+)
+
+func TestPropertyMappingGenerator(t *testing.T) {
+	testDir, err := initTestFiles(t, map[string]string{
+		"config.yaml": `
+pipeline:
+  processors:
+    - bloblang: 'root = this'
+`,
+		"mapping.blobl": `
+root.name = this.name.uppercase()
+root.age = this.age
+`,
+	})
+	require.NoError(t, err)
+
+	color.NoColor = true
+	failures := executeDefinition(t, filepath.Join(testDir, "config.yaml"), nil, `
+tests:
+  - name: handles nulls
+    target_mapping: mapping.blobl
+    property:
+      seed: 10
+      generator:
+        mapping: |
+          root.name = [ "foo", null, "bar" ].index(random_int() % 3)
+          root.age = random_int() % 100
+      invariants:
+        bloblang: 'this.age < 100'
+`)
+	require.Len(t, failures, 2)
+	assert.Regexp(t, `^handles nulls \[line 3\]: property falsified on iteration \d+ with seed 10, shrunk input: {"age":0,"name":null}$`, failures[0])
+	assert.Contains(t, failures[1], "handles nulls [line 3]: batch 0 message 0: failed assignment (line 2)")
+
+	// The same seed reproduces the same failure.
+	assert.Equal(t, failures, executeDefinition(t, filepath.Join(testDir, "config.yaml"), nil, `
+tests:
+  - name: handles nulls
+    target_mapping: mapping.blobl
+    property:
+      seed: 10
+      generator:
+        mapping: |
+          root.name = [ "foo", null, "bar" ].index(random_int() % 3)
+          root.age = random_int() % 100
+      invariants:
+        bloblang: 'this.age < 100'
+`))
+}
+
+func TestPropertySchemaGenerator(t *testing.T) {
+	testDir, err := initTestFiles(t, map[string]string{
+		"config.yaml": `
+pipeline:
+  processors:
+    - bloblang: |
+        root = this
+        root.tag_count = this.tags.or([]).length()
+        root.first_tag = this.tags.index(0).catch("none")
+`,
+	})
+	require.NoError(t, err)
+
+	color.NoColor = true
+	failures := executeDefinition(t, filepath.Join(testDir, "config.yaml"), nil, `
+tests:
+  - name: counts tags
+    property:
+      iterations: 50
+      generator:
+        json_schema:
+          type: object
+          required: [ id ]
+          properties:
+            id: { type: integer, minimum: 1, maximum: 10 }
+            kind: { enum: [ "a", "b" ] }
+            tags:
+              type: array
+              maxItems: 3
+              items: { type: [ string, "null" ] }
+      invariants:
+        bloblang: 'this.id >= 1 && this.id <= 10 && this.tag_count <= 3 && this.kind.or("a") != "c"'
+
+  - name: first tag is a string
+    property:
+      seed: 3
+      generator:
+        json_schema: '{"type":"object","required":["tags"],"properties":{"tags":{"type":"array","items":{"type":["string","null"]}}}}'
+      invariants:
+        bloblang: 'this.first_tag.type() == "string"'
+`)
+	require.Len(t, failures, 2)
+	assert.Regexp(t, `^first tag is a string \[line 20\]: property falsified on iteration \d+ with seed 3, shrunk input: {"tags":\[null\]}$`, failures[0])
+	assert.Equal(t, "first tag is a string [line 20]: batch 0 message 0: bloblang: bloblang expression was false", failures[1])
+}
+
+func TestPropertySeedOverride(t *testing.T) {
+	testDir, err := initTestFiles(t, map[string]string{
+		"config.yaml": `
+pipeline:
+  processors:
+    - bloblang: 'root = this'
+`,
+	})
+	require.NoError(t, err)
+
+	var definition test.Definition
+	require.NoError(t, yaml.Unmarshal([]byte(`
+tests:
+  - name: always fails
+    property:
+      seed: 10
+      generator:
+        mapping: 'root.n = random_int() % 5'
+      invariants:
+        bloblang: 'this.n > 10'
+`), &definition))
+
+	failures, err := definition.Execute(filepath.Join(testDir, "config.yaml"), nil, log.Noop(), test.OptPropertySeed(42))
+	require.NoError(t, err)
+	require.NotEmpty(t, failures)
+	assert.Equal(t, `property falsified on iteration 0 with seed 42, shrunk input: {"n":0}`, failures[0].Reason)
+}
@@ -0,0 +1,124 @@
+package test
+
+import (
+	"encoding/xml"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// TargetResult contains the outcome of executing the tests of a config file.
+type TargetResult struct {
+	Path     string
+	Cases    []Case
+	Lints    []string
+	Failures []CaseFailure
+}
+
+// Results records the outcomes of executed test targets.
+type Results struct {
+	mut     sync.Mutex
+	targets []TargetResult
+}
+
+func (r *Results) add(t TargetResult) {
+	r.mut.Lock()
+	r.targets = append(r.targets, t)
+	r.mut.Unlock()
+}
+
+// Targets returns the results of each executed test target in the order that
+// they were executed.
+func (r *Results) Targets() []TargetResult {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	return append([]TargetResult{}, r.targets...)
+}
+
+var ansiColourRegexp = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+// WriteJUnit writes the results as a JUnit XML report, where each test target
+// is a test suite containing a test case for each of its tests. Linting errors
+// of a target are reported as a failed test case named lint.
+func (r *Results) WriteJUnit(w io.Writer) error {
+	var report junitTestSuites
+	for _, t := range r.Targets() {
+		suite := junitTestSuite{Name: t.Path}
+
+		if len(t.Lints) > 0 {
+			suite.TestCases = append(suite.TestCases, junitTestCase{
+				Name:      "lint",
+				ClassName: t.Path,
+				Failure: &junitFailure{
+					Message: "linting errors",
+					Text:    strings.Join(t.Lints, "\n"),
+				},
+			})
+		}
+
+		for _, c := range t.Cases {
+			tc := junitTestCase{Name: c.Name, ClassName: t.Path}
+
+			var reasons []string
+			for _, f := range t.Failures {
+				if f.Name == c.Name && f.TestLine == c.line {
+					reasons = append(reasons, f.Reason)
+				}
+			}
+			if len(reasons) > 0 {
+				tc.Failure = &junitFailure{
+					Message: "test failed",
+					Text:    ansiColourRegexp.ReplaceAllString(strings.Join(reasons, "\n"), ""),
+				}
+			}
+			suite.TestCases = append(suite.TestCases, tc)
+		}
+
+		for _, tc := range suite.TestCases {
+			suite.Tests++
+			if tc.Failure != nil {
+				suite.Failures++
+			}
+		}
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Suites = append(report.Suites, suite)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
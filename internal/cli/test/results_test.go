@@ -0,0 +1,61 @@
+package test_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/cli/test"
+	"github.com/benthosdev/benthos/v4/internal/log"
+)
+
+func TestResultsJUnit(t *testing.T) {
+	testDir, err := initTestFiles(t, map[string]string{
+		"foo.yaml": `
+pipeline:
+  meow: woof
+  processors:
+  - bloblang: 'root = content().uppercase()'`,
+		"foo_benthos_test.yaml": `
+tests:
+  - name: passes
+    input_batch:
+      - content: 'example content'
+    output_batches:
+      -
+        - content_equals: EXAMPLE CONTENT
+  - name: fails
+    input_batch:
+      - content: 'example content'
+    output_batches:
+      -
+        - content_equals: example content`,
+	})
+	require.NoError(t, err)
+
+	color.NoColor = true
+	results := &test.Results{}
+	assert.False(t, test.RunAll([]string{filepath.Join(testDir, "foo.yaml")}, "_benthos_test", true, log.Noop(), nil, test.OptRecordResults(results)))
+
+	var buf bytes.Buffer
+	require.NoError(t, results.WriteJUnit(&buf))
+
+	confPath := filepath.Join(testDir, "foo.yaml")
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites tests="3" failures="2">
+  <testsuite name="`+confPath+`" tests="3" failures="2">
+    <testcase name="lint" classname="`+confPath+`">
+      <failure message="linting errors">line 3: field meow not recognised</failure>
+    </testcase>
+    <testcase name="passes" classname="`+confPath+`"></testcase>
+    <testcase name="fails" classname="`+confPath+`">
+      <failure message="test failed">batch 0 message 0: content_equals: content mismatch&#xA;  expected: example content&#xA;  received: EXAMPLE CONTENT</failure>
+    </testcase>
+  </testsuite>
+</testsuites>
+`, buf.String())
+}
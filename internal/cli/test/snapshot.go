@@ -0,0 +1,128 @@
+package test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// snapshot contains the batches resulting from a test case, recorded in a
+// golden file that subsequent runs of the test case are compared against.
+type snapshot struct {
+	OutputBatches [][]ConditionsMap             `yaml:"output_batches,omitempty"`
+	Outputs       map[string]OutputExpectations `yaml:"outputs,omitempty"`
+}
+
+type snapshotPart struct {
+	ContentEquals  string            `yaml:"content_equals"`
+	MetadataEquals map[string]string `yaml:"metadata_equals,omitempty"`
+}
+
+type snapshotOutput struct {
+	OutputBatches [][]snapshotPart `yaml:"output_batches"`
+}
+
+type snapshotFile struct {
+	OutputBatches [][]snapshotPart          `yaml:"output_batches,omitempty"`
+	Outputs       map[string]snapshotOutput `yaml:"outputs,omitempty"`
+}
+
+func snapshotBatches(batches []*message.Batch) [][]snapshotPart {
+	parts := make([][]snapshotPart, 0, len(batches))
+	for _, b := range batches {
+		var batchParts []snapshotPart
+		_ = b.Iter(func(_ int, p *message.Part) error {
+			sPart := snapshotPart{ContentEquals: string(p.Get())}
+			_ = p.MetaIter(func(k, v string) error {
+				if sPart.MetadataEquals == nil {
+					sPart.MetadataEquals = map[string]string{}
+				}
+				sPart.MetadataEquals[k] = v
+				return nil
+			})
+			batchParts = append(batchParts, sPart)
+			return nil
+		})
+		parts = append(parts, batchParts)
+	}
+	return parts
+}
+
+func capturedByOutput(captured []CapturedBatch) map[string][]*message.Batch {
+	outputs := map[string][]*message.Batch{}
+	for _, b := range captured {
+		outputs[b.Output] = append(outputs[b.Output], b.Batch)
+	}
+	return outputs
+}
+
+// writeSnapshot records the resulting batches of a test case into its snapshot
+// file. The batches of stream tests are recorded for each captured output.
+func (c *Case) writeSnapshot(dir string, outputBatches []*message.Batch, captured []CapturedBatch) error {
+	var file snapshotFile
+	if c.TargetStream {
+		file.Outputs = map[string]snapshotOutput{}
+		for name, batches := range capturedByOutput(captured) {
+			file.Outputs[name] = snapshotOutput{OutputBatches: snapshotBatches(batches)}
+		}
+	} else {
+		file.OutputBatches = snapshotBatches(outputBatches)
+	}
+
+	fileBytes, err := yaml.Marshal(file)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(dir, c.Snapshot)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, fileBytes, 0o644)
+}
+
+// checkSnapshot compares the resulting batches of a test case against its
+// snapshot file.
+func (c *Case) checkSnapshot(dir string, outputBatches []*message.Batch, captured []CapturedBatch) (failures []CaseFailure) {
+	path := filepath.Join(dir, c.Snapshot)
+	prefix := fmt.Sprintf("snapshot %v: ", c.Snapshot)
+
+	fileBytes, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []CaseFailure{c.failure(prefix + "file does not exist, run with --update-snapshots in order to create it")}
+		}
+		return []CaseFailure{c.failure(prefix + fmt.Sprintf("failed to read file: %v", err))}
+	}
+
+	var snap snapshot
+	if err := yaml.Unmarshal(fileBytes, &snap); err != nil {
+		return []CaseFailure{c.failure(prefix + fmt.Sprintf("failed to parse file: %v", err))}
+	}
+
+	if !c.TargetStream {
+		return c.checkBatches(dir, prefix, snap.OutputBatches, outputBatches)
+	}
+
+	outputs := capturedByOutput(captured)
+	names := make([]string, 0, len(outputs)+len(snap.Outputs))
+	for name := range snap.Outputs {
+		names = append(names, name)
+	}
+	for name := range outputs {
+		if _, exists := snap.Outputs[name]; !exists {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		failures = append(failures, c.checkBatches(dir, fmt.Sprintf("%voutput %v: ", prefix, name), snap.Outputs[name].OutputBatches, outputs[name])...)
+	}
+	return
+}
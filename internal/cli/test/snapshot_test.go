@@ -0,0 +1,132 @@
+package test_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/cli/test"
+	"github.com/benthosdev/benthos/v4/internal/log"
+)
+
+func TestSnapshots(t *testing.T) {
+	testDir, err := initTestFiles(t, map[string]string{
+		"config.yaml": `
+pipeline:
+  processors:
+    - bloblang: |
+        root = content().uppercase()
+        meta result = "done"
+output:
+  switch:
+    cases:
+      - check: content() == "FOO"
+        output:
+          label: foos
+          aws_s3:
+            bucket: foo
+      - output:
+          label: others
+          aws_s3:
+            bucket: bar
+`,
+	})
+	require.NoError(t, err)
+
+	var definition test.Definition
+	require.NoError(t, yaml.Unmarshal([]byte(`
+tests:
+  - name: processors
+    snapshot: snapshots/processors.yaml
+    input_batch:
+      - content: foo
+      - content: bar
+  - name: stream
+    target_stream: true
+    snapshot: snapshots/stream.yaml
+    input_batch:
+      - content: foo
+      - content: bar
+`), &definition))
+
+	configPath := filepath.Join(testDir, "config.yaml")
+	color.NoColor = true
+
+	failures, err := definition.Execute(configPath, nil, log.Noop())
+	require.NoError(t, err)
+	require.Len(t, failures, 2)
+	assert.Equal(t, "processors [line 3]: snapshot snapshots/processors.yaml: file does not exist, run with --update-snapshots in order to create it", failures[0].String())
+
+	failures, err = definition.Execute(configPath, nil, log.Noop(), test.OptUpdateSnapshots())
+	require.NoError(t, err)
+	assert.Empty(t, failures)
+
+	procsBytes, err := os.ReadFile(filepath.Join(testDir, "snapshots", "processors.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, `output_batches:
+    - - content_equals: FOO
+        metadata_equals:
+            result: done
+      - content_equals: BAR
+        metadata_equals:
+            result: done
+`, string(procsBytes))
+
+	streamBytes, err := os.ReadFile(filepath.Join(testDir, "snapshots", "stream.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, `outputs:
+    foos:
+        output_batches:
+            - - content_equals: FOO
+                metadata_equals:
+                    result: done
+    others:
+        output_batches:
+            - - content_equals: BAR
+                metadata_equals:
+                    result: done
+`, string(streamBytes))
+
+	failures, err = definition.Execute(configPath, nil, log.Noop())
+	require.NoError(t, err)
+	assert.Empty(t, failures)
+
+	require.NoError(t, os.WriteFile(filepath.Join(testDir, "config.yaml"), []byte(`
+pipeline:
+  processors:
+    - bloblang: |
+        root = content().uppercase()
+        meta result = "done"
+output:
+  switch:
+    cases:
+      - check: content() == "NOPE"
+        output:
+          label: foos
+          aws_s3:
+            bucket: foo
+      - output:
+          label: others
+          aws_s3:
+            bucket: bar
+`), 0o644))
+
+	failures, err = definition.Execute(configPath, nil, log.Noop())
+	require.NoError(t, err)
+
+	var failureStrs []string
+	for _, f := range failures {
+		failureStrs = append(failureStrs, f.String())
+	}
+	assert.Equal(t, []string{
+		"stream [line 8]: snapshot snapshots/stream.yaml: output foos: wrong batch count, expected 1, got 0",
+		"stream [line 8]: snapshot snapshots/stream.yaml: output others: mismatch of output batch 0 message counts, expected 1, got 2",
+		"stream [line 8]: snapshot snapshots/stream.yaml: output others: batch 0 message 0: content_equals: content mismatch\n  expected: BAR\n  received: FOO",
+		"stream [line 8]: snapshot snapshots/stream.yaml: output others: unexpected message from batch 0: BAR",
+	}, failureStrs)
+}
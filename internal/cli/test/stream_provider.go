@@ -0,0 +1,323 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/internal/config"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/manager"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/stream"
+)
+
+const (
+	streamTestInputPipe  = "benthos_test_input"
+	streamTestOutputPipe = "benthos_test_output_"
+	streamTestTimeout    = time.Second * 30
+)
+
+// CapturedBatch is a message batch that was written to a captured output of a
+// stream under test.
+type CapturedBatch struct {
+	// The label of the output, or a JSON Pointer to the output when it does
+	// not have a label.
+	Output string
+	Batch  *message.Batch
+}
+
+// StreamExecutor sends a message batch through a stream and returns the batches
+// written to each captured output in the order that they were written.
+type StreamExecutor interface {
+	// Outputs returns the names of all captured outputs of the stream.
+	Outputs() []string
+
+	// Execute the stream with a batch of messages.
+	Execute(batch *message.Batch) ([]CapturedBatch, error)
+}
+
+type capturedOutput struct {
+	name string
+
+	// The path of the output within the config in dot path form, where
+	// resources are identified by their label.
+	path string
+}
+
+type streamExecutor struct {
+	p        *ProcessorsProvider
+	mgr      manager.ResourceConfig
+	stream   stream.Config
+	captures map[string]capturedOutput
+	outputs  []string
+}
+
+// ProvideStream attempts to construct a full stream from a Benthos config,
+// where the input is replaced with one that injects test messages and each
+// output that isn't mocked is replaced with one that captures the messages
+// written to it. Processors of the replaced input and outputs are retained.
+func (p *ProcessorsProvider) ProvideStream(environment map[string]string, mocks map[string]yaml.Node) (StreamExecutor, error) {
+	target, err := p.readTarget(p.targetPath, environment, mocks)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &streamExecutor{
+		p:        p,
+		captures: map[string]capturedOutput{},
+	}
+
+	root := unwrapNode(target.root)
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("failed to parse config file '%v': expected an object", p.targetPath)
+	}
+	replaceInput(root)
+
+	for _, r := range append([]*yaml.Node{target.root}, target.resourceRoots...) {
+		if err := e.captureOutputs(r, target.mocked); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := target.decodeResources(p.targetPath); err != nil {
+		return nil, err
+	}
+	e.mgr = target.resources
+
+	conf := config.New()
+	if err := target.root.Decode(&conf); err != nil {
+		return nil, fmt.Errorf("failed to parse config file '%v': %v", p.targetPath, err)
+	}
+	e.stream = conf.Config
+	return e, nil
+}
+
+// replaceInput swaps the input of a config for an inproc input that test
+// messages are sent through, retaining the label and processors of the
+// original.
+func replaceInput(root *yaml.Node) {
+	input := &yaml.Node{Kind: yaml.MappingNode}
+
+	replaced := false
+	for i := 0; i < len(root.Content)-1; i += 2 {
+		if root.Content[i].Value != "input" {
+			continue
+		}
+		if prev := root.Content[i+1]; prev.Kind == yaml.MappingNode {
+			for j := 0; j < len(prev.Content)-1; j += 2 {
+				switch prev.Content[j].Value {
+				case "label", "processors":
+					input.Content = append(input.Content, prev.Content[j], prev.Content[j+1])
+				}
+			}
+		}
+		root.Content[i+1] = input
+		replaced = true
+		break
+	}
+	if !replaced {
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: "input"}, input)
+	}
+
+	input.Content = append(input.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Value: "inproc"},
+		&yaml.Node{Kind: yaml.ScalarNode, Value: streamTestInputPipe},
+	)
+}
+
+// nodePaths maps each node of a YAML tree to its path.
+func nodePaths(node *yaml.Node, path []string, paths map[*yaml.Node][]string) {
+	node = unwrapNode(node)
+	paths[node] = path
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i < len(node.Content)-1; i += 2 {
+			nodePaths(node.Content[i+1], append(append([]string{}, path...), node.Content[i].Value), paths)
+		}
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			nodePaths(child, append(append([]string{}, path...), strconv.Itoa(i)), paths)
+		}
+	}
+}
+
+func pathToJSONPointer(path []string) string {
+	var b strings.Builder
+	for _, p := range path {
+		b.WriteByte('/')
+		b.WriteString(strings.ReplaceAll(strings.ReplaceAll(p, "~", "~0"), "/", "~1"))
+	}
+	return b.String()
+}
+
+func hasPathPrefix(path, prefix []string) bool {
+	if len(path) <= len(prefix) {
+		return false
+	}
+	for i, p := range prefix {
+		if path[i] != p {
+			return false
+		}
+	}
+	return true
+}
+
+// captureOutputs replaces each output of a config that does not contain other
+// outputs, and which is neither a reference to a resource nor mocked, with an
+// inproc output that the messages written to it are captured from.
+func (e *streamExecutor) captureOutputs(root *yaml.Node, mocked map[*yaml.Node]struct{}) error {
+	paths := map[*yaml.Node][]string{}
+	nodePaths(root, nil, paths)
+
+	var mockedPaths [][]string
+	for node := range mocked {
+		if path, exists := paths[node]; exists {
+			mockedPaths = append(mockedPaths, path)
+		}
+	}
+
+	var outputs []docs.WalkedYAMLComponent
+	docs.YAMLWalker{
+		OnComponent: func(c docs.WalkedYAMLComponent) {
+			if c.Type == docs.TypeOutput {
+				outputs = append(outputs, c)
+			}
+		},
+	}.WalkFields(config.Spec(), root)
+
+componentsLoop:
+	for _, c := range outputs {
+		path := paths[c.Node]
+		if _, isMock := mocked[c.Node]; isMock || c.Name == "resource" {
+			continue
+		}
+		for _, mPath := range mockedPaths {
+			if hasPathPrefix(path, mPath) {
+				continue componentsLoop
+			}
+		}
+		for _, other := range outputs {
+			if hasPathPrefix(paths[other.Node], path) {
+				continue componentsLoop
+			}
+		}
+
+		name := c.Label
+		if name == "" {
+			name = pathToJSONPointer(path)
+		}
+		for _, existing := range e.captures {
+			if existing.name == name {
+				return fmt.Errorf("multiple outputs are identified by '%v'", name)
+			}
+		}
+
+		capturePath := path
+		if len(path) >= 2 && strings.HasSuffix(path[0], "_resources") && c.Label != "" {
+			capturePath = append([]string{path[0], c.Label}, path[2:]...)
+		}
+
+		pipe := streamTestOutputPipe + strconv.Itoa(len(e.captures))
+		e.captures[pipe] = capturedOutput{name: name, path: query.SliceToDotPath(capturePath...)}
+		e.outputs = append(e.outputs, name)
+
+		var content []*yaml.Node
+		for i := 0; i < len(c.Node.Content)-1; i += 2 {
+			switch c.Node.Content[i].Value {
+			case "label", "processors":
+				content = append(content, c.Node.Content[i], c.Node.Content[i+1])
+			}
+		}
+		c.Node.Content = append(content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: "inproc"},
+			&yaml.Node{Kind: yaml.ScalarNode, Value: pipe},
+		)
+	}
+	return nil
+}
+
+func (e *streamExecutor) Outputs() []string {
+	return e.outputs
+}
+
+func (e *streamExecutor) Execute(batch *message.Batch) (captured []CapturedBatch, err error) {
+	mgr, err := e.p.newManager(e.mgr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialise resources: %v", err)
+	}
+	defer func() {
+		mgr.CloseAsync()
+		_ = mgr.WaitForClose(streamTestTimeout)
+	}()
+
+	tranChan := make(chan message.Transaction)
+	mgr.SetPipe(streamTestInputPipe, tranChan)
+
+	strm, err := stream.New(e.stream, mgr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialise stream: %v", err)
+	}
+	defer func() {
+		_ = strm.Stop(streamTestTimeout)
+	}()
+
+	ctx, done := context.WithTimeout(context.Background(), streamTestTimeout)
+	defer done()
+
+	var capturedMut sync.Mutex
+	var wg sync.WaitGroup
+	for pipe, capture := range e.captures {
+		outChan, err := mgr.GetPipe(pipe)
+		if err != nil {
+			return nil, fmt.Errorf("failed to capture output '%v': %v", capture.name, err)
+		}
+		wg.Add(1)
+		go func(capture capturedOutput, outChan <-chan message.Transaction) {
+			defer wg.Done()
+			for {
+				select {
+				case tran, open := <-outChan:
+					if !open {
+						return
+					}
+					capturedMut.Lock()
+					captured = append(captured, CapturedBatch{
+						Output: capture.name,
+						Batch:  tran.Payload.DeepCopy(),
+					})
+					capturedMut.Unlock()
+					if e.p.coverage != nil {
+						e.p.coverage.outputCaptured(capture.path, tran.Payload.Len())
+					}
+					_ = tran.Ack(ctx, nil)
+				case <-ctx.Done():
+					return
+				}
+			}
+		}(capture, outChan)
+	}
+
+	resChan := make(chan error)
+	select {
+	case tranChan <- message.NewTransaction(batch, resChan):
+	case <-ctx.Done():
+		return nil, fmt.Errorf("timed out sending input batch to stream")
+	}
+
+	select {
+	case err = <-resChan:
+	case <-ctx.Done():
+		err = fmt.Errorf("timed out waiting for input batch to be acknowledged")
+	}
+
+	done()
+	wg.Wait()
+	return captured, err
+}
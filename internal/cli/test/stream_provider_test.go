@@ -0,0 +1,202 @@
+package test_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/cli/test"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+func executeDefinition(t *testing.T, configPath string, resourcesPaths []string, def string) []string {
+	t.Helper()
+
+	var definition test.Definition
+	require.NoError(t, yaml.Unmarshal([]byte(def), &definition))
+
+	failures, err := definition.Execute(configPath, resourcesPaths, log.Noop())
+	require.NoError(t, err)
+
+	var failureStrs []string
+	for _, f := range failures {
+		failureStrs = append(failureStrs, f.String())
+	}
+	return failureStrs
+}
+
+func TestStreamProviderSwitch(t *testing.T) {
+	testDir, err := initTestFiles(t, map[string]string{
+		"config.yaml": `
+input:
+  kafka:
+    addresses: [ localhost:9092 ]
+    topics: [ foo ]
+    consumer_group: bar
+  processors:
+    - bloblang: 'root = this.merge({"read": true})'
+pipeline:
+  processors:
+    - bloblang: 'root = this.merge({"processed": true})'
+output:
+  switch:
+    cases:
+      - check: this.type == "a"
+        output:
+          label: type_a
+          aws_s3:
+            bucket: foo
+            path: a
+          processors:
+            - bloblang: 'root = this.merge({"output": "a"})'
+      - check: this.type == "b"
+        output:
+          label: type_b
+          aws_s3:
+            bucket: foo
+            path: b
+      - output:
+          drop: {}
+`,
+	})
+	require.NoError(t, err)
+
+	provider := test.NewProcessorsProvider(filepath.Join(testDir, "config.yaml"))
+	executor, err := provider.ProvideStream(nil, nil)
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{
+		"type_a", "type_b", "/output/switch/cases/2/output",
+	}, executor.Outputs())
+
+	captured, err := executor.Execute(message.QuickBatch([][]byte{
+		[]byte(`{"type":"a","id":1}`),
+	}))
+	require.NoError(t, err)
+	require.Len(t, captured, 1)
+	assert.Equal(t, "type_a", captured[0].Output)
+	assert.Equal(t, `{"id":1,"output":"a","processed":true,"read":true,"type":"a"}`, string(captured[0].Batch.Get(0).Get()))
+
+	color.NoColor = true
+	failures := executeDefinition(t, filepath.Join(testDir, "config.yaml"), nil, `
+tests:
+  - name: routes messages
+    target_stream: true
+    input_batch:
+      - content: '{"type":"a","id":1}'
+      - content: '{"type":"b","id":2}'
+      - content: '{"type":"c","id":3}'
+      - content: '{"type":"b","id":4}'
+    outputs:
+      type_a:
+        output_batches:
+          - - json_contains: { "id": 1, "output": "a" }
+      type_b:
+        output_batches:
+          - - json_contains: { "id": 2 }
+            - json_contains: { "id": 4 }
+      /output/switch/cases/2/output:
+        output_batches:
+          - - json_equals: { "type": "c", "id": 3, "read": true, "processed": true }
+
+  - name: wrong expectations
+    target_stream: true
+    input_batch:
+      - content: '{"type":"a","id":1}'
+    outputs:
+      type_a:
+        output_batches:
+          - - content_equals: '{"id":2}'
+      type_b:
+        output_batches:
+          - - json_contains: { "id": 1 }
+`)
+	assert.Equal(t, []string{
+		"wrong expectations [line 22]: output type_a: batch 0 message 0: content_equals: content mismatch\n  expected: {\"id\":2}\n  received: {\"id\":1,\"output\":\"a\",\"processed\":true,\"read\":true,\"type\":\"a\"}",
+		"wrong expectations [line 22]: output type_b: wrong batch count, expected 1, got 0",
+	}, failures)
+}
+
+func TestStreamProviderMocks(t *testing.T) {
+	testDir, err := initTestFiles(t, map[string]string{
+		"config.yaml": `
+input:
+  generate:
+    mapping: 'root = "not used"'
+pipeline:
+  processors:
+    - label: enrich
+      http:
+        url: http://localhost:1234/nope
+    - cache:
+        resource: foocache
+        operator: add
+        key: ${! content() }
+        value: present
+output:
+  fallback:
+    - label: primary
+      http_client:
+        url: http://localhost:1234/nope
+    - label: secondary
+      aws_s3:
+        bucket: foo
+`,
+		"resources.yaml": `
+cache_resources:
+  - label: foocache
+    redis:
+      url: tcp://localhost:1234
+`,
+	})
+	require.NoError(t, err)
+
+	color.NoColor = true
+	failures := executeDefinition(t, filepath.Join(testDir, "config.yaml"), []string{
+		filepath.Join(testDir, "resources.yaml"),
+	}, `
+tests:
+  - name: falls back
+    target_stream: true
+    mocks:
+      enrich:
+        bloblang: 'root = content().uppercase()'
+      foocache:
+        memory:
+          init_values:
+            FOO: present
+      primary:
+        reject: 'nope'
+    input_batch:
+      - content: 'bar'
+    output_batches:
+      - - content_equals: BAR
+    outputs:
+      secondary:
+        output_batches:
+          - - content_equals: BAR
+
+  - name: cache rejects duplicates
+    target_stream: true
+    mocks:
+      enrich:
+        bloblang: 'root = content().uppercase()'
+      foocache:
+        memory:
+          init_values:
+            FOO: present
+      primary:
+        drop: {}
+    input_batch:
+      - content: 'foo'
+    outputs:
+      secondary:
+        output_batches: []
+`)
+	assert.Empty(t, failures)
+}
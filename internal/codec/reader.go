@@ -7,6 +7,7 @@ import (
 	"compress/gzip"
 	"context"
 	"encoding/csv"
+	"encoding/xml"
 	"errors"
 	"fmt"
 	"io"
@@ -16,6 +17,9 @@ import (
 	"strings"
 	"sync"
 
+	"golang.org/x/net/html/charset"
+	"gopkg.in/yaml.v3"
+
 	"github.com/benthosdev/benthos/v4/internal/docs"
 	"github.com/benthosdev/benthos/v4/internal/message"
 )
@@ -35,6 +39,7 @@ var ReaderDocs = docs.FieldString(
 	"multipart", "Consumes the output of another codec and batches messages together. A batch ends when an empty message is consumed. For example, the codec `lines/multipart` could be used to consume multipart messages where an empty line indicates the end of each batch.",
 	"regex:(?m)^\\d\\d:\\d\\d:\\d\\d", "Consume the file in segments divided by regular expression.",
 	"tar", "Parse the file as a tar archive, and consume each file of the archive as a message.",
+	"xml:x", "Consume each element of a given name from an XML document as a message, where the element is streamed from the document rather than loading the entire document in memory. Elements are matched either by their local name or by their prefixed name, e.g. the codec `xml:item` would consume each `<item>` element. Matching elements nested within another matching element are consumed as part of the outer element.",
 ).LinterFunc(nil) // Disable default option linter as it doesn't include foo:bar formats.
 
 //------------------------------------------------------------------------------
@@ -251,6 +256,15 @@ func partReader(codec string, conf ReaderConfig) (ReaderConstructor, bool, error
 			return newChunkerReader(conf, r, chunkSize, fn)
 		}, true, nil
 	}
+	if strings.HasPrefix(codec, "xml:") {
+		name := strings.TrimPrefix(codec, "xml:")
+		if name == "" {
+			return nil, false, errors.New("xml codec requires a non-empty element name")
+		}
+		return func(path string, r io.ReadCloser, fn ReaderAckFn) (Reader, error) {
+			return newXMLElementReader(r, name, fn)
+		}, true, nil
+	}
 	if strings.HasPrefix(codec, "regex:") {
 		by := strings.TrimPrefix(codec, "regex:")
 		if by == "" {
@@ -263,6 +277,29 @@ func partReader(codec string, conf ReaderConfig) (ReaderConstructor, bool, error
 	return nil, false, nil
 }
 
+func init() {
+	docs.RegisterMigration(docs.Migration{
+		Type:    docs.TypeInput,
+		Migrate: migrateDeprecatedCodec,
+	})
+}
+
+// migrateDeprecatedCodec replaces deprecated codecs within the codec field of
+// an input with their chained equivalents.
+func migrateDeprecatedCodec(c docs.WalkedYAMLComponent) ([]string, error) {
+	codec := docs.YAMLMappingGet(c.Config, "codec")
+	if codec == nil || codec.Kind != yaml.ScalarNode {
+		return nil, nil
+	}
+	replacement := convertDeprecatedCodec(codec.Value)
+	if replacement == codec.Value {
+		return nil, nil
+	}
+	what := fmt.Sprintf("deprecated codec %v of input %v replaced with %v", codec.Value, c.Name, replacement)
+	codec.Value = replacement
+	return []string{what}, nil
+}
+
 func convertDeprecatedCodec(codec string) string {
 	switch codec {
 	case "csv-gzip":
@@ -923,3 +960,215 @@ func (a *regexReader) Close(ctx context.Context) error {
 	}
 	return a.r.Close()
 }
+
+//------------------------------------------------------------------------------
+
+type xmlElementReader struct {
+	dec       *xml.Decoder
+	element   string
+	r         io.ReadCloser
+	sourceAck ReaderAckFn
+
+	// Namespace declarations of each open element, used in order to copy
+	// those declared on ancestors onto the elements that are emitted.
+	namespaces [][]xml.Attr
+
+	mut      sync.Mutex
+	finished bool
+	pending  int32
+}
+
+func newXMLElementReader(r io.ReadCloser, element string, ackFn ReaderAckFn) (Reader, error) {
+	dec := xml.NewDecoder(r)
+	dec.Strict = false
+	dec.CharsetReader = charset.NewReaderLabel
+	return &xmlElementReader{
+		dec:       dec,
+		element:   element,
+		r:         r,
+		sourceAck: ackOnce(ackFn),
+	}, nil
+}
+
+func (a *xmlElementReader) ack(ctx context.Context, err error) error {
+	a.mut.Lock()
+	a.pending--
+	doAck := a.pending == 0 && a.finished
+	a.mut.Unlock()
+
+	if err != nil {
+		return a.sourceAck(ctx, err)
+	}
+	if doAck {
+		return a.sourceAck(ctx, nil)
+	}
+	return nil
+}
+
+func xmlQualifiedName(n xml.Name) string {
+	if n.Space == "" {
+		return n.Local
+	}
+	return n.Space + ":" + n.Local
+}
+
+// writeXMLToken writes a raw token back out as XML. Raw tokens are used so that
+// namespace prefixes are written exactly as they appear within the source.
+func writeXMLToken(buf *bytes.Buffer, tok xml.Token) error {
+	switch t := tok.(type) {
+	case xml.StartElement:
+		buf.WriteByte('<')
+		buf.WriteString(xmlQualifiedName(t.Name))
+		for _, attr := range t.Attr {
+			buf.WriteByte(' ')
+			buf.WriteString(xmlQualifiedName(attr.Name))
+			buf.WriteString(`="`)
+			if err := xml.EscapeText(buf, []byte(attr.Value)); err != nil {
+				return err
+			}
+			buf.WriteByte('"')
+		}
+		buf.WriteByte('>')
+	case xml.EndElement:
+		buf.WriteString("</")
+		buf.WriteString(xmlQualifiedName(t.Name))
+		buf.WriteByte('>')
+	case xml.CharData:
+		return xml.EscapeText(buf, t)
+	case xml.Comment:
+		buf.WriteString("<!--")
+		buf.Write(t)
+		buf.WriteString("-->")
+	case xml.ProcInst:
+		buf.WriteString("<?")
+		buf.WriteString(t.Target)
+		if len(t.Inst) > 0 {
+			buf.WriteByte(' ')
+			buf.Write(t.Inst)
+		}
+		buf.WriteString("?>")
+	case xml.Directive:
+		buf.WriteString("<!")
+		buf.Write(t)
+		buf.WriteByte('>')
+	}
+	return nil
+}
+
+func isXMLNamespaceDecl(attr xml.Attr) bool {
+	return attr.Name.Space == "xmlns" || (attr.Name.Space == "" && attr.Name.Local == "xmlns")
+}
+
+// trackNamespaces pushes the namespace declarations of a start element onto
+// the stack and pops them for an end element.
+func (a *xmlElementReader) trackNamespaces(tok xml.Token) {
+	switch t := tok.(type) {
+	case xml.StartElement:
+		var decls []xml.Attr
+		for _, attr := range t.Attr {
+			if isXMLNamespaceDecl(attr) {
+				decls = append(decls, attr)
+			}
+		}
+		a.namespaces = append(a.namespaces, decls)
+	case xml.EndElement:
+		if len(a.namespaces) > 0 {
+			a.namespaces = a.namespaces[:len(a.namespaces)-1]
+		}
+	}
+}
+
+// withInheritedNamespaces returns a start element with the namespace
+// declarations that are in scope from its ancestors added, unless the element
+// declares them itself, so that it remains valid outside of the document.
+func (a *xmlElementReader) withInheritedNamespaces(start xml.StartElement) xml.StartElement {
+	declared := map[xml.Name]struct{}{}
+	for _, attr := range start.Attr {
+		if isXMLNamespaceDecl(attr) {
+			declared[attr.Name] = struct{}{}
+		}
+	}
+
+	var inherited []xml.Attr
+	for i := len(a.namespaces) - 1; i >= 0; i-- {
+		for _, attr := range a.namespaces[i] {
+			if _, exists := declared[attr.Name]; exists {
+				continue
+			}
+			declared[attr.Name] = struct{}{}
+			inherited = append(inherited, attr)
+		}
+	}
+	if len(inherited) == 0 {
+		return start
+	}
+
+	start.Attr = append(inherited, start.Attr...)
+	return start
+}
+
+func (a *xmlElementReader) nextElement() ([]byte, error) {
+	var buf bytes.Buffer
+	depth := 0
+	for {
+		tok, err := a.dec.RawToken()
+		if err != nil {
+			if errors.Is(err, io.EOF) && depth > 0 {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		if depth == 0 {
+			start, ok := tok.(xml.StartElement)
+			if !ok || (start.Name.Local != a.element && xmlQualifiedName(start.Name) != a.element) {
+				a.trackNamespaces(tok)
+				continue
+			}
+			tok = a.withInheritedNamespaces(start)
+		}
+		a.trackNamespaces(tok)
+		switch tok.(type) {
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			depth--
+		}
+		if err := writeXMLToken(&buf, tok); err != nil {
+			return nil, err
+		}
+		if depth == 0 {
+			return buf.Bytes(), nil
+		}
+	}
+}
+
+func (a *xmlElementReader) Next(ctx context.Context) ([]*message.Part, ReaderAckFn, error) {
+	elementBytes, err := a.nextElement()
+
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	if err == nil {
+		a.pending++
+		return []*message.Part{message.NewPart(elementBytes)}, a.ack, nil
+	}
+	if errors.Is(err, io.EOF) {
+		a.finished = true
+	} else {
+		_ = a.sourceAck(ctx, err)
+	}
+	return nil, nil, err
+}
+
+func (a *xmlElementReader) Close(ctx context.Context) error {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	if !a.finished {
+		_ = a.sourceAck(ctx, errors.New("service shutting down"))
+	}
+	if a.pending == 0 {
+		_ = a.sourceAck(ctx, nil)
+	}
+	return a.r.Close()
+}
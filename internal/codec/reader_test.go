@@ -13,7 +13,9 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 
+	"github.com/benthosdev/benthos/v4/internal/docs"
 	"github.com/benthosdev/benthos/v4/internal/message"
 )
 
@@ -770,3 +772,51 @@ func TestRegexpSplitReader(t *testing.T) {
 	data = []byte("")
 	testReaderSuite(t, "regex:split", "", data)
 }
+
+func TestXMLElementReader(t *testing.T) {
+	data := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<root>
+  <meta>ignored</meta>
+  <item id="1">foo</item>
+  <item id="2"><name>bar &amp; baz</name><!-- comment --></item>
+  <other><item>nested</item></other>
+</root>`)
+	testReaderSuite(t, "xml:item", "", data,
+		`<item id="1">foo</item>`,
+		`<item id="2"><name>bar &amp; baz</name><!-- comment --></item>`,
+		`<item>nested</item>`,
+	)
+
+	data = []byte(`<ns:root xmlns:ns="http://example.com"><ns:item a="b"><ns:item>inner</ns:item></ns:item></ns:root>`)
+	testReaderSuite(t, "xml:item", "", data, `<ns:item xmlns:ns="http://example.com" a="b"><ns:item>inner</ns:item></ns:item>`)
+	testReaderSuite(t, "xml:ns:item", "", data, `<ns:item xmlns:ns="http://example.com" a="b"><ns:item>inner</ns:item></ns:item>`)
+
+	data = []byte(`<root xmlns="http://example.com/a" xmlns:x="http://example.com/x"><list xmlns:x="http://example.com/y"><item x:id="1">foo</item><item xmlns="http://example.com/b">bar</item></list></root>`)
+	testReaderSuite(t, "xml:item", "", data,
+		`<item xmlns:x="http://example.com/y" xmlns="http://example.com/a" x:id="1">foo</item>`,
+		`<item xmlns:x="http://example.com/y" xmlns="http://example.com/b">bar</item>`,
+	)
+
+	data = []byte(`<root><other>foo</other></root>`)
+	testReaderSuite(t, "xml:item", "", data)
+}
+
+func TestMigrateDeprecatedCodec(t *testing.T) {
+	for _, test := range []struct {
+		codec    string
+		expected string
+		changed  bool
+	}{
+		{codec: "csv-gzip", expected: "gzip/csv", changed: true},
+		{codec: "tar-gzip", expected: "gzip/tar", changed: true},
+		{codec: "lines", expected: "lines"},
+	} {
+		var conf yaml.Node
+		require.NoError(t, yaml.Unmarshal([]byte("codec: "+test.codec), &conf))
+
+		changes, err := migrateDeprecatedCodec(docs.WalkedYAMLComponent{Name: "file", Config: conf.Content[0]})
+		require.NoError(t, err)
+		assert.Equal(t, test.changed, len(changes) == 1, test.codec)
+		assert.Equal(t, test.expected, docs.YAMLMappingGet(&conf, "codec").Value)
+	}
+}
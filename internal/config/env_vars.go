@@ -2,9 +2,17 @@ package config
 
 import (
 	"bytes"
+	"context"
+	"fmt"
 	"os"
 	"regexp"
 	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/secrets"
 )
 
 var (
@@ -12,6 +20,29 @@ var (
 	escapedEnvRegex = regexp.MustCompile(`\${({[0-9A-Za-z_.]+(:((\${[^}]+})|[^}])+)?})}`)
 )
 
+// secretLookupTimeout is the maximum period of time to wait for each secret to
+// be resolved by its provider.
+const secretLookupTimeout = time.Second * 30
+
+// SecretsMode determines how references to secrets are handled when replacing
+// environment variable interpolations.
+type SecretsMode int
+
+const (
+	// SecretsResolve resolves secret references with their providers, and
+	// tracks the fields they're written to so that they can be redacted.
+	SecretsResolve SecretsMode = iota
+
+	// SecretsPlaceholder replaces secret references with a placeholder that
+	// matches the type of their field without contacting their providers,
+	// which is used by commands that only read configs such as linting.
+	SecretsPlaceholder
+
+	// SecretsDisabled returns an error when a secret reference is found, which
+	// is used for configs supplied via APIs.
+	SecretsDisabled
+)
+
 // ReplaceEnvVariables will search a blob of data for the pattern `${FOO:bar}`,
 // where `FOO` is an environment variable name and `bar` is a default value. The
 // `bar` section (including the colon) can be left out if there is no
@@ -21,8 +52,33 @@ var (
 // respective environment variable will be read and will replace the pattern. If
 // the environment variable is empty or does not exist then either the default
 // value is used or the field will be left empty.
-func ReplaceEnvVariables(inBytes []byte) []byte {
+//
+// The pattern `${secret:file:/foo/bar}` instead references a secret, where the
+// name following `secret:` is the scheme of a secret provider and the remainder
+// is the reference given to it. Secret references are handled according to the
+// provided mode, and when resolved an error is returned if a secret cannot be
+// resolved.
+func ReplaceEnvVariables(inBytes []byte, secretsMode SecretsMode) ([]byte, error) {
+	return replaceEnvVariables("", inBytes, secretsMode)
+}
+
+// replaceEnvVariables replaces environment variable interpolations of a config
+// from a source, such as the path of a file, which identifies the fields of
+// resolved secrets that are tracked for the config.
+func replaceEnvVariables(source string, inBytes []byte, secretsMode SecretsMode) ([]byte, error) {
+	var placeholders map[secretPosition]string
+	if secretsMode == SecretsPlaceholder {
+		placeholders = secretPlaceholders(inBytes)
+	}
+
+	matches := envRegex.FindAllIndex(inBytes, -1)
+	matchIndex := -1
+
+	var resolvedSecrets bool
+	var lookupErr error
 	replaced := envRegex.ReplaceAllFunc(inBytes, func(content []byte) []byte {
+		matchIndex++
+
 		var value string
 		if len(content) > 3 {
 			if colonIndex := bytes.IndexByte(content, ':'); colonIndex == -1 {
@@ -31,9 +87,36 @@ func ReplaceEnvVariables(inBytes []byte) []byte {
 				targetVar := content[2:colonIndex]
 				defaultVal := content[colonIndex+1 : len(content)-1]
 
-				value = os.Getenv(string(targetVar))
-				if value == "" {
-					value = string(defaultVal)
+				if scheme, ref, isSecret := secretReference(string(targetVar), string(defaultVal)); isSecret {
+					switch secretsMode {
+					case SecretsPlaceholder:
+						pos := secretPosition{
+							line:    bytes.Count(inBytes[:matches[matchIndex][0]], []byte("\n")) + 1,
+							content: string(content),
+						}
+						if value = placeholders[pos]; value == "" {
+							value = secrets.Redacted
+						}
+					case SecretsDisabled:
+						if lookupErr == nil {
+							lookupErr = fmt.Errorf("secret reference %s is not allowed within this config", content)
+						}
+						return content
+					default:
+						var err error
+						if value, err = lookupSecret(scheme, ref); err != nil {
+							if lookupErr == nil {
+								lookupErr = err
+							}
+							return content
+						}
+						resolvedSecrets = true
+					}
+				} else {
+					value = os.Getenv(string(targetVar))
+					if value == "" {
+						value = string(defaultVal)
+					}
 				}
 			}
 			// Escape newlines, otherwise there's no way that they would work
@@ -42,6 +125,136 @@ func ReplaceEnvVariables(inBytes []byte) []byte {
 		}
 		return []byte(value)
 	})
+	if lookupErr != nil {
+		return nil, lookupErr
+	}
 	replaced = escapedEnvRegex.ReplaceAll(replaced, []byte("$$$1"))
-	return replaced
+
+	if resolvedSecrets {
+		if err := trackSecretFields(source, inBytes, replaced); err != nil {
+			return nil, err
+		}
+	} else if secretsMode == SecretsResolve && source != "" {
+		secrets.DefaultRegistry.ClearFields(source)
+	}
+	return replaced, nil
+}
+
+// secretPosition identifies a secret reference by its content and the line
+// that it is found on.
+type secretPosition struct {
+	line    int
+	content string
+}
+
+// secretPlaceholders returns the placeholders of secret references that make
+// up the entire value of a config field, where the placeholder matches the type
+// of the field so that configs using placeholders can still be parsed.
+func secretPlaceholders(raw []byte) map[secretPosition]string {
+	if !containsSecretReference(string(raw)) {
+		return nil
+	}
+
+	var rawNode yaml.Node
+	if err := yaml.Unmarshal(raw, &rawNode); err != nil {
+		return nil
+	}
+
+	placeholders := map[secretPosition]string{}
+	addPlaceholder := func(spec docs.FieldSpec, n *yaml.Node) {
+		if n.Kind != yaml.ScalarNode || envRegex.FindString(n.Value) != n.Value || !containsSecretReference(n.Value) {
+			return
+		}
+		placeholders[secretPosition{line: n.Line, content: n.Value}] = secretPlaceholder(spec, n.Style)
+	}
+
+	// Values of fields without a spec are treated as strings, and are then
+	// replaced by the values of fields with a spec.
+	var walkScalars func(n *yaml.Node)
+	walkScalars = func(n *yaml.Node) {
+		addPlaceholder(docs.FieldSpec{}, n)
+		for _, child := range n.Content {
+			walkScalars(child)
+		}
+	}
+	walkScalars(&rawNode)
+
+	docs.YAMLWalker{
+		OnField: func(field docs.WalkedYAMLField) {
+			addPlaceholder(field.Spec, field.Value)
+		},
+	}.WalkFields(Spec(), &rawNode)
+	return placeholders
+}
+
+// secretPlaceholder returns the placeholder of a secret reference that makes
+// up the entire value of a field.
+func secretPlaceholder(spec docs.FieldSpec, style yaml.Style) string {
+	switch spec.Type {
+	case docs.FieldTypeInt, docs.FieldTypeFloat:
+		return "0"
+	case docs.FieldTypeBool:
+		return "false"
+	case docs.FieldTypeString:
+		// Durations are string fields, which are identified by their default.
+		if spec.Default != nil {
+			if d, ok := (*spec.Default).(string); ok && d != "" {
+				if _, err := time.ParseDuration(d); err == nil {
+					return d
+				}
+			}
+		}
+	}
+	// The placeholder would otherwise be parsed as a tag.
+	if style&(yaml.SingleQuotedStyle|yaml.DoubleQuotedStyle) == 0 {
+		return "'" + secrets.Redacted + "'"
+	}
+	return secrets.Redacted
+}
+
+// trackSecretFields records the fields of a config that secrets have been
+// resolved within so that they're redacted from anything that exposes the
+// config.
+func trackSecretFields(source string, raw, resolved []byte) error {
+	var rawNode, resolvedNode yaml.Node
+	if err := yaml.Unmarshal(raw, &rawNode); err != nil {
+		return fmt.Errorf("failed to locate fields of resolved secrets: %w", err)
+	}
+	if err := yaml.Unmarshal(resolved, &resolvedNode); err != nil {
+		return fmt.Errorf("failed to locate fields of resolved secrets: %w", err)
+	}
+	secrets.DefaultRegistry.TrackFields(source, &rawNode, &resolvedNode, containsSecretReference)
+	return nil
+}
+
+// containsSecretReference returns whether a string contains a secret pattern.
+func containsSecretReference(s string) bool {
+	for _, content := range envRegex.FindAllString(s, -1) {
+		if colonIndex := strings.IndexByte(content, ':'); colonIndex != -1 {
+			if _, _, isSecret := secretReference(content[2:colonIndex], content[colonIndex+1:len(content)-1]); isSecret {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// secretReference returns the scheme and reference of a secret pattern, and
+// whether the pattern refers to a secret at all. Only the explicit form
+// `${secret:<scheme>:<reference>}` refers to a secret, so that environment
+// variables that share a name with a scheme are never mistaken for secrets.
+func secretReference(name, remainder string) (scheme, ref string, isSecret bool) {
+	if name != "secret" {
+		return "", "", false
+	}
+	if i := strings.IndexByte(remainder, ':'); i != -1 {
+		return remainder[:i], remainder[i+1:], true
+	}
+	return remainder, "", true
+}
+
+func lookupSecret(scheme, ref string) (string, error) {
+	ctx, done := context.WithTimeout(context.Background(), secretLookupTimeout)
+	defer done()
+	return secrets.DefaultRegistry.Lookup(ctx, scheme, ref)
 }
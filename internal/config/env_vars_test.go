@@ -2,7 +2,14 @@ package config
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/secrets"
 )
 
 func TestEnvSwapping(t *testing.T) {
@@ -36,9 +43,152 @@ func TestEnvSwapping(t *testing.T) {
 	}
 
 	for in, exp := range tests {
-		out := ReplaceEnvVariables([]byte(in))
+		out, err := ReplaceEnvVariables([]byte(in), SecretsResolve)
+		if err != nil {
+			t.Fatal(err)
+		}
 		if act := string(out); act != exp {
 			t.Errorf("Wrong result: %v != %v", act, exp)
 		}
 	}
 }
+
+func TestEnvSwappingSecrets(t *testing.T) {
+	dir := t.TempDir()
+	secretPath := filepath.Join(dir, "password")
+	require.NoError(t, os.WriteFile(secretPath, []byte("hunter2\n"), 0o600))
+
+	tests := map[string]string{
+		"foo ${secret:file:" + secretPath + "} baz":   "foo hunter2 baz",
+		"foo ${{secret:file:" + secretPath + "}} baz": "foo ${secret:file:" + secretPath + "} baz",
+		"foo ${file:/not/real} baz":                   "foo /not/real baz",
+		"foo ${BENTHOS_TEST_FOO:file:/not/real} baz":  "foo file:/not/real baz",
+	}
+	for in, exp := range tests {
+		out, err := ReplaceEnvVariables([]byte(in), SecretsResolve)
+		require.NoError(t, err, in)
+		assert.Equal(t, exp, string(out), in)
+	}
+
+	_, err := ReplaceEnvVariables([]byte("foo ${secret:file:"+filepath.Join(dir, "nope")+"} baz"), SecretsResolve)
+	require.Error(t, err)
+
+	_, err = ReplaceEnvVariables([]byte("foo ${secret:nope:bar} baz"), SecretsResolve)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown secret scheme")
+}
+
+func TestEnvSwappingSecretsModes(t *testing.T) {
+	in := []byte("foo: ${secret:nope:bar}\nbar: ${BENTHOS_TEST_FOO:baz}\n")
+
+	out, err := ReplaceEnvVariables(in, SecretsPlaceholder)
+	require.NoError(t, err)
+	assert.Equal(t, "foo: '!!!SECRET_SCRUBBED!!!'\nbar: baz\n", string(out))
+
+	_, err = ReplaceEnvVariables(in, SecretsDisabled)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not allowed")
+
+	out, err = ReplaceEnvVariables([]byte("bar: ${BENTHOS_TEST_FOO:baz}\n"), SecretsDisabled)
+	require.NoError(t, err)
+	assert.Equal(t, "bar: baz\n", string(out))
+}
+
+func TestEnvSwappingSecretsPlaceholderTypes(t *testing.T) {
+	out, err := ReplaceEnvVariables([]byte(`
+http:
+  enabled: ${secret:nope:a}
+  address: ${secret:nope:b}
+  root_path: "${secret:nope:c}"
+shutdown_timeout: ${secret:nope:d}
+input:
+  broker:
+    copies: ${secret:nope:e}
+    inputs:
+      - stdin:
+          max_buffer: ${secret:nope:f}
+`), SecretsPlaceholder)
+	require.NoError(t, err)
+	assert.Equal(t, `
+http:
+  enabled: false
+  address: '!!!SECRET_SCRUBBED!!!'
+  root_path: "!!!SECRET_SCRUBBED!!!"
+shutdown_timeout: 20s
+input:
+  broker:
+    copies: 0
+    inputs:
+      - stdin:
+          max_buffer: 0
+`, string(out))
+
+	conf := New()
+	require.NoError(t, yaml.Unmarshal(out, &conf))
+	assert.Equal(t, "!!!SECRET_SCRUBBED!!!", conf.HTTP.Address)
+}
+
+func TestEnvSwappingSecretsRereadFile(t *testing.T) {
+	dir := t.TempDir()
+	secretPath := filepath.Join(dir, "password")
+	require.NoError(t, os.WriteFile(secretPath, []byte("hunter4\n"), 0o600))
+
+	confPath := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(confPath, []byte(`
+output:
+  http_client:
+    basic_auth:
+      password: ${secret:file:`+secretPath+`}
+`), 0o600))
+
+	redact := func() string {
+		var node yaml.Node
+		require.NoError(t, yaml.Unmarshal([]byte("output: { http_client: { basic_auth: { password: hunter4 } } }"), &node))
+		redacted, err := yaml.Marshal(secrets.DefaultRegistry.RedactNode(&node))
+		require.NoError(t, err)
+		return string(redacted)
+	}
+
+	_, _, err := ReadFileEnvSwap(confPath, SecretsResolve)
+	require.NoError(t, err)
+	assert.Contains(t, redact(), "!!!SECRET_SCRUBBED!!!")
+
+	// Once the config no longer references the secret its fields are no
+	// longer redacted.
+	require.NoError(t, os.WriteFile(confPath, []byte(`
+output:
+  http_client:
+    basic_auth:
+      password: nope
+`), 0o600))
+
+	_, _, err = ReadFileEnvSwap(confPath, SecretsResolve)
+	require.NoError(t, err)
+	assert.Contains(t, redact(), "hunter4")
+}
+
+func TestEnvSwappingSecretsRedacted(t *testing.T) {
+	dir := t.TempDir()
+	secretPath := filepath.Join(dir, "password")
+	require.NoError(t, os.WriteFile(secretPath, []byte("hunter3\n"), 0o600))
+
+	out, err := ReplaceEnvVariables([]byte(`
+input:
+  generate:
+    mapping: 'root = "hunter3"'
+output:
+  http_client:
+    url: http://localhost:4195/post
+    basic_auth:
+      password: ${secret:file:`+secretPath+`}
+`), SecretsResolve)
+	require.NoError(t, err)
+
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal(out, &node))
+
+	redacted, err := yaml.Marshal(secrets.DefaultRegistry.RedactNode(&node))
+	require.NoError(t, err)
+	assert.Contains(t, string(redacted), `password: '!!!SECRET_SCRUBBED!!!'`)
+	assert.Contains(t, string(redacted), `mapping: 'root = "hunter3"'`)
+}
@@ -0,0 +1,392 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/docs"
+	ifilepath "github.com/benthosdev/benthos/v4/internal/filepath"
+)
+
+const (
+	// includeField is the root level field of a config file that lists the
+	// config files that it is merged over.
+	includeField = "include"
+
+	// appendTag marks an array within an overlay that should be appended to
+	// the array that it is merged over rather than replacing it.
+	appendTag = "!append"
+)
+
+type includeSource struct {
+	path   string
+	offset int
+	lines  int
+}
+
+// IncludedYAML is a config file that has been read and deep merged over the
+// config files that it includes.
+//
+// In order to preserve the origin of each line of the merged config the lines
+// of included files are offset beyond those of the including files, and can be
+// resolved back to their files with Source.
+type IncludedYAML struct {
+	// The merged config.
+	Node *yaml.Node
+
+	// Lints that occurred whilst reading the files.
+	Lints []string
+
+	// Whether the main config file disables linting.
+	LintDisabled bool
+
+	secretsMode SecretsMode
+	sources     []includeSource
+}
+
+// Paths returns the paths of all files that were read, starting with the main
+// config file.
+func (i *IncludedYAML) Paths() []string {
+	paths := make([]string, 0, len(i.sources))
+	for _, s := range i.sources {
+		paths = append(paths, s.path)
+	}
+	return paths
+}
+
+// Source returns the path of the file that a line of the merged config
+// originates from, and the line number within that file.
+func (i *IncludedYAML) Source(line int) (string, int) {
+	for _, s := range i.sources {
+		if line > s.offset && line <= s.offset+s.lines {
+			return s.path, line - s.offset
+		}
+	}
+	if len(i.sources) > 0 {
+		return i.sources[0].path, line
+	}
+	return "", line
+}
+
+var yamlErrLineRegexp = regexp.MustCompile(`^line ([0-9]+): `)
+
+// SourceError rewrites the line numbers of YAML decoding errors from the merged
+// config to the files and lines that they originate from.
+func (i *IncludedYAML) SourceError(err error) error {
+	var tErr *yaml.TypeError
+	if !errors.As(err, &tErr) || len(i.sources) < 2 {
+		return err
+	}
+	newErr := &yaml.TypeError{Errors: make([]string, len(tErr.Errors))}
+	for j, e := range tErr.Errors {
+		newErr.Errors[j] = yamlErrLineRegexp.ReplaceAllStringFunc(e, func(s string) string {
+			line, _ := strconv.Atoi(yamlErrLineRegexp.FindStringSubmatch(s)[1])
+			path, fileLine := i.Source(line)
+			return fmt.Sprintf("%v: line %v: ", path, fileLine)
+		})
+	}
+	return newErr
+}
+
+// ReadFileWithIncludes reads a config file, replacing environment variable
+// interpolations, and deep merges it over the config files listed within its
+// root level include field, which may contain glob patterns and are relative
+// to the directory of the file. Included files may also include other files.
+//
+// Objects are merged field by field, except for components where the type
+// differs, in which case the component is replaced. Arrays where every element
+// is an object with a label are merged by label, where elements replace those
+// of the same label and are otherwise appended. Arrays tagged with !append are
+// appended, and all other values replace the values they're merged over.
+//
+// Secret references within all files are handled according to the provided
+// mode.
+func ReadFileWithIncludes(path string, secretsMode SecretsMode) (*IncludedYAML, error) {
+	inc := &IncludedYAML{secretsMode: secretsMode}
+	root, err := inc.read(path, nil)
+	if err != nil {
+		return nil, err
+	}
+	stripAppendTags(root)
+	inc.Node = &yaml.Node{
+		Kind:    yaml.DocumentNode,
+		Content: []*yaml.Node{root},
+	}
+	return inc, nil
+}
+
+func (i *IncludedYAML) read(path string, stack []string) (*yaml.Node, error) {
+	cleanPath := filepath.Clean(path)
+	for j, p := range stack {
+		if p == cleanPath {
+			return nil, fmt.Errorf("include cycle detected: %v", strings.Join(append(stack[j:], cleanPath), " -> "))
+		}
+	}
+
+	wrapErr := func(err error) error {
+		if len(stack) == 0 {
+			return err
+		}
+		return fmt.Errorf("include %v: %w", path, err)
+	}
+
+	confBytes, lints, err := ReadFileEnvSwap(path, i.secretsMode)
+	if err != nil {
+		return nil, wrapErr(err)
+	}
+	for _, l := range lints {
+		if len(stack) > 0 {
+			l = fmt.Sprintf("%v: %v", path, l)
+		}
+		i.Lints = append(i.Lints, l)
+	}
+	if len(stack) == 0 {
+		i.LintDisabled = bytes.HasPrefix(confBytes, []byte("# BENTHOS LINT DISABLE"))
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal(confBytes, &node); err != nil {
+		return nil, wrapErr(err)
+	}
+
+	offset := 0
+	if n := len(i.sources); n > 0 {
+		offset = i.sources[n-1].offset + i.sources[n-1].lines
+	}
+	i.sources = append(i.sources, includeSource{
+		path:   path,
+		offset: offset,
+		lines:  bytes.Count(confBytes, []byte("\n")) + 1,
+	})
+	if offset > 0 {
+		offsetLines(&node, offset)
+	}
+
+	root := &node
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		root = root.Content[0]
+	}
+	if root.Kind != yaml.MappingNode {
+		if root.Kind == 0 || root.Kind == yaml.DocumentNode || (root.Kind == yaml.ScalarNode && root.Tag == "!!null") {
+			return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}, nil
+		}
+		return root, nil
+	}
+
+	includes, err := extractIncludes(root)
+	if err != nil {
+		return nil, wrapErr(err)
+	}
+
+	var base *yaml.Node
+	for _, incPath := range includes {
+		if !filepath.IsAbs(incPath) {
+			incPath = filepath.Join(filepath.Dir(path), incPath)
+		}
+		incPaths, err := ifilepath.Globs([]string{incPath})
+		if err != nil {
+			return nil, wrapErr(err)
+		}
+		for _, p := range incPaths {
+			incNode, err := i.read(p, append(stack, cleanPath))
+			if err != nil {
+				return nil, wrapErr(err)
+			}
+			base = mergeFieldsYAML(Spec(), base, incNode)
+		}
+	}
+	return mergeFieldsYAML(Spec(), base, root), nil
+}
+
+// extractIncludes removes the include field from the root of a config and
+// returns the paths it lists.
+func extractIncludes(root *yaml.Node) ([]string, error) {
+	for i := 0; i < len(root.Content)-1; i += 2 {
+		if root.Content[i].Value != includeField {
+			continue
+		}
+		value := root.Content[i+1]
+		root.Content = append(root.Content[:i], root.Content[i+2:]...)
+
+		switch value.Kind {
+		case yaml.ScalarNode:
+			return []string{value.Value}, nil
+		case yaml.SequenceNode:
+			var paths []string
+			if err := value.Decode(&paths); err != nil {
+				return nil, fmt.Errorf("line %v: field %v: %w", value.Line, includeField, err)
+			}
+			return paths, nil
+		}
+		return nil, fmt.Errorf("line %v: field %v: expected a path or an array of paths", value.Line, includeField)
+	}
+	return nil, nil
+}
+
+func offsetLines(n *yaml.Node, offset int) {
+	n.Line += offset
+	for _, c := range n.Content {
+		offsetLines(c, offset)
+	}
+}
+
+func stripAppendTags(n *yaml.Node) {
+	if n.Tag == appendTag {
+		n.Tag = "!!seq"
+	}
+	for _, c := range n.Content {
+		stripAppendTags(c)
+	}
+}
+
+func labelOf(n *yaml.Node) string {
+	if n.Kind != yaml.MappingNode {
+		return ""
+	}
+	for i := 0; i < len(n.Content)-1; i += 2 {
+		if n.Content[i].Value == "label" {
+			return n.Content[i+1].Value
+		}
+	}
+	return ""
+}
+
+func allLabelled(n *yaml.Node) bool {
+	for _, c := range n.Content {
+		if labelOf(c) == "" {
+			return false
+		}
+	}
+	return len(n.Content) > 0
+}
+
+// mergeMappings merges the fields of an overlay mapping node over those of a
+// base mapping node, where fields that exist in both are merged with a closure.
+func mergeMappings(base, overlay *yaml.Node, mergeValue func(key string, base, overlay *yaml.Node) *yaml.Node) *yaml.Node {
+	merged := *base
+	merged.Content = append([]*yaml.Node{}, base.Content...)
+overlayFields:
+	for i := 0; i < len(overlay.Content)-1; i += 2 {
+		key := overlay.Content[i].Value
+		for j := 0; j < len(merged.Content)-1; j += 2 {
+			if merged.Content[j].Value == key {
+				merged.Content[j+1] = mergeValue(key, merged.Content[j+1], overlay.Content[i+1])
+				continue overlayFields
+			}
+		}
+		merged.Content = append(merged.Content, overlay.Content[i], overlay.Content[i+1])
+	}
+	return &merged
+}
+
+// mergeSequences merges an overlay sequence node over a base sequence node.
+func mergeSequences(base, overlay *yaml.Node) *yaml.Node {
+	if overlay.Tag == appendTag {
+		merged := *overlay
+		merged.Content = append(append([]*yaml.Node{}, base.Content...), overlay.Content...)
+		return &merged
+	}
+	if !allLabelled(overlay) {
+		return overlay
+	}
+	merged := *base
+	merged.Content = append([]*yaml.Node{}, base.Content...)
+overlayElements:
+	for _, o := range overlay.Content {
+		label := labelOf(o)
+		for j, b := range merged.Content {
+			if labelOf(b) == label {
+				merged.Content[j] = o
+				continue overlayElements
+			}
+		}
+		merged.Content = append(merged.Content, o)
+	}
+	return &merged
+}
+
+// mergeYAML deep merges an overlay node over a base node and returns the
+// result, neither of the provided nodes are modified.
+func mergeYAML(base, overlay *yaml.Node) *yaml.Node {
+	if base == nil {
+		return overlay
+	}
+	if base.Kind == yaml.MappingNode && overlay.Kind == yaml.MappingNode {
+		return mergeMappings(base, overlay, func(_ string, b, o *yaml.Node) *yaml.Node {
+			return mergeYAML(b, o)
+		})
+	}
+	if base.Kind == yaml.SequenceNode && overlay.Kind == yaml.SequenceNode {
+		return mergeSequences(base, overlay)
+	}
+	return overlay
+}
+
+// mergeFieldsYAML deep merges an overlay node over a base node according to
+// field specs, which allows components to be replaced rather than merged when
+// the overlay changes their type.
+func mergeFieldsYAML(specs docs.FieldSpecs, base, overlay *yaml.Node) *yaml.Node {
+	if base == nil || base.Kind != yaml.MappingNode || overlay.Kind != yaml.MappingNode {
+		return mergeYAML(base, overlay)
+	}
+	specNames := map[string]docs.FieldSpec{}
+	for _, f := range specs {
+		specNames[f.Name] = f
+	}
+	return mergeMappings(base, overlay, func(key string, b, o *yaml.Node) *yaml.Node {
+		if f, exists := specNames[key]; exists {
+			return mergeFieldYAML(f, b, o)
+		}
+		return mergeYAML(b, o)
+	})
+}
+
+func mergeFieldYAML(f docs.FieldSpec, base, overlay *yaml.Node) *yaml.Node {
+	switch f.Kind {
+	case docs.KindArray, docs.Kind2DArray:
+		return mergeYAML(base, overlay)
+	case docs.KindMap:
+		if base.Kind != yaml.MappingNode || overlay.Kind != yaml.MappingNode {
+			return mergeYAML(base, overlay)
+		}
+		return mergeMappings(base, overlay, func(_ string, b, o *yaml.Node) *yaml.Node {
+			return mergeFieldYAML(f.Scalar(), b, o)
+		})
+	}
+	if coreType, isCore := f.Type.IsCoreComponent(); isCore {
+		return mergeComponentYAML(coreType, base, overlay)
+	}
+	if len(f.Children) > 0 {
+		return mergeFieldsYAML(f.Children, base, overlay)
+	}
+	return mergeYAML(base, overlay)
+}
+
+// mergeComponentYAML deep merges an overlay component over a base component,
+// unless the overlay is a different type of component in which case it
+// replaces the base.
+func mergeComponentYAML(cType docs.Type, base, overlay *yaml.Node) *yaml.Node {
+	if base.Kind != yaml.MappingNode || overlay.Kind != yaml.MappingNode {
+		return mergeYAML(base, overlay)
+	}
+	name, spec, err := docs.GetInferenceCandidateFromYAML(nil, cType, base)
+	if err != nil {
+		return mergeYAML(base, overlay)
+	}
+	if overlayName, _, err := docs.GetInferenceCandidateFromYAML(nil, cType, overlay); err == nil && overlayName != name {
+		return overlay
+	}
+	return mergeMappings(base, overlay, func(key string, b, o *yaml.Node) *yaml.Node {
+		if key == name {
+			return mergeFieldYAML(spec.Config, b, o)
+		}
+		return mergeYAML(b, o)
+	})
+}
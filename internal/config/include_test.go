@@ -0,0 +1,237 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/manager"
+	"github.com/benthosdev/benthos/v4/internal/stream"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		require.NoError(t, os.WriteFile(p, []byte(content), 0o644))
+	}
+}
+
+func TestIncludeMerge(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"base/main.yaml": `
+http:
+  address: 0.0.0.0:4195
+input:
+  kafka:
+    addresses: [ localhost:9092 ]
+    topics: [ foo ]
+    consumer_group: base
+pipeline:
+  processors:
+    - bloblang: 'root = this'
+output:
+  drop: {}
+cache_resources:
+  - label: foo
+    memory:
+      default_ttl: 5m
+  - label: bar
+    memory: {}
+`,
+		"base/extra.yaml": `
+pipeline:
+  threads: 4
+`,
+		"prod.yaml": `
+include:
+  - ./base/*.yaml
+input:
+  kafka:
+    addresses: [ prod:9092 ]
+pipeline:
+  processors: !append
+    - bloblang: 'root.env = "prod"'
+cache_resources:
+  - label: foo
+    redis:
+      url: tcp://prod:6379
+  - label: baz
+    memory: {}
+`,
+	})
+
+	inc, err := ReadFileWithIncludes(filepath.Join(dir, "prod.yaml"), SecretsResolve)
+	require.NoError(t, err)
+
+	var v interface{}
+	require.NoError(t, inc.Node.Decode(&v))
+	assert.Equal(t, map[string]interface{}{
+		"http": map[string]interface{}{"address": "0.0.0.0:4195"},
+		"input": map[string]interface{}{
+			"kafka": map[string]interface{}{
+				"addresses":      []interface{}{"prod:9092"},
+				"topics":         []interface{}{"foo"},
+				"consumer_group": "base",
+			},
+		},
+		"pipeline": map[string]interface{}{
+			"threads": 4,
+			"processors": []interface{}{
+				map[string]interface{}{"bloblang": "root = this"},
+				map[string]interface{}{"bloblang": `root.env = "prod"`},
+			},
+		},
+		"output": map[string]interface{}{"drop": map[string]interface{}{}},
+		"cache_resources": []interface{}{
+			map[string]interface{}{"label": "foo", "redis": map[string]interface{}{"url": "tcp://prod:6379"}},
+			map[string]interface{}{"label": "bar", "memory": map[string]interface{}{}},
+			map[string]interface{}{"label": "baz", "memory": map[string]interface{}{}},
+		},
+	}, v)
+
+	assert.Equal(t, []string{
+		filepath.Join(dir, "prod.yaml"),
+		filepath.Join(dir, "base/extra.yaml"),
+		filepath.Join(dir, "base/main.yaml"),
+	}, inc.Paths())
+
+	// Each processor retains the file and line that it originates from.
+	var procs *yaml.Node
+	root := inc.Node.Content[0]
+	for i := 0; i < len(root.Content)-1; i += 2 {
+		if root.Content[i].Value != "pipeline" {
+			continue
+		}
+		pipeline := root.Content[i+1]
+		for j := 0; j < len(pipeline.Content)-1; j += 2 {
+			if pipeline.Content[j].Value == "processors" {
+				procs = pipeline.Content[j+1]
+			}
+		}
+	}
+	require.NotNil(t, procs)
+	require.Len(t, procs.Content, 2)
+
+	path, line := inc.Source(procs.Content[0].Line)
+	assert.Equal(t, filepath.Join(dir, "base/main.yaml"), path)
+	assert.Equal(t, 11, line)
+
+	path, line = inc.Source(procs.Content[1].Line)
+	assert.Equal(t, filepath.Join(dir, "prod.yaml"), path)
+	assert.Equal(t, 9, line)
+}
+
+func TestIncludeCycle(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.yaml": "include: ./b.yaml\n",
+		"b.yaml": "include: ./a.yaml\n",
+	})
+
+	_, err := ReadFileWithIncludes(filepath.Join(dir, "a.yaml"), SecretsResolve)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "include cycle detected")
+}
+
+func TestIncludeMissing(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.yaml": "include: ./nope.yaml\n",
+	})
+
+	_, err := ReadFileWithIncludes(filepath.Join(dir, "a.yaml"), SecretsResolve)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "include "+filepath.Join(dir, "nope.yaml"))
+}
+
+func TestReaderIncludeLints(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"base.yaml": `
+input:
+  generate:
+    mapping: 'root = "hello"'
+    nope: true
+output:
+  drop: {}
+`,
+		"main.yaml": `include: ./base.yaml
+output:
+  stdout:
+    nah: true
+`,
+	})
+
+	conf := New()
+	lints, err := NewReader(filepath.Join(dir, "main.yaml"), nil).Read(&conf)
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{
+		filepath.Join(dir, "main.yaml") + ": line 4: field nah not recognised",
+		filepath.Join(dir, "base.yaml") + ": line 5: field nope not recognised",
+	}, lints)
+	assert.Equal(t, "generate", conf.Input.Type)
+	assert.Equal(t, "stdout", conf.Output.Type)
+}
+
+func TestReaderIncludeFileWatching(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"base.yaml": `
+input:
+  kafka: {}
+output:
+  aws_s3: {}
+`,
+		"main.yaml": `include: ./base.yaml
+`,
+	})
+
+	rdr := newDummyReader(filepath.Join(dir, "main.yaml"))
+
+	conf := New()
+	_, err := rdr.Read(&conf)
+	require.NoError(t, err)
+	assert.Equal(t, "kafka", conf.Input.Type)
+
+	changeChan := make(chan struct{})
+	var updatedConf stream.Config
+	require.NoError(t, rdr.SubscribeConfigChanges(func(conf stream.Config) bool {
+		updatedConf = conf
+		close(changeChan)
+		return true
+	}))
+
+	testMgr, err := manager.NewV2(manager.NewResourceConfig(), nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	require.NoError(t, rdr.BeginFileWatching(testMgr, true))
+
+	// Modify the included file only
+	writeFiles(t, dir, map[string]string{
+		"base.yaml": `
+input:
+  nats: {}
+output:
+  aws_s3: {}
+`,
+	})
+
+	select {
+	case <-changeChan:
+	case <-time.After(time.Second):
+		require.FailNow(t, "Expected a config change to be triggered")
+	}
+
+	assert.Equal(t, "nats", updatedConf.Input.Type)
+	assert.Equal(t, "aws_s3", updatedConf.Output.Type)
+}
@@ -12,9 +12,10 @@ import (
 )
 
 // ReadFileLinted will attempt to read a configuration file path into a
-// structure. Returns an array of lint messages or an error.
-func ReadFileLinted(path string, rejectDeprecated bool, config *Type) ([]string, error) {
-	configBytes, lints, err := ReadFileEnvSwap(path)
+// structure, handling secret references according to the provided mode.
+// Returns an array of lint messages or an error.
+func ReadFileLinted(path string, rejectDeprecated bool, secretsMode SecretsMode, config *Type) ([]string, error) {
+	configBytes, lints, err := ReadFileEnvSwap(path, secretsMode)
 	if err != nil {
 		return nil, err
 	}
@@ -36,6 +37,21 @@ func ReadFileLinted(path string, rejectDeprecated bool, config *Type) ([]string,
 // LintBytes attempts to report errors within a user config. Returns a slice of
 // lint results.
 func LintBytes(ctx docs.LintContext, rawBytes []byte) ([]string, error) {
+	lints, err := LintYAMLBytes(ctx, rawBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	var lintStrs []string
+	for _, lint := range lints {
+		lintStrs = append(lintStrs, fmt.Sprintf("line %v: %v", lint.Line, lint.What))
+	}
+	return lintStrs, nil
+}
+
+// LintYAMLBytes attempts to report errors within a user config. Returns a slice
+// of lints with an error level.
+func LintYAMLBytes(ctx docs.LintContext, rawBytes []byte) ([]docs.Lint, error) {
 	if bytes.HasPrefix(rawBytes, []byte("# BENTHOS LINT DISABLE")) {
 		return nil, nil
 	}
@@ -44,21 +60,26 @@ func LintBytes(ctx docs.LintContext, rawBytes []byte) ([]string, error) {
 	if err := yaml.Unmarshal(rawBytes, &rawNode); err != nil {
 		return nil, err
 	}
+	return LintYAML(ctx, &rawNode), nil
+}
 
-	var lintStrs []string
-	for _, lint := range Spec().LintYAML(ctx, &rawNode) {
+// LintYAML attempts to report errors within a parsed user config. Returns a
+// slice of lints with an error level.
+func LintYAML(ctx docs.LintContext, node *yaml.Node) []docs.Lint {
+	var lints []docs.Lint
+	for _, lint := range Spec().LintYAML(ctx, node) {
 		if lint.Level == docs.LintError {
-			lintStrs = append(lintStrs, fmt.Sprintf("line %v: %v", lint.Line, lint.What))
+			lints = append(lints, lint)
 		}
 	}
-	return lintStrs, nil
+	return lints
 }
 
 // ReadFileEnvSwap reads a file and replaces any environment variable
-// interpolations before returning the contents. Linting errors are returned if
-// the file has an unexpected higher level format, such as invalid utf-8
-// encoding.
-func ReadFileEnvSwap(path string) (configBytes []byte, lints []string, err error) {
+// interpolations before returning the contents, handling secret references
+// according to the provided mode. Linting errors are returned if the file has
+// an unexpected higher level format, such as invalid utf-8 encoding.
+func ReadFileEnvSwap(path string, secretsMode SecretsMode) (configBytes []byte, lints []string, err error) {
 	configBytes, err = os.ReadFile(path)
 	if err != nil {
 		return nil, nil, err
@@ -68,6 +89,8 @@ func ReadFileEnvSwap(path string) (configBytes []byte, lints []string, err error
 		lints = append(lints, "Detected invalid utf-8 encoding in config, this may result in interpolation functions not working as expected")
 	}
 
-	configBytes = ReplaceEnvVariables(configBytes)
+	if configBytes, err = replaceEnvVariables(path, configBytes, secretsMode); err != nil {
+		return nil, nil, err
+	}
 	return configBytes, lints, nil
 }
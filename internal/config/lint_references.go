@@ -0,0 +1,289 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/manager"
+)
+
+// LintFile is a parsed config file to be linted alongside other files.
+type LintFile struct {
+	Path string
+	Node *yaml.Node
+}
+
+// PathLint is a lint found within a specific file.
+type PathLint struct {
+	Path string
+	docs.Lint
+}
+
+// resourceFields maps the fields of a config that define resources to the
+// component type of those resources.
+var resourceFields = map[string]docs.Type{
+	"input_resources":      docs.TypeInput,
+	"processor_resources":  docs.TypeProcessor,
+	"output_resources":     docs.TypeOutput,
+	"cache_resources":      docs.TypeCache,
+	"rate_limit_resources": docs.TypeRateLimit,
+}
+
+// referenceFields maps component types and names to the paths of fields within
+// their config that reference resources, and the type of resource referenced.
+// An empty path refers to the config of the component itself.
+var referenceFields = map[docs.Type]map[string]map[string]docs.Type{
+	docs.TypeInput: {
+		"resource": {"": docs.TypeInput},
+	},
+	docs.TypeProcessor: {
+		"resource":   {"": docs.TypeProcessor},
+		"cache":      {"resource": docs.TypeCache},
+		"dedupe":     {"cache": docs.TypeCache},
+		"rate_limit": {"resource": docs.TypeRateLimit},
+		"workflow":   {"branch_resources": docs.TypeProcessor},
+	},
+	docs.TypeOutput: {
+		"resource": {"": docs.TypeOutput},
+		"cache":    {"target": docs.TypeCache},
+	},
+	docs.TypeCache: {
+		"multilevel": {"": docs.TypeCache},
+	},
+	docs.TypeRateLimit: {
+		"cache": {"resource": docs.TypeCache},
+	},
+}
+
+// referenceFieldNames maps field names that reference resources regardless of
+// the component they belong to.
+var referenceFieldNames = map[string]docs.Type{
+	"rate_limit":   docs.TypeRateLimit,
+	"cursor_cache": docs.TypeCache,
+}
+
+// ResourceReference returns the type of resource referenced by a string field
+// of a component, where the path is the field path relative to the config of
+// the component and excludes array indexes and map keys. Returns false if the
+// field does not reference a resource.
+func ResourceReference(cType docs.Type, name string, path []string) (docs.Type, bool) {
+	if rType, isRef := referenceFields[cType][name][strings.Join(path, ".")]; isRef {
+		return rType, true
+	}
+	if len(path) > 0 {
+		rType, isRef := referenceFieldNames[path[len(path)-1]]
+		return rType, isRef
+	}
+	return "", false
+}
+
+type resourceKey struct {
+	cType docs.Type
+	label string
+}
+
+type resourceSite struct {
+	resourceKey
+	path string
+	line int
+}
+
+type fileResources struct {
+	defs  []resourceSite
+	refs  []resourceSite
+	lints []PathLint
+}
+
+func walkLintFile(prov docs.Provider, spec docs.FieldSpecs, f LintFile) (res fileResources) {
+	root := f.Node
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		root = root.Content[0]
+	}
+	if root.Kind != yaml.MappingNode {
+		return
+	}
+
+	for i := 0; i < len(root.Content)-1; i += 2 {
+		cType, exists := resourceFields[root.Content[i].Value]
+		if !exists || root.Content[i+1].Kind != yaml.SequenceNode {
+			continue
+		}
+		for _, c := range root.Content[i+1].Content {
+			for j := 0; j < len(c.Content)-1; j += 2 {
+				if c.Content[j].Value == "label" && c.Content[j+1].Value != "" {
+					res.defs = append(res.defs, resourceSite{
+						resourceKey: resourceKey{cType: cType, label: c.Content[j+1].Value},
+						path:        f.Path,
+						line:        c.Content[j+1].Line,
+					})
+				}
+			}
+		}
+	}
+
+	lintCtx := docs.NewLintContext()
+	lintCtx.DocsProvider = prov
+
+	docs.YAMLWalker{
+		Provider: prov,
+		OnField: func(field docs.WalkedYAMLField) {
+			if field.Value.Kind != yaml.ScalarNode || field.Spec.Type != docs.FieldTypeString {
+				return
+			}
+			value := field.Value.Value
+
+			if strings.Contains(value, "${!") && !field.Spec.Interpolated && !field.Spec.Bloblang {
+				if lints := docs.LintBloblangField(lintCtx, field.Value.Line, field.Value.Column, value); len(lints) > 0 {
+					for _, l := range lints {
+						res.lints = append(res.lints, PathLint{Path: f.Path, Lint: l})
+					}
+				} else {
+					target := "field " + strings.Join(field.Path, ".")
+					if len(field.Path) == 0 && field.Component != nil {
+						target = fmt.Sprintf("%v %v", field.Component.Name, field.Component.Type)
+					}
+					res.lints = append(res.lints, PathLint{Path: f.Path, Lint: docs.NewLintWarning(
+						field.Value.Line, fmt.Sprintf("%v does not support interpolation functions, the value will be used literally", target),
+					)})
+				}
+			}
+
+			if value == "" || strings.Contains(value, "${") {
+				return
+			}
+			var cType docs.Type
+			var isRef bool
+			if field.Component != nil {
+				cType, isRef = ResourceReference(field.Component.Type, field.Component.Name, field.Path)
+			} else if len(field.Path) > 0 {
+				cType, isRef = referenceFieldNames[field.Path[len(field.Path)-1]]
+			}
+			if isRef {
+				res.refs = append(res.refs, resourceSite{
+					resourceKey: resourceKey{cType: cType, label: value},
+					path:        f.Path,
+					line:        field.Value.Line,
+				})
+			}
+		},
+	}.WalkFields(spec, f.Node)
+	return
+}
+
+// LintReferences performs a resolution pass across resource files and the
+// configs that use them, and reports references to resources that are not
+// defined, resources that are never referenced, resource labels that are
+// defined more than once across files, and interpolation functions that are
+// invalid or used within fields that do not support them.
+//
+// Resources defined within resource files are available to all configs, and
+// resources defined within a config are available only to that config.
+func LintReferences(prov docs.Provider, resources, configs []LintFile) []PathLint {
+	resourceSpec, configSpec := manager.Spec(), Spec()
+
+	var lints []PathLint
+	defined := map[resourceKey]resourceSite{}
+	used := map[resourceKey]struct{}{}
+
+	checkDuplicate := func(def resourceSite, against map[resourceKey]resourceSite) bool {
+		prev, exists := against[def.resourceKey]
+		if !exists {
+			return false
+		}
+		// Collisions within the same file are reported by regular linting.
+		if prev.path != def.path {
+			lints = append(lints, PathLint{Path: def.path, Lint: docs.NewLintError(def.line, fmt.Sprintf(
+				"%v resource label '%v' collides with a previously defined resource at %v line %v", def.cType, def.label, prev.path, prev.line,
+			))})
+		}
+		return true
+	}
+
+	var resourceRefs []resourceSite
+	for _, f := range resources {
+		res := walkLintFile(prov, resourceSpec, f)
+		lints = append(lints, res.lints...)
+		for _, def := range res.defs {
+			if !checkDuplicate(def, defined) {
+				defined[def.resourceKey] = def
+			}
+		}
+		resourceRefs = append(resourceRefs, res.refs...)
+	}
+
+	configDefined := map[resourceKey]struct{}{}
+	for _, f := range configs {
+		res := walkLintFile(prov, configSpec, f)
+		lints = append(lints, res.lints...)
+
+		local := map[resourceKey]resourceSite{}
+		for _, def := range res.defs {
+			if checkDuplicate(def, defined) || checkDuplicate(def, local) {
+				continue
+			}
+			local[def.resourceKey] = def
+			configDefined[def.resourceKey] = struct{}{}
+		}
+
+		localUsed := map[resourceKey]struct{}{}
+		for _, ref := range res.refs {
+			localUsed[ref.resourceKey] = struct{}{}
+			used[ref.resourceKey] = struct{}{}
+			_, isLocal := local[ref.resourceKey]
+			_, isShared := defined[ref.resourceKey]
+			if !isLocal && !isShared {
+				lints = append(lints, PathLint{Path: ref.path, Lint: docs.NewLintError(ref.line, fmt.Sprintf(
+					"%v resource '%v' is not defined", ref.cType, ref.label,
+				))})
+			}
+		}
+		for _, ref := range resourceRefs {
+			localUsed[ref.resourceKey] = struct{}{}
+		}
+		for _, def := range res.defs {
+			if _, exists := localUsed[def.resourceKey]; !exists && local[def.resourceKey] == def {
+				lints = append(lints, PathLint{Path: def.path, Lint: docs.NewLintWarning(def.line, fmt.Sprintf(
+					"%v resource '%v' is never referenced", def.cType, def.label,
+				))})
+			}
+		}
+	}
+
+	for _, ref := range resourceRefs {
+		used[ref.resourceKey] = struct{}{}
+		_, isShared := defined[ref.resourceKey]
+		_, isConfig := configDefined[ref.resourceKey]
+		if !isShared && !isConfig {
+			lints = append(lints, PathLint{Path: ref.path, Lint: docs.NewLintError(ref.line, fmt.Sprintf(
+				"%v resource '%v' is not defined", ref.cType, ref.label,
+			))})
+		}
+	}
+
+	// Resource files without any configs to use them are likely linted in
+	// isolation, and so unused resources are only reported with configs.
+	if len(configs) > 0 {
+		unused := make([]resourceSite, 0, len(defined))
+		for k, def := range defined {
+			if _, exists := used[k]; !exists {
+				unused = append(unused, def)
+			}
+		}
+		sort.Slice(unused, func(i, j int) bool {
+			if unused[i].path == unused[j].path {
+				return unused[i].line < unused[j].line
+			}
+			return unused[i].path < unused[j].path
+		})
+		for _, def := range unused {
+			lints = append(lints, PathLint{Path: def.path, Lint: docs.NewLintWarning(def.line, fmt.Sprintf(
+				"%v resource '%v' is never referenced", def.cType, def.label,
+			))})
+		}
+	}
+	return lints
+}
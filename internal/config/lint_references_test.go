@@ -0,0 +1,124 @@
+package config_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/config"
+)
+
+func lintFile(t *testing.T, path, conf string) config.LintFile {
+	t.Helper()
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(conf), &node))
+	return config.LintFile{Path: path, Node: &node}
+}
+
+func lintStrs(lints []config.PathLint) (strs []string) {
+	for _, l := range lints {
+		strs = append(strs, fmt.Sprintf("%v:%v: %v: %v", l.Path, l.Line, l.Level, l.What))
+	}
+	return
+}
+
+func TestLintReferences(t *testing.T) {
+	resources := []config.LintFile{
+		lintFile(t, "res_a.yaml", `
+cache_resources:
+  - label: foocache
+    memory: {}
+  - label: unusedcache
+    memory: {}
+
+processor_resources:
+  - label: fooproc
+    cache:
+      resource: barcache
+      operator: get
+      key: foo
+`),
+		lintFile(t, "res_b.yaml", `
+rate_limit_resources:
+  - label: foolimit
+    local: {}
+
+cache_resources:
+  - label: foocache
+    memory: {}
+`),
+	}
+
+	configs := []config.LintFile{
+		lintFile(t, "conf.yaml", `
+input:
+  http_client:
+    url: http://localhost:4195
+    rate_limit: foolimit
+
+pipeline:
+  processors:
+    - resource: fooproc
+    - resource: nope
+    - dedupe:
+        cache: foocache
+        key: ${! meta("id") }
+
+output:
+  resource: ${! meta("out") }
+
+cache_resources:
+  - label: barcache
+    memory: {}
+  - label: localunused
+    memory: {}
+`),
+	}
+
+	assert.Equal(t, []string{
+		"res_b.yaml:7: 0: cache resource label 'foocache' collides with a previously defined resource at res_a.yaml line 3",
+		"conf.yaml:16: 1: resource output does not support interpolation functions, the value will be used literally",
+		"conf.yaml:10: 0: processor resource 'nope' is not defined",
+		"conf.yaml:21: 1: cache resource 'localunused' is never referenced",
+		"res_a.yaml:5: 1: cache resource 'unusedcache' is never referenced",
+	}, lintStrs(config.LintReferences(nil, resources, configs)))
+}
+
+func TestLintReferencesDanglingResources(t *testing.T) {
+	resources := []config.LintFile{
+		lintFile(t, "res.yaml", `
+processor_resources:
+  - label: fooproc
+    cache:
+      resource: nope
+      operator: get
+      key: foo
+`),
+	}
+
+	// Without any configs unused resources are not reported.
+	assert.Equal(t, []string{
+		"res.yaml:5: 0: cache resource 'nope' is not defined",
+	}, lintStrs(config.LintReferences(nil, resources, nil)))
+}
+
+func TestLintReferencesInterpolations(t *testing.T) {
+	configs := []config.LintFile{
+		lintFile(t, "conf.yaml", `
+input:
+  file:
+    paths: [ './${! meta("path") }.txt' ]
+
+output:
+  file:
+    path: '${! meta("nope" }'
+`),
+	}
+
+	lints := lintStrs(config.LintReferences(nil, nil, configs))
+	require.Len(t, lints, 1)
+	assert.Contains(t, lints[0], "conf.yaml:4: 1: field paths does not support interpolation functions")
+}
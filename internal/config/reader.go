@@ -1,7 +1,6 @@
 package config
 
 import (
-	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -16,6 +15,7 @@ import (
 
 	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/docs"
+	ifilepath "github.com/benthosdev/benthos/v4/internal/filepath"
 	"github.com/benthosdev/benthos/v4/internal/stream"
 )
 
@@ -31,7 +31,8 @@ type configFileInfo struct {
 type streamFileInfo struct {
 	configFileInfo
 
-	id string
+	id   string
+	conf stream.Config
 }
 
 // Reader provides utilities for parsing a Benthos config as a main file with
@@ -45,14 +46,26 @@ type Reader struct {
 	mainPath      string
 	resourcePaths []string
 	streamsPaths  []string
+	templatePaths []string
 	overrides     []string
 
 	// Controls whether the main config should include input, output, etc.
 	streamsMode bool
 
+	// Controls how references to secrets are handled.
+	secretsMode SecretsMode
+
 	// Tracks the details of the config file when we last read it.
 	configFileInfo configFileInfo
 
+	// Tracks the files included by the main config when we last read it.
+	mainIncludes map[string]struct{}
+
+	// Tracks the stream config and resources of the main config when they
+	// were last applied.
+	mainConf         *stream.Config
+	mainResourceInfo resourceFileInfo
+
 	// Tracks the details of stream config files when we last read them.
 	streamFileInfo map[string]streamFileInfo
 
@@ -62,6 +75,9 @@ type Reader struct {
 	resourceFileInfo    map[string]resourceFileInfo
 	resourceFileInfoMut sync.Mutex
 
+	// Tracks the templates defined by template files when we last read them.
+	templateFileInfo map[string]templateFileInfo
+
 	mainUpdateFn   MainUpdateFunc
 	streamUpdateFn StreamUpdateFunc
 	watcher        *fsnotify.Watcher
@@ -78,6 +94,7 @@ func NewReader(mainPath string, resourcePaths []string, opts ...OptFunc) *Reader
 		resourcePaths:     resourcePaths,
 		streamFileInfo:    map[string]streamFileInfo{},
 		resourceFileInfo:  map[string]resourceFileInfo{},
+		templateFileInfo:  map[string]templateFileInfo{},
 		changeFlushPeriod: defaultChangeFlushPeriod,
 		changeDelayPeriod: defaultChangeDelayPeriod,
 	}
@@ -118,6 +135,25 @@ func OptSetStreamPaths(streamsPaths ...string) OptFunc {
 	}
 }
 
+// OptSetTemplatePaths sets the paths of template files, which may include glob
+// patterns. Templates are not read by the config reader, but when file watching
+// is enabled changes to template files cause the templates to be registered
+// again, and the streams and resources that use them to be rebuilt.
+func OptSetTemplatePaths(templatePaths ...string) OptFunc {
+	return func(r *Reader) {
+		r.templatePaths = templatePaths
+	}
+}
+
+// OptSetSecretsMode sets how references to secrets are handled when reading
+// config files. By default secrets are resolved with their providers, which
+// commands that only inspect configs should avoid.
+func OptSetSecretsMode(mode SecretsMode) OptFunc {
+	return func(r *Reader) {
+		r.secretsMode = mode
+	}
+}
+
 //------------------------------------------------------------------------------
 
 // Read a Benthos config from the files and options specified.
@@ -125,6 +161,10 @@ func (r *Reader) Read(conf *Type) (lints []string, err error) {
 	if lints, err = r.readMain(conf); err != nil {
 		return
 	}
+	mainConf := conf.Config
+	r.mainConf = &mainConf
+	r.mainResourceInfo = resInfoFromConfig(&conf.ResourceConfig)
+
 	var rLints []string
 	if rLints, err = r.readResources(&conf.ResourceConfig); err != nil {
 		return
@@ -197,6 +237,18 @@ func (r *Reader) BeginFileWatching(mgr bundle.NewManagement, strict bool) error
 		return errors.New("a file watcher cannot be started without a subscription function registered")
 	}
 
+	templatePaths, err := ifilepath.Globs(r.templatePaths)
+	if err != nil {
+		return fmt.Errorf("failed to resolve template glob pattern: %w", err)
+	}
+	for _, p := range templatePaths {
+		info, _, err := readTemplateInfo(p)
+		if err != nil {
+			return fmt.Errorf("template %v: %w", p, err)
+		}
+		r.templateFileInfo[filepath.Clean(p)] = info
+	}
+
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
 		return err
@@ -230,10 +282,12 @@ func (r *Reader) BeginFileWatching(mgr bundle.NewManagement, strict bool) error
 						continue
 					}
 					var succeeded bool
-					if nameClean == filepath.Clean(r.mainPath) {
+					if nameClean == filepath.Clean(r.mainPath) || r.isMainInclude(nameClean) {
 						succeeded = r.reactMainUpdate(mgr, strict)
 					} else if _, exists := r.streamFileInfo[nameClean]; exists {
 						succeeded = r.reactStreamUpdate(mgr, strict, nameClean)
+					} else if _, exists := r.templateFileInfo[nameClean]; exists {
+						succeeded = r.reactTemplateUpdate(mgr, strict, nameClean)
 					} else {
 						succeeded = r.reactResourceUpdate(mgr, strict, nameClean)
 					}
@@ -263,6 +317,10 @@ func (r *Reader) BeginFileWatching(mgr bundle.NewManagement, strict bool) error
 			_ = watcher.Close()
 			return err
 		}
+		if err := r.watchMainIncludes(); err != nil {
+			_ = watcher.Close()
+			return err
+		}
 	}
 	for _, p := range r.streamsPaths {
 		if err := watcher.Add(p); err != nil {
@@ -276,6 +334,12 @@ func (r *Reader) BeginFileWatching(mgr bundle.NewManagement, strict bool) error
 			return err
 		}
 	}
+	for _, p := range templatePaths {
+		if err := watcher.Add(p); err != nil {
+			_ = watcher.Close()
+			return err
+		}
+	}
 	return nil
 }
 
@@ -325,14 +389,19 @@ func (r *Reader) readMain(conf *Type) (lints []string, err error) {
 	}
 
 	var rawNode yaml.Node
-	var confBytes []byte
+	inc := &IncludedYAML{}
 	if r.mainPath != "" {
-		if confBytes, lints, err = ReadFileEnvSwap(r.mainPath); err != nil {
+		if inc, err = ReadFileWithIncludes(r.mainPath, r.secretsMode); err != nil {
 			return
 		}
-		if err = yaml.Unmarshal(confBytes, &rawNode); err != nil {
-			return
+		lints = inc.Lints
+		rawNode = *inc.Node
+
+		includes := map[string]struct{}{}
+		for _, p := range inc.Paths()[1:] {
+			includes[filepath.Clean(p)] = struct{}{}
 		}
+		r.mainIncludes = includes
 	}
 
 	// This is an unlikely race condition as the file could've been updated
@@ -352,20 +421,41 @@ func (r *Reader) readMain(conf *Type) (lints []string, err error) {
 		return
 	}
 
-	if !bytes.HasPrefix(confBytes, []byte("# BENTHOS LINT DISABLE")) {
-		lintFilePrefix := ""
-		if r.mainPath != "" {
-			lintFilePrefix = fmt.Sprintf("%v: ", r.mainPath)
-		}
+	if !inc.LintDisabled {
 		for _, lint := range confSpec.LintYAML(docs.NewLintContext(), &rawNode) {
-			lints = append(lints, fmt.Sprintf("%vline %v: %v", lintFilePrefix, lint.Line, lint.What))
+			lintFilePrefix := ""
+			lintPath, lintLine := inc.Source(lint.Line)
+			if lintPath != "" {
+				lintFilePrefix = fmt.Sprintf("%v: ", lintPath)
+			}
+			lints = append(lints, fmt.Sprintf("%vline %v: %v", lintFilePrefix, lintLine, lint.What))
 		}
 	}
 
-	err = rawNode.Decode(conf)
+	err = inc.SourceError(rawNode.Decode(conf))
 	return
 }
 
+// isMainInclude returns whether a path is a file included by the main config.
+func (r *Reader) isMainInclude(nameClean string) bool {
+	_, exists := r.mainIncludes[nameClean]
+	return exists
+}
+
+// watchMainIncludes adds any files included by the main config to the file
+// watcher, as the files included may change with each update.
+func (r *Reader) watchMainIncludes() error {
+	if r.watcher == nil || r.streamsMode {
+		return nil
+	}
+	for p := range r.mainIncludes {
+		if err := r.watcher.Add(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (r *Reader) reactMainUpdate(mgr bundle.NewManagement, strict bool) bool {
 	if r.mainUpdateFn == nil {
 		return true
@@ -382,6 +472,10 @@ func (r *Reader) reactMainUpdate(mgr bundle.NewManagement, strict bool) bool {
 		return true
 	}
 
+	if err := r.watchMainIncludes(); err != nil {
+		mgr.Logger().Errorf("Failed to watch files included by updated config: %v", err)
+	}
+
 	lintlog := mgr.Logger()
 	for _, lint := range lints {
 		lintlog.Infoln(lint)
@@ -394,9 +488,22 @@ func (r *Reader) reactMainUpdate(mgr bundle.NewManagement, strict bool) bool {
 	}
 
 	// Update any resources within the file.
-	if newInfo := resInfoFromConfig(&conf.ResourceConfig); !newInfo.applyChanges(mgr) {
+	newInfo := resInfoFromConfig(&conf.ResourceConfig)
+	if !newInfo.applyChanges(mgr, r.mainResourceInfo) {
 		return false
 	}
+	r.mainResourceInfo = newInfo
 
-	return r.mainUpdateFn(conf.Config)
+	// Only restart the pipeline when the stream itself has changed, as
+	// otherwise in-flight messages would be disrupted by changes to resources
+	// that have already been swapped.
+	if r.mainConf != nil && sameConfig(*r.mainConf, conf.Config) {
+		mgr.Logger().Infoln("Main config stream is unchanged, resources were updated without restarting the pipeline.")
+		return true
+	}
+	if !r.mainUpdateFn(conf.Config) {
+		return false
+	}
+	r.mainConf = &conf.Config
+	return true
 }
@@ -46,25 +46,38 @@ func resInfoFromConfig(conf *manager.ResourceConfig) resourceFileInfo {
 	resInfo.updatedAt = time.Now()
 
 	// New style
-	for _, c := range conf.ResourceInputs {
-		resInfo.inputs[c.Label] = &c
+	for i, c := range conf.ResourceInputs {
+		resInfo.inputs[c.Label] = &conf.ResourceInputs[i]
 	}
-	for _, c := range conf.ResourceProcessors {
-		resInfo.processors[c.Label] = &c
+	for i, c := range conf.ResourceProcessors {
+		resInfo.processors[c.Label] = &conf.ResourceProcessors[i]
 	}
-	for _, c := range conf.ResourceOutputs {
-		resInfo.outputs[c.Label] = &c
+	for i, c := range conf.ResourceOutputs {
+		resInfo.outputs[c.Label] = &conf.ResourceOutputs[i]
 	}
-	for _, c := range conf.ResourceCaches {
-		resInfo.caches[c.Label] = &c
+	for i, c := range conf.ResourceCaches {
+		resInfo.caches[c.Label] = &conf.ResourceCaches[i]
 	}
-	for _, c := range conf.ResourceRateLimits {
-		resInfo.rateLimits[c.Label] = &c
+	for i, c := range conf.ResourceRateLimits {
+		resInfo.rateLimits[c.Label] = &conf.ResourceRateLimits[i]
 	}
 
 	return resInfo
 }
 
+// sameConfig returns whether two component configs are equivalent.
+func sameConfig(a, b interface{}) bool {
+	aBytes, err := yaml.Marshal(a)
+	if err != nil {
+		return false
+	}
+	bBytes, err := yaml.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(aBytes, bBytes)
+}
+
 func (r *Reader) readResources(conf *manager.ResourceConfig) (lints []string, err error) {
 	resourcesPaths, err := ifilepath.Globs(r.resourcePaths)
 	if err != nil {
@@ -73,7 +86,7 @@ func (r *Reader) readResources(conf *manager.ResourceConfig) (lints []string, er
 	for _, path := range resourcesPaths {
 		rconf := manager.NewResourceConfig()
 		var rLints []string
-		if rLints, err = readResource(path, &rconf); err != nil {
+		if rLints, err = readResource(path, r.secretsMode, &rconf); err != nil {
 			return
 		}
 		lints = append(lints, rLints...)
@@ -87,7 +100,7 @@ func (r *Reader) readResources(conf *manager.ResourceConfig) (lints []string, er
 	return
 }
 
-func readResource(path string, conf *manager.ResourceConfig) (lints []string, err error) {
+func readResource(path string, secretsMode SecretsMode, conf *manager.ResourceConfig) (lints []string, err error) {
 	defer func() {
 		if err != nil {
 			err = fmt.Errorf("%v: %w", path, err)
@@ -95,7 +108,7 @@ func readResource(path string, conf *manager.ResourceConfig) (lints []string, er
 	}()
 
 	var confBytes []byte
-	if confBytes, lints, err = ReadFileEnvSwap(path); err != nil {
+	if confBytes, lints, err = ReadFileEnvSwap(path, secretsMode); err != nil {
 		return
 	}
 
@@ -128,7 +141,7 @@ func (r *Reader) reactResourceUpdate(mgr bundle.NewManagement, strict bool, path
 	mgr.Logger().Infof("Resource %v config updated, attempting to update resources.", path)
 
 	newResConf := manager.NewResourceConfig()
-	lints, err := readResource(path, &newResConf)
+	lints, err := readResource(path, r.secretsMode, &newResConf)
 	if err != nil {
 		mgr.Logger().Errorf("Failed to read updated resources config: %v", err)
 		return true
@@ -144,11 +157,10 @@ func (r *Reader) reactResourceUpdate(mgr bundle.NewManagement, strict bool, path
 	}
 
 	// TODO: Should we error out if the new config is missing some resources?
-	// (as they will continue to exist). Also, we could avoid restarting
-	// resources where the config hasn't changed.
+	// (as they will continue to exist).
 
 	newInfo := resInfoFromConfig(&newResConf)
-	if !newInfo.applyChanges(mgr) {
+	if !newInfo.applyChanges(mgr, r.resourceFileInfo[path]) {
 		return false
 	}
 
@@ -156,7 +168,10 @@ func (r *Reader) reactResourceUpdate(mgr bundle.NewManagement, strict bool, path
 	return true
 }
 
-func (i *resourceFileInfo) applyChanges(mgr bundle.NewManagement) bool {
+// applyChanges swaps out the resources of a manager that have changed since a
+// previous read, resources with an unchanged config are left as they are in
+// order to avoid disrupting components that are using them.
+func (i *resourceFileInfo) applyChanges(mgr bundle.NewManagement, prev resourceFileInfo) bool {
 	// Kind of arbitrary, but I feel better about having some sort of timeout.
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
 	defer cancel()
@@ -165,6 +180,9 @@ func (i *resourceFileInfo) applyChanges(mgr bundle.NewManagement) bool {
 	// with components that could be dependencies of other components. This is
 	// a "best attempt", so not all edge cases need to be accounted for.
 	for k, v := range i.rateLimits {
+		if p, exists := prev.rateLimits[k]; exists && sameConfig(p, v) {
+			continue
+		}
 		if err := mgr.StoreRateLimit(ctx, k, *v); err != nil {
 			mgr.Logger().Errorf("Failed to update resource %v: %v", k, err)
 			return false
@@ -172,6 +190,9 @@ func (i *resourceFileInfo) applyChanges(mgr bundle.NewManagement) bool {
 		mgr.Logger().Infof("Updated resource %v config from file.", k)
 	}
 	for k, v := range i.caches {
+		if p, exists := prev.caches[k]; exists && sameConfig(p, v) {
+			continue
+		}
 		if err := mgr.StoreCache(ctx, k, *v); err != nil {
 			mgr.Logger().Errorf("Failed to update resource %v: %v", k, err)
 			return false
@@ -179,6 +200,9 @@ func (i *resourceFileInfo) applyChanges(mgr bundle.NewManagement) bool {
 		mgr.Logger().Infof("Updated resource %v config from file.", k)
 	}
 	for k, v := range i.processors {
+		if p, exists := prev.processors[k]; exists && sameConfig(p, v) {
+			continue
+		}
 		if err := mgr.StoreProcessor(ctx, k, *v); err != nil {
 			mgr.Logger().Errorf("Failed to update resource %v: %v", k, err)
 			return false
@@ -186,6 +210,9 @@ func (i *resourceFileInfo) applyChanges(mgr bundle.NewManagement) bool {
 		mgr.Logger().Infof("Updated resource %v config from file.", k)
 	}
 	for k, v := range i.inputs {
+		if p, exists := prev.inputs[k]; exists && sameConfig(p, v) {
+			continue
+		}
 		if err := mgr.StoreInput(ctx, k, *v); err != nil {
 			mgr.Logger().Errorf("Failed to update resource %v: %v", k, err)
 			return false
@@ -193,6 +220,9 @@ func (i *resourceFileInfo) applyChanges(mgr bundle.NewManagement) bool {
 		mgr.Logger().Infof("Updated resource %v config from file.", k)
 	}
 	for k, v := range i.outputs {
+		if p, exists := prev.outputs[k]; exists && sameConfig(p, v) {
+			continue
+		}
 		if err := mgr.StoreOutput(ctx, k, *v); err != nil {
 			mgr.Logger().Errorf("Failed to update resource %v: %v", k, err)
 			return false
@@ -0,0 +1,173 @@
+package config
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component/cache"
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/manager"
+	"github.com/benthosdev/benthos/v4/internal/stream"
+)
+
+func cacheGet(t *testing.T, mgr *manager.Type, label, key string) (string, error) {
+	t.Helper()
+
+	var value []byte
+	var getErr error
+	require.NoError(t, mgr.AccessCache(context.Background(), label, func(c cache.V1) {
+		value, getErr = c.Get(context.Background(), key)
+	}))
+	return string(value), getErr
+}
+
+func cacheSet(t *testing.T, mgr *manager.Type, label, key, value string) {
+	t.Helper()
+
+	require.NoError(t, mgr.AccessCache(context.Background(), label, func(c cache.V1) {
+		require.NoError(t, c.Set(context.Background(), key, []byte(value), nil))
+	}))
+}
+
+func TestReaderResourceFileSwapsChangedOnly(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"res.yaml": `
+cache_resources:
+  - label: foo
+    memory: {}
+  - label: bar
+    memory: {}
+`,
+	})
+
+	rdr := NewReader("", []string{filepath.Join(dir, "res.yaml")}, OptSetStreamPaths())
+	rdr.changeDelayPeriod = 1 * time.Millisecond
+	rdr.changeFlushPeriod = 1 * time.Millisecond
+
+	conf := New()
+	_, err := rdr.Read(&conf)
+	require.NoError(t, err)
+	require.Len(t, conf.ResourceCaches, 2)
+
+	require.NoError(t, rdr.SubscribeStreamChanges(func(id string, conf stream.Config) bool {
+		return true
+	}))
+
+	testMgr, err := manager.NewV2(conf.ResourceConfig, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	require.NoError(t, rdr.BeginFileWatching(testMgr, true))
+
+	cacheSet(t, testMgr, "foo", "a", "foo value")
+	cacheSet(t, testMgr, "bar", "a", "bar value")
+
+	writeFiles(t, dir, map[string]string{
+		"res.yaml": `
+cache_resources:
+  - label: foo
+    memory: {}
+  - label: bar
+    memory:
+      default_ttl: 10m
+`,
+	})
+
+	// The changed cache is swapped for an empty one
+	assert.Eventually(t, func() bool {
+		_, err := cacheGet(t, testMgr, "bar", "a")
+		return err != nil
+	}, time.Second, time.Millisecond*10)
+
+	// Whereas the unchanged cache keeps its state
+	v, err := cacheGet(t, testMgr, "foo", "a")
+	require.NoError(t, err)
+	assert.Equal(t, "foo value", v)
+}
+
+func TestReaderMainResourceChangeKeepsStream(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.yaml": `
+input:
+  generate:
+    mapping: 'root = "hello"'
+output:
+  drop: {}
+cache_resources:
+  - label: foo
+    memory: {}
+`,
+	})
+
+	rdr := newDummyReader(filepath.Join(dir, "main.yaml"))
+
+	conf := New()
+	_, err := rdr.Read(&conf)
+	require.NoError(t, err)
+
+	streamUpdates := make(chan stream.Config, 10)
+	require.NoError(t, rdr.SubscribeConfigChanges(func(conf stream.Config) bool {
+		streamUpdates <- conf
+		return true
+	}))
+
+	testMgr, err := manager.NewV2(conf.ResourceConfig, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	require.NoError(t, rdr.BeginFileWatching(testMgr, true))
+
+	cacheSet(t, testMgr, "foo", "a", "foo value")
+
+	// Changing only the resource swaps it without updating the stream
+	writeFiles(t, dir, map[string]string{
+		"main.yaml": `
+input:
+  generate:
+    mapping: 'root = "hello"'
+output:
+  drop: {}
+cache_resources:
+  - label: foo
+    memory:
+      default_ttl: 10m
+`,
+	})
+
+	assert.Eventually(t, func() bool {
+		_, err := cacheGet(t, testMgr, "foo", "a")
+		return err != nil
+	}, time.Second, time.Millisecond*10)
+
+	select {
+	case <-streamUpdates:
+		t.Fatal("Expected the stream to remain untouched")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Changing the stream itself still triggers an update
+	writeFiles(t, dir, map[string]string{
+		"main.yaml": `
+input:
+  generate:
+    mapping: 'root = "world"'
+output:
+  drop: {}
+cache_resources:
+  - label: foo
+    memory:
+      default_ttl: 10m
+`,
+	})
+
+	select {
+	case conf := <-streamUpdates:
+		assert.Equal(t, `root = "world"`, conf.Input.Generate.Mapping)
+	case <-time.After(time.Second):
+		t.Fatal("Expected a stream update")
+	}
+}
@@ -41,12 +41,13 @@ func InferStreamID(dir, path string) (string, error) {
 	return id, nil
 }
 
-// ReadStreamFile attempts to read a stream config and returns the result
-func ReadStreamFile(path string) (conf stream.Config, lints []string, err error) {
+// ReadStreamFile attempts to read a stream config and returns the result,
+// handling secret references according to the provided mode.
+func ReadStreamFile(path string, secretsMode SecretsMode) (conf stream.Config, lints []string, err error) {
 	conf = stream.NewConfig()
 
 	var confBytes []byte
-	if confBytes, lints, err = ReadFileEnvSwap(path); err != nil {
+	if confBytes, lints, err = ReadFileEnvSwap(path, secretsMode); err != nil {
 		return
 	}
 
@@ -83,12 +84,12 @@ func (r *Reader) readStreamFile(dir, path string, confs map[string]stream.Config
 		return nil, fmt.Errorf("stream id (%v) collision from file: %v", id, path)
 	}
 
-	conf, lints, err := ReadStreamFile(path)
+	conf, lints, err := ReadStreamFile(path, r.secretsMode)
 	if err != nil {
 		return nil, err
 	}
 
-	strmInfo := streamFileInfo{id: id}
+	strmInfo := streamFileInfo{id: id, conf: conf}
 	// This is an unlikely race condition, see readMain for more info.
 	strmInfo.updatedAt = time.Now()
 
@@ -156,7 +157,7 @@ func (r *Reader) reactStreamUpdate(mgr bundle.NewManagement, strict bool, path s
 
 	mgr.Logger().Infof("Stream %v config updated, attempting to update stream.", info.id)
 
-	conf, lints, err := ReadStreamFile(path)
+	conf, lints, err := ReadStreamFile(path, r.secretsMode)
 	if err != nil {
 		mgr.Logger().Errorf("Failed to read updated stream config: %v", err)
 		return true
@@ -171,5 +172,10 @@ func (r *Reader) reactStreamUpdate(mgr bundle.NewManagement, strict bool, path s
 		return true
 	}
 
-	return r.streamUpdateFn(info.id, conf)
+	if !r.streamUpdateFn(info.id, conf) {
+		return false
+	}
+	info.conf = conf
+	r.streamFileInfo[path] = info
+	return true
 }
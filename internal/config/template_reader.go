@@ -0,0 +1,199 @@
+package config
+
+import (
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/component/cache"
+	"github.com/benthosdev/benthos/v4/internal/component/ratelimit"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/old/input"
+	"github.com/benthosdev/benthos/v4/internal/old/output"
+	"github.com/benthosdev/benthos/v4/internal/old/processor"
+	"github.com/benthosdev/benthos/v4/internal/stream"
+	"github.com/benthosdev/benthos/v4/internal/template"
+)
+
+type templateFileInfo struct {
+	cType docs.Type
+	name  string
+}
+
+// templateSet is a set of templates identified by their type and name.
+type templateSet map[templateFileInfo]struct{}
+
+func (t templateSet) usedBy(walk func(w docs.YAMLWalker, node *yaml.Node), conf interface{}) bool {
+	var node yaml.Node
+	if err := node.Encode(conf); err != nil {
+		// If we can't tell then assume that it is.
+		return true
+	}
+	return t.usedByNode(walk, &node, nil)
+}
+
+// usedByNode walks a config and returns whether it uses any of the templates,
+// including templates used by the configs that other templates expand to.
+func (t templateSet) usedByNode(walk func(w docs.YAMLWalker, node *yaml.Node), node *yaml.Node, expanding []templateFileInfo) bool {
+	var used bool
+	walk(docs.YAMLWalker{
+		OnComponent: func(c docs.WalkedYAMLComponent) {
+			if used {
+				return
+			}
+			info := templateFileInfo{cType: c.Type, name: c.Name}
+			if _, exists := t[info]; exists {
+				used = true
+				return
+			}
+			for _, e := range expanding {
+				if e == info {
+					return
+				}
+			}
+
+			conf := c.Config
+			if conf == nil {
+				conf = &yaml.Node{Kind: yaml.MappingNode}
+			}
+			expanded, isTemplate, err := template.ExpandNode(c.Type, c.Name, conf)
+			if !isTemplate {
+				return
+			}
+			if err != nil {
+				// If we can't tell then assume that it is.
+				used = true
+				return
+			}
+			used = t.usedByNode(func(w docs.YAMLWalker, node *yaml.Node) {
+				w.WalkComponent(c.Type, node)
+			}, expanded, append(expanding, info))
+		},
+	}, node)
+	return used
+}
+
+func (t templateSet) usedByStream(conf stream.Config) bool {
+	return t.usedBy(func(w docs.YAMLWalker, node *yaml.Node) {
+		w.WalkFields(stream.Spec(), node)
+	}, conf)
+}
+
+func (t templateSet) usedByComponent(cType docs.Type, conf interface{}) bool {
+	return t.usedBy(func(w docs.YAMLWalker, node *yaml.Node) {
+		w.WalkComponent(cType, node)
+	}, conf)
+}
+
+// usingTemplates returns the subset of resources that use any of a set of
+// templates.
+func (i *resourceFileInfo) usingTemplates(t templateSet) resourceFileInfo {
+	subset := resourceFileInfo{
+		inputs:     map[string]*input.Config{},
+		processors: map[string]*processor.Config{},
+		outputs:    map[string]*output.Config{},
+		caches:     map[string]*cache.Config{},
+		rateLimits: map[string]*ratelimit.Config{},
+	}
+	for k, v := range i.inputs {
+		if t.usedByComponent(docs.TypeInput, v) {
+			subset.inputs[k] = v
+		}
+	}
+	for k, v := range i.processors {
+		if t.usedByComponent(docs.TypeProcessor, v) {
+			subset.processors[k] = v
+		}
+	}
+	for k, v := range i.outputs {
+		if t.usedByComponent(docs.TypeOutput, v) {
+			subset.outputs[k] = v
+		}
+	}
+	for k, v := range i.caches {
+		if t.usedByComponent(docs.TypeCache, v) {
+			subset.caches[k] = v
+		}
+	}
+	for k, v := range i.rateLimits {
+		if t.usedByComponent(docs.TypeRateLimit, v) {
+			subset.rateLimits[k] = v
+		}
+	}
+	return subset
+}
+
+func readTemplateInfo(path string) (info templateFileInfo, lints []string, err error) {
+	tConf, lints, err := template.ReadConfig(path)
+	if err != nil {
+		return
+	}
+	info = templateFileInfo{cType: docs.Type(tConf.Type), name: tConf.Name}
+	return
+}
+
+// reactTemplateUpdate registers the updated template and then rebuilds only the
+// resources and streams that use it, or that used the template that was
+// previously defined within the file.
+func (r *Reader) reactTemplateUpdate(mgr bundle.NewManagement, strict bool, path string) bool {
+	prevInfo, exists := r.templateFileInfo[path]
+	if !exists {
+		mgr.Logger().Warnf("Skipping template update for unknown path: %v", path)
+		return true
+	}
+
+	mgr.Logger().Infof("Template %v updated, attempting to update components that use it.", path)
+
+	info, lints, err := readTemplateInfo(path)
+	if err != nil {
+		mgr.Logger().Errorf("Failed to read updated template: %v", err)
+		return true
+	}
+
+	lintlog := mgr.Logger()
+	for _, lint := range lints {
+		lintlog.Infof("template file %v: %v", path, lint)
+	}
+	if strict && len(lints) > 0 {
+		mgr.Logger().Errorln("Rejecting updated template due to linter errors, to allow linting errors run Benthos with --chilled")
+		return true
+	}
+
+	if _, err := template.InitTemplates(path); err != nil {
+		mgr.Logger().Errorf("Failed to register updated template: %v", err)
+		return true
+	}
+	r.templateFileInfo[path] = info
+
+	changed := templateSet{prevInfo: {}, info: {}}
+	succeeded := true
+
+	// Resources are updated first as streams may depend on them.
+	r.resourceFileInfoMut.Lock()
+	for _, resInfo := range r.resourceFileInfo {
+		affected := resInfo.usingTemplates(changed)
+		if !affected.applyChanges(mgr, resourceFileInfo{}) {
+			succeeded = false
+		}
+	}
+	r.resourceFileInfoMut.Unlock()
+
+	affected := r.mainResourceInfo.usingTemplates(changed)
+	if !affected.applyChanges(mgr, resourceFileInfo{}) {
+		succeeded = false
+	}
+
+	if r.mainUpdateFn != nil && r.mainConf != nil && changed.usedByStream(*r.mainConf) {
+		if !r.mainUpdateFn(*r.mainConf) {
+			succeeded = false
+		}
+	}
+
+	if r.streamUpdateFn != nil {
+		for _, strmInfo := range r.streamFileInfo {
+			if changed.usedByStream(strmInfo.conf) && !r.streamUpdateFn(strmInfo.id, strmInfo.conf) {
+				succeeded = false
+			}
+		}
+	}
+	return succeeded
+}
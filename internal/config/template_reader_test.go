@@ -0,0 +1,111 @@
+package config
+
+import (
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/manager"
+	"github.com/benthosdev/benthos/v4/internal/stream"
+	"github.com/benthosdev/benthos/v4/internal/template"
+)
+
+func TestReaderTemplateUpdateAffectedStreams(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"templates/greeting.yaml": `
+name: reader_test_greeting
+type: processor
+mapping: |
+  root.bloblang = "root = \"hello\""
+`,
+		"templates/wrapper.yaml": `
+name: reader_test_greeting_wrapper
+type: processor
+mapping: |
+  root.reader_test_greeting = {}
+`,
+		"streams/first.yaml": `
+pipeline:
+  processors:
+    - reader_test_greeting: {}
+`,
+		"streams/second.yaml": `
+pipeline:
+  processors:
+    - bloblang: 'root = "unrelated"'
+`,
+		"streams/third.yaml": `
+pipeline:
+  processors:
+    - reader_test_greeting_wrapper: {}
+`,
+	})
+
+	_, err := template.InitTemplates(
+		filepath.Join(dir, "templates/greeting.yaml"),
+		filepath.Join(dir, "templates/wrapper.yaml"),
+	)
+	require.NoError(t, err)
+
+	rdr := NewReader("", nil,
+		OptSetStreamPaths(filepath.Join(dir, "streams")),
+		OptSetTemplatePaths(filepath.Join(dir, "templates/*.yaml")),
+	)
+	rdr.changeDelayPeriod = 1 * time.Millisecond
+	rdr.changeFlushPeriod = 1 * time.Millisecond
+
+	conf := New()
+	_, err = rdr.Read(&conf)
+	require.NoError(t, err)
+
+	streamConfs := map[string]stream.Config{}
+	_, err = rdr.ReadStreams(streamConfs)
+	require.NoError(t, err)
+	require.Len(t, streamConfs, 3)
+
+	var updatedMut sync.Mutex
+	var updated []string
+	require.NoError(t, rdr.SubscribeStreamChanges(func(id string, conf stream.Config) bool {
+		updatedMut.Lock()
+		updated = append(updated, id)
+		updatedMut.Unlock()
+		return true
+	}))
+
+	testMgr, err := manager.NewV2(manager.NewResourceConfig(), nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	require.NoError(t, rdr.BeginFileWatching(testMgr, true))
+
+	writeFiles(t, dir, map[string]string{
+		"templates/greeting.yaml": `
+name: reader_test_greeting
+type: processor
+mapping: |
+  root.bloblang = "root = \"hey\""
+`,
+	})
+
+	assert.Eventually(t, func() bool {
+		updatedMut.Lock()
+		defer updatedMut.Unlock()
+		return len(updated) > 1
+	}, time.Second, time.Millisecond*10)
+
+	// Give any unexpected updates a chance to be triggered
+	<-time.After(50 * time.Millisecond)
+
+	// Streams that use the template indirectly via another template are also
+	// updated.
+	updatedMut.Lock()
+	sort.Strings(updated)
+	assert.Equal(t, []string{"first", "third"}, updated)
+	updatedMut.Unlock()
+}
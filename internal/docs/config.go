@@ -60,6 +60,12 @@ var labelField = FieldString(
 	return nil
 })
 
+// ReservedFieldsByType returns the fields that are accepted alongside the
+// config of any component of a given type, such as label and processors.
+func ReservedFieldsByType(t Type) map[string]FieldSpec {
+	return reservedFieldsByType(t)
+}
+
 func reservedFieldsByType(t Type) map[string]FieldSpec {
 	m := map[string]FieldSpec{
 		"type":   FieldString("type", ""),
@@ -0,0 +1,200 @@
+package docs
+
+import (
+	"fmt"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MigrateFn rewrites the YAML of a component in place, returning a
+// description of each change made, which is empty when the component did not
+// need migrating.
+type MigrateFn func(c WalkedYAMLComponent) ([]string, error)
+
+// Migration describes a rewrite of a deprecated component, or of deprecated
+// fields of a component, into their replacements.
+type Migration struct {
+	// The type of component that the migration applies to.
+	Type Type
+
+	// The name of the component that the migration applies to, or empty when
+	// the migration applies to all components of the type.
+	Name string
+
+	Migrate MigrateFn
+}
+
+var (
+	migrationsMut    sync.Mutex
+	globalMigrations []Migration
+)
+
+// RegisterMigration adds a migration to be applied by the migrate subcommand,
+// which is usually registered alongside the spec of the deprecated component.
+func RegisterMigration(m Migration) {
+	migrationsMut.Lock()
+	globalMigrations = append(globalMigrations, m)
+	migrationsMut.Unlock()
+}
+
+func migrationsFor(cType Type, name string) (migrations []Migration) {
+	migrationsMut.Lock()
+	defer migrationsMut.Unlock()
+	for _, m := range globalMigrations {
+		if m.Type == cType && (m.Name == "" || m.Name == name) {
+			migrations = append(migrations, m)
+		}
+	}
+	return
+}
+
+// MigrationChange describes a change made to a config by a migration.
+type MigrationChange struct {
+	Line int
+	What string
+}
+
+// MigrateYAML walks a YAML config according to field specs and applies the
+// registered migrations to each component within it. The node is modified in
+// place, which preserves any comments.
+func MigrateYAML(prov Provider, f FieldSpecs, node *yaml.Node) ([]MigrationChange, error) {
+	var components []WalkedYAMLComponent
+	YAMLWalker{
+		Provider: prov,
+		OnComponent: func(c WalkedYAMLComponent) {
+			components = append(components, c)
+		},
+	}.WalkFields(f, node)
+
+	var changes []MigrationChange
+	for _, c := range components {
+		line := c.Node.Line
+		for _, m := range migrationsFor(c.Type, c.Name) {
+			whats, err := m.Migrate(c)
+			if err != nil {
+				return nil, fmt.Errorf("line %v: failed to migrate %v %v: %w", line, c.Type, c.Name, err)
+			}
+			for _, what := range whats {
+				changes = append(changes, MigrationChange{Line: line, What: what})
+			}
+		}
+	}
+	return changes, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Rename changes the name of a component to another, keeping its config.
+func (c WalkedYAMLComponent) Rename(name string) {
+	for i := 0; i < len(c.Node.Content)-1; i += 2 {
+		key, value := c.Node.Content[i], c.Node.Content[i+1]
+		switch {
+		case key.Value == c.Name:
+			key.Value = name
+		case key.Value == "type" && value.Value == c.Name:
+			value.Value = name
+		}
+	}
+}
+
+// YAMLMappingGet returns the value of a key within a YAML mapping, or nil if
+// the key does not exist.
+func YAMLMappingGet(node *yaml.Node, key string) *yaml.Node {
+	node = unwrapDocumentNode(node)
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// YAMLMappingRename renames a key within a YAML mapping, keeping its position
+// and comments. Returns false if the key does not exist.
+func YAMLMappingRename(node *yaml.Node, from, to string) bool {
+	node = unwrapDocumentNode(node)
+	if node == nil || node.Kind != yaml.MappingNode {
+		return false
+	}
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		if node.Content[i].Value == from {
+			node.Content[i].Value = to
+			return true
+		}
+	}
+	return false
+}
+
+// YAMLMappingRemove removes a key from a YAML mapping and returns its value,
+// or nil if the key does not exist. Comments above the key are moved to the
+// key that follows it.
+func YAMLMappingRemove(node *yaml.Node, key string) *yaml.Node {
+	node = unwrapDocumentNode(node)
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		if node.Content[i].Value != key {
+			continue
+		}
+		keyNode, value := node.Content[i], node.Content[i+1]
+		node.Content = append(node.Content[:i], node.Content[i+2:]...)
+		if keyNode.HeadComment != "" {
+			if i < len(node.Content) {
+				next := node.Content[i]
+				next.HeadComment = joinComments(keyNode.HeadComment, next.HeadComment)
+			} else {
+				node.FootComment = joinComments(keyNode.HeadComment, node.FootComment)
+			}
+		}
+		return value
+	}
+	return nil
+}
+
+// YAMLMappingSet sets the value of a key within a YAML mapping, adding the key
+// to the end of the mapping if it does not already exist.
+func YAMLMappingSet(node *yaml.Node, key string, value *yaml.Node) {
+	YAMLMappingSetAfter(node, "", key, value)
+}
+
+// YAMLMappingSetAfter sets the value of a key within a YAML mapping, adding the
+// key directly after another key if it does not already exist, or to the end
+// of the mapping when the other key does not exist either.
+func YAMLMappingSetAfter(node *yaml.Node, after, key string, value *yaml.Node) {
+	node = unwrapDocumentNode(node)
+	if node.Kind != yaml.MappingNode {
+		*node = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	}
+
+	index := len(node.Content)
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		switch node.Content[i].Value {
+		case key:
+			node.Content[i+1] = value
+			return
+		case after:
+			index = i + 2
+		}
+	}
+
+	keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}
+	content := make([]*yaml.Node, 0, len(node.Content)+2)
+	content = append(content, node.Content[:index]...)
+	content = append(content, keyNode, value)
+	node.Content = append(content, node.Content[index:]...)
+}
+
+func joinComments(a, b string) string {
+	if a == "" {
+		return b
+	}
+	if b == "" {
+		return a
+	}
+	return a + "\n" + b
+}
@@ -0,0 +1,116 @@
+package docs_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+func TestMigrateYAML(t *testing.T) {
+	mockProv := docs.NewMappedDocsProvider()
+	mockProv.RegisterDocs(docs.ComponentSpec{
+		Name: "migratetest_old",
+		Type: docs.TypeInput,
+		Config: docs.FieldComponent().WithChildren(
+			docs.FieldString("old_field", ""),
+			docs.FieldString("other", ""),
+		),
+	})
+	mockProv.RegisterDocs(docs.ComponentSpec{
+		Name: "migratetest_proc",
+		Type: docs.TypeProcessor,
+		Config: docs.FieldComponent().WithChildren(
+			docs.FieldString("mode", ""),
+		),
+	})
+
+	docs.RegisterMigration(docs.Migration{
+		Type: docs.TypeInput,
+		Name: "migratetest_old",
+		Migrate: func(c docs.WalkedYAMLComponent) ([]string, error) {
+			docs.YAMLMappingRename(c.Config, "old_field", "new_field")
+			docs.YAMLMappingSetAfter(c.Config, "new_field", "added", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "b"})
+			c.Rename("migratetest_new")
+			return []string{"replaced migratetest_old"}, nil
+		},
+	})
+	docs.RegisterMigration(docs.Migration{
+		Type: docs.TypeProcessor,
+		Migrate: func(c docs.WalkedYAMLComponent) ([]string, error) {
+			if mode := docs.YAMLMappingGet(c.Config, "mode"); mode != nil && mode.Value == "legacy" {
+				mode.Value = "modern"
+				return []string{"replaced legacy mode"}, nil
+			}
+			return nil, nil
+		},
+	})
+
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(`
+input:
+  label: foo
+  # The old input
+  migratetest_old:
+    old_field: a # keep me
+    other: b
+  processors:
+    - migratetest_proc:
+        mode: legacy
+    - migratetest_proc:
+        mode: other
+`), &node))
+
+	changes, err := docs.MigrateYAML(mockProv, docs.FieldSpecs{docs.FieldInput("input", "")}, &node)
+	require.NoError(t, err)
+	assert.Equal(t, []docs.MigrationChange{
+		{Line: 3, What: "replaced migratetest_old"},
+		{Line: 9, What: "replaced legacy mode"},
+	}, changes)
+
+	resBytes, err := yaml.Marshal(&node)
+	require.NoError(t, err)
+	assert.Equal(t, `input:
+    label: foo
+    # The old input
+    migratetest_new:
+        new_field: a # keep me
+        added: b
+        other: b
+    processors:
+        - migratetest_proc:
+            mode: modern
+        - migratetest_proc:
+            mode: other
+`, string(resBytes))
+}
+
+func TestYAMLMappingRemove(t *testing.T) {
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(`
+# About a
+a: 1
+# About b
+b: 2
+# About c
+c: 3
+`), &node))
+
+	assert.Equal(t, "2", docs.YAMLMappingRemove(&node, "b").Value)
+	assert.Nil(t, docs.YAMLMappingRemove(&node, "d"))
+
+	docs.YAMLMappingSet(&node, "d", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: "4"})
+
+	resBytes, err := yaml.Marshal(&node)
+	require.NoError(t, err)
+	assert.Equal(t, `# About a
+a: 1
+# About b
+# About c
+c: 3
+d: 4
+`, string(resBytes))
+}
@@ -0,0 +1,181 @@
+package docs
+
+import (
+	"gopkg.in/yaml.v3"
+)
+
+// WalkedYAMLComponent describes a component encountered whilst walking a YAML
+// config.
+type WalkedYAMLComponent struct {
+	Type  Type
+	Name  string
+	Label string
+
+	// The node of the component, containing the label and type fields.
+	Node *yaml.Node
+
+	// The node of the component specific config.
+	Config *yaml.Node
+}
+
+// WalkedYAMLField describes a field encountered whilst walking a YAML config.
+type WalkedYAMLField struct {
+	// The component that the field belongs to, which is nil for fields that do
+	// not belong to a component.
+	Component *WalkedYAMLComponent
+
+	// The path of the field relative to the config of its component, or to the
+	// root of the config when the field does not belong to a component.
+	Path []string
+
+	Spec  FieldSpec
+	Value *yaml.Node
+}
+
+// YAMLWalker walks a YAML config using the specs of fields and components in
+// order to identify each component and field within it.
+type YAMLWalker struct {
+	// Provides documentation for component implementations.
+	Provider Provider
+
+	// Called for each component encountered, before its fields are walked.
+	OnComponent func(c WalkedYAMLComponent)
+
+	// Called for each leaf field value encountered. Elements of array and map
+	// fields are each provided with a scalar variant of the field spec.
+	OnField func(f WalkedYAMLField)
+}
+
+// WalkFields walks a YAML node according to field specs.
+func (w YAMLWalker) WalkFields(f FieldSpecs, node *yaml.Node) {
+	w.walkFields(nil, nil, f, node)
+}
+
+// WalkComponent walks a YAML node as a component of a given type.
+func (w YAMLWalker) WalkComponent(cType Type, node *yaml.Node) {
+	node = unwrapDocumentNode(node)
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+
+	c := WalkedYAMLComponent{Type: cType, Node: node}
+
+	var keys []string
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		switch node.Content[i].Value {
+		case "type":
+			c.Name = node.Content[i+1].Value
+		case "label":
+			c.Label = node.Content[i+1].Value
+		default:
+			keys = append(keys, node.Content[i].Value)
+		}
+	}
+	if c.Name == "" {
+		if len(node.Content) == 0 {
+			return
+		}
+		var err error
+		if c.Name, _, err = getInferenceCandidateFromList(w.Provider, cType, keys); err != nil {
+			return
+		}
+	}
+
+	cSpec, exists := GetDocs(w.Provider, c.Name, cType)
+	if !exists {
+		return
+	}
+
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		if node.Content[i].Value == c.Name || (node.Content[i].Value == "plugin" && cSpec.Plugin) {
+			c.Config = node.Content[i+1]
+		}
+	}
+
+	if w.OnComponent != nil {
+		w.OnComponent(c)
+	}
+
+	if c.Config != nil {
+		w.walkField(&c, nil, cSpec.Config, c.Config)
+	}
+
+	reservedFields := reservedFieldsByType(cType)
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		key := node.Content[i].Value
+		if key == c.Name || key == "type" || key == "plugin" || key == "label" {
+			continue
+		}
+		if spec, exists := reservedFields[key]; exists {
+			w.walkField(nil, []string{key}, spec, node.Content[i+1])
+		}
+	}
+}
+
+func (w YAMLWalker) walkFields(c *WalkedYAMLComponent, path []string, f FieldSpecs, node *yaml.Node) {
+	node = unwrapDocumentNode(node)
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+
+	specNames := map[string]FieldSpec{}
+	for _, field := range f {
+		specNames[field.Name] = field
+	}
+
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		spec, exists := specNames[node.Content[i].Value]
+		if !exists {
+			continue
+		}
+		fieldPath := append(append([]string{}, path...), spec.Name)
+		w.walkField(c, fieldPath, spec, node.Content[i+1])
+	}
+}
+
+func (w YAMLWalker) walkField(c *WalkedYAMLComponent, path []string, f FieldSpec, node *yaml.Node) {
+	node = unwrapDocumentNode(node)
+
+	switch f.Kind {
+	case Kind2DArray:
+		if node.Kind == yaml.SequenceNode {
+			for _, child := range node.Content {
+				w.walkField(c, path, f.Array(), child)
+			}
+		}
+		return
+	case KindArray:
+		if node.Kind == yaml.SequenceNode {
+			for _, child := range node.Content {
+				w.walkField(c, path, f.Scalar(), child)
+			}
+		}
+		return
+	case KindMap:
+		if node.Kind == yaml.MappingNode {
+			for i := 0; i < len(node.Content)-1; i += 2 {
+				w.walkField(c, path, f.Scalar(), node.Content[i+1])
+			}
+		}
+		return
+	}
+
+	if coreType, isCore := f.Type.IsCoreComponent(); isCore {
+		w.WalkComponent(coreType, node)
+		return
+	}
+
+	if len(f.Children) > 0 {
+		w.walkFields(c, path, f.Children, node)
+		return
+	}
+
+	if w.OnField != nil {
+		w.OnField(WalkedYAMLField{
+			Component: c,
+			Path:      path,
+			Spec:      f,
+			Value:     node,
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package docs_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+func TestYAMLWalker(t *testing.T) {
+	mockProv := docs.NewMappedDocsProvider()
+	mockProv.RegisterDocs(docs.ComponentSpec{
+		Name: "kafka",
+		Type: docs.TypeInput,
+		Config: docs.FieldComponent().WithChildren(
+			docs.FieldString("addresses", "").Array(),
+			docs.FieldObject("tls", "").WithChildren(
+				docs.FieldBool("enabled", ""),
+			),
+		),
+	})
+	mockProv.RegisterDocs(docs.ComponentSpec{
+		Name:   "bloblang",
+		Type:   docs.TypeProcessor,
+		Config: docs.FieldBloblang("", ""),
+	})
+
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(`
+input:
+  label: foo
+  kafka:
+    addresses: [ a, b ]
+    tls:
+      enabled: true
+  processors:
+    - bloblang: root = this
+`), &node))
+
+	var components, fields []string
+	docs.YAMLWalker{
+		Provider: mockProv,
+		OnComponent: func(c docs.WalkedYAMLComponent) {
+			components = append(components, fmt.Sprintf("%v:%v:%v", c.Type, c.Name, c.Label))
+		},
+		OnField: func(f docs.WalkedYAMLField) {
+			var cName string
+			if f.Component != nil {
+				cName = f.Component.Name
+			}
+			fields = append(fields, fmt.Sprintf("%v:%v:%v", cName, strings.Join(f.Path, "."), f.Value.Value))
+		},
+	}.WalkFields(docs.FieldSpecs{docs.FieldInput("input", "")}, &node)
+
+	assert.Equal(t, []string{
+		"input:kafka:foo",
+		"processor:bloblang:",
+	}, components)
+	assert.Equal(t, []string{
+		"kafka:addresses:a",
+		"kafka:addresses:b",
+		"kafka:tls.enabled:true",
+		"bloblang::root = this",
+	}, fields)
+}
@@ -0,0 +1,142 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/http/docs"
+)
+
+// ErrCircuitOpen is returned when a request is not attempted because the
+// circuit breaker of a client is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+type circuitState int64
+
+const (
+	circuitClosed circuitState = iota
+	circuitHalfOpen
+	circuitOpen
+)
+
+// circuitBreaker tracks consecutive request failures and, once a threshold is
+// reached, rejects requests for a period of time before permitting a limited
+// number of probes through in order to determine whether the server has
+// recovered.
+type circuitBreaker struct {
+	threshold    int
+	openDuration time.Duration
+	probes       int
+
+	mut            sync.Mutex
+	state          circuitState
+	failures       int
+	openedAt       time.Time
+	probesInFlight int
+	probesPassed   int
+
+	nowFn  func() time.Time
+	mState metrics.StatGauge
+}
+
+func newCircuitBreaker(conf docs.CircuitBreakerConfig, stats metrics.Type) (*circuitBreaker, error) {
+	if conf.ErrorThreshold <= 0 {
+		return nil, fmt.Errorf("circuit breaker error_threshold must be greater than zero, got %v", conf.ErrorThreshold)
+	}
+	if conf.HalfOpenProbes <= 0 {
+		return nil, fmt.Errorf("circuit breaker half_open_probes must be greater than zero, got %v", conf.HalfOpenProbes)
+	}
+	openDuration, err := time.ParseDuration(conf.OpenDuration)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse circuit breaker open_duration string: %v", err)
+	}
+	c := &circuitBreaker{
+		threshold:    conf.ErrorThreshold,
+		openDuration: openDuration,
+		probes:       conf.HalfOpenProbes,
+		nowFn:        time.Now,
+		mState:       stats.GetGauge("http_circuit_breaker_state"),
+	}
+	c.mState.Set(int64(circuitClosed))
+	return c, nil
+}
+
+func (c *circuitBreaker) setState(s circuitState) {
+	c.state = s
+	c.failures = 0
+	c.probesInFlight = 0
+	c.probesPassed = 0
+	if s == circuitOpen {
+		c.openedAt = c.nowFn()
+	}
+	c.mState.Set(int64(s))
+}
+
+// allow returns true if a request attempt may be made. When the circuit is
+// half-open a successful call reserves one of the available probes, which must
+// be returned with a call to either success, failure or release.
+func (c *circuitBreaker) allow() bool {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if c.state == circuitOpen {
+		if c.nowFn().Sub(c.openedAt) < c.openDuration {
+			return false
+		}
+		c.setState(circuitHalfOpen)
+	}
+	if c.state == circuitHalfOpen {
+		if c.probesInFlight+c.probesPassed >= c.probes {
+			return false
+		}
+		c.probesInFlight++
+	}
+	return true
+}
+
+// release returns a reserved probe without recording an outcome, this should
+// be called when a permitted request attempt is abandoned.
+func (c *circuitBreaker) release() {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if c.state == circuitHalfOpen && c.probesInFlight > 0 {
+		c.probesInFlight--
+	}
+}
+
+// success records a successful request attempt.
+func (c *circuitBreaker) success() {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	switch c.state {
+	case circuitHalfOpen:
+		if c.probesInFlight > 0 {
+			c.probesInFlight--
+		}
+		if c.probesPassed++; c.probesPassed >= c.probes {
+			c.setState(circuitClosed)
+		}
+	case circuitClosed:
+		c.failures = 0
+	}
+}
+
+// failure records a failed request attempt.
+func (c *circuitBreaker) failure() {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	switch c.state {
+	case circuitHalfOpen:
+		c.setState(circuitOpen)
+	case circuitClosed:
+		if c.failures++; c.failures >= c.threshold {
+			c.setState(circuitOpen)
+		}
+	}
+}
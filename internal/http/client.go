@@ -56,6 +56,7 @@ type Client struct {
 
 	conf          docs.Config
 	retryThrottle *throttle.Type
+	breaker       *circuitBreaker
 
 	log   log.Modular
 	stats metrics.Type
@@ -196,6 +197,12 @@ func NewClient(conf docs.Config, opts ...func(*Client)) (*Client, error) {
 		throttle.OptMaxExponentPeriod(maxBackoff),
 	)
 
+	if conf.CircuitBreaker.Enabled {
+		if h.breaker, err = newCircuitBreaker(conf.CircuitBreaker, h.stats); err != nil {
+			return nil, err
+		}
+	}
+
 	return &h, nil
 }
 
@@ -486,6 +493,39 @@ func (h *Client) checkStatus(code int) (succeeded bool, retStrat retryStrategy)
 	return true, noRetry
 }
 
+// breakerAllow returns false if the circuit breaker is enabled and currently
+// rejecting request attempts.
+func (h *Client) breakerAllow() bool {
+	if h.breaker == nil {
+		return true
+	}
+	return h.breaker.allow()
+}
+
+// breakerRelease informs the circuit breaker that a permitted request attempt
+// was abandoned before being made.
+func (h *Client) breakerRelease() {
+	if h.breaker == nil {
+		return
+	}
+	h.breaker.release()
+}
+
+// breakerRecord reports the outcome of a request attempt to the circuit
+// breaker. Only transport errors and responses indicating that the server is
+// unavailable (5xx or 429) count as failures, other status codes such as 4xx
+// are caused by the request itself and indicate that the server is reachable.
+func (h *Client) breakerRecord(res *http.Response, err error) {
+	if h.breaker == nil {
+		return
+	}
+	if err != nil && (res == nil || res.StatusCode >= 500 || res.StatusCode == http.StatusTooManyRequests) {
+		h.breaker.failure()
+	} else {
+		h.breaker.success()
+	}
+}
+
 // SendToResponse attempts to create an HTTP request from a provided message,
 // performs it, and then returns the *http.Response, allowing the raw response
 // to be consumed.
@@ -520,7 +560,13 @@ func (h *Client) SendToResponse(ctx context.Context, sendMsg, refMsg *message.Ba
 		}
 	}()
 
+	if !h.breakerAllow() {
+		err = ErrCircuitOpen
+		logErr(err)
+		return nil, err
+	}
 	if !h.waitForAccess(ctx) {
+		h.breakerRelease()
 		return nil, component.ErrTypeClosed
 	}
 
@@ -542,6 +588,7 @@ func (h *Client) SendToResponse(ctx context.Context, sendMsg, refMsg *message.Ba
 		}
 	}
 	h.mLatency.Timing(time.Since(startedAt).Nanoseconds())
+	h.breakerRecord(res, err)
 
 	i, j := 0, numRetries
 	for i < j && err != nil {
@@ -549,16 +596,23 @@ func (h *Client) SendToResponse(ctx context.Context, sendMsg, refMsg *message.Ba
 		if req, err = h.CreateRequest(sendMsg, refMsg); err != nil {
 			continue
 		}
+		if !h.breakerAllow() {
+			err = ErrCircuitOpen
+			break
+		}
 		if rateLimited {
 			if !h.retryThrottle.ExponentialRetryWithContext(ctx) {
+				h.breakerRelease()
 				return nil, component.ErrTypeClosed
 			}
 		} else {
 			if !h.retryThrottle.RetryWithContext(ctx) {
+				h.breakerRelease()
 				return nil, component.ErrTypeClosed
 			}
 		}
 		if !h.waitForAccess(ctx) {
+			h.breakerRelease()
 			return nil, component.ErrTypeClosed
 		}
 		rateLimited = false
@@ -578,6 +632,7 @@ func (h *Client) SendToResponse(ctx context.Context, sendMsg, refMsg *message.Ba
 			}
 		}
 		h.mLatency.Timing(time.Since(startedAt).Nanoseconds())
+		h.breakerRecord(res, err)
 		i++
 	}
 	if err != nil {
@@ -469,3 +469,79 @@ func TestHTTPClientReceiveMultipart(t *testing.T) {
 		assert.Equal(t, "201", resMsg.Get(1).MetaGet("http_status_code"))
 	}
 }
+
+func TestHTTPClientCircuitBreaker(t *testing.T) {
+	var reqCount uint32
+	var healthy int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddUint32(&reqCount, 1)
+		if atomic.LoadInt32(&healthy) == 0 {
+			http.Error(w, "test error", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	conf := docs.NewConfig()
+	conf.URL = ts.URL + "/testpost"
+	conf.Retry = "1ms"
+	conf.NumRetries = 5
+	conf.CircuitBreaker.Enabled = true
+	conf.CircuitBreaker.ErrorThreshold = 3
+	conf.CircuitBreaker.OpenDuration = "50ms"
+
+	stats := metrics.NewLocal()
+	h, err := NewClient(conf, OptSetStats(stats))
+	require.NoError(t, err)
+	defer h.Close(context.Background())
+
+	out := message.QuickBatch([][]byte{[]byte("test")})
+	_, err = h.Send(context.Background(), out, out)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, uint32(3), atomic.LoadUint32(&reqCount))
+	assert.Equal(t, int64(2), stats.GetCounters()["http_circuit_breaker_state"])
+
+	_, err = h.Send(context.Background(), out, out)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, uint32(3), atomic.LoadUint32(&reqCount))
+
+	atomic.StoreInt32(&healthy, 1)
+	<-time.After(60 * time.Millisecond)
+
+	res, err := h.Send(context.Background(), out, out)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", string(res.Get(0).Get()))
+	assert.Equal(t, uint32(4), atomic.LoadUint32(&reqCount))
+	assert.Equal(t, int64(0), stats.GetCounters()["http_circuit_breaker_state"])
+}
+
+func TestHTTPClientCircuitBreakerClientErrors(t *testing.T) {
+	var reqCount uint32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddUint32(&reqCount, 1)
+		http.Error(w, "bad payload", http.StatusBadRequest)
+	}))
+	defer ts.Close()
+
+	conf := docs.NewConfig()
+	conf.URL = ts.URL + "/testpost"
+	conf.Retry = "1ms"
+	conf.NumRetries = 5
+	conf.CircuitBreaker.Enabled = true
+	conf.CircuitBreaker.ErrorThreshold = 3
+	conf.CircuitBreaker.OpenDuration = "1m"
+
+	stats := metrics.NewLocal()
+	h, err := NewClient(conf, OptSetStats(stats))
+	require.NoError(t, err)
+	defer h.Close(context.Background())
+
+	out := message.QuickBatch([][]byte{[]byte("test")})
+	_, err = h.Send(context.Background(), out, out)
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, uint32(6), atomic.LoadUint32(&reqCount))
+	assert.Equal(t, int64(0), stats.GetCounters()["http_circuit_breaker_state"])
+}
@@ -0,0 +1,37 @@
+package docs
+
+import (
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+// CircuitBreakerConfig contains configuration for a circuit breaker that
+// prevents requests from being attempted while a downstream server is
+// considered unavailable.
+type CircuitBreakerConfig struct {
+	Enabled        bool   `json:"enabled" yaml:"enabled"`
+	ErrorThreshold int    `json:"error_threshold" yaml:"error_threshold"`
+	OpenDuration   string `json:"open_duration" yaml:"open_duration"`
+	HalfOpenProbes int    `json:"half_open_probes" yaml:"half_open_probes"`
+}
+
+// NewCircuitBreakerConfig returns a new circuit breaker config with default
+// fields.
+func NewCircuitBreakerConfig() CircuitBreakerConfig {
+	return CircuitBreakerConfig{
+		Enabled:        false,
+		ErrorThreshold: 5,
+		OpenDuration:   "30s",
+		HalfOpenProbes: 1,
+	}
+}
+
+// CircuitBreakerFieldSpec returns a field spec for an http client circuit
+// breaker.
+func CircuitBreakerFieldSpec() docs.FieldSpec {
+	return docs.FieldObject("circuit_breaker", "Allows you to configure a circuit breaker that stops requests from being attempted once a consecutive number of them have failed, causing messages to fail immediately until the server is probed successfully. The state of the circuit is reported with the gauge `http_circuit_breaker_state`, where `0` is closed, `1` is half-open and `2` is open.").WithChildren(
+		docs.FieldBool("enabled", "Whether the circuit breaker is enabled.").HasDefault(false),
+		docs.FieldInt("error_threshold", "The number of consecutive failed request attempts, including retries, after which the circuit is opened. Only connection errors and responses with a 5xx or 429 status code are counted as failures.").HasDefault(5),
+		docs.FieldString("open_duration", "The period of time to keep the circuit open, during which all requests fail immediately, before probing the server again.").HasDefault("30s"),
+		docs.FieldInt("half_open_probes", "The number of requests permitted to probe the server after the circuit has been open. When this number of probes succeed the circuit is closed, and if any probe fails the circuit is opened again.").HasDefault(1),
+	).Advanced()
+}
@@ -23,6 +23,7 @@ type Config struct {
 	SuccessfulOn    []int                        `json:"successful_on" yaml:"successful_on"`
 	TLS             tls.Config                   `json:"tls" yaml:"tls"`
 	ProxyURL        string                       `json:"proxy_url" yaml:"proxy_url"`
+	CircuitBreaker  CircuitBreakerConfig         `json:"circuit_breaker" yaml:"circuit_breaker"`
 	auth.Config     `json:",inline" yaml:",inline"`
 	OAuth2          auth.OAuth2Config `json:"oauth2" yaml:"oauth2"`
 }
@@ -45,6 +46,7 @@ func NewConfig() Config {
 		DropOn:          []int{},
 		SuccessfulOn:    []int{},
 		TLS:             tls.NewConfig(),
+		CircuitBreaker:  NewCircuitBreakerConfig(),
 		Config:          auth.NewConfig(),
 		OAuth2:          auth.NewOAuth2Config(),
 	}
@@ -4,19 +4,13 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/binary"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"net/http"
-	"net/url"
-	"path"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
 
-	"github.com/linkedin/goavro/v2"
-
 	"github.com/benthosdev/benthos/v4/internal/shutdown"
 	"github.com/benthosdev/benthos/v4/public/service"
 )
@@ -29,7 +23,9 @@ func schemaRegistryDecoderConfig() *service.ConfigSpec {
 		Description(`
 Decodes messages automatically from a schema stored within a [Confluent Schema Registry service](https://docs.confluent.io/platform/current/schema-registry/index.html) by extracting a schema ID from the message and obtaining the associated schema from the registry. If a message fails to match against the schema then it will remain unchanged and the error can be caught using error handling methods outlined [here](/docs/configuration/error_handling).
 
-Currently only Avro schemas are supported.
+Avro, Protobuf and JSON schemas are supported. Messages decoded with a Protobuf schema are converted into JSON documents, and messages decoded with a JSON schema are passed through unchanged once the schema ID has been removed.
+
+The ID of the schema used to decode each message is added to the metadata key ` + "`schema_registry_id`" + `, and the subject the schema is registered under is added to the metadata key ` + "`schema_registry_subject`" + ` when it can be obtained from the registry.
 
 ### Avro JSON Format
 
@@ -65,17 +61,13 @@ func init() {
 //------------------------------------------------------------------------------
 
 type schemaRegistryDecoder struct {
-	client      *http.Client
+	registryClient
 	avroRawJSON bool
 
-	schemaRegistryBaseURL *url.URL
-
 	schemas    map[int]*cachedSchemaDecoder
 	cacheMut   sync.RWMutex
 	requestMut sync.Mutex
 	shutSig    *shutdown.Signaller
-
-	logger *service.Logger
 }
 
 func newSchemaRegistryDecoderFromConfig(conf *service.ParsedConfig, logger *service.Logger) (*schemaRegistryDecoder, error) {
@@ -91,31 +83,16 @@ func newSchemaRegistryDecoderFromConfig(conf *service.ParsedConfig, logger *serv
 }
 
 func newSchemaRegistryDecoder(urlStr string, tlsConf *tls.Config, avroRawJSON bool, logger *service.Logger) (*schemaRegistryDecoder, error) {
-	u, err := url.Parse(urlStr)
+	client, err := newRegistryClient(urlStr, tlsConf, logger)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse url: %w", err)
+		return nil, err
 	}
 
 	s := &schemaRegistryDecoder{
-		avroRawJSON:           avroRawJSON,
-		schemaRegistryBaseURL: u,
-		schemas:               map[int]*cachedSchemaDecoder{},
-		shutSig:               shutdown.NewSignaller(),
-		logger:                logger,
-	}
-
-	s.client = http.DefaultClient
-	if tlsConf != nil {
-		s.client = &http.Client{}
-		if c, ok := http.DefaultTransport.(*http.Transport); ok {
-			cloned := c.Clone()
-			cloned.TLSClientConfig = tlsConf
-			s.client.Transport = cloned
-		} else {
-			s.client.Transport = &http.Transport{
-				TLSClientConfig: tlsConf,
-			}
-		}
+		registryClient: client,
+		avroRawJSON:    avroRawJSON,
+		schemas:        map[int]*cachedSchemaDecoder{},
+		shutSig:        shutdown.NewSignaller(),
 	}
 
 	go func() {
@@ -142,7 +119,7 @@ func (s *schemaRegistryDecoder) Process(ctx context.Context, msg *service.Messag
 		return nil, err
 	}
 
-	decoder, err := s.getDecoder(id)
+	decoder, subject, err := s.getDecoder(id)
 	if err != nil {
 		return nil, err
 	}
@@ -153,6 +130,11 @@ func (s *schemaRegistryDecoder) Process(ctx context.Context, msg *service.Messag
 		return nil, err
 	}
 
+	newMsg.MetaSet("schema_registry_id", strconv.Itoa(id))
+	if subject != "" {
+		newMsg.MetaSet("schema_registry_subject", subject)
+	}
+
 	return service.MessageBatch{newMsg}, nil
 }
 
@@ -175,6 +157,7 @@ type schemaDecoder func(m *service.Message) error
 
 type cachedSchemaDecoder struct {
 	lastUsedUnixSeconds int64
+	subject             string
 	decoder             schemaDecoder
 }
 
@@ -221,13 +204,13 @@ func (s *schemaRegistryDecoder) clearExpired() {
 	}
 }
 
-func (s *schemaRegistryDecoder) getDecoder(id int) (schemaDecoder, error) {
+func (s *schemaRegistryDecoder) getDecoder(id int) (schemaDecoder, string, error) {
 	s.cacheMut.RLock()
 	c, ok := s.schemas[id]
 	s.cacheMut.RUnlock()
 	if ok {
 		atomic.StoreInt64(&c.lastUsedUnixSeconds, time.Now().Unix())
-		return c.decoder, nil
+		return c.decoder, c.subject, nil
 	}
 
 	s.requestMut.Lock()
@@ -240,106 +223,44 @@ func (s *schemaRegistryDecoder) getDecoder(id int) (schemaDecoder, error) {
 	s.cacheMut.RUnlock()
 	if ok {
 		atomic.StoreInt64(&c.lastUsedUnixSeconds, time.Now().Unix())
-		return c.decoder, nil
+		return c.decoder, c.subject, nil
 	}
 
-	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
-	defer done()
-
-	reqURL := *s.schemaRegistryBaseURL
-	reqURL.Path = path.Join(reqURL.Path, fmt.Sprintf("/schemas/ids/%v", id))
-
-	req, err := http.NewRequestWithContext(ctx, "GET", reqURL.String(), http.NoBody)
+	info, err := s.getSchemaByID(id)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
-	req.Header.Add("Accept", "application/vnd.schemaregistry.v1+json")
-
-	var resBytes []byte
-	for i := 0; i < 3; i++ {
-		var res *http.Response
-		if res, err = s.client.Do(req); err != nil {
-			s.logger.Errorf("request failed for schema '%v': %v", id, err)
-			continue
-		}
-
-		if res.StatusCode == http.StatusNotFound {
-			err = fmt.Errorf("schema '%v' not found by registry", id)
-			s.logger.Errorf(err.Error())
-			break
-		}
-
-		if res.StatusCode != http.StatusOK {
-			err = fmt.Errorf("request failed for schema '%v'", id)
-			s.logger.Errorf(err.Error())
-			// TODO: Best attempt at parsing out the body
-			continue
-		}
 
-		if res.Body == nil {
-			s.logger.Errorf("request for schema '%v' returned an empty body", id)
-			err = errors.New("schema request returned an empty body")
-			continue
-		}
-
-		resBytes, err = io.ReadAll(res.Body)
-		res.Body.Close()
-		if err != nil {
-			s.logger.Errorf("failed to read response for schema '%v': %v", id, err)
-			continue
-		}
-
-		break
+	var decoder schemaDecoder
+	switch info.Type() {
+	case schemaTypeAvro:
+		decoder, err = s.getAvroDecoder(info)
+	case schemaTypeProtobuf:
+		decoder, err = s.getProtobufDecoder(info)
+	case schemaTypeJSON:
+		decoder, err = s.getJSONDecoder(info)
+	default:
+		err = fmt.Errorf("schema type '%v' is not supported", info.Type())
 	}
 	if err != nil {
-		return nil, err
-	}
-
-	resPayload := struct {
-		Schema string `json:"schema"`
-	}{}
-	if err = json.Unmarshal(resBytes, &resPayload); err != nil {
 		s.logger.Errorf("failed to parse response for schema '%v': %v", id, err)
-		return nil, err
+		return nil, "", err
 	}
 
-	var codec *goavro.Codec
-	if codec, err = goavro.NewCodecForStandardJSON(resPayload.Schema); err != nil {
-		s.logger.Errorf("failed to parse response for schema '%v': %v", id, err)
-		return nil, err
-	}
-
-	decoder := func(m *service.Message) error {
-		b, err := m.AsBytes()
-		if err != nil {
-			return err
-		}
-
-		native, _, err := codec.NativeFromBinary(b)
-		if err != nil {
-			return err
-		}
-
-		if s.avroRawJSON {
-			// TODO: This still encodes with Avro JSON format, needs
-			// investigation as to whether this is possible.
-			jb, err := codec.TextualFromNative(nil, native)
-			if err != nil {
-				return err
-			}
-			m.SetBytes(jb)
-		} else {
-			m.SetStructured(native)
-		}
-		return nil
+	// The subject is only informational and therefore we tolerate registries
+	// that are unable to provide it.
+	subject, err := s.getSubjectByID(id)
+	if err != nil {
+		s.logger.Debugf("Unable to obtain subject for schema '%v': %v", id, err)
 	}
 
 	s.cacheMut.Lock()
 	s.schemas[id] = &cachedSchemaDecoder{
 		lastUsedUnixSeconds: time.Now().Unix(),
+		subject:             subject,
 		decoder:             decoder,
 	}
 	s.cacheMut.Unlock()
 
-	return decoder, nil
+	return decoder, subject, nil
 }
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -185,6 +186,44 @@ func TestSchemaRegistryDecodeAvro(t *testing.T) {
 	decoder.cacheMut.Unlock()
 }
 
+func TestSchemaRegistryDecodeSubjectLookupSingleAttempt(t *testing.T) {
+	payload3, err := json.Marshal(struct {
+		Schema string `json:"schema"`
+	}{
+		Schema: testSchema,
+	})
+	require.NoError(t, err)
+
+	var versionRequests int32
+	urlStr := runSchemaRegistryServer(t, func(path string) ([]byte, error) {
+		switch path {
+		case "/schemas/ids/3":
+			return payload3, nil
+		case "/schemas/ids/3/versions":
+			atomic.AddInt32(&versionRequests, 1)
+			return nil, fmt.Errorf("nope")
+		}
+		return nil, nil
+	})
+
+	decoder, err := newSchemaRegistryDecoder(urlStr, nil, false, nil)
+	require.NoError(t, err)
+
+	outMsgs, err := decoder.Process(context.Background(), service.NewMessage([]byte("\x00\x00\x00\x00\x03\x06foo\x02\x06foo\x06bar\x00")))
+	require.NoError(t, err)
+	require.Len(t, outMsgs, 1)
+
+	v, exists := outMsgs[0].MetaGet("schema_registry_id")
+	assert.True(t, exists)
+	assert.Equal(t, "3", v)
+
+	_, exists = outMsgs[0].MetaGet("schema_registry_subject")
+	assert.False(t, exists)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&versionRequests))
+
+	require.NoError(t, decoder.Close(context.Background()))
+}
+
 func TestSchemaRegistryDecodeClearExpired(t *testing.T) {
 	urlStr := runSchemaRegistryServer(t, func(path string) ([]byte, error) {
 		return nil, fmt.Errorf("nope")
@@ -4,19 +4,13 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/binary"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"net/http"
-	"net/url"
-	"path"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
 
-	"github.com/linkedin/goavro/v2"
-
 	"github.com/benthosdev/benthos/v4/internal/shutdown"
 	"github.com/benthosdev/benthos/v4/public/service"
 )
@@ -31,7 +25,9 @@ Encodes messages automatically from schemas obtains from a [Confluent Schema Reg
 
 If a message fails to encode under the schema then it will remain unchanged and the error can be caught using error handling methods outlined [here](/docs/configuration/error_handling).
 
-Currently only Avro schemas are supported.
+Avro, Protobuf and JSON schemas are supported. When encoding with a Protobuf schema the documents are expected to be in JSON format and are serialised as the first message of the schema unless the field ` + "[`protobuf_message`](#protobuf_message)" + ` is set. When encoding with a JSON schema the documents are validated against the schema and are otherwise left unchanged.
+
+The ID of the schema used to encode each message is added to the metadata key ` + "`schema_registry_id`" + ` and the subject is added to the metadata key ` + "`schema_registry_subject`" + `.
 
 ### Avro JSON Format
 
@@ -59,6 +55,10 @@ However, it is possible to instead consume documents in raw JSON format (that ma
 		Field(service.NewBoolField("avro_raw_json").
 			Description("Whether messages encoded in Avro format should be parsed as raw JSON documents rather than [Avro JSON](https://avro.apache.org/docs/current/spec.html#json_encoding).").
 			Advanced().Default(false).Version("3.59.0")).
+		Field(service.NewStringField("protobuf_message").
+			Description("The fully qualified name of the message to encode documents as when the schema of a subject is a Protobuf schema. When empty the first message of the schema is used.").
+			Example("testing.Person").
+			Advanced().Default("").Version("4.0.0")).
 		Field(service.NewTLSField("tls")).
 		Version("3.58.0")
 }
@@ -78,20 +78,18 @@ func init() {
 //------------------------------------------------------------------------------
 
 type schemaRegistryEncoder struct {
-	client             *http.Client
+	registryClient
 	subject            *service.InterpolatedString
 	avroRawJSON        bool
+	protobufMessage    string
 	schemaRefreshAfter time.Duration
 
-	schemaRegistryBaseURL *url.URL
-
 	schemas    map[string]*cachedSchemaEncoder
 	cacheMut   sync.RWMutex
 	requestMut sync.Mutex
 	shutSig    *shutdown.Signaller
 
-	logger *service.Logger
-	nowFn  func() time.Time
+	nowFn func() time.Time
 }
 
 func newSchemaRegistryEncoderFromConfig(conf *service.ParsedConfig, logger *service.Logger) (*schemaRegistryEncoder, error) {
@@ -123,7 +121,15 @@ func newSchemaRegistryEncoderFromConfig(conf *service.ParsedConfig, logger *serv
 	if err != nil {
 		return nil, err
 	}
-	return newSchemaRegistryEncoder(urlStr, tlsConf, subject, avroRawJSON, refreshPeriod, refreshTicker, logger)
+	e, err := newSchemaRegistryEncoder(urlStr, tlsConf, subject, avroRawJSON, refreshPeriod, refreshTicker, logger)
+	if err != nil {
+		return nil, err
+	}
+	if e.protobufMessage, err = conf.FieldString("protobuf_message"); err != nil {
+		_ = e.Close(context.Background())
+		return nil, err
+	}
+	return e, nil
 }
 
 func newSchemaRegistryEncoder(
@@ -134,34 +140,19 @@ func newSchemaRegistryEncoder(
 	schemaRefreshAfter, schemaRefreshTicker time.Duration,
 	logger *service.Logger,
 ) (*schemaRegistryEncoder, error) {
-	u, err := url.Parse(urlStr)
+	client, err := newRegistryClient(urlStr, tlsConf, logger)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse url: %w", err)
+		return nil, err
 	}
 
 	s := &schemaRegistryEncoder{
-		schemaRegistryBaseURL: u,
-		subject:               subject,
-		avroRawJSON:           avroRawJSON,
-		schemaRefreshAfter:    schemaRefreshAfter,
-		schemas:               map[string]*cachedSchemaEncoder{},
-		shutSig:               shutdown.NewSignaller(),
-		logger:                logger,
-		nowFn:                 time.Now,
-	}
-
-	s.client = http.DefaultClient
-	if tlsConf != nil {
-		s.client = &http.Client{}
-		if c, ok := http.DefaultTransport.(*http.Transport); ok {
-			cloned := c.Clone()
-			cloned.TLSClientConfig = tlsConf
-			s.client.Transport = cloned
-		} else {
-			s.client.Transport = &http.Transport{
-				TLSClientConfig: tlsConf,
-			}
-		}
+		registryClient:     client,
+		subject:            subject,
+		avroRawJSON:        avroRawJSON,
+		schemaRefreshAfter: schemaRefreshAfter,
+		schemas:            map[string]*cachedSchemaEncoder{},
+		shutSig:            shutdown.NewSignaller(),
+		nowFn:              time.Now,
 	}
 
 	go func() {
@@ -180,7 +171,8 @@ func newSchemaRegistryEncoder(
 func (s *schemaRegistryEncoder) ProcessBatch(ctx context.Context, batch service.MessageBatch) ([]service.MessageBatch, error) {
 	batch = batch.Copy()
 	for i, msg := range batch {
-		encoder, id, err := s.getEncoder(batch.InterpolatedString(i, s.subject))
+		subject := batch.InterpolatedString(i, s.subject)
+		encoder, id, err := s.getEncoder(subject)
 		if err != nil {
 			msg.SetError(err)
 			continue
@@ -202,6 +194,8 @@ func (s *schemaRegistryEncoder) ProcessBatch(ctx context.Context, batch service.
 			continue
 		}
 		msg.SetBytes(rawBytes)
+		msg.MetaSet("schema_registry_id", strconv.Itoa(id))
+		msg.MetaSet("schema_registry_subject", subject)
 	}
 	return []service.MessageBatch{batch}, nil
 }
@@ -286,96 +280,27 @@ func (s *schemaRegistryEncoder) refreshEncoders() {
 }
 
 func (s *schemaRegistryEncoder) getLatestEncoder(subject string) (schemaEncoder, int, error) {
-	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
-	defer done()
-
-	reqURL := *s.schemaRegistryBaseURL
-	reqURL.Path = path.Join(reqURL.Path, fmt.Sprintf("/subjects/%s/versions/latest", subject))
-
-	req, err := http.NewRequestWithContext(ctx, "GET", reqURL.String(), http.NoBody)
+	info, err := s.getSchemaBySubject(subject, 0)
 	if err != nil {
 		return nil, 0, err
 	}
-	req.Header.Add("Accept", "application/vnd.schemaregistry.v1+json")
-
-	var resBytes []byte
-	for i := 0; i < 3; i++ {
-		var res *http.Response
-		if res, err = s.client.Do(req); err != nil {
-			s.logger.Errorf("request failed for schema subject '%v': %v", subject, err)
-			continue
-		}
 
-		if res.StatusCode == http.StatusNotFound {
-			err = fmt.Errorf("schema subject '%v' not found by registry", subject)
-			s.logger.Errorf(err.Error())
-			break
-		}
-
-		if res.StatusCode != http.StatusOK {
-			err = fmt.Errorf("request failed for schema subject '%v'", subject)
-			s.logger.Errorf(err.Error())
-			// TODO: Best attempt at parsing out the body
-			continue
-		}
-
-		if res.Body == nil {
-			s.logger.Errorf("request for schema subject latest '%v' returned an empty body", subject)
-			err = errors.New("schema request returned an empty body")
-			continue
-		}
-
-		resBytes, err = io.ReadAll(res.Body)
-		res.Body.Close()
-		if err != nil {
-			s.logger.Errorf("failed to read response for schema subject '%v': %v", subject, err)
-			continue
-		}
-
-		break
+	var encoder schemaEncoder
+	switch info.Type() {
+	case schemaTypeAvro:
+		encoder, err = s.getAvroEncoder(info)
+	case schemaTypeProtobuf:
+		encoder, err = s.getProtobufEncoder(info)
+	case schemaTypeJSON:
+		encoder, err = s.getJSONEncoder(info)
+	default:
+		err = fmt.Errorf("schema type '%v' is not supported", info.Type())
 	}
 	if err != nil {
-		return nil, 0, err
-	}
-
-	resPayload := struct {
-		Schema string `json:"schema"`
-		ID     int    `json:"id"`
-	}{}
-	if err = json.Unmarshal(resBytes, &resPayload); err != nil {
 		s.logger.Errorf("failed to parse response for schema subject '%v': %v", subject, err)
 		return nil, 0, err
 	}
-
-	var codec *goavro.Codec
-	if codec, err = goavro.NewCodecForStandardJSON(resPayload.Schema); err != nil {
-		s.logger.Errorf("failed to parse response for schema subject '%v': %v", subject, err)
-		return nil, 0, err
-	}
-
-	return func(m *service.Message) error {
-		var datum interface{}
-		if s.avroRawJSON {
-			b, err := m.AsBytes()
-			if err != nil {
-				return err
-			}
-
-			if datum, _, err = codec.NativeFromTextual(b); err != nil {
-				return err
-			}
-		} else if datum, err = m.AsStructured(); err != nil {
-			return err
-		}
-
-		binary, err := codec.BinaryFromNative(nil, datum)
-		if err != nil {
-			return err
-		}
-
-		m.SetBytes(binary)
-		return nil
-	}, resPayload.ID, nil
+	return encoder, info.ID, nil
 }
 
 func (s *schemaRegistryEncoder) getEncoder(subject string) (schemaEncoder, int, error) {
@@ -0,0 +1,233 @@
+package confluent
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	schemaTypeAvro     = "AVRO"
+	schemaTypeProtobuf = "PROTOBUF"
+	schemaTypeJSON     = "JSON"
+)
+
+// schemaReference is a reference from one schema to another, which for
+// protobuf schemas represents an import and for JSON schemas a $ref.
+type schemaReference struct {
+	Name    string `json:"name"`
+	Subject string `json:"subject"`
+	Version int    `json:"version"`
+}
+
+// schemaInfo is the schema payload returned by the registry. The schema type
+// is omitted by the registry for Avro schemas.
+type schemaInfo struct {
+	ID         int               `json:"id"`
+	Subject    string            `json:"subject"`
+	Version    int               `json:"version"`
+	Schema     string            `json:"schema"`
+	SchemaType string            `json:"schemaType"`
+	References []schemaReference `json:"references"`
+}
+
+// Type returns the type of the schema, which defaults to Avro.
+func (s schemaInfo) Type() string {
+	if s.SchemaType == "" {
+		return schemaTypeAvro
+	}
+	return s.SchemaType
+}
+
+// registryClient performs requests against the schema registry API.
+type registryClient struct {
+	client                *http.Client
+	schemaRegistryBaseURL *url.URL
+	logger                *service.Logger
+}
+
+func newRegistryClient(urlStr string, tlsConf *tls.Config, logger *service.Logger) (registryClient, error) {
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return registryClient{}, fmt.Errorf("failed to parse url: %w", err)
+	}
+
+	c := registryClient{
+		client:                http.DefaultClient,
+		schemaRegistryBaseURL: u,
+		logger:                logger,
+	}
+	if tlsConf != nil {
+		c.client = &http.Client{}
+		if t, ok := http.DefaultTransport.(*http.Transport); ok {
+			cloned := t.Clone()
+			cloned.TLSClientConfig = tlsConf
+			c.client.Transport = cloned
+		} else {
+			c.client.Transport = &http.Transport{
+				TLSClientConfig: tlsConf,
+			}
+		}
+	}
+	return c, nil
+}
+
+// get performs a GET request against a path of the registry and returns the
+// response body, retrying failed requests up to three times. The target
+// describes the resource being requested and is used within error messages.
+func (c *registryClient) get(reqPath, target string) ([]byte, error) {
+	var resBytes []byte
+	var err error
+	for i := 0; i < 3; i++ {
+		var retry bool
+		if resBytes, retry, err = c.getOnce(reqPath, target); err == nil {
+			break
+		}
+		c.logger.Errorf(err.Error())
+		if !retry {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resBytes, nil
+}
+
+// getOnce performs a single GET request against a path of the registry and
+// returns the response body, along with whether a failed request is worth
+// retrying.
+func (c *registryClient) getOnce(reqPath, target string) ([]byte, bool, error) {
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	reqURL := *c.schemaRegistryBaseURL
+	reqURL.Path = path.Join(reqURL.Path, reqPath)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL.String(), http.NoBody)
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header.Add("Accept", "application/vnd.schemaregistry.v1+json")
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, true, fmt.Errorf("request failed for %v: %w", target, err)
+	}
+	if res.Body == nil {
+		return nil, true, fmt.Errorf("request for %v returned an empty body", target)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return nil, false, fmt.Errorf("%v not found by registry", target)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		// TODO: Best attempt at parsing out the body
+		return nil, true, fmt.Errorf("request failed for %v", target)
+	}
+
+	resBytes, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to read response for %v: %w", target, err)
+	}
+	return resBytes, false, nil
+}
+
+func (c *registryClient) getSchema(reqPath, target string) (schemaInfo, error) {
+	resBytes, err := c.get(reqPath, target)
+	if err != nil {
+		return schemaInfo{}, err
+	}
+
+	var info schemaInfo
+	if err = json.Unmarshal(resBytes, &info); err != nil {
+		c.logger.Errorf("failed to parse response for %v: %v", target, err)
+		return schemaInfo{}, err
+	}
+	return info, nil
+}
+
+// getSchemaByID obtains a schema by its global ID.
+func (c *registryClient) getSchemaByID(id int) (schemaInfo, error) {
+	info, err := c.getSchema(fmt.Sprintf("/schemas/ids/%v", id), fmt.Sprintf("schema '%v'", id))
+	if err != nil {
+		return info, err
+	}
+	info.ID = id
+	return info, nil
+}
+
+// getSchemaBySubject obtains a schema by its subject and version, where a
+// version of zero obtains the latest version.
+func (c *registryClient) getSchemaBySubject(subject string, version int) (schemaInfo, error) {
+	versionStr := "latest"
+	if version > 0 {
+		versionStr = fmt.Sprintf("%v", version)
+	}
+	return c.getSchema(
+		fmt.Sprintf("/subjects/%s/versions/%v", subject, versionStr),
+		fmt.Sprintf("schema subject '%v'", subject),
+	)
+}
+
+// getSubjectByID attempts to obtain the subject that a schema is registered
+// under. Schemas can be registered under multiple subjects, in which case the
+// first is returned. The subject is only informational and not all registries
+// support this lookup, therefore it is attempted once and failures are left
+// to the caller to log.
+func (c *registryClient) getSubjectByID(id int) (string, error) {
+	resBytes, _, err := c.getOnce(fmt.Sprintf("/schemas/ids/%v/versions", id), fmt.Sprintf("schema '%v' versions", id))
+	if err != nil {
+		return "", err
+	}
+
+	var versions []struct {
+		Subject string `json:"subject"`
+	}
+	if err = json.Unmarshal(resBytes, &versions); err != nil {
+		return "", err
+	}
+	if len(versions) == 0 {
+		return "", fmt.Errorf("schema '%v' is not registered under a subject", id)
+	}
+	return versions[0].Subject, nil
+}
+
+// resolveReferences walks the references of a schema recursively and returns
+// a map of reference names to the referenced schemas.
+func (c *registryClient) resolveReferences(refs []schemaReference) (map[string]string, error) {
+	resolved := map[string]string{}
+
+	var walk func(refs []schemaReference) error
+	walk = func(refs []schemaReference) error {
+		for _, ref := range refs {
+			if _, exists := resolved[ref.Name]; exists {
+				continue
+			}
+			info, err := c.getSchemaBySubject(ref.Subject, ref.Version)
+			if err != nil {
+				return fmt.Errorf("failed to resolve reference '%v': %w", ref.Name, err)
+			}
+			resolved[ref.Name] = info.Schema
+			if err := walk(info.References); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := walk(refs); err != nil {
+		return nil, err
+	}
+	return resolved, nil
+}
@@ -0,0 +1,70 @@
+package confluent
+
+import (
+	"github.com/linkedin/goavro/v2"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func (s *schemaRegistryDecoder) getAvroDecoder(info schemaInfo) (schemaDecoder, error) {
+	codec, err := goavro.NewCodecForStandardJSON(info.Schema)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(m *service.Message) error {
+		b, err := m.AsBytes()
+		if err != nil {
+			return err
+		}
+
+		native, _, err := codec.NativeFromBinary(b)
+		if err != nil {
+			return err
+		}
+
+		if s.avroRawJSON {
+			// TODO: This still encodes with Avro JSON format, needs
+			// investigation as to whether this is possible.
+			jb, err := codec.TextualFromNative(nil, native)
+			if err != nil {
+				return err
+			}
+			m.SetBytes(jb)
+		} else {
+			m.SetStructured(native)
+		}
+		return nil
+	}, nil
+}
+
+func (s *schemaRegistryEncoder) getAvroEncoder(info schemaInfo) (schemaEncoder, error) {
+	codec, err := goavro.NewCodecForStandardJSON(info.Schema)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(m *service.Message) error {
+		var datum interface{}
+		if s.avroRawJSON {
+			b, err := m.AsBytes()
+			if err != nil {
+				return err
+			}
+
+			if datum, _, err = codec.NativeFromTextual(b); err != nil {
+				return err
+			}
+		} else if datum, err = m.AsStructured(); err != nil {
+			return err
+		}
+
+		binary, err := codec.BinaryFromNative(nil, datum)
+		if err != nil {
+			return err
+		}
+
+		m.SetBytes(binary)
+		return nil
+	}, nil
+}
@@ -0,0 +1,94 @@
+package confluent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/xeipuuv/gojsonschema"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const jsonSchemaRefBase = "https://schema-registry.benthos.dev/"
+
+func jsonSchemaRefURL(name string) string {
+	if u, err := url.Parse(name); err == nil && u.IsAbs() {
+		return name
+	}
+	return jsonSchemaRefBase + strings.TrimPrefix(name, "/")
+}
+
+func (s *schemaRegistryDecoder) getJSONDecoder(info schemaInfo) (schemaDecoder, error) {
+	// Documents are JSON already and are therefore passed through unchanged,
+	// validation only takes place during encoding.
+	return func(m *service.Message) error {
+		return nil
+	}, nil
+}
+
+func (s *schemaRegistryEncoder) getJSONEncoder(info schemaInfo) (schemaEncoder, error) {
+	refs, err := s.resolveReferences(info.References)
+	if err != nil {
+		return nil, err
+	}
+
+	// References are registered under canonical URLs, and so names that are
+	// relative are resolved against a base ID that is given to the schema
+	// when it does not already have one.
+	loader := gojsonschema.NewSchemaLoader()
+	for name, schema := range refs {
+		if err := loader.AddSchema(jsonSchemaRefURL(name), gojsonschema.NewStringLoader(schema)); err != nil {
+			return nil, fmt.Errorf("failed to load reference '%v': %w", name, err)
+		}
+	}
+
+	var root interface{}
+	if err := json.Unmarshal([]byte(info.Schema), &root); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON schema: %w", err)
+	}
+	if obj, ok := root.(map[string]interface{}); ok {
+		_, hasID := obj["$id"]
+		_, hasLegacyID := obj["id"]
+		if !hasID && !hasLegacyID {
+			obj["$id"] = jsonSchemaRefURL(fmt.Sprintf("benthos_schema_%v.json", info.ID))
+		}
+	}
+
+	schema, err := loader.Compile(gojsonschema.NewGoLoader(root))
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile JSON schema: %w", err)
+	}
+
+	return func(m *service.Message) error {
+		b, err := m.AsBytes()
+		if err != nil {
+			return err
+		}
+
+		result, err := schema.Validate(gojsonschema.NewBytesLoader(b))
+		if err != nil {
+			return err
+		}
+
+		if !result.Valid() {
+			var errStr string
+			for i, desc := range result.Errors() {
+				if i > 0 {
+					errStr += "\n"
+				}
+				description := strings.ToLower(desc.Description())
+				if property := desc.Details()["property"]; property != nil {
+					description = property.(string) + strings.TrimPrefix(description, strings.ToLower(property.(string)))
+				}
+				errStr += desc.Field() + " " + description
+			}
+			return errors.New(errStr)
+		}
+
+		m.SetBytes(b)
+		return nil
+	}, nil
+}
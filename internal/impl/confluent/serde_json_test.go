@@ -0,0 +1,96 @@
+package confluent
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestSchemaRegistryEncodeJSON(t *testing.T) {
+	address, err := json.Marshal(schemaInfo{
+		ID:         1,
+		Schema:     `{"type":"object","properties":{"city":{"type":"string"}},"required":["city"]}`,
+		SchemaType: schemaTypeJSON,
+	})
+	require.NoError(t, err)
+
+	person, err := json.Marshal(schemaInfo{
+		ID:         2,
+		Schema:     `{"type":"object","properties":{"name":{"type":"string"},"address":{"$ref":"address.json"}}}`,
+		SchemaType: schemaTypeJSON,
+		References: []schemaReference{
+			{Name: "address.json", Subject: "address", Version: 1},
+		},
+	})
+	require.NoError(t, err)
+
+	urlStr := runSchemaRegistryServer(t, func(path string) ([]byte, error) {
+		switch path {
+		case "/subjects/person/versions/latest":
+			return person, nil
+		case "/subjects/address/versions/1":
+			return address, nil
+		}
+		return nil, nil
+	})
+
+	subj, err := service.NewInterpolatedString("person")
+	require.NoError(t, err)
+
+	encoder, err := newSchemaRegistryEncoder(urlStr, nil, subj, false, time.Minute*10, time.Minute, nil)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		input       string
+		errContains string
+	}{
+		{
+			name:  "successful message",
+			input: `{"name":"foo","address":{"city":"bar"}}`,
+		},
+		{
+			name:        "message doesnt match schema",
+			input:       `{"name":"foo","address":{"state":"bar"}}`,
+			errContains: "city is required",
+		},
+		{
+			name:        "message doesnt match referenced schema types",
+			input:       `{"name":"foo","address":{"city":10}}`,
+			errContains: "invalid type",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			outBatches, err := encoder.ProcessBatch(
+				context.Background(),
+				service.MessageBatch{service.NewMessage([]byte(test.input))},
+			)
+			require.NoError(t, err)
+			require.Len(t, outBatches, 1)
+			require.Len(t, outBatches[0], 1)
+
+			err = outBatches[0][0].GetError()
+			if test.errContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.errContains)
+			} else {
+				require.NoError(t, err)
+
+				b, err := outBatches[0][0].AsBytes()
+				require.NoError(t, err)
+				assert.Equal(t, "\x00\x00\x00\x00\x02"+test.input, string(b))
+			}
+		})
+	}
+
+	require.NoError(t, encoder.Close(context.Background()))
+}
@@ -0,0 +1,183 @@
+package confluent
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+
+	"github.com/benthosdev/benthos/v4/internal/impl/protobuf"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+// parseProtobufSchema parses a protobuf schema obtained from the registry along
+// with any schemas it references (imports).
+func (c *registryClient) parseProtobufSchema(info schemaInfo) (*desc.FileDescriptor, *protobuf.Registry, error) {
+	files, err := c.resolveReferences(info.References)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fileName := fmt.Sprintf("benthos_schema_%v.proto", info.ID)
+	files[fileName] = info.Schema
+
+	parser := protoparse.Parser{
+		Accessor: protoparse.FileContentsFromMap(files),
+	}
+	fds, err := parser.ParseFiles(fileName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse protobuf schema: %w", err)
+	}
+	return fds[0], protobuf.NewRegistry(fds), nil
+}
+
+// Protobuf messages serialised with the Confluent wire format are prefixed
+// with an array of indexes that identify the message type within the schema,
+// starting with the index of the top level message followed by the indexes of
+// any nested messages. The array is encoded as a zig-zag varint length followed
+// by zig-zag varint indexes, with the common case of a single zero index
+// encoded as a lone zero.
+
+func readMessageIndexes(b []byte) (indexes []int, remaining []byte, err error) {
+	count, n := binary.Varint(b)
+	if n <= 0 {
+		return nil, nil, errors.New("failed to read message indexes")
+	}
+	b = b[n:]
+	if count == 0 {
+		return []int{0}, b, nil
+	}
+	if count < 0 || count > int64(len(b)) {
+		return nil, nil, fmt.Errorf("invalid message index count: %v", count)
+	}
+	for i := int64(0); i < count; i++ {
+		index, n := binary.Varint(b)
+		if n <= 0 {
+			return nil, nil, errors.New("failed to read message indexes")
+		}
+		indexes = append(indexes, int(index))
+		b = b[n:]
+	}
+	return indexes, b, nil
+}
+
+func appendMessageIndexes(b []byte, indexes []int) []byte {
+	if len(indexes) == 1 && indexes[0] == 0 {
+		return append(b, 0)
+	}
+	buf := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutVarint(buf, int64(len(indexes)))
+	b = append(b, buf[:n]...)
+	for _, index := range indexes {
+		n = binary.PutVarint(buf, int64(index))
+		b = append(b, buf[:n]...)
+	}
+	return b
+}
+
+func messageByIndexes(fd *desc.FileDescriptor, indexes []int) (*desc.MessageDescriptor, error) {
+	if len(indexes) == 0 {
+		return nil, errors.New("message indexes must not be empty")
+	}
+	msgs := fd.GetMessageTypes()
+	var md *desc.MessageDescriptor
+	for _, index := range indexes {
+		if index < 0 || index >= len(msgs) {
+			return nil, fmt.Errorf("message index %v not found within schema", indexes)
+		}
+		md = msgs[index]
+		msgs = md.GetNestedMessageTypes()
+	}
+	return md, nil
+}
+
+func messageIndexes(md *desc.MessageDescriptor) []int {
+	var indexes []int
+	for {
+		var siblings []*desc.MessageDescriptor
+		parent, isNested := md.GetParent().(*desc.MessageDescriptor)
+		if isNested {
+			siblings = parent.GetNestedMessageTypes()
+		} else {
+			siblings = md.GetFile().GetMessageTypes()
+		}
+		for i, s := range siblings {
+			if s == md {
+				indexes = append([]int{i}, indexes...)
+				break
+			}
+		}
+		if !isNested {
+			return indexes
+		}
+		md = parent
+	}
+}
+
+func (s *schemaRegistryDecoder) getProtobufDecoder(info schemaInfo) (schemaDecoder, error) {
+	fd, registry, err := s.parseProtobufSchema(info)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(m *service.Message) error {
+		b, err := m.AsBytes()
+		if err != nil {
+			return err
+		}
+
+		indexes, remaining, err := readMessageIndexes(b)
+		if err != nil {
+			return err
+		}
+
+		md, err := messageByIndexes(fd, indexes)
+		if err != nil {
+			return err
+		}
+
+		jBytes, err := registry.ToJSON(md, remaining)
+		if err != nil {
+			return err
+		}
+
+		m.SetBytes(jBytes)
+		return nil
+	}, nil
+}
+
+func (s *schemaRegistryEncoder) getProtobufEncoder(info schemaInfo) (schemaEncoder, error) {
+	fd, registry, err := s.parseProtobufSchema(info)
+	if err != nil {
+		return nil, err
+	}
+
+	var md *desc.MessageDescriptor
+	if s.protobufMessage != "" {
+		if md = fd.FindMessage(s.protobufMessage); md == nil {
+			return nil, fmt.Errorf("message '%v' not found within schema", s.protobufMessage)
+		}
+	} else if msgs := fd.GetMessageTypes(); len(msgs) > 0 {
+		md = msgs[0]
+	} else {
+		return nil, errors.New("schema does not contain any messages")
+	}
+	prefix := appendMessageIndexes(nil, messageIndexes(md))
+
+	return func(m *service.Message) error {
+		b, err := m.AsBytes()
+		if err != nil {
+			return err
+		}
+
+		pBytes, err := registry.FromJSON(md, b)
+		if err != nil {
+			return err
+		}
+
+		m.SetBytes(append(append([]byte{}, prefix...), pBytes...))
+		return nil
+	}, nil
+}
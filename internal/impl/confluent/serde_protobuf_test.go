@@ -0,0 +1,177 @@
+package confluent
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const testProtoAddressSchema = `
+syntax = "proto3";
+package testing;
+
+message Address {
+  string city = 1;
+  string state = 2;
+}
+`
+
+const testProtoPersonSchema = `
+syntax = "proto3";
+package testing;
+
+import "address.proto";
+
+message Person {
+  string name = 1;
+  Address address = 2;
+
+  message Hobby {
+    string name = 1;
+  }
+}
+`
+
+func runProtobufSchemaRegistryServer(t *testing.T) string {
+	t.Helper()
+
+	mustJSON := func(v interface{}) []byte {
+		b, err := json.Marshal(v)
+		require.NoError(t, err)
+		return b
+	}
+
+	address := mustJSON(schemaInfo{
+		ID:         1,
+		Subject:    "address",
+		Version:    1,
+		Schema:     testProtoAddressSchema,
+		SchemaType: schemaTypeProtobuf,
+	})
+	person := mustJSON(schemaInfo{
+		ID:         2,
+		Subject:    "person",
+		Version:    1,
+		Schema:     testProtoPersonSchema,
+		SchemaType: schemaTypeProtobuf,
+		References: []schemaReference{
+			{Name: "address.proto", Subject: "address", Version: 1},
+		},
+	})
+
+	return runSchemaRegistryServer(t, func(path string) ([]byte, error) {
+		switch path {
+		case "/schemas/ids/2":
+			return person, nil
+		case "/schemas/ids/2/versions":
+			return []byte(`[{"subject":"person","version":1}]`), nil
+		case "/subjects/person/versions/latest":
+			return person, nil
+		case "/subjects/address/versions/1":
+			return address, nil
+		}
+		return nil, nil
+	})
+}
+
+func TestMessageIndexesRoundTrip(t *testing.T) {
+	for _, indexes := range [][]int{{0}, {1}, {0, 2}, {3, 1, 4}} {
+		b := appendMessageIndexes(nil, indexes)
+		b = append(b, "foo"...)
+
+		res, remaining, err := readMessageIndexes(b)
+		require.NoError(t, err)
+		assert.Equal(t, indexes, res)
+		assert.Equal(t, "foo", string(remaining))
+	}
+
+	assert.Equal(t, []byte{0}, appendMessageIndexes(nil, []int{0}))
+}
+
+func TestSchemaRegistryProtobufRoundTrip(t *testing.T) {
+	urlStr := runProtobufSchemaRegistryServer(t)
+
+	subj, err := service.NewInterpolatedString("person")
+	require.NoError(t, err)
+
+	encoder, err := newSchemaRegistryEncoder(urlStr, nil, subj, false, time.Minute*10, time.Minute, nil)
+	require.NoError(t, err)
+
+	decoder, err := newSchemaRegistryDecoder(urlStr, nil, false, nil)
+	require.NoError(t, err)
+
+	input := `{"name":"foo","address":{"city":"bar","state":"baz"}}`
+
+	encoded, err := encoder.ProcessBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(input)),
+	})
+	require.NoError(t, err)
+	require.Len(t, encoded, 1)
+	require.Len(t, encoded[0], 1)
+	require.NoError(t, encoded[0][0].GetError())
+
+	b, err := encoded[0][0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "\x00\x00\x00\x00\x02\x00", string(b[:6]))
+
+	v, exists := encoded[0][0].MetaGet("schema_registry_id")
+	assert.True(t, exists)
+	assert.Equal(t, "2", v)
+
+	decoded, err := decoder.Process(context.Background(), encoded[0][0])
+	require.NoError(t, err)
+	require.Len(t, decoded, 1)
+	require.NoError(t, decoded[0].GetError())
+
+	b, err = decoded[0].AsBytes()
+	require.NoError(t, err)
+	assert.JSONEq(t, input, string(b))
+
+	v, _ = decoded[0].MetaGet("schema_registry_subject")
+	assert.Equal(t, "person", v)
+
+	require.NoError(t, encoder.Close(context.Background()))
+	require.NoError(t, decoder.Close(context.Background()))
+}
+
+func TestSchemaRegistryProtobufNestedMessage(t *testing.T) {
+	urlStr := runProtobufSchemaRegistryServer(t)
+
+	subj, err := service.NewInterpolatedString("person")
+	require.NoError(t, err)
+
+	encoder, err := newSchemaRegistryEncoder(urlStr, nil, subj, false, time.Minute*10, time.Minute, nil)
+	require.NoError(t, err)
+	encoder.protobufMessage = "testing.Person.Hobby"
+
+	decoder, err := newSchemaRegistryDecoder(urlStr, nil, false, nil)
+	require.NoError(t, err)
+
+	encoded, err := encoder.ProcessBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"name":"dancing"}`)),
+	})
+	require.NoError(t, err)
+	require.NoError(t, encoded[0][0].GetError())
+
+	b, err := encoded[0][0].AsBytes()
+	require.NoError(t, err)
+	// Two indexes (0, 0) zig-zag encoded after the magic byte and ID.
+	assert.Equal(t, "\x00\x00\x00\x00\x02\x04\x00\x00", string(b[:8]))
+
+	decoded, err := decoder.Process(context.Background(), encoded[0][0])
+	require.NoError(t, err)
+	require.NoError(t, decoded[0].GetError())
+
+	b, err = decoded[0].AsBytes()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name":"dancing"}`, string(b))
+
+	require.NoError(t, encoder.Close(context.Background()))
+	require.NoError(t, decoder.Close(context.Background()))
+}
@@ -0,0 +1,170 @@
+// Package cursor provides a mechanism shared by inputs that tail a source for
+// committing the position of delivered data within a cache resource, so that
+// consumption can resume from that position after a restart.
+package cursor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/checkpoint"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+// Cache stores the cursor of an input under a key of a cache resource. Cursors
+// are tracked as they're read and only committed once they, and all cursors
+// read before them, have been acknowledged.
+type Cache struct {
+	mgr   *service.Resources
+	cache string
+	key   string
+
+	checkpointer *checkpoint.Capped
+	mut          sync.Mutex
+}
+
+// CheckpointLimitField returns a config field for the maximum number of
+// messages that can be pending acknowledgement before a cursor is tracked.
+func CheckpointLimitField() *service.ConfigField {
+	return service.NewIntField("checkpoint_limit").
+		Description("The maximum number of messages that can be pending acknowledgement before applying back pressure. The cursor is only committed once all prior messages have been delivered, therefore reducing the limit reduces the number of duplicates in the event of a crash.").
+		Default(1024).
+		Advanced().
+		Version("4.0.0")
+}
+
+// NewCache returns a cursor cache that stores cursors under a key of a cache
+// resource, allowing up to checkpointLimit messages to be pending
+// acknowledgement, or an error if the cache resource does not exist.
+func NewCache(mgr *service.Resources, cache, key string, checkpointLimit int) (*Cache, error) {
+	if !mgr.HasCache(cache) {
+		return nil, fmt.Errorf("cache resource '%v' was not found", cache)
+	}
+	if checkpointLimit <= 0 {
+		return nil, errors.New("checkpoint limit must be larger than zero")
+	}
+	return &Cache{
+		mgr:          mgr,
+		cache:        cache,
+		key:          key,
+		checkpointer: checkpoint.NewCapped(int64(checkpointLimit)),
+	}, nil
+}
+
+// Load obtains the raw bytes of the last committed cursor from the cache, or
+// nil if a cursor has not yet been committed.
+func (c *Cache) Load(ctx context.Context) ([]byte, error) {
+	var cursorBytes []byte
+	var cacheErr error
+	if err := c.mgr.AccessCache(ctx, c.cache, func(cache service.Cache) {
+		cursorBytes, cacheErr = cache.Get(ctx, c.key)
+	}); err != nil {
+		return nil, fmt.Errorf("unable to access cache '%v': %w", c.cache, err)
+	}
+	if errors.Is(cacheErr, service.ErrKeyNotFound) {
+		return nil, nil
+	}
+	if cacheErr != nil {
+		return nil, fmt.Errorf("failed to obtain cursor from cache: %w", cacheErr)
+	}
+	return cursorBytes, nil
+}
+
+// LoadJSON obtains the last committed cursor from the cache parsed as a JSON
+// value, or nil if a cursor has not yet been committed. Numbers are returned as
+// an int64 when possible and a float64 otherwise.
+func (c *Cache) LoadJSON(ctx context.Context) (interface{}, error) {
+	cursorBytes, err := c.Load(ctx)
+	if err != nil || cursorBytes == nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(cursorBytes))
+	dec.UseNumber()
+
+	var cursor interface{}
+	if err := dec.Decode(&cursor); err != nil {
+		return nil, fmt.Errorf("failed to parse cursor: %w", err)
+	}
+	if n, ok := cursor.(json.Number); ok {
+		if i, err := n.Int64(); err == nil {
+			return i, nil
+		}
+		if f, err := n.Float64(); err == nil {
+			return f, nil
+		}
+	}
+	return cursor, nil
+}
+
+// store serialises a cursor as JSON and writes it to the cache.
+func (c *Cache) store(ctx context.Context, cursor interface{}) error {
+	cursorBytes, err := json.Marshal(cursor)
+	if err != nil {
+		return fmt.Errorf("failed to serialise cursor: %w", err)
+	}
+
+	var cacheErr error
+	if err := c.mgr.AccessCache(ctx, c.cache, func(cache service.Cache) {
+		cacheErr = cache.Set(ctx, c.key, cursorBytes, nil)
+	}); err != nil {
+		return fmt.Errorf("unable to access cache '%v': %w", c.cache, err)
+	}
+	return cacheErr
+}
+
+// Track a cursor of data that has been read, where the size is the number of
+// messages it represents. The returned func must be called once the data has
+// been delivered, and commits the highest cursor that has been delivered along
+// with all cursors tracked before it. Cursors are serialised as JSON, and a
+// json.RawMessage can be used for cursors that are already serialised.
+//
+// Blocks whilst the checkpoint limit is reached until either prior data is
+// delivered or the context is cancelled.
+func (c *Cache) Track(ctx context.Context, cursor interface{}, size int64) (func(ctx context.Context) error, error) {
+	release, err := c.checkpointer.Track(ctx, cursor, size)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context) error {
+		// Commits are made whilst holding the mutex so that cursors are
+		// written in order.
+		c.mut.Lock()
+		defer c.mut.Unlock()
+		if highest := release(); highest != nil {
+			return c.store(ctx, highest)
+		}
+		return nil
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// WaitForPoll blocks until the next poll of a source is due. The provided
+// mutex, which must be held by the caller, is released whilst waiting so that
+// the input can be closed in the meantime. Returns service.ErrEndOfInput if the
+// close channel is closed before the poll is due.
+func WaitForPoll(ctx context.Context, next time.Time, mut sync.Locker, closeChan <-chan struct{}) error {
+	wait := time.Until(next)
+	if wait <= 0 {
+		return nil
+	}
+
+	mut.Unlock()
+	defer mut.Lock()
+
+	select {
+	case <-time.After(wait):
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-closeChan:
+		return service.ErrEndOfInput
+	}
+	return nil
+}
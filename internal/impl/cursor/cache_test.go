@@ -0,0 +1,44 @@
+package cursor
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestCacheNotFound(t *testing.T) {
+	_, err := NewCache(service.MockResources(), "nope", "foo", 1024)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cache resource 'nope' was not found")
+}
+
+func TestWaitForPoll(t *testing.T) {
+	var mut sync.Mutex
+	closeChan := make(chan struct{})
+
+	mut.Lock()
+	require.NoError(t, WaitForPoll(context.Background(), time.Now().Add(-time.Second), &mut, closeChan))
+
+	// The mutex must be released whilst waiting.
+	go func() {
+		mut.Lock()
+		mut.Unlock()
+		close(closeChan)
+	}()
+	err := WaitForPoll(context.Background(), time.Now().Add(time.Minute), &mut, closeChan)
+	assert.Equal(t, service.ErrEndOfInput, err)
+
+	ctx, done := context.WithCancel(context.Background())
+	done()
+	err = WaitForPoll(ctx, time.Now().Add(time.Minute), &mut, make(chan struct{}))
+	assert.Equal(t, context.Canceled, err)
+
+	require.NoError(t, WaitForPoll(context.Background(), time.Now().Add(time.Millisecond*10), &mut, make(chan struct{})))
+	mut.Unlock()
+}
@@ -0,0 +1,652 @@
+package elasticsearch
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/olivere/elastic/v7"
+
+	"github.com/benthosdev/benthos/v4/internal/impl/cursor"
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	esiPaginationAuto        = "auto"
+	esiPaginationPointInTime = "point_in_time"
+	esiPaginationScroll      = "scroll"
+)
+
+func elasticsearchInputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Categories("Services").
+		Summary("Executes a query against an Elasticsearch index and creates a batch of messages for each page of hits.").
+		Description(`
+The document source of each hit becomes the contents of a message. Results are paged through using a [point in time](https://www.elastic.co/guide/en/elasticsearch/reference/current/point-in-time-api.html) along with ` + "`search_after`" + `, which gives a consistent view of the index for the duration of the query. Clusters that do not support points in time, such as older Elasticsearch versions and OpenSearch, can be paged through with the scroll API instead by setting ` + "`pagination`" + ` to ` + "`scroll`" + `, and when ` + "`pagination`" + ` is ` + "`auto`" + ` the input falls back to scrolling when a point in time cannot be opened.
+
+Once the hits of the query are exhausted this input shuts down, allowing the pipeline to gracefully terminate (or the next input in a [sequence](/docs/components/inputs/sequence) to execute).
+
+### Tailing
+
+When the field ` + "`timestamp_field`" + ` is set the input instead polls the index continuously, querying only documents where the timestamp field is greater than the highest value seen so far in ascending order of that field. Once the hits of a query are exhausted the next query is executed after the ` + "`poll_interval`" + ` has passed.
+
+The timestamp of each batch is committed to the [cache resource](/docs/components/caches/about) ` + "`cursor_cache`" + ` only once the batch, and all batches prior to it, have been acknowledged by the output, and when the input is restarted it resumes from the last committed value. This provides at-least-once delivery guarantees, and so in the event of crashes documents may be delivered more than once. Documents indexed with a timestamp lower than one already consumed are not picked up, and therefore the timestamp field should reflect the time at which a document was indexed.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "```text" + `
+- elasticsearch_id
+- elasticsearch_index
+- elasticsearch_score
+` + "```" + `
+
+You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#metadata).`).
+		Field(service.NewStringListField("urls").
+			Description("A list of URLs to connect to. If an item of the list contains commas it will be expanded into multiple URLs.").
+			Example([]string{"http://localhost:9200"})).
+		Field(service.NewStringField("index").
+			Description("The index, or comma separated list of indexes, to query.").
+			Example("foo").
+			Example("logs-*")).
+		Field(service.NewStringField("query").
+			Description("A [query](https://www.elastic.co/guide/en/elasticsearch/reference/current/query-dsl.html) in JSON format to select documents with.").
+			Example(`{"match":{"user":"foo"}}`).
+			Example(`{"range":{"age":{"gte":18}}}`).
+			Default(`{"match_all":{}}`)).
+		Field(service.NewStringListField("sort").
+			Description("An optional list of fields to sort hits by, where each field can be suffixed with `:asc` or `:desc` in order to specify the direction, which defaults to ascending.").
+			Example([]string{"created_at:desc", "user"}).
+			Optional()).
+		Field(service.NewIntField("batch_size").
+			Description("The maximum number of hits to obtain per request, each page of hits is dispatched as a batch.").
+			Default(100)).
+		Field(service.NewStringAnnotatedEnumField("pagination", map[string]string{
+			esiPaginationAuto:        "Use a point in time where supported, and otherwise fall back to the scroll API.",
+			esiPaginationPointInTime: "Use a point in time along with `search_after`.",
+			esiPaginationScroll:      "Use the scroll API.",
+		}).
+			Description("The mechanism used to page through the hits of a query.").
+			Default(esiPaginationAuto).
+			Advanced()).
+		Field(service.NewStringField("keep_alive").
+			Description("The period for which a point in time or scroll context is kept alive between requests.").
+			Default("1m").
+			Advanced()).
+		Field(service.NewStringField("timestamp_field").
+			Description("An optional timestamp field to [tail](#tailing) the index by. When set the input runs continuously, querying documents with a timestamp greater than the last document consumed.").
+			Example("@timestamp").
+			Optional()).
+		Field(service.NewStringField("cursor_cache").
+			Description("A [cache resource](/docs/components/caches/about) to persist the timestamp cursor within, which is required when `timestamp_field` is set.").
+			Optional()).
+		Field(service.NewStringField("cursor_key").
+			Description("The key to persist the timestamp cursor under within the `cursor_cache`. When empty a key is derived from the index.").
+			Default("").
+			Advanced()).
+		Field(service.NewDurationField("poll_interval").
+			Description("The period to wait after the hits of a query are exhausted before polling the index for new documents, only applicable when `timestamp_field` is set.").
+			Default("5s")).
+		Field(cursor.CheckpointLimitField()).
+		Field(service.NewBoolField("sniff").
+			Description("Prompts Benthos to sniff for brokers to connect to when establishing a connection.").
+			Default(true).
+			Advanced()).
+		Field(service.NewBoolField("healthcheck").
+			Description("Whether to enable healthchecks.").
+			Default(true).
+			Advanced()).
+		Field(service.NewTLSToggledField("tls")).
+		Field(service.NewObjectField("basic_auth",
+			service.NewBoolField("enabled").
+				Description("Whether to use basic authentication in requests.").
+				Default(false),
+			service.NewStringField("username").
+				Description("A username to authenticate as.").
+				Default(""),
+			service.NewStringField("password").
+				Description("A password to authenticate with.").
+				Default(""),
+		).
+			Description("Allows you to specify basic authentication.").
+			Advanced()).
+		Version("4.0.0").
+		Example("Reindex Between Clusters",
+			`
+Here we copy all documents of an index from one cluster into another, keeping the IDs of the documents intact:`,
+			`
+input:
+  elasticsearch:
+    urls: [ http://old-cluster:9200 ]
+    index: articles
+    sniff: false
+
+output:
+  elasticsearch:
+    urls: [ http://new-cluster:9200 ]
+    index: articles
+    id: ${! meta("elasticsearch_id") }
+    sniff: false
+`,
+		).
+		Example("Tail an Index",
+			`
+Here we continuously consume new log documents by polling an index every ten seconds for documents with a timestamp greater than the last document consumed, where the timestamp of the last document delivered is persisted within a Redis cache so that a restarted pipeline continues from where it left off:`,
+			`
+input:
+  elasticsearch:
+    urls: [ http://localhost:9200 ]
+    index: logs
+    query: '{"match":{"level":"error"}}'
+    timestamp_field: '@timestamp'
+    cursor_cache: cursors
+    poll_interval: 10s
+
+cache_resources:
+  - label: cursors
+    redis:
+      url: tcp://localhost:6379
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchInput(
+		"elasticsearch", elasticsearchInputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchInput, error) {
+			i, err := newElasticsearchInputFromConfig(conf, mgr)
+			if err != nil {
+				return nil, err
+			}
+			return service.AutoRetryNacksBatched(i), nil
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// esiPager pages through the hits of a query, returning io.EOF once the hits
+// are exhausted.
+type esiPager interface {
+	next(ctx context.Context) ([]*elastic.SearchHit, error)
+	close(ctx context.Context) error
+}
+
+type elasticsearchInput struct {
+	urls        []string
+	index       []string
+	query       elastic.Query
+	sorters     []elastic.Sorter
+	batchSize   int
+	pagination  string
+	keepAlive   string
+	sniff       bool
+	healthcheck bool
+	tlsConf     *tls.Config
+	username    string
+	password    string
+
+	client    *elastic.Client
+	pager     esiPager
+	exhausted bool
+	mut       sync.Mutex
+
+	// Fields used when tailing the index by a timestamp field.
+	timestampField string
+	cursorCache    *cursor.Cache
+	pollInterval   time.Duration
+	cursor         interface{}
+	nextPoll       time.Time
+
+	log     *service.Logger
+	shutSig *shutdown.Signaller
+}
+
+func newElasticsearchInputFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*elasticsearchInput, error) {
+	e := &elasticsearchInput{
+		log:     mgr.Logger(),
+		shutSig: shutdown.NewSignaller(),
+	}
+
+	urls, err := conf.FieldStringList("urls")
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range urls {
+		for _, splitURL := range strings.Split(u, ",") {
+			if splitURL = strings.TrimSpace(splitURL); splitURL != "" {
+				e.urls = append(e.urls, splitURL)
+			}
+		}
+	}
+	if len(e.urls) == 0 {
+		return nil, errors.New("at least one url must be specified")
+	}
+
+	indexStr, err := conf.FieldString("index")
+	if err != nil {
+		return nil, err
+	}
+	for _, index := range strings.Split(indexStr, ",") {
+		if index = strings.TrimSpace(index); index != "" {
+			e.index = append(e.index, index)
+		}
+	}
+	if len(e.index) == 0 {
+		return nil, errors.New("an index must be specified")
+	}
+
+	queryStr, err := conf.FieldString("query")
+	if err != nil {
+		return nil, err
+	}
+	if !json.Valid([]byte(queryStr)) {
+		return nil, errors.New("query must be valid JSON")
+	}
+	e.query = elastic.NewRawStringQuery(queryStr)
+
+	if e.batchSize, err = conf.FieldInt("batch_size"); err != nil {
+		return nil, err
+	}
+	if e.batchSize < 1 {
+		return nil, errors.New("batch_size must be greater than zero")
+	}
+	if e.pagination, err = conf.FieldString("pagination"); err != nil {
+		return nil, err
+	}
+	if e.keepAlive, err = conf.FieldString("keep_alive"); err != nil {
+		return nil, err
+	}
+
+	if conf.Contains("timestamp_field") {
+		if e.timestampField, err = conf.FieldString("timestamp_field"); err != nil {
+			return nil, err
+		}
+		if !conf.Contains("cursor_cache") {
+			return nil, errors.New("a cursor_cache must be specified when a timestamp_field is set")
+		}
+		cacheName, err := conf.FieldString("cursor_cache")
+		if err != nil {
+			return nil, err
+		}
+		cursorKey, err := conf.FieldString("cursor_key")
+		if err != nil {
+			return nil, err
+		}
+		if cursorKey == "" {
+			cursorKey = "elasticsearch_cursor_" + indexStr
+		}
+		checkpointLimit, err := conf.FieldInt("checkpoint_limit")
+		if err != nil {
+			return nil, err
+		}
+		if e.cursorCache, err = cursor.NewCache(mgr, cacheName, cursorKey, checkpointLimit); err != nil {
+			return nil, err
+		}
+		if e.pollInterval, err = conf.FieldDuration("poll_interval"); err != nil {
+			return nil, err
+		}
+		e.sorters = append(e.sorters, elastic.NewFieldSort(e.timestampField).Asc())
+	}
+
+	if conf.Contains("sort") {
+		sortStrs, err := conf.FieldStringList("sort")
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range sortStrs {
+			sorter, err := parseSort(s)
+			if err != nil {
+				return nil, err
+			}
+			e.sorters = append(e.sorters, sorter)
+		}
+	}
+
+	if e.sniff, err = conf.FieldBool("sniff"); err != nil {
+		return nil, err
+	}
+	if e.healthcheck, err = conf.FieldBool("healthcheck"); err != nil {
+		return nil, err
+	}
+
+	tlsConf, tlsEnabled, err := conf.FieldTLSToggled("tls")
+	if err != nil {
+		return nil, err
+	}
+	if tlsEnabled {
+		e.tlsConf = tlsConf
+	}
+
+	authEnabled, err := conf.FieldBool("basic_auth", "enabled")
+	if err != nil {
+		return nil, err
+	}
+	if authEnabled {
+		if e.username, err = conf.FieldString("basic_auth", "username"); err != nil {
+			return nil, err
+		}
+		if e.password, err = conf.FieldString("basic_auth", "password"); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+// parseSort converts a sort string of the form field[:asc|:desc] into a
+// sorter.
+func parseSort(s string) (elastic.Sorter, error) {
+	field, direction := s, "asc"
+	if i := strings.LastIndex(s, ":"); i >= 0 {
+		field, direction = s[:i], s[i+1:]
+	}
+	if field == "" {
+		return nil, fmt.Errorf("sort '%v' is missing a field name", s)
+	}
+	switch direction {
+	case "asc":
+		return elastic.NewFieldSort(field).Asc(), nil
+	case "desc":
+		return elastic.NewFieldSort(field).Desc(), nil
+	}
+	return nil, fmt.Errorf("sort '%v' has an unrecognised direction '%v', expected asc or desc", s, direction)
+}
+
+func (e *elasticsearchInput) Connect(ctx context.Context) error {
+	e.mut.Lock()
+	defer e.mut.Unlock()
+
+	if e.client != nil {
+		return nil
+	}
+
+	opts := []elastic.ClientOptionFunc{
+		elastic.SetURL(e.urls...),
+		elastic.SetSniff(e.sniff),
+		elastic.SetHealthcheck(e.healthcheck),
+	}
+	if e.username != "" || e.password != "" {
+		opts = append(opts, elastic.SetBasicAuth(e.username, e.password))
+	}
+	if e.tlsConf != nil {
+		opts = append(opts, elastic.SetHttpClient(&http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: e.tlsConf,
+			},
+		}))
+	}
+
+	client, err := elastic.NewClient(opts...)
+	if err != nil {
+		return err
+	}
+
+	if e.timestampField != "" {
+		if e.cursor, err = e.cursorCache.LoadJSON(ctx); err != nil {
+			client.Stop()
+			return err
+		}
+	}
+
+	e.client = client
+	e.log.Infof("Querying Elasticsearch index %v", strings.Join(e.index, ","))
+	return nil
+}
+
+// searchQuery returns the query to execute, which when tailing the index is
+// restricted to documents beyond the current cursor.
+func (e *elasticsearchInput) searchQuery() elastic.Query {
+	if e.timestampField == "" || e.cursor == nil {
+		return e.query
+	}
+	return elastic.NewBoolQuery().
+		Must(e.query).
+		Filter(elastic.NewRangeQuery(e.timestampField).Gt(e.cursor))
+}
+
+func (e *elasticsearchInput) newPager(ctx context.Context) (esiPager, error) {
+	query := e.searchQuery()
+	if e.pagination == esiPaginationScroll {
+		return e.newScrollPager(query), nil
+	}
+
+	res, err := e.client.OpenPointInTime(e.index...).KeepAlive(e.keepAlive).Do(ctx)
+	if err != nil {
+		if e.pagination == esiPaginationAuto && pointInTimeUnsupported(err) {
+			e.log.Infof("Falling back to the scroll API as a point in time could not be opened: %v", err)
+			e.pagination = esiPaginationScroll
+			return e.newScrollPager(query), nil
+		}
+		return nil, fmt.Errorf("failed to open point in time: %w", err)
+	}
+
+	sorters := e.sorters
+	if len(sorters) == 0 {
+		// Hits are only given sort values to search after when the search is
+		// explicitly sorted.
+		sorters = []elastic.Sorter{elastic.SortByDoc{}}
+	}
+	return &esiPointInTimePager{
+		client:    e.client,
+		query:     query,
+		sorters:   sorters,
+		size:      e.batchSize,
+		keepAlive: e.keepAlive,
+		pitID:     res.Id,
+	}, nil
+}
+
+func (e *elasticsearchInput) newScrollPager(query elastic.Query) esiPager {
+	scroll := e.client.Scroll(e.index...).
+		Query(query).
+		Size(e.batchSize).
+		KeepAlive(e.keepAlive)
+	if len(e.sorters) > 0 {
+		scroll = scroll.SortBy(e.sorters...)
+	}
+	return &esiScrollPager{scroll: scroll}
+}
+
+// pointInTimeUnsupported returns true if an error opening a point in time
+// indicates that the cluster does not support them, rather than a problem with
+// the request such as an authentication failure.
+func pointInTimeUnsupported(err error) bool {
+	var eErr *elastic.Error
+	if !errors.As(err, &eErr) {
+		return false
+	}
+	return eErr.Status >= 400 && eErr.Status < 500 &&
+		eErr.Status != http.StatusUnauthorized &&
+		eErr.Status != http.StatusForbidden
+}
+
+func (e *elasticsearchInput) closePager(ctx context.Context) {
+	if e.pager == nil {
+		return
+	}
+	if err := e.pager.close(ctx); err != nil {
+		e.log.Debugf("Failed to release search context: %v", err)
+	}
+	e.pager = nil
+}
+
+func (e *elasticsearchInput) ReadBatch(ctx context.Context) (service.MessageBatch, service.AckFunc, error) {
+	ctx, done := e.shutSig.CloseNowCtx(ctx)
+	defer done()
+
+	e.mut.Lock()
+	defer e.mut.Unlock()
+
+	for {
+		if e.client == nil {
+			return nil, nil, service.ErrNotConnected
+		}
+
+		if e.pager == nil {
+			if e.exhausted {
+				return nil, nil, service.ErrEndOfInput
+			}
+			if e.timestampField != "" {
+				if err := cursor.WaitForPoll(ctx, e.nextPoll, &e.mut, e.shutSig.CloseAtLeisureChan()); err != nil {
+					return nil, nil, err
+				}
+				if e.shutSig.ShouldCloseAtLeisure() {
+					return nil, nil, service.ErrEndOfInput
+				}
+			}
+			pager, err := e.newPager(ctx)
+			if err != nil {
+				e.nextPoll = time.Now().Add(e.pollInterval)
+				return nil, nil, err
+			}
+			e.pager = pager
+		}
+
+		// Failed requests are retried with the same pager on the next call so
+		// that hits already delivered are not repeated.
+		hits, err := e.pager.next(ctx)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, nil, err
+		}
+		if len(hits) == 0 {
+			e.closePager(ctx)
+			if e.timestampField == "" {
+				e.exhausted = true
+				return nil, nil, service.ErrEndOfInput
+			}
+			e.nextPoll = time.Now().Add(e.pollInterval)
+			continue
+		}
+
+		batch := make(service.MessageBatch, 0, len(hits))
+		for _, hit := range hits {
+			batch = append(batch, hitToMessage(hit))
+		}
+
+		if e.timestampField == "" {
+			return batch, func(ctx context.Context, err error) error {
+				// Nacks are handled by AutoRetryNacksBatched because we don't
+				// have an explicit ack mechanism right now.
+				return nil
+			}, nil
+		}
+
+		lastHit := hits[len(hits)-1]
+		if len(lastHit.Sort) == 0 {
+			e.closePager(ctx)
+			return nil, nil, fmt.Errorf("hit '%v' is missing a value for the timestamp field '%v'", lastHit.Id, e.timestampField)
+		}
+		commit, err := e.cursorCache.Track(ctx, lastHit.Sort[0], 1)
+		if err != nil {
+			// The batch was not consumed and so the index is queried again
+			// from the prior cursor.
+			e.closePager(ctx)
+			return nil, nil, err
+		}
+		e.cursor = lastHit.Sort[0]
+
+		return batch, func(ctx context.Context, err error) error {
+			// Nacks are handled by AutoRetryNacksBatched, and so the cursor is
+			// only committed once the batch has been delivered.
+			return commit(ctx)
+		}, nil
+	}
+}
+
+func hitToMessage(hit *elastic.SearchHit) *service.Message {
+	msg := service.NewMessage(hit.Source)
+	msg.MetaSet("elasticsearch_id", hit.Id)
+	msg.MetaSet("elasticsearch_index", hit.Index)
+	if hit.Score != nil {
+		msg.MetaSet("elasticsearch_score", strconv.FormatFloat(*hit.Score, 'f', -1, 64))
+	}
+	return msg
+}
+
+func (e *elasticsearchInput) Close(ctx context.Context) error {
+	e.shutSig.CloseNow()
+
+	e.mut.Lock()
+	defer e.mut.Unlock()
+
+	e.closePager(ctx)
+	if e.client != nil {
+		e.client.Stop()
+		e.client = nil
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+type esiPointInTimePager struct {
+	client      *elastic.Client
+	query       elastic.Query
+	sorters     []elastic.Sorter
+	size        int
+	keepAlive   string
+	pitID       string
+	searchAfter []interface{}
+}
+
+func (p *esiPointInTimePager) next(ctx context.Context) ([]*elastic.SearchHit, error) {
+	search := p.client.Search().
+		PointInTime(elastic.NewPointInTimeWithKeepAlive(p.pitID, p.keepAlive)).
+		Query(p.query).
+		SortBy(p.sorters...).
+		TrackScores(true).
+		Size(p.size)
+	if p.searchAfter != nil {
+		search = search.SearchAfter(p.searchAfter...)
+	}
+
+	res, err := search.Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if res.PitId != "" {
+		p.pitID = res.PitId
+	}
+	if res.Hits == nil || len(res.Hits.Hits) == 0 {
+		return nil, io.EOF
+	}
+	p.searchAfter = res.Hits.Hits[len(res.Hits.Hits)-1].Sort
+	return res.Hits.Hits, nil
+}
+
+func (p *esiPointInTimePager) close(ctx context.Context) error {
+	_, err := p.client.ClosePointInTime(p.pitID).Do(ctx)
+	return err
+}
+
+type esiScrollPager struct {
+	scroll *elastic.ScrollService
+}
+
+func (p *esiScrollPager) next(ctx context.Context) ([]*elastic.SearchHit, error) {
+	res, err := p.scroll.Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if res.Hits == nil || len(res.Hits.Hits) == 0 {
+		return nil, io.EOF
+	}
+	return res.Hits.Hits, nil
+}
+
+func (p *esiScrollPager) close(ctx context.Context) error {
+	return p.scroll.Clear(ctx)
+}
@@ -0,0 +1,443 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+
+	_ "github.com/benthosdev/benthos/v4/internal/impl/generic"
+)
+
+type stubHit struct {
+	id   string
+	ts   int64
+	body string
+}
+
+// stubHitsJSON renders a page of hits starting from the hit after the given
+// sort value.
+func stubHitsJSON(hits []stubHit, after int64, size int) string {
+	var page []interface{}
+	for _, h := range hits {
+		if h.ts <= after {
+			continue
+		}
+		if len(page) == size {
+			break
+		}
+		page = append(page, map[string]interface{}{
+			"_index":  "foo",
+			"_id":     h.id,
+			"_score":  1.5,
+			"_source": json.RawMessage(h.body),
+			"sort":    []interface{}{h.ts},
+		})
+	}
+	b, _ := json.Marshal(map[string]interface{}{
+		"hits": map[string]interface{}{
+			"hits": page,
+		},
+	})
+	return string(b)
+}
+
+func testInput(t *testing.T, conf string) *elasticsearchInput {
+	t.Helper()
+
+	pConf, err := elasticsearchInputConfig().ParseYAML(conf, nil)
+	require.NoError(t, err)
+
+	i, err := newElasticsearchInputFromConfig(pConf, service.MockResources())
+	require.NoError(t, err)
+	return i
+}
+
+func readAllBatches(t *testing.T, i *elasticsearchInput) (batches []service.MessageBatch) {
+	t.Helper()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	require.NoError(t, i.Connect(ctx))
+	for {
+		batch, ackFn, err := i.ReadBatch(ctx)
+		if err == service.ErrEndOfInput {
+			return
+		}
+		require.NoError(t, err)
+		require.NoError(t, ackFn(ctx, nil))
+		batches = append(batches, batch)
+	}
+}
+
+func TestElasticsearchInputConfigErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		conf   string
+		errStr string
+	}{
+		{
+			name: "missing cursor cache",
+			conf: `
+urls: [ http://localhost:9200 ]
+index: foo
+timestamp_field: ts
+`,
+			errStr: "cursor_cache must be specified",
+		},
+		{
+			name: "unknown cursor cache",
+			conf: `
+urls: [ http://localhost:9200 ]
+index: foo
+timestamp_field: ts
+cursor_cache: nope
+`,
+			errStr: "cache resource 'nope' was not found",
+		},
+		{
+			name: "bad sort direction",
+			conf: `
+urls: [ http://localhost:9200 ]
+index: foo
+sort: [ "ts:sideways" ]
+`,
+			errStr: "unrecognised direction 'sideways'",
+		},
+		{
+			name: "bad query",
+			conf: `
+urls: [ http://localhost:9200 ]
+index: foo
+query: '{"match_all":'
+`,
+			errStr: "query must be valid JSON",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			pConf, err := elasticsearchInputConfig().ParseYAML(test.conf, nil)
+			require.NoError(t, err)
+
+			_, err = newElasticsearchInputFromConfig(pConf, service.MockResources())
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.errStr)
+		})
+	}
+}
+
+func TestElasticsearchInputPointInTime(t *testing.T) {
+	hits := []stubHit{
+		{id: "a", ts: 1, body: `{"n":1}`},
+		{id: "b", ts: 2, body: `{"n":2}`},
+		{id: "c", ts: 3, body: `{"n":3}`},
+	}
+
+	var mut sync.Mutex
+	var pitClosed bool
+	var searchBodies []map[string]interface{}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mut.Lock()
+		defer mut.Unlock()
+
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/foo/_pit":
+			assert.Equal(t, "2m", r.URL.Query().Get("keep_alive"))
+			fmt.Fprint(w, `{"id":"pit-1"}`)
+		case r.Method == "DELETE" && r.URL.Path == "/_pit":
+			pitClosed = true
+			fmt.Fprint(w, `{"succeeded":true,"num_freed":1}`)
+		case r.Method == "POST" && r.URL.Path == "/_search":
+			var body map[string]interface{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			searchBodies = append(searchBodies, body)
+
+			after := int64(0)
+			if sa, ok := body["search_after"].([]interface{}); ok {
+				after = int64(sa[0].(float64))
+			}
+			fmt.Fprint(w, stubHitsJSON(hits, after, 2))
+		default:
+			t.Errorf("unexpected request: %v %v", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	i := testInput(t, fmt.Sprintf(`
+urls: [ %v ]
+index: foo
+query: '{"term":{"user":"bar"}}'
+sort: [ "ts" ]
+batch_size: 2
+keep_alive: 2m
+pagination: point_in_time
+sniff: false
+healthcheck: false
+`, ts.URL))
+
+	batches := readAllBatches(t, i)
+	require.Len(t, batches, 2)
+	require.Len(t, batches[0], 2)
+	require.Len(t, batches[1], 1)
+
+	var ids, bodies []string
+	for _, b := range batches {
+		for _, m := range b {
+			id, _ := m.MetaGet("elasticsearch_id")
+			ids = append(ids, id)
+
+			index, _ := m.MetaGet("elasticsearch_index")
+			assert.Equal(t, "foo", index)
+
+			score, _ := m.MetaGet("elasticsearch_score")
+			assert.Equal(t, "1.5", score)
+
+			mBytes, err := m.AsBytes()
+			require.NoError(t, err)
+			bodies = append(bodies, string(mBytes))
+		}
+	}
+	assert.Equal(t, []string{"a", "b", "c"}, ids)
+	assert.Equal(t, []string{`{"n":1}`, `{"n":2}`, `{"n":3}`}, bodies)
+
+	require.NoError(t, i.Close(context.Background()))
+
+	mut.Lock()
+	defer mut.Unlock()
+
+	assert.True(t, pitClosed)
+	require.Len(t, searchBodies, 3)
+	for _, body := range searchBodies {
+		assert.Equal(t, map[string]interface{}{"id": "pit-1", "keep_alive": "2m"}, body["pit"])
+		assert.Equal(t, map[string]interface{}{"term": map[string]interface{}{"user": "bar"}}, body["query"])
+	}
+	assert.Nil(t, searchBodies[0]["search_after"])
+	assert.Equal(t, []interface{}{float64(2)}, searchBodies[1]["search_after"])
+	assert.Equal(t, []interface{}{float64(3)}, searchBodies[2]["search_after"])
+}
+
+func TestElasticsearchInputScrollFallback(t *testing.T) {
+	pages := []string{
+		stubHitsJSON([]stubHit{{id: "a", ts: 1, body: `{"n":1}`}, {id: "b", ts: 2, body: `{"n":2}`}}, 0, 2),
+		stubHitsJSON([]stubHit{{id: "c", ts: 3, body: `{"n":3}`}}, 0, 2),
+		stubHitsJSON(nil, 0, 2),
+	}
+
+	var mut sync.Mutex
+	var scrollCleared bool
+	page := 0
+
+	writePage := func(w http.ResponseWriter) {
+		var res map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(pages[page]), &res))
+		res["_scroll_id"] = "scroll-1"
+		page++
+		require.NoError(t, json.NewEncoder(w).Encode(res))
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mut.Lock()
+		defer mut.Unlock()
+
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/foo/_pit":
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":{"type":"illegal_argument_exception","reason":"no handler found"},"status":400}`)
+		case r.Method == "POST" && r.URL.Path == "/foo/_search":
+			assert.Equal(t, "1m", r.URL.Query().Get("scroll"))
+			writePage(w)
+		case r.Method == "POST" && r.URL.Path == "/_search/scroll":
+			writePage(w)
+		case r.Method == "DELETE" && r.URL.Path == "/_search/scroll":
+			scrollCleared = true
+			fmt.Fprint(w, `{"succeeded":true,"num_freed":1}`)
+		default:
+			t.Errorf("unexpected request: %v %v", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	i := testInput(t, fmt.Sprintf(`
+urls: [ %v ]
+index: foo
+batch_size: 2
+sniff: false
+healthcheck: false
+`, ts.URL))
+
+	batches := readAllBatches(t, i)
+	require.Len(t, batches, 2)
+
+	var ids []string
+	for _, b := range batches {
+		for _, m := range b {
+			id, _ := m.MetaGet("elasticsearch_id")
+			ids = append(ids, id)
+		}
+	}
+	assert.Equal(t, []string{"a", "b", "c"}, ids)
+	assert.Equal(t, esiPaginationScroll, i.pagination)
+
+	require.NoError(t, i.Close(context.Background()))
+
+	mut.Lock()
+	assert.True(t, scrollCleared)
+	mut.Unlock()
+}
+
+func TestElasticsearchInputPointInTimeNoFallback(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"error":{"type":"security_exception","reason":"missing authentication credentials"},"status":401}`)
+	}))
+	defer ts.Close()
+
+	i := testInput(t, fmt.Sprintf(`
+urls: [ %v ]
+index: foo
+sniff: false
+healthcheck: false
+`, ts.URL))
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	require.NoError(t, i.Connect(ctx))
+	_, _, err := i.ReadBatch(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to open point in time")
+	assert.Equal(t, esiPaginationAuto, i.pagination)
+
+	require.NoError(t, i.Close(ctx))
+}
+
+func TestElasticsearchInputTail(t *testing.T) {
+	var mut sync.Mutex
+	hits := []stubHit{
+		{id: "a", ts: 1000, body: `{"n":1}`},
+		{id: "b", ts: 2000, body: `{"n":2}`},
+	}
+	var rangeFilters []interface{}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mut.Lock()
+		defer mut.Unlock()
+
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/foo/_pit":
+			fmt.Fprint(w, `{"id":"pit-1"}`)
+		case r.Method == "DELETE" && r.URL.Path == "/_pit":
+			fmt.Fprint(w, `{"succeeded":true}`)
+		case r.Method == "POST" && r.URL.Path == "/_search":
+			var body struct {
+				Query struct {
+					Bool struct {
+						Filter struct {
+							Range map[string]struct {
+								From int64 `json:"from"`
+							} `json:"range"`
+						} `json:"filter"`
+					} `json:"bool"`
+				} `json:"query"`
+				SearchAfter []int64 `json:"search_after"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+
+			after := int64(0)
+			if ts, exists := body.Query.Bool.Filter.Range["ts"]; exists {
+				after = ts.From
+				if len(body.SearchAfter) == 0 {
+					rangeFilters = append(rangeFilters, ts.From)
+				}
+			}
+			if len(body.SearchAfter) > 0 {
+				after = body.SearchAfter[0]
+			}
+			fmt.Fprint(w, stubHitsJSON(hits, after, 10))
+		default:
+			t.Errorf("unexpected request: %v %v", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	builder := service.NewStreamBuilder()
+	require.NoError(t, builder.AddCacheYAML(`
+label: cursors
+memory: {}
+`))
+	require.NoError(t, builder.AddInputYAML(fmt.Sprintf(`
+elasticsearch:
+  urls: [ %v ]
+  index: foo
+  timestamp_field: ts
+  cursor_cache: cursors
+  poll_interval: 10ms
+  sniff: false
+  healthcheck: false
+`, ts.URL)))
+
+	var resMut sync.Mutex
+	var ids []string
+	require.NoError(t, builder.AddConsumerFunc(func(ctx context.Context, m *service.Message) error {
+		id, _ := m.MetaGet("elasticsearch_id")
+		resMut.Lock()
+		ids = append(ids, id)
+		resMut.Unlock()
+		return nil
+	}))
+
+	stream, err := builder.Build()
+	require.NoError(t, err)
+
+	go func() {
+		_ = stream.Run(context.Background())
+	}()
+	defer func() {
+		require.NoError(t, stream.StopWithin(time.Second*5))
+	}()
+
+	assert.Eventually(t, func() bool {
+		resMut.Lock()
+		defer resMut.Unlock()
+		return len(ids) == 2
+	}, time.Second*5, time.Millisecond*10)
+
+	mut.Lock()
+	hits = append(hits, stubHit{id: "c", ts: 3000, body: `{"n":3}`})
+	mut.Unlock()
+
+	assert.Eventually(t, func() bool {
+		resMut.Lock()
+		defer resMut.Unlock()
+		return len(ids) == 3
+	}, time.Second*5, time.Millisecond*10)
+
+	resMut.Lock()
+	assert.Equal(t, []string{"a", "b", "c"}, ids)
+	resMut.Unlock()
+
+	mut.Lock()
+	require.NotEmpty(t, rangeFilters)
+	assert.Equal(t, int64(2000), rangeFilters[0])
+	mut.Unlock()
+}
@@ -0,0 +1,160 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func cacheRatelimitConfig() *service.ConfigSpec {
+	spec := service.NewConfigSpec().
+		Beta().
+		Summary(`A rate limit that tracks its quota within a [cache resource](/docs/components/caches/about), allowing the limit to be shared across multiple running instances of Benthos when the cache is distributed.`).
+		Description(`
+Requests are counted within fixed windows of time by adding a unique key to the cache for each request allowed within the current window, and therefore the target cache must support the ` + "`add`" + ` operation atomically across all instances sharing the limit. Each key is set with a TTL of twice the interval, and so caches that support TTLs will clean up keys of expired windows automatically.
+
+Since windows are calculated from the wall clock of each instance, it is important that the clocks of all instances sharing the limit are synchronised.`).
+		Field(service.NewStringField("resource").
+			Description("The [`cache` resource](/docs/components/caches/about) to track requests with.")).
+		Field(service.NewStringField("key").
+			Description("A prefix to apply to the keys of the rate limit, instances that share the same key within the same cache share the same quota.").
+			Default("benthos_rate_limit")).
+		Field(service.NewIntField("count").
+			Description("The maximum number of requests to allow for a given period of time.").
+			Default(1000)).
+		Field(service.NewDurationField("interval").
+			Description("The time window to limit requests by.").
+			Default("1s")).
+		Example(
+			"Shared Quota",
+			"Here we limit requests to a third party API to 100 per second across all instances of Benthos by tracking requests within a shared memcached server.",
+			`
+rate_limit_resources:
+  - label: api_quota
+    cache:
+      resource: shared
+      key: my_api_quota
+      count: 100
+      interval: 1s
+
+cache_resources:
+  - label: shared
+    memcached:
+      addresses: [ TODO:11211 ]
+`)
+
+	return spec
+}
+
+func init() {
+	err := service.RegisterRateLimit(
+		"cache", cacheRatelimitConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.RateLimit, error) {
+			return newCacheRatelimitFromConfig(conf, mgr)
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+func newCacheRatelimitFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*cacheRatelimit, error) {
+	resource, err := conf.FieldString("resource")
+	if err != nil {
+		return nil, err
+	}
+	if !mgr.HasCache(resource) {
+		return nil, fmt.Errorf("cache resource '%v' was not found", resource)
+	}
+	key, err := conf.FieldString("key")
+	if err != nil {
+		return nil, err
+	}
+	count, err := conf.FieldInt("count")
+	if err != nil {
+		return nil, err
+	}
+	interval, err := conf.FieldDuration("interval")
+	if err != nil {
+		return nil, err
+	}
+	return newCacheRatelimit(mgr, resource, key, count, interval)
+}
+
+//------------------------------------------------------------------------------
+
+type cacheRatelimit struct {
+	mgr      cacheProvider
+	resource string
+	key      string
+	size     int
+	period   time.Duration
+
+	// The window and slot fields are a local hint of the next slot that might
+	// be free, which prevents us from retrying keys that we already know to be
+	// taken within the current window.
+	mut    sync.Mutex
+	window int64
+	slot   int
+
+	nowFn func() time.Time
+}
+
+func newCacheRatelimit(mgr cacheProvider, resource, key string, count int, interval time.Duration) (*cacheRatelimit, error) {
+	if count <= 0 {
+		return nil, errors.New("count must be larger than zero")
+	}
+	if interval <= 0 {
+		return nil, errors.New("interval must be larger than zero")
+	}
+	return &cacheRatelimit{
+		mgr:      mgr,
+		resource: resource,
+		key:      key,
+		size:     count,
+		period:   interval,
+		nowFn:    time.Now,
+	}, nil
+}
+
+func (r *cacheRatelimit) Access(ctx context.Context) (time.Duration, error) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	now := r.nowFn()
+	window := now.UnixNano() / int64(r.period)
+	if window != r.window {
+		r.window = window
+		r.slot = 0
+	}
+
+	ttl := r.period * 2
+	for r.slot < r.size {
+		key := fmt.Sprintf("%v_%v_%v", r.key, window, r.slot)
+		r.slot++
+
+		var addErr error
+		if err := r.mgr.AccessCache(ctx, r.resource, func(c service.Cache) {
+			addErr = c.Add(ctx, key, []byte{'1'}, &ttl)
+		}); err != nil {
+			return 0, fmt.Errorf("unable to access cache '%v': %w", r.resource, err)
+		}
+		if addErr == nil {
+			return 0, nil
+		}
+		if !errors.Is(addErr, service.ErrKeyAlreadyExists) {
+			// Give the slot another chance on the next attempt.
+			r.slot--
+			return 0, addErr
+		}
+	}
+	return time.Duration((window+1)*int64(r.period) - now.UnixNano()), nil
+}
+
+func (r *cacheRatelimit) Close(ctx context.Context) error {
+	return nil
+}
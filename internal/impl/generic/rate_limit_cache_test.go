@@ -0,0 +1,70 @@
+package generic
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestCacheRateLimitConfErrors(t *testing.T) {
+	conf, err := cacheRatelimitConfig().ParseYAML(`resource: foo`, nil)
+	require.NoError(t, err)
+
+	_, err = newCacheRatelimitFromConfig(conf, service.MockResources())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cache resource 'foo' was not found")
+
+	_, err = newCacheRatelimit(&mockCacheProv{}, "foo", "bar", 0, time.Second)
+	require.Error(t, err)
+}
+
+func TestCacheRateLimitBasic(t *testing.T) {
+	mgr := &mockCacheProv{
+		caches: map[string]service.Cache{
+			"foo": newMemCache(0, 0, 1, nil),
+		},
+	}
+
+	now := time.Unix(100, 0)
+	newRL := func() *cacheRatelimit {
+		rl, err := newCacheRatelimit(mgr, "foo", "bar", 10, time.Second)
+		require.NoError(t, err)
+		rl.nowFn = func() time.Time { return now }
+		return rl
+	}
+
+	// Two instances share the same quota
+	rlOne, rlTwo := newRL(), newRL()
+
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		period, err := rlOne.Access(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, time.Duration(0), period)
+
+		period, err = rlTwo.Access(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, time.Duration(0), period)
+	}
+
+	now = now.Add(time.Millisecond * 300)
+
+	period, err := rlOne.Access(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, time.Millisecond*700, period)
+
+	period, err = rlTwo.Access(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, time.Millisecond*700, period)
+
+	now = now.Add(time.Millisecond * 700)
+
+	period, err = rlTwo.Access(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, time.Duration(0), period)
+}
@@ -0,0 +1,198 @@
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/jhump/protoreflect/desc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/benthosdev/benthos/v4/internal/impl/protobuf"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const clientDescription = `
+The method is described by either ` + "`.proto`" + ` files found within the ` + "`import_paths`" + `, or by precompiled descriptor sets listed in ` + "`descriptor_sets`" + `, which can be generated with ` + "`protoc --include_imports --descriptor_set_out`" + `. Messages are expected to be JSON documents, which are converted into the input type of the method before being sent.`
+
+func clientFields() []*service.ConfigField {
+	return []*service.ConfigField{
+		service.NewStringField("address").
+			Description("The address of the gRPC server to connect to.").
+			Example("localhost:50051"),
+		service.NewStringField("method").
+			Description("The full name of the method to call.").
+			Example("foo.BarService/Baz"),
+		service.NewStringListField("import_paths").
+			Description("A list of directories containing .proto files, including all definitions required for parsing the method. If both this field and `descriptor_sets` are empty the current directory is used.").
+			Default([]string{}),
+		service.NewStringListField("descriptor_sets").
+			Description("A list of precompiled FileDescriptorSet files containing all definitions required for the method.").
+			Default([]string{}),
+		service.NewStringMapField("metadata").
+			Description("A map of metadata to send with each call.").
+			Example(map[string]string{"authorization": "Bearer foo"}).
+			Default(map[string]string{}).
+			Advanced(),
+		service.NewDurationField("timeout").
+			Description("The maximum period to wait for a call to complete.").
+			Default("5s"),
+		service.NewTLSToggledField("tls"),
+	}
+}
+
+type grpcClient struct {
+	address  string
+	method   *desc.MethodDescriptor
+	registry *protobuf.Registry
+	metadata metadata.MD
+	timeout  time.Duration
+	tlsConf  *tls.Config
+}
+
+func newGRPCClientFromConfig(conf *service.ParsedConfig) (*grpcClient, error) {
+	c := &grpcClient{}
+
+	var err error
+	if c.address, err = conf.FieldString("address"); err != nil {
+		return nil, err
+	}
+
+	methodName, err := conf.FieldString("method")
+	if err != nil {
+		return nil, err
+	}
+
+	importPaths, err := conf.FieldStringList("import_paths")
+	if err != nil {
+		return nil, err
+	}
+	descriptorSets, err := conf.FieldStringList("descriptor_sets")
+	if err != nil {
+		return nil, err
+	}
+	if c.registry, err = protobuf.LoadRegistry(importPaths, descriptorSets); err != nil {
+		return nil, err
+	}
+	if c.method, err = c.registry.FindMethod(methodName); err != nil {
+		return nil, err
+	}
+
+	md, err := conf.FieldStringMap("metadata")
+	if err != nil {
+		return nil, err
+	}
+	c.metadata = metadata.New(md)
+
+	if c.timeout, err = conf.FieldDuration("timeout"); err != nil {
+		return nil, err
+	}
+
+	tlsConf, tlsEnabled, err := conf.FieldTLSToggled("tls")
+	if err != nil {
+		return nil, err
+	}
+	if tlsEnabled {
+		c.tlsConf = tlsConf
+	}
+	return c, nil
+}
+
+func (c *grpcClient) fullMethod() string {
+	return "/" + c.method.GetService().GetFullyQualifiedName() + "/" + c.method.GetName()
+}
+
+func (c *grpcClient) dial() (*grpc.ClientConn, error) {
+	creds := insecure.NewCredentials()
+	if c.tlsConf != nil {
+		creds = credentials.NewTLS(c.tlsConf)
+	}
+	return grpc.Dial(c.address,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(protobuf.RawCodec{})),
+	)
+}
+
+func (c *grpcClient) callContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if len(c.metadata) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, c.metadata)
+	}
+	if c.timeout > 0 {
+		return context.WithTimeout(ctx, c.timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+func (c *grpcClient) request(msg *service.Message) ([]byte, error) {
+	mBytes, err := msg.AsBytes()
+	if err != nil {
+		return nil, err
+	}
+	return c.registry.FromJSON(c.method.GetInputType(), mBytes)
+}
+
+// call invokes the method with a list of request messages, which must contain
+// exactly one message unless the method is client streaming, and returns the
+// responses converted into JSON documents.
+func (c *grpcClient) call(ctx context.Context, conn *grpc.ClientConn, batch service.MessageBatch) ([][]byte, error) {
+	if len(batch) != 1 && !c.method.IsClientStreaming() {
+		return nil, errors.New("method is not client streaming and must be called with exactly one message")
+	}
+
+	ctx, done := c.callContext(ctx)
+	defer done()
+
+	stream, err := conn.NewStream(ctx, &grpc.StreamDesc{
+		StreamName:    c.method.GetName(),
+		ServerStreams: c.method.IsServerStreaming(),
+		ClientStreams: c.method.IsClientStreaming(),
+	}, c.fullMethod())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, msg := range batch {
+		req, err := c.request(msg)
+		if err != nil {
+			return nil, err
+		}
+		if err := stream.SendMsg(req); err != nil {
+			if err == io.EOF {
+				// The actual status is obtained by receiving.
+				break
+			}
+			return nil, err
+		}
+	}
+	if err := stream.CloseSend(); err != nil {
+		return nil, err
+	}
+
+	var responses [][]byte
+	for {
+		var data []byte
+		if err := stream.RecvMsg(&data); err != nil {
+			if err == io.EOF && c.method.IsServerStreaming() {
+				break
+			}
+			return nil, err
+		}
+
+		jBytes, err := c.registry.ToJSON(c.method.GetOutputType(), data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse response: %w", err)
+		}
+		responses = append(responses, jBytes)
+
+		if !c.method.IsServerStreaming() {
+			break
+		}
+	}
+	return responses, nil
+}
@@ -0,0 +1,267 @@
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/benthosdev/benthos/v4/internal/impl/protobuf"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const testGreeterProto = `
+syntax = "proto3";
+package testing;
+
+message Greeting {
+  string name = 1;
+}
+
+service Greeter {
+  rpc Greet(Greeting) returns (Greeting);
+  rpc GreetMany(stream Greeting) returns (Greeting);
+  rpc GreetStream(Greeting) returns (stream Greeting);
+}
+`
+
+type testGreeter struct {
+	registry *protobuf.Registry
+
+	mut      sync.Mutex
+	received []string
+	metadata []string
+}
+
+func (g *testGreeter) recv(stream grpc.ServerStream) (string, error) {
+	var data []byte
+	if err := stream.RecvMsg(&data); err != nil {
+		return "", err
+	}
+	md, _ := g.registry.FindMessage("testing.Greeting")
+	j, err := g.registry.ToJSON(md, data)
+	if err != nil {
+		return "", err
+	}
+
+	g.mut.Lock()
+	g.received = append(g.received, string(j))
+	if reqMeta, _ := metadata.FromIncomingContext(stream.Context()); len(reqMeta.Get("foo")) > 0 {
+		g.metadata = append(g.metadata, reqMeta.Get("foo")[0])
+	}
+	g.mut.Unlock()
+	return string(j), nil
+}
+
+func (g *testGreeter) send(stream grpc.ServerStream, jStr string) error {
+	md, _ := g.registry.FindMessage("testing.Greeting")
+	data, err := g.registry.FromJSON(md, []byte(jStr))
+	if err != nil {
+		return err
+	}
+	return stream.SendMsg(data)
+}
+
+func runTestGreeter(t *testing.T) (dir, addr string, greeter *testGreeter) {
+	t.Helper()
+
+	dir = t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "greeter.proto"), []byte(testGreeterProto), 0o644))
+
+	registry, err := protobuf.LoadRegistry([]string{dir}, nil)
+	require.NoError(t, err)
+	greeter = &testGreeter{registry: registry}
+
+	server := grpc.NewServer(grpc.ForceServerCodec(protobuf.RawCodec{}))
+	server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "testing.Greeter",
+		HandlerType: (*interface{})(nil),
+		Streams: []grpc.StreamDesc{
+			{
+				StreamName: "Greet",
+				Handler: func(_ interface{}, stream grpc.ServerStream) error {
+					j, err := greeter.recv(stream)
+					if err != nil {
+						return err
+					}
+					var req struct {
+						Name string `json:"name"`
+					}
+					if err := json.Unmarshal([]byte(j), &req); err != nil {
+						return err
+					}
+					return greeter.send(stream, fmt.Sprintf(`{"name":"hello %v"}`, req.Name))
+				},
+			},
+			{
+				StreamName:    "GreetMany",
+				ClientStreams: true,
+				Handler: func(_ interface{}, stream grpc.ServerStream) error {
+					count := 0
+					for {
+						if _, err := greeter.recv(stream); err != nil {
+							if err == io.EOF {
+								break
+							}
+							return err
+						}
+						count++
+					}
+					return greeter.send(stream, fmt.Sprintf(`{"name":"%v"}`, count))
+				},
+			},
+			{
+				StreamName:    "GreetStream",
+				ServerStreams: true,
+				Handler: func(_ interface{}, stream grpc.ServerStream) error {
+					if _, err := greeter.recv(stream); err != nil {
+						return err
+					}
+					for _, n := range []string{"a", "b", "c"} {
+						if err := greeter.send(stream, fmt.Sprintf(`{"name":"%v"}`, n)); err != nil {
+							return err
+						}
+					}
+					return nil
+				},
+			},
+		},
+	}, greeter)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+
+	return dir, listener.Addr().String(), greeter
+}
+
+func TestGRPCClientProcessorUnary(t *testing.T) {
+	dir, addr, greeter := runTestGreeter(t)
+
+	conf, err := grpcClientProcessorConfig().ParseYAML(fmt.Sprintf(`
+address: %v
+method: testing.Greeter/Greet
+import_paths: [ %v ]
+metadata:
+  foo: bar
+`, addr, dir), nil)
+	require.NoError(t, err)
+
+	proc, err := newGRPCClientProcessorFromConfig(conf)
+	require.NoError(t, err)
+
+	inMsg := service.NewMessage([]byte(`{"name":"foo"}`))
+	inMsg.MetaSet("baz", "buz")
+
+	batch, err := proc.Process(context.Background(), inMsg)
+	require.NoError(t, err)
+	require.Len(t, batch, 1)
+
+	b, err := batch[0].AsBytes()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name":"hello foo"}`, string(b))
+
+	v, _ := batch[0].MetaGet("baz")
+	assert.Equal(t, "buz", v)
+	assert.Equal(t, []string{"bar"}, greeter.metadata)
+
+	_, err = proc.Process(context.Background(), service.NewMessage([]byte(`{"nope":"foo"}`)))
+	require.Error(t, err)
+
+	require.NoError(t, proc.Close(context.Background()))
+}
+
+func TestGRPCClientProcessorServerStreaming(t *testing.T) {
+	dir, addr, _ := runTestGreeter(t)
+
+	conf, err := grpcClientProcessorConfig().ParseYAML(fmt.Sprintf(`
+address: %v
+method: testing.Greeter.GreetStream
+import_paths: [ %v ]
+`, addr, dir), nil)
+	require.NoError(t, err)
+
+	proc, err := newGRPCClientProcessorFromConfig(conf)
+	require.NoError(t, err)
+
+	batch, err := proc.Process(context.Background(), service.NewMessage([]byte(`{"name":"foo"}`)))
+	require.NoError(t, err)
+
+	var names []string
+	for _, m := range batch {
+		b, err := m.AsBytes()
+		require.NoError(t, err)
+		names = append(names, string(b))
+	}
+	assert.Equal(t, []string{`{"name":"a"}`, `{"name":"b"}`, `{"name":"c"}`}, names)
+
+	require.NoError(t, proc.Close(context.Background()))
+}
+
+func TestGRPCClientProcessorClientStreamingRejected(t *testing.T) {
+	dir, addr, _ := runTestGreeter(t)
+
+	conf, err := grpcClientProcessorConfig().ParseYAML(fmt.Sprintf(`
+address: %v
+method: testing.Greeter/GreetMany
+import_paths: [ %v ]
+`, addr, dir), nil)
+	require.NoError(t, err)
+
+	_, err = newGRPCClientProcessorFromConfig(conf)
+	require.Error(t, err)
+}
+
+func TestGRPCClientOutput(t *testing.T) {
+	dir, addr, greeter := runTestGreeter(t)
+
+	for _, method := range []string{"testing.Greeter/Greet", "testing.Greeter/GreetMany"} {
+		conf, err := grpcClientOutputConfig().ParseYAML(fmt.Sprintf(`
+address: %v
+method: %v
+import_paths: [ %v ]
+`, addr, method, dir), nil)
+		require.NoError(t, err)
+
+		out, err := newGRPCClientOutputFromConfig(conf, service.MockResources().Logger())
+		require.NoError(t, err)
+
+		err = out.WriteBatch(context.Background(), service.MessageBatch{service.NewMessage([]byte(`{"name":"foo"}`))})
+		assert.Equal(t, service.ErrNotConnected, err)
+
+		require.NoError(t, out.Connect(context.Background()))
+		require.NoError(t, out.WriteBatch(context.Background(), service.MessageBatch{
+			service.NewMessage([]byte(`{"name":"foo"}`)),
+			service.NewMessage([]byte(`{"name":"bar"}`)),
+		}))
+		require.NoError(t, out.Close(context.Background()))
+	}
+
+	assert.Equal(t, []string{
+		`{"name":"foo"}`, `{"name":"bar"}`,
+		`{"name":"foo"}`, `{"name":"bar"}`,
+	}, greeter.received)
+
+	conf, err := grpcClientOutputConfig().ParseYAML(fmt.Sprintf(`
+address: %v
+method: testing.Greeter/GreetStream
+import_paths: [ %v ]
+`, addr, dir), nil)
+	require.NoError(t, err)
+
+	_, err = newGRPCClientOutputFromConfig(conf, nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,437 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jhump/protoreflect/desc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/benthosdev/benthos/v4/internal/impl/protobuf"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
+	"github.com/benthosdev/benthos/v4/internal/transaction"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func grpcServerInputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Network").
+		Version("4.0.0").
+		Summary("Serve the methods of gRPC services defined by protobuf descriptors, where requests are consumed as messages.").
+		Description(`
+Services are described by either ` + "`.proto`" + ` files found within the ` + "`import_paths`" + `, or by precompiled descriptor sets listed in ` + "`descriptor_sets`" + `, which can be generated with ` + "`protoc --include_imports --descriptor_set_out`" + `. By default every service found is served, this can be restricted with the field ` + "`services`" + `.
+
+Requests are converted from protobuf into JSON documents. A unary request is consumed as a single message, and a client streaming request is consumed once the stream is closed by the client as a batch where each message of the stream is a message of the batch. Methods with streamed responses are not supported and calling them returns the status ` + "`UNIMPLEMENTED`" + `.
+
+TLS is enabled when key and cert files are specified.
+
+### Responses
+
+It's possible to return a response for each request received using [synchronous responses](/docs/guides/sync_responses). The first message of the response is converted from JSON into the output type of the method, and when there's no response message an empty message of the output type is returned.
+
+If a request fails to be delivered the call returns an error status, and if it isn't delivered within the ` + "`timeout`" + ` the call returns the status ` + "`DEADLINE_EXCEEDED`" + `.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "``` text" + `
+- grpc_server_method
+- Request metadata that matches the ` + "`metadata`" + ` filter (only first values are taken)
+` + "```" + `
+
+Where ` + "`grpc_server_method`" + ` is the full name of the method called, e.g. ` + "`/foo.BarService/Baz`" + `. Pseudo-headers such as ` + "`:authority`" + ` are never added.
+
+You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#metadata).`).
+		Field(service.NewStringField("address").
+			Description("The address to listen from.").
+			Default("0.0.0.0:50051")).
+		Field(service.NewStringListField("import_paths").
+			Description("A list of directories containing .proto files, including all definitions required for parsing the services. If both this field and `descriptor_sets` are empty the current directory is used.").
+			Default([]string{})).
+		Field(service.NewStringListField("descriptor_sets").
+			Description("A list of precompiled FileDescriptorSet files containing all definitions required for the services.").
+			Default([]string{})).
+		Field(service.NewStringListField("services").
+			Description("An optional list of fully qualified names of services to serve. If empty all services found are served.").
+			Example([]string{"foo.BarService"}).
+			Default([]string{})).
+		Field(service.NewDurationField("timeout").
+			Description("Timeout for requests. If a consumed request takes longer than this to be delivered the call returns an error, but the message may still be delivered.").
+			Default("5s")).
+		Field(service.NewMetadataFilterField("metadata").
+			Description("Specify criteria for which request metadata values are added to messages as metadata fields.").
+			Advanced()).
+		Field(service.NewStringField("cert_file").
+			Description("Enable TLS by specifying a certificate and key file.").
+			Default("").
+			Advanced()).
+		Field(service.NewStringField("key_file").
+			Description("Enable TLS by specifying a certificate and key file.").
+			Default("").
+			Advanced()).
+		Field(service.NewObjectField("sync_response",
+			service.NewMetadataFilterField("metadata_headers").
+				Description("Specify criteria for which metadata values are added to the response as gRPC headers."),
+		).
+			Description("Customise messages returned via [synchronous responses](/docs/guides/sync_responses).").
+			Advanced())
+}
+
+func init() {
+	err := service.RegisterBatchInput(
+		"grpc_server", grpcServerInputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchInput, error) {
+			return newGRPCServerInputFromConfig(conf, mgr.Logger())
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// grpcServerRequest is a batch of request messages and a channel on which the
+// result of delivering them is sent.
+type grpcServerRequest struct {
+	batch   service.MessageBatch
+	resChan chan error
+}
+
+type grpcServerInput struct {
+	address     string
+	tls         bool
+	timeout     time.Duration
+	reqFilter   *service.MetadataFilter
+	resFilter   *service.MetadataFilter
+	registry    *protobuf.Registry
+	server      *grpc.Server
+	log         *service.Logger
+	requestChan chan grpcServerRequest
+
+	serveMut sync.Mutex
+	serving  bool
+	shutSig  *shutdown.Signaller
+}
+
+func newGRPCServerInputFromConfig(conf *service.ParsedConfig, log *service.Logger) (*grpcServerInput, error) {
+	g := &grpcServerInput{
+		log:         log,
+		requestChan: make(chan grpcServerRequest),
+		shutSig:     shutdown.NewSignaller(),
+	}
+
+	var err error
+	if g.address, err = conf.FieldString("address"); err != nil {
+		return nil, err
+	}
+	if g.timeout, err = conf.FieldDuration("timeout"); err != nil {
+		return nil, err
+	}
+	if g.reqFilter, err = conf.FieldMetadataFilter("metadata"); err != nil {
+		return nil, err
+	}
+	if g.resFilter, err = conf.FieldMetadataFilter("sync_response", "metadata_headers"); err != nil {
+		return nil, err
+	}
+
+	importPaths, err := conf.FieldStringList("import_paths")
+	if err != nil {
+		return nil, err
+	}
+	descriptorSets, err := conf.FieldStringList("descriptor_sets")
+	if err != nil {
+		return nil, err
+	}
+	if g.registry, err = protobuf.LoadRegistry(importPaths, descriptorSets); err != nil {
+		return nil, err
+	}
+
+	serviceNames, err := conf.FieldStringList("services")
+	if err != nil {
+		return nil, err
+	}
+	services, err := g.selectServices(serviceNames)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []grpc.ServerOption{
+		grpc.ForceServerCodec(protobuf.RawCodec{}),
+	}
+
+	certFile, err := conf.FieldString("cert_file")
+	if err != nil {
+		return nil, err
+	}
+	keyFile, err := conf.FieldString("key_file")
+	if err != nil {
+		return nil, err
+	}
+	if len(keyFile) > 0 || len(certFile) > 0 {
+		creds, err := credentials.NewServerTLSFromFile(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+		}
+		opts = append(opts, grpc.Creds(creds))
+		g.tls = true
+	}
+
+	g.server = grpc.NewServer(opts...)
+	for _, sd := range services {
+		g.server.RegisterService(g.serviceDesc(sd), g)
+	}
+	return g, nil
+}
+
+func (g *grpcServerInput) selectServices(names []string) ([]*desc.ServiceDescriptor, error) {
+	all := g.registry.Services()
+	if len(names) == 0 {
+		if len(all) == 0 {
+			return nil, errors.New("no services were found in the loaded descriptors")
+		}
+		return all, nil
+	}
+
+	byName := make(map[string]*desc.ServiceDescriptor, len(all))
+	for _, sd := range all {
+		byName[sd.GetFullyQualifiedName()] = sd
+	}
+
+	services := make([]*desc.ServiceDescriptor, 0, len(names))
+	for _, name := range names {
+		sd, exists := byName[name]
+		if !exists {
+			return nil, fmt.Errorf("unable to find service '%v' definition", name)
+		}
+		services = append(services, sd)
+	}
+	return services, nil
+}
+
+func (g *grpcServerInput) serviceDesc(sd *desc.ServiceDescriptor) *grpc.ServiceDesc {
+	gsd := &grpc.ServiceDesc{
+		ServiceName: sd.GetFullyQualifiedName(),
+		HandlerType: (*interface{})(nil),
+		Metadata:    sd.GetFile().GetName(),
+	}
+	for _, md := range sd.GetMethods() {
+		gsd.Streams = append(gsd.Streams, grpc.StreamDesc{
+			StreamName:    md.GetName(),
+			Handler:       g.methodHandler(md),
+			ServerStreams: md.IsServerStreaming(),
+			ClientStreams: md.IsClientStreaming(),
+		})
+	}
+	return gsd
+}
+
+//------------------------------------------------------------------------------
+
+// requestMetadata returns a message carrying the first value of each request
+// metadata key, excluding pseudo-headers such as :authority, which is used as
+// the source when copying filtered metadata onto request messages.
+func requestMetadata(ctx context.Context) *service.Message {
+	metaMsg := service.NewMessage(nil)
+	md, _ := metadata.FromIncomingContext(ctx)
+	for k, v := range md {
+		if strings.HasPrefix(k, ":") || len(v) == 0 {
+			continue
+		}
+		metaMsg.MetaSet(k, v[0])
+	}
+	return metaMsg
+}
+
+func (g *grpcServerInput) methodHandler(md *desc.MethodDescriptor) grpc.StreamHandler {
+	fullMethod := "/" + md.GetService().GetFullyQualifiedName() + "/" + md.GetName()
+	return func(_ interface{}, stream grpc.ServerStream) error {
+		if md.IsServerStreaming() {
+			return status.Errorf(codes.Unimplemented, "method %v has a streamed response, which is not supported", fullMethod)
+		}
+
+		var batch service.MessageBatch
+		for {
+			var data []byte
+			if err := stream.RecvMsg(&data); err != nil {
+				if err == io.EOF {
+					break
+				}
+				return err
+			}
+
+			jBytes, err := g.registry.ToJSON(md.GetInputType(), data)
+			if err != nil {
+				return status.Errorf(codes.InvalidArgument, "failed to parse request: %v", err)
+			}
+			batch = append(batch, service.NewMessage(jBytes))
+
+			if !md.IsClientStreaming() {
+				break
+			}
+		}
+		if len(batch) == 0 {
+			return status.Error(codes.InvalidArgument, "request stream did not contain any messages")
+		}
+
+		metaMsg := requestMetadata(stream.Context())
+		for _, msg := range batch {
+			_ = g.reqFilter.Walk(metaMsg, func(k, v string) error {
+				msg.MetaSet(k, v)
+				return nil
+			})
+			msg.MetaSet("grpc_server_method", fullMethod)
+		}
+
+		resPart, err := g.deliver(stream.Context(), batch)
+		if err != nil {
+			return err
+		}
+
+		var payload []byte
+		if resPart != nil {
+			resMsg := service.NewMessage(nil)
+			_ = resPart.MetaIter(func(k, v string) error {
+				resMsg.MetaSet(k, v)
+				return nil
+			})
+			header := metadata.MD{}
+			_ = g.resFilter.Walk(resMsg, func(k, v string) error {
+				header.Append(k, v)
+				return nil
+			})
+			if len(header) > 0 {
+				if err := stream.SetHeader(header); err != nil {
+					return err
+				}
+			}
+			if payload, err = g.registry.FromJSON(md.GetOutputType(), resPart.Get()); err != nil {
+				g.log.Errorf("Failed to convert sync response of method %v: %v", fullMethod, err)
+				return status.Errorf(codes.Internal, "failed to convert response: %v", err)
+			}
+		}
+		return stream.SendMsg(payload)
+	}
+}
+
+// deliver sends a batch of messages through the pipeline and returns the first
+// message of the synchronous response, if any.
+func (g *grpcServerInput) deliver(ctx context.Context, batch service.MessageBatch) (*message.Part, error) {
+	store := transaction.NewResultStore()
+	for i, msg := range batch {
+		batch[i] = msg.WithContext(context.WithValue(msg.Context(), transaction.ResultStoreKey, store))
+	}
+
+	var timeoutChan <-chan time.Time
+	if g.timeout > 0 {
+		timer := time.NewTimer(g.timeout)
+		defer timer.Stop()
+		timeoutChan = timer.C
+	}
+
+	resChan := make(chan error, 1)
+	select {
+	case g.requestChan <- grpcServerRequest{batch: batch, resChan: resChan}:
+	case <-timeoutChan:
+		return nil, status.Error(codes.DeadlineExceeded, "request timed out")
+	case <-ctx.Done():
+		return nil, status.FromContextError(ctx.Err()).Err()
+	case <-g.shutSig.CloseAtLeisureChan():
+		return nil, status.Error(codes.Unavailable, "server closing")
+	}
+
+	select {
+	case res := <-resChan:
+		if res != nil {
+			return nil, status.Error(codes.Internal, res.Error())
+		}
+	case <-timeoutChan:
+		return nil, status.Error(codes.DeadlineExceeded, "request timed out")
+	case <-ctx.Done():
+		return nil, status.FromContextError(ctx.Err()).Err()
+	case <-g.shutSig.CloseNowChan():
+		return nil, status.Error(codes.Unavailable, "server closing")
+	}
+
+	for _, resMsg := range store.Get() {
+		if resMsg.Len() > 0 {
+			return resMsg.Get(0), nil
+		}
+	}
+	return nil, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (g *grpcServerInput) Connect(ctx context.Context) error {
+	g.serveMut.Lock()
+	defer g.serveMut.Unlock()
+
+	if g.serving {
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", g.address)
+	if err != nil {
+		return err
+	}
+
+	scheme := "grpc"
+	if g.tls {
+		scheme = "grpcs"
+	}
+	g.log.Infof("Receiving gRPC requests at: %v://%v", scheme, listener.Addr())
+
+	go func() {
+		if err := g.server.Serve(listener); err != nil && err != grpc.ErrServerStopped {
+			g.log.Errorf("Server error: %v", err)
+		}
+	}()
+	g.serving = true
+	return nil
+}
+
+func (g *grpcServerInput) ReadBatch(ctx context.Context) (service.MessageBatch, service.AckFunc, error) {
+	select {
+	case req := <-g.requestChan:
+		return req.batch, func(ctx context.Context, err error) error {
+			req.resChan <- err
+			return nil
+		}, nil
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	case <-g.shutSig.CloseAtLeisureChan():
+		return nil, nil, service.ErrEndOfInput
+	}
+}
+
+func (g *grpcServerInput) Close(ctx context.Context) error {
+	g.shutSig.CloseAtLeisure()
+
+	stopped := make(chan struct{})
+	go func() {
+		g.server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		g.shutSig.CloseNow()
+		g.server.Stop()
+		<-stopped
+	}
+	return nil
+}
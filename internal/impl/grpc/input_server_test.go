@@ -0,0 +1,226 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/benthosdev/benthos/v4/internal/impl/protobuf"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/transaction"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+var errTestNack = errors.New("nope")
+
+type grpcTestClient struct {
+	t        *testing.T
+	conn     *grpc.ClientConn
+	registry *protobuf.Registry
+}
+
+func (c *grpcTestClient) encode(jStr string) []byte {
+	md, err := c.registry.FindMessage("testing.Greeting")
+	require.NoError(c.t, err)
+	b, err := c.registry.FromJSON(md, []byte(jStr))
+	require.NoError(c.t, err)
+	return b
+}
+
+func (c *grpcTestClient) decode(b []byte) string {
+	md, err := c.registry.FindMessage("testing.Greeting")
+	require.NoError(c.t, err)
+	j, err := c.registry.ToJSON(md, b)
+	require.NoError(c.t, err)
+	return string(j)
+}
+
+func grpcServerForTest(t *testing.T, extraConf string) (*grpcServerInput, *grpcTestClient) {
+	t.Helper()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "greeter.proto"), []byte(testGreeterProto), 0o644))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	conf, err := grpcServerInputConfig().ParseYAML(fmt.Sprintf(`
+address: %v
+import_paths: [ %v ]
+%v
+`, addr, dir, extraConf), nil)
+	require.NoError(t, err)
+
+	g, err := newGRPCServerInputFromConfig(conf, service.MockResources().Logger())
+	require.NoError(t, err)
+	require.NoError(t, g.Connect(context.Background()))
+	t.Cleanup(func() {
+		ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+		defer done()
+		assert.NoError(t, g.Close(ctx))
+	})
+
+	registry, err := protobuf.LoadRegistry([]string{dir}, nil)
+	require.NoError(t, err)
+
+	conn, err := grpc.Dial(addr,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(protobuf.RawCodec{})),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		conn.Close()
+	})
+
+	return g, &grpcTestClient{t: t, conn: conn, registry: registry}
+}
+
+func TestGRPCServerUnarySyncResponse(t *testing.T) {
+	tCtx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	g, client := grpcServerForTest(t, `
+metadata:
+  include_prefixes: [ "ba" ]
+sync_response:
+  metadata_headers:
+    include_prefixes: [ "greet" ]
+`)
+
+	go func() {
+		batch, ackFn, err := g.ReadBatch(tCtx)
+		if !assert.NoError(t, err) {
+			return
+		}
+		require.Len(t, batch, 1)
+
+		b, err := batch[0].AsBytes()
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"name":"foo"}`, string(b))
+
+		v, _ := batch[0].MetaGet("grpc_server_method")
+		assert.Equal(t, "/testing.Greeter/Greet", v)
+		v, _ = batch[0].MetaGet("baz")
+		assert.Equal(t, "bar", v)
+		_, exists := batch[0].MetaGet("qux")
+		assert.False(t, exists)
+		_, exists = batch[0].MetaGet(":authority")
+		assert.False(t, exists)
+
+		store, ok := batch[0].Context().Value(transaction.ResultStoreKey).(transaction.ResultStore)
+		require.True(t, ok)
+
+		part := message.NewPart([]byte(`{"name":"hello foo"}`))
+		part.MetaSet("greeting_type", "friendly")
+		part.MetaSet("baz", "bar")
+		resMsg := message.QuickBatch(nil)
+		resMsg.Append(part)
+		store.Add(resMsg)
+
+		assert.NoError(t, ackFn(tCtx, nil))
+	}()
+
+	ctx := metadata.AppendToOutgoingContext(tCtx, "baz", "bar", "qux", "quz")
+
+	var header metadata.MD
+	var res []byte
+	require.NoError(t, client.conn.Invoke(ctx, "/testing.Greeter/Greet", client.encode(`{"name":"foo"}`), &res, grpc.Header(&header)))
+	assert.JSONEq(t, `{"name":"hello foo"}`, client.decode(res))
+	assert.Equal(t, []string{"friendly"}, header.Get("greeting_type"))
+	assert.Empty(t, header.Get("baz"))
+}
+
+func TestGRPCServerClientStreaming(t *testing.T) {
+	tCtx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	g, client := grpcServerForTest(t, "")
+
+	go func() {
+		batch, ackFn, err := g.ReadBatch(tCtx)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		var names []string
+		for _, msg := range batch {
+			b, err := msg.AsBytes()
+			require.NoError(t, err)
+			names = append(names, string(b))
+		}
+		assert.Len(t, names, 2)
+		assert.JSONEq(t, `{"name":"foo"}`, names[0])
+		assert.JSONEq(t, `{"name":"bar"}`, names[1])
+		assert.NoError(t, ackFn(tCtx, nil))
+	}()
+
+	stream, err := client.conn.NewStream(tCtx, &grpc.StreamDesc{ClientStreams: true}, "/testing.Greeter/GreetMany")
+	require.NoError(t, err)
+	require.NoError(t, stream.SendMsg(client.encode(`{"name":"foo"}`)))
+	require.NoError(t, stream.SendMsg(client.encode(`{"name":"bar"}`)))
+	require.NoError(t, stream.CloseSend())
+
+	// Without a sync response an empty message is returned.
+	var res []byte
+	require.NoError(t, stream.RecvMsg(&res))
+	assert.Equal(t, `{}`, client.decode(res))
+}
+
+func TestGRPCServerErrors(t *testing.T) {
+	tCtx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	g, client := grpcServerForTest(t, `services: [ testing.Greeter ]`)
+
+	go func() {
+		_, ackFn, err := g.ReadBatch(tCtx)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.NoError(t, ackFn(tCtx, errTestNack))
+	}()
+
+	var res []byte
+	err := client.conn.Invoke(tCtx, "/testing.Greeter/Greet", client.encode(`{"name":"foo"}`), &res)
+	require.Error(t, err)
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Contains(t, err.Error(), errTestNack.Error())
+
+	stream, err := client.conn.NewStream(tCtx, &grpc.StreamDesc{ServerStreams: true}, "/testing.Greeter/GreetStream")
+	require.NoError(t, err)
+	require.NoError(t, stream.SendMsg(client.encode(`{"name":"foo"}`)))
+	require.NoError(t, stream.CloseSend())
+	err = stream.RecvMsg(&res)
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+}
+
+func TestGRPCServerUnknownService(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "greeter.proto"), []byte(testGreeterProto), 0o644))
+
+	conf, err := grpcServerInputConfig().ParseYAML(fmt.Sprintf(`
+address: 127.0.0.1:0
+import_paths: [ %v ]
+services: [ testing.Nope ]
+`, dir), nil)
+	require.NoError(t, err)
+
+	_, err = newGRPCServerInputFromConfig(conf, service.MockResources().Logger())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "testing.Nope")
+}
@@ -0,0 +1,139 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"google.golang.org/grpc"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func grpcClientOutputConfig() *service.ConfigSpec {
+	spec := service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Network").
+		Version("4.0.0").
+		Summary("Sends messages to a gRPC server by calling a method with them.").
+		Description(clientDescription + `
+
+Methods with a unary request are called once for each message, and methods with a client streaming request are called once for each batch, where each message of the batch is sent on the stream. Responses are discarded, and methods with streamed responses are not supported.
+
+In order to obtain responses use the ` + "[`grpc_client` processor](/docs/components/processors/grpc_client)" + ` instead.`)
+	for _, f := range clientFields() {
+		spec = spec.Field(f)
+	}
+	return spec.
+		Field(service.NewIntField("max_in_flight").
+			Description("The maximum number of calls to have in flight at a given time. Increase this to improve throughput.").
+			Default(64)).
+		Field(service.NewBatchPolicyField("batching")).
+		Example("Client Streaming",
+			`
+Here we send batches of up to 100 messages to a client streaming method:`,
+			`
+output:
+  grpc_client:
+    address: localhost:50051
+    method: foo.BarService/UploadMany
+    import_paths: [ ./protos ]
+    batching:
+      count: 100
+      period: 1s
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchOutput(
+		"grpc_client", grpcClientOutputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (out service.BatchOutput, batchPolicy service.BatchPolicy, maxInFlight int, err error) {
+			if batchPolicy, err = conf.FieldBatchPolicy("batching"); err != nil {
+				return
+			}
+			if maxInFlight, err = conf.FieldInt("max_in_flight"); err != nil {
+				return
+			}
+			out, err = newGRPCClientOutputFromConfig(conf, mgr.Logger())
+			return
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type grpcClientOutput struct {
+	client *grpcClient
+	log    *service.Logger
+
+	connMut sync.RWMutex
+	conn    *grpc.ClientConn
+}
+
+func newGRPCClientOutputFromConfig(conf *service.ParsedConfig, log *service.Logger) (*grpcClientOutput, error) {
+	client, err := newGRPCClientFromConfig(conf)
+	if err != nil {
+		return nil, err
+	}
+	if client.method.IsServerStreaming() {
+		return nil, errors.New("methods with streamed responses are not supported by the grpc_client output")
+	}
+	return &grpcClientOutput{
+		client: client,
+		log:    log,
+	}, nil
+}
+
+func (g *grpcClientOutput) Connect(ctx context.Context) error {
+	g.connMut.Lock()
+	defer g.connMut.Unlock()
+
+	if g.conn != nil {
+		return nil
+	}
+
+	conn, err := g.client.dial()
+	if err != nil {
+		return err
+	}
+
+	g.log.Infof("Calling gRPC method %v at: %v", g.client.fullMethod(), g.client.address)
+	g.conn = conn
+	return nil
+}
+
+func (g *grpcClientOutput) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
+	g.connMut.RLock()
+	conn := g.conn
+	g.connMut.RUnlock()
+	if conn == nil {
+		return service.ErrNotConnected
+	}
+
+	if g.client.method.IsClientStreaming() {
+		_, err := g.client.call(ctx, conn, batch)
+		return err
+	}
+	for _, msg := range batch {
+		if _, err := g.client.call(ctx, conn, service.MessageBatch{msg}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *grpcClientOutput) Close(ctx context.Context) error {
+	g.connMut.Lock()
+	defer g.connMut.Unlock()
+
+	if g.conn == nil {
+		return nil
+	}
+	err := g.conn.Close()
+	g.conn = nil
+	return err
+}
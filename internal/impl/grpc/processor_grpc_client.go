@@ -0,0 +1,100 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func grpcClientProcessorConfig() *service.ConfigSpec {
+	spec := service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Integration").
+		Version("4.0.0").
+		Summary("Calls a gRPC method with each message and replaces it with the response.").
+		Description(clientDescription + `
+
+The response is converted from the output type of the method into a JSON document, which replaces the contents of the message. Methods with a streamed response result in a message for each response received, and methods with a client streaming request are not supported.
+
+If the call fails the message is left unchanged and flagged as failed, allowing you to handle it with [error handling patterns](/docs/configuration/error_handling).`)
+	for _, f := range clientFields() {
+		spec = spec.Field(f)
+	}
+	return spec.
+		Example("Enrichment",
+			`
+Here we use a `+"[`branch` processor](/docs/components/processors/branch)"+` in order to add the response of a method to the original document:`,
+			`
+pipeline:
+  processors:
+    - branch:
+        request_map: 'root.id = this.user_id'
+        processors:
+          - grpc_client:
+              address: localhost:50051
+              method: foo.UserService/GetUser
+              import_paths: [ ./protos ]
+        result_map: 'root.user = this'
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterProcessor(
+		"grpc_client", grpcClientProcessorConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Processor, error) {
+			return newGRPCClientProcessorFromConfig(conf)
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type grpcClientProcessor struct {
+	client *grpcClient
+	conn   *grpc.ClientConn
+}
+
+func newGRPCClientProcessorFromConfig(conf *service.ParsedConfig) (*grpcClientProcessor, error) {
+	client, err := newGRPCClientFromConfig(conf)
+	if err != nil {
+		return nil, err
+	}
+	if client.method.IsClientStreaming() {
+		return nil, errors.New("methods with streamed requests are not supported by the grpc_client processor")
+	}
+
+	conn, err := client.dial()
+	if err != nil {
+		return nil, err
+	}
+	return &grpcClientProcessor{
+		client: client,
+		conn:   conn,
+	}, nil
+}
+
+func (g *grpcClientProcessor) Process(ctx context.Context, msg *service.Message) (service.MessageBatch, error) {
+	responses, err := g.client.call(ctx, g.conn, service.MessageBatch{msg})
+	if err != nil {
+		return nil, err
+	}
+
+	batch := make(service.MessageBatch, 0, len(responses))
+	for _, res := range responses {
+		resMsg := msg.Copy()
+		resMsg.SetBytes(res)
+		batch = append(batch, resMsg)
+	}
+	return batch, nil
+}
+
+func (g *grpcClientProcessor) Close(ctx context.Context) error {
+	return g.conn.Close()
+}
@@ -0,0 +1,226 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+// transactSessionKey is the context key of messages consumed within the
+// transactions of a kafka_franz input, which allows kafka_franz outputs of the
+// same stream to produce messages within those transactions.
+type transactSessionKey struct{}
+
+type transactSession struct {
+	id      string
+	session *kgo.GroupTransactSession
+}
+
+func withTransactSession(msg *service.Message, ts *transactSession) *service.Message {
+	return msg.WithContext(context.WithValue(msg.Context(), transactSessionKey{}, ts))
+}
+
+// batchTransactSession returns the transact session that the messages of a
+// batch were consumed within, which must be that of the transactional ID.
+func batchTransactSession(b service.MessageBatch, id string) (*kgo.GroupTransactSession, error) {
+	var session *kgo.GroupTransactSession
+	for _, msg := range b {
+		ts, _ := msg.Context().Value(transactSessionKey{}).(*transactSession)
+		if ts == nil || ts.id != id {
+			return nil, fmt.Errorf("message was not consumed within a transaction of a kafka_franz input with transactional_id %v", id)
+		}
+		if session != nil && session != ts.session {
+			return nil, errors.New("messages of a batch were consumed within different transactions")
+		}
+		session = ts.session
+	}
+	return session, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (f *franzKafkaReader) connectTransactional() error {
+	var assignmentGeneration int64
+	resetOffset := kgo.NewOffset().AtStart()
+	if !f.startFromOldest {
+		resetOffset = kgo.NewOffset().AtEnd()
+	}
+
+	clientOpts := []kgo.Opt{
+		kgo.SeedBrokers(f.seedBrokers...),
+		kgo.ConsumerGroup(f.consumerGroup),
+		kgo.ConsumeTopics(f.topics...),
+		kgo.ConsumeResetOffset(resetOffset),
+		kgo.SASL(f.saslConfs...),
+		kgo.TransactionalID(f.transactionalID),
+		kgo.FetchIsolationLevel(kgo.ReadCommitted()),
+		kgo.RequireStableFetchOffsets(),
+		kgo.DisableAutoCommit(),
+		kgo.AllowAutoTopicCreation(),
+		kgo.OnPartitionsAssigned(func(_ context.Context, _ *kgo.Client, m map[string][]int32) {
+			generation := atomic.AddInt64(&assignmentGeneration, 1)
+			f.log.Infof("Partitions assigned (generation %v): %v", generation, m)
+		}),
+		kgo.OnPartitionsRevoked(func(_ context.Context, _ *kgo.Client, m map[string][]int32) {
+			// The transact session aborts any transaction in progress, and
+			// offsets are only ever committed within transactions.
+			f.log.Infof("Partitions revoked: %v", m)
+		}),
+		kgo.OnPartitionsLost(func(_ context.Context, _ *kgo.Client, m map[string][]int32) {
+			f.log.Warnf("Partitions lost: %v", m)
+		}),
+		kgo.WithLogger(&kgoLogger{f.log}),
+	}
+	if f.regexpTopics {
+		clientOpts = append(clientOpts, kgo.ConsumeRegex())
+	}
+	if f.tlsConf != nil {
+		clientOpts = append(clientOpts, kgo.DialTLSConfig(f.tlsConf))
+	}
+
+	session, err := kgo.NewGroupTransactSession(clientOpts...)
+	if err != nil {
+		return err
+	}
+	ts := &transactSession{id: f.transactionalID, session: session}
+
+	msgChan := make(chan msgWithAckFn)
+	go func() {
+		defer func() {
+			session.Close()
+			f.storeMsgChan(nil)
+			close(msgChan)
+			if f.shutSig.ShouldCloseAtLeisure() {
+				f.shutSig.ShutdownComplete()
+			}
+		}()
+
+		closeCtx, done := f.shutSig.CloseAtLeisureCtx(context.Background())
+		defer done()
+
+		for {
+			fetches := session.PollFetches(closeCtx)
+			if errs := fetches.Errors(); len(errs) > 0 {
+				for _, kerr := range errs {
+					if errors.Is(kerr.Err, context.Canceled) {
+						continue
+					}
+					f.log.Errorf("Kafka poll error on topic %v, partition %v: %v", kerr.Topic, kerr.Partition, kerr.Err)
+				}
+				return
+			}
+			if closeCtx.Err() != nil {
+				return
+			}
+
+			generation := strconv.FormatInt(atomic.LoadInt64(&assignmentGeneration), 10)
+			if !f.transact(closeCtx, ts, fetches, msgChan, generation) {
+				return
+			}
+		}
+	}()
+
+	f.storeMsgChan(msgChan)
+	f.log.Infof("Receiving messages from Kafka topics %v within transactions of ID: %v", f.topics, f.transactionalID)
+	return nil
+}
+
+// transact delivers the records of a fetch within a transaction, which is
+// committed once all of the resulting messages are acknowledged and aborted if
+// any of them are rejected. Returns false if the session can no longer be used.
+func (f *franzKafkaReader) transact(
+	closeCtx context.Context,
+	ts *transactSession,
+	fetches kgo.Fetches,
+	msgChan chan<- msgWithAckFn,
+	generation string,
+) bool {
+	records, lags := f.fetchedRecords(fetches, nil)
+	if len(records) == 0 {
+		return true
+	}
+
+	session := ts.session
+	if err := session.Begin(); err != nil {
+		f.log.Errorf("Failed to begin transaction: %v", err)
+		return false
+	}
+
+	var pending sync.WaitGroup
+	var txnErrMut sync.Mutex
+	var txnErr error
+	setTxnErr := func(err error) {
+		txnErrMut.Lock()
+		if txnErr == nil {
+			txnErr = err
+		}
+		txnErrMut.Unlock()
+	}
+
+dispatchLoop:
+	for i, record := range records {
+		msg := withTransactSession(recordToMessage(record), ts)
+		msg.MetaSet("kafka_lag", strconv.FormatInt(lags[i], 10))
+		msg.MetaSet("kafka_assignment_generation", generation)
+
+		pending.Add(1)
+		select {
+		case msgChan <- msgWithAckFn{
+			msg: msg,
+			onAck: func(err error) {
+				if err != nil {
+					setTxnErr(err)
+				}
+				pending.Done()
+			},
+		}:
+		case <-closeCtx.Done():
+			pending.Done()
+			setTxnErr(service.ErrEndOfInput)
+			break dispatchLoop
+		}
+	}
+
+	// Wait for every dispatched message to be acknowledged, as the output must
+	// not produce while the transaction is ending.
+	acked := make(chan struct{})
+	go func() {
+		pending.Wait()
+		close(acked)
+	}()
+	select {
+	case <-acked:
+	case <-f.shutSig.CloseNowChan():
+		// Transactions that are never ended are aborted by the brokers once
+		// they time out.
+		return false
+	}
+
+	commit := kgo.TryCommit
+	if txnErr != nil {
+		if txnErr != service.ErrEndOfInput {
+			f.log.Warnf("Aborting transaction of %v messages due to: %v", len(records), txnErr)
+		}
+		commit = kgo.TryAbort
+	}
+
+	endCtx, done := f.shutSig.CloseNowCtx(context.Background())
+	defer done()
+
+	committed, err := session.End(endCtx, commit)
+	if err != nil {
+		f.log.Errorf("Failed to end transaction: %v", err)
+		return false
+	}
+	if commit == kgo.TryCommit && !committed {
+		f.log.Warnf("Transaction of %v messages aborted due to a rebalance, the messages will be consumed again", len(records))
+	}
+	return true
+}
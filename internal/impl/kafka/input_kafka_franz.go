@@ -4,12 +4,15 @@ import (
 	"context"
 	"crypto/tls"
 	"errors"
+	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/twmb/franz-go/pkg/kerr"
 	"github.com/twmb/franz-go/pkg/kgo"
 	"github.com/twmb/franz-go/pkg/kmsg"
 	"github.com/twmb/franz-go/pkg/sasl"
@@ -44,8 +47,27 @@ This input adds the following metadata fields to each message:
 - kafka_partition
 - kafka_offset
 - kafka_timestamp_unix
+- kafka_lag
+- kafka_assignment_generation
 - All record headers
 ` + "```" + `
+
+The field ` + "`kafka_lag`" + ` is the number of records between the message and the high watermark of its partition at the time it was fetched. The field ` + "`kafka_assignment_generation`" + ` is a number that increments each time partitions are assigned to this consumer by a rebalance of the group, which allows messages consumed under different assignments to be told apart.
+
+### Metrics
+
+This input emits the following metrics with the labels ` + "`topic`" + ` and ` + "`partition`" + `:
+
+- ` + "`kafka_lag`" + `: The number of records between the last message fetched from a partition and its high watermark.
+- ` + "`kafka_committed_offset`" + `: The last offset committed for a partition by the consumer group.
+
+### Exactly-Once Delivery
+
+When the field ` + "`transactional_id`" + ` is set this input consumes within Kafka transactions, and a ` + "`kafka_franz`" + ` output of the same stream configured with the same ` + "`transactional_id`" + ` produces its messages within those transactions. The offsets of the consumed messages are committed in the same transaction as the messages produced from them, and therefore a message is only ever observed by consumers reading with ` + "`read_committed`" + ` isolation once.
+
+Each batch of records fetched from the brokers is processed within a single transaction, which is committed once all messages of the batch have been delivered. If any message fails to be delivered, or if the partitions of the consumer are rebalanced before the transaction is committed, the transaction is aborted and the records are consumed again.
+
+Each message carries the transaction that it was consumed within to the output, and therefore the stream must not contain any other inputs or outputs that the messages of this input can be routed to. The output does not flush its batching policy when a transaction ends, and so a policy that waits for more messages than a single fetch returns holds each transaction open until its ` + "`period`" + ` elapses. Batching policies of a transactional output must therefore include a ` + "`period`" + `, which should be kept short. Messages are consumed with ` + "`read_committed`" + ` isolation in this mode, and partitions are neither paused by the ` + "`checkpoint_limit`" + ` nor moved by the ` + "`start_from_timestamp`" + `, which cannot be used.
 `).
 		Field(service.NewStringListField("seed_brokers").
 			Description("A list of broker addresses to connect to in order to establish connections. If an item of the list contains commas it will be expanded into multiple addresses.").
@@ -53,13 +75,39 @@ This input adds the following metadata fields to each message:
 			Example([]string{"foo:9092", "bar:9092"}).
 			Example([]string{"foo:9092,bar:9092"})).
 		Field(service.NewStringListField("topics").
-			Description("A list of topics to consume from, partitions are automatically shared across consumers sharing the consumer group.")).
+			Description("A list of topics to consume from, partitions are automatically shared across consumers sharing the consumer group. If an item of the list contains commas it will be expanded into multiple topics.")).
+		Field(service.NewBoolField("regexp_topics").
+			Description("Whether listed topics should be interpreted as regular expression patterns for matching multiple topics. Topics created after the consumer has started are consumed as soon as they are discovered.").
+			Default(false).
+			Version("4.0.0")).
 		Field(service.NewStringField("consumer_group").
 			Description("A consumer group to consume as. Partitions are automatically distributed across consumers sharing a consumer group, and partition offsets are automatically commited and resumed under this name.")).
 		Field(service.NewIntField("checkpoint_limit").
 			Description("Determines how many messages of the same partition can be processed in parallel before applying back pressure. When a message of a given offset is delivered to the output the offset is only allowed to be committed when all messages of prior offsets have also been delivered, this ensures at-least-once delivery guarantees. However, this mechanism also increases the likelihood of duplicates in the event of crashes or server faults, reducing the checkpoint limit will mitigate this.").
 			Default(1024).
 			Advanced()).
+		Field(service.NewBoolField("start_from_oldest").
+			Description("If the consumer group has no committed offset for a partition, determines whether to consume from the oldest available offset, otherwise messages are consumed from the latest offset.").
+			Default(true).
+			Advanced().
+			Version("4.0.0")).
+		Field(service.NewStringField("start_from_timestamp").
+			Description("An optional RFC 3339 timestamp to start consuming from when the consumer group has no committed offset for a partition. Messages of a partition are consumed from the first offset with a timestamp at or after this time, and when no such offset exists consumption begins at the end of the partition. Takes precedence over `start_from_oldest`.").
+			Example("2022-01-02T15:04:05Z").
+			Optional().
+			Advanced().
+			Version("4.0.0")).
+		Field(service.NewBoolField("read_committed").
+			Description("Whether to only consume messages that were produced within committed transactions, messages of aborted transactions are skipped. This is always enabled when `transactional_id` is set.").
+			Default(false).
+			Advanced().
+			Version("4.0.0")).
+		Field(service.NewStringField("transactional_id").
+			Description("An optional transactional ID that, when set, consumes messages within Kafka transactions that a `kafka_franz` output with the same `transactional_id` produces to, providing exactly-once delivery from topic to topic. The ID must be unique to this stream across all instances consuming with the same consumer group.").
+			Example("billing-enrichment").
+			Optional().
+			Advanced().
+			Version("4.0.0")).
 		Field(service.NewTLSToggledField("tls")).
 		Field(saslField)
 }
@@ -67,10 +115,14 @@ This input adds the following metadata fields to each message:
 func init() {
 	err := service.RegisterInput("kafka_franz", franzKafkaInputConfig(),
 		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Input, error) {
-			rdr, err := newFranzKafkaReaderFromConfig(conf, mgr.Logger())
+			rdr, err := newFranzKafkaReaderFromConfig(conf, mgr.Logger(), mgr.Metrics())
 			if err != nil {
 				return nil, err
 			}
+			if rdr.transactionalID != "" {
+				// Nacks must abort the transaction rather than be retried.
+				return rdr, nil
+			}
 			return service.AutoRetryNacks(rdr), nil
 		})
 
@@ -82,21 +134,29 @@ func init() {
 //------------------------------------------------------------------------------
 
 type msgWithAckFn struct {
-	onAck func()
+	onAck func(err error)
 	msg   *service.Message
 }
 
 type franzKafkaReader struct {
-	seedBrokers     []string
-	topics          []string
-	consumerGroup   string
-	tlsConf         *tls.Config
-	saslConfs       []sasl.Mechanism
-	checkpointLimit int
+	seedBrokers        []string
+	topics             []string
+	regexpTopics       bool
+	consumerGroup      string
+	tlsConf            *tls.Config
+	saslConfs          []sasl.Mechanism
+	checkpointLimit    int
+	startFromOldest    bool
+	startFromTimestamp time.Time
+	readCommitted      bool
+	transactionalID    string
 
 	msgChan atomic.Value
 	log     *service.Logger
 	shutSig *shutdown.Signaller
+
+	mLag       *service.MetricGauge
+	mCommitted *service.MetricGauge
 }
 
 func (f *franzKafkaReader) getMsgChan() chan msgWithAckFn {
@@ -108,10 +168,12 @@ func (f *franzKafkaReader) storeMsgChan(c chan msgWithAckFn) {
 	f.msgChan.Store(c)
 }
 
-func newFranzKafkaReaderFromConfig(conf *service.ParsedConfig, log *service.Logger) (*franzKafkaReader, error) {
+func newFranzKafkaReaderFromConfig(conf *service.ParsedConfig, log *service.Logger, metrics *service.Metrics) (*franzKafkaReader, error) {
 	f := franzKafkaReader{
-		log:     log,
-		shutSig: shutdown.NewSignaller(),
+		log:        log,
+		shutSig:    shutdown.NewSignaller(),
+		mLag:       metrics.NewGauge("kafka_lag", "topic", "partition"),
+		mCommitted: metrics.NewGauge("kafka_committed_offset", "topic", "partition"),
 	}
 
 	brokerList, err := conf.FieldStringList("seed_brokers")
@@ -130,6 +192,17 @@ func newFranzKafkaReaderFromConfig(conf *service.ParsedConfig, log *service.Logg
 		f.topics = append(f.topics, strings.Split(t, ",")...)
 	}
 
+	if f.regexpTopics, err = conf.FieldBool("regexp_topics"); err != nil {
+		return nil, err
+	}
+	if f.regexpTopics {
+		for _, t := range f.topics {
+			if _, err := regexp.Compile(t); err != nil {
+				return nil, fmt.Errorf("failed to compile topic regular expression %q: %w", t, err)
+			}
+		}
+	}
+
 	if f.consumerGroup, err = conf.FieldString("consumer_group"); err != nil {
 		return nil, err
 	}
@@ -138,6 +211,32 @@ func newFranzKafkaReaderFromConfig(conf *service.ParsedConfig, log *service.Logg
 		return nil, err
 	}
 
+	if f.startFromOldest, err = conf.FieldBool("start_from_oldest"); err != nil {
+		return nil, err
+	}
+	if conf.Contains("start_from_timestamp") {
+		tsStr, err := conf.FieldString("start_from_timestamp")
+		if err != nil {
+			return nil, err
+		}
+		if f.startFromTimestamp, err = time.Parse(time.RFC3339Nano, tsStr); err != nil {
+			return nil, fmt.Errorf("failed to parse start_from_timestamp: %w", err)
+		}
+	}
+
+	if f.readCommitted, err = conf.FieldBool("read_committed"); err != nil {
+		return nil, err
+	}
+	if conf.Contains("transactional_id") {
+		if f.transactionalID, err = conf.FieldString("transactional_id"); err != nil {
+			return nil, err
+		}
+		if !f.startFromTimestamp.IsZero() {
+			return nil, errors.New("start_from_timestamp cannot be used with transactional_id")
+		}
+		f.readCommitted = true
+	}
+
 	tlsConf, tlsEnabled, err := conf.FieldTLSToggled("tls")
 	if err != nil {
 		return nil, err
@@ -243,6 +342,170 @@ func (c *checkpointTracker) removeTopicPartitions(m map[string][]int32) {
 
 //------------------------------------------------------------------------------
 
+// timestampSeeker moves newly assigned partitions without a committed offset to
+// the first offset at or after a timestamp. The client has no native support
+// for resetting to a timestamp, and so partitions are consumed from the oldest
+// offset and are moved forward once their first records have been fetched.
+type timestampSeeker struct {
+	mut       sync.Mutex
+	timestamp time.Time
+	checked   map[string]map[int32]struct{}
+}
+
+func newTimestampSeeker(timestamp time.Time) *timestampSeeker {
+	return &timestampSeeker{
+		timestamp: timestamp,
+		checked:   map[string]map[int32]struct{}{},
+	}
+}
+
+// uncheckedPartitions returns the partitions of a fetch that have records and
+// have not been checked since they were assigned, along with their first
+// record and high watermark, and marks them as checked.
+func (s *timestampSeeker) uncheckedPartitions(fetches kgo.Fetches) map[string]map[int32]kgo.FetchPartition {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	unchecked := map[string]map[int32]kgo.FetchPartition{}
+	fetches.EachPartition(func(p kgo.FetchTopicPartition) {
+		if len(p.Records) == 0 {
+			return
+		}
+		checkedTopic := s.checked[p.Topic]
+		if checkedTopic == nil {
+			checkedTopic = map[int32]struct{}{}
+			s.checked[p.Topic] = checkedTopic
+		}
+		if _, exists := checkedTopic[p.Partition]; exists {
+			return
+		}
+		checkedTopic[p.Partition] = struct{}{}
+
+		if unchecked[p.Topic] == nil {
+			unchecked[p.Topic] = map[int32]kgo.FetchPartition{}
+		}
+		unchecked[p.Topic][p.Partition] = p.FetchPartition
+	})
+	return unchecked
+}
+
+// seek moves any partitions of a fetch that need to begin at a later offset
+// and returns them, the records fetched for these partitions should be
+// discarded. If the offsets cannot be obtained then the unchecked partitions
+// are rewound so that they are checked again on the next fetch.
+func (s *timestampSeeker) seek(ctx context.Context, cl *kgo.Client, fetches kgo.Fetches) (map[string]map[int32]struct{}, error) {
+	unchecked := s.uncheckedPartitions(fetches)
+	if len(unchecked) == 0 {
+		return nil, nil
+	}
+
+	skip, err := s.seekPartitions(ctx, cl, unchecked)
+	if err == nil {
+		return skip, nil
+	}
+
+	rewind := map[string]map[int32]kgo.EpochOffset{}
+	skip = map[string]map[int32]struct{}{}
+	uncheck := map[string][]int32{}
+	for topic, partitions := range unchecked {
+		rewind[topic] = map[int32]kgo.EpochOffset{}
+		skip[topic] = map[int32]struct{}{}
+		for partition, p := range partitions {
+			rewind[topic][partition] = kgo.EpochOffset{Epoch: -1, Offset: p.Records[0].Offset}
+			skip[topic][partition] = struct{}{}
+			uncheck[topic] = append(uncheck[topic], partition)
+		}
+	}
+	s.removeTopicPartitions(uncheck)
+	cl.SetOffsets(rewind)
+	return skip, err
+}
+
+func (s *timestampSeeker) seekPartitions(ctx context.Context, cl *kgo.Client, unchecked map[string]map[int32]kgo.FetchPartition) (map[string]map[int32]struct{}, error) {
+	committed := cl.CommittedOffsets()
+	tsMillis := s.timestamp.UnixNano() / int64(time.Millisecond)
+
+	req := kmsg.NewPtrListOffsetsRequest()
+	for topic, partitions := range unchecked {
+		reqTopic := kmsg.NewListOffsetsRequestTopic()
+		reqTopic.Topic = topic
+		for partition, p := range partitions {
+			if _, exists := committed[topic][partition]; exists {
+				continue
+			}
+			if p.Records[0].Timestamp.UnixNano()/int64(time.Millisecond) >= tsMillis {
+				continue
+			}
+			reqPartition := kmsg.NewListOffsetsRequestTopicPartition()
+			reqPartition.Partition = partition
+			reqPartition.Timestamp = tsMillis
+			reqTopic.Partitions = append(reqTopic.Partitions, reqPartition)
+		}
+		if len(reqTopic.Partitions) > 0 {
+			req.Topics = append(req.Topics, reqTopic)
+		}
+	}
+	if len(req.Topics) == 0 {
+		return nil, nil
+	}
+
+	res, err := req.RequestWith(ctx, cl)
+	if err != nil {
+		return nil, err
+	}
+
+	setOffsets := map[string]map[int32]kgo.EpochOffset{}
+	skip := map[string]map[int32]struct{}{}
+	for _, resTopic := range res.Topics {
+		for _, resPartition := range resTopic.Partitions {
+			if err := kerr.ErrorForCode(resPartition.ErrorCode); err != nil {
+				return nil, fmt.Errorf("failed to list offsets of topic %v partition %v: %w", resTopic.Topic, resPartition.Partition, err)
+			}
+			p := unchecked[resTopic.Topic][resPartition.Partition]
+
+			offset := resPartition.Offset
+			if offset < 0 {
+				// No record exists at or after the timestamp.
+				offset = p.HighWatermark
+			}
+			if offset <= p.Records[0].Offset {
+				continue
+			}
+
+			if setOffsets[resTopic.Topic] == nil {
+				setOffsets[resTopic.Topic] = map[int32]kgo.EpochOffset{}
+				skip[resTopic.Topic] = map[int32]struct{}{}
+			}
+			setOffsets[resTopic.Topic][resPartition.Partition] = kgo.EpochOffset{Epoch: -1, Offset: offset}
+			skip[resTopic.Topic][resPartition.Partition] = struct{}{}
+		}
+	}
+	if len(setOffsets) > 0 {
+		cl.SetOffsets(setOffsets)
+	}
+	return skip, nil
+}
+
+func (s *timestampSeeker) removeTopicPartitions(m map[string][]int32) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	for topicName, lostTopic := range m {
+		checkedTopic, exists := s.checked[topicName]
+		if !exists {
+			continue
+		}
+		for _, lostPartition := range lostTopic {
+			delete(checkedTopic, lostPartition)
+		}
+		if len(checkedTopic) == 0 {
+			delete(s.checked, topicName)
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
 func (f *franzKafkaReader) Connect(ctx context.Context) error {
 	if f.getMsgChan() != nil {
 		return nil
@@ -253,14 +516,36 @@ func (f *franzKafkaReader) Connect(ctx context.Context) error {
 		return service.ErrEndOfInput
 	}
 
+	if f.transactionalID != "" {
+		return f.connectTransactional()
+	}
+
 	checkpoints := newCheckpointTracker()
 
+	var seeker *timestampSeeker
+	if !f.startFromTimestamp.IsZero() {
+		seeker = newTimestampSeeker(f.startFromTimestamp)
+	}
+
+	var assignmentGeneration int64
+	resetOffset := kgo.NewOffset().AtStart()
+	if !f.startFromOldest && seeker == nil {
+		resetOffset = kgo.NewOffset().AtEnd()
+	}
+
 	clientOpts := []kgo.Opt{
 		kgo.SeedBrokers(f.seedBrokers...),
 		kgo.ConsumerGroup(f.consumerGroup),
 		kgo.ConsumeTopics(f.topics...),
+		kgo.ConsumeResetOffset(resetOffset),
 		kgo.SASL(f.saslConfs...),
+		kgo.OnPartitionsAssigned(func(_ context.Context, _ *kgo.Client, m map[string][]int32) {
+			generation := atomic.AddInt64(&assignmentGeneration, 1)
+			f.log.Infof("Partitions assigned (generation %v): %v", generation, m)
+		}),
 		kgo.OnPartitionsRevoked(func(rctx context.Context, c *kgo.Client, m map[string][]int32) {
+			f.log.Infof("Partitions revoked: %v", m)
+
 			// Note: this is a best attempt, there's a chance of duplicates if
 			// the checkpoint limit is borked with slow moving pending messages,
 			// but we can't block here, so work with that we have.
@@ -285,14 +570,35 @@ func (f *franzKafkaReader) Connect(ctx context.Context) error {
 				f.log.Errorf("Commit error on partition revoke: %v", commitErr)
 			})
 			checkpoints.removeTopicPartitions(m)
+			if seeker != nil {
+				seeker.removeTopicPartitions(m)
+			}
 		}),
 		kgo.OnPartitionsLost(func(_ context.Context, _ *kgo.Client, m map[string][]int32) {
+			f.log.Warnf("Partitions lost: %v", m)
+
 			// No point trying to commit our offsets, just clean up our topic map
 			checkpoints.removeTopicPartitions(m)
+			if seeker != nil {
+				seeker.removeTopicPartitions(m)
+			}
 		}),
 		kgo.AutoCommitMarks(),
+		kgo.AutoCommitCallback(func(_ *kgo.Client, req *kmsg.OffsetCommitRequest, res *kmsg.OffsetCommitResponse, commitErr error) {
+			if commitErr != nil {
+				f.log.Errorf("Commit error: %v", commitErr)
+				return
+			}
+			f.recordCommits(req, res)
+		}),
 		kgo.WithLogger(&kgoLogger{f.log}),
 	}
+	if f.regexpTopics {
+		clientOpts = append(clientOpts, kgo.ConsumeRegex())
+	}
+	if f.readCommitted {
+		clientOpts = append(clientOpts, kgo.FetchIsolationLevel(kgo.ReadCommitted()))
+	}
 	if f.tlsConf != nil {
 		clientOpts = append(clientOpts, kgo.DialTLSConfig(f.tlsConf))
 	}
@@ -344,11 +650,22 @@ func (f *franzKafkaReader) Connect(ctx context.Context) error {
 				return
 			}
 
+			var skipPartitions map[string]map[int32]struct{}
+			if seeker != nil {
+				var err error
+				if skipPartitions, err = seeker.seek(closeCtx, cl, fetches); err != nil {
+					f.log.Errorf("Failed to seek partitions to start timestamp: %v", err)
+				}
+			}
+
+			records, lags := f.fetchedRecords(fetches, skipPartitions)
+			generation := strconv.FormatInt(atomic.LoadInt64(&assignmentGeneration), 10)
+
 			pauseTopicPartitions := map[string][]int32{}
-			iter := fetches.RecordIter()
-			for !iter.Done() {
-				record := iter.Next()
+			for i, record := range records {
 				msg := recordToMessage(record)
+				msg.MetaSet("kafka_lag", strconv.FormatInt(lags[i], 10))
+				msg.MetaSet("kafka_assignment_generation", generation)
 
 				// The record lives on for checkpointing, but we don't need the
 				// contents going forward so discard these. This looked fine to
@@ -373,7 +690,7 @@ func (f *franzKafkaReader) Connect(ctx context.Context) error {
 				select {
 				case msgChan <- msgWithAckFn{
 					msg: msg,
-					onAck: func() {
+					onAck: func(error) {
 						if maxRec := releaseFn(); maxRec != nil {
 							cl.MarkCommitRecords(maxRec)
 						}
@@ -403,10 +720,65 @@ func (f *franzKafkaReader) Connect(ctx context.Context) error {
 	}()
 
 	f.storeMsgChan(msgChan)
-	f.log.Infof("Receiving messages from Kafka topics: %v", f.topics)
+	if f.regexpTopics {
+		f.log.Infof("Receiving messages from Kafka topics matching: %v", f.topics)
+	} else {
+		f.log.Infof("Receiving messages from Kafka topics: %v", f.topics)
+	}
 	return nil
 }
 
+// fetchedRecords returns the records of a fetch, excluding those of skipped
+// partitions, along with the lag of each record, and updates the lag metrics.
+func (f *franzKafkaReader) fetchedRecords(fetches kgo.Fetches, skipPartitions map[string]map[int32]struct{}) (records []*kgo.Record, lags []int64) {
+	fetches.EachPartition(func(p kgo.FetchTopicPartition) {
+		if len(p.Records) == 0 {
+			return
+		}
+		if _, skip := skipPartitions[p.Topic][p.Partition]; skip {
+			return
+		}
+		for _, record := range p.Records {
+			records = append(records, record)
+			lags = append(lags, lagOf(p.HighWatermark, record.Offset))
+		}
+		f.mLag.Set(lagOf(p.HighWatermark, p.Records[len(p.Records)-1].Offset), p.Topic, strconv.Itoa(int(p.Partition)))
+	})
+	return
+}
+
+// lagOf returns the number of records following an offset in a partition with
+// a given high watermark.
+func lagOf(highWatermark, offset int64) int64 {
+	if lag := highWatermark - offset - 1; lag > 0 {
+		return lag
+	}
+	return 0
+}
+
+// recordCommits updates the committed offset metrics from a successful commit.
+func (f *franzKafkaReader) recordCommits(req *kmsg.OffsetCommitRequest, res *kmsg.OffsetCommitResponse) {
+	committed := map[string]map[int32]int64{}
+	for _, reqTopic := range req.Topics {
+		offsets := map[int32]int64{}
+		for _, reqPartition := range reqTopic.Partitions {
+			offsets[reqPartition.Partition] = reqPartition.Offset
+		}
+		committed[reqTopic.Topic] = offsets
+	}
+	for _, resTopic := range res.Topics {
+		for _, resPartition := range resTopic.Partitions {
+			if err := kerr.ErrorForCode(resPartition.ErrorCode); err != nil {
+				f.log.Errorf("Commit error on topic %v, partition %v: %v", resTopic.Topic, resPartition.Partition, err)
+				continue
+			}
+			if offset, exists := committed[resTopic.Topic][resPartition.Partition]; exists {
+				f.mCommitted.Set(offset, resTopic.Topic, strconv.Itoa(int(resPartition.Partition)))
+			}
+		}
+	}
+}
+
 func recordToMessage(record *kgo.Record) *service.Message {
 	msg := service.NewMessage(record.Value)
 	msg.MetaSet("kafka_key", string(record.Key))
@@ -438,8 +810,9 @@ func (f *franzKafkaReader) Read(ctx context.Context) (*service.Message, service.
 	}
 
 	return mAck.msg, func(ctx context.Context, res error) error {
-		// Res will always be nil because we initialize with service.AutoRetryNacks
-		mAck.onAck()
+		// Res will always be nil unless the input is transactional because
+		// otherwise we initialize with service.AutoRetryNacks
+		mAck.onAck(res)
 		return nil
 	}, nil
 }
@@ -456,6 +829,7 @@ func (f *franzKafkaReader) Close(ctx context.Context) error {
 	select {
 	case <-f.shutSig.HasClosedChan():
 	case <-ctx.Done():
+		f.shutSig.CloseNow()
 		return ctx.Err()
 	}
 	return nil
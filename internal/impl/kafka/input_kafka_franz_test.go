@@ -0,0 +1,214 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestFranzKafkaInputConfig(t *testing.T) {
+	spec := franzKafkaInputConfig()
+	env := service.NewEnvironment()
+
+	conf, err := spec.ParseYAML(`
+seed_brokers: [ localhost:9092 ]
+topics: [ "foo-.*,bar" ]
+regexp_topics: true
+consumer_group: baz
+start_from_timestamp: 2022-01-02T15:04:05Z
+`, env)
+	require.NoError(t, err)
+
+	f, err := newFranzKafkaReaderFromConfig(conf, nil, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"foo-.*", "bar"}, f.topics)
+	assert.True(t, f.regexpTopics)
+	assert.True(t, f.startFromOldest)
+	assert.Equal(t, time.Date(2022, 1, 2, 15, 4, 5, 0, time.UTC), f.startFromTimestamp)
+
+	conf, err = spec.ParseYAML(`
+seed_brokers: [ localhost:9092 ]
+topics: [ "foo-(" ]
+regexp_topics: true
+consumer_group: baz
+`, env)
+	require.NoError(t, err)
+
+	_, err = newFranzKafkaReaderFromConfig(conf, nil, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to compile topic regular expression")
+
+	conf, err = spec.ParseYAML(`
+seed_brokers: [ localhost:9092 ]
+topics: [ foo ]
+consumer_group: baz
+start_from_timestamp: yesterday
+`, env)
+	require.NoError(t, err)
+
+	_, err = newFranzKafkaReaderFromConfig(conf, nil, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse start_from_timestamp")
+}
+
+func TestFranzKafkaLag(t *testing.T) {
+	assert.Equal(t, int64(9), lagOf(10, 0))
+	assert.Equal(t, int64(0), lagOf(10, 9))
+	assert.Equal(t, int64(0), lagOf(0, 5))
+}
+
+func TestTimestampSeekerUncheckedPartitions(t *testing.T) {
+	fetches := kgo.Fetches{
+		{
+			Topics: []kgo.FetchTopic{
+				{
+					Topic: "foo",
+					Partitions: []kgo.FetchPartition{
+						{Partition: 0, HighWatermark: 10, Records: []*kgo.Record{{Topic: "foo", Partition: 0, Offset: 3}}},
+						{Partition: 1, HighWatermark: 10},
+					},
+				},
+				{
+					Topic: "bar",
+					Partitions: []kgo.FetchPartition{
+						{Partition: 2, HighWatermark: 5, Records: []*kgo.Record{{Topic: "bar", Partition: 2, Offset: 1}}},
+					},
+				},
+			},
+		},
+	}
+
+	s := newTimestampSeeker(time.Now())
+
+	unchecked := s.uncheckedPartitions(fetches)
+	require.Len(t, unchecked, 2)
+	assert.Len(t, unchecked["foo"], 1)
+	assert.Equal(t, int64(3), unchecked["foo"][0].Records[0].Offset)
+	assert.Equal(t, int64(5), unchecked["bar"][2].HighWatermark)
+
+	assert.Empty(t, s.uncheckedPartitions(fetches))
+
+	s.removeTopicPartitions(map[string][]int32{"foo": {0}})
+	unchecked = s.uncheckedPartitions(fetches)
+	require.Len(t, unchecked, 1)
+	assert.Contains(t, unchecked["foo"], int32(0))
+}
+
+func TestFranzKafkaTransactionalConfig(t *testing.T) {
+	spec := franzKafkaInputConfig()
+	env := service.NewEnvironment()
+
+	conf, err := spec.ParseYAML(`
+seed_brokers: [ localhost:9092 ]
+topics: [ foo ]
+consumer_group: bar
+transactional_id: baz
+`, env)
+	require.NoError(t, err)
+
+	f, err := newFranzKafkaReaderFromConfig(conf, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "baz", f.transactionalID)
+	assert.True(t, f.readCommitted)
+
+	conf, err = spec.ParseYAML(`
+seed_brokers: [ localhost:9092 ]
+topics: [ foo ]
+consumer_group: bar
+transactional_id: baz
+start_from_timestamp: 2022-01-02T15:04:05Z
+`, env)
+	require.NoError(t, err)
+
+	_, err = newFranzKafkaReaderFromConfig(conf, nil, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "start_from_timestamp cannot be used with transactional_id")
+}
+
+func TestBatchTransactSession(t *testing.T) {
+	sessionA, sessionB := new(kgo.GroupTransactSession), new(kgo.GroupTransactSession)
+	tsA := &transactSession{id: "foo", session: sessionA}
+
+	session, err := batchTransactSession(service.MessageBatch{
+		withTransactSession(service.NewMessage([]byte("a")), tsA),
+		withTransactSession(service.NewMessage([]byte("b")), tsA),
+	}, "foo")
+	require.NoError(t, err)
+	assert.Equal(t, sessionA, session)
+
+	_, err = batchTransactSession(service.MessageBatch{
+		withTransactSession(service.NewMessage([]byte("a")), tsA),
+		withTransactSession(service.NewMessage([]byte("b")), &transactSession{id: "foo", session: sessionB}),
+	}, "foo")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "different transactions")
+
+	_, err = batchTransactSession(service.MessageBatch{
+		withTransactSession(service.NewMessage([]byte("a")), tsA),
+	}, "bar")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "kafka_franz input with transactional_id bar")
+}
+
+func TestFranzKafkaOutputTransactionalNoInput(t *testing.T) {
+	conf, err := franzKafkaOutputConfig().ParseYAML(`
+topic: foo
+transactional_id: nope
+`, service.NewEnvironment())
+	require.NoError(t, err)
+
+	w, err := newFranzKafkaWriterFromConfig(conf, nil)
+	require.NoError(t, err)
+
+	err = w.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte("hello")),
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not consumed within a transaction of a kafka_franz input with transactional_id nope")
+}
+
+func TestFranzKafkaOutputTransactionalConfigErrors(t *testing.T) {
+	tests := map[string]string{
+		"field seed_brokers cannot be used": `seed_brokers: [ localhost:9092 ]`,
+		"field partitioner cannot be used":  `partitioner: round_robin`,
+		"field compression cannot be used":  `compression: gzip`,
+		"field tls cannot be used":          `tls: { enabled: true }`,
+		"field sasl cannot be used":         `sasl: [ { mechanism: PLAIN, username: foo, password: bar } ]`,
+		"must include a period":             `batching: { count: 10 }`,
+	}
+	for errContains, confStr := range tests {
+		conf, err := franzKafkaOutputConfig().ParseYAML(`
+topic: foo
+transactional_id: bar
+`+confStr, service.NewEnvironment())
+		require.NoError(t, err, errContains)
+
+		_, err = newFranzKafkaWriterFromConfig(conf, nil)
+		require.Error(t, err, errContains)
+		assert.Contains(t, err.Error(), errContains)
+	}
+
+	conf, err := franzKafkaOutputConfig().ParseYAML(`
+topic: foo
+transactional_id: bar
+batching: { count: 10, period: 100ms }
+`, service.NewEnvironment())
+	require.NoError(t, err)
+
+	_, err = newFranzKafkaWriterFromConfig(conf, nil)
+	require.NoError(t, err)
+
+	conf, err = franzKafkaOutputConfig().ParseYAML(`topic: foo`, service.NewEnvironment())
+	require.NoError(t, err)
+
+	_, err = newFranzKafkaWriterFromConfig(conf, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "seed_brokers must be specified")
+}
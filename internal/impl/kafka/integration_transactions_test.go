@@ -0,0 +1,133 @@
+package kafka_test
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ory/dockertest/v3"
+	"github.com/ory/dockertest/v3/docker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/benthosdev/benthos/v4/internal/integration"
+	"github.com/benthosdev/benthos/v4/public/service"
+
+	_ "github.com/benthosdev/benthos/v4/public/components/all"
+)
+
+func TestIntegrationKafkaTransactions(t *testing.T) {
+	integration.CheckSkip(t)
+	t.Parallel()
+
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err)
+
+	kafkaPort, err := integration.GetFreePort()
+	require.NoError(t, err)
+
+	kafkaPortStr := strconv.Itoa(kafkaPort)
+
+	options := &dockertest.RunOptions{
+		Repository:   "docker.vectorized.io/vectorized/redpanda",
+		Tag:          "latest",
+		Hostname:     "redpanda",
+		ExposedPorts: []string{"9092"},
+		PortBindings: map[docker.Port][]docker.PortBinding{
+			"9092/tcp": {{HostIP: "", HostPort: kafkaPortStr}},
+		},
+		Cmd: []string{
+			"redpanda", "start", "--smp 1", "--overprovisioned",
+			"--kafka-addr 0.0.0.0:9092",
+			fmt.Sprintf("--advertise-kafka-addr localhost:%v", kafkaPort),
+		},
+	}
+
+	pool.MaxWait = time.Second * 30
+	resource, err := pool.RunWithOptions(options)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, pool.Purge(resource))
+	})
+
+	address := "localhost:" + kafkaPortStr
+
+	resource.Expire(900)
+	require.NoError(t, pool.Retry(func() error {
+		return createKafkaTopic(address, "testingconnection", 1)
+	}))
+	require.NoError(t, createKafkaTopic(address, "txn-source", 4))
+	require.NoError(t, createKafkaTopic(address, "txn-sink", 4))
+
+	ctx, done := context.WithTimeout(context.Background(), time.Minute*2)
+	defer done()
+
+	producer, err := kgo.NewClient(kgo.SeedBrokers(address))
+	require.NoError(t, err)
+	defer producer.Close()
+
+	const total = 1000
+	var records []*kgo.Record
+	for i := 0; i < total; i++ {
+		records = append(records, &kgo.Record{
+			Topic: "topic-txn-source",
+			Value: []byte(strconv.Itoa(i)),
+		})
+	}
+	require.NoError(t, producer.ProduceSync(ctx, records...).FirstErr())
+
+	builder := service.NewStreamBuilder()
+	require.NoError(t, builder.SetYAML(fmt.Sprintf(`
+input:
+  kafka_franz:
+    seed_brokers: [ %[1]v ]
+    topics: [ topic-txn-source ]
+    consumer_group: txn-group
+    transactional_id: txn-test
+
+pipeline:
+  processors:
+    - bloblang: 'root = "processed " + content()'
+
+output:
+  kafka_franz:
+    topic: topic-txn-sink
+    transactional_id: txn-test
+    max_in_flight: 4
+`, address)))
+
+	stream, err := builder.Build()
+	require.NoError(t, err)
+
+	streamDone := make(chan error, 1)
+	go func() {
+		streamDone <- stream.Run(ctx)
+	}()
+
+	consumer, err := kgo.NewClient(
+		kgo.SeedBrokers(address),
+		kgo.ConsumeTopics("topic-txn-sink"),
+		kgo.FetchIsolationLevel(kgo.ReadCommitted()),
+		kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()),
+	)
+	require.NoError(t, err)
+	defer consumer.Close()
+
+	seen := map[string]int{}
+	for len(seen) < total {
+		fetches := consumer.PollFetches(ctx)
+		require.Empty(t, fetches.Errors())
+		fetches.EachRecord(func(r *kgo.Record) {
+			seen[string(r.Value)]++
+		})
+	}
+	for i := 0; i < total; i++ {
+		assert.Equal(t, 1, seen["processed "+strconv.Itoa(i)], i)
+	}
+
+	require.NoError(t, stream.StopWithin(time.Second*10))
+	<-streamDone
+}
@@ -3,6 +3,7 @@ package kafka
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"math"
 	"strings"
@@ -29,12 +30,17 @@ This output is new and experimental, and the existing ` + "`kafka`" + ` input is
 - You like shiny new stuff
 - You are experiencing issues with the existing ` + "`kafka`" + ` output
 - Someone told you to
+
+### Exactly-Once Delivery
+
+When the field ` + "`transactional_id`" + ` is set this output produces messages within the transactions of a ` + "`kafka_franz`" + ` input of the same stream configured with the same ` + "`transactional_id`" + `, committing the messages atomically along with the offsets of the messages they were consumed from. In this mode the connection of the input is used, and therefore the fields ` + "`seed_brokers`" + `, ` + "`partitioner`" + `, ` + "`compression`" + `, ` + "`tls`" + ` and ` + "`sasl`" + ` must not be set, and a batching policy must include a ` + "`period`" + ` as batches are not flushed when a transaction ends. For more information read the documentation of the ` + "`kafka_franz`" + ` input.
 `).
 		Field(service.NewStringListField("seed_brokers").
-			Description("A list of broker addresses to connect to in order to establish connections. If an item of the list contains commas it will be expanded into multiple addresses.").
+			Description("A list of broker addresses to connect to in order to establish connections. If an item of the list contains commas it will be expanded into multiple addresses. This field is required unless `transactional_id` is set.").
 			Example([]string{"localhost:9092"}).
 			Example([]string{"foo:9092", "bar:9092"}).
-			Example([]string{"foo:9092,bar:9092"})).
+			Example([]string{"foo:9092,bar:9092"}).
+			Optional()).
 		Field(service.NewInterpolatedStringField("topic").
 			Description("A topic to write messages to.")).
 		Field(service.NewInterpolatedStringField("key").
@@ -62,6 +68,12 @@ This output is new and experimental, and the existing ` + "`kafka`" + ` input is
 			Description("Optionally set an explicit compression type. The default preference is to use snappy when the broker supports it, and fall back to none if not.").
 			Optional().
 			Advanced()).
+		Field(service.NewStringField("transactional_id").
+			Description("An optional transactional ID of a `kafka_franz` input of the same stream, within whose transactions messages are produced.").
+			Example("billing-enrichment").
+			Optional().
+			Advanced().
+			Version("4.0.0")).
 		Field(service.NewTLSToggledField("tls")).
 		Field(saslField)
 }
@@ -102,6 +114,7 @@ type franzKafkaWriter struct {
 	partitioner      kgo.Partitioner
 	produceMaxBytes  int32
 	compressionPrefs []kgo.CompressionCodec
+	transactionalID  string
 
 	client *kgo.Client
 
@@ -115,14 +128,17 @@ func newFranzKafkaWriterFromConfig(conf *service.ParsedConfig, log *service.Logg
 		shutSig: shutdown.NewSignaller(),
 	}
 
-	brokerList, err := conf.FieldStringList("seed_brokers")
-	if err != nil {
-		return nil, err
-	}
-	for _, b := range brokerList {
-		f.seedBrokers = append(f.seedBrokers, strings.Split(b, ",")...)
+	if conf.Contains("seed_brokers") {
+		brokerList, err := conf.FieldStringList("seed_brokers")
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range brokerList {
+			f.seedBrokers = append(f.seedBrokers, strings.Split(b, ",")...)
+		}
 	}
 
+	var err error
 	if f.topic, err = conf.FieldInterpolatedString("topic"); err != nil {
 		return nil, err
 	}
@@ -193,6 +209,12 @@ func newFranzKafkaWriterFromConfig(conf *service.ParsedConfig, log *service.Logg
 		}
 	}
 
+	if conf.Contains("transactional_id") {
+		if f.transactionalID, err = conf.FieldString("transactional_id"); err != nil {
+			return nil, err
+		}
+	}
+
 	tlsConf, tlsEnabled, err := conf.FieldTLSToggled("tls")
 	if err != nil {
 		return nil, err
@@ -204,12 +226,51 @@ func newFranzKafkaWriterFromConfig(conf *service.ParsedConfig, log *service.Logg
 		return nil, err
 	}
 
+	if f.transactionalID == "" {
+		if len(f.seedBrokers) == 0 {
+			return nil, errors.New("seed_brokers must be specified unless transactional_id is set")
+		}
+		return &f, nil
+	}
+
+	// Messages are produced with the client of the input within transactional
+	// mode, and so fields configuring a client of the output are rejected.
+	if len(f.seedBrokers) > 0 {
+		return nil, errors.New("field seed_brokers cannot be used with transactional_id")
+	}
+	for _, field := range []string{"partitioner", "compression"} {
+		if conf.Contains(field) {
+			return nil, fmt.Errorf("field %v cannot be used with transactional_id", field)
+		}
+	}
+	if tlsEnabled {
+		return nil, errors.New("field tls cannot be used with transactional_id")
+	}
+	if len(f.saslConfs) > 0 {
+		return nil, errors.New("field sasl cannot be used with transactional_id")
+	}
+
+	// A transaction only ends once all of its messages are written, and so a
+	// batch waiting on more messages than a transaction contains is only
+	// flushed by its period.
+	batchPolicy, err := conf.FieldBatchPolicy("batching")
+	if err != nil {
+		return nil, err
+	}
+	if (batchPolicy.Count > 1 || batchPolicy.ByteSize > 0 || batchPolicy.Check != "") && batchPolicy.Period == "" {
+		return nil, errors.New("a batching policy used with transactional_id must include a period")
+	}
 	return &f, nil
 }
 
 //------------------------------------------------------------------------------
 
 func (f *franzKafkaWriter) Connect(ctx context.Context) error {
+	if f.transactionalID != "" {
+		f.log.Infof("Writing messages to Kafka topic %v within transactions of ID: %v", f.topicStr, f.transactionalID)
+		return nil
+	}
+
 	if f.client != nil {
 		return nil
 	}
@@ -242,7 +303,14 @@ func (f *franzKafkaWriter) Connect(ctx context.Context) error {
 }
 
 func (f *franzKafkaWriter) WriteBatch(ctx context.Context, b service.MessageBatch) (err error) {
-	if f.client == nil {
+	var session *kgo.GroupTransactSession
+	if f.transactionalID != "" {
+		// The session is obtained from the messages as the input creates a
+		// new one whenever it reconnects.
+		if session, err = batchTransactSession(b, f.transactionalID); err != nil {
+			return
+		}
+	} else if f.client == nil {
 		return service.ErrNotConnected
 	}
 
@@ -262,9 +330,17 @@ func (f *franzKafkaWriter) WriteBatch(ctx context.Context, b service.MessageBatc
 			})
 			return nil
 		})
+		if session != nil && len(record.Value) > int(f.produceMaxBytes) {
+			return fmt.Errorf("message of %v bytes exceeds max_message_bytes", len(record.Value))
+		}
 		records = append(records, record)
 	}
 
+	if session != nil {
+		err = session.ProduceSync(ctx, records...).FirstErr()
+		return
+	}
+
 	// TODO: This is very cool and allows us to easily return granular errors,
 	// so we should honor travis by doing it.
 	err = f.client.ProduceSync(ctx, records...).FirstErr()
@@ -2,11 +2,14 @@ package mongodb
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 
+	"github.com/benthosdev/benthos/v4/internal/impl/cursor"
 	"github.com/benthosdev/benthos/v4/internal/impl/mongodb/client"
 	"github.com/benthosdev/benthos/v4/public/service"
 )
@@ -16,28 +19,105 @@ func mongoConfigSpec() *service.ConfigSpec {
 		// Stable(). TODO
 		Version("3.64.0").
 		Categories("Services").
-		Summary("Executes a find query and creates a message for each row received.").
-		Description(`Once the rows from the query are exhausted this input shuts down, allowing the pipeline to gracefully terminate (or the next input in a [sequence](/docs/components/inputs/sequence) to execute).`).
+		Summary("Executes a find query and creates a message for each row received, or watches a change stream and creates a message for each change event.").
+		Description(`Once the rows from the query are exhausted this input shuts down, allowing the pipeline to gracefully terminate (or the next input in a [sequence](/docs/components/inputs/sequence) to execute).
+
+### Change Streams
+
+When ` + "`change_stream.enabled`" + ` is set the input instead [watches](https://docs.mongodb.com/manual/changeStreams/) a collection, a database or an entire deployment depending on ` + "`change_stream.scope`" + `, and runs continuously, creating a message for each insert, update, replace and delete event. Change streams require a replica set or sharded cluster.
+
+The contents of each message is the change event marshalled as [extended JSON](https://docs.mongodb.com/manual/reference/mongodb-extended-json/), where the full document of inserts and replacements is found under ` + "`fullDocument`" + `, and the field ` + "`change_stream.full_document`" + ` can be used in order to also include the current version of the document within update events.
+
+When ` + "`change_stream.cursor_cache`" + ` is set the resume token of each event is committed to the [cache resource](/docs/components/caches/about) only once the event, and all events prior to it, have been acknowledged by the output, and when the input is restarted it resumes the stream from the last committed token. This provides at-least-once delivery guarantees, and so in the event of crashes events may be delivered more than once. Without a cursor cache the stream begins from the current time each time the input is started.
+
+The following metadata fields are added to each change event message:
+
+` + "```text" + `
+- mongodb_operation_type
+- mongodb_database
+- mongodb_collection
+- mongodb_document_key
+` + "```" + `
+
+You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#metadata).`).
 		Field(urlField).
-		Field(service.NewStringField("database").Description("The name of the target MongoDB database.")).
-		Field(service.NewStringField("collection").Description("The collection to select from.")).
+		Field(service.NewStringField("database").Description("The name of the target MongoDB database, which is ignored when watching a deployment.").Default("")).
+		Field(service.NewStringField("collection").Description("The collection to select from, which is ignored when watching a database or deployment.").Default("")).
 		Field(service.NewStringField("username").Description("The username to connect to the database.").Default("")).
 		Field(service.NewStringField("password").Description("The password to connect to the database.").Default("")).
-		Field(queryField)
+		Field(queryField).
+		Field(service.NewObjectField("change_stream",
+			service.NewBoolField("enabled").
+				Description("Whether to watch a change stream instead of executing the `query`.").
+				Default(false),
+			service.NewStringEnumField("scope", csScopeCollection, csScopeDatabase, csScopeDeployment).
+				Description("Whether to watch changes to the `collection`, to all collections of the `database`, or to all databases of the deployment.").
+				Default(csScopeCollection),
+			service.NewStringAnnotatedEnumField("full_document", map[string]string{
+				string(options.Default):      "Only include the full document within insert and replace events.",
+				string(options.UpdateLookup): "Also include the most current majority-committed version of the document within update events.",
+				"whenAvailable":              "Include the post-image of the document within update events when one is available (MongoDB 6.0+).",
+				"required":                   "Include the post-image of the document within update events and fail when one is not available (MongoDB 6.0+).",
+			}).
+				Description("Determines whether update events include the full document.").
+				Default(string(options.Default)),
+			service.NewStringListField("operation_types").
+				Description("An optional list of operation types to filter events by. When empty all events are emitted.").
+				Example([]string{"insert", "update", "delete"}).
+				Default([]string{}),
+			service.NewStringField("cursor_cache").
+				Description("An optional [cache resource](/docs/components/caches/about) to persist the resume token of the last acknowledged event within.").
+				Default(""),
+			service.NewStringField("cursor_key").
+				Description("The key to persist the resume token under within the `cursor_cache`. When empty a key is derived from the watched namespace.").
+				Default("").
+				Advanced(),
+			cursor.CheckpointLimitField(),
+			service.NewStringAnnotatedEnumField("json_marshal_mode", map[string]string{
+				string(client.JSONMarshalModeCanonical): "A string format that emphasizes type preservation at the expense of readability and interoperability.",
+				string(client.JSONMarshalModeRelaxed):   "A string format that emphasizes readability and interoperability at the expense of type preservation.",
+			}).
+				Description("Controls the extended JSON format of change event messages.").
+				Default(string(client.JSONMarshalModeRelaxed)).
+				Advanced(),
+		).
+			Description("Watch a [change stream](#change-streams) rather than executing a query.").
+			Version("4.0.0")).
+		Example("Watch a Collection",
+			`
+Here we consume every insert, update and delete made to a collection, including the current version of updated documents, where the resume token of the last event delivered is persisted within a Redis cache so that a restarted pipeline continues from where it left off:`,
+			`
+input:
+  mongodb:
+    url: mongodb://localhost:27017/?replicaSet=rs0
+    database: shop
+    collection: orders
+    change_stream:
+      enabled: true
+      full_document: updateLookup
+      operation_types: [ insert, update, delete ]
+      cursor_cache: resume_tokens
+
+cache_resources:
+  - label: resume_tokens
+    redis:
+      url: tcp://localhost:6379
+`,
+		)
 }
 
 func init() {
 	err := service.RegisterInput(
 		"mongodb", mongoConfigSpec(),
 		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Input, error) {
-			return newMongoInput(conf)
+			return newMongoInput(conf, mgr)
 		})
 	if err != nil {
 		panic(err)
 	}
 }
 
-func newMongoInput(conf *service.ParsedConfig) (service.Input, error) {
+func newMongoInput(conf *service.ParsedConfig, mgr *service.Resources) (service.Input, error) {
 	url, err := conf.FieldString("url")
 	if err != nil {
 		return nil, err
@@ -58,6 +138,32 @@ func newMongoInput(conf *service.ParsedConfig) (service.Input, error) {
 	if err != nil {
 		return nil, err
 	}
+	config := client.Config{
+		URL:        url,
+		Database:   database,
+		Collection: collection,
+		Username:   username,
+		Password:   password,
+	}
+
+	csEnabled, err := conf.FieldBool("change_stream", "enabled")
+	if err != nil {
+		return nil, err
+	}
+	if csEnabled {
+		cs, err := newMongoChangeStreamInput(conf.Namespace("change_stream"), config, mgr)
+		if err != nil {
+			return nil, err
+		}
+		return service.AutoRetryNacks(cs), nil
+	}
+
+	if database == "" || collection == "" {
+		return nil, errors.New("a database and collection must be specified in order to execute a query")
+	}
+	if !conf.Contains("query") {
+		return nil, errors.New("a query must be specified unless change_stream is enabled")
+	}
 	queryExecutor, err := conf.FieldBloblang("query")
 	if err != nil {
 		return nil, err
@@ -70,13 +176,6 @@ func newMongoInput(conf *service.ParsedConfig) (service.Input, error) {
 	if err != nil {
 		return nil, err
 	}
-	config := client.Config{
-		URL:        url,
-		Database:   database,
-		Collection: collection,
-		Username:   username,
-		Password:   password,
-	}
 	return service.AutoRetryNacks(&mongoInput{
 		queryBSON,
 		config, nil, nil}), nil
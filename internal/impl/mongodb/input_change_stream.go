@@ -0,0 +1,308 @@
+package mongodb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/benthosdev/benthos/v4/internal/impl/cursor"
+	"github.com/benthosdev/benthos/v4/internal/impl/mongodb/client"
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	csScopeCollection = "collection"
+	csScopeDatabase   = "database"
+	csScopeDeployment = "deployment"
+)
+
+type mongoChangeStreamInput struct {
+	config         client.Config
+	scope          string
+	fullDocument   options.FullDocument
+	operationTypes []string
+	canonical      bool
+
+	client *mongo.Client
+	stream *mongo.ChangeStream
+	mut    sync.Mutex
+
+	// The resume token of the last event read, which is used in order to
+	// resume the stream after connection problems.
+	resumeToken bson.Raw
+
+	cursorCache *cursor.Cache
+
+	log     *service.Logger
+	shutSig *shutdown.Signaller
+}
+
+func newMongoChangeStreamInput(conf *service.ParsedConfig, config client.Config, mgr *service.Resources) (*mongoChangeStreamInput, error) {
+	m := &mongoChangeStreamInput{
+		config:  config,
+		log:     mgr.Logger(),
+		shutSig: shutdown.NewSignaller(),
+	}
+
+	var err error
+	if m.scope, err = conf.FieldString("scope"); err != nil {
+		return nil, err
+	}
+
+	var namespace string
+	switch m.scope {
+	case csScopeCollection:
+		if config.Database == "" || config.Collection == "" {
+			return nil, errors.New("a database and collection must be specified in order to watch a collection")
+		}
+		namespace = config.Database + "." + config.Collection
+	case csScopeDatabase:
+		if config.Database == "" {
+			return nil, errors.New("a database must be specified in order to watch a database")
+		}
+		namespace = config.Database
+	case csScopeDeployment:
+	default:
+		return nil, fmt.Errorf("unrecognised change stream scope: %v", m.scope)
+	}
+
+	fullDocument, err := conf.FieldString("full_document")
+	if err != nil {
+		return nil, err
+	}
+	m.fullDocument = options.FullDocument(fullDocument)
+
+	if m.operationTypes, err = conf.FieldStringList("operation_types"); err != nil {
+		return nil, err
+	}
+
+	marshalMode, err := conf.FieldString("json_marshal_mode")
+	if err != nil {
+		return nil, err
+	}
+	m.canonical = marshalMode == string(client.JSONMarshalModeCanonical)
+
+	cacheName, err := conf.FieldString("cursor_cache")
+	if err != nil {
+		return nil, err
+	}
+	if cacheName != "" {
+		cursorKey, err := conf.FieldString("cursor_key")
+		if err != nil {
+			return nil, err
+		}
+		if cursorKey == "" {
+			cursorKey = "mongodb_resume_token_" + namespace
+		}
+		checkpointLimit, err := conf.FieldInt("checkpoint_limit")
+		if err != nil {
+			return nil, err
+		}
+		if m.cursorCache, err = cursor.NewCache(mgr, cacheName, cursorKey, checkpointLimit); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// pipeline returns the aggregation pipeline of the change stream, which
+// filters events by their operation type when configured.
+func (m *mongoChangeStreamInput) pipeline() mongo.Pipeline {
+	if len(m.operationTypes) == 0 {
+		return mongo.Pipeline{}
+	}
+	return mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{
+			{Key: "operationType", Value: bson.D{{Key: "$in", Value: m.operationTypes}}},
+		}}},
+	}
+}
+
+func (m *mongoChangeStreamInput) Connect(ctx context.Context) error {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	if m.stream != nil {
+		return nil
+	}
+
+	if m.resumeToken == nil && m.cursorCache != nil {
+		token, err := m.loadCursor(ctx)
+		if err != nil {
+			return err
+		}
+		m.resumeToken = token
+	}
+
+	mClient, err := m.config.Client()
+	if err != nil {
+		return err
+	}
+	if err = mClient.Connect(ctx); err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+
+	opts := options.ChangeStream().SetFullDocument(m.fullDocument)
+	if m.resumeToken != nil {
+		opts = opts.SetResumeAfter(m.resumeToken)
+	}
+
+	var stream *mongo.ChangeStream
+	switch m.scope {
+	case csScopeCollection:
+		stream, err = mClient.Database(m.config.Database).Collection(m.config.Collection).Watch(ctx, m.pipeline(), opts)
+	case csScopeDatabase:
+		stream, err = mClient.Database(m.config.Database).Watch(ctx, m.pipeline(), opts)
+	default:
+		stream, err = mClient.Watch(ctx, m.pipeline(), opts)
+	}
+	if err != nil {
+		_ = mClient.Disconnect(ctx)
+		return fmt.Errorf("failed to open change stream: %w", err)
+	}
+
+	m.client = mClient
+	m.stream = stream
+	if m.resumeToken != nil {
+		m.log.Infof("Resuming MongoDB change stream of scope %v", m.scope)
+	} else {
+		m.log.Infof("Watching MongoDB change stream of scope %v", m.scope)
+	}
+	return nil
+}
+
+// loadCursor obtains the last committed resume token from the cache, or nil if
+// a token has not yet been committed.
+func (m *mongoChangeStreamInput) loadCursor(ctx context.Context) (bson.Raw, error) {
+	tokenBytes, err := m.cursorCache.Load(ctx)
+	if err != nil || tokenBytes == nil {
+		return nil, err
+	}
+
+	var token bson.Raw
+	if err := bson.UnmarshalExtJSON(tokenBytes, true, &token); err != nil {
+		return nil, fmt.Errorf("failed to parse resume token: %w", err)
+	}
+	return token, nil
+}
+
+// disconnect closes the change stream and client, whilst holding the mutex.
+func (m *mongoChangeStreamInput) disconnect(ctx context.Context) {
+	if m.stream != nil {
+		_ = m.stream.Close(ctx)
+		m.stream = nil
+	}
+	if m.client != nil {
+		_ = m.client.Disconnect(ctx)
+		m.client = nil
+	}
+}
+
+func (m *mongoChangeStreamInput) Read(ctx context.Context) (*service.Message, service.AckFunc, error) {
+	ctx, done := m.shutSig.CloseNowCtx(ctx)
+	defer done()
+
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	if m.stream == nil {
+		return nil, nil, service.ErrNotConnected
+	}
+
+	if !m.stream.Next(ctx) {
+		err := m.stream.Err()
+		if ctx.Err() != nil {
+			return nil, nil, ctx.Err()
+		}
+		if err == nil {
+			// The stream has been invalidated, which happens when the watched
+			// collection or database is dropped or renamed.
+			m.disconnect(context.Background())
+			return nil, nil, service.ErrEndOfInput
+		}
+		m.log.Errorf("Change stream failed: %v", err)
+		m.disconnect(context.Background())
+		return nil, nil, service.ErrNotConnected
+	}
+
+	msg, err := changeEventToMessage(m.stream.Current, m.canonical)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	token := append(bson.Raw(nil), m.stream.ResumeToken()...)
+
+	if m.cursorCache == nil {
+		m.resumeToken = token
+		return msg, func(ctx context.Context, err error) error {
+			// Nacks are handled by AutoRetryNacks because we don't have an
+			// explicit ack mechanism right now.
+			return nil
+		}, nil
+	}
+
+	tokenBytes, err := bson.MarshalExtJSON(token, true, false)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to serialise resume token: %w", err)
+	}
+
+	commit, err := m.cursorCache.Track(ctx, json.RawMessage(tokenBytes), 1)
+	if err != nil {
+		// The event was not consumed and so the stream is reopened from the
+		// token of the prior event.
+		m.disconnect(context.Background())
+		return nil, nil, err
+	}
+	m.resumeToken = token
+
+	return msg, func(ctx context.Context, err error) error {
+		// Nacks are handled by AutoRetryNacks, and so the resume token is only
+		// committed once the event has been delivered.
+		return commit(ctx)
+	}, nil
+}
+
+// changeEventToMessage marshals a change event as extended JSON and adds
+// metadata describing the change.
+func changeEventToMessage(event bson.Raw, canonical bool) (*service.Message, error) {
+	eventBytes, err := bson.MarshalExtJSON(event, canonical, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal change event: %w", err)
+	}
+
+	msg := service.NewMessage(eventBytes)
+	if v, err := event.LookupErr("operationType"); err == nil {
+		msg.MetaSet("mongodb_operation_type", v.StringValue())
+	}
+	if v, err := event.LookupErr("ns", "db"); err == nil {
+		msg.MetaSet("mongodb_database", v.StringValue())
+	}
+	if v, err := event.LookupErr("ns", "coll"); err == nil {
+		msg.MetaSet("mongodb_collection", v.StringValue())
+	}
+	if v, err := event.LookupErr("documentKey"); err == nil {
+		if keyDoc, ok := v.DocumentOK(); ok {
+			if keyBytes, err := bson.MarshalExtJSON(keyDoc, canonical, false); err == nil {
+				msg.MetaSet("mongodb_document_key", string(keyBytes))
+			}
+		}
+	}
+	return msg, nil
+}
+
+func (m *mongoChangeStreamInput) Close(ctx context.Context) error {
+	m.shutSig.CloseNow()
+
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	m.disconnect(ctx)
+	return nil
+}
@@ -0,0 +1,136 @@
+package mongodb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/benthosdev/benthos/v4/internal/impl/mongodb/client"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestMongoChangeStreamInputConfigErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		conf   string
+		errStr string
+	}{
+		{
+			name: "no collection",
+			conf: `
+url: "mongodb://localhost:27017"
+database: foo
+change_stream:
+  enabled: true
+`,
+			errStr: "a database and collection must be specified in order to watch a collection",
+		},
+		{
+			name: "no database",
+			conf: `
+url: "mongodb://localhost:27017"
+change_stream:
+  enabled: true
+  scope: database
+`,
+			errStr: "a database must be specified in order to watch a database",
+		},
+		{
+			name: "unknown cache",
+			conf: `
+url: "mongodb://localhost:27017"
+change_stream:
+  enabled: true
+  scope: deployment
+  cursor_cache: nope
+`,
+			errStr: "cache resource 'nope' was not found",
+		},
+		{
+			name: "query without change stream",
+			conf: `
+url: "mongodb://localhost:27017"
+database: foo
+collection: bar
+`,
+			errStr: "a query must be specified unless change_stream is enabled",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			mongoConfig, err := mongoConfigSpec().ParseYAML(test.conf, nil)
+			require.NoError(t, err)
+
+			_, err = newMongoInput(mongoConfig, service.MockResources())
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.errStr)
+		})
+	}
+}
+
+func TestMongoChangeStreamInputPipeline(t *testing.T) {
+	mongoConfig, err := mongoConfigSpec().ParseYAML(`
+url: "mongodb://localhost:27017"
+change_stream:
+  enabled: true
+  scope: deployment
+  operation_types: [ insert, delete ]
+`, nil)
+	require.NoError(t, err)
+
+	m, err := newMongoChangeStreamInput(mongoConfig.Namespace("change_stream"), client.Config{}, service.MockResources())
+	require.NoError(t, err)
+
+	pipelineJSON, err := bson.MarshalExtJSON(bson.D{{Key: "pipeline", Value: m.pipeline()}}, false, false)
+	require.NoError(t, err)
+	assert.Equal(t, `{"pipeline":[{"$match":{"operationType":{"$in":["insert","delete"]}}}]}`, string(pipelineJSON))
+
+	m.operationTypes = nil
+	assert.Equal(t, mongo.Pipeline{}, m.pipeline())
+}
+
+func TestMongoChangeEventToMessage(t *testing.T) {
+	event, err := bson.Marshal(bson.D{
+		{Key: "_id", Value: bson.D{{Key: "_data", Value: "826"}}},
+		{Key: "operationType", Value: "update"},
+		{Key: "ns", Value: bson.D{{Key: "db", Value: "shop"}, {Key: "coll", Value: "orders"}}},
+		{Key: "documentKey", Value: bson.D{{Key: "_id", Value: int32(5)}}},
+		{Key: "fullDocument", Value: bson.D{{Key: "_id", Value: int32(5)}, {Key: "total", Value: 10.5}}},
+	})
+	require.NoError(t, err)
+
+	msg, err := changeEventToMessage(event, false)
+	require.NoError(t, err)
+
+	msgBytes, err := msg.AsBytes()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+	"_id":{"_data":"826"},
+	"operationType":"update",
+	"ns":{"db":"shop","coll":"orders"},
+	"documentKey":{"_id":5},
+	"fullDocument":{"_id":5,"total":10.5}
+}`, string(msgBytes))
+
+	for k, v := range map[string]string{
+		"mongodb_operation_type": "update",
+		"mongodb_database":       "shop",
+		"mongodb_collection":     "orders",
+		"mongodb_document_key":   `{"_id":5}`,
+	} {
+		actual, exists := msg.MetaGet(k)
+		assert.True(t, exists, k)
+		assert.Equal(t, v, actual, k)
+	}
+
+	msg, err = changeEventToMessage(event, true)
+	require.NoError(t, err)
+
+	key, _ := msg.MetaGet("mongodb_document_key")
+	assert.Equal(t, `{"_id":{"$numberInt":"5"}}`, key)
+}
@@ -27,7 +27,7 @@ query: |
 	mongoConfig, err := spec.ParseYAML(conf, env)
 	require.NoError(t, err)
 
-	selectInput, err := newMongoInput(mongoConfig)
+	selectInput, err := newMongoInput(mongoConfig, service.MockResources())
 	require.NoError(t, err)
 	require.NoError(t, selectInput.Close(context.Background()))
 }
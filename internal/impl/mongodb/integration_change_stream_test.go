@@ -0,0 +1,191 @@
+package mongodb_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ory/dockertest/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/benthosdev/benthos/v4/internal/integration"
+	"github.com/benthosdev/benthos/v4/public/service"
+
+	_ "github.com/benthosdev/benthos/v4/public/components/all"
+)
+
+type changeEvent struct {
+	operation string
+	key       string
+}
+
+func TestIntegrationMongoDBChangeStream(t *testing.T) {
+	integration.CheckSkip(t)
+	t.Parallel()
+
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err)
+
+	pool.MaxWait = time.Second * 30
+
+	resource, err := pool.RunWithOptions(&dockertest.RunOptions{
+		Repository:   "mongo",
+		Tag:          "latest",
+		Cmd:          []string{"--replSet", "rs0", "--bind_ip_all"},
+		ExposedPorts: []string{"27017"},
+	})
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		assert.NoError(t, pool.Purge(resource))
+	})
+
+	url := fmt.Sprintf("mongodb://localhost:%v/?directConnection=true", resource.GetPort("27017/tcp"))
+
+	var mongoClient *mongo.Client
+	resource.Expire(900)
+	require.NoError(t, pool.Retry(func() error {
+		if mongoClient, err = mongo.Connect(context.Background(), options.Client().ApplyURI(url)); err != nil {
+			return err
+		}
+		_ = mongoClient.Database("admin").RunCommand(context.Background(), bson.D{
+			{Key: "replSetInitiate", Value: bson.D{
+				{Key: "_id", Value: "rs0"},
+				{Key: "members", Value: bson.A{bson.D{{Key: "_id", Value: 0}, {Key: "host", Value: "localhost:27017"}}}},
+			}},
+		}).Err()
+		var status bson.M
+		if err := mongoClient.Database("admin").RunCommand(context.Background(), bson.D{{Key: "isMaster", Value: 1}}).Decode(&status); err != nil {
+			_ = mongoClient.Disconnect(context.Background())
+			return err
+		}
+		if isPrimary, _ := status["ismaster"].(bool); !isPrimary {
+			_ = mongoClient.Disconnect(context.Background())
+			return fmt.Errorf("replica set not yet initiated")
+		}
+		return nil
+	}))
+	t.Cleanup(func() {
+		_ = mongoClient.Disconnect(context.Background())
+	})
+
+	ctx, done := context.WithTimeout(context.Background(), time.Minute)
+	defer done()
+
+	db := mongoClient.Database("TestDB")
+	require.NoError(t, db.CreateCollection(ctx, "orders"))
+	require.NoError(t, db.CreateCollection(ctx, "resumetokens"))
+	orders := db.Collection("orders")
+
+	var eventsMut sync.Mutex
+	var events []changeEvent
+	var probed bool
+
+	runStream := func() *service.Stream {
+		builder := service.NewStreamBuilder()
+		require.NoError(t, builder.AddResourcesYAML(fmt.Sprintf(`
+cache_resources:
+  - label: tokens
+    mongodb:
+      url: %v
+      database: TestDB
+      collection: resumetokens
+      key_field: key
+      value_field: value
+`, url)))
+		require.NoError(t, builder.AddInputYAML(fmt.Sprintf(`
+mongodb:
+  url: %v
+  database: TestDB
+  collection: orders
+  change_stream:
+    enabled: true
+    full_document: updateLookup
+    cursor_cache: tokens
+`, url)))
+		require.NoError(t, builder.AddConsumerFunc(func(ctx context.Context, m *service.Message) error {
+			op, _ := m.MetaGet("mongodb_operation_type")
+			key, _ := m.MetaGet("mongodb_document_key")
+			if strings.Contains(key, "probe") {
+				eventsMut.Lock()
+				probed = true
+				eventsMut.Unlock()
+				return nil
+			}
+			eventsMut.Lock()
+			events = append(events, changeEvent{operation: op, key: key})
+			eventsMut.Unlock()
+			return nil
+		}))
+
+		stream, err := builder.Build()
+		require.NoError(t, err)
+
+		go func() {
+			_ = stream.Run(context.Background())
+		}()
+		return stream
+	}
+
+	waitForEvents := func(n int) {
+		assert.Eventually(t, func() bool {
+			eventsMut.Lock()
+			defer eventsMut.Unlock()
+			return len(events) >= n
+		}, time.Second*30, time.Millisecond*100)
+	}
+
+	stream := runStream()
+
+	// The change stream is opened asynchronously, and so we keep inserting
+	// probe documents until the first of them is seen.
+	probes := 0
+	require.Eventually(t, func() bool {
+		probes++
+		_, err := orders.InsertOne(ctx, bson.D{{Key: "_id", Value: fmt.Sprintf("probe%v", probes)}})
+		require.NoError(t, err)
+		eventsMut.Lock()
+		defer eventsMut.Unlock()
+		return probed
+	}, time.Second*30, time.Millisecond*500)
+
+	_, err = orders.InsertOne(ctx, bson.D{{Key: "_id", Value: "a"}, {Key: "total", Value: 1}})
+	require.NoError(t, err)
+	_, err = orders.UpdateOne(ctx, bson.D{{Key: "_id", Value: "a"}}, bson.D{{Key: "$set", Value: bson.D{{Key: "total", Value: 2}}}})
+	require.NoError(t, err)
+	_, err = orders.DeleteOne(ctx, bson.D{{Key: "_id", Value: "a"}})
+	require.NoError(t, err)
+
+	waitForEvents(3)
+	require.NoError(t, stream.StopWithin(time.Second*10))
+
+	eventsMut.Lock()
+	assert.Equal(t, []changeEvent{
+		{operation: "insert", key: `{"_id":"a"}`},
+		{operation: "update", key: `{"_id":"a"}`},
+		{operation: "delete", key: `{"_id":"a"}`},
+	}, events)
+	events = nil
+	eventsMut.Unlock()
+
+	// Changes made whilst the input is stopped are consumed once it resumes.
+	_, err = orders.InsertOne(ctx, bson.D{{Key: "_id", Value: "b"}})
+	require.NoError(t, err)
+
+	stream = runStream()
+	waitForEvents(1)
+	require.NoError(t, stream.StopWithin(time.Second*10))
+
+	eventsMut.Lock()
+	assert.Equal(t, []changeEvent{
+		{operation: "insert", key: `{"_id":"b"}`},
+	}, events)
+	eventsMut.Unlock()
+}
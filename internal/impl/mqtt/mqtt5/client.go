@@ -0,0 +1,563 @@
+package mqtt5
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrConnectionLost is returned by calls made on a client after its connection
+// has been lost or closed.
+var ErrConnectionLost = errors.New("connection lost")
+
+// DefaultReceiveMaximum is the receive maximum of a client when one is not
+// specified in its options.
+const DefaultReceiveMaximum = 1024
+
+// ReasonError is returned when the server responds with a failure reason code.
+type ReasonError struct {
+	Code   byte
+	Reason string
+}
+
+func (r *ReasonError) Error() string {
+	if r.Reason != "" {
+		return fmt.Sprintf("reason code %#x: %v", r.Code, r.Reason)
+	}
+	return fmt.Sprintf("reason code %#x", r.Code)
+}
+
+// Options configures a client.
+type Options struct {
+	// Brokers is a list of URLs to attempt connections with in order, where
+	// the schemes tcp and mqtt connect in plain text, and the schemes ssl, tls
+	// and mqtts connect with TLS.
+	Brokers []string
+
+	ClientID       string
+	CleanStart     bool
+	SessionExpiry  uint32
+	KeepAlive      time.Duration
+	ConnectTimeout time.Duration
+	WriteTimeout   time.Duration
+	Username       string
+	Password       string
+	TLSConfig      *tls.Config
+	Will           *Message
+
+	// ReceiveMaximum is the number of QoS 1 and 2 messages that the server
+	// may send before they are acknowledged, which is also the capacity of
+	// the queue of received messages awaiting delivery. When the queue is
+	// full packets are not read from the connection until a message has been
+	// delivered. Defaults to DefaultReceiveMaximum when zero.
+	ReceiveMaximum uint16
+
+	// OnMessage is called for each message received on a subscription, and
+	// must eventually call the acknowledgement function of the message, which
+	// for QoS 1 and 2 messages signals to the server that the message has
+	// been processed. Messages are handled sequentially by a goroutine
+	// separate from the one reading packets, and so blocking within this
+	// function does not prevent the connection from being kept alive.
+	OnMessage func(msg *Message, ack func())
+
+	// OnConnectionLost is called when the connection is lost for any reason
+	// other than a call to Disconnect.
+	OnConnectionLost func(err error)
+}
+
+// Client is a connection to an MQTT 5 server.
+type Client struct {
+	opts Options
+	conn net.Conn
+	rdr  *bufio.Reader
+
+	writeMut sync.Mutex
+
+	idMut    sync.Mutex
+	lastID   uint16
+	inflight map[uint16]chan *Ack
+	subs     map[uint16]chan *Suback
+
+	// Limits the number of unacknowledged QoS 1 and 2 messages we send to the
+	// receive maximum of the server.
+	sendQuota chan struct{}
+
+	// Tracks QoS 2 messages received that have been acknowledged by the
+	// application but not yet released by the server.
+	received map[uint16]struct{}
+
+	// Messages received are queued for delivery so that packets continue to
+	// be read whilst a message is being handled, up to the receive maximum.
+	queue chan *Message
+
+	// Set whilst reading packets is paused due to the queue being full, in
+	// which case ping responses cannot be read.
+	readPaused int32
+
+	pingOutstanding int32
+
+	closeOnce sync.Once
+	closed    chan struct{}
+
+	// Properties of the CONNACK packet received from the server.
+	ConnackProperties Properties
+}
+
+// Dial establishes a connection with the first broker of the options that
+// accepts it.
+func Dial(ctx context.Context, opts Options) (*Client, error) {
+	if len(opts.Brokers) == 0 {
+		return nil, errors.New("at least one broker url must be specified")
+	}
+
+	var errs []error
+	for _, broker := range opts.Brokers {
+		c, err := connectBroker(ctx, opts, broker)
+		if err == nil {
+			return c, nil
+		}
+		errs = append(errs, fmt.Errorf("%v: %w", broker, err))
+	}
+	if len(errs) == 1 {
+		return nil, errs[0]
+	}
+	return nil, fmt.Errorf("failed to connect to any broker: %v", errs)
+}
+
+func dialBroker(ctx context.Context, opts Options, broker string) (net.Conn, error) {
+	u, err := url.Parse(broker)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{Timeout: opts.ConnectTimeout}
+	switch u.Scheme {
+	case "tcp", "mqtt":
+		return dialer.DialContext(ctx, "tcp", u.Host)
+	case "ssl", "tls", "mqtts", "tcps":
+		tlsConf := opts.TLSConfig
+		if tlsConf == nil {
+			tlsConf = &tls.Config{}
+		}
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: tlsConf}
+		return tlsDialer.DialContext(ctx, "tcp", u.Host)
+	}
+	return nil, fmt.Errorf("unsupported scheme: %v", u.Scheme)
+}
+
+func connectBroker(ctx context.Context, opts Options, broker string) (*Client, error) {
+	if opts.ConnectTimeout > 0 {
+		var done func()
+		ctx, done = context.WithTimeout(ctx, opts.ConnectTimeout)
+		defer done()
+	}
+
+	conn, err := dialBroker(ctx, opts, broker)
+	if err != nil {
+		return nil, err
+	}
+
+	receiveMaximum := opts.ReceiveMaximum
+	if receiveMaximum == 0 {
+		receiveMaximum = DefaultReceiveMaximum
+	}
+
+	c := &Client{
+		opts:     opts,
+		conn:     conn,
+		rdr:      bufio.NewReader(conn),
+		inflight: map[uint16]chan *Ack{},
+		subs:     map[uint16]chan *Suback{},
+		received: map[uint16]struct{}{},
+		queue:    make(chan *Message, receiveMaximum),
+		closed:   make(chan struct{}),
+	}
+
+	connect := &Connect{
+		ClientID:   opts.ClientID,
+		CleanStart: opts.CleanStart,
+		KeepAlive:  uint16(opts.KeepAlive / time.Second),
+		Will:       opts.Will,
+	}
+	connect.Properties.ReceiveMaximum = &receiveMaximum
+	if opts.SessionExpiry > 0 {
+		expiry := opts.SessionExpiry
+		connect.Properties.SessionExpiry = &expiry
+	}
+	if opts.Username != "" {
+		username := opts.Username
+		connect.Username = &username
+	}
+	if opts.Password != "" {
+		connect.Password = []byte(opts.Password)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	connack, err := c.handshake(connect)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	_ = conn.SetDeadline(time.Time{})
+
+	c.ConnackProperties = connack.Properties
+	sendMaximum := 65535
+	if connack.Properties.ReceiveMaximum != nil {
+		sendMaximum = int(*connack.Properties.ReceiveMaximum)
+	}
+	c.sendQuota = make(chan struct{}, sendMaximum)
+
+	keepAlive := opts.KeepAlive
+	if connack.Properties.ServerKeepAlive != nil {
+		keepAlive = time.Duration(*connack.Properties.ServerKeepAlive) * time.Second
+	}
+
+	go c.readLoop()
+	go c.deliverLoop()
+	if keepAlive > 0 {
+		go c.pingLoop(keepAlive)
+	}
+	return c, nil
+}
+
+func (c *Client) handshake(connect *Connect) (*Connack, error) {
+	if err := WritePacket(c.conn, connect); err != nil {
+		return nil, err
+	}
+	packet, err := ReadPacket(c.rdr)
+	if err != nil {
+		return nil, err
+	}
+	connack, ok := packet.(*Connack)
+	if !ok {
+		return nil, fmt.Errorf("expected CONNACK packet, received %T", packet)
+	}
+	if connack.ReasonCode >= 0x80 {
+		return nil, &ReasonError{Code: connack.ReasonCode, Reason: connack.Properties.ReasonString}
+	}
+	return connack, nil
+}
+
+func (c *Client) write(packet interface{}) error {
+	c.writeMut.Lock()
+	defer c.writeMut.Unlock()
+
+	if c.opts.WriteTimeout > 0 {
+		_ = c.conn.SetWriteDeadline(time.Now().Add(c.opts.WriteTimeout))
+	}
+	if err := WritePacket(c.conn, packet); err != nil {
+		c.close(fmt.Errorf("failed to write packet: %w", err))
+		return ErrConnectionLost
+	}
+	return nil
+}
+
+// close terminates the connection, notifying the connection lost handler when
+// the cause is not a call to Disconnect.
+func (c *Client) close(err error) {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+		_ = c.conn.Close()
+		if err != nil && c.opts.OnConnectionLost != nil {
+			go c.opts.OnConnectionLost(err)
+		}
+	})
+}
+
+// Done returns a channel that is closed once the connection is terminated.
+func (c *Client) Done() <-chan struct{} {
+	return c.closed
+}
+
+// Disconnect gracefully terminates the connection.
+func (c *Client) Disconnect() error {
+	select {
+	case <-c.closed:
+		return nil
+	default:
+	}
+	err := c.write(&Disconnect{})
+	c.close(nil)
+	return err
+}
+
+// nextID reserves a free packet identifier.
+func (c *Client) nextID() (uint16, error) {
+	c.idMut.Lock()
+	defer c.idMut.Unlock()
+
+	for i := 0; i < 65535; i++ {
+		c.lastID++
+		if c.lastID == 0 {
+			c.lastID = 1
+		}
+		_, pubExists := c.inflight[c.lastID]
+		_, subExists := c.subs[c.lastID]
+		if !pubExists && !subExists {
+			return c.lastID, nil
+		}
+	}
+	return 0, errors.New("no packet identifiers available")
+}
+
+// Subscribe subscribes to a list of topic filters and blocks until the server
+// acknowledges the subscriptions.
+func (c *Client) Subscribe(ctx context.Context, subs ...Subscription) error {
+	id, err := c.nextID()
+	if err != nil {
+		return err
+	}
+
+	resChan := make(chan *Suback, 1)
+	c.idMut.Lock()
+	c.subs[id] = resChan
+	c.idMut.Unlock()
+	defer func() {
+		c.idMut.Lock()
+		delete(c.subs, id)
+		c.idMut.Unlock()
+	}()
+
+	if err := c.write(&Subscribe{PacketID: id, Subscriptions: subs}); err != nil {
+		return err
+	}
+
+	select {
+	case suback := <-resChan:
+		for i, code := range suback.ReasonCodes {
+			if code >= 0x80 {
+				topic := ""
+				if i < len(subs) {
+					topic = subs[i].Topic
+				}
+				return fmt.Errorf("subscription to '%v' rejected: %w", topic, &ReasonError{Code: code, Reason: suback.Properties.ReasonString})
+			}
+		}
+		return nil
+	case <-c.closed:
+		return ErrConnectionLost
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Publish sends a message and blocks until it has been delivered according to
+// its QoS level, QoS 0 messages are considered delivered once written.
+func (c *Client) Publish(ctx context.Context, msg *Message) error {
+	if msg.QoS == 0 {
+		return c.write(&Publish{Message: *msg})
+	}
+
+	select {
+	case c.sendQuota <- struct{}{}:
+	case <-c.closed:
+		return ErrConnectionLost
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() {
+		<-c.sendQuota
+	}()
+
+	id, err := c.nextID()
+	if err != nil {
+		return err
+	}
+
+	ackChan := make(chan *Ack, 2)
+	c.idMut.Lock()
+	c.inflight[id] = ackChan
+	c.idMut.Unlock()
+	defer func() {
+		c.idMut.Lock()
+		delete(c.inflight, id)
+		c.idMut.Unlock()
+	}()
+
+	pub := &Publish{Message: *msg}
+	pub.PacketID = id
+	if err := c.write(pub); err != nil {
+		return err
+	}
+
+	awaitAck := func(ackType byte) error {
+		select {
+		case ack := <-ackChan:
+			if ack.Type != ackType {
+				return fmt.Errorf("unexpected acknowledgement type %v", ack.Type)
+			}
+			if ack.ReasonCode >= 0x80 {
+				return &ReasonError{Code: ack.ReasonCode, Reason: ack.Properties.ReasonString}
+			}
+			return nil
+		case <-c.closed:
+			return ErrConnectionLost
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if msg.QoS == 1 {
+		return awaitAck(TypePuback)
+	}
+	if err := awaitAck(TypePubrec); err != nil {
+		return err
+	}
+	if err := c.write(&Ack{Type: TypePubrel, PacketID: id}); err != nil {
+		return err
+	}
+	return awaitAck(TypePubcomp)
+}
+
+func (c *Client) pingLoop(keepAlive time.Duration) {
+	ticker := time.NewTicker(keepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-c.closed:
+			return
+		}
+		// Responses are not read whilst reading is paused, and so are only
+		// expected once it resumes.
+		if !atomic.CompareAndSwapInt32(&c.pingOutstanding, 0, 1) && atomic.LoadInt32(&c.readPaused) == 0 {
+			c.close(errors.New("ping response not received within keep alive period"))
+			return
+		}
+		if err := c.write(&Pingreq{}); err != nil {
+			return
+		}
+	}
+}
+
+func (c *Client) readLoop() {
+	for {
+		packet, err := ReadPacket(c.rdr)
+		if err != nil {
+			c.close(fmt.Errorf("failed to read packet: %w", err))
+			return
+		}
+		atomic.StoreInt32(&c.pingOutstanding, 0)
+
+		switch p := packet.(type) {
+		case *Publish:
+			if !c.enqueue(&p.Message) {
+				return
+			}
+		case *Ack:
+			switch p.Type {
+			case TypePubrel:
+				c.idMut.Lock()
+				delete(c.received, p.PacketID)
+				c.idMut.Unlock()
+				_ = c.write(&Ack{Type: TypePubcomp, PacketID: p.PacketID})
+			default:
+				c.idMut.Lock()
+				ackChan, exists := c.inflight[p.PacketID]
+				c.idMut.Unlock()
+				if exists {
+					ackChan <- p
+				}
+			}
+		case *Suback:
+			c.idMut.Lock()
+			resChan, exists := c.subs[p.PacketID]
+			c.idMut.Unlock()
+			if exists {
+				resChan <- p
+			}
+		case *Pingresp:
+		case *Disconnect:
+			c.close(fmt.Errorf("disconnected by server: %w", &ReasonError{Code: p.ReasonCode, Reason: p.Properties.ReasonString}))
+			return
+		default:
+			c.close(fmt.Errorf("unexpected packet type: %T", packet))
+			return
+		}
+	}
+}
+
+// enqueue adds a received message to the queue of messages to deliver,
+// blocking whilst the queue is full so that no further packets are read until
+// a message has been delivered. Returns false if the connection is closed
+// whilst blocked.
+func (c *Client) enqueue(msg *Message) bool {
+	select {
+	case c.queue <- msg:
+		return true
+	default:
+	}
+
+	atomic.StoreInt32(&c.readPaused, 1)
+	defer atomic.StoreInt32(&c.readPaused, 0)
+
+	select {
+	case c.queue <- msg:
+		return true
+	case <-c.closed:
+		return false
+	}
+}
+
+// deliverLoop delivers queued messages in the order they were received until
+// the connection is terminated. Messages remaining in the queue at that point
+// have not been acknowledged and are therefore redelivered by the server.
+func (c *Client) deliverLoop() {
+	for {
+		select {
+		case msg := <-c.queue:
+			select {
+			case <-c.closed:
+				return
+			default:
+			}
+			c.deliver(msg)
+		case <-c.closed:
+			return
+		}
+	}
+}
+
+func (c *Client) deliver(msg *Message) {
+	var ackOnce sync.Once
+	ack := func() {
+		ackOnce.Do(func() {
+			switch msg.QoS {
+			case 1:
+				_ = c.write(&Ack{Type: TypePuback, PacketID: msg.PacketID})
+			case 2:
+				c.idMut.Lock()
+				c.received[msg.PacketID] = struct{}{}
+				c.idMut.Unlock()
+				_ = c.write(&Ack{Type: TypePubrec, PacketID: msg.PacketID})
+			}
+		})
+	}
+	if msg.QoS == 2 {
+		c.idMut.Lock()
+		_, duplicate := c.received[msg.PacketID]
+		c.idMut.Unlock()
+		if duplicate {
+			// The message has already been delivered and acknowledged, but the
+			// server has not seen our PUBREC.
+			ack()
+			return
+		}
+	}
+	if c.opts.OnMessage == nil {
+		ack()
+		return
+	}
+
+	c.opts.OnMessage(msg, ack)
+}
@@ -0,0 +1,374 @@
+package mqtt5_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/impl/mqtt/mqtt5"
+	"github.com/benthosdev/benthos/v4/internal/impl/mqtt/mqtt5/mqtt5test"
+)
+
+func TestPacketRoundTrip(t *testing.T) {
+	expiry := uint32(60)
+	format := byte(1)
+	username := "foo"
+
+	packets := []interface{}{
+		&mqtt5.Connect{
+			ClientID:   "client",
+			CleanStart: true,
+			KeepAlive:  30,
+			Username:   &username,
+			Password:   []byte("bar"),
+			Will: &mqtt5.Message{
+				Topic:   "will",
+				Payload: []byte("gone"),
+				QoS:     1,
+				Retain:  true,
+				Properties: mqtt5.Properties{
+					User: []mqtt5.UserProperty{{Key: "a", Value: "b"}},
+				},
+			},
+			Properties: mqtt5.Properties{SessionExpiry: &expiry},
+		},
+		&mqtt5.Connack{SessionPresent: true, Properties: mqtt5.Properties{AssignedClientID: "abc"}},
+		&mqtt5.Publish{Message: mqtt5.Message{
+			Topic:     "foo/bar",
+			Payload:   []byte("hello world"),
+			QoS:       2,
+			Retain:    true,
+			Duplicate: true,
+			PacketID:  7,
+			Properties: mqtt5.Properties{
+				PayloadFormat:   &format,
+				MessageExpiry:   &expiry,
+				ContentType:     "text/plain",
+				ResponseTopic:   "replies",
+				CorrelationData: []byte("corr"),
+				User: []mqtt5.UserProperty{
+					{Key: "a", Value: "1"},
+					{Key: "a", Value: "2"},
+				},
+			},
+		}},
+		&mqtt5.Publish{Message: mqtt5.Message{Topic: "foo", Payload: []byte{}}},
+		&mqtt5.Ack{Type: mqtt5.TypePubrel, PacketID: 3, ReasonCode: 0x92},
+		&mqtt5.Subscribe{PacketID: 4, Subscriptions: []mqtt5.Subscription{
+			{Topic: "$share/group/foo/+", QoS: 1},
+			{Topic: "bar/#", QoS: 2},
+		}},
+		&mqtt5.Suback{PacketID: 4, ReasonCodes: []byte{1, 0x80}},
+		&mqtt5.Pingreq{},
+		&mqtt5.Pingresp{},
+		&mqtt5.Disconnect{ReasonCode: 0x04},
+	}
+
+	for _, p := range packets {
+		var buf bytes.Buffer
+		require.NoError(t, mqtt5.WritePacket(&buf, p))
+
+		decoded, err := mqtt5.ReadPacket(bufio.NewReader(&buf))
+		require.NoError(t, err)
+		assert.Equal(t, p, decoded)
+		assert.Equal(t, 0, buf.Len())
+	}
+}
+
+func TestReadPacketMalformed(t *testing.T) {
+	for _, b := range [][]byte{
+		{0x30, 0x03, 0x00, 0x05, 'a'},
+		{0x30, 0xFF, 0xFF, 0xFF, 0xFF, 0x01},
+		{0x20, 0x03, 0x00, 0x00, 0x02},
+	} {
+		_, err := mqtt5.ReadPacket(bufio.NewReader(bytes.NewReader(b)))
+		assert.Error(t, err, "%v", b)
+	}
+}
+
+type received struct {
+	msg *mqtt5.Message
+	ack func()
+}
+
+func connectClient(t *testing.T, broker *mqtt5test.Broker, id string, msgChan chan received) *mqtt5.Client {
+	t.Helper()
+
+	opts := mqtt5.Options{
+		Brokers:        []string{broker.URL},
+		ClientID:       id,
+		CleanStart:     true,
+		KeepAlive:      time.Second,
+		ConnectTimeout: time.Second,
+	}
+	if msgChan != nil {
+		opts.OnMessage = func(msg *mqtt5.Message, ack func()) {
+			msgChan <- received{msg: msg, ack: ack}
+		}
+	}
+
+	c, err := mqtt5.Dial(context.Background(), opts)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = c.Disconnect()
+	})
+	return c
+}
+
+func TestClientPublishSubscribe(t *testing.T) {
+	broker, err := mqtt5test.NewBroker()
+	require.NoError(t, err)
+	defer broker.Close()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	msgChan := make(chan received, 10)
+	sub := connectClient(t, broker, "sub", msgChan)
+	require.NoError(t, sub.Subscribe(ctx, mqtt5.Subscription{Topic: "foo/+", QoS: 2}))
+
+	pub := connectClient(t, broker, "pub", nil)
+
+	expiry := uint32(120)
+	for qos := byte(0); qos <= 2; qos++ {
+		require.NoError(t, pub.Publish(ctx, &mqtt5.Message{
+			Topic:   "foo/bar",
+			Payload: []byte{'0' + qos},
+			QoS:     qos,
+			Properties: mqtt5.Properties{
+				MessageExpiry:   &expiry,
+				ResponseTopic:   "replies",
+				CorrelationData: []byte("corr"),
+				User:            []mqtt5.UserProperty{{Key: "source", Value: "test"}},
+			},
+		}))
+	}
+	require.NoError(t, pub.Publish(ctx, &mqtt5.Message{Topic: "baz", Payload: []byte("nope")}))
+
+	for qos := byte(0); qos <= 2; qos++ {
+		select {
+		case r := <-msgChan:
+			assert.Equal(t, "foo/bar", r.msg.Topic)
+			assert.Equal(t, []byte{'0' + qos}, r.msg.Payload)
+			assert.Equal(t, qos, r.msg.QoS)
+			assert.Equal(t, "replies", r.msg.Properties.ResponseTopic)
+			assert.Equal(t, []byte("corr"), r.msg.Properties.CorrelationData)
+			assert.Equal(t, []mqtt5.UserProperty{{Key: "source", Value: "test"}}, r.msg.Properties.User)
+			require.NotNil(t, r.msg.Properties.MessageExpiry)
+			assert.Equal(t, expiry, *r.msg.Properties.MessageExpiry)
+			r.ack()
+		case <-ctx.Done():
+			t.Fatal("timed out")
+		}
+	}
+
+	assert.Eventually(t, func() bool {
+		return len(broker.Acked()) == 2
+	}, time.Second*5, time.Millisecond*10)
+
+	select {
+	case r := <-msgChan:
+		t.Errorf("unexpected message: %s", r.msg.Payload)
+	case <-time.After(time.Millisecond * 50):
+	}
+}
+
+func TestClientSharedSubscription(t *testing.T) {
+	broker, err := mqtt5test.NewBroker()
+	require.NoError(t, err)
+	defer broker.Close()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	var mut sync.Mutex
+	counts := map[string]int{}
+
+	for _, id := range []string{"a", "b"} {
+		id := id
+		msgChan := make(chan received)
+		c := connectClient(t, broker, id, msgChan)
+		require.NoError(t, c.Subscribe(ctx, mqtt5.Subscription{Topic: "$share/workers/jobs", QoS: 1}))
+		go func() {
+			for r := range msgChan {
+				mut.Lock()
+				counts[id]++
+				mut.Unlock()
+				r.ack()
+			}
+		}()
+	}
+
+	pub := connectClient(t, broker, "pub", nil)
+	for i := 0; i < 10; i++ {
+		require.NoError(t, pub.Publish(ctx, &mqtt5.Message{Topic: "jobs", Payload: []byte("job"), QoS: 1}))
+	}
+
+	assert.Eventually(t, func() bool {
+		mut.Lock()
+		defer mut.Unlock()
+		return counts["a"]+counts["b"] == 10
+	}, time.Second*5, time.Millisecond*10)
+
+	mut.Lock()
+	assert.Equal(t, map[string]int{"a": 5, "b": 5}, counts)
+	mut.Unlock()
+}
+
+func TestClientKeepAliveWhilstDelivering(t *testing.T) {
+	broker, err := mqtt5test.NewBroker()
+	require.NoError(t, err)
+	defer broker.Close()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	msgChan := make(chan received)
+	sub := connectClient(t, broker, "sub", msgChan)
+	require.NoError(t, sub.Subscribe(ctx, mqtt5.Subscription{Topic: "foo", QoS: 1}))
+
+	pub := connectClient(t, broker, "pub", nil)
+	for _, payload := range []string{"a", "b"} {
+		require.NoError(t, pub.Publish(ctx, &mqtt5.Message{Topic: "foo", Payload: []byte(payload)}))
+	}
+
+	// Delivery is blocked for longer than the keep alive period, which must
+	// not cause the connection to be dropped.
+	select {
+	case <-sub.Done():
+		t.Fatal("connection lost whilst delivering")
+	case <-time.After(time.Millisecond * 2500):
+	}
+
+	for _, payload := range []string{"a", "b"} {
+		select {
+		case r := <-msgChan:
+			assert.Equal(t, payload, string(r.msg.Payload))
+			r.ack()
+		case <-ctx.Done():
+			t.Fatal("timed out")
+		}
+	}
+}
+
+func TestClientReceiveMaximum(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	const total = 10
+
+	connectChan := make(chan *mqtt5.Connect, 1)
+	written := make(chan struct{})
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		p, err := mqtt5.ReadPacket(bufio.NewReader(conn))
+		if err != nil {
+			return
+		}
+		connectChan <- p.(*mqtt5.Connect)
+		if err := mqtt5.WritePacket(conn, &mqtt5.Connack{}); err != nil {
+			return
+		}
+
+		// Ping requests are never responded to, and so the client relies on
+		// reading being paused in order to keep the connection.
+		for i := 0; i < total; i++ {
+			if err := mqtt5.WritePacket(conn, &mqtt5.Publish{Message: mqtt5.Message{
+				Topic:   "foo",
+				Payload: []byte{byte('a' + i)},
+			}}); err != nil {
+				return
+			}
+		}
+		close(written)
+		_, _ = conn.Read(make([]byte, 1024))
+		time.Sleep(time.Second * 10)
+	}()
+
+	msgChan := make(chan *mqtt5.Message)
+	c, err := mqtt5.Dial(context.Background(), mqtt5.Options{
+		Brokers:        []string{"tcp://" + listener.Addr().String()},
+		ClientID:       "foo",
+		KeepAlive:      time.Second,
+		ConnectTimeout: time.Second,
+		ReceiveMaximum: 2,
+		OnMessage: func(msg *mqtt5.Message, ack func()) {
+			msgChan <- msg
+			ack()
+		},
+	})
+	require.NoError(t, err)
+	defer c.Disconnect()
+
+	connect := <-connectChan
+	require.NotNil(t, connect.Properties.ReceiveMaximum)
+	assert.Equal(t, uint16(2), *connect.Properties.ReceiveMaximum)
+
+	<-written
+
+	// Delivery is blocked for longer than the keep alive period with the
+	// queue full.
+	select {
+	case <-c.Done():
+		t.Fatal("connection lost whilst reading was paused")
+	case <-time.After(time.Millisecond * 2500):
+	}
+
+	for i := 0; i < total; i++ {
+		select {
+		case msg := <-msgChan:
+			assert.Equal(t, []byte{byte('a' + i)}, msg.Payload)
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out")
+		}
+	}
+}
+
+func TestClientConnectionLost(t *testing.T) {
+	broker, err := mqtt5test.NewBroker()
+	require.NoError(t, err)
+	defer broker.Close()
+
+	lostChan := make(chan error, 1)
+	c, err := mqtt5.Dial(context.Background(), mqtt5.Options{
+		Brokers:  []string{broker.URL},
+		ClientID: "foo",
+		OnConnectionLost: func(err error) {
+			lostChan <- err
+		},
+	})
+	require.NoError(t, err)
+
+	broker.DisconnectClients()
+
+	select {
+	case err := <-lostChan:
+		assert.Error(t, err)
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+
+	<-c.Done()
+	assert.Equal(t, mqtt5.ErrConnectionLost, c.Publish(context.Background(), &mqtt5.Message{Topic: "foo", QoS: 1}))
+}
+
+func TestClientConnectBadScheme(t *testing.T) {
+	_, err := mqtt5.Dial(context.Background(), mqtt5.Options{
+		Brokers: []string{"ws://localhost:1883"},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported scheme")
+}
@@ -0,0 +1,295 @@
+// Package mqtt5test provides a minimal in-memory MQTT 5 broker for use within
+// tests, in the spirit of net/http/httptest.
+package mqtt5test
+
+import (
+	"bufio"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/benthosdev/benthos/v4/internal/impl/mqtt/mqtt5"
+)
+
+// Broker is an MQTT 5 broker that routes published messages to subscribers,
+// including shared subscriptions of the form $share/<group>/<filter> where
+// each message is delivered to only one member of a group. Sessions are not
+// persisted and retained messages are not supported.
+type Broker struct {
+	// URL is the address of the broker of the form tcp://host:port.
+	URL string
+
+	listener net.Listener
+
+	mut     sync.Mutex
+	clients map[*brokerClient]struct{}
+	acked   []string
+	shareRR map[string]int
+	wg      sync.WaitGroup
+}
+
+type brokerSub struct {
+	filter string
+	group  string
+	qos    byte
+}
+
+type brokerClient struct {
+	id   string
+	conn net.Conn
+	out  chan interface{}
+	done chan struct{}
+
+	// Fields protected by the broker mutex.
+	subs    []brokerSub
+	lastID  uint16
+	pending map[uint16]string
+}
+
+// send queues a packet to be written to the client, packets are written in
+// the order that they are queued.
+func (c *brokerClient) send(packet interface{}) {
+	select {
+	case c.out <- packet:
+	case <-c.done:
+	}
+}
+
+func (c *brokerClient) writeLoop() {
+	for {
+		select {
+		case packet := <-c.out:
+			if err := mqtt5.WritePacket(c.conn, packet); err != nil {
+				_ = c.conn.Close()
+				return
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// NewBroker starts a broker listening on a random local port.
+func NewBroker() (*Broker, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	b := &Broker{
+		URL:      "tcp://" + listener.Addr().String(),
+		listener: listener,
+		clients:  map[*brokerClient]struct{}{},
+		shareRR:  map[string]int{},
+	}
+	b.wg.Add(1)
+	go b.accept()
+	return b, nil
+}
+
+// Close stops the broker and terminates all client connections.
+func (b *Broker) Close() {
+	_ = b.listener.Close()
+	b.DisconnectClients()
+	b.wg.Wait()
+}
+
+// DisconnectClients terminates all client connections without stopping the
+// broker.
+func (b *Broker) DisconnectClients() {
+	b.mut.Lock()
+	for c := range b.clients {
+		_ = c.conn.Close()
+	}
+	b.mut.Unlock()
+}
+
+// Acked returns the topics of messages delivered to subscribers at QoS 1 or 2
+// that have since been acknowledged, in the order of acknowledgement.
+func (b *Broker) Acked() []string {
+	b.mut.Lock()
+	defer b.mut.Unlock()
+	return append([]string{}, b.acked...)
+}
+
+func (b *Broker) accept() {
+	defer b.wg.Done()
+	for {
+		conn, err := b.listener.Accept()
+		if err != nil {
+			return
+		}
+		b.wg.Add(1)
+		go func() {
+			defer b.wg.Done()
+			b.serve(conn)
+		}()
+	}
+}
+
+func (b *Broker) serve(conn net.Conn) {
+	c := &brokerClient{
+		conn:    conn,
+		out:     make(chan interface{}, 1024),
+		done:    make(chan struct{}),
+		pending: map[uint16]string{},
+	}
+	go c.writeLoop()
+
+	b.mut.Lock()
+	b.clients[c] = struct{}{}
+	b.mut.Unlock()
+
+	defer func() {
+		b.mut.Lock()
+		delete(b.clients, c)
+		b.mut.Unlock()
+		close(c.done)
+		_ = conn.Close()
+	}()
+
+	rdr := bufio.NewReader(conn)
+	for {
+		packet, err := mqtt5.ReadPacket(rdr)
+		if err != nil {
+			return
+		}
+
+		switch p := packet.(type) {
+		case *mqtt5.Connect:
+			connack := &mqtt5.Connack{}
+			b.mut.Lock()
+			c.id = p.ClientID
+			if c.id == "" {
+				c.id = conn.RemoteAddr().String()
+				connack.Properties.AssignedClientID = c.id
+			}
+			b.mut.Unlock()
+			c.send(connack)
+		case *mqtt5.Subscribe:
+			codes := make([]byte, 0, len(p.Subscriptions))
+			b.mut.Lock()
+			for _, s := range p.Subscriptions {
+				sub := brokerSub{filter: s.Topic, qos: s.QoS}
+				if strings.HasPrefix(s.Topic, "$share/") {
+					parts := strings.SplitN(s.Topic, "/", 3)
+					if len(parts) != 3 || parts[1] == "" {
+						codes = append(codes, 0x8F)
+						continue
+					}
+					sub.group, sub.filter = parts[1], parts[2]
+				}
+				c.subs = append(c.subs, sub)
+				codes = append(codes, s.QoS)
+			}
+			b.mut.Unlock()
+			c.send(&mqtt5.Suback{PacketID: p.PacketID, ReasonCodes: codes})
+		case *mqtt5.Publish:
+			switch p.QoS {
+			case 1:
+				c.send(&mqtt5.Ack{Type: mqtt5.TypePuback, PacketID: p.PacketID})
+			case 2:
+				c.send(&mqtt5.Ack{Type: mqtt5.TypePubrec, PacketID: p.PacketID})
+			}
+			b.route(p.Message)
+		case *mqtt5.Ack:
+			switch p.Type {
+			case mqtt5.TypePubrel:
+				c.send(&mqtt5.Ack{Type: mqtt5.TypePubcomp, PacketID: p.PacketID})
+			case mqtt5.TypePuback, mqtt5.TypePubrec:
+				b.mut.Lock()
+				if topic, exists := c.pending[p.PacketID]; exists {
+					b.acked = append(b.acked, topic)
+					delete(c.pending, p.PacketID)
+				}
+				b.mut.Unlock()
+				if p.Type == mqtt5.TypePubrec {
+					c.send(&mqtt5.Ack{Type: mqtt5.TypePubrel, PacketID: p.PacketID})
+				}
+			}
+		case *mqtt5.Pingreq:
+			c.send(&mqtt5.Pingresp{})
+		case *mqtt5.Disconnect:
+			return
+		}
+	}
+}
+
+type delivery struct {
+	client *brokerClient
+	qos    byte
+}
+
+// route delivers a message to all matching subscribers, where only one member
+// of each shared subscription group receives it.
+func (b *Broker) route(msg mqtt5.Message) {
+	b.mut.Lock()
+	defer b.mut.Unlock()
+
+	var deliveries []delivery
+	groups := map[string][]delivery{}
+
+	for c := range b.clients {
+		for _, s := range c.subs {
+			if !topicMatches(s.filter, msg.Topic) {
+				continue
+			}
+			qos := s.qos
+			if msg.QoS < qos {
+				qos = msg.QoS
+			}
+			if s.group != "" {
+				key := s.group + "/" + s.filter
+				groups[key] = append(groups[key], delivery{client: c, qos: qos})
+				continue
+			}
+			deliveries = append(deliveries, delivery{client: c, qos: qos})
+			break
+		}
+	}
+	for key, members := range groups {
+		// Clients are held within a map and so members are sorted by client ID
+		// in order for the round robin to be stable.
+		sort.Slice(members, func(i, j int) bool {
+			return members[i].client.id < members[j].client.id
+		})
+		deliveries = append(deliveries, members[b.shareRR[key]%len(members)])
+		b.shareRR[key]++
+	}
+
+	for _, d := range deliveries {
+		out := &mqtt5.Publish{Message: msg}
+		out.QoS = d.qos
+		out.Duplicate = false
+		out.Retain = false
+		out.PacketID = 0
+		if d.qos > 0 {
+			d.client.lastID++
+			if d.client.lastID == 0 {
+				d.client.lastID = 1
+			}
+			out.PacketID = d.client.lastID
+			d.client.pending[out.PacketID] = msg.Topic
+		}
+		d.client.send(out)
+	}
+}
+
+// topicMatches returns whether a topic name matches a topic filter, which may
+// contain the wildcards + and #.
+func topicMatches(filter, topic string) bool {
+	filterLevels := strings.Split(filter, "/")
+	topicLevels := strings.Split(topic, "/")
+	for i, f := range filterLevels {
+		if f == "#" {
+			return true
+		}
+		if i >= len(topicLevels) {
+			return false
+		}
+		if f != "+" && f != topicLevels[i] {
+			return false
+		}
+	}
+	return len(filterLevels) == len(topicLevels)
+}
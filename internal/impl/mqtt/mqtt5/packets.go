@@ -0,0 +1,644 @@
+// Package mqtt5 implements the subset of the MQTT 5 protocol required by the
+// mqtt input and output, which is the encoding and decoding of control packets
+// and a client capable of subscribing and publishing at any QoS level.
+package mqtt5
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Control packet types.
+const (
+	TypeConnect    byte = 1
+	TypeConnack    byte = 2
+	TypePublish    byte = 3
+	TypePuback     byte = 4
+	TypePubrec     byte = 5
+	TypePubrel     byte = 6
+	TypePubcomp    byte = 7
+	TypeSubscribe  byte = 8
+	TypeSuback     byte = 9
+	TypePingreq    byte = 12
+	TypePingresp   byte = 13
+	TypeDisconnect byte = 14
+)
+
+// Property identifiers.
+const (
+	propPayloadFormat          byte = 0x01
+	propMessageExpiry          byte = 0x02
+	propContentType            byte = 0x03
+	propResponseTopic          byte = 0x08
+	propCorrelationData        byte = 0x09
+	propSubscriptionIdentifier byte = 0x0B
+	propSessionExpiry          byte = 0x11
+	propAssignedClientID       byte = 0x12
+	propServerKeepAlive        byte = 0x13
+	propAuthMethod             byte = 0x15
+	propAuthData               byte = 0x16
+	propRequestProblemInfo     byte = 0x17
+	propWillDelay              byte = 0x18
+	propRequestResponseInfo    byte = 0x19
+	propResponseInfo           byte = 0x1A
+	propServerReference        byte = 0x1C
+	propReasonString           byte = 0x1F
+	propReceiveMaximum         byte = 0x21
+	propTopicAliasMaximum      byte = 0x22
+	propTopicAlias             byte = 0x23
+	propMaximumQoS             byte = 0x24
+	propRetainAvailable        byte = 0x25
+	propUserProperty           byte = 0x26
+	propMaximumPacketSize      byte = 0x27
+	propWildcardSubAvailable   byte = 0x28
+	propSubIDAvailable         byte = 0x29
+	propSharedSubAvailable     byte = 0x2A
+)
+
+// maxRemainingLength is the largest remaining length that can be encoded
+// within a fixed header.
+const maxRemainingLength = 268435455
+
+// ErrMalformedPacket is returned when a packet cannot be decoded.
+var ErrMalformedPacket = errors.New("malformed packet")
+
+// UserProperty is a name and value pair attached to a packet.
+type UserProperty struct {
+	Key   string
+	Value string
+}
+
+// Properties contains the properties of a packet that are understood by this
+// package, properties not listed here are skipped when decoding.
+type Properties struct {
+	PayloadFormat          *byte
+	MessageExpiry          *uint32
+	ContentType            string
+	ResponseTopic          string
+	CorrelationData        []byte
+	SubscriptionIdentifier []int
+	SessionExpiry          *uint32
+	AssignedClientID       string
+	ServerKeepAlive        *uint16
+	ReasonString           string
+	ReceiveMaximum         *uint16
+	TopicAlias             *uint16
+	MaximumQoS             *byte
+	RetainAvailable        *byte
+	User                   []UserProperty
+}
+
+// Message is an application message, which is the contents of a PUBLISH
+// packet, and also describes the will message of a CONNECT packet.
+type Message struct {
+	Topic      string
+	Payload    []byte
+	QoS        byte
+	Retain     bool
+	Duplicate  bool
+	PacketID   uint16
+	Properties Properties
+}
+
+// Connect is a CONNECT packet.
+type Connect struct {
+	ClientID   string
+	CleanStart bool
+	KeepAlive  uint16
+	Username   *string
+	Password   []byte
+	Will       *Message
+	Properties Properties
+}
+
+// Connack is a CONNACK packet.
+type Connack struct {
+	SessionPresent bool
+	ReasonCode     byte
+	Properties     Properties
+}
+
+// Publish is a PUBLISH packet.
+type Publish struct {
+	Message
+}
+
+// Ack is a PUBACK, PUBREC, PUBREL or PUBCOMP packet, depending on its Type.
+type Ack struct {
+	Type       byte
+	PacketID   uint16
+	ReasonCode byte
+	Properties Properties
+}
+
+// Subscription is a topic filter and the options to subscribe with.
+type Subscription struct {
+	Topic string
+	QoS   byte
+}
+
+// Subscribe is a SUBSCRIBE packet.
+type Subscribe struct {
+	PacketID      uint16
+	Properties    Properties
+	Subscriptions []Subscription
+}
+
+// Suback is a SUBACK packet.
+type Suback struct {
+	PacketID    uint16
+	Properties  Properties
+	ReasonCodes []byte
+}
+
+// Pingreq is a PINGREQ packet.
+type Pingreq struct{}
+
+// Pingresp is a PINGRESP packet.
+type Pingresp struct{}
+
+// Disconnect is a DISCONNECT packet.
+type Disconnect struct {
+	ReasonCode byte
+	Properties Properties
+}
+
+//------------------------------------------------------------------------------
+
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) byte(b byte) {
+	e.buf = append(e.buf, b)
+}
+
+func (e *encoder) uint16(v uint16) {
+	e.buf = append(e.buf, byte(v>>8), byte(v))
+}
+
+func (e *encoder) uint32(v uint32) {
+	e.buf = append(e.buf, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+func (e *encoder) varint(v int) {
+	for {
+		b := byte(v % 128)
+		v /= 128
+		if v > 0 {
+			b |= 0x80
+		}
+		e.buf = append(e.buf, b)
+		if v == 0 {
+			return
+		}
+	}
+}
+
+func (e *encoder) binary(b []byte) {
+	e.uint16(uint16(len(b)))
+	e.buf = append(e.buf, b...)
+}
+
+func (e *encoder) string(s string) {
+	e.binary([]byte(s))
+}
+
+func (e *encoder) properties(p Properties) {
+	var pe encoder
+	if p.PayloadFormat != nil {
+		pe.byte(propPayloadFormat)
+		pe.byte(*p.PayloadFormat)
+	}
+	if p.MessageExpiry != nil {
+		pe.byte(propMessageExpiry)
+		pe.uint32(*p.MessageExpiry)
+	}
+	if p.ContentType != "" {
+		pe.byte(propContentType)
+		pe.string(p.ContentType)
+	}
+	if p.ResponseTopic != "" {
+		pe.byte(propResponseTopic)
+		pe.string(p.ResponseTopic)
+	}
+	if p.CorrelationData != nil {
+		pe.byte(propCorrelationData)
+		pe.binary(p.CorrelationData)
+	}
+	for _, id := range p.SubscriptionIdentifier {
+		pe.byte(propSubscriptionIdentifier)
+		pe.varint(id)
+	}
+	if p.SessionExpiry != nil {
+		pe.byte(propSessionExpiry)
+		pe.uint32(*p.SessionExpiry)
+	}
+	if p.AssignedClientID != "" {
+		pe.byte(propAssignedClientID)
+		pe.string(p.AssignedClientID)
+	}
+	if p.ServerKeepAlive != nil {
+		pe.byte(propServerKeepAlive)
+		pe.uint16(*p.ServerKeepAlive)
+	}
+	if p.ReasonString != "" {
+		pe.byte(propReasonString)
+		pe.string(p.ReasonString)
+	}
+	if p.ReceiveMaximum != nil {
+		pe.byte(propReceiveMaximum)
+		pe.uint16(*p.ReceiveMaximum)
+	}
+	if p.TopicAlias != nil {
+		pe.byte(propTopicAlias)
+		pe.uint16(*p.TopicAlias)
+	}
+	if p.MaximumQoS != nil {
+		pe.byte(propMaximumQoS)
+		pe.byte(*p.MaximumQoS)
+	}
+	if p.RetainAvailable != nil {
+		pe.byte(propRetainAvailable)
+		pe.byte(*p.RetainAvailable)
+	}
+	for _, u := range p.User {
+		pe.byte(propUserProperty)
+		pe.string(u.Key)
+		pe.string(u.Value)
+	}
+	e.varint(len(pe.buf))
+	e.buf = append(e.buf, pe.buf...)
+}
+
+//------------------------------------------------------------------------------
+
+type decoder struct {
+	buf []byte
+	err error
+}
+
+func (d *decoder) take(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n > len(d.buf) {
+		d.err = ErrMalformedPacket
+		return nil
+	}
+	b := d.buf[:n]
+	d.buf = d.buf[n:]
+	return b
+}
+
+func (d *decoder) byte() byte {
+	if b := d.take(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (d *decoder) uint16() uint16 {
+	if b := d.take(2); b != nil {
+		return binary.BigEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (d *decoder) uint32() uint32 {
+	if b := d.take(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (d *decoder) varint() int {
+	v, multiplier := 0, 1
+	for i := 0; i < 4; i++ {
+		b := d.byte()
+		if d.err != nil {
+			return 0
+		}
+		v += int(b&0x7F) * multiplier
+		if b&0x80 == 0 {
+			return v
+		}
+		multiplier *= 128
+	}
+	d.err = ErrMalformedPacket
+	return 0
+}
+
+func (d *decoder) binary() []byte {
+	n := int(d.uint16())
+	if b := d.take(n); b != nil {
+		return append([]byte{}, b...)
+	}
+	return nil
+}
+
+func (d *decoder) string() string {
+	return string(d.binary())
+}
+
+func (d *decoder) properties() Properties {
+	var p Properties
+	n := d.varint()
+	pd := decoder{buf: d.take(n), err: d.err}
+	for pd.err == nil && len(pd.buf) > 0 {
+		switch id := pd.varint(); byte(id) {
+		case propPayloadFormat:
+			v := pd.byte()
+			p.PayloadFormat = &v
+		case propMessageExpiry:
+			v := pd.uint32()
+			p.MessageExpiry = &v
+		case propContentType:
+			p.ContentType = pd.string()
+		case propResponseTopic:
+			p.ResponseTopic = pd.string()
+		case propCorrelationData:
+			p.CorrelationData = pd.binary()
+		case propSubscriptionIdentifier:
+			p.SubscriptionIdentifier = append(p.SubscriptionIdentifier, pd.varint())
+		case propSessionExpiry:
+			v := pd.uint32()
+			p.SessionExpiry = &v
+		case propAssignedClientID:
+			p.AssignedClientID = pd.string()
+		case propServerKeepAlive:
+			v := pd.uint16()
+			p.ServerKeepAlive = &v
+		case propReasonString:
+			p.ReasonString = pd.string()
+		case propReceiveMaximum:
+			v := pd.uint16()
+			p.ReceiveMaximum = &v
+		case propTopicAlias:
+			v := pd.uint16()
+			p.TopicAlias = &v
+		case propMaximumQoS:
+			v := pd.byte()
+			p.MaximumQoS = &v
+		case propRetainAvailable:
+			v := pd.byte()
+			p.RetainAvailable = &v
+		case propUserProperty:
+			k := pd.string()
+			p.User = append(p.User, UserProperty{Key: k, Value: pd.string()})
+		case propRequestProblemInfo, propRequestResponseInfo,
+			propWildcardSubAvailable, propSubIDAvailable, propSharedSubAvailable:
+			pd.byte()
+		case propTopicAliasMaximum:
+			pd.uint16()
+		case propWillDelay, propMaximumPacketSize:
+			pd.uint32()
+		case propAuthMethod, propResponseInfo, propServerReference:
+			pd.string()
+		case propAuthData:
+			pd.binary()
+		default:
+			pd.err = fmt.Errorf("%w: unknown property identifier %#x", ErrMalformedPacket, id)
+		}
+	}
+	if d.err == nil {
+		d.err = pd.err
+	}
+	return p
+}
+
+//------------------------------------------------------------------------------
+
+// WritePacket encodes a packet and writes it to a writer.
+func WritePacket(w io.Writer, packet interface{}) error {
+	var e encoder
+	var header byte
+
+	switch p := packet.(type) {
+	case *Connect:
+		header = TypeConnect << 4
+		e.string("MQTT")
+		e.byte(5)
+		var flags byte
+		if p.Username != nil {
+			flags |= 0x80
+		}
+		if p.Password != nil {
+			flags |= 0x40
+		}
+		if p.Will != nil {
+			flags |= 0x04 | (p.Will.QoS&0x03)<<3
+			if p.Will.Retain {
+				flags |= 0x20
+			}
+		}
+		if p.CleanStart {
+			flags |= 0x02
+		}
+		e.byte(flags)
+		e.uint16(p.KeepAlive)
+		e.properties(p.Properties)
+		e.string(p.ClientID)
+		if p.Will != nil {
+			e.properties(p.Will.Properties)
+			e.string(p.Will.Topic)
+			e.binary(p.Will.Payload)
+		}
+		if p.Username != nil {
+			e.string(*p.Username)
+		}
+		if p.Password != nil {
+			e.binary(p.Password)
+		}
+	case *Connack:
+		header = TypeConnack << 4
+		if p.SessionPresent {
+			e.byte(1)
+		} else {
+			e.byte(0)
+		}
+		e.byte(p.ReasonCode)
+		e.properties(p.Properties)
+	case *Publish:
+		header = TypePublish<<4 | (p.QoS&0x03)<<1
+		if p.Duplicate {
+			header |= 0x08
+		}
+		if p.Retain {
+			header |= 0x01
+		}
+		e.string(p.Topic)
+		if p.QoS > 0 {
+			e.uint16(p.PacketID)
+		}
+		e.properties(p.Properties)
+		e.buf = append(e.buf, p.Payload...)
+	case *Ack:
+		header = p.Type << 4
+		if p.Type == TypePubrel {
+			header |= 0x02
+		}
+		e.uint16(p.PacketID)
+		e.byte(p.ReasonCode)
+		e.properties(p.Properties)
+	case *Subscribe:
+		header = TypeSubscribe<<4 | 0x02
+		e.uint16(p.PacketID)
+		e.properties(p.Properties)
+		for _, s := range p.Subscriptions {
+			e.string(s.Topic)
+			e.byte(s.QoS & 0x03)
+		}
+	case *Suback:
+		header = TypeSuback << 4
+		e.uint16(p.PacketID)
+		e.properties(p.Properties)
+		e.buf = append(e.buf, p.ReasonCodes...)
+	case *Pingreq:
+		header = TypePingreq << 4
+	case *Pingresp:
+		header = TypePingresp << 4
+	case *Disconnect:
+		header = TypeDisconnect << 4
+		e.byte(p.ReasonCode)
+		e.properties(p.Properties)
+	default:
+		return fmt.Errorf("unsupported packet type: %T", packet)
+	}
+
+	if len(e.buf) > maxRemainingLength {
+		return fmt.Errorf("packet exceeds maximum size: %v bytes", len(e.buf))
+	}
+
+	var fixed encoder
+	fixed.byte(header)
+	fixed.varint(len(e.buf))
+	_, err := w.Write(append(fixed.buf, e.buf...))
+	return err
+}
+
+// ReadPacket reads and decodes a single packet from a reader.
+func ReadPacket(r *bufio.Reader) (interface{}, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	length, multiplier := 0, 1
+	for i := 0; ; i++ {
+		if i == 4 {
+			return nil, ErrMalformedPacket
+		}
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		length += int(b&0x7F) * multiplier
+		if b&0x80 == 0 {
+			break
+		}
+		multiplier *= 128
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	d := decoder{buf: body}
+
+	var packet interface{}
+	switch packetType, flags := header>>4, header&0x0F; packetType {
+	case TypeConnect:
+		if d.string() != "MQTT" || d.byte() != 5 {
+			return nil, fmt.Errorf("%w: unsupported protocol", ErrMalformedPacket)
+		}
+		connFlags := d.byte()
+		p := &Connect{
+			CleanStart: connFlags&0x02 != 0,
+			KeepAlive:  d.uint16(),
+			Properties: d.properties(),
+		}
+		p.ClientID = d.string()
+		if connFlags&0x04 != 0 {
+			p.Will = &Message{
+				QoS:    (connFlags >> 3) & 0x03,
+				Retain: connFlags&0x20 != 0,
+			}
+			p.Will.Properties = d.properties()
+			p.Will.Topic = d.string()
+			p.Will.Payload = d.binary()
+		}
+		if connFlags&0x80 != 0 {
+			username := d.string()
+			p.Username = &username
+		}
+		if connFlags&0x40 != 0 {
+			p.Password = d.binary()
+		}
+		packet = p
+	case TypeConnack:
+		p := &Connack{SessionPresent: d.byte()&0x01 != 0}
+		p.ReasonCode = d.byte()
+		p.Properties = d.properties()
+		packet = p
+	case TypePublish:
+		p := &Publish{Message{
+			QoS:       (flags >> 1) & 0x03,
+			Retain:    flags&0x01 != 0,
+			Duplicate: flags&0x08 != 0,
+		}}
+		p.Topic = d.string()
+		if p.QoS > 0 {
+			p.PacketID = d.uint16()
+		}
+		p.Properties = d.properties()
+		if d.err == nil {
+			p.Payload = append([]byte{}, d.buf...)
+		}
+		packet = p
+	case TypePuback, TypePubrec, TypePubrel, TypePubcomp:
+		p := &Ack{Type: packetType, PacketID: d.uint16()}
+		if len(d.buf) > 0 {
+			p.ReasonCode = d.byte()
+		}
+		if len(d.buf) > 0 {
+			p.Properties = d.properties()
+		}
+		packet = p
+	case TypeSubscribe:
+		p := &Subscribe{PacketID: d.uint16(), Properties: d.properties()}
+		for d.err == nil && len(d.buf) > 0 {
+			topic := d.string()
+			p.Subscriptions = append(p.Subscriptions, Subscription{Topic: topic, QoS: d.byte() & 0x03})
+		}
+		packet = p
+	case TypeSuback:
+		p := &Suback{PacketID: d.uint16(), Properties: d.properties()}
+		if d.err == nil {
+			p.ReasonCodes = append([]byte{}, d.buf...)
+		}
+		packet = p
+	case TypePingreq:
+		packet = &Pingreq{}
+	case TypePingresp:
+		packet = &Pingresp{}
+	case TypeDisconnect:
+		p := &Disconnect{}
+		if len(d.buf) > 0 {
+			p.ReasonCode = d.byte()
+		}
+		if len(d.buf) > 0 {
+			p.Properties = d.properties()
+		}
+		packet = p
+	default:
+		return nil, fmt.Errorf("%w: unsupported packet type %v", ErrMalformedPacket, packetType)
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	return packet, nil
+}
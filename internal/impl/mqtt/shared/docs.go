@@ -5,6 +5,7 @@ package shared
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/benthosdev/benthos/v4/internal/docs"
 )
@@ -47,3 +48,26 @@ func WillFieldSpec() docs.FieldSpec {
 		docs.FieldString("payload", "Set payload for last will message."),
 	).Advanced()
 }
+
+// Supported versions of the MQTT protocol.
+const (
+	ProtocolVersion311 = "3.1.1"
+	ProtocolVersion5   = "5"
+)
+
+// ValidateProtocolVersion returns an error if a protocol version is not
+// supported.
+func ValidateProtocolVersion(v string) error {
+	switch v {
+	case ProtocolVersion311, ProtocolVersion5:
+		return nil
+	}
+	return fmt.Errorf("unsupported protocol_version: %v", v)
+}
+
+// ProtocolVersionFieldSpec defines the version of the MQTT protocol to use.
+func ProtocolVersionFieldSpec() docs.FieldSpec {
+	return docs.FieldString(
+		"protocol_version", "The version of the MQTT protocol to connect with. Features such as user properties and shared subscriptions require version 5.",
+	).HasOptions(ProtocolVersion311, ProtocolVersion5).HasDefault(ProtocolVersion311).AtVersion("4.0.0")
+}
@@ -0,0 +1,384 @@
+// Package otlp decodes OpenTelemetry protocol (OTLP) export requests into their
+// individual log records, spans and metric data points.
+package otlp
+
+import (
+	"bytes"
+	"embed"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+
+	// nolint:staticcheck // Ignore SA1019 deprecation warning until we can switch to "google.golang.org/protobuf/types/dynamicpb"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"github.com/jhump/protoreflect/dynamic"
+)
+
+//go:embed proto
+var protoFS embed.FS
+
+// Signal is a type of telemetry data.
+type Signal string
+
+// Signals supported by OTLP.
+const (
+	SignalLogs    Signal = "logs"
+	SignalTraces  Signal = "traces"
+	SignalMetrics Signal = "metrics"
+)
+
+// Signals lists all supported signals.
+var Signals = []Signal{SignalLogs, SignalTraces, SignalMetrics}
+
+var signalFiles = map[Signal]string{
+	SignalLogs:    "opentelemetry/proto/collector/logs/v1/logs_service.proto",
+	SignalTraces:  "opentelemetry/proto/collector/trace/v1/trace_service.proto",
+	SignalMetrics: "opentelemetry/proto/collector/metrics/v1/metrics_service.proto",
+}
+
+// Item is a single log record, span or metric data point extracted from an
+// export request.
+type Item struct {
+	// Body is the item encoded in the OTLP JSON format.
+	Body []byte
+
+	// Metadata contains the flattened attributes of the resource and scope
+	// that the item belongs to.
+	Metadata map[string]string
+}
+
+// Decoder decodes export requests of each signal.
+type Decoder struct {
+	services    map[Signal]*desc.ServiceDescriptor
+	marshaler   *jsonpb.Marshaler
+	unmarshaler *jsonpb.Unmarshaler
+}
+
+// NewDecoder parses the embedded OTLP definitions and returns a decoder.
+func NewDecoder() (*Decoder, error) {
+	parser := protoparse.Parser{
+		Accessor: func(filename string) (io.ReadCloser, error) {
+			return protoFS.Open(path.Join("proto", filename))
+		},
+	}
+
+	d := &Decoder{
+		services: map[Signal]*desc.ServiceDescriptor{},
+		marshaler: &jsonpb.Marshaler{
+			EnumsAsInts: true,
+		},
+		unmarshaler: &jsonpb.Unmarshaler{
+			AllowUnknownFields: true,
+		},
+	}
+	for _, signal := range Signals {
+		fds, err := parser.ParseFiles(signalFiles[signal])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse OTLP definitions: %w", err)
+		}
+		services := fds[0].GetServices()
+		if len(services) != 1 {
+			return nil, fmt.Errorf("expected one service in %v", signalFiles[signal])
+		}
+		d.services[signal] = services[0]
+	}
+	return d, nil
+}
+
+// Service returns the descriptor of the collector service of a signal.
+func (d *Decoder) Service(signal Signal) *desc.ServiceDescriptor {
+	return d.services[signal]
+}
+
+func (d *Decoder) request(signal Signal) (*dynamic.Message, error) {
+	sd, exists := d.services[signal]
+	if !exists {
+		return nil, fmt.Errorf("signal not recognised: %v", signal)
+	}
+	return dynamic.NewMessage(sd.GetMethods()[0].GetInputType()), nil
+}
+
+// DecodeProtobuf decodes a protobuf encoded export request of a signal.
+func (d *Decoder) DecodeProtobuf(signal Signal, data []byte) ([]Item, error) {
+	req, err := d.request(signal)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Unmarshal(data); err != nil {
+		return nil, fmt.Errorf("failed to decode export request: %w", err)
+	}
+	return d.items(signal, req)
+}
+
+// DecodeJSON decodes a JSON encoded export request of a signal.
+func (d *Decoder) DecodeJSON(signal Signal, data []byte) ([]Item, error) {
+	req, err := d.request(signal)
+	if err != nil {
+		return nil, err
+	}
+
+	// Trace and span IDs are hex encoded in OTLP JSON rather than the base64
+	// encoding used for bytes fields by the standard protobuf JSON mapping.
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("failed to decode export request: %w", err)
+	}
+	if err := walkIDs(v, hexToBase64); err != nil {
+		return nil, fmt.Errorf("failed to decode export request: %w", err)
+	}
+	if data, err = json.Marshal(v); err != nil {
+		return nil, err
+	}
+
+	if err := req.UnmarshalJSONPB(d.unmarshaler, data); err != nil {
+		return nil, fmt.Errorf("failed to decode export request: %w", err)
+	}
+	return d.items(signal, req)
+}
+
+//------------------------------------------------------------------------------
+
+func (d *Decoder) marshal(m *dynamic.Message) ([]byte, error) {
+	data, err := m.MarshalJSONPB(d.marshaler)
+	if err != nil {
+		return nil, err
+	}
+
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if err := walkIDs(v, base64ToHex); err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+func (d *Decoder) items(signal Signal, req *dynamic.Message) ([]Item, error) {
+	resourceField, scopeField, itemsField := "resource_logs", "scope_logs", "log_records"
+	switch signal {
+	case SignalTraces:
+		resourceField, scopeField, itemsField = "resource_spans", "scope_spans", "spans"
+	case SignalMetrics:
+		resourceField, scopeField, itemsField = "resource_metrics", "scope_metrics", "metrics"
+	}
+
+	var items []Item
+	for _, r := range messages(req, resourceField) {
+		resourceMeta := map[string]string{
+			"otlp_signal": string(signal),
+		}
+		if resource := message(r, "resource"); resource != nil {
+			flattenAttributes(resourceMeta, "otlp_resource_attr_", resource)
+		}
+
+		for _, s := range messages(r, scopeField) {
+			scopeMeta := make(map[string]string, len(resourceMeta))
+			for k, v := range resourceMeta {
+				scopeMeta[k] = v
+			}
+			if scope := message(s, "scope"); scope != nil {
+				if name, _ := scope.GetFieldByName("name").(string); name != "" {
+					scopeMeta["otlp_scope_name"] = name
+				}
+				if version, _ := scope.GetFieldByName("version").(string); version != "" {
+					scopeMeta["otlp_scope_version"] = version
+				}
+				flattenAttributes(scopeMeta, "otlp_scope_attr_", scope)
+			}
+
+			for _, m := range messages(s, itemsField) {
+				if signal == SignalMetrics {
+					metricItems, err := d.metricItems(scopeMeta, m)
+					if err != nil {
+						return nil, err
+					}
+					items = append(items, metricItems...)
+					continue
+				}
+
+				body, err := d.marshal(m)
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, Item{Body: body, Metadata: scopeMeta})
+			}
+		}
+	}
+	return items, nil
+}
+
+var metricTypes = []string{"gauge", "sum", "histogram", "exponential_histogram", "summary"}
+
+// metricItems returns an item for each data point of a metric, where the body
+// of each item is the metric containing only that data point.
+func (d *Decoder) metricItems(scopeMeta map[string]string, metric *dynamic.Message) ([]Item, error) {
+	for _, t := range metricTypes {
+		data := message(metric, t)
+		if data == nil {
+			continue
+		}
+
+		meta := make(map[string]string, len(scopeMeta)+2)
+		for k, v := range scopeMeta {
+			meta[k] = v
+		}
+		meta["otlp_metric_name"], _ = metric.GetFieldByName("name").(string)
+		meta["otlp_metric_type"] = t
+
+		var items []Item
+		dataPoints, _ := data.GetFieldByName("data_points").([]interface{})
+		for _, dp := range dataPoints {
+			if err := data.TrySetFieldByName("data_points", []interface{}{dp}); err != nil {
+				return nil, err
+			}
+			body, err := d.marshal(metric)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, Item{Body: body, Metadata: meta})
+		}
+		return items, nil
+	}
+	return nil, nil
+}
+
+//------------------------------------------------------------------------------
+
+func message(m *dynamic.Message, field string) *dynamic.Message {
+	if !m.HasFieldName(field) {
+		return nil
+	}
+	v, _ := m.GetFieldByName(field).(*dynamic.Message)
+	return v
+}
+
+func messages(m *dynamic.Message, field string) []*dynamic.Message {
+	values, _ := m.GetFieldByName(field).([]interface{})
+	res := make([]*dynamic.Message, 0, len(values))
+	for _, v := range values {
+		if vm, ok := v.(*dynamic.Message); ok {
+			res = append(res, vm)
+		}
+	}
+	return res
+}
+
+func flattenAttributes(meta map[string]string, prefix string, m *dynamic.Message) {
+	for _, kv := range messages(m, "attributes") {
+		key, _ := kv.GetFieldByName("key").(string)
+		var value interface{}
+		if av := message(kv, "value"); av != nil {
+			value = anyValue(av)
+		}
+		switch t := value.(type) {
+		case string:
+			meta[prefix+key] = t
+		case nil:
+			meta[prefix+key] = ""
+		default:
+			b, _ := json.Marshal(t)
+			meta[prefix+key] = string(b)
+		}
+	}
+}
+
+// anyValue converts an AnyValue message into a structured value.
+func anyValue(m *dynamic.Message) interface{} {
+	switch {
+	case m.HasFieldName("string_value"):
+		return m.GetFieldByName("string_value")
+	case m.HasFieldName("bool_value"):
+		return m.GetFieldByName("bool_value")
+	case m.HasFieldName("int_value"):
+		return m.GetFieldByName("int_value")
+	case m.HasFieldName("double_value"):
+		return m.GetFieldByName("double_value")
+	case m.HasFieldName("bytes_value"):
+		return m.GetFieldByName("bytes_value")
+	case m.HasFieldName("array_value"):
+		values := []interface{}{}
+		for _, v := range messages(message(m, "array_value"), "values") {
+			values = append(values, anyValue(v))
+		}
+		return values
+	case m.HasFieldName("kvlist_value"):
+		obj := map[string]interface{}{}
+		for _, kv := range messages(message(m, "kvlist_value"), "values") {
+			key, _ := kv.GetFieldByName("key").(string)
+			var value interface{}
+			if av := message(kv, "value"); av != nil {
+				value = anyValue(av)
+			}
+			obj[key] = value
+		}
+		return obj
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+var idFields = map[string]struct{}{
+	"traceId":        {},
+	"trace_id":       {},
+	"spanId":         {},
+	"span_id":        {},
+	"parentSpanId":   {},
+	"parent_span_id": {},
+}
+
+// walkIDs walks a JSON structure and applies a conversion to the values of all
+// trace and span ID fields.
+func walkIDs(v interface{}, convert func(string) (string, error)) error {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, child := range t {
+			if s, ok := child.(string); ok {
+				if _, isID := idFields[k]; isID {
+					converted, err := convert(s)
+					if err != nil {
+						return fmt.Errorf("field %v: %w", k, err)
+					}
+					t[k] = converted
+				}
+				continue
+			}
+			if err := walkIDs(child, convert); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, child := range t {
+			if err := walkIDs(child, convert); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func hexToBase64(s string) (string, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+func base64ToHex(s string) (string, error) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
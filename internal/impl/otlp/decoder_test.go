@@ -0,0 +1,225 @@
+package otlp
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testLogsRequest = `{
+  "resourceLogs": [{
+    "resource": {
+      "attributes": [
+        {"key": "service.name", "value": {"stringValue": "foo"}},
+        {"key": "host.cpus", "value": {"intValue": "4"}}
+      ]
+    },
+    "scopeLogs": [{
+      "scope": {
+        "name": "bar",
+        "version": "1.2.3",
+        "attributes": [{"key": "baz", "value": {"boolValue": true}}]
+      },
+      "logRecords": [
+        {
+          "timeUnixNano": "1581452772000000321",
+          "severityNumber": 9,
+          "body": {"stringValue": "first"},
+          "traceId": "5b8efff798038103d269b633813fc60c",
+          "spanId": "eee19b7ec3c1b174"
+        },
+        {
+          "severityNumber": 17,
+          "body": {"stringValue": "second"},
+          "attributes": [{"key": "tags", "value": {"arrayValue": {"values": [{"stringValue": "a"}, {"intValue": "1"}]}}}]
+        }
+      ]
+    }]
+  }]
+}`
+
+const testTracesRequest = `{
+  "resourceSpans": [{
+    "resource": {
+      "attributes": [{"key": "service.name", "value": {"stringValue": "foo"}}]
+    },
+    "scopeSpans": [{
+      "spans": [{
+        "traceId": "5b8efff798038103d269b633813fc60c",
+        "spanId": "eee19b7ec3c1b174",
+        "parentSpanId": "eee19b7ec3c1b173",
+        "name": "span",
+        "kind": 2,
+        "startTimeUnixNano": "1544712660000000000",
+        "endTimeUnixNano": "1544712661000000000"
+      }]
+    }]
+  }]
+}`
+
+const testMetricsRequest = `{
+  "resourceMetrics": [{
+    "scopeMetrics": [{
+      "metrics": [
+        {
+          "name": "requests",
+          "unit": "1",
+          "sum": {
+            "aggregationTemporality": 2,
+            "isMonotonic": true,
+            "dataPoints": [
+              {"asInt": "10", "timeUnixNano": "1544712660300000000"},
+              {"asInt": "20", "timeUnixNano": "1544712660400000000"}
+            ]
+          }
+        },
+        {
+          "name": "temperature",
+          "gauge": {
+            "dataPoints": [{"asDouble": 21.5}]
+          }
+        }
+      ]
+    }]
+  }]
+}`
+
+func TestDecodeLogs(t *testing.T) {
+	d, err := NewDecoder()
+	require.NoError(t, err)
+
+	items, err := d.DecodeJSON(SignalLogs, []byte(testLogsRequest))
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+
+	assert.JSONEq(t, `{
+  "timeUnixNano": "1581452772000000321",
+  "severityNumber": 9,
+  "body": {"stringValue": "first"},
+  "traceId": "5b8efff798038103d269b633813fc60c",
+  "spanId": "eee19b7ec3c1b174"
+}`, string(items[0].Body))
+
+	assert.Equal(t, map[string]string{
+		"otlp_signal":                     "logs",
+		"otlp_resource_attr_service.name": "foo",
+		"otlp_resource_attr_host.cpus":    "4",
+		"otlp_scope_name":                 "bar",
+		"otlp_scope_version":              "1.2.3",
+		"otlp_scope_attr_baz":             "true",
+	}, items[0].Metadata)
+	assert.Equal(t, items[0].Metadata, items[1].Metadata)
+
+	var second map[string]interface{}
+	require.NoError(t, json.Unmarshal(items[1].Body, &second))
+	assert.Equal(t, map[string]interface{}{"stringValue": "second"}, second["body"])
+}
+
+func TestDecodeProtobufRoundTrip(t *testing.T) {
+	d, err := NewDecoder()
+	require.NoError(t, err)
+
+	for signal, reqJSON := range map[Signal]string{
+		SignalLogs:    testLogsRequest,
+		SignalTraces:  testTracesRequest,
+		SignalMetrics: testMetricsRequest,
+	} {
+		expected, err := d.DecodeJSON(signal, []byte(reqJSON))
+		require.NoError(t, err, signal)
+
+		// Convert the JSON request into protobuf.
+		req, err := d.request(signal)
+		require.NoError(t, err)
+		var v interface{}
+		require.NoError(t, json.Unmarshal([]byte(reqJSON), &v))
+		require.NoError(t, walkIDs(v, hexToBase64))
+		jBytes, err := json.Marshal(v)
+		require.NoError(t, err)
+		require.NoError(t, req.UnmarshalJSONPB(d.unmarshaler, jBytes))
+		pBytes, err := req.Marshal()
+		require.NoError(t, err)
+
+		actual, err := d.DecodeProtobuf(signal, pBytes)
+		require.NoError(t, err, signal)
+
+		require.Len(t, actual, len(expected), signal)
+		for i := range expected {
+			assert.JSONEq(t, string(expected[i].Body), string(actual[i].Body), signal)
+			assert.Equal(t, expected[i].Metadata, actual[i].Metadata, signal)
+		}
+	}
+}
+
+func TestDecodeTraces(t *testing.T) {
+	d, err := NewDecoder()
+	require.NoError(t, err)
+
+	items, err := d.DecodeJSON(SignalTraces, []byte(testTracesRequest))
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+
+	assert.JSONEq(t, `{
+  "traceId": "5b8efff798038103d269b633813fc60c",
+  "spanId": "eee19b7ec3c1b174",
+  "parentSpanId": "eee19b7ec3c1b173",
+  "name": "span",
+  "kind": 2,
+  "startTimeUnixNano": "1544712660000000000",
+  "endTimeUnixNano": "1544712661000000000"
+}`, string(items[0].Body))
+	assert.Equal(t, "traces", items[0].Metadata["otlp_signal"])
+	assert.Equal(t, "foo", items[0].Metadata["otlp_resource_attr_service.name"])
+}
+
+func TestDecodeMetrics(t *testing.T) {
+	d, err := NewDecoder()
+	require.NoError(t, err)
+
+	items, err := d.DecodeJSON(SignalMetrics, []byte(testMetricsRequest))
+	require.NoError(t, err)
+	require.Len(t, items, 3)
+
+	assert.JSONEq(t, `{
+  "name": "requests",
+  "unit": "1",
+  "sum": {
+    "aggregationTemporality": 2,
+    "isMonotonic": true,
+    "dataPoints": [{"asInt": "10", "timeUnixNano": "1544712660300000000"}]
+  }
+}`, string(items[0].Body))
+	assert.JSONEq(t, `{
+  "name": "requests",
+  "unit": "1",
+  "sum": {
+    "aggregationTemporality": 2,
+    "isMonotonic": true,
+    "dataPoints": [{"asInt": "20", "timeUnixNano": "1544712660400000000"}]
+  }
+}`, string(items[1].Body))
+	assert.JSONEq(t, `{"name":"temperature","gauge":{"dataPoints":[{"asDouble":21.5}]}}`, string(items[2].Body))
+
+	assert.Equal(t, "requests", items[0].Metadata["otlp_metric_name"])
+	assert.Equal(t, "sum", items[0].Metadata["otlp_metric_type"])
+	assert.Equal(t, "temperature", items[2].Metadata["otlp_metric_name"])
+	assert.Equal(t, "gauge", items[2].Metadata["otlp_metric_type"])
+}
+
+func TestDecodeErrors(t *testing.T) {
+	d, err := NewDecoder()
+	require.NoError(t, err)
+
+	_, err = d.DecodeJSON(SignalLogs, []byte(`not json`))
+	require.Error(t, err)
+
+	_, err = d.DecodeJSON(SignalLogs, []byte(`{"resourceLogs":[{"scopeLogs":[{"logRecords":[{"traceId":"nothex"}]}]}]}`))
+	require.Error(t, err)
+
+	_, err = d.DecodeProtobuf(SignalTraces, []byte(`\xff\xff\xff`))
+	require.Error(t, err)
+
+	_, err = d.DecodeProtobuf("nope", nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,484 @@
+package otlp
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+
+	"github.com/benthosdev/benthos/v4/internal/impl/protobuf"
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func otlpInputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Network").
+		Version("4.0.0").
+		Summary("Receive logs, traces and metrics exported with the [OpenTelemetry protocol (OTLP)](https://opentelemetry.io/docs/reference/specification/protocol/otlp/) over HTTP and gRPC.").
+		Description(`
+Export requests received over OTLP/HTTP at the paths ` + "`/v1/logs`, `/v1/traces` and `/v1/metrics`" + ` can be encoded either as protobuf (` + "`application/x-protobuf`" + `) or JSON (` + "`application/json`" + `), optionally compressed with gzip. Export requests received over OTLP/gRPC are served by the standard logs, trace and metrics collector services.
+
+If the ` + "`http_address`" + ` field is left blank the [service-wide HTTP server](/docs/components/http/about) is used for OTLP/HTTP, and if the ` + "`grpc_address`" + ` field is left blank OTLP/gRPC is disabled.
+
+Each export request is consumed as a batch containing a message for each log record, span or metric data point, encoded in the [OTLP JSON format](https://opentelemetry.io/docs/reference/specification/protocol/otlp/#json-protobuf-encoding). Messages of metric data points contain the metric with only that data point in its list of data points.
+
+A successful response is returned to the exporter once the batch has been delivered. When delivery fails, or takes longer than the ` + "`timeout`" + `, an error is returned that indicates to the exporter that the request should be retried.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "``` text" + `
+- otlp_signal
+- otlp_scope_name
+- otlp_scope_version
+- otlp_metric_name
+- otlp_metric_type
+- All resource attributes prefixed with otlp_resource_attr_
+- All scope attributes prefixed with otlp_scope_attr_
+` + "```" + `
+
+Where ` + "`otlp_signal`" + ` is one of ` + "`logs`, `traces` or `metrics`" + `, and the metric fields are only added to metric data points. Attribute values that are not strings are added as JSON, e.g. the resource attribute ` + "`service.name`" + ` is added as the metadata field ` + "`otlp_resource_attr_service.name`" + `.
+
+You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#metadata).`).
+		Field(service.NewStringField("http_address").
+			Description("The address to receive OTLP/HTTP requests from. If left empty the service wide HTTP server is used.").
+			Default("0.0.0.0:4318")).
+		Field(service.NewStringField("grpc_address").
+			Description("The address to receive OTLP/gRPC requests from. If left empty OTLP/gRPC is disabled.").
+			Default("0.0.0.0:4317")).
+		Field(service.NewDurationField("timeout").
+			Description("Timeout for requests. If a consumed request takes longer than this to be delivered an error is returned, but the messages may still be delivered.").
+			Default("5s")).
+		Field(service.NewStringField("cert_file").
+			Description("Enable TLS for both OTLP/HTTP and OTLP/gRPC by specifying a certificate and key file. Only valid with a custom `http_address`.").
+			Default("").
+			Advanced()).
+		Field(service.NewStringField("key_file").
+			Description("Enable TLS for both OTLP/HTTP and OTLP/gRPC by specifying a certificate and key file. Only valid with a custom `http_address`.").
+			Default("").
+			Advanced()).
+		Example("Route Logs by Service", "Here we receive logs and write them to a file per service, using the `service.name` resource attribute:", `
+input:
+  otlp:
+    http_address: 0.0.0.0:4318
+    grpc_address: 0.0.0.0:4317
+
+pipeline:
+  processors:
+    - bloblang: |
+        root = if meta("otlp_signal") != "logs" { deleted() }
+
+output:
+  file:
+    path: ./logs/${! meta("otlp_resource_attr_service.name") }.jsonl
+    codec: lines
+`)
+}
+
+func init() {
+	err := service.RegisterBatchInput(
+		"otlp", otlpInputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchInput, error) {
+			return newOTLPInputFromConfig(conf, mgr)
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+var otlpHTTPPaths = map[Signal]string{
+	SignalLogs:    "/v1/logs",
+	SignalTraces:  "/v1/traces",
+	SignalMetrics: "/v1/metrics",
+}
+
+var (
+	errOTLPTimeout = errors.New("request timed out")
+	errOTLPClosing = errors.New("server closing")
+)
+
+// otlpRequest is a batch of items from an export request and a channel on
+// which the result of delivering them is sent.
+type otlpRequest struct {
+	batch   service.MessageBatch
+	resChan chan error
+}
+
+type otlpInput struct {
+	httpAddress string
+	grpcAddress string
+	certFile    string
+	keyFile     string
+	timeout     time.Duration
+
+	decoder     *Decoder
+	mgr         *service.Resources
+	log         *service.Logger
+	requestChan chan otlpRequest
+
+	httpServer *http.Server
+	grpcServer *grpc.Server
+
+	serveMut  sync.Mutex
+	serving   bool
+	handlerWG sync.WaitGroup
+	shutSig   *shutdown.Signaller
+}
+
+func newOTLPInputFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*otlpInput, error) {
+	o := &otlpInput{
+		mgr:         mgr,
+		log:         mgr.Logger(),
+		requestChan: make(chan otlpRequest),
+		shutSig:     shutdown.NewSignaller(),
+	}
+
+	var err error
+	if o.httpAddress, err = conf.FieldString("http_address"); err != nil {
+		return nil, err
+	}
+	if o.grpcAddress, err = conf.FieldString("grpc_address"); err != nil {
+		return nil, err
+	}
+	if o.timeout, err = conf.FieldDuration("timeout"); err != nil {
+		return nil, err
+	}
+	if o.certFile, err = conf.FieldString("cert_file"); err != nil {
+		return nil, err
+	}
+	if o.keyFile, err = conf.FieldString("key_file"); err != nil {
+		return nil, err
+	}
+
+	if o.decoder, err = NewDecoder(); err != nil {
+		return nil, err
+	}
+
+	if o.grpcAddress != "" {
+		opts := []grpc.ServerOption{
+			grpc.ForceServerCodec(protobuf.RawCodec{}),
+		}
+		if o.tlsEnabled() {
+			creds, err := credentials.NewServerTLSFromFile(o.certFile, o.keyFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+			}
+			opts = append(opts, grpc.Creds(creds))
+		}
+		o.grpcServer = grpc.NewServer(opts...)
+		for _, signal := range Signals {
+			o.grpcServer.RegisterService(o.grpcServiceDesc(signal), o)
+		}
+	}
+
+	if o.httpAddress != "" {
+		mux := http.NewServeMux()
+		for _, signal := range Signals {
+			mux.HandleFunc(otlpHTTPPaths[signal], o.httpHandler(signal))
+		}
+		o.httpServer = &http.Server{Handler: mux}
+	} else {
+		for _, signal := range Signals {
+			mgr.RegisterEndpoint(
+				otlpHTTPPaths[signal], fmt.Sprintf("Export OTLP %v into Benthos.", signal), o.httpHandler(signal),
+			)
+		}
+	}
+	return o, nil
+}
+
+func (o *otlpInput) tlsEnabled() bool {
+	return len(o.keyFile) > 0 || len(o.certFile) > 0
+}
+
+//------------------------------------------------------------------------------
+
+func otlpBatch(items []Item) service.MessageBatch {
+	batch := make(service.MessageBatch, 0, len(items))
+	for _, item := range items {
+		msg := service.NewMessage(item.Body)
+		for k, v := range item.Metadata {
+			msg.MetaSet(k, v)
+		}
+		batch = append(batch, msg)
+	}
+	return batch
+}
+
+func (o *otlpInput) deliver(ctx context.Context, batch service.MessageBatch) error {
+	var timeoutChan <-chan time.Time
+	if o.timeout > 0 {
+		timer := time.NewTimer(o.timeout)
+		defer timer.Stop()
+		timeoutChan = timer.C
+	}
+
+	resChan := make(chan error, 1)
+	select {
+	case o.requestChan <- otlpRequest{batch: batch, resChan: resChan}:
+	case <-timeoutChan:
+		return errOTLPTimeout
+	case <-ctx.Done():
+		return errOTLPTimeout
+	case <-o.shutSig.CloseAtLeisureChan():
+		return errOTLPClosing
+	}
+
+	select {
+	case res := <-resChan:
+		return res
+	case <-timeoutChan:
+		return errOTLPTimeout
+	case <-ctx.Done():
+		return errOTLPTimeout
+	case <-o.shutSig.CloseNowChan():
+		return errOTLPClosing
+	}
+}
+
+func (o *otlpInput) httpHandler(signal Signal) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		o.handlerWG.Add(1)
+		defer o.handlerWG.Done()
+		defer r.Body.Close()
+
+		if r.Method != http.MethodPost {
+			http.Error(w, "Incorrect method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil {
+			http.Error(w, "Unsupported content type", http.StatusUnsupportedMediaType)
+			return
+		}
+		isJSON := mediaType == "application/json"
+		if !isJSON && mediaType != "application/x-protobuf" && mediaType != "application/protobuf" {
+			http.Error(w, "Unsupported content type", http.StatusUnsupportedMediaType)
+			return
+		}
+
+		var body io.Reader = r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			gz, err := gzip.NewReader(r.Body)
+			if err != nil {
+				http.Error(w, "Bad request", http.StatusBadRequest)
+				o.log.Warnf("Request read failed: %v", err)
+				return
+			}
+			defer gz.Close()
+			body = gz
+		}
+
+		data, err := io.ReadAll(body)
+		if err != nil {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			o.log.Warnf("Request read failed: %v", err)
+			return
+		}
+
+		var items []Item
+		if isJSON {
+			items, err = o.decoder.DecodeJSON(signal, data)
+		} else {
+			items, err = o.decoder.DecodeProtobuf(signal, data)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			o.log.Warnf("Request read failed: %v", err)
+			return
+		}
+
+		if len(items) > 0 {
+			switch err := o.deliver(r.Context(), otlpBatch(items)); err {
+			case nil:
+			case errOTLPTimeout:
+				http.Error(w, "Request timed out", http.StatusGatewayTimeout)
+				return
+			default:
+				http.Error(w, err.Error(), http.StatusServiceUnavailable)
+				return
+			}
+		}
+
+		// Export responses are empty when the request is fully accepted.
+		if isJSON {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{}`))
+		} else {
+			w.Header().Set("Content-Type", "application/x-protobuf")
+			w.WriteHeader(http.StatusOK)
+		}
+	}
+}
+
+func (o *otlpInput) grpcServiceDesc(signal Signal) *grpc.ServiceDesc {
+	sd := o.decoder.Service(signal)
+	return &grpc.ServiceDesc{
+		ServiceName: sd.GetFullyQualifiedName(),
+		HandlerType: (*interface{})(nil),
+		Streams: []grpc.StreamDesc{
+			{
+				StreamName: sd.GetMethods()[0].GetName(),
+				Handler:    o.grpcHandler(signal),
+			},
+		},
+		Metadata: sd.GetFile().GetName(),
+	}
+}
+
+func (o *otlpInput) grpcHandler(signal Signal) grpc.StreamHandler {
+	return func(_ interface{}, stream grpc.ServerStream) error {
+		var data []byte
+		if err := stream.RecvMsg(&data); err != nil {
+			return err
+		}
+
+		items, err := o.decoder.DecodeProtobuf(signal, data)
+		if err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		if len(items) > 0 {
+			switch err := o.deliver(stream.Context(), otlpBatch(items)); err {
+			case nil:
+			case errOTLPTimeout:
+				return status.Error(codes.DeadlineExceeded, err.Error())
+			default:
+				return status.Error(codes.Unavailable, err.Error())
+			}
+		}
+
+		// An empty response message indicates that the request was fully
+		// accepted.
+		return stream.SendMsg([]byte{})
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func (o *otlpInput) Connect(ctx context.Context) error {
+	o.serveMut.Lock()
+	defer o.serveMut.Unlock()
+
+	if o.serving {
+		return nil
+	}
+
+	var grpcListener, httpListener net.Listener
+	var err error
+	if o.grpcServer != nil {
+		if grpcListener, err = net.Listen("tcp", o.grpcAddress); err != nil {
+			return err
+		}
+	}
+	if o.httpServer != nil {
+		if httpListener, err = net.Listen("tcp", o.httpAddress); err != nil {
+			if grpcListener != nil {
+				grpcListener.Close()
+			}
+			return err
+		}
+	}
+
+	if grpcListener != nil {
+		o.log.Infof("Receiving OTLP/gRPC requests at: %v", grpcListener.Addr())
+		go func() {
+			if err := o.grpcServer.Serve(grpcListener); err != nil && err != grpc.ErrServerStopped {
+				o.log.Errorf("Server error: %v", err)
+			}
+		}()
+	}
+
+	if httpListener != nil {
+		go func() {
+			var err error
+			if o.tlsEnabled() {
+				o.log.Infof("Receiving OTLP/HTTP requests at: https://%v", httpListener.Addr())
+				err = o.httpServer.ServeTLS(httpListener, o.certFile, o.keyFile)
+			} else {
+				o.log.Infof("Receiving OTLP/HTTP requests at: http://%v", httpListener.Addr())
+				err = o.httpServer.Serve(httpListener)
+			}
+			if err != http.ErrServerClosed {
+				o.log.Errorf("Server error: %v", err)
+			}
+		}()
+	}
+
+	o.serving = true
+	return nil
+}
+
+func (o *otlpInput) ReadBatch(ctx context.Context) (service.MessageBatch, service.AckFunc, error) {
+	select {
+	case req := <-o.requestChan:
+		return req.batch, func(ctx context.Context, err error) error {
+			req.resChan <- err
+			return nil
+		}, nil
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	case <-o.shutSig.CloseAtLeisureChan():
+		return nil, nil, service.ErrEndOfInput
+	}
+}
+
+func (o *otlpInput) Close(ctx context.Context) error {
+	o.shutSig.CloseAtLeisure()
+	defer o.shutSig.ShutdownComplete()
+
+	// Requests that are still being delivered once the context ends are
+	// abandoned.
+	go func() {
+		select {
+		case <-ctx.Done():
+			o.shutSig.CloseNow()
+		case <-o.shutSig.HasClosedChan():
+		}
+	}()
+
+	if o.httpServer != nil {
+		if err := o.httpServer.Shutdown(ctx); err != nil {
+			o.log.Errorf("Failed to gracefully terminate otlp HTTP server: %v", err)
+		}
+	} else {
+		for _, signal := range Signals {
+			o.mgr.RegisterEndpoint(otlpHTTPPaths[signal], "Does nothing.", http.NotFound)
+		}
+	}
+
+	if o.grpcServer != nil {
+		stopped := make(chan struct{})
+		go func() {
+			o.grpcServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			o.grpcServer.Stop()
+			<-stopped
+		}
+	}
+
+	o.handlerWG.Wait()
+	return nil
+}
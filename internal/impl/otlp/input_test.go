@@ -0,0 +1,233 @@
+package otlp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/benthosdev/benthos/v4/internal/impl/protobuf"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+var errTestNack = errors.New("nope")
+
+func freeTestAddress(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	require.NoError(t, listener.Close())
+	return addr
+}
+
+// protoField appends a length delimited protobuf field.
+func protoField(b []byte, num byte, value []byte) []byte {
+	b = append(b, num<<3|2)
+	l := len(value)
+	for l >= 0x80 {
+		b = append(b, byte(l)|0x80)
+		l >>= 7
+	}
+	b = append(b, byte(l))
+	return append(b, value...)
+}
+
+func otlpForTest(t *testing.T) (*otlpInput, string, string) {
+	t.Helper()
+
+	httpAddr, grpcAddr := freeTestAddress(t), freeTestAddress(t)
+
+	conf, err := otlpInputConfig().ParseYAML(fmt.Sprintf(`
+http_address: %v
+grpc_address: %v
+`, httpAddr, grpcAddr), nil)
+	require.NoError(t, err)
+
+	o, err := newOTLPInputFromConfig(conf, service.MockResources())
+	require.NoError(t, err)
+	require.NoError(t, o.Connect(context.Background()))
+	t.Cleanup(func() {
+		ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+		defer done()
+		assert.NoError(t, o.Close(ctx))
+	})
+	return o, httpAddr, grpcAddr
+}
+
+func consumeOTLP(t *testing.T, ctx context.Context, o *otlpInput, ackErr error) chan service.MessageBatch {
+	t.Helper()
+
+	resChan := make(chan service.MessageBatch, 1)
+	go func() {
+		batch, ackFn, err := o.ReadBatch(ctx)
+		if err != nil {
+			t.Errorf("failed to read batch: %v", err)
+			close(resChan)
+			return
+		}
+		resChan <- batch
+		assert.NoError(t, ackFn(ctx, ackErr))
+	}()
+	return resChan
+}
+
+func messageBytes(t *testing.T, msg *service.Message) string {
+	t.Helper()
+
+	b, err := msg.AsBytes()
+	require.NoError(t, err)
+	return string(b)
+}
+
+func messageMeta(msg *service.Message, key string) string {
+	v, _ := msg.MetaGet(key)
+	return v
+}
+
+func TestOTLPHTTPJSON(t *testing.T) {
+	tCtx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	o, httpAddr, _ := otlpForTest(t)
+	resChan := consumeOTLP(t, tCtx, o, nil)
+
+	res, err := http.Post("http://"+httpAddr+"/v1/logs", "application/json", bytes.NewReader([]byte(`{
+  "resourceLogs": [{
+    "resource": {"attributes": [{"key": "service.name", "value": {"stringValue": "foo"}}]},
+    "scopeLogs": [{
+      "scope": {"name": "bar"},
+      "logRecords": [
+        {"severityText": "INFO", "body": {"stringValue": "first"}},
+        {"severityText": "WARN", "body": {"stringValue": "second"}}
+      ]
+    }]
+  }]
+}`)))
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	resBytes, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, `{}`, string(resBytes))
+	assert.Equal(t, "application/json", res.Header.Get("Content-Type"))
+
+	parts := <-resChan
+	require.Len(t, parts, 2)
+	assert.JSONEq(t, `{"severityText":"INFO","body":{"stringValue":"first"}}`, messageBytes(t, parts[0]))
+	assert.JSONEq(t, `{"severityText":"WARN","body":{"stringValue":"second"}}`, messageBytes(t, parts[1]))
+	for _, p := range parts {
+		assert.Equal(t, "logs", messageMeta(p, "otlp_signal"))
+		assert.Equal(t, "foo", messageMeta(p, "otlp_resource_attr_service.name"))
+		assert.Equal(t, "bar", messageMeta(p, "otlp_scope_name"))
+	}
+}
+
+func TestOTLPHTTPProtobufNack(t *testing.T) {
+	tCtx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	o, httpAddr, _ := otlpForTest(t)
+	resChan := consumeOTLP(t, tCtx, o, errTestNack)
+
+	// ExportMetricsServiceRequest with a single gauge data point.
+	dataPoint := []byte{4<<3 | 1, 0, 0, 0, 0, 0, 0, 0x35, 0x40} // as_double = 21
+	gauge := protoField(nil, 1, dataPoint)
+	metric := protoField(nil, 1, []byte("temperature"))
+	metric = protoField(metric, 5, gauge)
+	scopeMetrics := protoField(nil, 2, metric)
+	resourceMetrics := protoField(nil, 2, scopeMetrics)
+	req := protoField(nil, 1, resourceMetrics)
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err := gz.Write(req)
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+
+	hReq, err := http.NewRequest(http.MethodPost, "http://"+httpAddr+"/v1/metrics", &buf)
+	require.NoError(t, err)
+	hReq.Header.Set("Content-Type", "application/x-protobuf")
+	hReq.Header.Set("Content-Encoding", "gzip")
+
+	res, err := http.DefaultClient.Do(hReq)
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+
+	parts := <-resChan
+	require.Len(t, parts, 1)
+	assert.JSONEq(t, `{"name":"temperature","gauge":{"dataPoints":[{"asDouble":21}]}}`, messageBytes(t, parts[0]))
+	assert.Equal(t, "metrics", messageMeta(parts[0], "otlp_signal"))
+	assert.Equal(t, "temperature", messageMeta(parts[0], "otlp_metric_name"))
+	assert.Equal(t, "gauge", messageMeta(parts[0], "otlp_metric_type"))
+}
+
+func TestOTLPHTTPBadRequests(t *testing.T) {
+	_, httpAddr, _ := otlpForTest(t)
+
+	res, err := http.Post("http://"+httpAddr+"/v1/traces", "text/plain", bytes.NewReader([]byte(`hello`)))
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusUnsupportedMediaType, res.StatusCode)
+
+	res, err = http.Post("http://"+httpAddr+"/v1/traces", "application/json", bytes.NewReader([]byte(`not json`)))
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	res, err = http.Get("http://" + httpAddr + "/v1/traces")
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, res.StatusCode)
+
+	// Requests without any items are accepted without consuming messages.
+	res, err = http.Post("http://"+httpAddr+"/v1/traces", "application/json", bytes.NewReader([]byte(`{}`)))
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+}
+
+func TestOTLPGRPC(t *testing.T) {
+	tCtx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	o, _, grpcAddr := otlpForTest(t)
+	resChan := consumeOTLP(t, tCtx, o, nil)
+
+	conn, err := grpc.Dial(grpcAddr,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(protobuf.RawCodec{})),
+	)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// ExportTraceServiceRequest with a single span.
+	span := protoField(nil, 1, []byte{0x5b, 0x8e, 0xff, 0xf7, 0x98, 0x03, 0x81, 0x03, 0xd2, 0x69, 0xb6, 0x33, 0x81, 0x3f, 0xc6, 0x0c})
+	span = protoField(span, 5, []byte("span"))
+	scopeSpans := protoField(nil, 2, span)
+	resourceSpans := protoField(nil, 2, scopeSpans)
+	req := protoField(nil, 1, resourceSpans)
+
+	var res []byte
+	require.NoError(t, conn.Invoke(tCtx, "/opentelemetry.proto.collector.trace.v1.TraceService/Export", req, &res))
+	assert.Empty(t, res)
+
+	parts := <-resChan
+	require.Len(t, parts, 1)
+	assert.JSONEq(t, `{"traceId":"5b8efff798038103d269b633813fc60c","name":"span"}`, messageBytes(t, parts[0]))
+	assert.Equal(t, "traces", messageMeta(parts[0], "otlp_signal"))
+}
@@ -0,0 +1,116 @@
+package protobuf
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+)
+
+func protobufMethodSpec(description string) *bloblang.PluginSpec {
+	return bloblang.NewPluginSpec().
+		Category(string(query.MethodCategoryParsing)).
+		Description(description).
+		Param(bloblang.NewStringParam("message").Description("The fully qualified name of the protobuf message.")).
+		Param(bloblang.NewStringParam("import_path").Description("A directory to walk for .proto files containing all definitions required for the message.").Default("")).
+		Param(bloblang.NewStringParam("descriptor_set").Description("A path to a precompiled `FileDescriptorSet` file containing all definitions required for the message.").Default(""))
+}
+
+func registryFromArgs(args *bloblang.ParsedParams) (*Registry, string, error) {
+	message, err := args.GetString("message")
+	if err != nil {
+		return nil, "", err
+	}
+	importPath, err := args.GetString("import_path")
+	if err != nil {
+		return nil, "", err
+	}
+	descriptorSet, err := args.GetString("descriptor_set")
+	if err != nil {
+		return nil, "", err
+	}
+	if importPath == "" && descriptorSet == "" {
+		return nil, "", errors.New("either an import_path or a descriptor_set must be specified")
+	}
+
+	var importPaths, descriptorSets []string
+	if importPath != "" {
+		importPaths = []string{importPath}
+	}
+	if descriptorSet != "" {
+		descriptorSets = []string{descriptorSet}
+	}
+
+	r, err := LoadRegistry(importPaths, descriptorSets)
+	if err != nil {
+		return nil, "", err
+	}
+	return r, message, nil
+}
+
+func init() {
+	decodeSpec := protobufMethodSpec("Decodes a serialised protobuf message into a structured document. Definitions are loaded either from .proto files found within `import_path` or from a precompiled `descriptor_set`, and fields of the type `google.protobuf.Any` are resolved using the same definitions. Definitions are loaded once when the mapping is parsed.\n\n" +
+		"```coffee\n" +
+		`root.person = this.person_proto.decode("base64").decode_protobuf(message: "testing.Person", import_path: "./schema")` +
+		"\n```")
+
+	if err := bloblang.RegisterMethodV2(
+		"decode_protobuf", decodeSpec,
+		func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+			r, message, err := registryFromArgs(args)
+			if err != nil {
+				return nil, err
+			}
+			md, err := r.FindMessage(message)
+			if err != nil {
+				return nil, err
+			}
+			return func(v interface{}) (interface{}, error) {
+				b, err := query.IGetBytes(v)
+				if err != nil {
+					return nil, err
+				}
+				jBytes, err := r.ToJSON(md, b)
+				if err != nil {
+					return nil, err
+				}
+				var jObj interface{}
+				if err := json.Unmarshal(jBytes, &jObj); err != nil {
+					return nil, err
+				}
+				return jObj, nil
+			}, nil
+		},
+	); err != nil {
+		panic(err)
+	}
+
+	encodeSpec := protobufMethodSpec("Encodes a structured document into a serialised protobuf message. Definitions are loaded either from .proto files found within `import_path` or from a precompiled `descriptor_set`, and fields of the type `google.protobuf.Any` are resolved using the same definitions. Definitions are loaded once when the mapping is parsed.\n\n" +
+		"```coffee\n" +
+		`root.person_proto = this.person.encode_protobuf(message: "testing.Person", descriptor_set: "./schema.pb").encode("base64")` +
+		"\n```")
+
+	if err := bloblang.RegisterMethodV2(
+		"encode_protobuf", encodeSpec,
+		func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+			r, message, err := registryFromArgs(args)
+			if err != nil {
+				return nil, err
+			}
+			md, err := r.FindMessage(message)
+			if err != nil {
+				return nil, err
+			}
+			return func(v interface{}) (interface{}, error) {
+				jBytes, err := json.Marshal(v)
+				if err != nil {
+					return nil, err
+				}
+				return r.FromJSON(md, jBytes)
+			}, nil
+		},
+	); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,63 @@
+package protobuf
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+)
+
+func TestBloblangProtobufRoundTrip(t *testing.T) {
+	setPath := writeTestDescriptorSet(t)
+
+	for _, args := range []string{
+		fmt.Sprintf(`message: "testing.Envelope", import_path: %q`, testSchemaPath),
+		fmt.Sprintf(`message: "testing.Envelope", descriptor_set: %q`, setPath),
+	} {
+		exec, err := bloblang.Parse(fmt.Sprintf(`
+let encoded = this.doc.encode_protobuf(%v)
+root.decoded = $encoded.decode_protobuf(%v)
+root.size = $encoded.length()
+`, args, args))
+		require.NoError(t, err, args)
+
+		res, err := exec.Query(map[string]interface{}{
+			"doc": map[string]interface{}{
+				"id": 747,
+				"content": map[string]interface{}{
+					"@type":   "type.googleapis.com/testing.House",
+					"address": "123",
+				},
+			},
+		})
+		require.NoError(t, err, args)
+
+		assert.Equal(t, map[string]interface{}{
+			"decoded": map[string]interface{}{
+				"id": 747.0,
+				"content": map[string]interface{}{
+					"@type":   "type.googleapis.com/testing.House",
+					"address": "123",
+				},
+			},
+			"size": int64(47),
+		}, res, args)
+	}
+}
+
+func TestBloblangProtobufErrors(t *testing.T) {
+	_, err := bloblang.Parse(`root = this.encode_protobuf("testing.Person")`)
+	require.Error(t, err)
+
+	_, err = bloblang.Parse(fmt.Sprintf(`root = this.encode_protobuf("testing.Nope", %q)`, testSchemaPath))
+	require.Error(t, err)
+
+	exec, err := bloblang.Parse(fmt.Sprintf(`root = this.encode_protobuf("testing.Person", %q)`, testSchemaPath))
+	require.NoError(t, err)
+
+	_, err = exec.Query(map[string]interface{}{"nope": "foo"})
+	require.Error(t, err)
+}
@@ -0,0 +1,35 @@
+package protobuf
+
+import "fmt"
+
+// RawCodec is a gRPC codec that passes serialised protobuf messages through as
+// raw bytes. This allows gRPC services to be served and invoked with messages
+// converted by a Registry rather than generated types.
+type RawCodec struct{}
+
+// Marshal returns the serialised message, which must be a []byte or *[]byte.
+func (RawCodec) Marshal(v interface{}) ([]byte, error) {
+	switch t := v.(type) {
+	case []byte:
+		return t, nil
+	case *[]byte:
+		return *t, nil
+	}
+	return nil, fmt.Errorf("unable to marshal message of type %T", v)
+}
+
+// Unmarshal copies serialised message data into v, which must be a *[]byte.
+func (RawCodec) Unmarshal(data []byte, v interface{}) error {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("unable to unmarshal message into type %T", v)
+	}
+	*b = append((*b)[:0], data...)
+	return nil
+}
+
+// Name returns the name of the codec, which matches the default proto codec so
+// that it's used for the content type application/grpc+proto.
+func (RawCodec) Name() string {
+	return "proto"
+}
@@ -0,0 +1,223 @@
+// Package protobuf contains helpers for loading protobuf descriptors from
+// either .proto files or precompiled descriptor sets, and for converting
+// messages described by them to and from JSON.
+package protobuf
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	// nolint:staticcheck // Ignore SA1019 deprecation warning until we can switch to "google.golang.org/protobuf/types/dynamicpb"
+	"github.com/golang/protobuf/jsonpb"
+	// nolint:staticcheck // Ignore SA1019 deprecation warning until we can switch to "google.golang.org/protobuf/types/dynamicpb"
+	"github.com/golang/protobuf/proto"
+	dpb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"github.com/jhump/protoreflect/dynamic"
+)
+
+// Registry contains a set of loaded file descriptors and provides methods for
+// converting the messages they describe to and from JSON. Any messages of the
+// type google.protobuf.Any are resolved using the same set of descriptors.
+type Registry struct {
+	files       []*desc.FileDescriptor
+	factory     *dynamic.MessageFactory
+	marshaler   *jsonpb.Marshaler
+	unmarshaler *jsonpb.Unmarshaler
+}
+
+// NewRegistry creates a registry from a list of file descriptors.
+func NewRegistry(files []*desc.FileDescriptor) *Registry {
+	factory := dynamic.NewMessageFactoryWithDefaults()
+	resolver := dynamic.AnyResolver(factory, files...)
+	return &Registry{
+		files:       files,
+		factory:     factory,
+		marshaler:   &jsonpb.Marshaler{AnyResolver: resolver},
+		unmarshaler: &jsonpb.Unmarshaler{AnyResolver: resolver},
+	}
+}
+
+// LoadRegistry creates a registry from descriptors obtained by walking a list
+// of directories for .proto files and parsing them, and also by reading a list
+// of precompiled FileDescriptorSet files (as produced by protoc with the flag
+// --descriptor_set_out). When both lists are empty the current directory is
+// walked for .proto files.
+func LoadRegistry(importPaths, descriptorSetPaths []string) (*Registry, error) {
+	var files []*desc.FileDescriptor
+	if len(importPaths) > 0 || len(descriptorSetPaths) == 0 {
+		fds, err := LoadFromImportPaths(importPaths)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, fds...)
+	}
+	if len(descriptorSetPaths) > 0 {
+		fds, err := LoadFromDescriptorSets(descriptorSetPaths)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, fds...)
+	}
+	return NewRegistry(files), nil
+}
+
+// LoadFromImportPaths walks a list of directories for .proto files and parses
+// them into file descriptors. If the list is empty the current directory is
+// used.
+func LoadFromImportPaths(importPaths []string) ([]*desc.FileDescriptor, error) {
+	var parser protoparse.Parser
+	if len(importPaths) == 0 {
+		importPaths = []string{"."}
+	} else {
+		parser.ImportPaths = importPaths
+	}
+
+	var files []string
+	for _, importPath := range importPaths {
+		if err := filepath.Walk(importPath, func(path string, info os.FileInfo, ferr error) error {
+			if ferr != nil || info.IsDir() {
+				return ferr
+			}
+			if filepath.Ext(info.Name()) == ".proto" {
+				rPath, ferr := filepath.Rel(importPath, path)
+				if ferr != nil {
+					return fmt.Errorf("failed to get relative path: %w", ferr)
+				}
+				files = append(files, rPath)
+			}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	fds, err := parser.ParseFiles(files...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse .proto file: %w", err)
+	}
+	if len(fds) == 0 {
+		return nil, fmt.Errorf("no .proto files were found in the paths '%v'", importPaths)
+	}
+	return fds, nil
+}
+
+// LoadFromDescriptorSets reads a list of files containing serialised
+// FileDescriptorSet messages and returns the file descriptors within them.
+func LoadFromDescriptorSets(paths []string) ([]*desc.FileDescriptor, error) {
+	var fds []*desc.FileDescriptor
+	for _, path := range paths {
+		setBytes, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read descriptor set '%v': %w", path, err)
+		}
+
+		var set dpb.FileDescriptorSet
+		if err := proto.Unmarshal(setBytes, &set); err != nil {
+			return nil, fmt.Errorf("failed to parse descriptor set '%v': %w", path, err)
+		}
+		if len(set.File) == 0 {
+			return nil, fmt.Errorf("descriptor set '%v' does not contain any files", path)
+		}
+
+		fdMap, err := desc.CreateFileDescriptorsFromSet(&set)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve descriptor set '%v': %w", path, err)
+		}
+
+		names := make([]string, 0, len(fdMap))
+		for k := range fdMap {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		for _, k := range names {
+			fds = append(fds, fdMap[k])
+		}
+	}
+	return fds, nil
+}
+
+// Files returns the file descriptors of the registry.
+func (r *Registry) Files() []*desc.FileDescriptor {
+	return r.files
+}
+
+// FindMessage attempts to find a message descriptor by its fully qualified
+// name.
+func (r *Registry) FindMessage(name string) (*desc.MessageDescriptor, error) {
+	if name == "" {
+		return nil, errors.New("message name must not be empty")
+	}
+	for _, fd := range r.files {
+		if md := fd.FindMessage(name); md != nil {
+			return md, nil
+		}
+	}
+	return nil, fmt.Errorf("unable to find message '%v' definition", name)
+}
+
+// Services returns the descriptors of all services defined within the files of
+// the registry.
+func (r *Registry) Services() []*desc.ServiceDescriptor {
+	var services []*desc.ServiceDescriptor
+	for _, fd := range r.files {
+		services = append(services, fd.GetServices()...)
+	}
+	return services
+}
+
+// FindMethod attempts to find a service method descriptor by its full name,
+// which can be of the form package.Service/Method or package.Service.Method.
+func (r *Registry) FindMethod(name string) (*desc.MethodDescriptor, error) {
+	fullName := strings.TrimPrefix(name, "/")
+	i := strings.LastIndexAny(fullName, "/.")
+	if i <= 0 || i == len(fullName)-1 {
+		return nil, fmt.Errorf("method name '%v' must be of the form package.Service/Method", name)
+	}
+	serviceName, methodName := fullName[:i], fullName[i+1:]
+	for _, fd := range r.files {
+		if sd := fd.FindService(serviceName); sd != nil {
+			if md := sd.FindMethodByName(methodName); md != nil {
+				return md, nil
+			}
+			return nil, fmt.Errorf("unable to find method '%v' of service '%v'", methodName, serviceName)
+		}
+	}
+	return nil, fmt.Errorf("unable to find service '%v' definition", serviceName)
+}
+
+// ToJSON unmarshals a serialised protobuf message of a given type and returns
+// its JSON representation.
+func (r *Registry) ToJSON(md *desc.MessageDescriptor, data []byte) ([]byte, error) {
+	msg := r.factory.NewDynamicMessage(md)
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal message: %w", err)
+	}
+
+	jBytes, err := msg.MarshalJSONPB(r.marshaler)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal protobuf message: %w", err)
+	}
+	return jBytes, nil
+}
+
+// FromJSON parses a JSON document as a protobuf message of a given type and
+// returns it serialised.
+func (r *Registry) FromJSON(md *desc.MessageDescriptor, jBytes []byte) ([]byte, error) {
+	msg := r.factory.NewDynamicMessage(md)
+	if err := msg.UnmarshalJSONPB(r.unmarshaler, jBytes); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal JSON message: %w", err)
+	}
+
+	data, err := msg.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal protobuf message: %w", err)
+	}
+	return data, nil
+}
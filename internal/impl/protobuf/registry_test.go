@@ -0,0 +1,95 @@
+package protobuf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	// nolint:staticcheck // Ignore SA1019 deprecation warning until we can switch to "google.golang.org/protobuf/types/dynamicpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSchemaPath = "../../../config/test/protobuf/schema"
+
+func writeTestDescriptorSet(t testing.TB) string {
+	t.Helper()
+
+	fds, err := LoadFromImportPaths([]string{testSchemaPath})
+	require.NoError(t, err)
+
+	setBytes, err := proto.Marshal(desc.ToFileDescriptorSet(fds...))
+	require.NoError(t, err)
+
+	setPath := filepath.Join(t.TempDir(), "schema.pb")
+	require.NoError(t, os.WriteFile(setPath, setBytes, 0o644))
+	return setPath
+}
+
+func TestRegistryDescriptorSet(t *testing.T) {
+	setPath := writeTestDescriptorSet(t)
+
+	r, err := LoadRegistry(nil, []string{setPath})
+	require.NoError(t, err)
+
+	md, err := r.FindMessage("testing.Envelope")
+	require.NoError(t, err)
+
+	input := `{"id":747,"content":{"@type":"type.googleapis.com/testing.Person","firstName":"bob"}}`
+
+	pBytes, err := r.FromJSON(md, []byte(input))
+	require.NoError(t, err)
+
+	jBytes, err := r.ToJSON(md, pBytes)
+	require.NoError(t, err)
+	assert.Equal(t, input, string(jBytes))
+
+	_, err = r.FindMessage("testing.Nope")
+	require.Error(t, err)
+}
+
+func TestRegistryDescriptorSetErrors(t *testing.T) {
+	_, err := LoadRegistry(nil, []string{"./does_not_exist.pb"})
+	require.Error(t, err)
+
+	badPath := filepath.Join(t.TempDir(), "bad.pb")
+	require.NoError(t, os.WriteFile(badPath, []byte("not a descriptor set"), 0o644))
+
+	_, err = LoadRegistry(nil, []string{badPath})
+	require.Error(t, err)
+}
+
+func TestRegistryFindMethod(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "greeter.proto"), []byte(`
+syntax = "proto3";
+package testing;
+
+message Greeting {
+  string name = 1;
+}
+
+service Greeter {
+  rpc Greet(Greeting) returns (Greeting);
+}
+`), 0o644))
+
+	reg, err := LoadRegistry([]string{dir}, nil)
+	require.NoError(t, err)
+
+	require.Len(t, reg.Services(), 1)
+	assert.Equal(t, "testing.Greeter", reg.Services()[0].GetFullyQualifiedName())
+
+	for _, name := range []string{"testing.Greeter/Greet", "/testing.Greeter/Greet", "testing.Greeter.Greet"} {
+		md, err := reg.FindMethod(name)
+		require.NoError(t, err, name)
+		assert.Equal(t, "testing.Greeter.Greet", md.GetFullyQualifiedName())
+	}
+
+	for _, name := range []string{"", "Greet", "testing.Greeter/", "testing.Greeter/Nope", "testing.Nope/Greet"} {
+		_, err := reg.FindMethod(name)
+		assert.Error(t, err, name)
+	}
+}
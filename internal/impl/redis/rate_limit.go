@@ -0,0 +1,161 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func redisRatelimitConfig() *service.ConfigSpec {
+	spec := service.NewConfigSpec().
+		Beta().
+		Summary(`A rate limit implementation using Redis. It works by using a generic cell rate algorithm (GCRA), executed atomically with a Lua script, and is therefore able to share a quota across any number of running instances of Benthos.`).
+		Description(`
+The timestamps used by the algorithm are obtained from the Redis server, and so the clocks of the Benthos instances sharing the limit do not need to be synchronised. Requests are spread evenly across the interval, but bursts of up to ` + "`count`" + ` requests are permitted when the limit has not been reached.`)
+
+	for _, f := range clientFields() {
+		spec = spec.Field(f)
+	}
+
+	spec = spec.
+		Field(service.NewIntField("count").
+			Description("The maximum number of requests to allow for a given period of time.").
+			Default(1000)).
+		Field(service.NewDurationField("interval").
+			Description("The time window to limit requests by.").
+			Default("1s")).
+		Field(service.NewStringField("key").
+			Description("The key to use for the rate limit, instances that share the same key share the same quota.").
+			Example("benthos_rate_limit"))
+
+	return spec
+}
+
+func init() {
+	err := service.RegisterRateLimit(
+		"redis", redisRatelimitConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.RateLimit, error) {
+			return newRedisRatelimitFromConfig(conf)
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+func newRedisRatelimitFromConfig(conf *service.ParsedConfig) (*redisRatelimit, error) {
+	client, err := getClient(conf)
+	if err != nil {
+		return nil, err
+	}
+	count, err := conf.FieldInt("count")
+	if err != nil {
+		return nil, err
+	}
+	interval, err := conf.FieldDuration("interval")
+	if err != nil {
+		return nil, err
+	}
+	key, err := conf.FieldString("key")
+	if err != nil {
+		return nil, err
+	}
+	return newRedisRatelimit(key, count, interval, client)
+}
+
+//------------------------------------------------------------------------------
+
+// gcraScript implements the generic cell rate algorithm. The theoretical
+// arrival time (TAT) of the next request is stored under the key in
+// microseconds, and the script returns the number of microseconds to wait
+// before the request would be permitted, or zero if it was permitted.
+//
+// ARGV[1] is the emission interval (interval / count) and ARGV[2] is the
+// interval, both in microseconds.
+var gcraScript = redis.NewScript(`
+redis.replicate_commands()
+local t = redis.call("TIME")
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+local emission = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+
+local tat = tonumber(redis.call("GET", KEYS[1]))
+if tat == nil or tat < now then
+  tat = now
+end
+
+local new_tat = tat + emission
+local wait = new_tat - limit - now
+if wait > 0 then
+  return wait
+end
+
+redis.call("SET", KEYS[1], string.format("%d", new_tat), "PX", math.ceil((new_tat - now) / 1000))
+return 0
+`)
+
+type redisRatelimit struct {
+	client redis.UniversalClient
+	key    string
+
+	emission time.Duration
+	interval time.Duration
+}
+
+func newRedisRatelimit(key string, count int, interval time.Duration, client redis.UniversalClient) (*redisRatelimit, error) {
+	if key == "" {
+		return nil, errors.New("key must not be empty")
+	}
+	if count <= 0 {
+		return nil, errors.New("count must be larger than zero")
+	}
+	if interval <= 0 {
+		return nil, errors.New("interval must be larger than zero")
+	}
+	// The script works in microseconds, and therefore an emission interval
+	// below that would be truncated to zero and disable the limit.
+	emission := interval / time.Duration(count)
+	if emission < time.Microsecond {
+		return nil, fmt.Errorf("interval %v divided by count %v must be at least one microsecond", interval, count)
+	}
+	return &redisRatelimit{
+		client:   client,
+		key:      key,
+		emission: emission,
+		interval: interval,
+	}, nil
+}
+
+// scriptClient returns a client bound to a context, falling back to the
+// unbound client for implementations that do not support one.
+func (r *redisRatelimit) scriptClient(ctx context.Context) redis.UniversalClient {
+	switch c := r.client.(type) {
+	case *redis.Client:
+		return c.WithContext(ctx)
+	case *redis.ClusterClient:
+		return c.WithContext(ctx)
+	case *redis.Ring:
+		return c.WithContext(ctx)
+	}
+	return r.client
+}
+
+func (r *redisRatelimit) Access(ctx context.Context) (time.Duration, error) {
+	res, err := gcraScript.Run(
+		r.scriptClient(ctx), []string{r.key},
+		r.emission.Microseconds(), r.interval.Microseconds(),
+	).Int64()
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(res) * time.Microsecond, nil
+}
+
+func (r *redisRatelimit) Close(ctx context.Context) error {
+	return r.client.Close()
+}
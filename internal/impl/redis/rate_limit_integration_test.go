@@ -0,0 +1,78 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ory/dockertest/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/integration"
+)
+
+func TestIntegrationRedisRateLimit(t *testing.T) {
+	integration.CheckSkip(t)
+	t.Parallel()
+
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err)
+
+	pool.MaxWait = time.Second * 30
+
+	resource, err := pool.Run("redis", "latest", nil)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, pool.Purge(resource))
+	})
+
+	resource.Expire(900)
+
+	newRL := func() *redisRatelimit {
+		pConf, err := redisRatelimitConfig().ParseYAML(fmt.Sprintf(`
+url: tcp://localhost:%v/1
+key: benthos_test_rate_limit
+count: 10
+interval: 1s
+`, resource.GetPort("6379/tcp")), nil)
+		require.NoError(t, err)
+
+		r, err := newRedisRatelimitFromConfig(pConf)
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			_ = r.Close(context.Background())
+		})
+		return r
+	}
+
+	rlOne := newRL()
+	require.NoError(t, pool.Retry(func() error {
+		_, cErr := rlOne.client.Ping().Result()
+		return cErr
+	}))
+	rlTwo := newRL()
+
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		period, err := rlOne.Access(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, time.Duration(0), period)
+
+		period, err = rlTwo.Access(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, time.Duration(0), period)
+	}
+
+	period, err := rlOne.Access(ctx)
+	require.NoError(t, err)
+	assert.Greater(t, period, time.Duration(0))
+	assert.LessOrEqual(t, period, time.Second)
+
+	<-time.After(period)
+
+	period, err = rlTwo.Access(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, time.Duration(0), period)
+}
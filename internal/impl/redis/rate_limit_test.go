@@ -0,0 +1,39 @@
+package redis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisRatelimitConfigErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		key         string
+		count       int
+		interval    time.Duration
+		errContains string
+	}{
+		{name: "empty key", count: 10, interval: time.Second, errContains: "key must not be empty"},
+		{name: "zero count", key: "foo", interval: time.Second, errContains: "count must be larger than zero"},
+		{name: "zero interval", key: "foo", count: 10, errContains: "interval must be larger than zero"},
+		{name: "sub microsecond emission", key: "foo", count: 1001, interval: time.Millisecond, errContains: "must be at least one microsecond"},
+		{name: "microsecond emission", key: "foo", count: 1000, interval: time.Millisecond},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			r, err := newRedisRatelimit(test.key, test.count, test.interval, nil)
+			if test.errContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.errContains)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, time.Microsecond, r.emission)
+		})
+	}
+}
@@ -1,13 +1,18 @@
 package sql
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/Masterminds/squirrel"
 
+	"github.com/benthosdev/benthos/v4/internal/impl/cursor"
 	"github.com/benthosdev/benthos/v4/internal/shutdown"
 	"github.com/benthosdev/benthos/v4/public/bloblang"
 	"github.com/benthosdev/benthos/v4/public/service"
@@ -18,7 +23,18 @@ func sqlSelectInputConfig() *service.ConfigSpec {
 		// Stable(). TODO
 		Categories("Services").
 		Summary("Executes a select query and creates a message for each row received.").
-		Description(`Once the rows from the query are exhausted this input shuts down, allowing the pipeline to gracefully terminate (or the next input in a [sequence](/docs/components/inputs/sequence) to execute).`).
+		Description(`
+Once the rows from the query are exhausted this input shuts down, allowing the pipeline to gracefully terminate (or the next input in a [sequence](/docs/components/inputs/sequence) to execute).
+
+### Polling
+
+When the field ` + "`cursor_column`" + ` is set the input instead polls the table continuously, selecting only rows where the cursor column is greater than or equal to the highest value seen so far in ascending order of that column. The cursor column should therefore be monotonically increasing, such as an auto incrementing ID or an update timestamp, and must be included within the selected ` + "`columns`" + `. Once the rows of a query are exhausted the next query is executed after the ` + "`poll_interval`" + ` has passed.
+
+The cursor column does not need to be unique. Rows that share the highest cursor value are selected again by the next query, and those that have already been consumed are skipped by comparing all selected columns, therefore rows that arrive with the same cursor value after a poll are not missed.
+
+The cursor value of each row is committed to the [cache resource](/docs/components/caches/about) ` + "`cursor_cache`" + ` only once the row, and all rows prior to it, have been acknowledged by the output, and when the input is restarted it resumes from the last committed value. This provides at-least-once delivery guarantees, and so in the event of crashes rows may be delivered more than once, including rows that share the last committed cursor value. Timestamp cursors are stored along with their type so that they are restored as timestamps.
+
+When polling the query is ordered by the cursor column, and therefore the ` + "`suffix`" + ` field should not contain an ` + "`ORDER BY`" + ` clause.`).
 		Field(driverField).
 		Field(dsnField).
 		Field(service.NewStringField("table").
@@ -45,6 +61,26 @@ func sqlSelectInputConfig() *service.ConfigSpec {
 			Description("An optional suffix to append to the select query.").
 			Optional().
 			Advanced()).
+		Field(service.NewStringField("cursor_column").
+			Description("An optional column to [poll](#polling) the table by, which must be monotonically increasing. When set the input runs continuously, selecting rows that have a cursor value greater than or equal to the last row consumed.").
+			Example("id").
+			Example("updated_at").
+			Optional().
+			Version("4.0.0")).
+		Field(service.NewStringField("cursor_cache").
+			Description("A [cache resource](/docs/components/caches/about) to persist the cursor value within, which is required when `cursor_column` is set.").
+			Optional().
+			Version("4.0.0")).
+		Field(service.NewStringField("cursor_key").
+			Description("The key to persist the cursor value under within the `cursor_cache`. When empty a key is derived from the table name.").
+			Default("").
+			Advanced().
+			Version("4.0.0")).
+		Field(service.NewDurationField("poll_interval").
+			Description("The period to wait after the rows of a query are exhausted before polling the table for new rows, only applicable when `cursor_column` is set.").
+			Default("5s").
+			Version("4.0.0")).
+		Field(cursor.CheckpointLimitField()).
 		Version("3.59.0").
 		Example("Consume a Table (PostgreSQL)",
 			`
@@ -61,6 +97,26 @@ input:
       root = [
         now().format_timestamp_unix() - 3600
       ]
+`,
+		).
+		Example("Poll a Table (MySQL)",
+			`
+Here we define a pipeline that continuously consumes new rows from a table by polling it every ten seconds for rows with an ID greater than the last row consumed, where the ID of the last row delivered is persisted within a Redis cache so that a restarted pipeline continues from where it left off:`,
+			`
+input:
+  sql_select:
+    driver: mysql
+    dsn: foouser:foopassword@tcp(localhost:3306)/foodb
+    table: footable
+    columns: [ '*' ]
+    cursor_column: id
+    cursor_cache: cursors
+    poll_interval: 10s
+
+cache_resources:
+  - label: cursors
+    redis:
+      url: tcp://localhost:6379
 `,
 		)
 }
@@ -69,7 +125,7 @@ func init() {
 	err := service.RegisterInput(
 		"sql_select", sqlSelectInputConfig(),
 		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Input, error) {
-			i, err := newSQLSelectInputFromConfig(conf, mgr.Logger())
+			i, err := newSQLSelectInputFromConfig(conf, mgr)
 			if err != nil {
 				return nil, err
 			}
@@ -94,13 +150,24 @@ type sqlSelectInput struct {
 	where       string
 	argsMapping *bloblang.Executor
 
+	// Fields used when polling the table by a cursor column.
+	cursorColumn string
+	cursorCache  *cursor.Cache
+	pollInterval time.Duration
+	cursor       interface{}
+	nextPoll     time.Time
+
+	// Rows that share the current cursor value and have already been read,
+	// keyed by their serialised columns.
+	boundaryRows map[string]struct{}
+
 	logger  *service.Logger
 	shutSig *shutdown.Signaller
 }
 
-func newSQLSelectInputFromConfig(conf *service.ParsedConfig, logger *service.Logger) (*sqlSelectInput, error) {
+func newSQLSelectInputFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*sqlSelectInput, error) {
 	s := &sqlSelectInput{
-		logger:  logger,
+		logger:  mgr.Logger(),
 		shutSig: shutdown.NewSignaller(),
 	}
 
@@ -136,7 +203,40 @@ func newSQLSelectInputFromConfig(conf *service.ParsedConfig, logger *service.Log
 		}
 	}
 
+	if conf.Contains("cursor_column") {
+		if s.cursorColumn, err = conf.FieldString("cursor_column"); err != nil {
+			return nil, err
+		}
+		if !conf.Contains("cursor_cache") {
+			return nil, errors.New("a cursor_cache must be specified when a cursor_column is set")
+		}
+		cacheName, err := conf.FieldString("cursor_cache")
+		if err != nil {
+			return nil, err
+		}
+		cursorKey, err := conf.FieldString("cursor_key")
+		if err != nil {
+			return nil, err
+		}
+		if cursorKey == "" {
+			cursorKey = "sql_select_cursor_" + tableStr
+		}
+		checkpointLimit, err := conf.FieldInt("checkpoint_limit")
+		if err != nil {
+			return nil, err
+		}
+		if s.cursorCache, err = cursor.NewCache(mgr, cacheName, cursorKey, checkpointLimit); err != nil {
+			return nil, err
+		}
+		if s.pollInterval, err = conf.FieldDuration("poll_interval"); err != nil {
+			return nil, err
+		}
+	}
+
 	s.builder = squirrel.Select(columns...).From(tableStr)
+	if s.cursorColumn != "" {
+		s.builder = s.builder.OrderBy(s.cursorColumn + " ASC")
+	}
 	if s.driver == "postgres" {
 		s.builder = s.builder.PlaceholderFormat(squirrel.Dollar)
 	}
@@ -178,26 +278,15 @@ func (s *sqlSelectInput) Connect(ctx context.Context) (err error) {
 		}
 	}()
 
-	var args []interface{}
-	if s.argsMapping != nil {
-		var iargs interface{}
-		if iargs, err = s.argsMapping.Query(nil); err != nil {
-			return err
-		}
-
-		var ok bool
-		if args, ok = iargs.([]interface{}); !ok {
-			err = fmt.Errorf("mapping returned non-array result: %T", iargs)
+	if s.cursorColumn != "" {
+		if s.cursor, err = s.loadCursor(ctx); err != nil {
 			return
 		}
+		s.boundaryRows = map[string]struct{}{}
 	}
 
-	queryBuilder := s.builder
-	if s.where != "" {
-		queryBuilder = queryBuilder.Where(s.where, args...)
-	}
 	var rows *sql.Rows
-	if rows, err = queryBuilder.RunWith(db).Query(); err != nil {
+	if rows, err = s.query(db); err != nil {
 		return
 	}
 
@@ -222,42 +311,207 @@ func (s *sqlSelectInput) Connect(ctx context.Context) (err error) {
 	return nil
 }
 
+func (s *sqlSelectInput) query(db *sql.DB) (*sql.Rows, error) {
+	var args []interface{}
+	if s.argsMapping != nil {
+		iargs, err := s.argsMapping.Query(nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var ok bool
+		if args, ok = iargs.([]interface{}); !ok {
+			return nil, fmt.Errorf("mapping returned non-array result: %T", iargs)
+		}
+	}
+
+	queryBuilder := s.builder
+	if s.where != "" {
+		queryBuilder = queryBuilder.Where(s.where, args...)
+	}
+	if s.cursorColumn != "" && s.cursor != nil {
+		queryBuilder = queryBuilder.Where(squirrel.GtOrEq{s.cursorColumn: s.cursor})
+	}
+	return queryBuilder.RunWith(db).Query()
+}
+
 func (s *sqlSelectInput) Read(ctx context.Context) (*service.Message, service.AckFunc, error) {
 	s.dbMut.Lock()
 	defer s.dbMut.Unlock()
 
-	if s.db == nil && s.rows == nil {
-		return nil, nil, service.ErrNotConnected
+	for {
+		if s.db == nil && s.rows == nil {
+			return nil, nil, service.ErrNotConnected
+		}
+
+		if s.rows == nil {
+			if s.cursorColumn == "" {
+				return nil, nil, service.ErrEndOfInput
+			}
+			if err := cursor.WaitForPoll(ctx, s.nextPoll, &s.dbMut, s.shutSig.CloseAtLeisureChan()); err != nil {
+				return nil, nil, err
+			}
+			if s.shutSig.ShouldCloseAtLeisure() {
+				return nil, nil, service.ErrEndOfInput
+			}
+			rows, err := s.query(s.db)
+			if err != nil {
+				s.nextPoll = time.Now().Add(s.pollInterval)
+				return nil, nil, err
+			}
+			s.rows = rows
+		}
+
+		if !s.rows.Next() {
+			err := s.rows.Err()
+			_ = s.rows.Close()
+			s.rows = nil
+			if err != nil {
+				return nil, nil, err
+			}
+			if s.cursorColumn == "" {
+				return nil, nil, service.ErrEndOfInput
+			}
+			s.nextPoll = time.Now().Add(s.pollInterval)
+			continue
+		}
+
+		obj, err := sqlRowToMap(s.rows)
+		if err != nil {
+			_ = s.rows.Close()
+			s.rows = nil
+			return nil, nil, err
+		}
+
+		msg := service.NewMessage(nil)
+		msg.SetStructured(obj)
+
+		if s.cursorColumn == "" {
+			return msg, func(ctx context.Context, err error) error {
+				// Nacks are handled by AutoRetryNacks because we don't have an explicit
+				// ack mechanism right now.
+				return nil
+			}, nil
+		}
+
+		rowCursor, exists := obj[s.cursorColumn]
+		if !exists {
+			_ = s.rows.Close()
+			s.rows = nil
+			return nil, nil, fmt.Errorf("cursor column '%v' was not found within the selected columns", s.cursorColumn)
+		}
+
+		rowKeyBytes, err := json.Marshal(obj)
+		if err != nil {
+			_ = s.rows.Close()
+			s.rows = nil
+			return nil, nil, err
+		}
+		rowKey := string(rowKeyBytes)
+
+		atBoundary := s.cursor != nil && sqlCursorEqual(s.cursor, rowCursor)
+		if atBoundary {
+			if _, seen := s.boundaryRows[rowKey]; seen {
+				continue
+			}
+		}
+
+		commit, err := s.cursorCache.Track(ctx, sqlCursor{value: rowCursor}, 1)
+		if err != nil {
+			// The row is selected again by the next query as the cursor has
+			// not moved past it.
+			_ = s.rows.Close()
+			s.rows = nil
+			return nil, nil, err
+		}
+
+		if !atBoundary {
+			s.cursor = rowCursor
+			s.boundaryRows = map[string]struct{}{}
+		}
+		s.boundaryRows[rowKey] = struct{}{}
+
+		return msg, func(ctx context.Context, err error) error {
+			// Nacks are handled by AutoRetryNacks, and so the cursor is only
+			// committed once the row has been delivered.
+			return commit(ctx)
+		}, nil
 	}
+}
+
+// sqlCursor is the persisted form of a cursor value, which records the type
+// of values that JSON has no representation for so that they are restored as
+// the same type.
+type sqlCursor struct {
+	value interface{}
+}
+
+type sqlCursorJSON struct {
+	Type  string      `json:"type,omitempty"`
+	Value interface{} `json:"value"`
+}
+
+const sqlCursorTypeTimestamp = "timestamp"
 
-	if s.rows == nil {
-		return nil, nil, service.ErrEndOfInput
+func (c sqlCursor) MarshalJSON() ([]byte, error) {
+	if t, ok := c.value.(time.Time); ok {
+		return json.Marshal(sqlCursorJSON{Type: sqlCursorTypeTimestamp, Value: t.Format(time.RFC3339Nano)})
 	}
+	return json.Marshal(sqlCursorJSON{Value: c.value})
+}
+
+func (c *sqlCursor) UnmarshalJSON(b []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
 
-	if !s.rows.Next() {
-		err := s.rows.Err()
-		if err == nil {
-			err = service.ErrEndOfInput
+	var raw sqlCursorJSON
+	if err := dec.Decode(&raw); err != nil {
+		return err
+	}
+
+	switch t := raw.Value.(type) {
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			c.value = i
+		} else if c.value, err = t.Float64(); err != nil {
+			return err
+		}
+		return nil
+	case string:
+		if raw.Type == sqlCursorTypeTimestamp {
+			ts, err := time.Parse(time.RFC3339Nano, t)
+			if err != nil {
+				return fmt.Errorf("failed to parse timestamp cursor: %w", err)
+			}
+			c.value = ts
+			return nil
 		}
-		_ = s.rows.Close()
-		s.rows = nil
-		return nil, nil, err
 	}
+	c.value = raw.Value
+	return nil
+}
+
+// sqlCursorEqual returns whether two cursor values are equal, where values of
+// differing numeric types are compared by their formatted value.
+func sqlCursorEqual(a, b interface{}) bool {
+	if at, ok := a.(time.Time); ok {
+		bt, ok := b.(time.Time)
+		return ok && at.Equal(bt)
+	}
+	return fmt.Sprintf("%v", a) == fmt.Sprintf("%v", b)
+}
 
-	obj, err := sqlRowToMap(s.rows)
-	if err != nil {
-		_ = s.rows.Close()
-		s.rows = nil
-		return nil, nil, err
+func (s *sqlSelectInput) loadCursor(ctx context.Context) (interface{}, error) {
+	cursorBytes, err := s.cursorCache.Load(ctx)
+	if err != nil || cursorBytes == nil {
+		return nil, err
 	}
 
-	msg := service.NewMessage(nil)
-	msg.SetStructured(obj)
-	return msg, func(ctx context.Context, err error) error {
-		// Nacks are handled by AutoRetryNacks because we don't have an explicit
-		// ack mechanism right now.
-		return nil
-	}, nil
+	var c sqlCursor
+	if err := json.Unmarshal(cursorBytes, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse cursor: %w", err)
+	}
+	return c.value, nil
 }
 
 func (s *sqlSelectInput) Close(ctx context.Context) error {
@@ -2,8 +2,11 @@ package sql
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/benthosdev/benthos/v4/public/service"
@@ -25,7 +28,57 @@ args_mapping: 'root = [ this.id ]'
 	selectConfig, err := spec.ParseYAML(conf, env)
 	require.NoError(t, err)
 
-	selectInput, err := newSQLSelectInputFromConfig(selectConfig, nil)
+	selectInput, err := newSQLSelectInputFromConfig(selectConfig, service.MockResources())
 	require.NoError(t, err)
 	require.NoError(t, selectInput.Close(context.Background()))
 }
+
+func TestSQLSelectInputCursorConfig(t *testing.T) {
+	spec := sqlSelectInputConfig()
+	env := service.NewEnvironment()
+
+	selectConfig, err := spec.ParseYAML(`
+driver: meow
+dsn: woof
+table: quack
+columns: [ foo, bar, baz ]
+cursor_column: foo
+`, env)
+	require.NoError(t, err)
+
+	_, err = newSQLSelectInputFromConfig(selectConfig, service.MockResources())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "cursor_cache must be specified")
+
+	selectConfig, err = spec.ParseYAML(`
+driver: meow
+dsn: woof
+table: quack
+columns: [ foo, bar, baz ]
+cursor_column: foo
+cursor_cache: nope
+`, env)
+	require.NoError(t, err)
+
+	_, err = newSQLSelectInputFromConfig(selectConfig, service.MockResources())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "cache resource 'nope' was not found")
+}
+
+func TestSQLSelectCursorSerialisation(t *testing.T) {
+	ts := time.Date(2022, 3, 4, 5, 6, 7, 8, time.UTC)
+
+	for _, v := range []interface{}{int64(5), 5.5, "foo", ts} {
+		b, err := json.Marshal(sqlCursor{value: v})
+		require.NoError(t, err)
+
+		var c sqlCursor
+		require.NoError(t, json.Unmarshal(b, &c))
+		assert.Equal(t, v, c.value, string(b))
+	}
+
+	assert.True(t, sqlCursorEqual(ts, ts.In(time.FixedZone("foo", 3600))))
+	assert.False(t, sqlCursorEqual(ts, ts.Format(time.RFC3339Nano)))
+	assert.True(t, sqlCursorEqual(int64(5), int32(5)))
+	assert.False(t, sqlCursorEqual(int64(5), int64(6)))
+}
@@ -512,12 +512,134 @@ processors:
 	})
 }
 
+func testSelectPolling(t *testing.T, driver, dsn, table string) {
+	t.Run("select_polling", func(t *testing.T) {
+		cacheDir := t.TempDir()
+		confReplacer := strings.NewReplacer(
+			"$driver", driver,
+			"$dsn", dsn,
+			"$table", table,
+		)
+
+		insertRows := func(from, to int) {
+			t.Helper()
+
+			streamInBuilder := service.NewStreamBuilder()
+			require.NoError(t, streamInBuilder.SetLoggerYAML(`level: OFF`))
+			require.NoError(t, streamInBuilder.AddOutputYAML(confReplacer.Replace(`
+sql_insert:
+  driver: $driver
+  dsn: $dsn
+  table: $table
+  columns: [ foo, bar, baz ]
+  args_mapping: 'root = [ this.foo, this.bar.floor(), this.baz ]'
+`)))
+
+			inFn, err := streamInBuilder.AddBatchProducerFunc()
+			require.NoError(t, err)
+
+			streamIn, err := streamInBuilder.Build()
+			require.NoError(t, err)
+
+			go func() {
+				assert.NoError(t, streamIn.Run(context.Background()))
+			}()
+
+			var insertBatch service.MessageBatch
+			for i := from; i < to; i++ {
+				insertBatch = append(insertBatch, service.NewMessage([]byte(fmt.Sprintf(`{"foo":"doc-%v","bar":%v,"baz":"and this"}`, i, i))))
+			}
+			require.NoError(t, inFn(context.Background(), insertBatch))
+			require.NoError(t, streamIn.StopWithin(time.Second))
+		}
+
+		// Consumes rows until the expected count is reached and then shuts
+		// the stream down.
+		consumeRows := func(count int) []string {
+			t.Helper()
+
+			streamOutBuilder := service.NewStreamBuilder()
+			require.NoError(t, streamOutBuilder.SetLoggerYAML(`level: OFF`))
+			require.NoError(t, streamOutBuilder.AddCacheYAML(fmt.Sprintf(`
+label: cursors
+file:
+  directory: %v
+`, cacheDir)))
+			require.NoError(t, streamOutBuilder.AddInputYAML(confReplacer.Replace(`
+sql_select:
+  driver: $driver
+  dsn: $dsn
+  table: $table
+  columns: [ "*" ]
+  cursor_column: bar
+  cursor_cache: cursors
+  poll_interval: 100ms
+processors:
+  - bloblang: |
+      root = this
+      root.bar = this.bar.number()
+`)))
+
+			var outMut sync.Mutex
+			var outRows []string
+			doneChan := make(chan struct{})
+			require.NoError(t, streamOutBuilder.AddConsumerFunc(func(c context.Context, m *service.Message) error {
+				msgBytes, err := m.AsBytes()
+				require.NoError(t, err)
+
+				outMut.Lock()
+				outRows = append(outRows, string(msgBytes))
+				if len(outRows) == count {
+					close(doneChan)
+				}
+				outMut.Unlock()
+				return nil
+			}))
+
+			streamOut, err := streamOutBuilder.Build()
+			require.NoError(t, err)
+
+			go func() {
+				assert.NoError(t, streamOut.Run(context.Background()))
+			}()
+
+			select {
+			case <-doneChan:
+			case <-time.After(time.Second * 30):
+				t.Fatal("timed out waiting for rows")
+			}
+			require.NoError(t, streamOut.StopWithin(time.Second*5))
+
+			outMut.Lock()
+			defer outMut.Unlock()
+			return outRows
+		}
+
+		insertRows(0, 3)
+		assert.Equal(t, []string{
+			"{\"bar\":0,\"baz\":\"and this\",\"foo\":\"doc-0\"}",
+			"{\"bar\":1,\"baz\":\"and this\",\"foo\":\"doc-1\"}",
+			"{\"bar\":2,\"baz\":\"and this\",\"foo\":\"doc-2\"}",
+		}, consumeRows(3))
+
+		// A restarted input continues from the committed cursor, where rows
+		// that share the committed cursor value are delivered again.
+		insertRows(3, 5)
+		assert.Equal(t, []string{
+			"{\"bar\":2,\"baz\":\"and this\",\"foo\":\"doc-2\"}",
+			"{\"bar\":3,\"baz\":\"and this\",\"foo\":\"doc-3\"}",
+			"{\"bar\":4,\"baz\":\"and this\",\"foo\":\"doc-4\"}",
+		}, consumeRows(3))
+	})
+}
+
 func testSuite(t *testing.T, driver, dsn string, createTableFn func(string) error) {
 	for _, fn := range []testFn{
 		testBatchProcessorBasic,
 		testBatchProcessorParallel,
 		testBatchInputOutputBatch,
 		testBatchInputOutputRaw,
+		testSelectPolling,
 		testRawProcessorsBasic,
 		testDeprecatedProcessorsBasic,
 	} {
@@ -1,6 +1,7 @@
 package sql
 
 import (
+	"github.com/benthosdev/benthos/v4/internal/docs"
 	"github.com/benthosdev/benthos/v4/public/bloblang"
 	"github.com/benthosdev/benthos/v4/public/service"
 )
@@ -48,6 +49,12 @@ func init() {
 	if err != nil {
 		panic(err)
 	}
+
+	docs.RegisterMigration(docs.Migration{
+		Type:    docs.TypeOutput,
+		Name:    "sql",
+		Migrate: migrateDeprecatedSQL,
+	})
 }
 
 //------------------------------------------------------------------------------
@@ -0,0 +1,371 @@
+package sql
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// pgMessageKind describes the kind of a logical decoding message.
+type pgMessageKind int
+
+const (
+	pgMessageIgnored pgMessageKind = iota
+	pgMessageBegin
+	pgMessageCommit
+	pgMessageChange
+)
+
+// pgChange is a single row change obtained from a logical replication slot.
+type pgChange struct {
+	Operation string
+	Schema    string
+	Table     string
+	Before    map[string]interface{}
+	After     map[string]interface{}
+}
+
+// pgDecoder decodes the output of a logical decoding plugin.
+type pgDecoder interface {
+	decode(data []byte) (pgMessageKind, *pgChange, error)
+}
+
+func newPGDecoder(plugin string) (pgDecoder, error) {
+	switch plugin {
+	case "pgoutput":
+		return &pgoutputDecoder{relations: map[uint32]*pgRelation{}}, nil
+	case "wal2json":
+		return &wal2jsonDecoder{}, nil
+	}
+	return nil, fmt.Errorf("logical decoding plugin not recognised: %v", plugin)
+}
+
+// parseLSN parses a log sequence number in the textual format X/X.
+func parseLSN(s string) (uint64, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("invalid LSN: %v", s)
+	}
+	upper, err := strconv.ParseUint(parts[0], 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid LSN: %v", s)
+	}
+	lower, err := strconv.ParseUint(parts[1], 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid LSN: %v", s)
+	}
+	return upper<<32 | lower, nil
+}
+
+func formatLSN(lsn uint64) string {
+	return fmt.Sprintf("%X/%X", uint32(lsn>>32), uint32(lsn))
+}
+
+//------------------------------------------------------------------------------
+
+type pgColumn struct {
+	name    string
+	typeOID uint32
+}
+
+type pgRelation struct {
+	schema  string
+	table   string
+	columns []pgColumn
+}
+
+// pgoutputDecoder decodes version 1 of the pgoutput protocol, which is the
+// logical decoding plugin built into PostgreSQL 10+. Relation messages are
+// sent before the first change of each relation within a decoding session and
+// are retained for decoding subsequent changes.
+type pgoutputDecoder struct {
+	relations map[uint32]*pgRelation
+}
+
+type pgoutputReader struct {
+	b   []byte
+	err error
+}
+
+func (r *pgoutputReader) fail() {
+	if r.err == nil {
+		r.err = errors.New("unexpected end of pgoutput message")
+	}
+}
+
+func (r *pgoutputReader) byte1() byte {
+	if len(r.b) < 1 {
+		r.fail()
+		return 0
+	}
+	v := r.b[0]
+	r.b = r.b[1:]
+	return v
+}
+
+func (r *pgoutputReader) int16() int {
+	if len(r.b) < 2 {
+		r.fail()
+		return 0
+	}
+	v := binary.BigEndian.Uint16(r.b)
+	r.b = r.b[2:]
+	return int(v)
+}
+
+func (r *pgoutputReader) int32() uint32 {
+	if len(r.b) < 4 {
+		r.fail()
+		return 0
+	}
+	v := binary.BigEndian.Uint32(r.b)
+	r.b = r.b[4:]
+	return v
+}
+
+func (r *pgoutputReader) string() string {
+	i := bytes.IndexByte(r.b, 0)
+	if i < 0 {
+		r.fail()
+		return ""
+	}
+	v := string(r.b[:i])
+	r.b = r.b[i+1:]
+	return v
+}
+
+func (r *pgoutputReader) bytes(n int) []byte {
+	if n < 0 || len(r.b) < n {
+		r.fail()
+		return nil
+	}
+	v := r.b[:n]
+	r.b = r.b[n:]
+	return v
+}
+
+func (d *pgoutputDecoder) tuple(r *pgoutputReader, rel *pgRelation) (map[string]interface{}, error) {
+	n := r.int16()
+	if r.err != nil {
+		return nil, r.err
+	}
+	if n > len(rel.columns) {
+		return nil, fmt.Errorf("tuple of relation %v.%v has %v columns, expected %v", rel.schema, rel.table, n, len(rel.columns))
+	}
+	row := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		col := rel.columns[i]
+		switch kind := r.byte1(); kind {
+		case 'n':
+			row[col.name] = nil
+		case 'u':
+			// Unchanged TOAST values are not sent and are therefore omitted.
+		case 't':
+			l := r.int32()
+			row[col.name] = pgTextValue(col.typeOID, string(r.bytes(int(l))))
+		default:
+			if r.err == nil {
+				return nil, fmt.Errorf("unsupported tuple data kind: %c", kind)
+			}
+		}
+		if r.err != nil {
+			return nil, r.err
+		}
+	}
+	return row, nil
+}
+
+func (d *pgoutputDecoder) relation(r *pgoutputReader) (*pgRelation, error) {
+	id := r.int32()
+	if r.err != nil {
+		return nil, r.err
+	}
+	rel, exists := d.relations[id]
+	if !exists {
+		return nil, fmt.Errorf("relation %v was not found", id)
+	}
+	return rel, nil
+}
+
+func (d *pgoutputDecoder) decode(data []byte) (pgMessageKind, *pgChange, error) {
+	r := &pgoutputReader{b: data}
+	switch r.byte1() {
+	case 'B':
+		return pgMessageBegin, nil, nil
+	case 'C':
+		return pgMessageCommit, nil, nil
+	case 'R':
+		id := r.int32()
+		rel := &pgRelation{
+			schema: r.string(),
+			table:  r.string(),
+		}
+		_ = r.byte1() // Replica identity
+		n := r.int16()
+		for i := 0; i < n && r.err == nil; i++ {
+			_ = r.byte1() // Flags
+			col := pgColumn{name: r.string()}
+			col.typeOID = r.int32()
+			_ = r.int32() // Type modifier
+			rel.columns = append(rel.columns, col)
+		}
+		if r.err != nil {
+			return 0, nil, r.err
+		}
+		d.relations[id] = rel
+		return pgMessageIgnored, nil, nil
+	case 'I':
+		rel, err := d.relation(r)
+		if err != nil {
+			return 0, nil, err
+		}
+		if kind := r.byte1(); kind != 'N' {
+			return 0, nil, fmt.Errorf("unexpected insert tuple kind: %c", kind)
+		}
+		after, err := d.tuple(r, rel)
+		if err != nil {
+			return 0, nil, err
+		}
+		return pgMessageChange, &pgChange{
+			Operation: "insert",
+			Schema:    rel.schema,
+			Table:     rel.table,
+			After:     after,
+		}, nil
+	case 'U':
+		rel, err := d.relation(r)
+		if err != nil {
+			return 0, nil, err
+		}
+		change := &pgChange{
+			Operation: "update",
+			Schema:    rel.schema,
+			Table:     rel.table,
+		}
+		kind := r.byte1()
+		if kind == 'K' || kind == 'O' {
+			if change.Before, err = d.tuple(r, rel); err != nil {
+				return 0, nil, err
+			}
+			kind = r.byte1()
+		}
+		if kind != 'N' {
+			return 0, nil, fmt.Errorf("unexpected update tuple kind: %c", kind)
+		}
+		if change.After, err = d.tuple(r, rel); err != nil {
+			return 0, nil, err
+		}
+		return pgMessageChange, change, nil
+	case 'D':
+		rel, err := d.relation(r)
+		if err != nil {
+			return 0, nil, err
+		}
+		if kind := r.byte1(); kind != 'K' && kind != 'O' {
+			return 0, nil, fmt.Errorf("unexpected delete tuple kind: %c", kind)
+		}
+		before, err := d.tuple(r, rel)
+		if err != nil {
+			return 0, nil, err
+		}
+		return pgMessageChange, &pgChange{
+			Operation: "delete",
+			Schema:    rel.schema,
+			Table:     rel.table,
+			Before:    before,
+		}, nil
+	}
+	if r.err != nil {
+		return 0, nil, r.err
+	}
+	// Origin, type, truncate and generic messages are ignored.
+	return pgMessageIgnored, nil, nil
+}
+
+// pgTextValue converts the text representation of common types into their
+// structured equivalents, other types are returned as strings.
+func pgTextValue(typeOID uint32, v string) interface{} {
+	switch typeOID {
+	case 16: // bool
+		return v == "t"
+	case 20, 21, 23: // int8, int2, int4
+		if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return i
+		}
+	case 700, 701: // float4, float8
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f
+		}
+	case 114, 3802: // json, jsonb
+		var j interface{}
+		if err := json.Unmarshal([]byte(v), &j); err == nil {
+			return j
+		}
+	}
+	return v
+}
+
+//------------------------------------------------------------------------------
+
+// wal2jsonDecoder decodes version 2 of the wal2json format, where each change
+// is emitted as an individual JSON document.
+type wal2jsonDecoder struct{}
+
+type wal2jsonColumn struct {
+	Name  string      `json:"name"`
+	Value interface{} `json:"value"`
+}
+
+type wal2jsonMessage struct {
+	Action   string           `json:"action"`
+	Schema   string           `json:"schema"`
+	Table    string           `json:"table"`
+	Columns  []wal2jsonColumn `json:"columns"`
+	Identity []wal2jsonColumn `json:"identity"`
+}
+
+func wal2jsonRow(cols []wal2jsonColumn) map[string]interface{} {
+	if cols == nil {
+		return nil
+	}
+	row := make(map[string]interface{}, len(cols))
+	for _, c := range cols {
+		row[c.Name] = c.Value
+	}
+	return row
+}
+
+func (d *wal2jsonDecoder) decode(data []byte) (pgMessageKind, *pgChange, error) {
+	var msg wal2jsonMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return 0, nil, fmt.Errorf("failed to parse wal2json message: %w", err)
+	}
+
+	change := &pgChange{
+		Schema: msg.Schema,
+		Table:  msg.Table,
+	}
+	switch msg.Action {
+	case "B":
+		return pgMessageBegin, nil, nil
+	case "C":
+		return pgMessageCommit, nil, nil
+	case "I":
+		change.Operation = "insert"
+		change.After = wal2jsonRow(msg.Columns)
+	case "U":
+		change.Operation = "update"
+		change.Before = wal2jsonRow(msg.Identity)
+		change.After = wal2jsonRow(msg.Columns)
+	case "D":
+		change.Operation = "delete"
+		change.Before = wal2jsonRow(msg.Identity)
+	default:
+		return pgMessageIgnored, nil, nil
+	}
+	return pgMessageChange, change, nil
+}
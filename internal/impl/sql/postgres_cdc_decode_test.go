@@ -0,0 +1,171 @@
+package sql
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type pgoutputBuilder struct {
+	b []byte
+}
+
+func (p *pgoutputBuilder) byte1(v byte) *pgoutputBuilder {
+	p.b = append(p.b, v)
+	return p
+}
+
+func (p *pgoutputBuilder) int16(v int) *pgoutputBuilder {
+	p.b = append(p.b, 0, 0)
+	binary.BigEndian.PutUint16(p.b[len(p.b)-2:], uint16(v))
+	return p
+}
+
+func (p *pgoutputBuilder) int32(v uint32) *pgoutputBuilder {
+	p.b = append(p.b, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(p.b[len(p.b)-4:], v)
+	return p
+}
+
+func (p *pgoutputBuilder) str(v string) *pgoutputBuilder {
+	p.b = append(p.b, v...)
+	p.b = append(p.b, 0)
+	return p
+}
+
+func (p *pgoutputBuilder) text(v string) *pgoutputBuilder {
+	p.byte1('t').int32(uint32(len(v)))
+	p.b = append(p.b, v...)
+	return p
+}
+
+func TestPGOutputDecoder(t *testing.T) {
+	d, err := newPGDecoder("pgoutput")
+	require.NoError(t, err)
+
+	relation := (&pgoutputBuilder{}).
+		byte1('R').int32(16385).str("public").str("foo").byte1('d').int16(3).
+		byte1(1).str("id").int32(23).int32(0xFFFFFFFF).
+		byte1(0).str("name").int32(25).int32(0xFFFFFFFF).
+		byte1(0).str("active").int32(16).int32(0xFFFFFFFF)
+
+	kind, change, err := d.decode(relation.b)
+	require.NoError(t, err)
+	assert.Equal(t, pgMessageIgnored, kind)
+	assert.Nil(t, change)
+
+	kind, _, err = d.decode((&pgoutputBuilder{}).byte1('B').b)
+	require.NoError(t, err)
+	assert.Equal(t, pgMessageBegin, kind)
+
+	insert := (&pgoutputBuilder{}).
+		byte1('I').int32(16385).byte1('N').int16(3).
+		text("1").text("hello").byte1('n')
+
+	kind, change, err = d.decode(insert.b)
+	require.NoError(t, err)
+	assert.Equal(t, pgMessageChange, kind)
+	assert.Equal(t, &pgChange{
+		Operation: "insert",
+		Schema:    "public",
+		Table:     "foo",
+		After:     map[string]interface{}{"id": int64(1), "name": "hello", "active": nil},
+	}, change)
+
+	update := (&pgoutputBuilder{}).
+		byte1('U').int32(16385).
+		byte1('K').int16(3).text("1").byte1('n').byte1('n').
+		byte1('N').int16(3).text("2").byte1('u').text("t")
+
+	kind, change, err = d.decode(update.b)
+	require.NoError(t, err)
+	assert.Equal(t, pgMessageChange, kind)
+	assert.Equal(t, &pgChange{
+		Operation: "update",
+		Schema:    "public",
+		Table:     "foo",
+		Before:    map[string]interface{}{"id": int64(1), "name": nil, "active": nil},
+		After:     map[string]interface{}{"id": int64(2), "active": true},
+	}, change)
+
+	del := (&pgoutputBuilder{}).
+		byte1('D').int32(16385).
+		byte1('O').int16(3).text("2").text("hello").text("f")
+
+	kind, change, err = d.decode(del.b)
+	require.NoError(t, err)
+	assert.Equal(t, pgMessageChange, kind)
+	assert.Equal(t, &pgChange{
+		Operation: "delete",
+		Schema:    "public",
+		Table:     "foo",
+		Before:    map[string]interface{}{"id": int64(2), "name": "hello", "active": false},
+	}, change)
+
+	kind, _, err = d.decode((&pgoutputBuilder{}).byte1('C').b)
+	require.NoError(t, err)
+	assert.Equal(t, pgMessageCommit, kind)
+
+	_, _, err = d.decode((&pgoutputBuilder{}).byte1('I').int32(1).byte1('N').b)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "relation 1 was not found")
+
+	_, _, err = d.decode(insert.b[:10])
+	require.Error(t, err)
+}
+
+func TestWal2JSONDecoder(t *testing.T) {
+	d, err := newPGDecoder("wal2json")
+	require.NoError(t, err)
+
+	kind, _, err := d.decode([]byte(`{"action":"B"}`))
+	require.NoError(t, err)
+	assert.Equal(t, pgMessageBegin, kind)
+
+	kind, change, err := d.decode([]byte(`{"action":"U","schema":"public","table":"foo","columns":[{"name":"id","type":"integer","value":2},{"name":"name","type":"text","value":"hello"}],"identity":[{"name":"id","type":"integer","value":1}]}`))
+	require.NoError(t, err)
+	assert.Equal(t, pgMessageChange, kind)
+	assert.Equal(t, &pgChange{
+		Operation: "update",
+		Schema:    "public",
+		Table:     "foo",
+		Before:    map[string]interface{}{"id": float64(1)},
+		After:     map[string]interface{}{"id": float64(2), "name": "hello"},
+	}, change)
+
+	kind, change, err = d.decode([]byte(`{"action":"D","schema":"public","table":"foo","identity":[{"name":"id","type":"integer","value":2}]}`))
+	require.NoError(t, err)
+	assert.Equal(t, pgMessageChange, kind)
+	assert.Equal(t, "delete", change.Operation)
+	assert.Nil(t, change.After)
+
+	kind, _, err = d.decode([]byte(`{"action":"T","schema":"public","table":"foo"}`))
+	require.NoError(t, err)
+	assert.Equal(t, pgMessageIgnored, kind)
+
+	kind, _, err = d.decode([]byte(`{"action":"C"}`))
+	require.NoError(t, err)
+	assert.Equal(t, pgMessageCommit, kind)
+
+	_, _, err = d.decode([]byte(`not json`))
+	require.Error(t, err)
+}
+
+func TestLSNRoundTrip(t *testing.T) {
+	for _, s := range []string{"0/0", "0/16B6C50", "1/FFFFFFFF", "A3/1"} {
+		lsn, err := parseLSN(s)
+		require.NoError(t, err)
+		assert.Equal(t, s, formatLSN(lsn))
+	}
+
+	lsn, err := parseLSN("1/2")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1<<32|2), lsn)
+
+	for _, s := range []string{"", "0", "x/1", "1/y", "1/2/3"} {
+		_, err := parseLSN(s)
+		assert.Error(t, err, s)
+	}
+}
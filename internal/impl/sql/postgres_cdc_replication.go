@@ -0,0 +1,74 @@
+package sql
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgproto3/v2"
+)
+
+// Messages of the streaming replication protocol are sent within CopyData
+// messages once replication has started, and are identified by their first
+// byte. See https://www.postgresql.org/docs/current/protocol-replication.html.
+const (
+	pgXLogDataByteID                = 'w'
+	pgPrimaryKeepaliveMessageByteID = 'k'
+	pgStandbyStatusUpdateByteID     = 'r'
+)
+
+// pgEpoch is the epoch of timestamps within the replication protocol.
+var pgEpoch = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// pgXLogData contains the output of the logical decoding plugin for a single
+// message, where the WAL start is the LSN of the change, or the end LSN of the
+// transaction for commit messages.
+type pgXLogData struct {
+	walStart     uint64
+	serverWALEnd uint64
+	walData      []byte
+}
+
+func parsePGXLogData(b []byte) (pgXLogData, error) {
+	if len(b) < 24 {
+		return pgXLogData{}, errors.New("XLogData message too short")
+	}
+	return pgXLogData{
+		walStart:     binary.BigEndian.Uint64(b),
+		serverWALEnd: binary.BigEndian.Uint64(b[8:]),
+		walData:      b[24:],
+	}, nil
+}
+
+// pgPrimaryKeepalive is sent periodically by the server, and when a reply is
+// requested a standby status update must be sent promptly in order to avoid
+// the connection being terminated.
+type pgPrimaryKeepalive struct {
+	serverWALEnd   uint64
+	replyRequested bool
+}
+
+func parsePGPrimaryKeepalive(b []byte) (pgPrimaryKeepalive, error) {
+	if len(b) != 17 {
+		return pgPrimaryKeepalive{}, fmt.Errorf("primary keepalive message has unexpected length: %v", len(b))
+	}
+	return pgPrimaryKeepalive{
+		serverWALEnd:   binary.BigEndian.Uint64(b),
+		replyRequested: b[16] != 0,
+	}, nil
+}
+
+// encodePGStandbyStatusUpdate creates a standby status update which reports
+// the positions that have been received and flushed by the client. The
+// confirmed flush LSN of the slot is advanced to the flushed position, unless
+// it is zero.
+func encodePGStandbyStatusUpdate(written, flushed uint64, now time.Time) []byte {
+	data := make([]byte, 34)
+	data[0] = pgStandbyStatusUpdateByteID
+	binary.BigEndian.PutUint64(data[1:], written)
+	binary.BigEndian.PutUint64(data[9:], flushed)
+	binary.BigEndian.PutUint64(data[17:], flushed)
+	binary.BigEndian.PutUint64(data[25:], uint64(now.Sub(pgEpoch).Microseconds()))
+	return (&pgproto3.CopyData{Data: data}).Encode(nil)
+}
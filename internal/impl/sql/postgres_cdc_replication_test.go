@@ -0,0 +1,224 @@
+package sql
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestPGReplicationMessages(t *testing.T) {
+	xld := make([]byte, 24, 27)
+	binary.BigEndian.PutUint64(xld, 0x16B3748)
+	binary.BigEndian.PutUint64(xld[8:], 0x16B3800)
+	xld = append(xld, "foo"...)
+
+	x, err := parsePGXLogData(xld)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0x16B3748), x.walStart)
+	assert.Equal(t, uint64(0x16B3800), x.serverWALEnd)
+	assert.Equal(t, "foo", string(x.walData))
+
+	_, err = parsePGXLogData(xld[:10])
+	require.Error(t, err)
+
+	ka := make([]byte, 17)
+	binary.BigEndian.PutUint64(ka, 0x16B3800)
+	ka[16] = 1
+
+	k, err := parsePGPrimaryKeepalive(ka)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0x16B3800), k.serverWALEnd)
+	assert.True(t, k.replyRequested)
+
+	_, err = parsePGPrimaryKeepalive(ka[:16])
+	require.Error(t, err)
+
+	status := encodePGStandbyStatusUpdate(0x20, 0x10, pgEpoch.Add(time.Second))
+	require.Len(t, status, 39)
+	assert.Equal(t, byte('d'), status[0])
+	assert.Equal(t, uint32(38), binary.BigEndian.Uint32(status[1:]))
+
+	data := status[5:]
+	assert.Equal(t, byte('r'), data[0])
+	assert.Equal(t, uint64(0x20), binary.BigEndian.Uint64(data[1:]))
+	assert.Equal(t, uint64(0x10), binary.BigEndian.Uint64(data[9:]))
+	assert.Equal(t, uint64(0x10), binary.BigEndian.Uint64(data[17:]))
+	assert.Equal(t, uint64(1000000), binary.BigEndian.Uint64(data[25:]))
+	assert.Equal(t, byte(0), data[33])
+}
+
+func TestPGStartReplicationQuery(t *testing.T) {
+	p := &postgresCDCInput{
+		slotName:     `foo"slot`,
+		plugin:       "pgoutput",
+		publications: []string{"bar", `it's"baz`},
+	}
+	assert.Equal(t, `START_REPLICATION SLOT "foo""slot" LOGICAL 0/0 (proto_version '1', publication_names '"bar","it''s""baz"')`, p.startReplicationQuery())
+
+	p.plugin = "wal2json"
+	assert.Equal(t, `START_REPLICATION SLOT "foo""slot" LOGICAL 0/0 ("format-version" '2', "include-transaction" 'true')`, p.startReplicationQuery())
+}
+
+// pgFakeServer is a minimal stand-in of a PostgreSQL server that accepts a
+// replication connection and streams messages to it.
+type pgFakeServer struct {
+	t       *testing.T
+	conn    net.Conn
+	backend *pgproto3.Backend
+}
+
+func newPGFakeServer(t *testing.T) (*pgFakeServer, *pgconn.PgConn) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	clientConn, err := net.Dial("tcp", ln.Addr().String())
+	require.NoError(t, err)
+
+	serverConn, err := ln.Accept()
+	require.NoError(t, err)
+	t.Cleanup(func() { serverConn.Close() })
+
+	s := &pgFakeServer{
+		t:       t,
+		conn:    serverConn,
+		backend: pgproto3.NewBackend(pgproto3.NewChunkReader(serverConn), serverConn),
+	}
+
+	started := make(chan error, 1)
+	go func() {
+		if _, err := s.backend.ReceiveStartupMessage(); err != nil {
+			started <- err
+			return
+		}
+		if err := s.backend.Send(&pgproto3.AuthenticationOk{}); err != nil {
+			started <- err
+			return
+		}
+		started <- s.backend.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+	}()
+
+	conf, err := pgconn.ParseConfig("postgres://foo@localhost/bar?sslmode=disable")
+	require.NoError(t, err)
+	conf.DialFunc = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return clientConn, nil
+	}
+
+	conn, err := pgconn.ConnectConfig(context.Background(), conf)
+	require.NoError(t, err)
+	require.NoError(t, <-started)
+	return s, conn
+}
+
+func (s *pgFakeServer) sendXLogData(walStart uint64, data string) {
+	b := make([]byte, 25, 25+len(data))
+	b[0] = pgXLogDataByteID
+	binary.BigEndian.PutUint64(b[1:], walStart)
+	binary.BigEndian.PutUint64(b[9:], walStart)
+	b = append(b, data...)
+	require.NoError(s.t, s.backend.Send(&pgproto3.CopyData{Data: b}))
+}
+
+func (s *pgFakeServer) sendKeepalive(walEnd uint64) {
+	b := make([]byte, 18)
+	b[0] = pgPrimaryKeepaliveMessageByteID
+	binary.BigEndian.PutUint64(b[1:], walEnd)
+	b[17] = 1
+	require.NoError(s.t, s.backend.Send(&pgproto3.CopyData{Data: b}))
+}
+
+// waitForFlush reads standby status updates until one reports the given flush
+// position.
+func (s *pgFakeServer) waitForFlush(lsn uint64) {
+	for {
+		msg, err := s.backend.Receive()
+		require.NoError(s.t, err)
+		if cd, ok := msg.(*pgproto3.CopyData); ok && cd.Data[0] == pgStandbyStatusUpdateByteID {
+			if binary.BigEndian.Uint64(cd.Data[9:]) == lsn {
+				return
+			}
+		}
+	}
+}
+
+func TestPostgresCDCReplication(t *testing.T) {
+	conf, err := postgresCDCInputConfig().ParseYAML(`
+dsn: postgres://foo@localhost/bar
+slot_name: foo_slot
+plugin: wal2json
+status_interval: 10ms
+`, nil)
+	require.NoError(t, err)
+
+	p, err := newPostgresCDCInputFromConfig(conf, nil)
+	require.NoError(t, err)
+
+	server, conn := newPGFakeServer(t)
+
+	go func() {
+		msg, err := server.backend.Receive()
+		require.NoError(t, err)
+		assert.Equal(t, &pgproto3.Query{String: p.startReplicationQuery()}, msg)
+		// The encoding of CopyBothResponse within pgproto3 omits the overall
+		// format and so the message is written manually.
+		_, err = server.conn.Write([]byte{'W', 0, 0, 0, 7, 0, 0, 0})
+		require.NoError(t, err)
+	}()
+	require.NoError(t, p.startReplication(context.Background(), conn))
+
+	decoder, err := newPGDecoder("wal2json")
+	require.NoError(t, err)
+
+	p.changes = make(chan pgQueuedChange)
+	go p.replicate(conn, decoder, p.changes)
+
+	server.sendXLogData(0x100, `{"action":"B"}`)
+	server.sendXLogData(0x110, `{"action":"I","schema":"public","table":"foo","columns":[{"name":"id","type":"integer","value":1}]}`)
+	server.sendXLogData(0x120, `{"action":"I","schema":"public","table":"foo","columns":[{"name":"id","type":"integer","value":2}]}`)
+	server.sendXLogData(0x130, `{"action":"C"}`)
+
+	// Status updates are sent whilst waiting for changes to be read.
+	server.waitForFlush(0)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	var acks []service.AckFunc
+	for _, id := range []string{"1", "2"} {
+		msg, ackFn, err := p.Read(ctx)
+		require.NoError(t, err)
+
+		b, err := msg.AsBytes()
+		require.NoError(t, err)
+		assert.Equal(t, `{"after":{"id":`+id+`},"before":null}`, string(b))
+
+		commitLSN, _ := msg.MetaGet("postgres_cdc_commit_lsn")
+		assert.Equal(t, "0/130", commitLSN)
+		acks = append(acks, ackFn)
+	}
+
+	// Status updates continue to be sent whilst changes are pending, and only
+	// report the transaction as flushed once all of its changes are acked.
+	server.waitForFlush(0)
+	require.NoError(t, acks[1](ctx, nil))
+	server.waitForFlush(0)
+	require.NoError(t, acks[0](ctx, nil))
+	server.waitForFlush(0x130)
+
+	// Once nothing is pending keepalives advance the slot.
+	server.sendKeepalive(0x200)
+	server.waitForFlush(0x200)
+
+	require.NoError(t, p.Close(ctx))
+}
@@ -2,6 +2,14 @@ package sql
 
 import (
 	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/docs"
 )
 
 func sqlRowsToArray(rows *sql.Rows) ([]interface{}, error) {
@@ -76,3 +84,98 @@ func sqlRowToMap(rows *sql.Rows) (map[string]interface{}, error) {
 	}
 	return jObj, nil
 }
+
+//------------------------------------------------------------------------------
+
+var (
+	sqlInsertQueryRegexp  = regexp.MustCompile(`(?is)^\s*INSERT\s+INTO\s+([\w."]+)\s*\(([^)]*)\)\s*VALUES\s*\(([^)]*)\)\s*;?\s*$`)
+	sqlNumberedArgsRegexp = regexp.MustCompile(`^\$(\d+)$`)
+)
+
+// sqlInsertFromQuery attempts to extract the table and columns of an insert
+// query where each value is a placeholder argument, in the order of the
+// columns.
+func sqlInsertFromQuery(query string) (table string, columns []string, ok bool) {
+	m := sqlInsertQueryRegexp.FindStringSubmatch(query)
+	if m == nil {
+		return "", nil, false
+	}
+	for _, c := range strings.Split(m[2], ",") {
+		if c = strings.TrimSpace(c); c == "" {
+			return "", nil, false
+		}
+		columns = append(columns, c)
+	}
+	values := strings.Split(m[3], ",")
+	if len(values) != len(columns) {
+		return "", nil, false
+	}
+	for i, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "?" {
+			continue
+		}
+		if n := sqlNumberedArgsRegexp.FindStringSubmatch(v); n == nil || n[1] != strconv.Itoa(i+1) {
+			return "", nil, false
+		}
+	}
+	return m[1], columns, true
+}
+
+// migrateDeprecatedSQL rewrites the config of a deprecated sql output or
+// processor into an sql_insert component when its query is a basic insert, and
+// an sql_raw component otherwise.
+func migrateDeprecatedSQL(c docs.WalkedYAMLComponent) ([]string, error) {
+	conf := c.Config
+	if conf == nil {
+		conf = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		docs.YAMLMappingSet(c.Node, c.Name, conf)
+	}
+	if conf.Kind != yaml.MappingNode {
+		if conf.Kind != 0 && conf.Tag != "!!null" {
+			return nil, fmt.Errorf("expected object value, got %v", conf.Tag)
+		}
+		*conf = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	}
+
+	docs.YAMLMappingRename(conf, "data_source_name", "dsn")
+
+	execOnly := true
+	if c.Type == docs.TypeProcessor {
+		if codec := docs.YAMLMappingRemove(conf, "result_codec"); codec != nil && codec.Value != "none" {
+			execOnly = false
+		}
+	}
+
+	dynamicQuery := false
+	if dyn := docs.YAMLMappingGet(conf, "unsafe_dynamic_query"); dyn != nil && dyn.Value == "true" {
+		dynamicQuery = true
+	}
+
+	if query := docs.YAMLMappingGet(conf, "query"); query != nil && execOnly && !dynamicQuery && docs.YAMLMappingGet(conf, "args_mapping") != nil {
+		if table, columns, ok := sqlInsertFromQuery(query.Value); ok {
+			columnsNode := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Style: yaml.FlowStyle}
+			for _, col := range columns {
+				columnsNode.Content = append(columnsNode.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: col})
+			}
+
+			docs.YAMLMappingRename(conf, "query", "table")
+			docs.YAMLMappingSet(conf, "table", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: table})
+			docs.YAMLMappingSetAfter(conf, "table", "columns", columnsNode)
+			docs.YAMLMappingRemove(conf, "unsafe_dynamic_query")
+
+			c.Rename("sql_insert")
+			return []string{fmt.Sprintf("deprecated %v sql replaced with sql_insert", c.Type)}, nil
+		}
+	}
+
+	if c.Type == docs.TypeProcessor && execOnly {
+		after := "args_mapping"
+		if docs.YAMLMappingGet(conf, after) == nil {
+			after = "query"
+		}
+		docs.YAMLMappingSetAfter(conf, after, "exec_only", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: "true"})
+	}
+	c.Rename("sql_raw")
+	return []string{fmt.Sprintf("deprecated %v sql replaced with sql_raw", c.Type)}, nil
+}
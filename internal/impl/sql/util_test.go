@@ -0,0 +1,104 @@
+package sql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+func TestSQLInsertFromQuery(t *testing.T) {
+	tests := []struct {
+		query   string
+		table   string
+		columns []string
+	}{
+		{query: "INSERT INTO footable (foo, bar, baz) VALUES (?, ?, ?);", table: "footable", columns: []string{"foo", "bar", "baz"}},
+		{query: "insert into foo.bar (a,b)\nvalues ($1, $2)", table: "foo.bar", columns: []string{"a", "b"}},
+		{query: "INSERT INTO footable (foo, bar) VALUES ($2, $1);"},
+		{query: "INSERT INTO footable (foo, bar) VALUES (?, NOW());"},
+		{query: "INSERT INTO footable (foo) VALUES (?) ON CONFLICT DO NOTHING;"},
+		{query: "UPDATE footable SET foo = ?;"},
+	}
+
+	for _, test := range tests {
+		table, columns, ok := sqlInsertFromQuery(test.query)
+		assert.Equal(t, test.table != "", ok, test.query)
+		assert.Equal(t, test.table, table, test.query)
+		assert.Equal(t, test.columns, columns, test.query)
+	}
+}
+
+func TestMigrateDeprecatedSQL(t *testing.T) {
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(`
+pipeline:
+  processors:
+    - sql:
+        driver: postgres
+        # The database
+        data_source_name: postgres://localhost/foo
+        query: INSERT INTO footable (foo, bar) VALUES ($1, $2);
+        args_mapping: root = [ this.foo, this.bar ]
+    - sql:
+        driver: mysql
+        data_source_name: foo
+        query: SELECT * FROM bar WHERE id = ?
+        args_mapping: root = [ this.id ]
+        result_codec: json_array
+    - sql:
+        driver: mysql
+        data_source_name: foo
+        query: DELETE FROM bar
+output:
+  sql:
+    driver: mysql
+    data_source_name: foo
+    query: INSERT INTO footable (foo) VALUES (?);
+    max_in_flight: 4
+`), &node))
+
+	changes, err := docs.MigrateYAML(nil, docs.FieldSpecs{
+		docs.FieldObject("pipeline", "").WithChildren(docs.FieldProcessor("processors", "").Array()),
+		docs.FieldOutput("output", ""),
+	}, &node)
+	require.NoError(t, err)
+	assert.Equal(t, []docs.MigrationChange{
+		{Line: 4, What: "deprecated processor sql replaced with sql_insert"},
+		{Line: 10, What: "deprecated processor sql replaced with sql_raw"},
+		{Line: 16, What: "deprecated processor sql replaced with sql_raw"},
+		{Line: 21, What: "deprecated output sql replaced with sql_raw"},
+	}, changes)
+
+	resBytes, err := yaml.Marshal(&node)
+	require.NoError(t, err)
+	assert.Equal(t, `pipeline:
+    processors:
+        - sql_insert:
+            driver: postgres
+            # The database
+            dsn: postgres://localhost/foo
+            table: footable
+            columns: [foo, bar]
+            args_mapping: root = [ this.foo, this.bar ]
+        - sql_raw:
+            driver: mysql
+            dsn: foo
+            query: SELECT * FROM bar WHERE id = ?
+            args_mapping: root = [ this.id ]
+        - sql_raw:
+            driver: mysql
+            dsn: foo
+            query: DELETE FROM bar
+            exec_only: true
+output:
+    sql_raw:
+        driver: mysql
+        dsn: foo
+        query: INSERT INTO footable (foo) VALUES (?);
+        max_in_flight: 4
+`, string(resBytes))
+}
@@ -0,0 +1,114 @@
+package syslog
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// syslogFrameReader splits a stream of syslog messages according to the
+// framing methods of RFC 6587.
+type syslogFrameReader struct {
+	r         *bufio.Reader
+	framing   string
+	maxBuffer int
+}
+
+func newSyslogFrameReader(r io.Reader, framing string, maxBuffer int) *syslogFrameReader {
+	size := maxBuffer
+	if size > 65536 {
+		size = 65536
+	}
+	return &syslogFrameReader{
+		r:         bufio.NewReaderSize(r, size),
+		framing:   framing,
+		maxBuffer: maxBuffer,
+	}
+}
+
+// Next returns the next non-empty message of the stream, or io.EOF once the
+// stream has ended.
+func (f *syslogFrameReader) Next() ([]byte, error) {
+	for {
+		octetCounting := f.framing == "octet_counting"
+		if f.framing == "auto" {
+			b, err := f.r.Peek(1)
+			if err != nil {
+				return nil, err
+			}
+			octetCounting = b[0] >= '1' && b[0] <= '9'
+		}
+
+		var frame []byte
+		var err error
+		if octetCounting {
+			frame, err = f.readOctetCounted()
+		} else {
+			frame, err = f.readLine()
+		}
+		if err != nil || len(frame) > 0 {
+			return frame, err
+		}
+	}
+}
+
+func (f *syslogFrameReader) readOctetCounted() ([]byte, error) {
+	var length int
+	for digits := 0; ; digits++ {
+		c, err := f.r.ReadByte()
+		if err != nil {
+			if err == io.EOF && digits > 0 {
+				return nil, io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		if c == ' ' && digits > 0 {
+			break
+		}
+		if c < '0' || c > '9' || digits >= 10 {
+			return nil, errors.New("invalid octet count")
+		}
+		length = length*10 + int(c-'0')
+	}
+	if length > f.maxBuffer {
+		return nil, fmt.Errorf("message length %v exceeds max_buffer", length)
+	}
+	frame := make([]byte, length)
+	if _, err := io.ReadFull(f.r, frame); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return trimSyslogFrame(frame), nil
+}
+
+func (f *syslogFrameReader) readLine() ([]byte, error) {
+	var line []byte
+	for {
+		chunk, err := f.r.ReadSlice('\n')
+		line = append(line, chunk...)
+		if len(line) > f.maxBuffer {
+			return nil, fmt.Errorf("message exceeds max_buffer")
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil {
+			if err == io.EOF && len(line) > 0 {
+				// The final message of a stream may lack a trailer.
+				return trimSyslogFrame(line), nil
+			}
+			return nil, err
+		}
+		return trimSyslogFrame(line), nil
+	}
+}
+
+func trimSyslogFrame(b []byte) []byte {
+	for len(b) > 0 && (b[len(b)-1] == '\n' || b[len(b)-1] == '\r' || b[len(b)-1] == 0) {
+		b = b[:len(b)-1]
+	}
+	return b
+}
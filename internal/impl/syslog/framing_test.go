@@ -0,0 +1,94 @@
+package syslog
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyslogFrameReader(t *testing.T) {
+	tests := []struct {
+		name        string
+		framing     string
+		input       string
+		maxBuffer   int
+		frames      []string
+		errContains string
+	}{
+		{
+			name:    "non transparent",
+			framing: "non_transparent",
+			input:   "<1>1 - - - - - - foo\r\n<1>1 - - - - - - bar\n\n<1>1 - - - - - - baz",
+			frames:  []string{"<1>1 - - - - - - foo", "<1>1 - - - - - - bar", "<1>1 - - - - - - baz"},
+		},
+		{
+			name:    "octet counting",
+			framing: "octet_counting",
+			input:   "20 <1>1 - - - - - - foo20 <1>1 - - - - - - b\nr",
+			frames:  []string{"<1>1 - - - - - - foo", "<1>1 - - - - - - b\nr"},
+		},
+		{
+			name:    "auto mixed",
+			framing: "auto",
+			input:   "20 <1>1 - - - - - - foo<1>1 - - - - - - bar\n20 <1>1 - - - - - - baz",
+			frames:  []string{"<1>1 - - - - - - foo", "<1>1 - - - - - - bar", "<1>1 - - - - - - baz"},
+		},
+		{
+			name:        "octet count exceeds buffer",
+			framing:     "octet_counting",
+			input:       "20 <1>1 - - - - - - foo",
+			maxBuffer:   10,
+			errContains: "exceeds max_buffer",
+		},
+		{
+			name:        "line exceeds buffer",
+			framing:     "non_transparent",
+			input:       "<1>1 - - - - - - foo\n",
+			maxBuffer:   10,
+			errContains: "exceeds max_buffer",
+		},
+		{
+			name:        "truncated octet counted message",
+			framing:     "octet_counting",
+			input:       "30 <1>1 - - - - - - foo",
+			errContains: "unexpected EOF",
+		},
+		{
+			name:        "invalid octet count",
+			framing:     "octet_counting",
+			input:       "2a <1>1 - - - - - - foo",
+			errContains: "invalid octet count",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			maxBuffer := test.maxBuffer
+			if maxBuffer == 0 {
+				maxBuffer = 1000
+			}
+			r := newSyslogFrameReader(bytes.NewReader([]byte(test.input)), test.framing, maxBuffer)
+
+			var frames []string
+			for {
+				frame, err := r.Next()
+				if err == io.EOF {
+					break
+				}
+				if test.errContains != "" {
+					require.Error(t, err)
+					assert.Contains(t, err.Error(), test.errContains)
+					return
+				}
+				require.NoError(t, err)
+				frames = append(frames, string(frame))
+			}
+			require.Empty(t, test.errContains)
+			assert.Equal(t, test.frames, frames)
+		})
+	}
+}
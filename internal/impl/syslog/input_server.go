@@ -0,0 +1,483 @@
+package syslog
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/go-syslog/v3"
+	"github.com/influxdata/go-syslog/v3/rfc3164"
+	"github.com/influxdata/go-syslog/v3/rfc5424"
+
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func syslogServerInputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Network").
+		Version("4.0.0").
+		Summary("Creates a server that receives syslog messages over tcp or udp.").
+		Description(`
+Messages are parsed according to the ` + "`format`" + `, which is either [RFC 5424](https://tools.ietf.org/html/rfc5424) or [RFC 3164](https://tools.ietf.org/html/rfc3164). The contents of each message are the MSG part of the syslog message, and the remaining fields are added as metadata.
+
+### Framing
+
+Messages received over ` + "`tcp`" + ` are framed according to [RFC 6587](https://tools.ietf.org/html/rfc6587), either with octet counting, where each message is prefixed with its length, or with non-transparent framing, where each message is terminated by a line feed. When ` + "`framing`" + ` is set to ` + "`auto`" + ` the method is detected for each message individually. Each datagram received over ` + "`udp`" + ` is treated as a single message.
+
+The field ` + "`max_buffer`" + ` specifies the maximum size of a single message. If a message from a connection exceeds this value then the connection will be closed.
+
+### TLS
+
+Connections of the ` + "`tcp`" + ` network can be encrypted with TLS as described in [RFC 5425](https://tools.ietf.org/html/rfc5425) by enabling the field ` + "`tls`" + `, where the certificates listed in ` + "`client_certs`" + ` are presented by the server. Mutual TLS is enabled by specifying ` + "`root_cas`" + ` or ` + "`root_cas_file`" + `, in which case clients must present a certificate signed by one of those certificate authorities.
+
+### Metadata
+
+This input adds the following metadata fields to each message when they are present:
+
+` + "```text" + `
+- syslog_facility
+- syslog_severity
+- syslog_priority
+- syslog_timestamp
+- syslog_hostname
+- syslog_appname
+- syslog_procid
+- syslog_msgid
+- syslog_version
+- syslog_remote_addr
+- syslog_sd_<id>.<param>
+` + "```" + `
+
+Where ` + "`syslog_timestamp`" + ` is formatted as RFC 3339, and each parameter of the structured data of an RFC 5424 message is added with the key ` + "`syslog_sd_<id>.<param>`" + `, e.g. ` + "`syslog_sd_exampleSDID@32473.eventID`" + `.
+
+Messages that cannot be parsed are not dropped. Instead, the raw message is passed on with the metadata field ` + "`syslog_error`" + ` containing the reason it could not be parsed.
+
+You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#metadata).`).
+		Field(service.NewStringEnumField("network", "tcp", "udp").
+			Description("A network type to accept.").
+			Default("tcp")).
+		Field(service.NewStringField("address").
+			Description("The address to listen from.").
+			Example("0.0.0.0:514").
+			Example("0.0.0.0:6514")).
+		Field(service.NewStringEnumField("format", "rfc5424", "rfc3164").
+			Description("The syslog format of messages.").
+			Default("rfc5424")).
+		Field(service.NewStringAnnotatedEnumField("framing", map[string]string{
+			"auto":            "Detect the framing method of each message.",
+			"octet_counting":  "Messages are prefixed with their length in bytes followed by a space.",
+			"non_transparent": "Messages are terminated by a line feed.",
+		}).
+			Description("The framing method of messages received over tcp.").
+			Default("auto")).
+		Field(service.NewBoolField("best_effort").
+			Description("Whether to accept messages that are only partially valid, retaining the fields that could be parsed.").
+			Default(true).
+			Advanced()).
+		Field(service.NewIntField("max_buffer").
+			Description("The maximum message buffer size. Must exceed the largest message to be consumed.").
+			Default(1000000).
+			Advanced()).
+		Field(service.NewTLSToggledField("tls").
+			Description("TLS settings for accepting encrypted connections over `tcp`. The certificates listed in `client_certs` are presented by the server, and when `root_cas` or `root_cas_file` are specified clients must present a certificate signed by one of them.")).
+		Example("Syslog over TLS", "This input receives RFC 5424 messages secured with TLS and writes the message of each one to stdout along with its host and application.", `
+input:
+  syslog_server:
+    network: tcp
+    address: 0.0.0.0:6514
+    tls:
+      enabled: true
+      client_certs:
+        - cert_file: ./server.pem
+          key_file: ./server.key
+
+pipeline:
+  processors:
+    - bloblang: |
+        root.message = content().string()
+        root.host = meta("syslog_hostname")
+        root.app = meta("syslog_appname")
+
+output:
+  stdout: {}
+`)
+}
+
+func init() {
+	err := service.RegisterInput(
+		"syslog_server", syslogServerInputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Input, error) {
+			i, err := newSyslogServerInputFromConfig(conf, mgr.Logger())
+			if err != nil {
+				return nil, err
+			}
+			return service.AutoRetryNacks(i), nil
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type syslogServerInput struct {
+	network    string
+	address    string
+	format     string
+	framing    string
+	bestEffort bool
+	maxBuffer  int
+	tlsConf    *tls.Config
+
+	log     *service.Logger
+	msgChan chan *service.Message
+
+	connMut  sync.Mutex
+	listener net.Listener
+	conn     net.PacketConn
+
+	connWG  sync.WaitGroup
+	shutSig *shutdown.Signaller
+}
+
+func newSyslogServerInputFromConfig(conf *service.ParsedConfig, log *service.Logger) (*syslogServerInput, error) {
+	s := &syslogServerInput{
+		log:     log,
+		msgChan: make(chan *service.Message),
+		shutSig: shutdown.NewSignaller(),
+	}
+
+	var err error
+	if s.network, err = conf.FieldString("network"); err != nil {
+		return nil, err
+	}
+	if s.address, err = conf.FieldString("address"); err != nil {
+		return nil, err
+	}
+	if s.format, err = conf.FieldString("format"); err != nil {
+		return nil, err
+	}
+	if s.framing, err = conf.FieldString("framing"); err != nil {
+		return nil, err
+	}
+	if s.bestEffort, err = conf.FieldBool("best_effort"); err != nil {
+		return nil, err
+	}
+	if s.maxBuffer, err = conf.FieldInt("max_buffer"); err != nil {
+		return nil, err
+	}
+
+	switch s.network {
+	case "tcp", "udp":
+	default:
+		return nil, fmt.Errorf("syslog network '%v' is not supported by this input", s.network)
+	}
+	switch s.format {
+	case "rfc5424", "rfc3164":
+	default:
+		return nil, fmt.Errorf("syslog format '%v' is not supported", s.format)
+	}
+	switch s.framing {
+	case "auto", "octet_counting", "non_transparent":
+	default:
+		return nil, fmt.Errorf("syslog framing '%v' is not supported", s.framing)
+	}
+	if s.maxBuffer <= 0 {
+		return nil, errors.New("max_buffer must be greater than zero")
+	}
+
+	tlsConf, tlsEnabled, err := conf.FieldTLSToggled("tls")
+	if err != nil {
+		return nil, err
+	}
+	if tlsEnabled {
+		if s.network != "tcp" {
+			return nil, fmt.Errorf("tls is not supported with the syslog network '%v'", s.network)
+		}
+		if tlsConf == nil || len(tlsConf.Certificates) == 0 {
+			return nil, errors.New("at least one certificate must be specified in tls.client_certs")
+		}
+		// The root certificate authorities verify the certificates presented
+		// by clients, which enables mutual TLS.
+		if tlsConf.RootCAs != nil {
+			tlsConf.ClientCAs = tlsConf.RootCAs
+			tlsConf.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+		s.tlsConf = tlsConf
+	}
+	return s, nil
+}
+
+//------------------------------------------------------------------------------
+
+// newParser creates a syslog parser, which must not be shared between
+// connections as parsers are stateful.
+func (s *syslogServerInput) newParser() syslog.Machine {
+	if s.format == "rfc3164" {
+		opts := []syslog.MachineOption{rfc3164.WithYear(rfc3164.CurrentYear{})}
+		if s.bestEffort {
+			opts = append(opts, rfc3164.WithBestEffort())
+		}
+		return rfc3164.NewParser(opts...)
+	}
+	var opts []syslog.MachineOption
+	if s.bestEffort {
+		opts = append(opts, rfc5424.WithBestEffort())
+	}
+	return rfc5424.NewParser(opts...)
+}
+
+func (s *syslogServerInput) toMessage(parser syslog.Machine, frame []byte, remoteAddr net.Addr) *service.Message {
+	res, err := parser.Parse(frame)
+	if err != nil && (res == nil || !s.bestEffort) {
+		s.log.Debugf("Failed to parse syslog message: %v", err)
+		msg := service.NewMessage(frame)
+		msg.MetaSet("syslog_error", err.Error())
+		if remoteAddr != nil {
+			msg.MetaSet("syslog_remote_addr", remoteAddr.String())
+		}
+		return msg
+	}
+
+	var base syslog.Base
+	msg := service.NewMessage(nil)
+	switch m := res.(type) {
+	case *rfc5424.SyslogMessage:
+		base = m.Base
+		msg.MetaSet("syslog_version", strconv.Itoa(int(m.Version)))
+		if m.StructuredData != nil {
+			for id, params := range *m.StructuredData {
+				for k, v := range params {
+					msg.MetaSet("syslog_sd_"+id+"."+k, v)
+				}
+			}
+		}
+	case *rfc3164.SyslogMessage:
+		base = m.Base
+	}
+
+	if base.Message != nil {
+		msg.SetBytes([]byte(*base.Message))
+	}
+	setUint := func(key string, v *uint8) {
+		if v != nil {
+			msg.MetaSet(key, strconv.Itoa(int(*v)))
+		}
+	}
+	setUint("syslog_facility", base.Facility)
+	setUint("syslog_severity", base.Severity)
+	setUint("syslog_priority", base.Priority)
+	if base.Timestamp != nil {
+		msg.MetaSet("syslog_timestamp", base.Timestamp.Format(time.RFC3339Nano))
+	}
+	setStr := func(key string, v *string) {
+		if v != nil {
+			msg.MetaSet(key, *v)
+		}
+	}
+	setStr("syslog_hostname", base.Hostname)
+	setStr("syslog_appname", base.Appname)
+	setStr("syslog_procid", base.ProcID)
+	setStr("syslog_msgid", base.MsgID)
+	if remoteAddr != nil {
+		msg.MetaSet("syslog_remote_addr", remoteAddr.String())
+	}
+	return msg
+}
+
+// sendMsg blocks until a message is consumed, returning false if the input is
+// closed beforehand.
+func (s *syslogServerInput) sendMsg(msg *service.Message) bool {
+	select {
+	case s.msgChan <- msg:
+		return true
+	case <-s.shutSig.CloseAtLeisureChan():
+		return false
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// addr returns the address of the underlying listener, or nil if the input is
+// not yet connected.
+func (s *syslogServerInput) addr() net.Addr {
+	s.connMut.Lock()
+	defer s.connMut.Unlock()
+
+	if s.listener != nil {
+		return s.listener.Addr()
+	}
+	if s.conn != nil {
+		return s.conn.LocalAddr()
+	}
+	return nil
+}
+
+func (s *syslogServerInput) Connect(ctx context.Context) error {
+	s.connMut.Lock()
+	defer s.connMut.Unlock()
+
+	if s.listener != nil || s.conn != nil {
+		return nil
+	}
+	if s.shutSig.ShouldCloseAtLeisure() {
+		return service.ErrEndOfInput
+	}
+
+	if s.network == "udp" {
+		conn, err := net.ListenPacket(s.network, s.address)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+		s.log.Infof("Receiving udp syslog messages from address: %v", conn.LocalAddr())
+
+		s.connWG.Add(1)
+		go s.udpLoop(conn)
+		return nil
+	}
+
+	listener, err := net.Listen(s.network, s.address)
+	if err != nil {
+		return err
+	}
+	if s.tlsConf != nil {
+		listener = tls.NewListener(listener, s.tlsConf)
+	}
+	s.listener = listener
+	s.log.Infof("Receiving syslog messages from address: %v", listener.Addr())
+
+	s.connWG.Add(1)
+	go s.loop(listener)
+	return nil
+}
+
+func (s *syslogServerInput) loop(listener net.Listener) {
+	defer s.connWG.Done()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if s.shutSig.ShouldCloseAtLeisure() {
+				return
+			}
+			if !strings.Contains(err.Error(), "use of closed network connection") {
+				s.log.Errorf("Failed to accept syslog connection: %v", err)
+			}
+			select {
+			case <-time.After(time.Second):
+				continue
+			case <-s.shutSig.CloseAtLeisureChan():
+				return
+			}
+		}
+
+		connDone := make(chan struct{})
+		go func() {
+			select {
+			case <-connDone:
+			case <-s.shutSig.CloseAtLeisureChan():
+			}
+			conn.Close()
+		}()
+
+		s.connWG.Add(1)
+		go func(c net.Conn) {
+			defer func() {
+				close(connDone)
+				s.connWG.Done()
+			}()
+
+			parser := s.newParser()
+			frames := newSyslogFrameReader(c, s.framing, s.maxBuffer)
+			for {
+				frame, err := frames.Next()
+				if err != nil {
+					if err != io.EOF && !s.shutSig.ShouldCloseAtLeisure() {
+						s.log.Errorf("Connection dropped due to: %v", err)
+					}
+					return
+				}
+				if !s.sendMsg(s.toMessage(parser, frame, c.RemoteAddr())) {
+					return
+				}
+			}
+		}(conn)
+	}
+}
+
+func (s *syslogServerInput) udpLoop(conn net.PacketConn) {
+	defer s.connWG.Done()
+
+	parser := s.newParser()
+	buf := make([]byte, 65536)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if !s.shutSig.ShouldCloseAtLeisure() {
+				s.log.Errorf("Connection dropped due to: %v", err)
+			}
+			return
+		}
+		frame := trimSyslogFrame(buf[:n])
+		if len(frame) == 0 {
+			continue
+		}
+
+		frameCopy := make([]byte, len(frame))
+		copy(frameCopy, frame)
+		if !s.sendMsg(s.toMessage(parser, frameCopy, addr)) {
+			return
+		}
+	}
+}
+
+func (s *syslogServerInput) Read(ctx context.Context) (*service.Message, service.AckFunc, error) {
+	select {
+	case msg := <-s.msgChan:
+		return msg, func(ctx context.Context, err error) error {
+			// Nacks are handled by AutoRetryNacks because syslog has no
+			// mechanism for acknowledging messages.
+			return nil
+		}, nil
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	case <-s.shutSig.CloseAtLeisureChan():
+		return nil, nil, service.ErrEndOfInput
+	}
+}
+
+func (s *syslogServerInput) Close(ctx context.Context) error {
+	s.shutSig.CloseAtLeisure()
+
+	s.connMut.Lock()
+	if s.listener != nil {
+		s.listener.Close()
+	}
+	if s.conn != nil {
+		s.conn.Close()
+	}
+	s.connMut.Unlock()
+
+	closed := make(chan struct{})
+	go func() {
+		s.connWG.Wait()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
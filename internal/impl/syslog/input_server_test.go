@@ -0,0 +1,339 @@
+package syslog
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	testRFC5424Msg = `<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog - ID47 [exampleSDID@32473 iut="3" eventSource="Application" eventID="1011"] An application event log entry...`
+	testRFC3164Msg = `<34>Oct 11 22:14:15 mymachine su: 'su root' failed for lonvick on /dev/pts/8`
+)
+
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM string
+	keyPEM  string
+}
+
+func (c testCert) tlsCert(t testing.TB) tls.Certificate {
+	t.Helper()
+	cert, err := tls.X509KeyPair([]byte(c.certPEM), []byte(c.keyPEM))
+	require.NoError(t, err)
+	return cert
+}
+
+// newTestCert creates a certificate signed by parent, or a self signed CA
+// certificate when parent is nil.
+func newTestCert(t testing.TB, name string, parent *testCert) testCert {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	signer, signerKey := tmpl, key
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage |= x509.KeyUsageCertSign
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		keyPEM:  string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})),
+	}
+}
+
+// yamlBlock indents a multi line string so that it can be used as a block
+// scalar within a YAML config.
+func yamlBlock(s, indent string) string {
+	return "|\n" + indent + strings.ReplaceAll(strings.TrimSpace(s), "\n", "\n"+indent)
+}
+
+func syslogServerForTest(t *testing.T, confStr string) (*syslogServerInput, service.Input) {
+	t.Helper()
+
+	conf, err := syslogServerInputConfig().ParseYAML(confStr, nil)
+	require.NoError(t, err)
+
+	s, err := newSyslogServerInputFromConfig(conf, service.MockResources().Logger())
+	require.NoError(t, err)
+
+	rdr := service.AutoRetryNacks(s)
+	require.NoError(t, rdr.Connect(context.Background()))
+	t.Cleanup(func() {
+		ctx, done := context.WithTimeout(context.Background(), time.Second)
+		defer done()
+		assert.NoError(t, rdr.Close(ctx))
+	})
+	return s, rdr
+}
+
+func readTestMessage(t testing.TB, rdr service.Input, ackErr error) *service.Message {
+	t.Helper()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	msg, ackFn, err := rdr.Read(ctx)
+	require.NoError(t, err)
+	require.NoError(t, ackFn(ctx, ackErr))
+	return msg
+}
+
+func testSyslogMeta(msg *service.Message) map[string]string {
+	meta := map[string]string{}
+	_ = msg.MetaWalk(func(k, v string) error {
+		if k != "syslog_remote_addr" {
+			meta[k] = v
+		}
+		return nil
+	})
+	return meta
+}
+
+func testMessageStr(t testing.TB, msg *service.Message) string {
+	t.Helper()
+
+	b, err := msg.AsBytes()
+	require.NoError(t, err)
+	return string(b)
+}
+
+func testMetaStr(msg *service.Message, key string) string {
+	v, _ := msg.MetaGet(key)
+	return v
+}
+
+func TestSyslogServerTCP(t *testing.T) {
+	s, rdr := syslogServerForTest(t, `address: 127.0.0.1:0`)
+
+	conn, err := net.Dial("tcp", s.addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte(testRFC5424Msg + "\n"))
+	require.NoError(t, err)
+	_, err = conn.Write([]byte("28 <13>1 - host app - - - hello"))
+	require.NoError(t, err)
+	_, err = conn.Write([]byte("not a syslog message\n"))
+	require.NoError(t, err)
+
+	msg := readTestMessage(t, rdr, nil)
+	assert.Equal(t, "An application event log entry...", testMessageStr(t, msg))
+	assert.Equal(t, map[string]string{
+		"syslog_facility":                         "20",
+		"syslog_severity":                         "5",
+		"syslog_priority":                         "165",
+		"syslog_version":                          "1",
+		"syslog_timestamp":                        "2003-10-11T22:14:15.003Z",
+		"syslog_hostname":                         "mymachine.example.com",
+		"syslog_appname":                          "evntslog",
+		"syslog_msgid":                            "ID47",
+		"syslog_sd_exampleSDID@32473.iut":         "3",
+		"syslog_sd_exampleSDID@32473.eventSource": "Application",
+		"syslog_sd_exampleSDID@32473.eventID":     "1011",
+	}, testSyslogMeta(msg))
+	assert.NotEmpty(t, testMetaStr(msg, "syslog_remote_addr"))
+
+	msg = readTestMessage(t, rdr, nil)
+	assert.Equal(t, "hello", testMessageStr(t, msg))
+	assert.Equal(t, "host", testMetaStr(msg, "syslog_hostname"))
+	assert.Equal(t, "app", testMetaStr(msg, "syslog_appname"))
+
+	msg = readTestMessage(t, rdr, nil)
+	assert.Equal(t, "not a syslog message", testMessageStr(t, msg))
+	assert.NotEmpty(t, testMetaStr(msg, "syslog_error"))
+}
+
+func TestSyslogServerUDPRFC3164(t *testing.T) {
+	s, rdr := syslogServerForTest(t, `
+network: udp
+address: 127.0.0.1:0
+format: rfc3164
+`)
+
+	conn, err := net.Dial("udp", s.addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte(testRFC3164Msg + "\n"))
+	require.NoError(t, err)
+
+	msg := readTestMessage(t, rdr, nil)
+	assert.Equal(t, "'su root' failed for lonvick on /dev/pts/8", testMessageStr(t, msg))
+
+	meta := testSyslogMeta(msg)
+	assert.Equal(t, "4", meta["syslog_facility"])
+	assert.Equal(t, "2", meta["syslog_severity"])
+	assert.Equal(t, "34", meta["syslog_priority"])
+	assert.Equal(t, "mymachine", meta["syslog_hostname"])
+	assert.Equal(t, "su", meta["syslog_appname"])
+	assert.Contains(t, meta["syslog_timestamp"], "-10-11T22:14:15")
+	assert.NotContains(t, meta, "syslog_version")
+}
+
+func TestSyslogServerRetries(t *testing.T) {
+	s, rdr := syslogServerForTest(t, `address: 127.0.0.1:0`)
+
+	conn, err := net.Dial("tcp", s.addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("<13>1 - - - - - - foo\n"))
+	require.NoError(t, err)
+
+	msg := readTestMessage(t, rdr, errors.New("nope"))
+	assert.Equal(t, "foo", testMessageStr(t, msg))
+
+	msg = readTestMessage(t, rdr, nil)
+	assert.Equal(t, "foo", testMessageStr(t, msg))
+}
+
+func TestSyslogServerTLS(t *testing.T) {
+	ca := newTestCert(t, "ca", nil)
+	server := newTestCert(t, "server", &ca)
+	client := newTestCert(t, "client", &ca)
+
+	s, rdr := syslogServerForTest(t, fmt.Sprintf(`
+address: 127.0.0.1:0
+tls:
+  enabled: true
+  root_cas: %v
+  client_certs:
+    - cert: %v
+      key: %v
+`, yamlBlock(ca.certPEM, "    "), yamlBlock(server.certPEM, "        "), yamlBlock(server.keyPEM, "        ")))
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	conn, err := tls.Dial("tcp", s.addr().String(), &tls.Config{
+		RootCAs:      roots,
+		Certificates: []tls.Certificate{client.tlsCert(t)},
+	})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("24 <13>1 - host - - - - foo"))
+	require.NoError(t, err)
+
+	msg := readTestMessage(t, rdr, nil)
+	assert.Equal(t, "foo", testMessageStr(t, msg))
+	assert.Equal(t, "host", testMetaStr(msg, "syslog_hostname"))
+}
+
+func TestSyslogServerMutualTLSRejectsClients(t *testing.T) {
+	ca := newTestCert(t, "ca", nil)
+	server := newTestCert(t, "server", &ca)
+
+	otherCA := newTestCert(t, "other ca", nil)
+	otherClient := newTestCert(t, "other client", &otherCA)
+
+	s, _ := syslogServerForTest(t, fmt.Sprintf(`
+address: 127.0.0.1:0
+tls:
+  enabled: true
+  root_cas: %v
+  client_certs:
+    - cert: %v
+      key: %v
+`, yamlBlock(ca.certPEM, "    "), yamlBlock(server.certPEM, "        "), yamlBlock(server.keyPEM, "        ")))
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	for _, certs := range [][]tls.Certificate{nil, {otherClient.tlsCert(t)}} {
+		conn, err := tls.Dial("tcp", s.addr().String(), &tls.Config{
+			RootCAs:      roots,
+			Certificates: certs,
+		})
+		if err != nil {
+			continue
+		}
+		// Handshake failures of TLS 1.3 are only reported on the first read.
+		_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+		_, err = conn.Read(make([]byte, 1))
+		conn.Close()
+		assert.Error(t, err)
+		if nErr, ok := err.(net.Error); ok {
+			assert.False(t, nErr.Timeout(), "expected the handshake to be rejected")
+		}
+	}
+}
+
+func TestSyslogServerConfigErrors(t *testing.T) {
+	ca := newTestCert(t, "ca", nil)
+	server := newTestCert(t, "server", &ca)
+
+	tests := map[string]string{
+		"syslog format 'nope' is not supported":  `format: nope`,
+		"syslog framing 'nope' is not supported": `framing: nope`,
+		"syslog network 'unix' is not supported": `network: unix`,
+		"tls is not supported with the syslog network 'udp'": fmt.Sprintf(`
+network: udp
+tls:
+  enabled: true
+  client_certs:
+    - cert: %v
+      key: %v
+`, yamlBlock(server.certPEM, "        "), yamlBlock(server.keyPEM, "        ")),
+		"at least one certificate must be specified": `
+tls:
+  enabled: true
+`,
+	}
+	for errContains, confStr := range tests {
+		conf, err := syslogServerInputConfig().ParseYAML("address: 127.0.0.1:0\n"+confStr, nil)
+		require.NoError(t, err, errContains)
+
+		_, err = newSyslogServerInputFromConfig(conf, service.MockResources().Logger())
+		require.Error(t, err, errContains)
+		assert.Contains(t, err.Error(), errContains)
+	}
+}
@@ -1,11 +1,18 @@
-// Package xml is a temporary way to convert XML to JSON. This package is only
+// Package xml is a temporary way to convert XML to and from JSON. This package is only
 // necessary because github.com/clbanning/mxj has global configuration. If we
 // are able to configure a decoder etc at the API level then this package can be
 // removed.
 package xml
 
 import (
+	"bytes"
+	"encoding/json"
 	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
 
 	"github.com/clbanning/mxj/v2"
 	"golang.org/x/net/html/charset"
@@ -27,3 +34,253 @@ func ToMap(xmlBytes []byte, cast bool) (map[string]interface{}, error) {
 	}
 	return map[string]interface{}(root), nil
 }
+
+// FromMap serializes a generic structure as an XML document following the same
+// conventions as ToMap, where keys prefixed with a hyphen, `-`, are attributes
+// and the key `#text` is the value of an element with attributes. The
+// structure must contain a single key which is used as the root element,
+// otherwise the keys are wrapped within a root element named `doc`. When the
+// indent is non-empty the document is pretty printed.
+//
+// Go maps do not retain the order of their keys and therefore elements and
+// attributes are serialized in the order of their names, use FromJSON in
+// order to retain the order of a document.
+func FromMap(v interface{}, indent string) ([]byte, error) {
+	root, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected object value, got %T", v)
+	}
+	return fromObject(sortedObject(root), indent)
+}
+
+// FromJSON serializes a JSON document as an XML document following the same
+// conventions as FromMap, where elements and attributes are serialized in the
+// order that they appear within the document.
+func FromJSON(jsonBytes []byte, indent string) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(jsonBytes))
+	dec.UseNumber()
+
+	v, err := readOrderedValue(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, errors.New("expected a single JSON document")
+	}
+
+	root, ok := v.(orderedObject)
+	if !ok {
+		return nil, fmt.Errorf("expected object value, got %T", v)
+	}
+	return fromObject(root, indent)
+}
+
+//------------------------------------------------------------------------------
+
+// orderedField is a key/value pair of an object that retains key order.
+type orderedField struct {
+	key   string
+	value interface{}
+}
+
+// orderedObject is an object where the fields are kept in order, values are
+// either an orderedObject, an []interface{} or a scalar value.
+type orderedObject []orderedField
+
+func sortedObject(m map[string]interface{}) orderedObject {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	obj := make(orderedObject, 0, len(keys))
+	for _, k := range keys {
+		obj = append(obj, orderedField{key: k, value: sortedValue(m[k])})
+	}
+	return obj
+}
+
+func sortedValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		return sortedObject(t)
+	case []interface{}:
+		newSlice := make([]interface{}, len(t))
+		for i, e := range t {
+			newSlice[i] = sortedValue(e)
+		}
+		return newSlice
+	}
+	return v
+}
+
+func readOrderedValue(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		obj := orderedObject{}
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key, _ := keyTok.(string)
+			v, err := readOrderedValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, orderedField{key: key, value: v})
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return obj, nil
+	case json.Delim('['):
+		arr := []interface{}{}
+		for dec.More() {
+			v, err := readOrderedValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return arr, nil
+	}
+	return tok, nil
+}
+
+//------------------------------------------------------------------------------
+
+// xmlWriter serializes ordered values as XML, when the indent is non-empty
+// each element is written on a new line.
+type xmlWriter struct {
+	buf    bytes.Buffer
+	indent string
+}
+
+func fromObject(root orderedObject, indent string) ([]byte, error) {
+	if len(root) == 0 {
+		return nil, errors.New("expected object value with at least one key")
+	}
+	w := &xmlWriter{indent: indent}
+
+	var err error
+	if _, isArray := root[0].value.([]interface{}); len(root) == 1 && !isArray {
+		err = w.writeElement(root[0].key, root[0].value, 0)
+	} else {
+		err = w.writeElement("doc", root, 0)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return w.buf.Bytes(), nil
+}
+
+func (w *xmlWriter) newLine(depth int) {
+	if w.indent == "" {
+		return
+	}
+	w.buf.WriteByte('\n')
+	w.buf.WriteString(strings.Repeat(w.indent, depth))
+}
+
+func (w *xmlWriter) writeElement(name string, value interface{}, depth int) error {
+	switch t := value.(type) {
+	case []interface{}:
+		if len(t) == 0 {
+			w.buf.WriteString("<" + name + "/>")
+			return nil
+		}
+		for i, e := range t {
+			if i > 0 {
+				w.newLine(depth)
+			}
+			if err := w.writeElement(name, e, depth); err != nil {
+				return err
+			}
+		}
+		return nil
+	case orderedObject:
+		return w.writeObjectElement(name, t, depth)
+	}
+
+	text := scalarText(value)
+	if text == "" {
+		w.buf.WriteString("<" + name + "/>")
+		return nil
+	}
+	w.buf.WriteString("<" + name + ">" + text + "</" + name + ">")
+	return nil
+}
+
+func (w *xmlWriter) writeObjectElement(name string, obj orderedObject, depth int) error {
+	w.buf.WriteString("<" + name)
+
+	var text string
+	var children orderedObject
+	for _, f := range obj {
+		switch {
+		case f.key == "#text":
+			text = scalarText(f.value)
+		case len(f.key) > 1 && f.key[0] == '-':
+			switch f.value.(type) {
+			case orderedObject, []interface{}:
+				return fmt.Errorf("invalid attribute value for: %v", f.key)
+			}
+			w.buf.WriteString(" " + f.key[1:] + `="` + scalarText(f.value) + `"`)
+		default:
+			children = append(children, f)
+		}
+	}
+
+	if text == "" && len(children) == 0 {
+		w.buf.WriteString("/>")
+		return nil
+	}
+
+	w.buf.WriteString(">" + text)
+	for _, c := range children {
+		w.newLine(depth + 1)
+		if err := w.writeElement(c.key, c.value, depth+1); err != nil {
+			return err
+		}
+	}
+	if len(children) > 0 {
+		w.newLine(depth)
+	}
+	w.buf.WriteString("</" + name + ">")
+	return nil
+}
+
+// scalarText returns the escaped text of a scalar value, null values are
+// written as empty strings.
+func scalarText(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return xmlEscaper.Replace(t)
+	case []byte:
+		return xmlEscaper.Replace(string(t))
+	}
+	return xmlEscaper.Replace(fmt.Sprintf("%v", v))
+}
+
+// xmlEscaper escapes characters that are invalid within XML attribute and
+// element values. Serialization is done here rather than with mxj as it sorts
+// elements, and enabling mxj.XMLEscapeChars would change the behaviour of all
+// other users of mxj.
+var xmlEscaper = strings.NewReplacer(
+	`&`, `&amp;`,
+	`<`, `&lt;`,
+	`>`, `&gt;`,
+	`"`, `&quot;`,
+	`'`, `&apos;`,
+)
@@ -0,0 +1,102 @@
+package xml
+
+import (
+	"testing"
+
+	"github.com/clbanning/mxj/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestXMLRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		cast  bool
+	}{
+		{
+			name:  "simple elements",
+			input: `<root><description>This is a description</description><title>This is a title</title></root>`,
+		},
+		{
+			name:  "attributes and repeated elements",
+			input: `<root><description tone="boring">This is a description</description><elements id="1">foo1</elements><elements id="2">foo2</elements><elements>foo3</elements></root>`,
+		},
+		{
+			name:  "cast values",
+			input: `<root><bool>true</bool><number id="99">123</number></root>`,
+			cast:  true,
+		},
+		{
+			name:  "escaped characters",
+			input: `<root><code lang="go &amp; c">if a &lt; b &amp;&amp; c &gt; d</code></root>`,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			root, err := ToMap([]byte(test.input), test.cast)
+			require.NoError(t, err)
+
+			res, err := FromMap(root, "")
+			require.NoError(t, err)
+			assert.Equal(t, test.input, string(res))
+
+			again, err := ToMap(res, test.cast)
+			require.NoError(t, err)
+			assert.Equal(t, root, again)
+		})
+	}
+}
+
+func TestXMLFromMap(t *testing.T) {
+	res, err := FromMap(map[string]interface{}{
+		"root": map[string]interface{}{
+			"a": nil,
+			"b": map[string]interface{}{"-id": 5, "#text": "foo"},
+		},
+	}, "  ")
+	require.NoError(t, err)
+	assert.Equal(t, "<root>\n  <a/>\n  <b id=\"5\">foo</b>\n</root>", string(res))
+
+	res, err = FromMap(map[string]interface{}{"a": "foo", "b": "bar"}, "")
+	require.NoError(t, err)
+	assert.Equal(t, "<doc><a>foo</a><b>bar</b></doc>", string(res))
+
+	_, err = FromMap("nope", "")
+	require.Error(t, err)
+
+	_, err = FromMap(map[string]interface{}{}, "")
+	require.Error(t, err)
+}
+
+func TestXMLFromJSON(t *testing.T) {
+	res, err := FromJSON([]byte(`{"root":{"title":"foo","-b":"1","-a":"2","number":{"#text":5,"-id":"x"},"list":["a","b"],"empty":null}}`), "")
+	require.NoError(t, err)
+	assert.Equal(t, `<root b="1" a="2"><title>foo</title><number id="x">5</number><list>a</list><list>b</list><empty/></root>`, string(res))
+
+	res, err = FromJSON([]byte(`{"b":"bar","a":"foo"}`), "  ")
+	require.NoError(t, err)
+	assert.Equal(t, "<doc>\n  <b>bar</b>\n  <a>foo</a>\n</doc>", string(res))
+
+	_, err = FromJSON([]byte(`["nope"]`), "")
+	require.Error(t, err)
+
+	_, err = FromJSON([]byte(`{"a":"b"} {"c":"d"}`), "")
+	require.Error(t, err)
+
+	_, err = FromJSON([]byte(`{"a":{"-b":["c"]}}`), "")
+	require.Error(t, err)
+}
+
+func TestXMLEscapingIsLocal(t *testing.T) {
+	res, err := FromMap(map[string]interface{}{"a": `x < "y"`}, "")
+	require.NoError(t, err)
+	assert.Equal(t, `<a>x &lt; &quot;y&quot;</a>`, string(res))
+
+	// Other users of mxj within the binary keep its default behaviour.
+	res, err = mxj.Map(map[string]interface{}{"a": `x < "y"`}).Xml()
+	require.NoError(t, err)
+	assert.Equal(t, `<a>x < "y"</a>`, string(res))
+}
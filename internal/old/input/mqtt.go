@@ -31,11 +31,25 @@ This input adds the following metadata fields to each message:
 - mqtt_message_id
 ` + "```" + `
 
+When connected with ` + "`protocol_version` set to `5`" + ` the user properties of each message are also added as metadata fields, along with the following fields when they are present:
+
+` + "``` text" + `
+- mqtt_response_topic
+- mqtt_correlation_data
+- mqtt_content_type
+- mqtt_message_expiry
+` + "```" + `
+
 You can access these metadata fields using
-[function interpolation](/docs/configuration/interpolation#metadata).`,
+[function interpolation](/docs/configuration/interpolation#metadata).
+
+### Shared Subscriptions
+
+With ` + "`protocol_version` set to `5`" + ` topics of the form ` + "`$share/<group>/<filter>`" + ` can be used in order to balance messages across all consumers subscribed with the same group, which allows multiple Benthos instances to divide the work of a topic between them.`,
 		Config: docs.FieldComponent().WithChildren(
 			docs.FieldString("urls", "A list of URLs to connect to. If an item of the list contains commas it will be expanded into multiple URLs.").Array(),
-			docs.FieldString("topics", "A list of topics to consume from.").Array(),
+			mqttconf.ProtocolVersionFieldSpec(),
+			docs.FieldString("topics", "A list of topics to consume from.", []string{"$share/benthos/foo/+"}).Array(),
 			docs.FieldString("client_id", "An identifier for the client connection."),
 			docs.FieldString("dynamic_client_id_suffix", "Append a dynamically generated suffix to the specified `client_id` on each run of the pipeline. This can be useful when clustering Benthos producers.").Optional().Advanced().HasAnnotatedOptions(
 				"nanoid", "append a nanoid of length 21 characters",
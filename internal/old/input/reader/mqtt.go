@@ -3,6 +3,7 @@ package reader
 import (
 	"context"
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
 	"sync"
@@ -13,6 +14,7 @@ import (
 
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/impl/mqtt/mqtt5"
 	mqttconf "github.com/benthosdev/benthos/v4/internal/impl/mqtt/shared"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/message"
@@ -24,6 +26,7 @@ import (
 // MQTTConfig contains configuration fields for the MQTT input type.
 type MQTTConfig struct {
 	URLs                  []string      `json:"urls" yaml:"urls"`
+	ProtocolVersion       string        `json:"protocol_version" yaml:"protocol_version"`
 	QoS                   uint8         `json:"qos" yaml:"qos"`
 	Topics                []string      `json:"topics" yaml:"topics"`
 	ClientID              string        `json:"client_id" yaml:"client_id"`
@@ -40,17 +43,18 @@ type MQTTConfig struct {
 // NewMQTTConfig creates a new MQTTConfig with default values.
 func NewMQTTConfig() MQTTConfig {
 	return MQTTConfig{
-		URLs:           []string{},
-		QoS:            1,
-		Topics:         []string{},
-		ClientID:       "",
-		Will:           mqttconf.EmptyWill(),
-		CleanSession:   true,
-		User:           "",
-		Password:       "",
-		ConnectTimeout: "30s",
-		KeepAlive:      30,
-		TLS:            tls.NewConfig(),
+		URLs:            []string{},
+		ProtocolVersion: mqttconf.ProtocolVersion311,
+		QoS:             1,
+		Topics:          []string{},
+		ClientID:        "",
+		Will:            mqttconf.EmptyWill(),
+		CleanSession:    true,
+		User:            "",
+		Password:        "",
+		ConnectTimeout:  "30s",
+		KeepAlive:       30,
+		TLS:             tls.NewConfig(),
 	}
 }
 
@@ -59,6 +63,7 @@ func NewMQTTConfig() MQTTConfig {
 // MQTT is an input type that reads MQTT Pub/Sub messages.
 type MQTT struct {
 	client  mqtt.Client
+	client5 *mqtt5.Client
 	msgChan chan mqtt.Message
 	cMut    sync.Mutex
 
@@ -105,6 +110,10 @@ func NewMQTT(
 		return nil, err
 	}
 
+	if err := mqttconf.ValidateProtocolVersion(m.conf.ProtocolVersion); err != nil {
+		return nil, err
+	}
+
 	for _, u := range conf.URLs {
 		for _, splitURL := range strings.Split(u, ",") {
 			if len(splitURL) > 0 {
@@ -123,10 +132,14 @@ func (m *MQTT) ConnectWithContext(ctx context.Context) error {
 	m.cMut.Lock()
 	defer m.cMut.Unlock()
 
-	if m.client != nil {
+	if m.client != nil || m.client5 != nil {
 		return nil
 	}
 
+	if m.conf.ProtocolVersion == mqttconf.ProtocolVersion5 {
+		return m.connectV5(ctx)
+	}
+
 	var msgMut sync.Mutex
 	msgChan := make(chan mqtt.Message)
 
@@ -230,6 +243,88 @@ func (m *MQTT) ConnectWithContext(ctx context.Context) error {
 	return nil
 }
 
+// connectV5 establishes a connection to an MQTT 5 server, this must be called
+// whilst holding the connection mutex.
+func (m *MQTT) connectV5(ctx context.Context) error {
+	var msgMut sync.Mutex
+	msgChan := make(chan mqtt.Message)
+
+	closeMsgChan := func() {
+		msgMut.Lock()
+		if msgChan != nil {
+			close(msgChan)
+			msgChan = nil
+		}
+		msgMut.Unlock()
+	}
+
+	opts := mqtt5.Options{
+		Brokers:        m.urls,
+		ClientID:       m.conf.ClientID,
+		CleanStart:     m.conf.CleanSession,
+		KeepAlive:      time.Duration(m.conf.KeepAlive) * time.Second,
+		ConnectTimeout: m.connectTimeout,
+		Username:       m.conf.User,
+		Password:       m.conf.Password,
+		OnMessage: func(msg *mqtt5.Message, ack func()) {
+			msgMut.Lock()
+			if msgChan != nil {
+				select {
+				case msgChan <- &mqtt5Message{msg: msg, ack: ack}:
+				case <-m.interruptChan:
+				}
+			}
+			msgMut.Unlock()
+		},
+		OnConnectionLost: func(reason error) {
+			closeMsgChan()
+			m.log.Errorf("Connection lost due to: %v\n", reason)
+		},
+	}
+	if !m.conf.CleanSession {
+		// Without a session expiry interval an MQTT 5 session ends along with
+		// the connection.
+		opts.SessionExpiry = math.MaxUint32
+	}
+
+	if m.conf.Will.Enabled {
+		opts.Will = &mqtt5.Message{
+			Topic:   m.conf.Will.Topic,
+			Payload: []byte(m.conf.Will.Payload),
+			QoS:     m.conf.Will.QoS,
+			Retain:  m.conf.Will.Retained,
+		}
+	}
+
+	if m.conf.TLS.Enabled {
+		tlsConf, err := m.conf.TLS.Get()
+		if err != nil {
+			return err
+		}
+		opts.TLSConfig = tlsConf
+	}
+
+	client, err := mqtt5.Dial(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	subs := make([]mqtt5.Subscription, 0, len(m.conf.Topics))
+	for _, topic := range m.conf.Topics {
+		subs = append(subs, mqtt5.Subscription{Topic: topic, QoS: m.conf.QoS})
+	}
+	if err := client.Subscribe(ctx, subs...); err != nil {
+		_ = client.Disconnect()
+		return fmt.Errorf("failed to subscribe to topics '%v': %w", m.conf.Topics, err)
+	}
+
+	m.log.Infof("Receiving MQTT 5 messages from topics: %v\n", m.conf.Topics)
+
+	m.client5 = client
+	m.msgChan = msgChan
+	return nil
+}
+
 // ReadWithContext attempts to read a new message from an MQTT broker.
 func (m *MQTT) ReadWithContext(ctx context.Context) (*message.Batch, AsyncAckFn, error) {
 	m.cMut.Lock()
@@ -246,6 +341,10 @@ func (m *MQTT) ReadWithContext(ctx context.Context) (*message.Batch, AsyncAckFn,
 			m.cMut.Lock()
 			m.msgChan = nil
 			m.client = nil
+			if m.client5 != nil {
+				_ = m.client5.Disconnect()
+				m.client5 = nil
+			}
 			m.cMut.Unlock()
 			return nil, nil, component.ErrNotConnected
 		}
@@ -258,6 +357,9 @@ func (m *MQTT) ReadWithContext(ctx context.Context) (*message.Batch, AsyncAckFn,
 		p.MetaSet("mqtt_retained", strconv.FormatBool(msg.Retained()))
 		p.MetaSet("mqtt_topic", msg.Topic())
 		p.MetaSet("mqtt_message_id", strconv.Itoa(int(msg.MessageID())))
+		if m5, ok := msg.(*mqtt5Message); ok {
+			m5.setMetadata(p)
+		}
 
 		return message, func(ctx context.Context, res error) error {
 			if res == nil {
@@ -275,9 +377,15 @@ func (m *MQTT) ReadWithContext(ctx context.Context) (*message.Batch, AsyncAckFn,
 // CloseAsync shuts down the MQTT input and stops processing requests.
 func (m *MQTT) CloseAsync() {
 	m.cMut.Lock()
-	if m.client != nil {
-		m.client.Disconnect(0)
-		m.client = nil
+	if m.client != nil || m.client5 != nil {
+		if m.client != nil {
+			m.client.Disconnect(0)
+			m.client = nil
+		}
+		if m.client5 != nil {
+			_ = m.client5.Disconnect()
+			m.client5 = nil
+		}
 		close(m.interruptChan)
 	}
 	m.cMut.Unlock()
@@ -289,3 +397,41 @@ func (m *MQTT) WaitForClose(timeout time.Duration) error {
 }
 
 //------------------------------------------------------------------------------
+
+// mqtt5Message adapts a message received from an MQTT 5 server to the message
+// interface of the MQTT 3 client.
+type mqtt5Message struct {
+	msg *mqtt5.Message
+	ack func()
+}
+
+func (m *mqtt5Message) Duplicate() bool   { return m.msg.Duplicate }
+func (m *mqtt5Message) Qos() byte         { return m.msg.QoS }
+func (m *mqtt5Message) Retained() bool    { return m.msg.Retain }
+func (m *mqtt5Message) Topic() string     { return m.msg.Topic }
+func (m *mqtt5Message) MessageID() uint16 { return m.msg.PacketID }
+func (m *mqtt5Message) Payload() []byte   { return m.msg.Payload }
+func (m *mqtt5Message) Ack()              { m.ack() }
+
+// setMetadata adds the MQTT 5 properties of the message to a message part,
+// where user properties are added as metadata of the same key.
+func (m *mqtt5Message) setMetadata(p *message.Part) {
+	props := m.msg.Properties
+	for _, u := range props.User {
+		p.MetaSet(u.Key, u.Value)
+	}
+	if props.ResponseTopic != "" {
+		p.MetaSet("mqtt_response_topic", props.ResponseTopic)
+	}
+	if props.CorrelationData != nil {
+		p.MetaSet("mqtt_correlation_data", string(props.CorrelationData))
+	}
+	if props.ContentType != "" {
+		p.MetaSet("mqtt_content_type", props.ContentType)
+	}
+	if props.MessageExpiry != nil {
+		p.MetaSet("mqtt_message_expiry", strconv.FormatUint(uint64(*props.MessageExpiry), 10))
+	}
+}
+
+//------------------------------------------------------------------------------
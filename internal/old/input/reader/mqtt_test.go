@@ -9,9 +9,13 @@ import (
 
 	mqtt "github.com/eclipse/paho.mqtt.golang"
 	"github.com/ory/dockertest/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/impl/mqtt/mqtt5"
+	"github.com/benthosdev/benthos/v4/internal/impl/mqtt/mqtt5/mqtt5test"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/message"
 )
@@ -182,3 +186,119 @@ func testMQTTDisconnect(urls []string, t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestMQTT5Read(t *testing.T) {
+	broker, err := mqtt5test.NewBroker()
+	require.NoError(t, err)
+	defer broker.Close()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	conf := NewMQTTConfig()
+	conf.ProtocolVersion = "5"
+	conf.ClientID = "foo"
+	conf.Topics = []string{"foo/+"}
+	conf.URLs = []string{broker.URL}
+
+	m, err := NewMQTT(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	require.NoError(t, m.ConnectWithContext(ctx))
+	defer func() {
+		m.CloseAsync()
+		assert.NoError(t, m.WaitForClose(time.Second))
+	}()
+
+	pub, err := mqtt5.Dial(ctx, mqtt5.Options{
+		Brokers:  []string{broker.URL},
+		ClientID: "pub",
+	})
+	require.NoError(t, err)
+	defer pub.Disconnect()
+
+	expiry := uint32(60)
+	require.NoError(t, pub.Publish(ctx, &mqtt5.Message{
+		Topic:   "foo/bar",
+		Payload: []byte("hello world"),
+		QoS:     1,
+		Properties: mqtt5.Properties{
+			MessageExpiry:   &expiry,
+			ContentType:     "text/plain",
+			ResponseTopic:   "replies",
+			CorrelationData: []byte("abc"),
+			User:            []mqtt5.UserProperty{{Key: "source", Value: "test"}},
+		},
+	}))
+
+	msg, ackFn, err := m.ReadWithContext(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, msg.Len())
+
+	p := msg.Get(0)
+	assert.Equal(t, "hello world", string(p.Get()))
+	for k, v := range map[string]string{
+		"mqtt_topic":            "foo/bar",
+		"mqtt_qos":              "1",
+		"mqtt_response_topic":   "replies",
+		"mqtt_correlation_data": "abc",
+		"mqtt_content_type":     "text/plain",
+		"mqtt_message_expiry":   "60",
+		"source":                "test",
+	} {
+		assert.Equal(t, v, p.MetaGet(k), k)
+	}
+
+	assert.Empty(t, broker.Acked())
+	require.NoError(t, ackFn(ctx, nil))
+	assert.Eventually(t, func() bool {
+		return len(broker.Acked()) == 1
+	}, time.Second*5, time.Millisecond*10)
+}
+
+func TestMQTT5ReadSharedSubscription(t *testing.T) {
+	broker, err := mqtt5test.NewBroker()
+	require.NoError(t, err)
+	defer broker.Close()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	var readers []*MQTT
+	for _, id := range []string{"a", "b"} {
+		conf := NewMQTTConfig()
+		conf.ProtocolVersion = "5"
+		conf.ClientID = id
+		conf.Topics = []string{"$share/benthos/jobs"}
+		conf.URLs = []string{broker.URL}
+
+		m, err := NewMQTT(conf, log.Noop(), metrics.Noop())
+		require.NoError(t, err)
+		require.NoError(t, m.ConnectWithContext(ctx))
+		defer m.CloseAsync()
+		readers = append(readers, m)
+	}
+
+	pub, err := mqtt5.Dial(ctx, mqtt5.Options{
+		Brokers:  []string{broker.URL},
+		ClientID: "pub",
+	})
+	require.NoError(t, err)
+	defer pub.Disconnect()
+
+	for i := 0; i < 4; i++ {
+		require.NoError(t, pub.Publish(ctx, &mqtt5.Message{
+			Topic:   "jobs",
+			Payload: []byte(fmt.Sprintf("job %v", i)),
+			QoS:     1,
+		}))
+	}
+
+	for i, m := range readers {
+		for j := 0; j < 2; j++ {
+			msg, ackFn, err := m.ReadWithContext(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, fmt.Sprintf("job %v", i+(j*2)), string(msg.Get(0).Get()))
+			require.NoError(t, ackFn(ctx, nil))
+		}
+	}
+}
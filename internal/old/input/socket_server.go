@@ -2,6 +2,8 @@ package input
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -17,6 +19,7 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/interop"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/message"
+	btls "github.com/benthosdev/benthos/v4/internal/tls"
 )
 
 //------------------------------------------------------------------------------
@@ -26,7 +29,11 @@ func init() {
 		constructor: fromSimpleConstructor(NewSocketServer),
 		Summary:     `Creates a server that receives a stream of messages over a tcp, udp or unix socket.`,
 		Description: `
-The field ` + "`max_buffer`" + ` specifies the maximum amount of memory to allocate _per connection_ for buffering lines of data. If a line of data from a connection exceeds this value then the connection will be closed.`,
+The field ` + "`max_buffer`" + ` specifies the maximum amount of memory to allocate _per connection_ for buffering lines of data. If a line of data from a connection exceeds this value then the connection will be closed.
+
+### TLS
+
+Connections of the ` + "`tcp`" + ` network can be encrypted with TLS by enabling the field ` + "`tls`" + `, where the certificates listed in ` + "`client_certs`" + ` are presented by the server. Mutual TLS is enabled by specifying ` + "`root_cas`" + ` or ` + "`root_cas_file`" + `, in which case clients must present a certificate signed by one of those certificate authorities.`,
 		Config: docs.FieldComponent().WithChildren(
 			docs.FieldString("network", "A network type to accept (unix|tcp|udp).").HasOptions(
 				"unix", "tcp", "udp",
@@ -34,6 +41,7 @@ The field ` + "`max_buffer`" + ` specifies the maximum amount of memory to alloc
 			docs.FieldString("address", "The address to listen from.", "/tmp/benthos.sock", "0.0.0.0:6000"),
 			codec.ReaderDocs.AtVersion("3.42.0"),
 			docs.FieldInt("max_buffer", "The maximum message buffer size. Must exceed the largest message to be consumed.").Advanced(),
+			btls.FieldSpec().AtVersion("4.0.0"),
 		),
 		Categories: []string{
 			"Network",
@@ -45,10 +53,11 @@ The field ` + "`max_buffer`" + ` specifies the maximum amount of memory to alloc
 
 // SocketServerConfig contains configuration for the SocketServer input type.
 type SocketServerConfig struct {
-	Network   string `json:"network" yaml:"network"`
-	Address   string `json:"address" yaml:"address"`
-	Codec     string `json:"codec" yaml:"codec"`
-	MaxBuffer int    `json:"max_buffer" yaml:"max_buffer"`
+	Network   string      `json:"network" yaml:"network"`
+	Address   string      `json:"address" yaml:"address"`
+	Codec     string      `json:"codec" yaml:"codec"`
+	MaxBuffer int         `json:"max_buffer" yaml:"max_buffer"`
+	TLS       btls.Config `json:"tls" yaml:"tls"`
 }
 
 // NewSocketServerConfig creates a new SocketServerConfig with default values.
@@ -58,6 +67,7 @@ func NewSocketServerConfig() SocketServerConfig {
 		Address:   "",
 		Codec:     "lines",
 		MaxBuffer: 1000000,
+		TLS:       btls.NewConfig(),
 	}
 }
 
@@ -109,6 +119,25 @@ func NewSocketServer(conf Config, mgr interop.Manager, log log.Modular, stats me
 		return nil, err
 	}
 
+	var tlsConf *tls.Config
+	if sconf.TLS.Enabled {
+		if sconf.Network != "tcp" {
+			return nil, fmt.Errorf("tls is not supported with the socket network '%v'", sconf.Network)
+		}
+		if tlsConf, err = sconf.TLS.Get(); err != nil {
+			return nil, err
+		}
+		if tlsConf == nil || len(tlsConf.Certificates) == 0 {
+			return nil, errors.New("at least one certificate must be specified in tls.client_certs")
+		}
+		// The root certificate authorities verify the certificates presented
+		// by clients, which enables mutual TLS.
+		if tlsConf.RootCAs != nil {
+			tlsConf.ClientCAs = tlsConf.RootCAs
+			tlsConf.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+
 	switch sconf.Network {
 	case "tcp", "unix":
 		ln, err = net.Listen(sconf.Network, sconf.Address)
@@ -120,6 +149,9 @@ func NewSocketServer(conf Config, mgr interop.Manager, log log.Modular, stats me
 	if err != nil {
 		return nil, err
 	}
+	if tlsConf != nil {
+		ln = tls.NewListener(ln, tlsConf)
+	}
 
 	t := SocketServer{
 		conf:  conf.SocketServer,
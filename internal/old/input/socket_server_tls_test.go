@@ -0,0 +1,218 @@
+package input
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	btls "github.com/benthosdev/benthos/v4/internal/tls"
+)
+
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM string
+	keyPEM  string
+}
+
+func (c testCert) tlsCert(t testing.TB) tls.Certificate {
+	t.Helper()
+	cert, err := tls.X509KeyPair([]byte(c.certPEM), []byte(c.keyPEM))
+	require.NoError(t, err)
+	return cert
+}
+
+// newTestCert creates a certificate signed by parent, or a self signed CA
+// certificate when parent is nil.
+func newTestCert(t testing.TB, name string, parent *testCert) testCert {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	signer, signerKey := tmpl, key
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage |= x509.KeyUsageCertSign
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		keyPEM:  string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})),
+	}
+}
+
+func testServerTLSConfig(ca, server testCert, mutual bool) btls.Config {
+	conf := btls.NewConfig()
+	conf.Enabled = true
+	conf.ClientCertificates = []btls.ClientCertConfig{
+		{Cert: server.certPEM, Key: server.keyPEM},
+	}
+	if mutual {
+		conf.RootCAs = ca.certPEM
+	}
+	return conf
+}
+
+func readTestTransaction(t testing.TB, rdr input.Streamed) *message.Batch {
+	t.Helper()
+
+	var tran message.Transaction
+	select {
+	case tran = <-rdr.TransactionChan():
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out waiting for message")
+	}
+	require.NoError(t, tran.Ack(context.Background(), nil))
+	return tran.Payload
+}
+
+func TestSocketServerTLS(t *testing.T) {
+	ca := newTestCert(t, "ca", nil)
+	server := newTestCert(t, "server", &ca)
+
+	conf := NewConfig()
+	conf.SocketServer.Network = "tcp"
+	conf.SocketServer.Address = "127.0.0.1:0"
+	conf.SocketServer.TLS = testServerTLSConfig(ca, server, false)
+
+	rdr, err := NewSocketServer(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	defer func() {
+		rdr.CloseAsync()
+		assert.NoError(t, rdr.WaitForClose(time.Second))
+	}()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	conn, err := tls.Dial("tcp", rdr.(*SocketServer).Addr().String(), &tls.Config{
+		RootCAs: roots,
+	})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("foo\nbar\n"))
+	require.NoError(t, err)
+
+	assert.Equal(t, [][]byte{[]byte("foo")}, message.GetAllBytes(readTestTransaction(t, rdr)))
+	assert.Equal(t, [][]byte{[]byte("bar")}, message.GetAllBytes(readTestTransaction(t, rdr)))
+}
+
+func TestSocketServerMutualTLS(t *testing.T) {
+	ca := newTestCert(t, "ca", nil)
+	server := newTestCert(t, "server", &ca)
+	client := newTestCert(t, "client", &ca)
+
+	otherCA := newTestCert(t, "other ca", nil)
+	otherClient := newTestCert(t, "other client", &otherCA)
+
+	conf := NewConfig()
+	conf.SocketServer.Network = "tcp"
+	conf.SocketServer.Address = "127.0.0.1:0"
+	conf.SocketServer.TLS = testServerTLSConfig(ca, server, true)
+
+	rdr, err := NewSocketServer(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	defer func() {
+		rdr.CloseAsync()
+		assert.NoError(t, rdr.WaitForClose(time.Second))
+	}()
+
+	addr := rdr.(*SocketServer).Addr().String()
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	dialAndWrite := func(certs []tls.Certificate) error {
+		conn, err := tls.Dial("tcp", addr, &tls.Config{
+			RootCAs:      roots,
+			Certificates: certs,
+		})
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		if _, err = conn.Write([]byte("foo\n")); err != nil {
+			return err
+		}
+		// Handshake failures of TLS 1.3 are only reported on the first read.
+		_ = conn.SetReadDeadline(time.Now().Add(time.Millisecond * 100))
+		_, err = conn.Read(make([]byte, 1))
+		if nErr, ok := err.(net.Error); ok && nErr.Timeout() {
+			return nil
+		}
+		return err
+	}
+
+	assert.Error(t, dialAndWrite(nil))
+	assert.Error(t, dialAndWrite([]tls.Certificate{otherClient.tlsCert(t)}))
+
+	require.NoError(t, dialAndWrite([]tls.Certificate{client.tlsCert(t)}))
+	assert.Equal(t, [][]byte{[]byte("foo")}, message.GetAllBytes(readTestTransaction(t, rdr)))
+}
+
+func TestSocketServerTLSErrors(t *testing.T) {
+	ca := newTestCert(t, "ca", nil)
+	server := newTestCert(t, "server", &ca)
+
+	conf := NewConfig()
+	conf.SocketServer.Network = "udp"
+	conf.SocketServer.Address = "127.0.0.1:0"
+	conf.SocketServer.TLS = testServerTLSConfig(ca, server, false)
+
+	_, err := NewSocketServer(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "tls is not supported")
+
+	conf.SocketServer.Network = "tcp"
+	conf.SocketServer.TLS = btls.NewConfig()
+	conf.SocketServer.TLS.Enabled = true
+
+	_, err = NewSocketServer(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "at least one certificate must be specified")
+}
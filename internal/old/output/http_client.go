@@ -21,6 +21,11 @@ When the number of retries expires the output will reject the message, the
 behaviour after this will depend on the pipeline but usually this simply means
 the send is attempted again until successful whilst applying back pressure.
 
+When the ` + "`circuit_breaker`" + ` is enabled and open the output rejects
+messages immediately without attempting requests, which allows outputs such as
+` + "[`fallback`](/docs/components/outputs/fallback)" + ` to route them elsewhere
+until the server recovers.
+
 The URL and header values of this type can be dynamically set using function
 interpolations described [here](/docs/configuration/interpolation#bloblang-queries).
 
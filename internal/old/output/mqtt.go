@@ -7,6 +7,7 @@ import (
 	mqttconf "github.com/benthosdev/benthos/v4/internal/impl/mqtt/shared"
 	"github.com/benthosdev/benthos/v4/internal/interop"
 	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/metadata"
 	"github.com/benthosdev/benthos/v4/internal/old/output/writer"
 	"github.com/benthosdev/benthos/v4/internal/tls"
 )
@@ -21,10 +22,15 @@ Pushes messages to an MQTT broker.`,
 		Description: `
 The ` + "`topic`" + ` field can be dynamically set using function interpolations
 described [here](/docs/configuration/interpolation#bloblang-queries). When sending batched
-messages these interpolations are performed per message part.`,
+messages these interpolations are performed per message part.
+
+### MQTT 5
+
+When ` + "`protocol_version`" + ` is set to ` + "`5`" + ` the metadata of each message is sent as user properties, which can be filtered with the ` + "`metadata`" + ` field. The fields ` + "`response_topic`, `correlation_data`, `content_type` and `message_expiry`" + ` are also only applicable to version 5 and are ignored otherwise.`,
 		Async: true,
 		Config: docs.FieldComponent().WithChildren(
 			docs.FieldString("urls", "A list of URLs to connect to. If an item of the list contains commas it will be expanded into multiple URLs.", []string{"tcp://localhost:1883"}).Array(),
+			mqttconf.ProtocolVersionFieldSpec(),
 			docs.FieldString("topic", "The topic to publish messages to."),
 			docs.FieldString("client_id", "An identifier for the client connection."),
 			docs.FieldString("dynamic_client_id_suffix", "Append a dynamically generated suffix to the specified `client_id` on each run of the pipeline. This can be useful when clustering Benthos producers.").Optional().Advanced().HasAnnotatedOptions(
@@ -41,6 +47,11 @@ messages these interpolations are performed per message part.`,
 			docs.FieldInt("keepalive", "Max seconds of inactivity before a keepalive message is sent.").Advanced(),
 			tls.FieldSpec().AtVersion("3.45.0"),
 			docs.FieldInt("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
+			docs.FieldObject("metadata", "Specify criteria for which metadata values are sent with messages as user properties when `protocol_version` is `5`.").WithChildren(metadata.ExcludeFilterFields()...).AtVersion("4.0.0"),
+			docs.FieldString("response_topic", "An optional topic for receivers to send responses to, requires `protocol_version` `5`.", `${! meta("reply_to") }`).IsInterpolated().Advanced().HasDefault("").AtVersion("4.0.0"),
+			docs.FieldString("correlation_data", "Optional correlation data for receivers to attach to responses, requires `protocol_version` `5`.", `${! meta("request_id") }`).IsInterpolated().Advanced().HasDefault("").AtVersion("4.0.0"),
+			docs.FieldString("content_type", "An optional content type describing the payload of each message, requires `protocol_version` `5`.", "application/json").IsInterpolated().Advanced().HasDefault("").AtVersion("4.0.0"),
+			docs.FieldString("message_expiry", "An optional duration after which messages are discarded by the broker when not yet delivered, requires `protocol_version` `5`.", "60s", "1h").Advanced().HasDefault("").AtVersion("4.0.0"),
 		),
 		Categories: []string{
 			"Services",
@@ -2,6 +2,7 @@ package writer
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -14,10 +15,12 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/bloblang/field"
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/impl/mqtt/mqtt5"
 	mqttconf "github.com/benthosdev/benthos/v4/internal/impl/mqtt/shared"
 	"github.com/benthosdev/benthos/v4/internal/interop"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/metadata"
 	"github.com/benthosdev/benthos/v4/internal/tls"
 )
 
@@ -26,6 +29,7 @@ import (
 // MQTTConfig contains configuration fields for the MQTT output type.
 type MQTTConfig struct {
 	URLs                  []string      `json:"urls" yaml:"urls"`
+	ProtocolVersion       string        `json:"protocol_version" yaml:"protocol_version"`
 	QoS                   uint8         `json:"qos" yaml:"qos"`
 	Retained              bool          `json:"retained" yaml:"retained"`
 	RetainedInterpolated  string        `json:"retained_interpolated" yaml:"retained_interpolated"`
@@ -40,23 +44,31 @@ type MQTTConfig struct {
 	KeepAlive             int64         `json:"keepalive" yaml:"keepalive"`
 	MaxInFlight           int           `json:"max_in_flight" yaml:"max_in_flight"`
 	TLS                   tls.Config    `json:"tls" yaml:"tls"`
+
+	Metadata        metadata.ExcludeFilterConfig `json:"metadata" yaml:"metadata"`
+	ResponseTopic   string                       `json:"response_topic" yaml:"response_topic"`
+	CorrelationData string                       `json:"correlation_data" yaml:"correlation_data"`
+	ContentType     string                       `json:"content_type" yaml:"content_type"`
+	MessageExpiry   string                       `json:"message_expiry" yaml:"message_expiry"`
 }
 
 // NewMQTTConfig creates a new MQTTConfig with default values.
 func NewMQTTConfig() MQTTConfig {
 	return MQTTConfig{
-		URLs:           []string{},
-		QoS:            1,
-		Topic:          "",
-		ClientID:       "",
-		Will:           mqttconf.EmptyWill(),
-		User:           "",
-		Password:       "",
-		ConnectTimeout: "30s",
-		WriteTimeout:   "3s",
-		MaxInFlight:    64,
-		KeepAlive:      30,
-		TLS:            tls.NewConfig(),
+		URLs:            []string{},
+		ProtocolVersion: mqttconf.ProtocolVersion311,
+		QoS:             1,
+		Topic:           "",
+		ClientID:        "",
+		Will:            mqttconf.EmptyWill(),
+		User:            "",
+		Password:        "",
+		ConnectTimeout:  "30s",
+		WriteTimeout:    "3s",
+		MaxInFlight:     64,
+		KeepAlive:       30,
+		TLS:             tls.NewConfig(),
+		Metadata:        metadata.NewExcludeFilterConfig(),
 	}
 }
 
@@ -75,7 +87,14 @@ type MQTT struct {
 	topic    *field.Expression
 	retained *field.Expression
 
+	metaFilter      *metadata.ExcludeFilter
+	responseTopic   *field.Expression
+	correlationData *field.Expression
+	contentType     *field.Expression
+	messageExpiry   *uint32
+
 	client  mqtt.Client
+	client5 *mqtt5.Client
 	connMut sync.RWMutex
 }
 
@@ -110,6 +129,41 @@ func NewMQTTV2(
 		}
 	}
 
+	if err := mqttconf.ValidateProtocolVersion(conf.ProtocolVersion); err != nil {
+		return nil, err
+	}
+
+	if m.metaFilter, err = conf.Metadata.Filter(); err != nil {
+		return nil, fmt.Errorf("failed to construct metadata filter: %w", err)
+	}
+
+	if conf.ResponseTopic != "" {
+		if m.responseTopic, err = mgr.BloblEnvironment().NewField(conf.ResponseTopic); err != nil {
+			return nil, fmt.Errorf("failed to parse response topic expression: %v", err)
+		}
+	}
+
+	if conf.CorrelationData != "" {
+		if m.correlationData, err = mgr.BloblEnvironment().NewField(conf.CorrelationData); err != nil {
+			return nil, fmt.Errorf("failed to parse correlation data expression: %v", err)
+		}
+	}
+
+	if conf.ContentType != "" {
+		if m.contentType, err = mgr.BloblEnvironment().NewField(conf.ContentType); err != nil {
+			return nil, fmt.Errorf("failed to parse content type expression: %v", err)
+		}
+	}
+
+	if conf.MessageExpiry != "" {
+		expiry, err := time.ParseDuration(conf.MessageExpiry)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse message expiry duration string: %w", err)
+		}
+		expirySeconds := uint32(expiry / time.Second)
+		m.messageExpiry = &expirySeconds
+	}
+
 	switch m.conf.DynamicClientIDSuffix {
 	case "nanoid":
 		nid, err := gonanoid.New()
@@ -149,10 +203,14 @@ func (m *MQTT) Connect() error {
 	m.connMut.Lock()
 	defer m.connMut.Unlock()
 
-	if m.client != nil {
+	if m.client != nil || m.client5 != nil {
 		return nil
 	}
 
+	if m.conf.ProtocolVersion == mqttconf.ProtocolVersion5 {
+		return m.connectV5()
+	}
+
 	conf := mqtt.NewClientOptions().
 		SetAutoReconnect(false).
 		SetConnectionLostHandler(func(client mqtt.Client, reason error) {
@@ -200,10 +258,56 @@ func (m *MQTT) Connect() error {
 	return nil
 }
 
+// connectV5 establishes a connection to an MQTT 5 server, this must be called
+// whilst holding the connection mutex.
+func (m *MQTT) connectV5() error {
+	opts := mqtt5.Options{
+		Brokers:        m.urls,
+		ClientID:       m.conf.ClientID,
+		CleanStart:     true,
+		KeepAlive:      time.Duration(m.conf.KeepAlive) * time.Second,
+		ConnectTimeout: m.connectTimeout,
+		WriteTimeout:   m.writeTimeout,
+		Username:       m.conf.User,
+		Password:       m.conf.Password,
+		OnConnectionLost: func(reason error) {
+			m.log.Errorf("Connection lost due to: %v\n", reason)
+		},
+	}
+
+	if m.conf.Will.Enabled {
+		opts.Will = &mqtt5.Message{
+			Topic:   m.conf.Will.Topic,
+			Payload: []byte(m.conf.Will.Payload),
+			QoS:     m.conf.Will.QoS,
+			Retain:  m.conf.Will.Retained,
+		}
+	}
+
+	if m.conf.TLS.Enabled {
+		tlsConf, err := m.conf.TLS.Get()
+		if err != nil {
+			return err
+		}
+		opts.TLSConfig = tlsConf
+	}
+
+	client, err := mqtt5.Dial(context.Background(), opts)
+	if err != nil {
+		return err
+	}
+
+	m.client5 = client
+	return nil
+}
+
 //------------------------------------------------------------------------------
 
 // WriteWithContext attempts to write a message by pushing it to an MQTT broker.
 func (m *MQTT) WriteWithContext(ctx context.Context, msg *message.Batch) error {
+	if m.conf.ProtocolVersion == mqttconf.ProtocolVersion5 {
+		return m.writeV5(ctx, msg)
+	}
 	return m.Write(msg)
 }
 
@@ -218,15 +322,7 @@ func (m *MQTT) Write(msg *message.Batch) error {
 	}
 
 	return IterateBatchedSend(msg, func(i int, p *message.Part) error {
-		retained := m.conf.Retained
-		if m.retained != nil {
-			var parseErr error
-			retained, parseErr = strconv.ParseBool(m.retained.String(i, msg))
-			if parseErr != nil {
-				m.log.Errorf("Error parsing boolean value from retained flag: %v \n", parseErr)
-			}
-		}
-		mtok := client.Publish(m.topic.String(i, msg), m.conf.QoS, retained, p.Get())
+		mtok := client.Publish(m.topic.String(i, msg), m.conf.QoS, m.retainedFlag(i, msg), p.Get())
 		mtok.Wait()
 		sendErr := mtok.Error()
 		if sendErr == mqtt.ErrNotConnected {
@@ -239,6 +335,65 @@ func (m *MQTT) Write(msg *message.Batch) error {
 	})
 }
 
+func (m *MQTT) retainedFlag(i int, msg *message.Batch) bool {
+	retained := m.conf.Retained
+	if m.retained != nil {
+		var parseErr error
+		retained, parseErr = strconv.ParseBool(m.retained.String(i, msg))
+		if parseErr != nil {
+			m.log.Errorf("Error parsing boolean value from retained flag: %v \n", parseErr)
+		}
+	}
+	return retained
+}
+
+// writeV5 publishes a message to an MQTT 5 server, where metadata is sent as
+// user properties.
+func (m *MQTT) writeV5(ctx context.Context, msg *message.Batch) error {
+	m.connMut.RLock()
+	client := m.client5
+	m.connMut.RUnlock()
+
+	if client == nil {
+		return component.ErrNotConnected
+	}
+
+	return IterateBatchedSend(msg, func(i int, p *message.Part) error {
+		out := &mqtt5.Message{
+			Topic:   m.topic.String(i, msg),
+			Payload: p.Get(),
+			QoS:     m.conf.QoS,
+			Retain:  m.retainedFlag(i, msg),
+		}
+
+		_ = m.metaFilter.Iter(p, func(k, v string) error {
+			out.Properties.User = append(out.Properties.User, mqtt5.UserProperty{Key: k, Value: v})
+			return nil
+		})
+		if m.responseTopic != nil {
+			out.Properties.ResponseTopic = m.responseTopic.String(i, msg)
+		}
+		if m.correlationData != nil {
+			out.Properties.CorrelationData = m.correlationData.Bytes(i, msg)
+		}
+		if m.contentType != nil {
+			out.Properties.ContentType = m.contentType.String(i, msg)
+		}
+		out.Properties.MessageExpiry = m.messageExpiry
+
+		sendErr := client.Publish(ctx, out)
+		if errors.Is(sendErr, mqtt5.ErrConnectionLost) {
+			m.connMut.Lock()
+			if m.client5 == client {
+				m.client5 = nil
+			}
+			m.connMut.Unlock()
+			sendErr = component.ErrNotConnected
+		}
+		return sendErr
+	})
+}
+
 // CloseAsync shuts down the MQTT output and stops processing messages.
 func (m *MQTT) CloseAsync() {
 	go func() {
@@ -247,6 +402,10 @@ func (m *MQTT) CloseAsync() {
 			m.client.Disconnect(0)
 			m.client = nil
 		}
+		if m.client5 != nil {
+			_ = m.client5.Disconnect()
+			m.client5 = nil
+		}
 		m.connMut.Unlock()
 	}()
 }
@@ -0,0 +1,116 @@
+package writer_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/impl/mqtt/mqtt5"
+	"github.com/benthosdev/benthos/v4/internal/impl/mqtt/mqtt5/mqtt5test"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/old/output/writer"
+)
+
+func TestMQTT5Write(t *testing.T) {
+	broker, err := mqtt5test.NewBroker()
+	require.NoError(t, err)
+	defer broker.Close()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	msgChan := make(chan *mqtt5.Message, 10)
+	sub, err := mqtt5.Dial(ctx, mqtt5.Options{
+		Brokers:  []string{broker.URL},
+		ClientID: "sub",
+		OnMessage: func(msg *mqtt5.Message, ack func()) {
+			ack()
+			msgChan <- msg
+		},
+	})
+	require.NoError(t, err)
+	defer sub.Disconnect()
+	require.NoError(t, sub.Subscribe(ctx, mqtt5.Subscription{Topic: "foo/#", QoS: 1}))
+
+	conf := writer.NewMQTTConfig()
+	conf.ProtocolVersion = "5"
+	conf.URLs = []string{broker.URL}
+	conf.ClientID = "pub"
+	conf.Topic = `foo/${! meta("id") }`
+	conf.Metadata.ExcludePrefixes = []string{"id"}
+	conf.ResponseTopic = `replies/${! meta("id") }`
+	conf.CorrelationData = `${! meta("id") }`
+	conf.ContentType = "application/json"
+	conf.MessageExpiry = "1m"
+
+	w, err := writer.NewMQTTV2(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	require.NoError(t, w.ConnectWithContext(ctx))
+	defer func() {
+		w.CloseAsync()
+		assert.NoError(t, w.WaitForClose(time.Second))
+	}()
+
+	part := message.NewPart([]byte(`{"hello":"world"}`))
+	part.MetaSet("id", "1")
+	part.MetaSet("source", "test")
+	batch := message.QuickBatch(nil)
+	batch.Append(part)
+	require.NoError(t, w.WriteWithContext(ctx, batch))
+
+	var msg *mqtt5.Message
+	select {
+	case msg = <-msgChan:
+	case <-ctx.Done():
+		t.Fatal("timed out")
+	}
+
+	assert.Equal(t, "foo/1", msg.Topic)
+	assert.Equal(t, `{"hello":"world"}`, string(msg.Payload))
+	assert.Equal(t, byte(1), msg.QoS)
+	assert.Equal(t, "replies/1", msg.Properties.ResponseTopic)
+	assert.Equal(t, []byte("1"), msg.Properties.CorrelationData)
+	assert.Equal(t, "application/json", msg.Properties.ContentType)
+	assert.Equal(t, []mqtt5.UserProperty{{Key: "source", Value: "test"}}, msg.Properties.User)
+	require.NotNil(t, msg.Properties.MessageExpiry)
+	assert.Equal(t, uint32(60), *msg.Properties.MessageExpiry)
+}
+
+func TestMQTT5WriteNotConnected(t *testing.T) {
+	broker, err := mqtt5test.NewBroker()
+	require.NoError(t, err)
+	defer broker.Close()
+
+	conf := writer.NewMQTTConfig()
+	conf.ProtocolVersion = "5"
+	conf.URLs = []string{broker.URL}
+	conf.Topic = "foo"
+
+	w, err := writer.NewMQTTV2(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	msg := message.QuickBatch([][]byte{[]byte("hello")})
+
+	assert.Error(t, w.WriteWithContext(ctx, msg))
+
+	require.NoError(t, w.ConnectWithContext(ctx))
+	require.NoError(t, w.WriteWithContext(ctx, msg))
+
+	broker.DisconnectClients()
+	assert.Eventually(t, func() bool {
+		return w.WriteWithContext(ctx, msg) != nil
+	}, time.Second*5, time.Millisecond*10)
+
+	require.NoError(t, w.ConnectWithContext(ctx))
+	require.NoError(t, w.WriteWithContext(ctx, msg))
+
+	w.CloseAsync()
+	require.NoError(t, w.WaitForClose(time.Second))
+}
@@ -72,7 +72,12 @@ headers should be copied into the resulting message from the response.
 When all retry attempts for a message are exhausted the processor cancels the
 attempt. These failed messages will continue through the pipeline unchanged, but
 can be dropped or placed in a dead letter queue according to your config, you
-can read about these patterns [here](/docs/configuration/error_handling).`,
+can read about these patterns [here](/docs/configuration/error_handling).
+
+When the ` + "`circuit_breaker`" + ` is enabled and open, requests are not
+attempted and messages are flagged as failed immediately, allowing error
+handling branches such as ` + "[`catch`](/docs/components/processors/catch)" + `
+to be executed without waiting through retries.`,
 		Config: ihttpdocs.ClientFieldSpec(false,
 			docs.FieldBool("batch_as_multipart", "Send message batches as a single request using [RFC1341](https://www.w3.org/Protocols/rfc1341/7_2_Multipart.html).").Advanced().HasDefault(false),
 			docs.FieldBool("parallel", "When processing batched messages, whether to send messages of the batch in parallel, otherwise they are sent serially.").HasDefault(false)),
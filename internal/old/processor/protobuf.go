@@ -4,24 +4,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
+
+	"github.com/jhump/protoreflect/desc"
 
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
 	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/impl/protobuf"
 	"github.com/benthosdev/benthos/v4/internal/interop"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/message"
-
-	// nolint:staticcheck // Ignore SA1019 deprecation warning until we can switch to "google.golang.org/protobuf/types/dynamicpb"
-	"github.com/golang/protobuf/jsonpb"
-	// nolint:staticcheck // Ignore SA1019 deprecation warning until we can switch to "google.golang.org/protobuf/types/dynamicpb"
-	"github.com/golang/protobuf/proto"
-
-	"github.com/jhump/protoreflect/desc"
-	"github.com/jhump/protoreflect/desc/protoparse"
-	"github.com/jhump/protoreflect/dynamic"
 )
 
 //------------------------------------------------------------------------------
@@ -63,11 +55,23 @@ to manipulate the contents of the document within Benthos.
 
 ### ` + "`from_json`" + `
 
-Attempts to create a target protobuf message from a generic JSON structure.`,
+Attempts to create a target protobuf message from a generic JSON structure.
+
+## Any Types
+
+Fields of the type ` + "`google.protobuf.Any`" + ` are resolved using all of the
+definitions loaded from ` + "`import_paths` and `descriptor_sets`" + `, and are
+therefore converted to and from JSON along with the rest of the message as long
+as their type is present within those definitions.
+
+If you only need to convert individual fields of a document rather than whole
+messages you can use the [Bloblang methods](/docs/guides/bloblang/methods)
+` + "`decode_protobuf` and `encode_protobuf`" + ` instead.`,
 		Config: docs.FieldComponent().WithChildren(
 			docs.FieldString("operator", "The [operator](#operators) to execute").HasOptions("to_json", "from_json"),
 			docs.FieldString("message", "The fully qualified name of the protobuf message to convert to/from."),
-			docs.FieldString("import_paths", "A list of directories containing .proto files, including all definitions required for parsing the target message. If left empty the current directory is used. Each directory listed will be walked with all found .proto files imported.").Array(),
+			docs.FieldString("import_paths", "A list of directories containing .proto files, including all definitions required for parsing the target message. If both this field and `descriptor_sets` are left empty the current directory is used. Each directory listed will be walked with all found .proto files imported.").Array(),
+			docs.FieldString("descriptor_sets", "A list of paths to precompiled `FileDescriptorSet` files, as produced by `protoc` with the flags `--include_imports --descriptor_set_out`, containing all definitions required for parsing the target message. These can be used instead of, or in addition to, `import_paths`.").Array().Advanced(),
 		),
 		Examples: []docs.AnnotatedExample{
 			{
@@ -164,17 +168,19 @@ pipeline:
 
 // ProtobufConfig contains configuration fields for the Protobuf processor.
 type ProtobufConfig struct {
-	Operator    string   `json:"operator" yaml:"operator"`
-	Message     string   `json:"message" yaml:"message"`
-	ImportPaths []string `json:"import_paths" yaml:"import_paths"`
+	Operator       string   `json:"operator" yaml:"operator"`
+	Message        string   `json:"message" yaml:"message"`
+	ImportPaths    []string `json:"import_paths" yaml:"import_paths"`
+	DescriptorSets []string `json:"descriptor_sets" yaml:"descriptor_sets"`
 }
 
 // NewProtobufConfig returns a ProtobufConfig with default values.
 func NewProtobufConfig() ProtobufConfig {
 	return ProtobufConfig{
-		Operator:    "",
-		Message:     "",
-		ImportPaths: []string{},
+		Operator:       "",
+		Message:        "",
+		ImportPaths:    []string{},
+		DescriptorSets: []string{},
 	}
 }
 
@@ -182,133 +188,53 @@ func NewProtobufConfig() ProtobufConfig {
 
 type protobufOperator func(part *message.Part) error
 
-func newProtobufToJSONOperator(msg string, importPaths []string) (protobufOperator, error) {
-	if msg == "" {
-		return nil, errors.New("message field must not be empty")
-	}
-
-	descriptors, err := loadDescriptors(importPaths)
-	if err != nil {
-		return nil, err
-	}
-
-	m := getMessageFromDescriptors(msg, descriptors)
-	if m == nil {
-		return nil, fmt.Errorf("unable to find message '%v' definition within '%v'", msg, importPaths)
-	}
-
-	marshaller := &jsonpb.Marshaler{
-		AnyResolver: dynamic.AnyResolver(dynamic.NewMessageFactoryWithDefaults(), descriptors...),
-	}
-
+func newProtobufToJSONOperator(registry *protobuf.Registry, m *desc.MessageDescriptor) protobufOperator {
 	return func(part *message.Part) error {
-		msg := dynamic.NewMessage(m)
-		if err := proto.Unmarshal(part.Get(), msg); err != nil {
-			return fmt.Errorf("failed to unmarshal message: %w", err)
-		}
-
-		data, err := msg.MarshalJSONPB(marshaller)
+		data, err := registry.ToJSON(m, part.Get())
 		if err != nil {
-			return fmt.Errorf("failed to marshal protobuf message: %w", err)
+			return err
 		}
-
 		part.Set(data)
 		return nil
-	}, nil
-}
-
-func newProtobufFromJSONOperator(msg string, importPaths []string) (protobufOperator, error) {
-	if msg == "" {
-		return nil, errors.New("message field must not be empty")
-	}
-
-	descriptors, err := loadDescriptors(importPaths)
-	if err != nil {
-		return nil, err
-	}
-
-	m := getMessageFromDescriptors(msg, descriptors)
-	if m == nil {
-		return nil, fmt.Errorf("unable to find message '%v' definition within '%v'", msg, importPaths)
-	}
-
-	unmarshaler := &jsonpb.Unmarshaler{
-		AnyResolver: dynamic.AnyResolver(dynamic.NewMessageFactoryWithDefaults(), descriptors...),
 	}
+}
 
+func newProtobufFromJSONOperator(registry *protobuf.Registry, m *desc.MessageDescriptor) protobufOperator {
 	return func(part *message.Part) error {
-		msg := dynamic.NewMessage(m)
-		if err := msg.UnmarshalJSONPB(unmarshaler, part.Get()); err != nil {
-			return fmt.Errorf("failed to unmarshal JSON message: %w", err)
-		}
-
-		data, err := msg.Marshal()
+		data, err := registry.FromJSON(m, part.Get())
 		if err != nil {
-			return fmt.Errorf("failed to marshal protobuf message: %v", err)
+			return err
 		}
-
 		part.Set(data)
 		return nil
-	}, nil
+	}
 }
 
-func strToProtobufOperator(opStr, message string, importPaths []string) (protobufOperator, error) {
-	switch opStr {
+func strToProtobufOperator(conf ProtobufConfig) (protobufOperator, error) {
+	var ctor func(*protobuf.Registry, *desc.MessageDescriptor) protobufOperator
+	switch conf.Operator {
 	case "to_json":
-		return newProtobufToJSONOperator(message, importPaths)
+		ctor = newProtobufToJSONOperator
 	case "from_json":
-		return newProtobufFromJSONOperator(message, importPaths)
-	}
-	return nil, fmt.Errorf("operator not recognised: %v", opStr)
-}
-
-func loadDescriptors(importPaths []string) ([]*desc.FileDescriptor, error) {
-	var parser protoparse.Parser
-	if len(importPaths) == 0 {
-		importPaths = []string{"."}
-	} else {
-		parser.ImportPaths = importPaths
+		ctor = newProtobufFromJSONOperator
+	default:
+		return nil, fmt.Errorf("operator not recognised: %v", conf.Operator)
 	}
 
-	var files []string
-	for _, importPath := range importPaths {
-		if err := filepath.Walk(importPath, func(path string, info os.FileInfo, ferr error) error {
-			if ferr != nil || info.IsDir() {
-				return ferr
-			}
-			if filepath.Ext(info.Name()) == ".proto" {
-				rPath, ferr := filepath.Rel(importPath, path)
-				if ferr != nil {
-					return fmt.Errorf("failed to get relative path: %v", ferr)
-				}
-				files = append(files, rPath)
-			}
-			return nil
-		}); err != nil {
-			return nil, err
-		}
+	if conf.Message == "" {
+		return nil, errors.New("message field must not be empty")
 	}
 
-	fds, err := parser.ParseFiles(files...)
+	registry, err := protobuf.LoadRegistry(conf.ImportPaths, conf.DescriptorSets)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse .proto file: %v", err)
-	}
-	if len(fds) == 0 {
-		return nil, fmt.Errorf("no .proto files were found in the paths '%v'", importPaths)
+		return nil, err
 	}
 
-	return fds, err
-}
-
-func getMessageFromDescriptors(message string, fds []*desc.FileDescriptor) *desc.MessageDescriptor {
-	var msg *desc.MessageDescriptor
-	for _, fd := range fds {
-		msg = fd.FindMessage(message)
-		if msg != nil {
-			break
-		}
+	m, err := registry.FindMessage(conf.Message)
+	if err != nil {
+		return nil, err
 	}
-	return msg
+	return ctor(registry, m), nil
 }
 
 //------------------------------------------------------------------------------
@@ -323,7 +249,7 @@ func newProtobuf(conf ProtobufConfig, mgr interop.Manager) (*protobufProc, error
 		log: mgr.Logger(),
 	}
 	var err error
-	if p.operator, err = strToProtobufOperator(conf.Operator, conf.Message, conf.ImportPaths); err != nil {
+	if p.operator, err = strToProtobufOperator(conf); err != nil {
 		return nil, err
 	}
 	return p, nil
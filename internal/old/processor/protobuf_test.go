@@ -1,12 +1,18 @@
 package processor
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
+	// nolint:staticcheck // Ignore SA1019 deprecation warning until we can switch to "google.golang.org/protobuf/types/dynamicpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/jhump/protoreflect/desc"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/impl/protobuf"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/manager/mock"
 	"github.com/benthosdev/benthos/v4/internal/message"
@@ -199,3 +205,37 @@ func TestProtobufErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestProtobufDescriptorSet(t *testing.T) {
+	fds, err := protobuf.LoadFromImportPaths([]string{"../../../config/test/protobuf/schema"})
+	require.NoError(t, err)
+
+	setBytes, err := proto.Marshal(desc.ToFileDescriptorSet(fds...))
+	require.NoError(t, err)
+
+	setPath := filepath.Join(t.TempDir(), "schema.pb")
+	require.NoError(t, os.WriteFile(setPath, setBytes, 0o644))
+
+	conf := NewConfig()
+	conf.Type = TypeProtobuf
+	conf.Protobuf.Operator = "to_json"
+	conf.Protobuf.Message = "testing.Envelope"
+	conf.Protobuf.DescriptorSets = []string{setPath}
+
+	proc, err := New(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := proc.ProcessMessage(message.QuickBatch([][]byte{
+		{
+			0x8, 0xeb, 0x5, 0x12, 0x2a, 0xa, 0x21, 0x74, 0x79, 0x70, 0x65, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c,
+			0x65, 0x61, 0x70, 0x69, 0x73, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x74, 0x65, 0x73, 0x74, 0x69, 0x6e,
+			0x67, 0x2e, 0x48, 0x6f, 0x75, 0x73, 0x65, 0x12, 0x5, 0x12, 0x3, 0x31, 0x32, 0x33,
+		},
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	assert.Equal(t, [][]byte{
+		[]byte(`{"id":747,"content":{"@type":"type.googleapis.com/testing.House","address":"123"}}`),
+	}, message.GetAllBytes(msgs[0]))
+}
@@ -29,6 +29,10 @@ func init() {
 		Summary: `
 Parses messages as an XML document, performs a mutation on the data, and then
 overwrites the previous contents with the new value.`,
+		Footnotes: `
+## Round Trips
+
+Documents converted with ` + "`to_json`" + ` can be converted back into XML with ` + "`from_json`" + ` without loss, other than any comments, directives and process instructions, which are not retained. The ` + "`to_json`" + ` operator writes the keys of objects in alphabetical order, and ` + "`from_json`" + ` keeps the order of the JSON document, therefore sibling elements of different names come back in alphabetical order.`,
 		Description: `
 ## Operators
 
@@ -92,10 +96,37 @@ With cast set to true, the resulting JSON structure would look like this:
     ]
   }
 }
+` + "```" + `
+
+### ` + "`from_json`" + `
+
+Converts a JSON structure into an XML document following the same rules as ` + "`to_json`" + ` in reverse, where keys prefixed with a hyphen, ` + "`-`" + `, become attributes, the key ` + "`#text`" + ` becomes the value of an element with attributes, and arrays become repeated elements.
+
+The JSON structure must be an object with a single key, which becomes the root element, otherwise the keys of the object are wrapped within a root element ` + "`doc`" + `. Elements and attributes are written in the order that they appear within the JSON document.
+
+For example, given the following JSON:
+
+` + "```json" + `
+{
+  "root":{
+    "description":{
+      "#text":"This is a description",
+      "-tone":"boring"
+    },
+    "elements":["foo1","foo2"]
+  }
+}
+` + "```" + `
+
+The resulting XML document would look like this:
+
+` + "```xml" + `
+<root><description tone="boring">This is a description</description><elements>foo1</elements><elements>foo2</elements></root>
 ` + "```" + ``,
 		Config: docs.FieldComponent().WithChildren(
-			docs.FieldString("operator", "An XML [operation](#operators) to apply to messages.").HasOptions("to_json"),
-			docs.FieldBool("cast", "Whether to try to cast values that are numbers and booleans to the right type. Default: all values are strings."),
+			docs.FieldString("operator", "An XML [operation](#operators) to apply to messages.").HasOptions("to_json", "from_json"),
+			docs.FieldBool("cast", "Whether to try to cast values that are numbers and booleans to the right type when converting to JSON. Default: all values are strings."),
+			docs.FieldString("indent", "An indentation string to pretty print XML documents with when converting from JSON. Default: no indentation.").Advanced(),
 		),
 	}
 }
@@ -106,6 +137,7 @@ With cast set to true, the resulting JSON structure would look like this:
 type XMLConfig struct {
 	Operator string `json:"operator" yaml:"operator"`
 	Cast     bool   `json:"cast" yaml:"cast"`
+	Indent   string `json:"indent" yaml:"indent"`
 }
 
 // NewXMLConfig returns a XMLConfig with default values.
@@ -113,29 +145,47 @@ func NewXMLConfig() XMLConfig {
 	return XMLConfig{
 		Operator: "",
 		Cast:     false,
+		Indent:   "",
 	}
 }
 
 //------------------------------------------------------------------------------
 
 type xmlProc struct {
-	log  log.Modular
-	cast bool
+	log      log.Modular
+	fromJSON bool
+	cast     bool
+	indent   string
 }
 
 func newXML(conf XMLConfig, mgr interop.Manager) (*xmlProc, error) {
-	if conf.Operator != "to_json" {
-		return nil, fmt.Errorf("operator not recognised: %v", conf.Operator)
-	}
 	j := &xmlProc{
-		log:  mgr.Logger(),
-		cast: conf.Cast,
+		log:    mgr.Logger(),
+		cast:   conf.Cast,
+		indent: conf.Indent,
+	}
+	switch conf.Operator {
+	case "to_json":
+	case "from_json":
+		j.fromJSON = true
+	default:
+		return nil, fmt.Errorf("operator not recognised: %v", conf.Operator)
 	}
 	return j, nil
 }
 
 func (p *xmlProc) Process(ctx context.Context, msg *message.Part) ([]*message.Part, error) {
 	newPart := msg.Copy()
+	if p.fromJSON {
+		xmlBytes, err := xml.FromJSON(newPart.Get(), p.indent)
+		if err != nil {
+			p.log.Debugf("Failed to format part as XML: %v", err)
+			return nil, err
+		}
+		newPart.Set(xmlBytes)
+		return []*message.Part{newPart}, nil
+	}
+
 	root, err := xml.ToMap(newPart.Get(), p.cast)
 	if err != nil {
 		p.log.Debugf("Failed to parse part as XML: %v", err)
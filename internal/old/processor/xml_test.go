@@ -143,3 +143,38 @@ func TestXMLWithCast(t *testing.T) {
 		t.Error(errStr)
 	}
 }
+
+func TestXMLFromJSON(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = "xml"
+	conf.XML.Operator = "from_json"
+
+	proc, err := New(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testString := `{"root":{"number":123,"description":{"#text":"This is a description","-tone":"boring"},"elements":["foo1","foo2"]}}`
+
+	msgsOut, res := proc.ProcessMessage(message.QuickBatch([][]byte{[]byte(testString)}))
+	if res != nil {
+		t.Fatal(res.Error())
+	}
+	if len(msgsOut) != 1 {
+		t.Fatalf("Wrong count of result messages: %v != 1", len(msgsOut))
+	}
+	if exp, act := `<root><number>123</number><description tone="boring">This is a description</description><elements>foo1</elements><elements>foo2</elements></root>`, string(msgsOut[0].Get(0).Get()); exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+	if errStr := GetFail(msgsOut[0].Get(0)); len(errStr) > 0 {
+		t.Error(errStr)
+	}
+
+	msgsOut, _ = proc.ProcessMessage(message.QuickBatch([][]byte{[]byte(`["not","an","object"]`)}))
+	if len(msgsOut) != 1 {
+		t.Fatalf("Wrong count of result messages: %v != 1", len(msgsOut))
+	}
+	if errStr := GetFail(msgsOut[0].Get(0)); len(errStr) == 0 {
+		t.Error("Expected error flag")
+	}
+}
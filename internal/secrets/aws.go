@@ -0,0 +1,68 @@
+package secrets
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+)
+
+type awsSecretsManagerProvider struct {
+	conf *aws.Config
+
+	mut    sync.Mutex
+	client *secretsmanager.SecretsManager
+}
+
+// NewAWSSecretsManagerProvider creates a provider that resolves secrets from
+// AWS Secrets Manager, with references of the form <secret id>#<key> where the
+// key is optional and extracts a field from a JSON secret. When the config is
+// nil credentials and region are obtained from the environment and shared
+// config files.
+func NewAWSSecretsManagerProvider(conf *aws.Config) Provider {
+	if conf == nil {
+		conf = &aws.Config{}
+	}
+	return &awsSecretsManagerProvider{conf: conf}
+}
+
+func (a *awsSecretsManagerProvider) getClient() (*secretsmanager.SecretsManager, error) {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	if a.client != nil {
+		return a.client, nil
+	}
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            *a.conf,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, err
+	}
+	a.client = secretsmanager.New(sess)
+	return a.client, nil
+}
+
+func (a *awsSecretsManagerProvider) Lookup(ctx context.Context, ref string) (string, error) {
+	client, err := a.getClient()
+	if err != nil {
+		return "", err
+	}
+
+	id, key := splitKey(ref)
+	out, err := client.GetSecretValueWithContext(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(id),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	secret := out.SecretBinary
+	if out.SecretString != nil {
+		secret = []byte(*out.SecretString)
+	}
+	return extractKey(secret, key)
+}
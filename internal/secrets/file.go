@@ -0,0 +1,18 @@
+package secrets
+
+import (
+	"context"
+	"os"
+	"strings"
+)
+
+// fileLookup resolves a secret from the contents of a file, where trailing
+// newlines are removed. This is compatible with secrets mounted by container
+// orchestrators such as Docker and Kubernetes.
+func fileLookup(ctx context.Context, path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
@@ -0,0 +1,99 @@
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"golang.org/x/oauth2/google"
+)
+
+const gcpSecretManagerURL = "https://secretmanager.googleapis.com"
+
+type gcpSecretManagerProvider struct {
+	baseURL string
+
+	mut    sync.Mutex
+	client *http.Client
+}
+
+// NewGCPSecretManagerProvider creates a provider that resolves secrets from
+// GCP Secret Manager, with references of the form
+// projects/<project>/secrets/<secret>[/versions/<version>]#<key> where the
+// version defaults to latest and the key is optional and extracts a field from
+// a JSON secret. When the client is nil requests are authenticated with the
+// application default credentials.
+func NewGCPSecretManagerProvider(baseURL string, client *http.Client) Provider {
+	return &gcpSecretManagerProvider{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  client,
+	}
+}
+
+func (g *gcpSecretManagerProvider) getClient(ctx context.Context) (*http.Client, error) {
+	g.mut.Lock()
+	defer g.mut.Unlock()
+
+	if g.client != nil {
+		return g.client, nil
+	}
+	// The client outlives the context of the lookup that creates it.
+	client, err := google.DefaultClient(context.Background(), "https://www.googleapis.com/auth/cloud-platform")
+	if err != nil {
+		return nil, err
+	}
+	g.client = client
+	return g.client, nil
+}
+
+func (g *gcpSecretManagerProvider) Lookup(ctx context.Context, ref string) (string, error) {
+	client, err := g.getClient(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	name, key := splitKey(ref)
+	name = strings.Trim(name, "/")
+	if !strings.Contains(name, "/versions/") {
+		name += "/versions/latest"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", g.baseURL+"/v1/"+name+":access", nil)
+	if err != nil {
+		return "", err
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("secret manager responded with status %v: %s", res.StatusCode, body)
+	}
+
+	var secret struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := json.Unmarshal(body, &secret); err != nil {
+		return "", fmt.Errorf("failed to parse secret manager response: %w", err)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(secret.Payload.Data)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode secret payload: %w", err)
+	}
+	return extractKey(data, key)
+}
@@ -0,0 +1,160 @@
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVaultProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "footoken" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/db":
+			_, _ = w.Write([]byte(`{"data":{"data":{"user":"foo","password":"bar"},"metadata":{"version":1}}}`))
+		case "/v1/kv/api":
+			_, _ = w.Write([]byte(`{"data":{"token":"baz"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	p := NewVaultProvider(server.URL, "footoken")
+	ctx := context.Background()
+
+	for ref, exp := range map[string]string{
+		"secret/data/db#password": "bar",
+		"secret/data/db#user":     "foo",
+		"kv/api#token":            "baz",
+		"kv/api":                  "baz",
+	} {
+		v, err := p.Lookup(ctx, ref)
+		require.NoError(t, err, ref)
+		assert.Equal(t, exp, v, ref)
+	}
+
+	for _, ref := range []string{"secret/data/db", "secret/data/db#nope", "kv/nope#token"} {
+		_, err := p.Lookup(ctx, ref)
+		assert.Error(t, err, ref)
+	}
+
+	_, err := NewVaultProvider(server.URL, "badtoken").Lookup(ctx, "kv/api")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "403")
+}
+
+func TestVaultProviderFromEnv(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "footoken", r.Header.Get("X-Vault-Token"))
+		_, _ = w.Write([]byte(`{"data":{"token":"baz"}}`))
+	}))
+	defer server.Close()
+
+	for k, v := range map[string]string{
+		"VAULT_ADDR":  server.URL,
+		"VAULT_TOKEN": "footoken",
+	} {
+		prev, exists := os.LookupEnv(k)
+		require.NoError(t, os.Setenv(k, v))
+		k := k
+		t.Cleanup(func() {
+			if exists {
+				_ = os.Setenv(k, prev)
+			} else {
+				_ = os.Unsetenv(k)
+			}
+		})
+	}
+
+	v, err := NewVaultProviderFromEnv().Lookup(context.Background(), "kv/api#token")
+	require.NoError(t, err)
+	assert.Equal(t, "baz", v)
+}
+
+func TestAWSSecretsManagerProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secretsmanager.GetSecretValue", r.Header.Get("X-Amz-Target"))
+		body, _ := io.ReadAll(r.Body)
+		switch string(body) {
+		case `{"SecretId":"db"}`:
+			_, _ = w.Write([]byte(`{"Name":"db","SecretString":"{\"password\":\"bar\"}"}`))
+		case `{"SecretId":"arn:aws:secretsmanager:eu-west-1:123:secret:api"}`:
+			_, _ = w.Write([]byte(`{"Name":"api","SecretString":"baz"}`))
+		default:
+			w.Header().Set("X-Amzn-Errortype", "ResourceNotFoundException")
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"__type":"ResourceNotFoundException","message":"not found"}`))
+		}
+	}))
+	defer server.Close()
+
+	p := NewAWSSecretsManagerProvider(&aws.Config{
+		Endpoint:    aws.String(server.URL),
+		Region:      aws.String("eu-west-1"),
+		Credentials: credentials.NewStaticCredentials("foo", "bar", ""),
+	})
+	ctx := context.Background()
+
+	for ref, exp := range map[string]string{
+		"db#password": "bar",
+		"db":          `{"password":"bar"}`,
+		"arn:aws:secretsmanager:eu-west-1:123:secret:api": "baz",
+	} {
+		v, err := p.Lookup(ctx, ref)
+		require.NoError(t, err, ref)
+		assert.Equal(t, exp, v, ref)
+	}
+
+	for _, ref := range []string{"nope", "db#nope"} {
+		_, err := p.Lookup(ctx, ref)
+		assert.Error(t, err, ref)
+	}
+}
+
+func TestGCPSecretManagerProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload string
+		switch r.URL.Path {
+		case "/v1/projects/foo/secrets/db/versions/latest:access":
+			payload = `{"password":"bar"}`
+		case "/v1/projects/foo/secrets/api/versions/3:access":
+			payload = "baz"
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"name":"x","payload":{"data":"` + base64.StdEncoding.EncodeToString([]byte(payload)) + `"}}`))
+	}))
+	defer server.Close()
+
+	p := NewGCPSecretManagerProvider(server.URL, server.Client())
+	ctx := context.Background()
+
+	for ref, exp := range map[string]string{
+		"projects/foo/secrets/db#password":      "bar",
+		"projects/foo/secrets/db":               `{"password":"bar"}`,
+		"projects/foo/secrets/api/versions/3":   "baz",
+		"/projects/foo/secrets/api/versions/3/": "baz",
+	} {
+		v, err := p.Lookup(ctx, ref)
+		require.NoError(t, err, ref)
+		assert.Equal(t, exp, v, ref)
+	}
+
+	_, err := p.Lookup(ctx, "projects/foo/secrets/nope")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "404")
+}
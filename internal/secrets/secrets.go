@@ -0,0 +1,359 @@
+// Package secrets provides pluggable providers for resolving secrets that are
+// referenced within config files, along with the means to redact the fields
+// that resolved secrets are written to from anything that may expose them.
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Redacted is the value that resolved secrets are replaced with when redacted.
+const Redacted = "!!!SECRET_SCRUBBED!!!"
+
+// ErrUnknownScheme is returned when a lookup targets a scheme that has no
+// registered provider.
+var ErrUnknownScheme = errors.New("unknown secret scheme")
+
+// Provider resolves references to secrets.
+type Provider interface {
+	Lookup(ctx context.Context, ref string) (string, error)
+}
+
+// ProviderFunc is a closure that implements Provider.
+type ProviderFunc func(ctx context.Context, ref string) (string, error)
+
+// Lookup resolves a reference to a secret.
+func (f ProviderFunc) Lookup(ctx context.Context, ref string) (string, error) {
+	return f(ctx, ref)
+}
+
+//------------------------------------------------------------------------------
+
+// refreshTimeout is the maximum period of time to wait for a provider when
+// refreshing a cached secret.
+const refreshTimeout = time.Second * 30
+
+type cachedSecret struct {
+	value      string
+	resolved   time.Time
+	refreshing bool
+}
+
+// fieldValues holds the resolved values of secrets by the path of the field
+// they were written to.
+type fieldValues map[string]map[string]struct{}
+
+// Registry holds secret providers by the scheme that they resolve, caches
+// resolved secrets by their reference, and tracks the fields of configs that
+// resolved secrets have been written to so that they can be redacted.
+type Registry struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mut       sync.Mutex
+	providers map[string]Provider
+	cache     map[string]*cachedSecret
+	fields    map[string]fieldValues
+}
+
+// NewRegistry creates an empty registry where resolved secrets are cached for
+// the provided duration before they are refreshed, a duration of zero disables
+// caching.
+func NewRegistry(ttl time.Duration) *Registry {
+	return &Registry{
+		ttl:       ttl,
+		now:       time.Now,
+		providers: map[string]Provider{},
+		cache:     map[string]*cachedSecret{},
+		fields:    map[string]fieldValues{},
+	}
+}
+
+// Register a provider for a scheme, replacing any existing provider.
+func (r *Registry) Register(scheme string, p Provider) {
+	r.mut.Lock()
+	r.providers[scheme] = p
+	r.mut.Unlock()
+}
+
+// Has returns whether a provider is registered for a scheme.
+func (r *Registry) Has(scheme string) bool {
+	r.mut.Lock()
+	_, exists := r.providers[scheme]
+	r.mut.Unlock()
+	return exists
+}
+
+// Lookup resolves a reference to a secret with the provider of a scheme. Once
+// resolved a secret is cached, and when a cached secret has expired it is
+// returned whilst being refreshed in the background. If the refresh fails the
+// last resolved value is kept, so that a provider outage does not prevent
+// configs from being read.
+func (r *Registry) Lookup(ctx context.Context, scheme, ref string) (string, error) {
+	key := scheme + ":" + ref
+
+	r.mut.Lock()
+	p, exists := r.providers[scheme]
+	if cached, isCached := r.cache[key]; exists && isCached {
+		if !cached.refreshing && r.now().Sub(cached.resolved) >= r.ttl {
+			cached.refreshing = true
+			go r.refresh(p, ref, cached)
+		}
+		r.mut.Unlock()
+		return cached.value, nil
+	}
+	r.mut.Unlock()
+
+	if !exists {
+		return "", fmt.Errorf("%w: %v", ErrUnknownScheme, scheme)
+	}
+
+	value, err := p.Lookup(ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %v secret '%v': %w", scheme, ref, err)
+	}
+
+	if r.ttl > 0 {
+		r.mut.Lock()
+		r.cache[key] = &cachedSecret{value: value, resolved: r.now()}
+		r.mut.Unlock()
+	}
+	return value, nil
+}
+
+func (r *Registry) refresh(p Provider, ref string, cached *cachedSecret) {
+	ctx, done := context.WithTimeout(context.Background(), refreshTimeout)
+	defer done()
+
+	value, err := p.Lookup(ctx, ref)
+
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	// A failed refresh is attempted again by the next lookup.
+	cached.refreshing = false
+	if err == nil {
+		cached.value = value
+		cached.resolved = r.now()
+	}
+}
+
+// TrackFields records the fields of a config that resolved secrets have been
+// written to, where raw is the config before interpolation, resolved is the
+// config after interpolation, and isSecret reports whether the value of a raw
+// scalar references a secret. Each field is identified by its path, where
+// array indexes are ignored as the elements of arrays are often merged with
+// those of other config files, along with its resolved value.
+//
+// The fields are recorded for a source, such as the path of a config file,
+// and replace those previously recorded for it so that configs that are read
+// again do not accumulate fields. Fields recorded with an empty source are
+// added to those of prior configs without a source.
+func (r *Registry) TrackFields(source string, raw, resolved *yaml.Node, isSecret func(value string) bool) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	fields := r.fields[source]
+	if fields == nil || source != "" {
+		fields = fieldValues{}
+	}
+	fields.track(raw, resolved, "", isSecret)
+	r.fields[source] = fields
+}
+
+// ClearFields removes the fields recorded for a source, which is used when a
+// config is read again and no longer resolves secrets.
+func (r *Registry) ClearFields(source string) {
+	r.mut.Lock()
+	delete(r.fields, source)
+	r.mut.Unlock()
+}
+
+func (f fieldValues) track(raw, resolved *yaml.Node, path string, isSecret func(value string) bool) {
+	raw, resolved = unwrapNode(raw), unwrapNode(resolved)
+	if raw == nil || resolved == nil {
+		return
+	}
+
+	if raw.Kind == yaml.ScalarNode && isSecret(raw.Value) {
+		// A secret may resolve to structured data, in which case all scalars
+		// within it are tracked.
+		walkScalars(resolved, path, func(path string, n *yaml.Node) {
+			values, exists := f[path]
+			if !exists {
+				values = map[string]struct{}{}
+				f[path] = values
+			}
+			values[n.Value] = struct{}{}
+		})
+		return
+	}
+
+	switch {
+	case raw.Kind == yaml.MappingNode && resolved.Kind == yaml.MappingNode:
+		for i := 0; i+1 < len(raw.Content); i += 2 {
+			key := raw.Content[i].Value
+			for j := 0; j+1 < len(resolved.Content); j += 2 {
+				if resolved.Content[j].Value == key {
+					f.track(raw.Content[i+1], resolved.Content[j+1], joinPath(path, key), isSecret)
+					break
+				}
+			}
+		}
+	case raw.Kind == yaml.SequenceNode && resolved.Kind == yaml.SequenceNode:
+		for i := 0; i < len(raw.Content) && i < len(resolved.Content); i++ {
+			f.track(raw.Content[i], resolved.Content[i], joinPath(path, "*"), isSecret)
+		}
+	}
+}
+
+// RedactNode returns a copy of a YAML node where the values of fields that
+// resolved secrets have been written to are redacted.
+func (r *Registry) RedactNode(n *yaml.Node) *yaml.Node {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	return r.redactNode(n, "")
+}
+
+func (r *Registry) redactNode(n *yaml.Node, path string) *yaml.Node {
+	if n == nil {
+		return nil
+	}
+	c := *n
+	if c.Kind == yaml.ScalarNode {
+		for _, fields := range r.fields {
+			if _, exists := fields[path][c.Value]; exists {
+				c.Value = Redacted
+				c.Tag = "!!str"
+				c.Style = 0
+				break
+			}
+		}
+		return &c
+	}
+	if len(n.Content) > 0 {
+		c.Content = make([]*yaml.Node, len(n.Content))
+		copy(c.Content, n.Content)
+		switch n.Kind {
+		case yaml.DocumentNode:
+			for i, child := range n.Content {
+				c.Content[i] = r.redactNode(child, path)
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				c.Content[i+1] = r.redactNode(n.Content[i+1], joinPath(path, n.Content[i].Value))
+			}
+		case yaml.SequenceNode:
+			for i, child := range n.Content {
+				c.Content[i] = r.redactNode(child, joinPath(path, "*"))
+			}
+		}
+	}
+	return &c
+}
+
+func joinPath(path, segment string) string {
+	if path == "" {
+		return segment
+	}
+	return path + "." + segment
+}
+
+// unwrapNode returns the content of document nodes and the target of aliases.
+func unwrapNode(n *yaml.Node) *yaml.Node {
+	for n != nil {
+		switch {
+		case n.Kind == yaml.DocumentNode && len(n.Content) > 0:
+			n = n.Content[0]
+		case n.Kind == yaml.AliasNode:
+			n = n.Alias
+		default:
+			return n
+		}
+	}
+	return nil
+}
+
+// walkScalars calls fn for each scalar within a node along with its path.
+func walkScalars(n *yaml.Node, path string, fn func(path string, n *yaml.Node)) {
+	if n = unwrapNode(n); n == nil {
+		return
+	}
+	switch n.Kind {
+	case yaml.ScalarNode:
+		fn(path, n)
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			walkScalars(n.Content[i+1], joinPath(path, n.Content[i].Value), fn)
+		}
+	case yaml.SequenceNode:
+		for _, child := range n.Content {
+			walkScalars(child, joinPath(path, "*"), fn)
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// DefaultCacheTTL is the period of time that secrets resolved by the default
+// registry are cached for before they are refreshed.
+const DefaultCacheTTL = time.Minute * 5
+
+// DefaultRegistry is the registry used for resolving secrets within configs,
+// which contains providers for the schemes file, vault, aws_secrets_manager and
+// gcp_secret_manager.
+var DefaultRegistry = NewRegistry(DefaultCacheTTL)
+
+func init() {
+	DefaultRegistry.Register("file", ProviderFunc(fileLookup))
+	DefaultRegistry.Register("vault", NewVaultProviderFromEnv())
+	DefaultRegistry.Register("aws_secrets_manager", NewAWSSecretsManagerProvider(nil))
+	DefaultRegistry.Register("gcp_secret_manager", NewGCPSecretManagerProvider(gcpSecretManagerURL, nil))
+}
+
+//------------------------------------------------------------------------------
+
+// splitKey splits a reference of the form <name>#<key> where the key is
+// optional.
+func splitKey(ref string) (name, key string) {
+	if i := strings.LastIndexByte(ref, '#'); i != -1 {
+		return ref[:i], ref[i+1:]
+	}
+	return ref, ""
+}
+
+// extractKey obtains a field from a JSON object secret, or the secret as is
+// when no key is specified.
+func extractKey(secret []byte, key string) (string, error) {
+	if key == "" {
+		return string(secret), nil
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal(secret, &obj); err != nil {
+		return "", fmt.Errorf("failed to parse secret as a JSON object: %w", err)
+	}
+	return fieldFromObject(obj, key)
+}
+
+func fieldFromObject(obj map[string]interface{}, key string) (string, error) {
+	v, exists := obj[key]
+	if !exists {
+		return "", fmt.Errorf("key '%v' was not found within secret", key)
+	}
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
@@ -0,0 +1,220 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestRegistryLookup(t *testing.T) {
+	reg := NewRegistry(0)
+
+	var calls int
+	reg.Register("foo", ProviderFunc(func(ctx context.Context, ref string) (string, error) {
+		if ref == "nope" {
+			return "", errors.New("nope")
+		}
+		calls++
+		return ref + "-resolved", nil
+	}))
+
+	for i := 0; i < 3; i++ {
+		v, err := reg.Lookup(context.Background(), "foo", "bar")
+		require.NoError(t, err)
+		assert.Equal(t, "bar-resolved", v)
+	}
+	assert.Equal(t, 3, calls)
+
+	_, err := reg.Lookup(context.Background(), "foo", "nope")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "nope")
+
+	_, err = reg.Lookup(context.Background(), "nope", "baz")
+	require.True(t, errors.Is(err, ErrUnknownScheme))
+}
+
+func TestRegistryLookupCached(t *testing.T) {
+	reg := NewRegistry(time.Minute)
+
+	var nowMut sync.Mutex
+	now := time.Now()
+	reg.now = func() time.Time {
+		nowMut.Lock()
+		defer nowMut.Unlock()
+		return now
+	}
+	setNow := func(t time.Time) {
+		nowMut.Lock()
+		now = t
+		nowMut.Unlock()
+	}
+
+	var mut sync.Mutex
+	var calls int
+	var value string
+	var lookupErr error
+	setValue := func(v string, err error) {
+		mut.Lock()
+		value, lookupErr = v, err
+		mut.Unlock()
+	}
+	setValue("first", nil)
+
+	reg.Register("foo", ProviderFunc(func(ctx context.Context, ref string) (string, error) {
+		mut.Lock()
+		defer mut.Unlock()
+		calls++
+		return value, lookupErr
+	}))
+
+	lookup := func() string {
+		v, err := reg.Lookup(context.Background(), "foo", "bar")
+		require.NoError(t, err)
+		return v
+	}
+	getCalls := func() int {
+		mut.Lock()
+		defer mut.Unlock()
+		return calls
+	}
+
+	assert.Equal(t, "first", lookup())
+	setValue("second", nil)
+	assert.Equal(t, "first", lookup())
+	assert.Equal(t, 1, getCalls())
+
+	// Expired secrets are returned whilst being refreshed in the background.
+	start := reg.now()
+	setNow(start.Add(time.Minute))
+	assert.Equal(t, "first", lookup())
+	assert.Eventually(t, func() bool {
+		return lookup() == "second"
+	}, time.Second*5, time.Millisecond*10)
+	assert.Equal(t, 2, getCalls())
+
+	// Failed refreshes keep the last resolved value.
+	setValue("", errors.New("nope"))
+	setNow(start.Add(time.Minute * 2))
+	assert.Equal(t, "second", lookup())
+	assert.Eventually(t, func() bool {
+		return getCalls() == 3
+	}, time.Second*5, time.Millisecond*10)
+	assert.Equal(t, "second", lookup())
+}
+
+func TestRegistryTrackFieldsBySource(t *testing.T) {
+	reg := NewRegistry(0)
+
+	isSecret := func(v string) bool {
+		return v == "SECRET"
+	}
+	track := func(source, resolvedStr string) {
+		var raw, resolved yaml.Node
+		require.NoError(t, yaml.Unmarshal([]byte(`foo: SECRET`), &raw))
+		require.NoError(t, yaml.Unmarshal([]byte(resolvedStr), &resolved))
+		reg.TrackFields(source, &raw, &resolved, isSecret)
+	}
+	redact := func(confStr string) string {
+		var node yaml.Node
+		require.NoError(t, yaml.Unmarshal([]byte(confStr), &node))
+		out, err := yaml.Marshal(reg.RedactNode(&node))
+		require.NoError(t, err)
+		return string(out)
+	}
+
+	track("a", `foo: first`)
+	track("b", `foo: second`)
+	assert.Equal(t, "foo: '!!!SECRET_SCRUBBED!!!'\n", redact(`foo: first`))
+	assert.Equal(t, "foo: '!!!SECRET_SCRUBBED!!!'\n", redact(`foo: second`))
+
+	// Fields tracked for a source replace those previously tracked for it.
+	track("a", `foo: third`)
+	assert.Equal(t, "foo: first\n", redact(`foo: first`))
+	assert.Equal(t, "foo: '!!!SECRET_SCRUBBED!!!'\n", redact(`foo: third`))
+
+	reg.ClearFields("b")
+	assert.Equal(t, "foo: second\n", redact(`foo: second`))
+
+	// Fields tracked without a source are accumulated.
+	track("", `foo: fourth`)
+	track("", `foo: fifth`)
+	assert.Equal(t, "foo: '!!!SECRET_SCRUBBED!!!'\n", redact(`foo: fourth`))
+	assert.Equal(t, "foo: '!!!SECRET_SCRUBBED!!!'\n", redact(`foo: fifth`))
+}
+
+func TestRegistryRedactNode(t *testing.T) {
+	reg := NewRegistry(0)
+
+	isSecret := func(v string) bool {
+		return strings.Contains(v, "SECRET")
+	}
+
+	var raw, resolved yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(`
+foo: SECRET
+bar: [ "not sensitive", "SECRET" ]
+baz: not sensitive
+`), &raw))
+	require.NoError(t, yaml.Unmarshal([]byte(`
+foo: hunter2
+bar: [ "not sensitive", "hunter2" ]
+baz: not sensitive
+`), &resolved))
+	reg.TrackFields("", &raw, &resolved, isSecret)
+
+	// Values that match a secret in fields that aren't tracked are left alone,
+	// as are tracked fields with values that differ.
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(`
+foo: hunter2
+bar: [ "hunter2", "hunter2" ]
+baz: hunter2
+buz: hunter2
+`), &node))
+
+	out, err := yaml.Marshal(reg.RedactNode(&node))
+	require.NoError(t, err)
+	assert.Equal(t, `foo: '!!!SECRET_SCRUBBED!!!'
+bar: ['!!!SECRET_SCRUBBED!!!', '!!!SECRET_SCRUBBED!!!']
+baz: hunter2
+buz: hunter2
+`, string(out))
+
+	var changed yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(`foo: not sensitive`), &changed))
+
+	out, err = yaml.Marshal(reg.RedactNode(&changed))
+	require.NoError(t, err)
+	assert.Equal(t, "foo: not sensitive\n", string(out))
+
+	// The original node is not modified.
+	out, err = yaml.Marshal(&node)
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "foo: hunter2")
+}
+
+func TestFileProvider(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a"), []byte("foo\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b"), []byte("foo\nbar\r\n"), 0o600))
+
+	v, err := fileLookup(context.Background(), filepath.Join(dir, "a"))
+	require.NoError(t, err)
+	assert.Equal(t, "foo", v)
+
+	v, err = fileLookup(context.Background(), filepath.Join(dir, "b"))
+	require.NoError(t, err)
+	assert.Equal(t, "foo\nbar", v)
+
+	_, err = fileLookup(context.Background(), filepath.Join(dir, "c"))
+	require.Error(t, err)
+}
@@ -0,0 +1,98 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+type vaultProvider struct {
+	address string
+	token   string
+	fromEnv bool
+	client  *http.Client
+}
+
+// NewVaultProvider creates a provider that resolves secrets from a HashiCorp
+// Vault KV secrets engine, with references of the form <path>#<key>. Both
+// versions 1 and 2 of the engine are supported, where the path of a version 2
+// secret must include the data segment, e.g. secret/data/db#password.
+func NewVaultProvider(address, token string) Provider {
+	return &vaultProvider{
+		address: address,
+		token:   token,
+		client:  http.DefaultClient,
+	}
+}
+
+// NewVaultProviderFromEnv creates a Vault provider where the address and token
+// are read from the environment variables VAULT_ADDR and VAULT_TOKEN at the
+// time of each lookup.
+func NewVaultProviderFromEnv() Provider {
+	return &vaultProvider{
+		fromEnv: true,
+		client:  http.DefaultClient,
+	}
+}
+
+func (v *vaultProvider) Lookup(ctx context.Context, ref string) (string, error) {
+	address, token := v.address, v.token
+	if v.fromEnv {
+		address, token = os.Getenv("VAULT_ADDR"), os.Getenv("VAULT_TOKEN")
+	}
+	if address == "" {
+		return "", fmt.Errorf("a vault address must be specified with VAULT_ADDR")
+	}
+
+	path, key := splitKey(ref)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", strings.TrimSuffix(address, "/")+"/v1/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+
+	res, err := v.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault responded with status %v: %s", res.StatusCode, body)
+	}
+
+	var secret struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &secret); err != nil {
+		return "", fmt.Errorf("failed to parse vault response: %w", err)
+	}
+
+	fields := secret.Data
+	if nested, ok := fields["data"].(map[string]interface{}); ok {
+		if _, hasMeta := fields["metadata"]; hasMeta {
+			fields = nested
+		}
+	}
+
+	if key == "" {
+		if len(fields) != 1 {
+			return "", fmt.Errorf("secret contains %v keys and so a key must be specified with <path>#<key>", len(fields))
+		}
+		for k := range fields {
+			key = k
+		}
+	}
+	return fieldFromObject(fields, key)
+}
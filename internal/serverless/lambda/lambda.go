@@ -48,7 +48,11 @@ func Run() {
 	conf.Output.Switch.Cases = append(conf.Output.Switch.Cases, errorCase, responseCase)
 
 	if confStr := os.Getenv("BENTHOS_CONFIG"); len(confStr) > 0 {
-		confBytes := config.ReplaceEnvVariables([]byte(confStr))
+		confBytes, err := config.ReplaceEnvVariables([]byte(confStr), config.SecretsResolve)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Configuration file read error: %v\n", err)
+			os.Exit(1)
+		}
 		if err := yaml.Unmarshal(confBytes, &conf); err != nil {
 			fmt.Fprintf(os.Stderr, "Configuration file read error: %v\n", err)
 			os.Exit(1)
@@ -57,7 +61,7 @@ func Run() {
 		// Iterate default config paths
 		for _, path := range defaultPaths {
 			if _, err := os.Stat(path); err == nil {
-				if _, err = config.ReadFileLinted(path, false, &conf); err != nil {
+				if _, err = config.ReadFileLinted(path, false, config.SecretsResolve, &conf); err != nil {
 					fmt.Fprintf(os.Stderr, "Configuration file read error: %v\n", err)
 					os.Exit(1)
 				}
@@ -8,6 +8,7 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/old/input"
 	"github.com/benthosdev/benthos/v4/internal/old/output"
 	"github.com/benthosdev/benthos/v4/internal/pipeline"
+	"github.com/benthosdev/benthos/v4/internal/secrets"
 )
 
 //------------------------------------------------------------------------------
@@ -33,14 +34,15 @@ func NewConfig() Config {
 
 // Sanitised returns a sanitised copy of the Benthos configuration, meaning
 // fields of no consequence (unused inputs, outputs, processors etc) are
-// excluded.
+// excluded, and the values of resolved secrets are redacted.
 func (c Config) Sanitised() (interface{}, error) {
-	var node yaml.Node
-	if err := node.Encode(c); err != nil {
+	var rawNode yaml.Node
+	if err := rawNode.Encode(c); err != nil {
 		return nil, err
 	}
+	node := secrets.DefaultRegistry.RedactNode(&rawNode)
 
-	if err := Spec().SanitiseYAML(&node, docs.SanitiseConfig{
+	if err := Spec().SanitiseYAML(node, docs.SanitiseConfig{
 		RemoveTypeField: true,
 	}); err != nil {
 		return nil, err
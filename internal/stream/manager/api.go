@@ -288,7 +288,9 @@ func (m *Type) HandleStreamCRUD(w http.ResponseWriter, r *http.Request) {
 		if confBytes, err = io.ReadAll(r.Body); err != nil {
 			return
 		}
-		confBytes = config.ReplaceEnvVariables(confBytes)
+		if confBytes, err = config.ReplaceEnvVariables(confBytes, config.SecretsDisabled); err != nil {
+			return
+		}
 
 		if r.URL.Query().Get("chilled") != "true" {
 			var node yaml.Node
@@ -513,7 +515,9 @@ func (m *Type) HandleResourceCRUD(w http.ResponseWriter, r *http.Request) {
 		if confBytes, requestErr = io.ReadAll(r.Body); requestErr != nil {
 			return
 		}
-		confBytes = config.ReplaceEnvVariables(confBytes)
+		if confBytes, requestErr = config.ReplaceEnvVariables(confBytes, config.SecretsDisabled); requestErr != nil {
+			return
+		}
 
 		var node yaml.Node
 		if requestErr = yaml.Unmarshal(confBytes, &node); requestErr != nil {
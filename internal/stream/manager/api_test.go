@@ -21,6 +21,7 @@ import (
 
 	"github.com/benthosdev/benthos/v4/internal/bundle/mock"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/config"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	bmanager "github.com/benthosdev/benthos/v4/internal/manager"
 	"github.com/benthosdev/benthos/v4/internal/message"
@@ -886,3 +887,60 @@ file:
 	require.NoError(t, err)
 	assert.Equal(t, `{"id":"second","content":"hello world 2"}`, string(file2Bytes))
 }
+
+func TestTypeAPISecrets(t *testing.T) {
+	res, err := bmanager.NewV2(bmanager.NewResourceConfig(), mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	mgr := manager.New(res)
+	r := router(mgr)
+
+	tmpDir := t.TempDir()
+
+	secretPath := filepath.Join(tmpDir, "path")
+	require.NoError(t, os.WriteFile(secretPath, []byte("/hunter2"), 0o600))
+
+	// Secrets are not resolved within configs supplied by the API.
+	request := genYAMLRequest("POST", "/streams/foo?chilled=true", fmt.Sprintf(`
+input:
+  http_server:
+    path: ${secret:file:%v}
+output:
+  drop: {}
+`, secretPath))
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	assert.Contains(t, response.Body.String(), "not allowed")
+
+	request = genYAMLRequest("POST", "/resources/cache/foocache?chilled=true", fmt.Sprintf(`
+file:
+  directory: ${secret:file:%v}
+`, secretPath))
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	assert.Contains(t, response.Body.String(), "not allowed")
+
+	// Secrets resolved within stream config files are redacted.
+	streamPath := filepath.Join(tmpDir, "foo.yaml")
+	require.NoError(t, os.WriteFile(streamPath, []byte(fmt.Sprintf(`
+input:
+  http_server:
+    path: ${secret:file:%v}
+output:
+  drop: {}
+`, secretPath)), 0o600))
+
+	conf, _, err := config.ReadStreamFile(streamPath, config.SecretsResolve)
+	require.NoError(t, err)
+	require.Equal(t, "/hunter2", conf.Input.HTTPServer.Path)
+	require.NoError(t, mgr.Create("foo", conf))
+
+	request = genYAMLRequest("GET", "/streams/foo", nil)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	require.Equal(t, http.StatusOK, response.Code)
+	assert.NotContains(t, response.Body.String(), "hunter2")
+	assert.Contains(t, response.Body.String(), `"path":"!!!SECRET_SCRUBBED!!!"`)
+}
@@ -58,7 +58,7 @@ func LoadStreamConfigsFromDirectory(replaceEnvVars bool, dir string) (map[string
 		}
 
 		conf := config.New()
-		if _, readerr := config.ReadFileLinted(path, false, &conf); readerr != nil {
+		if _, readerr := config.ReadFileLinted(path, false, config.SecretsResolve, &conf); readerr != nil {
 			// TODO: Read and report linting errors.
 			return readerr
 		}
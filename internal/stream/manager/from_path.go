@@ -25,7 +25,7 @@ func loadFile(dir, path, testSuffix string, confs map[string]stream.Config) ([]s
 		return nil, fmt.Errorf("stream id (%v) collision from file: %v", id, path)
 	}
 
-	conf, lints, err := config.ReadStreamFile(path)
+	conf, lints, err := config.ReadStreamFile(path, config.SecretsResolve)
 	if err != nil {
 		return nil, err
 	}
@@ -128,22 +128,55 @@ func (c *compiled) ExpandToNode(node *yaml.Node) (*yaml.Node, error) {
 
 //------------------------------------------------------------------------------
 
+var (
+	registeredMut sync.RWMutex
+	registered    = map[docs.Type]map[string]*compiled{}
+)
+
 // RegisterTemplate attempts to add a template component to the global list of
 // component types.
 func registerTemplate(tmpl *compiled) error {
+	var err error
 	switch tmpl.spec.Type {
 	case docs.TypeCache:
-		return registerCacheTemplate(tmpl, bundle.AllCaches)
+		err = registerCacheTemplate(tmpl, bundle.AllCaches)
 	case docs.TypeInput:
-		return registerInputTemplate(tmpl, bundle.AllInputs)
+		err = registerInputTemplate(tmpl, bundle.AllInputs)
 	case docs.TypeOutput:
-		return registerOutputTemplate(tmpl, bundle.AllOutputs)
+		err = registerOutputTemplate(tmpl, bundle.AllOutputs)
 	case docs.TypeProcessor:
-		return registerProcessorTemplate(tmpl, bundle.AllProcessors)
+		err = registerProcessorTemplate(tmpl, bundle.AllProcessors)
 	case docs.TypeRateLimit:
-		return registerRateLimitTemplate(tmpl, bundle.AllRateLimits)
+		err = registerRateLimitTemplate(tmpl, bundle.AllRateLimits)
+	default:
+		return fmt.Errorf("unable to register template for component type %v", tmpl.spec.Type)
+	}
+	if err != nil {
+		return err
+	}
+
+	registeredMut.Lock()
+	if registered[tmpl.spec.Type] == nil {
+		registered[tmpl.spec.Type] = map[string]*compiled{}
+	}
+	registered[tmpl.spec.Type][tmpl.spec.Name] = tmpl
+	registeredMut.Unlock()
+	return nil
+}
+
+// ExpandNode attempts to apply a registered template of a given component type
+// and name to the config of a component, and returns the expanded config of
+// the component that the template resolves to. If a template of the type and
+// name has not been registered then false is returned.
+func ExpandNode(cType docs.Type, name string, node *yaml.Node) (*yaml.Node, bool, error) {
+	registeredMut.RLock()
+	tmpl, exists := registered[cType][name]
+	registeredMut.RUnlock()
+	if !exists {
+		return nil, false, nil
 	}
-	return fmt.Errorf("unable to register template for component type %v", tmpl.spec.Type)
+	newNode, err := tmpl.ExpandToNode(node)
+	return newNode, true, err
 }
 
 // WithMetricsMapping attempts to wrap the metrics of a manager with a metrics
@@ -7,7 +7,9 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 
+	"github.com/benthosdev/benthos/v4/internal/docs"
 	"github.com/benthosdev/benthos/v4/internal/template"
 	_ "github.com/benthosdev/benthos/v4/public/components/all"
 )
@@ -29,3 +31,41 @@ func TestTemplateTesting(t *testing.T) {
 		})
 	}
 }
+
+func TestTemplateExpandNode(t *testing.T) {
+	tmpDir := t.TempDir()
+	tmplPath := filepath.Join(tmpDir, "foo.yaml")
+	require.NoError(t, os.WriteFile(tmplPath, []byte(`
+name: expand_node_test
+type: input
+fields:
+  - name: count
+    type: int
+mapping: |
+  root.generate.mapping = "root = \"hello\""
+  root.generate.count = this.count
+`), 0o644))
+
+	_, err := template.InitTemplates(tmplPath)
+	require.NoError(t, err)
+
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(`count: 5`), &node))
+
+	expanded, exists, err := template.ExpandNode(docs.TypeInput, "expand_node_test", node.Content[0])
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	var v interface{}
+	require.NoError(t, expanded.Decode(&v))
+	assert.Equal(t, map[string]interface{}{
+		"generate": map[string]interface{}{
+			"mapping": `root = "hello"`,
+			"count":   5,
+		},
+	}, v)
+
+	_, exists, err = template.ExpandNode(docs.TypeOutput, "expand_node_test", node.Content[0])
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
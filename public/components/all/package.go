@@ -13,8 +13,10 @@ import (
 	_ "github.com/benthosdev/benthos/v4/internal/impl/aws"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/confluent"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/dgraph"
+	_ "github.com/benthosdev/benthos/v4/internal/impl/elasticsearch"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/gcp"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/generic"
+	_ "github.com/benthosdev/benthos/v4/internal/impl/grpc"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/influxdb"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/jaeger"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/kafka"
@@ -23,11 +25,14 @@ import (
 	_ "github.com/benthosdev/benthos/v4/internal/impl/mongodb"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/msgpack"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/nats"
+	_ "github.com/benthosdev/benthos/v4/internal/impl/otlp"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/parquet"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/prometheus"
+	_ "github.com/benthosdev/benthos/v4/internal/impl/protobuf"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/redis"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/sql"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/statsd"
+	_ "github.com/benthosdev/benthos/v4/internal/impl/syslog"
 	"github.com/benthosdev/benthos/v4/internal/template"
 
 	// Import all (supported) sql drivers
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"net/http"
 
 	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/bundle/mock"
@@ -47,6 +48,13 @@ func (r *Resources) Metrics() *Metrics {
 	return newReverseAirGapMetrics(r.mgr.Metrics())
 }
 
+// RegisterEndpoint registers a server wide HTTP endpoint at the provided path
+// with a description. Registering an endpoint at a path that already exists
+// replaces the prior handler.
+func (r *Resources) RegisterEndpoint(path, desc string, fn http.HandlerFunc) {
+	r.mgr.RegisterEndpoint(path, desc, fn)
+}
+
 // AccessCache attempts to access a cache resource by name. This action can
 // block if CRUD operations are being actively performed on the resource.
 func (r *Resources) AccessCache(ctx context.Context, name string, fn func(c Cache)) error {
@@ -790,7 +790,10 @@ func (s *StreamBuilder) buildConfig() builderConfig {
 //------------------------------------------------------------------------------
 
 func getYAMLNode(b []byte) (*yaml.Node, error) {
-	b = config.ReplaceEnvVariables(b)
+	b, err := config.ReplaceEnvVariables(b, config.SecretsResolve)
+	if err != nil {
+		return nil, err
+	}
 	var nconf yaml.Node
 	if err := yaml.Unmarshal(b, &nconf); err != nil {
 		return nil, err